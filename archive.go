@@ -0,0 +1,307 @@
+package sbomvalidator
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Archive formats recognized by DetectArchive.
+const (
+	ArchiveZip   = "zip"
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tar+gzip"
+)
+
+// sbomNamePattern matches file names that follow common SBOM naming
+// conventions, e.g., "bom.json", "app.cdx.xml" or "sbom-app.spdx.json".
+var sbomNamePattern = regexp.MustCompile(`(?i)(^|[._-])(s?bom|cdx|cyclonedx|spdx)([._-]|$)`)
+
+// tarMagic is the format identifier of POSIX and GNU tar headers, at
+// tarMagicOffset.
+var tarMagic = []byte("ustar")
+
+const tarMagicOffset = 257
+
+// archiveEntry is a regular file read from an archive.
+type archiveEntry struct {
+	name    string
+	content []byte
+}
+
+// DetectArchive returns the format of an archive (ArchiveZip, ArchiveTar or
+// ArchiveTarGz) from its leading bytes, or "" if content is not an archive. A
+// gzip-compressed SBOM is not an archive.
+func DetectArchive(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte("PK\x03\x04")), bytes.HasPrefix(content, []byte("PK\x05\x06")):
+		return ArchiveZip
+	case isTar(content):
+		return ArchiveTar
+	case bytes.HasPrefix(content, []byte{0x1f, 0x8b}):
+		r, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return ""
+		}
+		header := make([]byte, tarMagicOffset+len(tarMagic))
+		if _, err := io.ReadFull(r, header); err == nil && isTar(header) {
+			return ArchiveTarGz
+		}
+	}
+	return ""
+}
+
+func isTar(content []byte) bool {
+	return len(content) >= tarMagicOffset+len(tarMagic) &&
+		bytes.Equal(content[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic)
+}
+
+// ValidateSBOMArchive validates every SBOM in a .zip, .tar or .tar.gz archive
+// concurrently, like ValidateSBOMBatch, and returns a result per SBOM.
+//
+// Archives often hold other files besides SBOMs, so an entry is only
+// validated if its name follows a common SBOM naming convention (e.g.,
+// "bom.json" or "app.cdx.json", optionally compressed) or its content is
+// recognizably an SBOM from its first bytes. The latter finds the SBOMs in an
+// OCI image layout, whose blobs are named by digest. Archives nested in the
+// archive are not opened. The entries validated are held in memory, so an
+// archive with more than 100,000 files, or whose SBOMs add up to more than
+// 1 GiB once decompressed, is rejected. Names in the results are paths within
+// the archive. Unless `WithChecksums` is given, a SHA256SUMS manifest at the
+// root of the archive is verified against, as for ValidateSBOMDir.
+//
+// Parameters:
+//   - archive: The content of the archive.
+//   - opts: Optional settings, applied to every SBOM as for ValidateSBOMData.
+//
+// Returns:
+//   - A BatchResult with a result per SBOM found and the aggregate summary.
+//   - An error if the archive cannot be read, exceeds the limits above, or
+//     its SHA256SUMS cannot be parsed.
+//
+// Example:
+//
+//	data, _ := os.ReadFile("release-sboms.tar.gz")
+//	batch, err := ValidateSBOMArchive(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, r := range batch.Results {
+//	    fmt.Println(r.Name, r.Result != nil && r.Result.IsValid)
+//	}
+func ValidateSBOMArchive(archive []byte, opts ...Option) (*BatchResult, error) {
+	return ValidateSBOMArchiveContext(context.Background(), archive, opts...)
+}
+
+// ValidateSBOMArchiveContext validates every SBOM in an archive like
+// ValidateSBOMArchive, but stops when ctx is done, as described for
+// ValidateSBOMBatchContext. The BatchResult is nil only if the archive cannot
+// be read or its SHA256SUMS cannot be parsed.
+//
+// Example:
+//
+//	batch, err := ValidateSBOMArchiveContext(ctx, data)
+func ValidateSBOMArchiveContext(ctx context.Context, archive []byte, opts ...Option) (*BatchResult, error) {
+	options := newValidationOptions(opts)
+	entries, err := readArchive(archive, func(name string, head []byte) bool {
+		return name == ChecksumsFileName || isArchivedSBOM(name, head, options)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []BatchInput
+	var manifest []byte
+	for _, entry := range entries {
+		if entry.name == ChecksumsFileName {
+			manifest = entry.content
+			continue
+		}
+		content := entry.content
+		inputs = append(inputs, BatchInput{Name: entry.name, Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		}})
+	}
+
+	if options.checksums == nil && manifest != nil {
+		sums, err := ParseChecksums(bytes.NewReader(manifest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", ChecksumsFileName, err)
+		}
+		opts = append(opts, WithChecksums(sums))
+	}
+
+	return ValidateSBOMBatchContext(ctx, inputs, opts...)
+}
+
+// Limits on reading an archive, so that a small archive cannot exhaust
+// memory: the number of files it may hold and the total size of the files
+// kept, once decompressed. Each file is also capped like a decompressed SBOM.
+// They are variables for tests.
+var (
+	maxArchiveEntries       = 100_000
+	maxArchiveSize    int64 = maxDecompressedSize
+)
+
+// archiveSniffSize is how much of a file, decompressed, is read to tell
+// whether it is an SBOM.
+const archiveSniffSize = 64 << 10
+
+// archiveReader reads the files of an archive within the archive limits.
+type archiveReader struct {
+	// keep selects the files to read in full, from their name and first
+	// archiveSniffSize bytes; the others are skipped.
+	keep    func(name string, head []byte) bool
+	entries []archiveEntry
+	files   int
+	size    int64
+}
+
+// readArchive returns the regular files of an archive that keep selects, in
+// archive order. It fails if the archive holds more than maxArchiveEntries
+// files, or the files kept add up to more than maxArchiveSize bytes.
+func readArchive(archive []byte, keep func(name string, head []byte) bool) ([]archiveEntry, error) {
+	ar := &archiveReader{keep: keep}
+	var err error
+	switch DetectArchive(archive) {
+	case ArchiveZip:
+		err = ar.readZip(archive)
+	case ArchiveTar:
+		err = ar.readTar(bytes.NewReader(archive))
+	case ArchiveTarGz:
+		r, gzErr := gzip.NewReader(bytes.NewReader(archive))
+		if gzErr != nil {
+			return nil, fmt.Errorf("invalid gzip stream: %v", gzErr)
+		}
+		defer r.Close()
+		err = ar.readTar(r)
+	default:
+		return nil, fmt.Errorf("unsupported archive format; expected zip, tar or tar.gz")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ar.entries, nil
+}
+
+func (ar *archiveReader) readZip(archive []byte) error {
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %v", err)
+	}
+
+	for _, file := range r.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		f, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", file.Name, err)
+		}
+		err = ar.read(path.Clean(file.Name), f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ar *archiveReader) readTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := ar.read(path.Clean(strings.TrimPrefix(header.Name, "./")), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// read reads a file of the archive, in full if it is kept, and accounts for
+// it in the archive limits.
+func (ar *archiveReader) read(name string, r io.Reader) error {
+	ar.files++
+	if ar.files > maxArchiveEntries {
+		return fmt.Errorf("archive holds more than %d files", maxArchiveEntries)
+	}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(io.LimitReader(r, archiveSniffSize)); err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if !ar.keep(name, buf.Bytes()) {
+		return nil
+	}
+
+	limit := min(maxDecompressedSize, maxArchiveSize-ar.size)
+	if _, err := buf.ReadFrom(io.LimitReader(r, limit-int64(buf.Len())+1)); err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	n := int64(buf.Len())
+	switch {
+	case n > maxDecompressedSize:
+		return fmt.Errorf("%s exceeds %d bytes", name, maxDecompressedSize)
+	case ar.size+n > maxArchiveSize:
+		return fmt.Errorf("archive files exceed %d bytes in total", maxArchiveSize)
+	}
+	ar.size += n
+	ar.entries = append(ar.entries, archiveEntry{name: name, content: buf.Bytes()})
+	return nil
+}
+
+// isArchivedSBOM reports whether an archive entry should be validated: its
+// name follows an SBOM naming convention, or its first bytes, once
+// decompressed, are recognizably a CycloneDX or SPDX document.
+func isArchivedSBOM(name string, head []byte, options *validationOptions) bool {
+	if isBatchName(name) && sbomNamePattern.MatchString(path.Base(trimCompressionExtension(name))) {
+		return true
+	}
+
+	head = decompressHead(head, detectEncoding(head, EncodingForName(name)), options)
+	switch {
+	case isJSON(head):
+		_, _, err := scanSBOMType(bytes.NewReader(head), false)
+		return err == nil
+	case isXML(head):
+		return bytes.Contains(head[:min(len(head), 4096)], []byte("cyclonedx.org/schema/bom"))
+	default:
+		return isCycloneDXProtobuf(head) || isSPDXTagValue(head)
+	}
+}
+
+// decompressHead returns up to archiveSniffSize decompressed bytes of the
+// start of compressed content, or head itself if it is not compressed or
+// cannot be decompressed.
+func decompressHead(head []byte, encoding string, options *validationOptions) []byte {
+	decompressor, ok := decompressorFor(encoding, options)
+	if !ok {
+		return head
+	}
+	r, err := decompressor(bytes.NewReader(head))
+	if err != nil {
+		return head
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+	// head is usually a truncated stream, so reading ends with an error
+	decompressed, _ := io.ReadAll(io.LimitReader(r, archiveSniffSize))
+	return decompressed
+}
@@ -0,0 +1,161 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testArchiveFile struct {
+	name    string
+	content []byte
+}
+
+func zipArchive(t *testing.T, files []testArchiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		f.Write(file.content)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func tarArchive(t *testing.T, files []testArchiveFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, file := range files {
+		if err := w.WriteHeader(&tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.content))}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		w.Write(file.content)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestValidateSBOMArchive(t *testing.T) {
+	files := []testArchiveFile{
+		{"sboms/app.cdx.json", []byte(`{"bomFormat": "CycloneDX"`)},
+		{"sboms/lib.spdx.json.gz", gzipped(t, spdxDocument(spdxPackage("a", "a", "MIT")))},
+		{"package.json", []byte(`{"name": "app", "version": "1.0.0"}`)},
+		{"index.json", []byte(`{"schemaVersion": 2, "manifests": []}`)},
+		// an SBOM attached to an OCI image layout, named by its digest
+		{"blobs/sha256/4f1c0d", spdxDocument(spdxPackage("b", "b", "MIT"))},
+		{"README.md", []byte("ignored")},
+	}
+	wantNames := []string{"sboms/app.cdx.json", "sboms/lib.spdx.json.gz", "blobs/sha256/4f1c0d"}
+
+	tests := []struct {
+		name    string
+		archive []byte
+		format  string
+	}{
+		{name: "Zip", archive: zipArchive(t, files), format: ArchiveZip},
+		{name: "Tar", archive: tarArchive(t, files), format: ArchiveTar},
+		{name: "Tar.gz", archive: gzipped(t, tarArchive(t, files)), format: ArchiveTarGz},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if format := DetectArchive(tt.archive); format != tt.format {
+				t.Errorf("DetectArchive() = %q, want %q", format, tt.format)
+			}
+
+			batch, err := ValidateSBOMArchive(tt.archive)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var names []string
+			for _, r := range batch.Results {
+				names = append(names, r.Name)
+			}
+			if !reflect.DeepEqual(names, wantNames) {
+				t.Errorf("names = %q, want %q", names, wantNames)
+			}
+			if batch.Summary.Valid != 2 || batch.Summary.Failed != 1 {
+				t.Errorf("unexpected summary: %+v", batch.Summary)
+			}
+		})
+	}
+}
+
+func TestValidateSBOMArchiveChecksums(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+	archive := tarArchive(t, []testArchiveFile{
+		{"SHA256SUMS", []byte(SBOMDigest(sbom)[len("sha256:"):] + "  app.spdx.json\n")},
+		{"app.spdx.json", sbom},
+		{"extra.spdx.json", sbom},
+	})
+
+	batch, err := ValidateSBOMArchive(archive)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch.Summary.Valid != 1 || batch.Summary.Unlisted != 1 {
+		t.Errorf("unexpected summary: %+v", batch.Summary)
+	}
+}
+
+func TestValidateSBOMArchiveUnsupported(t *testing.T) {
+	for _, content := range [][]byte{gzipped(t, spdxDocument()), []byte("not an archive")} {
+		if format := DetectArchive(content); format != "" {
+			t.Errorf("DetectArchive() = %q, want none", format)
+		}
+		if _, err := ValidateSBOMArchive(content); err == nil {
+			t.Error("expected an error")
+		}
+	}
+}
+
+func TestValidateSBOMArchiveLimits(t *testing.T) {
+	defer func(entries int, size int64) { maxArchiveEntries, maxArchiveSize = entries, size }(maxArchiveEntries, maxArchiveSize)
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+	maxArchiveEntries, maxArchiveSize = 3, int64(2*len(sbom))
+
+	tests := []struct {
+		name    string
+		files   []testArchiveFile
+		wantErr string
+	}{
+		// files that are not SBOMs are only sniffed, so they do not count
+		// towards the size limit
+		{name: "Within limits", files: []testArchiveFile{
+			{"a.spdx.json", sbom}, {"b.spdx.json", sbom}, {"image.bin", bytes.Repeat([]byte{0}, 4*len(sbom))},
+		}},
+		{name: "Too many files", files: []testArchiveFile{
+			{"a.spdx.json", sbom}, {"README.md", nil}, {"LICENSE", nil}, {"NOTICE", nil},
+		}, wantErr: "archive holds more than 3 files"},
+		{name: "Too large", files: []testArchiveFile{
+			{"a.spdx.json", sbom}, {"b.spdx.json", sbom}, {"c.spdx.json", sbom},
+		}, wantErr: fmt.Sprintf("archive files exceed %d bytes in total", 2*len(sbom))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, archive := range [][]byte{zipArchive(t, tt.files), tarArchive(t, tt.files)} {
+				batch, err := ValidateSBOMArchive(archive)
+				if tt.wantErr != "" {
+					if err == nil || err.Error() != tt.wantErr {
+						t.Errorf("ValidateSBOMArchive() error = %v, want %q", err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil || batch.Summary.Valid != 2 {
+					t.Errorf("ValidateSBOMArchive() = %+v, %v", batch, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// batchExtensions are the file extensions ValidateSBOMDir picks up, with or
+// without a compression extension such as .zst.
+var batchExtensions = map[string]bool{
+	".json": true,
+	".xml":  true,
+	".spdx": true,
+	// CycloneDX protobuf, e.g. bom.cdx.bin
+	".bin": true,
+}
+
+// BatchInput is one SBOM to validate in a batch. Name identifies it in the
+// results, and Open is called once, by the worker that validates it, so
+// content is only held in memory while it is being validated. A compression
+// extension in Name (e.g., ".br") declares the content encoding. Remove, if
+// set, deletes the SBOM from its source, for a quarantine that moves SBOMs
+// (see `QuarantinePolicy`).
+type BatchInput struct {
+	Name   string
+	Open   func() (io.ReadCloser, error)
+	Remove func() error
+}
+
+// BatchFiles returns a batch input for each of the given file paths.
+func BatchFiles(paths ...string) []BatchInput {
+	inputs := make([]BatchInput, 0, len(paths))
+	for _, p := range paths {
+		p := p
+		inputs = append(inputs, BatchInput{
+			Name:   p,
+			Open:   func() (io.ReadCloser, error) { return os.Open(p) },
+			Remove: func() error { return os.Remove(p) },
+		})
+	}
+	return inputs
+}
+
+// BatchFS returns a batch input for each file of fsys matching one of the
+// patterns, such as an embedded directory, a zip file system or a
+// fstest.MapFS, so SBOMs can be validated without touching the OS file
+// system. Patterns are matched against slash-separated paths within fsys as
+// by path.Match, with "**" matching any number of directories; without
+// patterns, the files ValidateSBOMDir picks up are returned. When fsys is a
+// RemoveFS, a quarantine that moves SBOMs removes them from it.
+//
+// Returns the inputs in lexical order, or an error if a pattern is malformed
+// or fsys cannot be walked.
+//
+// Example:
+//
+//	//go:embed testdata
+//	var fixtures embed.FS
+//
+//	inputs, err := BatchFS(fixtures, "testdata/**/*.cdx.json")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	batch := ValidateSBOMBatch(inputs)
+func BatchFS(fsys fs.FS, patterns ...string) ([]BatchInput, error) {
+	for _, pattern := range patterns {
+		for _, elem := range strings.Split(pattern, "/") {
+			if _, err := path.Match(elem, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+
+	var inputs []BatchInput
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !matchesBatchPatterns(name, patterns) {
+			return err
+		}
+		input := BatchInput{Name: name, Open: func() (io.ReadCloser, error) { return fsys.Open(name) }}
+		if removable, ok := fsys.(RemoveFS); ok {
+			input.Remove = func() error { return removable.Remove(name) }
+		}
+		inputs = append(inputs, input)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
+
+// matchesBatchPatterns reports whether a file matches one of the patterns of
+// BatchFS, or has the extension of an SBOM if there are none.
+func matchesBatchPatterns(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return isBatchName(name)
+	}
+	for _, pattern := range patterns {
+		if matchPathElements(strings.Split(pattern, "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathElements matches the elements of a path against those of a
+// pattern, in which "**" matches any number of elements.
+func matchPathElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchPathElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// BatchReader returns a batch input reading from r.
+func BatchReader(name string, r io.Reader) BatchInput {
+	return BatchInput{Name: name, Open: func() (io.ReadCloser, error) { return io.NopCloser(r), nil }}
+}
+
+// BatchFileResult is the outcome of validating one SBOM of a batch. Exactly
+// one of Result and Error is set. With `WithChecksums`, Checksum records the
+// outcome of verifying the file's digest, and only verified files are
+// validated. With `WithQuarantine`, Quarantined reports whether the SBOM was
+// quarantined and QuarantineError why it could not be.
+//
+// To keep the memory of large batches bounded, results do not retain the
+// source document, so `Locate` returns (0, 0) on them.
+type BatchFileResult struct {
+	Name     string            `json:"name"`
+	Checksum string            `json:"checksum,omitempty"`
+	Result   *StructuredResult `json:"result,omitempty"`
+	Error    string            `json:"error,omitempty"`
+
+	Quarantined     bool   `json:"quarantined,omitempty"`
+	QuarantineError string `json:"quarantineError,omitempty"`
+}
+
+// BatchSummary aggregates the results of a batch. ByFormat counts SBOMs by
+// format (e.g., "CycloneDX") and ByVersion by format and spec version (e.g.,
+// "CycloneDX 1.6"); SBOMs that could not be validated are only counted in
+// Failed. Files failing checksum verification are counted in Tampered,
+// Unlisted or Missing instead. Quarantined counts the SBOMs quarantined with
+// `WithQuarantine`, which are also counted by outcome.
+type BatchSummary struct {
+	Total       int            `json:"total"`
+	Valid       int            `json:"valid"`
+	Invalid     int            `json:"invalid"`
+	Failed      int            `json:"failed"`
+	Tampered    int            `json:"tampered,omitempty"`
+	Unlisted    int            `json:"unlisted,omitempty"`
+	Missing     int            `json:"missing,omitempty"`
+	Quarantined int            `json:"quarantined,omitempty"`
+	ByFormat    map[string]int `json:"byFormat"`
+	ByVersion   map[string]int `json:"byVersion"`
+}
+
+// BatchResult represents the outcome of ValidateSBOMBatch or ValidateSBOMDir.
+// Results are in input order, followed by any SBOMs missing according to the
+// checksums.
+type BatchResult struct {
+	Results []BatchFileResult `json:"results"`
+	Summary BatchSummary      `json:"summary"`
+}
+
+// ValidateSBOMBatch validates many SBOMs concurrently, with a bounded pool of
+// workers (see `WithConcurrency`).
+//
+// Parameters:
+//   - inputs: The SBOMs to validate, e.g., from BatchFiles.
+//   - opts: Optional settings, applied to every SBOM as for ValidateSBOMData.
+//
+// Returns:
+//   - A BatchResult with a result per input and the aggregate summary. An SBOM
+//     that cannot be read or validated is reported in its result's Error and
+//     does not stop the batch.
+//
+// Example:
+//
+//	batch := ValidateSBOMBatch(BatchFiles("a.cdx.json", "b.spdx.json"))
+//	fmt.Printf("%d valid, %d invalid, %d failed\n",
+//	    batch.Summary.Valid, batch.Summary.Invalid, batch.Summary.Failed)
+func ValidateSBOMBatch(inputs []BatchInput, opts ...Option) *BatchResult {
+	batch, _ := ValidateSBOMBatchContext(context.Background(), inputs, opts...)
+	return batch
+}
+
+// ValidateSBOMBatchContext validates many SBOMs concurrently like
+// ValidateSBOMBatch, but stops when ctx is done: SBOMs being validated are
+// abandoned as for ValidateSBOMDataContext, and SBOMs not yet started are
+// skipped. Both are reported with the context's error in their result's
+// Error.
+//
+// Returns:
+//   - A BatchResult with a result per input, which is never nil.
+//   - ctx.Err() if ctx was done before every SBOM was validated.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+//	defer cancel()
+//	batch, err := ValidateSBOMBatchContext(ctx, BatchFiles(paths...))
+//	if err != nil {
+//	    log.Printf("batch stopped early: %v", err)
+//	}
+func ValidateSBOMBatchContext(ctx context.Context, inputs []BatchInput, opts ...Option) (*BatchResult, error) {
+	options := newValidationOptions(opts)
+	workers := options.concurrency
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	// progress events carry no file name, so they would be ambiguous here
+	opts = append(opts[:len(opts):len(opts)], WithProgress(nil))
+
+	results := make([]BatchFileResult, len(inputs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = validateBatchInput(ctx, inputs[i], options, opts)
+			}
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if options.checksums != nil {
+		results = append(results, missingBatchInputs(inputs, options.checksums)...)
+	}
+
+	return &BatchResult{Results: results, Summary: summarizeBatch(results)}, ctx.Err()
+}
+
+// ValidateSBOMDir validates every SBOM in a directory tree concurrently, like
+// ValidateSBOMBatch. Files with a .json, .xml or .spdx extension, optionally
+// followed by .gz, .zst or .br, are validated; other files are ignored. Names
+// in the results are paths within dir. Unless `WithChecksums` is given, a
+// SHA256SUMS manifest at the root of dir is verified against. When dir is a
+// RemoveFS, a quarantine that moves SBOMs removes them from it.
+//
+// Returns an error only if the directory cannot be walked or its SHA256SUMS
+// cannot be parsed.
+//
+// Example:
+//
+//	batch, err := ValidateSBOMDir(os.DirFS("/var/sboms"), WithConcurrency(8))
+func ValidateSBOMDir(dir fs.FS, opts ...Option) (*BatchResult, error) {
+	return ValidateSBOMDirContext(context.Background(), dir, opts...)
+}
+
+// ValidateSBOMDirContext validates every SBOM in a directory tree like
+// ValidateSBOMDir, but stops when ctx is done, as described for
+// ValidateSBOMBatchContext. The BatchResult is nil only if the directory
+// cannot be walked or its SHA256SUMS cannot be parsed.
+//
+// Example:
+//
+//	batch, err := ValidateSBOMDirContext(ctx, os.DirFS("/var/sboms"))
+func ValidateSBOMDirContext(ctx context.Context, dir fs.FS, opts ...Option) (*BatchResult, error) {
+	inputs, err := BatchFS(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %v", err)
+	}
+
+	if newValidationOptions(opts).checksums == nil {
+		manifest, err := fs.ReadFile(dir, ChecksumsFileName)
+		if err == nil {
+			sums, err := ParseChecksums(bytes.NewReader(manifest))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", ChecksumsFileName, err)
+			}
+			opts = append(opts, WithChecksums(sums))
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %v", ChecksumsFileName, err)
+		}
+	}
+
+	return ValidateSBOMBatchContext(ctx, inputs, opts...)
+}
+
+// isBatchName reports whether a file name has the extension of an SBOM.
+func isBatchName(name string) bool {
+	return batchExtensions[strings.ToLower(path.Ext(trimCompressionExtension(name)))]
+}
+
+// missingBatchInputs returns a result for each SBOM listed in the checksums
+// that is not among the inputs.
+func missingBatchInputs(inputs []BatchInput, sums Checksums) []BatchFileResult {
+	present := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		present[path.Clean(input.Name)] = true
+	}
+
+	var missing []BatchFileResult
+	for name := range sums {
+		if !present[name] && isBatchName(name) {
+			missing = append(missing, BatchFileResult{
+				Name:     name,
+				Checksum: ChecksumMissing,
+				Error:    "listed in the checksums but not found",
+			})
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Name < missing[j].Name })
+	return missing
+}
+
+func validateBatchInput(ctx context.Context, input BatchInput, options *validationOptions, opts []Option) BatchFileResult {
+	if err := ctx.Err(); err != nil {
+		return BatchFileResult{Name: input.Name, Error: err.Error()}
+	}
+
+	content, err := readBatchInput(input)
+	if err != nil {
+		return BatchFileResult{Name: input.Name, Error: err.Error()}
+	}
+
+	result := validateBatchContent(ctx, input.Name, content, options.checksums, opts)
+	if options.quarantine != nil {
+		quarantineBatchInput(ctx, *options.quarantine, input, content, &result)
+	}
+	return result
+}
+
+// validateBatchContent verifies and validates the content of a batch input.
+func validateBatchContent(ctx context.Context, name string, content []byte, sums Checksums, opts []Option) BatchFileResult {
+	result := BatchFileResult{Name: name}
+	if sums != nil {
+		result.Checksum = sums.verify(name, content)
+		switch result.Checksum {
+		case ChecksumMismatch:
+			result.Error = "SHA-256 digest does not match the checksums"
+			return result
+		case ChecksumUnlisted:
+			result.Error = "not listed in the checksums"
+			return result
+		}
+	}
+
+	if encoding := EncodingForName(name); encoding != "" {
+		opts = append([]Option{WithContentEncoding(encoding)}, opts...)
+	}
+	validation, err := ValidateSBOMDataStructuredContext(ctx, content, opts...)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	validation.locator = nil
+	result.Result = validation
+	return result
+}
+
+// quarantineBatchInput quarantines an input that did not pass validation,
+// unless it was abandoned because ctx is done, and records the outcome on
+// result.
+func quarantineBatchInput(ctx context.Context, policy QuarantinePolicy, input BatchInput, content []byte, result *BatchFileResult) {
+	if ctx.Err() != nil || (result.Result != nil && result.Result.IsValid) {
+		return
+	}
+
+	report, err := json.MarshalIndent(result, "", "  ")
+	if err == nil {
+		err = policy.Quarantine.Quarantine(ctx, QuarantinedSBOM{Name: input.Name, Content: content, Report: report})
+	}
+	if err != nil {
+		result.QuarantineError = err.Error()
+		return
+	}
+	result.Quarantined = true
+
+	if policy.Move && input.Remove != nil {
+		if err := input.Remove(); err != nil {
+			result.QuarantineError = fmt.Sprintf("quarantined, but not removed: %v", err)
+		}
+	}
+}
+
+func readBatchInput(input BatchInput) ([]byte, error) {
+	r, err := input.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func summarizeBatch(results []BatchFileResult) BatchSummary {
+	summary := BatchSummary{
+		Total:     len(results),
+		ByFormat:  map[string]int{},
+		ByVersion: map[string]int{},
+	}
+
+	for _, r := range results {
+		if r.Quarantined {
+			summary.Quarantined++
+		}
+		switch r.Checksum {
+		case ChecksumMismatch:
+			summary.Tampered++
+			continue
+		case ChecksumUnlisted:
+			summary.Unlisted++
+			continue
+		case ChecksumMissing:
+			summary.Missing++
+			continue
+		}
+
+		if r.Result == nil {
+			summary.Failed++
+			continue
+		}
+		if r.Result.IsValid {
+			summary.Valid++
+		} else {
+			summary.Invalid++
+		}
+		summary.ByFormat[r.Result.SBOMType]++
+		summary.ByVersion[r.Result.SBOMType+" "+r.Result.SBOMVersion]++
+	}
+
+	return summary
+}
@@ -0,0 +1,81 @@
+package sbomvalidator
+
+import (
+	"sort"
+)
+
+// BatchGroup is the part of a batch whose SBOMs share a value of the property
+// named by `WithGroupBy`. Name is the value, or empty for the SBOMs without
+// one and those that could not be validated.
+type BatchGroup struct {
+	Name string `json:"name"`
+	BatchResult
+}
+
+// Groups splits the batch by the `ValidationResult.Group` of its SBOMs, e.g.,
+// into per-team reports with `WithGroupBy("internal:team")`. Each group has
+// its own summary.
+//
+// Returns the groups in the order of their names, followed by the SBOMs
+// without a group, if any.
+//
+// Example:
+//
+//	batch, err := ValidateSBOMDir(os.DirFS("sboms"), WithGroupBy("internal:team"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, group := range batch.Groups() {
+//	    fmt.Printf("%s: %d invalid\n", group.Name, group.Summary.Invalid)
+//	}
+func (b *BatchResult) Groups() []BatchGroup {
+	index := map[string]int{}
+	var groups []BatchGroup
+	for _, r := range b.Results {
+		var name string
+		if r.Result != nil {
+			name = r.Result.Group
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, BatchGroup{Name: name})
+		}
+		groups[i].Results = append(groups[i].Results, r)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Name == "" || groups[j].Name == "" {
+			return groups[j].Name == "" && groups[i].Name != ""
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	for i := range groups {
+		groups[i].Summary = summarizeBatch(groups[i].Results)
+	}
+	return groups
+}
+
+// sbomProperty returns the value of a property of the SBOM as a whole: for
+// CycloneDX, from metadata.properties or else the properties of
+// metadata.component. SPDX documents have no properties.
+func sbomProperty(doc *sbomDocument, name string) string {
+	if doc.sbomType != SBOM_CYCLONEDX {
+		return ""
+	}
+	metadata, _ := doc.obj["metadata"].(map[string]interface{})
+	component, _ := metadata["component"].(map[string]interface{})
+	for _, owner := range []map[string]interface{}{metadata, component} {
+		properties, _ := owner["properties"].([]interface{})
+		for _, p := range properties {
+			property, _ := p.(map[string]interface{})
+			if property["name"] == name {
+				if value, ok := property["value"].(string); ok && value != "" {
+					return value
+				}
+			}
+		}
+	}
+	return ""
+}
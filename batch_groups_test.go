@@ -0,0 +1,59 @@
+//go:build !sbomvalidator_no_cyclonedx && !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBatchGroups(t *testing.T) {
+	dir := fstest.MapFS{
+		"payments.cdx.json": {Data: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1,
+  "metadata": {"properties": [{"name": "internal:team", "value": "payments"}]}}`)},
+		"search.cdx.json": {Data: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1,
+  "metadata": {"component": {"type": "application", "name": "search",
+    "properties": [{"name": "internal:owner", "value": "someone"}, {"name": "internal:team", "value": "discovery"}]}}}`)},
+		"checkout.cdx.json": {Data: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1,
+  "metadata": {"properties": [{"name": "internal:team", "value": "payments"}]}, "components": [{"name": "untyped"}]}`)},
+		"app.spdx.json":   {Data: spdxDocument(spdxPackage("a", "a", "MIT"))},
+		"broken.cdx.json": {Data: []byte(`{"bomFormat": `)},
+	}
+
+	batch, err := ValidateSBOMDir(dir, WithGroupBy("internal:team"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := map[string][]string{}
+	var order []string
+	for _, group := range batch.Groups() {
+		order = append(order, group.Name)
+		for _, r := range group.Results {
+			got[group.Name] = append(got[group.Name], r.Name)
+		}
+		if group.Summary.Total != len(group.Results) {
+			t.Errorf("group %q: Summary = %+v", group.Name, group.Summary)
+		}
+	}
+	if want := []string{"discovery", "payments", ""}; !reflect.DeepEqual(order, want) {
+		t.Errorf("groups = %q, want %q", order, want)
+	}
+	want := map[string][]string{
+		"discovery": {"search.cdx.json"},
+		"payments":  {"checkout.cdx.json", "payments.cdx.json"},
+		"":          {"app.spdx.json", "broken.cdx.json"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+
+	groups := batch.Groups()
+	if s := groups[1].Summary; s.Valid != 1 || s.Invalid != 1 {
+		t.Errorf("payments Summary = %+v, want 1 valid and 1 invalid", s)
+	}
+	if s := groups[2].Summary; s.Valid != 1 || s.Failed != 1 {
+		t.Errorf("ungrouped Summary = %+v, want 1 valid and 1 failed", s)
+	}
+}
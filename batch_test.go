@@ -0,0 +1,163 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidateSBOMDir(t *testing.T) {
+	tagValue, err := os.ReadFile("sample-sboms/sample-2.3.spdx")
+	if err != nil {
+		t.Fatalf("Failed to read sample: %v", err)
+	}
+
+	dir := fstest.MapFS{
+		"a/valid.spdx.json":   {Data: spdxDocument(spdxPackage("a", "a", "MIT"))},
+		"a/invalid.spdx.json": {Data: []byte(`{"spdxVersion": "SPDX-2.3"}`)},
+		"b/sample.spdx":       {Data: tagValue},
+		"b/notes.json":        {Data: []byte(`{"hello": "world"}`)},
+		"README.md":           {Data: []byte("ignored")},
+	}
+
+	batch, err := ValidateSBOMDir(dir, WithConcurrency(2))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, r := range batch.Results {
+		names = append(names, r.Name)
+		if (r.Result == nil) == (r.Error == "") {
+			t.Errorf("%s: expected exactly one of Result and Error, got %+v", r.Name, r)
+		}
+	}
+	wantNames := []string{"a/invalid.spdx.json", "a/valid.spdx.json", "b/notes.json", "b/sample.spdx"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("names = %v, want %v", names, wantNames)
+	}
+
+	want := BatchSummary{
+		Total:     4,
+		Valid:     2,
+		Invalid:   1,
+		Failed:    1,
+		ByFormat:  map[string]int{SBOM_SPDX: 3},
+		ByVersion: map[string]int{"SPDX 2.3": 3},
+	}
+	if !reflect.DeepEqual(batch.Summary, want) {
+		t.Errorf("Summary = %+v, want %+v", batch.Summary, want)
+	}
+
+	if line, col := batch.Results[0].Result.Locate("(root)"); line != 0 || col != 0 {
+		t.Errorf("Expected batch results not to retain the source, got %d:%d", line, col)
+	}
+}
+
+func TestBatchFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.cdx.json":                 {Data: []byte("{}")},
+		"services/api/bom.cdx.json":    {Data: []byte("{}")},
+		"services/api/bom.spdx.json":   {Data: spdxDocument(spdxPackage("a", "a", "MIT"))},
+		"services/web/sbom.spdx.json":  {Data: spdxDocument(spdxPackage("b", "b", "MIT"))},
+		"services/web/package.json":    {Data: []byte("{}")},
+		"services/web/fixtures/x.json": {Data: []byte("{}")},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{name: "No patterns", want: []string{"app.cdx.json", "services/api/bom.cdx.json", "services/api/bom.spdx.json",
+			"services/web/fixtures/x.json", "services/web/package.json", "services/web/sbom.spdx.json"}},
+		{name: "Any depth", patterns: []string{"**/*.cdx.json"}, want: []string{"app.cdx.json", "services/api/bom.cdx.json"}},
+		{name: "Several patterns", patterns: []string{"services/*/*.spdx.json", "app.*"},
+			want: []string{"app.cdx.json", "services/api/bom.spdx.json", "services/web/sbom.spdx.json"}},
+		{name: "Nested wildcard", patterns: []string{"services/**/x.json"}, want: []string{"services/web/fixtures/x.json"}},
+		{name: "No match", patterns: []string{"*.xml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, err := BatchFS(fsys, tt.patterns...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var names []string
+			for _, input := range inputs {
+				names = append(names, input.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("names = %q, want %q", names, tt.want)
+			}
+		})
+	}
+
+	inputs, err := BatchFS(fsys, "**/*.spdx.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch := ValidateSBOMBatch(inputs); batch.Summary.Valid != 2 {
+		t.Errorf("Summary = %+v, want 2 valid", batch.Summary)
+	}
+
+	if _, err := BatchFS(fsys, "services/[a-/*.json"); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("error = %v, want a bad pattern", err)
+	}
+}
+
+func TestValidateSBOMBatch(t *testing.T) {
+	inputs := append(BatchFiles("sample-sboms/sample-2.3.spdx.json", "no/such/file.json"),
+		BatchReader("reader", bytes.NewReader(spdxDocument(spdxPackage("a", "a", "MIT")))))
+
+	batch := ValidateSBOMBatch(inputs, WithConcurrency(1))
+
+	if len(batch.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(batch.Results))
+	}
+	if r := batch.Results[0]; r.Name != "sample-sboms/sample-2.3.spdx.json" || r.Result == nil || !r.Result.IsValid {
+		t.Errorf("Unexpected result for the sample: %+v", r)
+	}
+	if r := batch.Results[1]; r.Error == "" {
+		t.Errorf("Expected an error for a missing file, got %+v", r)
+	}
+	if r := batch.Results[2]; r.Name != "reader" || r.Result == nil || !r.Result.IsValid {
+		t.Errorf("Unexpected result for the reader: %+v", r)
+	}
+	if batch.Summary.Valid != 2 || batch.Summary.Failed != 1 {
+		t.Errorf("Unexpected summary: %+v", batch.Summary)
+	}
+}
+
+func TestValidateSBOMBatchEmpty(t *testing.T) {
+	batch := ValidateSBOMBatch(nil)
+	if len(batch.Results) != 0 || batch.Summary.Total != 0 {
+		t.Errorf("Unexpected result: %+v", batch)
+	}
+}
+
+func TestValidateSBOMBatchContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	batch, err := ValidateSBOMBatchContext(ctx, BatchFiles("sample-sboms/sample-2.3.spdx.json", "sample-sboms/sample-2.3.spdx"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	for _, r := range batch.Results {
+		if r.Result != nil || r.Error != context.Canceled.Error() {
+			t.Errorf("expected %s to be skipped, got %+v", r.Name, r)
+		}
+	}
+	if batch.Summary.Failed != 2 {
+		t.Errorf("Unexpected summary: %+v", batch.Summary)
+	}
+}
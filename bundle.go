@@ -0,0 +1,395 @@
+package sbomvalidator
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// BundleManifestName is the path of the manifest at the root of an SBOM
+// bundle.
+const BundleManifestName = "sbom-bundle.json"
+
+// Bundle manifest format identifiers.
+const (
+	BundleFormat  = "sbom-bundle"
+	BundleVersion = "1"
+)
+
+// Relationship types between the documents of a bundle.
+const (
+	// BundleDependsOn means the target of From depends on the target of To.
+	BundleDependsOn = "depends-on"
+	// BundleContains means the target of From contains the target of To,
+	// e.g., an image and one of its layers.
+	BundleContains = "contains"
+	// BundleVariantOf means both documents describe builds of the same
+	// software, e.g., for different platforms.
+	BundleVariantOf = "variant-of"
+)
+
+var bundleRelationshipTypes = map[string]bool{
+	BundleDependsOn: true,
+	BundleContains:  true,
+	BundleVariantOf: true,
+}
+
+// BundleManifest indexes the SBOMs of a multi-artifact release shipped as one
+// bundle, together with the artifacts they describe and how those relate.
+type BundleManifest struct {
+	BundleFormat  string               `json:"bundleFormat"`
+	BundleVersion string               `json:"bundleVersion"`
+	Documents     []BundleDocument     `json:"documents"`
+	Relationships []BundleRelationship `json:"relationships,omitempty"`
+}
+
+// BundleDocument is a single SBOM in a bundle. Path is relative to the root
+// of the bundle, and Digest is its `SBOMDigest`. Target identifies the
+// artifact the SBOM describes, e.g., a purl.
+type BundleDocument struct {
+	Path        string `json:"path"`
+	Digest      string `json:"digest"`
+	SBOMType    string `json:"sbomType"`
+	SBOMVersion string `json:"sbomVersion"`
+	Target      string `json:"target,omitempty"`
+}
+
+// BundleRelationship relates two documents of a bundle, by path.
+type BundleRelationship struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+// BundleDocumentResult is the validation result of one document of a bundle.
+// Result is nil when the document could not be read or validated; the reason
+// is then in the bundle's `ManifestErrors`.
+type BundleDocumentResult struct {
+	Path   string            `json:"path"`
+	Result *ValidationResult `json:"result,omitempty"`
+}
+
+// BundleResult represents the outcome of validating an SBOM bundle.
+//
+// The bundle is valid when the manifest is well-formed, every document it
+// lists is present with the declared digest, type, version and target, and
+// every document is a valid SBOM. Files that are not listed in the manifest
+// are reported as warnings.
+type BundleResult struct {
+	IsValid        bool                   `json:"isValid"`
+	ManifestErrors []string               `json:"manifestErrors,omitempty"`
+	Documents      []BundleDocumentResult `json:"documents,omitempty"`
+	Warnings       []string               `json:"warnings,omitempty"`
+}
+
+// ValidateBundle validates an SBOM bundle: its manifest and each SBOM it
+// lists.
+//
+// Parameters:
+//   - bundle: The bundle's file system, e.g., a `*zip.Reader` or `os.DirFS`.
+//   - opts: Options applied to the validation of each document, as for
+//     ValidateSBOMData.
+//
+// Returns:
+//   - A BundleResult with the manifest errors and per-document results.
+//   - An error if the manifest is missing or is not JSON.
+//
+// Example:
+//
+//	archive, err := zip.OpenReader("release-sboms.zip")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer archive.Close()
+//	result, err := ValidateBundle(archive)
+//	if err != nil {
+//	    log.Fatalf("Bundle validation failed: %v", err)
+//	}
+//	fmt.Println("Bundle valid:", result.IsValid)
+func ValidateBundle(bundle fs.FS, opts ...Option) (*BundleResult, error) {
+	data, err := fs.ReadFile(bundle, BundleManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle manifest: %v", err)
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle manifest: %v", err)
+	}
+
+	result := &BundleResult{ManifestErrors: checkBundleManifest(&manifest)}
+	listed := map[string]bool{BundleManifestName: true}
+
+	for i, doc := range manifest.Documents {
+		path := fmt.Sprintf("documents.%d", i)
+		listed[doc.Path] = true
+		if !fs.ValidPath(doc.Path) {
+			continue
+		}
+
+		content, err := fs.ReadFile(bundle, doc.Path)
+		if err != nil {
+			result.ManifestErrors = append(result.ManifestErrors, fmt.Sprintf("%s: document %q is missing from the bundle", path, doc.Path))
+			result.Documents = append(result.Documents, BundleDocumentResult{Path: doc.Path})
+			continue
+		}
+
+		if digest := SBOMDigest(content); digest != doc.Digest {
+			result.ManifestErrors = append(result.ManifestErrors, fmt.Sprintf("%s.digest: manifest declares %s but %q has digest %s", path, doc.Digest, doc.Path, digest))
+		}
+
+		validation, err := ValidateSBOMData(content, opts...)
+		if err != nil {
+			result.ManifestErrors = append(result.ManifestErrors, fmt.Sprintf("%s: %q could not be validated: %v", path, doc.Path, err))
+			result.Documents = append(result.Documents, BundleDocumentResult{Path: doc.Path})
+			continue
+		}
+		result.Documents = append(result.Documents, BundleDocumentResult{Path: doc.Path, Result: validation})
+
+		if validation.SBOMType != doc.SBOMType {
+			result.ManifestErrors = append(result.ManifestErrors, fmt.Sprintf("%s.sbomType: manifest declares %q but %q is %s", path, doc.SBOMType, doc.Path, validation.SBOMType))
+		}
+		if validation.SBOMVersion != doc.SBOMVersion {
+			result.ManifestErrors = append(result.ManifestErrors, fmt.Sprintf("%s.sbomVersion: manifest declares %q but %q is version %s", path, doc.SBOMVersion, doc.Path, validation.SBOMVersion))
+		}
+		if doc.Target != "" {
+			if target := bundleTarget(content); target != doc.Target {
+				result.ManifestErrors = append(result.ManifestErrors, fmt.Sprintf("%s.target: manifest declares %q but %q describes %q", path, doc.Target, doc.Path, target))
+			}
+		}
+	}
+
+	err = fs.WalkDir(bundle, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !listed[name] {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: file is not listed in the bundle manifest", name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bundle: %v", err)
+	}
+
+	result.IsValid = len(result.ManifestErrors) == 0
+	for _, doc := range result.Documents {
+		if doc.Result == nil || !doc.Result.IsValid {
+			result.IsValid = false
+		}
+	}
+
+	return result, nil
+}
+
+// checkBundleManifest checks the structure of a manifest, independently of
+// the documents it lists.
+func checkBundleManifest(manifest *BundleManifest) []string {
+	var errors []string
+
+	if manifest.BundleFormat != BundleFormat {
+		errors = append(errors, fmt.Sprintf("bundleFormat: expected %q, got %q", BundleFormat, manifest.BundleFormat))
+	}
+	if manifest.BundleVersion != BundleVersion {
+		errors = append(errors, fmt.Sprintf("bundleVersion: unsupported version %q", manifest.BundleVersion))
+	}
+	if len(manifest.Documents) == 0 {
+		errors = append(errors, "documents: the bundle lists no documents")
+	}
+
+	paths := map[string]bool{}
+	for i, doc := range manifest.Documents {
+		path := fmt.Sprintf("documents.%d", i)
+		switch {
+		case !fs.ValidPath(doc.Path) || doc.Path == "." || doc.Path == BundleManifestName:
+			errors = append(errors, fmt.Sprintf("%s.path: %q is not a valid document path", path, doc.Path))
+		case paths[doc.Path]:
+			errors = append(errors, fmt.Sprintf("%s.path: %q is listed more than once", path, doc.Path))
+		}
+		paths[doc.Path] = true
+
+		if !strings.HasPrefix(doc.Digest, "sha256:") {
+			errors = append(errors, fmt.Sprintf("%s.digest: expected a sha256 digest, got %q", path, doc.Digest))
+		}
+	}
+
+	for i, rel := range manifest.Relationships {
+		path := fmt.Sprintf("relationships.%d", i)
+		if !bundleRelationshipTypes[rel.Type] {
+			errors = append(errors, fmt.Sprintf("%s.type: unknown relationship type %q", path, rel.Type))
+		}
+		for _, end := range []struct{ field, doc string }{{"from", rel.From}, {"to", rel.To}} {
+			if !paths[end.doc] {
+				errors = append(errors, fmt.Sprintf("%s.%s: %q is not a document of the bundle", path, end.field, end.doc))
+			}
+		}
+		if rel.From == rel.To {
+			errors = append(errors, fmt.Sprintf("%s: document %q is related to itself", path, rel.From))
+		}
+	}
+
+	return errors
+}
+
+// GenerateBundleManifest builds the manifest for a directory of SBOMs. Every
+// file that is an SBOM is listed, with its digest, type, version and target;
+// other files are ignored.
+//
+// Parameters:
+//   - documents: The file system containing the SBOMs, e.g., `os.DirFS`.
+//   - relationships: Relationships between the documents, by path.
+//
+// Returns:
+//   - The manifest, with documents sorted by path.
+//   - An error if no SBOM is found, a relationship is invalid, or a file
+//     cannot be read.
+func GenerateBundleManifest(documents fs.FS, relationships ...BundleRelationship) (*BundleManifest, error) {
+	manifest := &BundleManifest{
+		BundleFormat:  BundleFormat,
+		BundleVersion: BundleVersion,
+		Relationships: relationships,
+	}
+
+	err := fs.WalkDir(documents, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || name == BundleManifestName {
+			return err
+		}
+
+		content, err := fs.ReadFile(documents, name)
+		if err != nil {
+			return err
+		}
+		validation, err := ValidateSBOMData(content)
+		if err != nil {
+			// not an SBOM
+			return nil
+		}
+
+		manifest.Documents = append(manifest.Documents, BundleDocument{
+			Path:        name,
+			Digest:      SBOMDigest(content),
+			SBOMType:    validation.SBOMType,
+			SBOMVersion: validation.SBOMVersion,
+			Target:      bundleTarget(content),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read documents: %v", err)
+	}
+
+	sort.Slice(manifest.Documents, func(i, j int) bool {
+		return manifest.Documents[i].Path < manifest.Documents[j].Path
+	})
+
+	if errors := checkBundleManifest(manifest); len(errors) > 0 {
+		return nil, fmt.Errorf("invalid bundle: %s", strings.Join(errors, "; "))
+	}
+
+	return manifest, nil
+}
+
+// WriteBundle writes the SBOMs in documents to w as a ZIP bundle, with a
+// generated manifest at its root (see GenerateBundleManifest). Files that are
+// not SBOMs are left out.
+//
+// Returns the manifest written, or an error if it cannot be generated or the
+// archive cannot be written.
+func WriteBundle(w io.Writer, documents fs.FS, relationships ...BundleRelationship) (*BundleManifest, error) {
+	manifest, err := GenerateBundleManifest(documents, relationships...)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	archive := zip.NewWriter(w)
+	entry, err := archive.Create(BundleManifestName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := entry.Write(data); err != nil {
+		return nil, err
+	}
+
+	for _, doc := range manifest.Documents {
+		content, err := fs.ReadFile(documents, doc.Path)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := archive.Create(doc.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// bundleTarget returns the identifier of the artifact an SBOM describes: the
+// purl, or else the name and version, of the CycloneDX metadata.component or
+// of the package an SPDX document describes. It is empty if the SBOM does not
+// say.
+func bundleTarget(content []byte) string {
+	jsonContent, err := sbomJSON(content, newValidationOptions(nil))
+	if err != nil {
+		return ""
+	}
+
+	obj, err := parseJSON(string(jsonContent))
+	if err != nil {
+		return ""
+	}
+
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		component, _ := metadata["component"].(map[string]interface{})
+		return componentTarget(component, "purl", "version")
+	}
+
+	described := spdxDescribedIDs(obj)
+	packages, _ := obj["packages"].([]interface{})
+	for _, p := range packages {
+		pkg, _ := p.(map[string]interface{})
+		if id, _ := pkg["SPDXID"].(string); !described[id] {
+			continue
+		}
+		refs, _ := pkg["externalRefs"].([]interface{})
+		for _, r := range refs {
+			ref, _ := r.(map[string]interface{})
+			if ref["referenceType"] == "purl" {
+				if locator, ok := ref["referenceLocator"].(string); ok {
+					return locator
+				}
+			}
+		}
+		return componentTarget(pkg, "", "versionInfo")
+	}
+
+	return ""
+}
+
+// componentTarget identifies a component by its purl field, if any, or by
+// its name and version.
+func componentTarget(component map[string]interface{}, purlField, versionField string) string {
+	if purl, _ := component[purlField].(string); purl != "" {
+		return purl
+	}
+	name, _ := component["name"].(string)
+	if version, _ := component[versionField].(string); version != "" && name != "" {
+		return name + "@" + version
+	}
+	return name
+}
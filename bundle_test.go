@@ -0,0 +1,167 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func bundleDocuments() fstest.MapFS {
+	app := strings.Replace(string(spdxDocument(spdxPackage("app", "app", "MIT"))),
+		`"packages"`, `"documentDescribes": ["SPDXRef-app"], "packages"`, 1)
+	return fstest.MapFS{
+		"app.spdx.json":     {Data: []byte(app)},
+		"lib/lib.spdx.json": {Data: spdxDocument(spdxPackage("lib", "lib", "MIT"))},
+		"README.txt":        {Data: []byte("release notes")},
+	}
+}
+
+func TestGenerateBundleManifest(t *testing.T) {
+	manifest, err := GenerateBundleManifest(bundleDocuments(),
+		BundleRelationship{From: "app.spdx.json", To: "lib/lib.spdx.json", Type: BundleDependsOn})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(manifest.Documents) != 2 {
+		t.Fatalf("Expected 2 documents, got %+v", manifest.Documents)
+	}
+	app := manifest.Documents[0]
+	if app.Path != "app.spdx.json" || app.SBOMType != SBOM_SPDX || app.SBOMVersion != "2.3" ||
+		app.Target != "pkg:npm/app@1.0.0" || app.Digest != SBOMDigest(bundleDocuments()["app.spdx.json"].Data) {
+		t.Errorf("Unexpected document: %+v", app)
+	}
+	if manifest.Documents[1].Target != "" {
+		t.Errorf("Expected no target for a document that describes nothing, got %q", manifest.Documents[1].Target)
+	}
+
+	_, err = GenerateBundleManifest(bundleDocuments(),
+		BundleRelationship{From: "app.spdx.json", To: "missing.json", Type: BundleDependsOn})
+	if err == nil || !strings.Contains(err.Error(), `relationships.0.to: "missing.json" is not a document of the bundle`) {
+		t.Errorf("Expected an invalid relationship error, got %v", err)
+	}
+
+	if _, err := GenerateBundleManifest(fstest.MapFS{"a.txt": {Data: []byte("a")}}); err == nil {
+		t.Errorf("Expected an error for a bundle without SBOMs")
+	}
+}
+
+func TestValidateBundle(t *testing.T) {
+	documents := bundleDocuments()
+	manifest, err := GenerateBundleManifest(documents)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(m *BundleManifest, files fstest.MapFS)
+		wantValid bool
+		wantError string
+	}{
+		{name: "Generated bundle", modify: func(m *BundleManifest, files fstest.MapFS) {}, wantValid: true},
+		{
+			name: "Tampered document",
+			modify: func(m *BundleManifest, files fstest.MapFS) {
+				files["lib/lib.spdx.json"] = &fstest.MapFile{Data: spdxDocument(spdxPackage("lib", "lib", "Apache-2.0"))}
+			},
+			wantError: "documents.1.digest: manifest declares",
+		},
+		{
+			name:      "Missing document",
+			modify:    func(m *BundleManifest, files fstest.MapFS) { delete(files, "lib/lib.spdx.json") },
+			wantError: `documents.1: document "lib/lib.spdx.json" is missing from the bundle`,
+		},
+		{
+			name:      "Wrong version",
+			modify:    func(m *BundleManifest, files fstest.MapFS) { m.Documents[0].SBOMVersion = "2.2" },
+			wantError: `documents.0.sbomVersion: manifest declares "2.2"`,
+		},
+		{
+			name:      "Wrong target",
+			modify:    func(m *BundleManifest, files fstest.MapFS) { m.Documents[0].Target = "pkg:npm/other@1.0.0" },
+			wantError: `documents.0.target: manifest declares "pkg:npm/other@1.0.0"`,
+		},
+		{
+			name:      "Duplicate document",
+			modify:    func(m *BundleManifest, files fstest.MapFS) { m.Documents = append(m.Documents, m.Documents[0]) },
+			wantError: `documents.2.path: "app.spdx.json" is listed more than once`,
+		},
+		{
+			name: "Unknown relationship type",
+			modify: func(m *BundleManifest, files fstest.MapFS) {
+				m.Relationships = []BundleRelationship{{From: "app.spdx.json", To: "lib/lib.spdx.json", Type: "uses"}}
+			},
+			wantError: `relationships.0.type: unknown relationship type "uses"`,
+		},
+		{
+			name:      "Unsupported version",
+			modify:    func(m *BundleManifest, files fstest.MapFS) { m.BundleVersion = "2" },
+			wantError: `bundleVersion: unsupported version "2"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := fstest.MapFS{}
+			for name, file := range documents {
+				files[name] = file
+			}
+			m := *manifest
+			m.Documents = append([]BundleDocument(nil), manifest.Documents...)
+			tt.modify(&m, files)
+
+			data, _ := json.Marshal(m)
+			files[BundleManifestName] = &fstest.MapFile{Data: data}
+
+			result, err := ValidateBundle(files)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v (errors: %v)", result.IsValid, tt.wantValid, result.ManifestErrors)
+			}
+			if tt.wantError != "" && !strings.Contains(strings.Join(result.ManifestErrors, "\n"), tt.wantError) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantError, result.ManifestErrors)
+			}
+			if len(result.Warnings) != 1 || result.Warnings[0] != "README.txt: file is not listed in the bundle manifest" {
+				t.Errorf("Unexpected warnings: %v", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestValidateBundleWithoutManifest(t *testing.T) {
+	if _, err := ValidateBundle(bundleDocuments()); err == nil {
+		t.Errorf("Expected an error for a bundle without a manifest")
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	var buf bytes.Buffer
+	manifest, err := WriteBundle(&buf, bundleDocuments())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read the bundle: %v", err)
+	}
+	if len(archive.File) != len(manifest.Documents)+1 {
+		t.Errorf("Expected the manifest and %d documents, got %d files", len(manifest.Documents), len(archive.File))
+	}
+
+	result, err := ValidateBundle(archive)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.IsValid || len(result.Warnings) != 0 || len(result.Documents) != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
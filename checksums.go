@@ -0,0 +1,106 @@
+package sbomvalidator
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ChecksumsFileName is the checksums manifest ValidateSBOMDir picks up from
+// the root of the directory.
+const ChecksumsFileName = "SHA256SUMS"
+
+// Outcomes of verifying a batch input against a checksums manifest.
+const (
+	// ChecksumVerified means the file matches its digest in the manifest.
+	ChecksumVerified = "verified"
+	// ChecksumMismatch means the file does not match its digest, i.e., it
+	// was tampered with or corrupted.
+	ChecksumMismatch = "mismatch"
+	// ChecksumUnlisted means the file is not listed in the manifest.
+	ChecksumUnlisted = "unlisted"
+	// ChecksumMissing means the manifest lists an SBOM that is not among the
+	// inputs.
+	ChecksumMissing = "missing"
+)
+
+// Checksums maps file names to their lowercase hex SHA-256 digests, as listed
+// in a SHA256SUMS manifest.
+type Checksums map[string]string
+
+var (
+	// gnuChecksumLine matches `sha256sum` output: "<hex>  <name>" or, in
+	// binary mode, "<hex> *<name>".
+	gnuChecksumLine = regexp.MustCompile(`^([0-9a-fA-F]{64}) [ *](.+)$`)
+	// bsdChecksumLine matches `sha256sum --tag` and BSD `sha256` output.
+	bsdChecksumLine = regexp.MustCompile(`^SHA256 \((.+)\) = ([0-9a-fA-F]{64})$`)
+)
+
+// ParseChecksums parses a SHA256SUMS style manifest, in either the GNU
+// (`sha256sum`) or the BSD (`sha256sum --tag`) format. Blank lines and lines
+// starting with "#" are ignored. Names are cleaned, so "./a.json" and "a.json"
+// are the same file.
+//
+// Parameters:
+//   - r: A reader supplying the manifest.
+//
+// Returns:
+//   - The Checksums listed in the manifest.
+//   - An error if a line is malformed or a file is listed twice with
+//     different digests.
+//
+// Example:
+//
+//	f, _ := os.Open("SHA256SUMS")
+//	sums, err := ParseChecksums(f)
+func ParseChecksums(r io.Reader) (Checksums, error) {
+	sums := Checksums{}
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var name, digest string
+		if m := gnuChecksumLine.FindStringSubmatch(line); m != nil {
+			digest, name = m[1], m[2]
+		} else if m := bsdChecksumLine.FindStringSubmatch(line); m != nil {
+			name, digest = m[1], m[2]
+		} else {
+			return nil, fmt.Errorf("line %d: not a SHA-256 checksum line", n)
+		}
+
+		name = path.Clean(name)
+		digest = strings.ToLower(digest)
+		if existing, ok := sums[name]; ok && existing != digest {
+			return nil, fmt.Errorf("line %d: %s is listed with two different digests", n, name)
+		}
+		sums[name] = digest
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksums: %v", err)
+	}
+
+	return sums, nil
+}
+
+// verify returns the checksum outcome for a named file's content.
+func (c Checksums) verify(name string, content []byte) string {
+	want, ok := c[path.Clean(name)]
+	if !ok {
+		return ChecksumUnlisted
+	}
+
+	digest := sha256.Sum256(content)
+	if hex.EncodeToString(digest[:]) != want {
+		return ChecksumMismatch
+	}
+	return ChecksumVerified
+}
@@ -0,0 +1,120 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func sha256Hex(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
+
+func TestParseChecksums(t *testing.T) {
+	a := strings.Repeat("a", 64)
+	b := strings.Repeat("B", 64)
+
+	tests := []struct {
+		name     string
+		manifest string
+		want     Checksums
+		wantErr  string
+	}{
+		{
+			name:     "GNU format",
+			manifest: "# release 1.0\n" + a + "  ./sboms/app.cdx.json\n" + b + " *lib.spdx.json\r\n\n",
+			want:     Checksums{"sboms/app.cdx.json": a, "lib.spdx.json": strings.ToLower(b)},
+		},
+		{
+			name:     "BSD format",
+			manifest: "SHA256 (app.cdx.json) = " + a + "\n",
+			want:     Checksums{"app.cdx.json": a},
+		},
+		{
+			name:     "Malformed line",
+			manifest: a + "  app.cdx.json\nd41d8cd98f00b204e9800998ecf8427e  lib.json\n",
+			wantErr:  "line 2: not a SHA-256 checksum line",
+		},
+		{
+			name:     "Conflicting entries",
+			manifest: a + "  app.cdx.json\n" + b + "  ./app.cdx.json\n",
+			wantErr:  "line 2: app.cdx.json is listed with two different digests",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChecksums(strings.NewReader(tt.manifest))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSBOMDirChecksums(t *testing.T) {
+	valid := spdxDocument(spdxPackage("a", "a", "MIT"))
+	invalid := []byte(`{"spdxVersion": "SPDX-2.3"}`)
+
+	dir := fstest.MapFS{
+		"valid.spdx.json":    {Data: valid},
+		"invalid.spdx.json":  {Data: invalid},
+		"tampered.spdx.json": {Data: valid},
+		"extra.spdx.json":    {Data: valid},
+		ChecksumsFileName: {Data: []byte(sha256Hex(valid) + "  valid.spdx.json\n" +
+			sha256Hex(invalid) + "  invalid.spdx.json\n" +
+			sha256Hex(invalid) + "  tampered.spdx.json\n" +
+			sha256Hex(valid) + "  gone.spdx.json\n" +
+			sha256Hex(valid) + "  app.tar.gz\n")},
+	}
+
+	batch, err := ValidateSBOMDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, r := range batch.Results {
+		got[r.Name] = r.Checksum
+		if r.Checksum != ChecksumVerified && r.Result != nil {
+			t.Errorf("%s: expected a file failing verification not to be validated", r.Name)
+		}
+	}
+	want := map[string]string{
+		"valid.spdx.json":    ChecksumVerified,
+		"invalid.spdx.json":  ChecksumVerified,
+		"tampered.spdx.json": ChecksumMismatch,
+		"extra.spdx.json":    ChecksumUnlisted,
+		"gone.spdx.json":     ChecksumMissing,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("checksums = %v, want %v", got, want)
+	}
+
+	if s := batch.Summary; s.Total != 5 || s.Valid != 1 || s.Invalid != 1 || s.Failed != 0 ||
+		s.Tampered != 1 || s.Unlisted != 1 || s.Missing != 1 {
+		t.Errorf("Unexpected summary: %+v", s)
+	}
+}
+
+func TestValidateSBOMDirInvalidChecksums(t *testing.T) {
+	dir := fstest.MapFS{ChecksumsFileName: {Data: []byte("not a checksum\n")}}
+	if _, err := ValidateSBOMDir(dir); err == nil {
+		t.Errorf("Expected an error for a malformed manifest")
+	}
+}
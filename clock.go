@@ -0,0 +1,90 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxClockSkew is how far in the future an SBOM's creation time may be before
+// it is reported, to tolerate clocks that are slightly off.
+const maxClockSkew = 5 * time.Minute
+
+// Clock tells the time for the checks that depend on it, such as the age of
+// an SBOM, and for stamping results. It can be replaced with `WithClock`, so
+// that such checks and reports are reproducible in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function, such as time.Now, to a Clock.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the default Clock: the system time.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// FixedClock returns a Clock that is always at t.
+//
+// Example:
+//
+//	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+//	result, err := ValidateSBOMData(data, WithClock(FixedClock(at)), WithMaxSBOMAge(90*24*time.Hour))
+func FixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
+
+// now returns the time on the configured clock.
+func (o *validationOptions) now() time.Time {
+	if o.clock != nil {
+		return o.clock.Now()
+	}
+	return time.Now()
+}
+
+// checkSBOMAge reports an SBOM whose creation time (CycloneDX
+// metadata.timestamp, SPDX creationInfo.created) is older than maxAge, or in
+// the future, as of now. A missing or malformed creation time is left to the
+// schema and the quality-timestamp check.
+//
+// Returns a warning message, prefixed with the JSON path of the creation
+// time, or nil.
+func checkSBOMAge(obj map[string]interface{}, sbomType string, now time.Time, maxAge time.Duration) []string {
+	path := "metadata.timestamp"
+	holder, _ := obj["metadata"].(map[string]interface{})
+	field := "timestamp"
+	if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		path, field = "creationInfo.created", "created"
+		holder, _ = obj["creationInfo"].(map[string]interface{})
+	}
+	value, _ := holder[field].(string)
+	created, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+
+	switch age := now.Sub(created); {
+	case age < -maxClockSkew:
+		return []string{fmt.Sprintf("%s: SBOM was created at %s, which is in the future", path, value)}
+	case maxAge > 0 && age > maxAge:
+		return []string{fmt.Sprintf("%s: SBOM was created at %s, more than %s ago", path, value, formatAge(maxAge))}
+	}
+	return nil
+}
+
+// formatAge formats a duration in days when it is a whole number of them,
+// the unit SBOM retention policies are usually written in.
+func formatAge(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d >= day && d%day == 0 {
+		if d == day {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", d/day)
+	}
+	return d.String()
+}
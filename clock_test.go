@@ -0,0 +1,93 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckSBOMAge(t *testing.T) {
+	created := time.Date(2024, 10, 22, 12, 0, 0, 0, time.UTC)
+	cycloneDX := map[string]interface{}{"metadata": map[string]interface{}{"timestamp": "2024-10-22T12:00:00Z"}}
+	spdx := map[string]interface{}{"creationInfo": map[string]interface{}{"created": "2024-10-22T12:00:00Z"}}
+
+	tests := []struct {
+		name     string
+		obj      map[string]interface{}
+		sbomType string
+		now      time.Time
+		maxAge   time.Duration
+		want     []string
+	}{
+		{name: "Recent", obj: cycloneDX, sbomType: SBOM_CYCLONEDX, now: created.Add(24 * time.Hour), maxAge: 90 * 24 * time.Hour},
+		{
+			name: "Too old", obj: cycloneDX, sbomType: SBOM_CYCLONEDX, now: created.Add(91 * 24 * time.Hour), maxAge: 90 * 24 * time.Hour,
+			want: []string{"metadata.timestamp: SBOM was created at 2024-10-22T12:00:00Z, more than 90 days ago"},
+		},
+		{
+			name: "Too old SPDX", obj: spdx, sbomType: "SPDX-2.3", now: created.Add(2 * time.Hour), maxAge: time.Hour,
+			want: []string{"creationInfo.created: SBOM was created at 2024-10-22T12:00:00Z, more than 1h0m0s ago"},
+		},
+		{name: "Age not checked", obj: cycloneDX, sbomType: SBOM_CYCLONEDX, now: created.Add(1000 * 24 * time.Hour)},
+		{name: "Within clock skew", obj: cycloneDX, sbomType: SBOM_CYCLONEDX, now: created.Add(-time.Minute)},
+		{
+			name: "In the future", obj: spdx, sbomType: "SPDX-2.3", now: created.Add(-time.Hour),
+			want: []string{"creationInfo.created: SBOM was created at 2024-10-22T12:00:00Z, which is in the future"},
+		},
+		{name: "No timestamp", obj: map[string]interface{}{}, sbomType: SBOM_CYCLONEDX, now: created, maxAge: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkSBOMAge(tt.obj, tt.sbomType, tt.now, tt.maxAge); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkSBOMAge() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "left-pad", "MIT"))
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	result, err := ValidateSBOMDataStructured(sbom, WithClock(FixedClock(at)), WithMaxSBOMAge(30*24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ValidatedAt == nil || !result.ValidatedAt.Equal(at) || result.Duration != 0 {
+		t.Errorf("ValidatedAt = %v, Duration = %v", result.ValidatedAt, result.Duration)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "more than 30 days ago") {
+		t.Errorf("unexpected warnings: %q", result.Warnings)
+	}
+
+	// the system clock stamps results by default
+	result, err = ValidateSBOMDataStructured(sbom)
+	if err != nil || result.ValidatedAt == nil || time.Since(*result.ValidatedAt) > time.Minute {
+		t.Errorf("result = %+v, %v", result, err)
+	}
+}
+
+func TestWithDeterministic(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "left-pad", "MIT"))
+
+	var reports []string
+	for i := 0; i < 2; i++ {
+		result, err := ValidateSBOMDataStructured(sbom, WithDeterministic(true))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, err := json.Marshal(result.ValidationResult)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, string(data))
+	}
+	if reports[0] != reports[1] || strings.Contains(reports[0], "validatedAt") || strings.Contains(reports[0], "duration") {
+		t.Errorf("reports differ or have timing fields:\n%s\n%s", reports[0], reports[1])
+	}
+}
@@ -0,0 +1,303 @@
+// Package compare reports how two SBOMs differ: the components added and
+// removed, and, for components in both, version drifts and license changes.
+//
+// The SBOMs may be in different formats and encodings (e.g., the CycloneDX
+// SBOM declared for a release and the SPDX SBOM generated from a rebuild of
+// it). Components are normalized to a format-neutral view and matched by an
+// identity that leaves out the version: the type, namespace and name of their
+// purl and, for components not matched that way, their normalized name (see
+// `sbomvalidator.PackageIdentity` and `sbomvalidator.NameIdentity`).
+//
+// `ProvenanceDiff` builds on the comparison to review a submitted SBOM for
+// manual edits against one regenerated from the same source.
+//
+// Example:
+//
+//	diff, err := compare.SBOMs(declared, rebuilt, compare.Config{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if !diff.Identical() {
+//	    for _, c := range diff.VersionDrifts() {
+//	        fmt.Printf("%s: %s -> %s\n", c.Key, c.Before.Version, c.After.Version)
+//	    }
+//	}
+package compare
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// DefaultResolvers are the identity resolvers used when Config.Resolvers is
+// empty: components are matched by purl and then, for those left unmatched,
+// by name.
+var DefaultResolvers = []sbomvalidator.IdentityResolver{sbomvalidator.PackageIdentity, sbomvalidator.NameIdentity}
+
+// Config configures a comparison.
+type Config struct {
+	// Resolvers match components across the SBOMs, each in turn matching the
+	// components the previous ones left unmatched. Components a resolver
+	// gives the same identity are the same package; their versions are
+	// compared, so resolvers should leave versions out. Defaults to
+	// DefaultResolvers.
+	Resolvers []sbomvalidator.IdentityResolver
+	// Options are passed to `sbomvalidator.ExtractComponents`, e.g., to
+	// register decompressors.
+	Options []sbomvalidator.Option
+}
+
+// Component is a component of one of the compared SBOMs.
+type Component struct {
+	Key      string   `json:"key"`
+	Name     string   `json:"name"`
+	Version  string   `json:"version,omitempty"`
+	PURL     string   `json:"purl,omitempty"`
+	Licenses []string `json:"licenses,omitempty"`
+	// Evidence reports that the SBOM carries evidence of how the component
+	// was found (see `sbomvalidator.ComponentDescriptor`).
+	Evidence bool `json:"evidence,omitempty"`
+
+	descriptor sbomvalidator.ComponentDescriptor
+}
+
+// Change is a component that is in both SBOMs with a different version or
+// different licenses.
+type Change struct {
+	Key    string    `json:"key"`
+	Before Component `json:"before"`
+	After  Component `json:"after"`
+	// VersionDrift reports that the versions differ.
+	VersionDrift bool `json:"versionDrift,omitempty"`
+	// LicenseChange reports that the sets of declared licenses differ.
+	LicenseChange bool `json:"licenseChange,omitempty"`
+}
+
+// Report is the outcome of a comparison. Components, changes and keys are
+// sorted by key, then version.
+type Report struct {
+	Added   []Component `json:"added,omitempty"`
+	Removed []Component `json:"removed,omitempty"`
+	Changed []Change    `json:"changed,omitempty"`
+	// Unchanged is the number of components in both SBOMs with the same
+	// version and licenses.
+	Unchanged int `json:"unchanged"`
+	// Unidentified is the number of components, across both SBOMs, that no
+	// resolver could identify and that were left out of the comparison.
+	Unidentified int `json:"unidentified,omitempty"`
+}
+
+// Identical reports whether the SBOMs declare the same components with the
+// same versions and licenses.
+func (r *Report) Identical() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// VersionDrifts returns the changes whose versions differ.
+func (r *Report) VersionDrifts() []Change {
+	return r.filter(func(c Change) bool { return c.VersionDrift })
+}
+
+// LicenseChanges returns the changes whose licenses differ.
+func (r *Report) LicenseChanges() []Change {
+	return r.filter(func(c Change) bool { return c.LicenseChange })
+}
+
+func (r *Report) filter(keep func(Change) bool) []Change {
+	var changes []Change
+	for _, c := range r.Changed {
+		if keep(c) {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// SBOMs compares two SBOMs, in any encoding `sbomvalidator.ValidateSBOMData`
+// accepts. Neither is validated.
+//
+// Parameters:
+//   - before: The reference SBOM, e.g., the one declared for a release.
+//   - after: The SBOM compared with it, e.g., one generated from a rebuild.
+//   - cfg: The identity resolvers and decoding options.
+//
+// Returns:
+//   - The Report.
+//   - An error if either SBOM cannot be decoded or its type is unsupported.
+func SBOMs(before, after []byte, cfg Config) (*Report, error) {
+	beforeComponents, err := sbomvalidator.ExtractComponents(before, cfg.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the first SBOM: %v", err)
+	}
+	afterComponents, err := sbomvalidator.ExtractComponents(after, cfg.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the second SBOM: %v", err)
+	}
+	return Components(beforeComponents, afterComponents, cfg), nil
+}
+
+// Components compares two lists of components, e.g., from
+// `sbomvalidator.ExtractComponents`. Only cfg.Resolvers is used.
+//
+// Each resolver matches the components left unmatched by the previous ones.
+// When several components share an identity, such as two versions of a
+// package vendored side by side, components with the same version are
+// matched first and the rest are paired in order of their version strings.
+// Components still unmatched after the last resolver are added or removed.
+func Components(before, after []sbomvalidator.ComponentDescriptor, cfg Config) *Report {
+	resolvers := cfg.Resolvers
+	if len(resolvers) == 0 {
+		resolvers = DefaultResolvers
+	}
+
+	report := &Report{}
+	for _, resolver := range resolvers {
+		beforeByKey, afterByKey := groupByKey(before, resolver), groupByKey(after, resolver)
+		before, after = unresolved(before, resolver), unresolved(after, resolver)
+
+		for _, key := range sortedKeys(beforeByKey, afterByKey) {
+			removed, added := beforeByKey[key], afterByKey[key]
+			if len(removed) == 0 || len(added) == 0 {
+				// left for the next resolver
+				before = append(before, descriptors(removed)...)
+				after = append(after, descriptors(added)...)
+				continue
+			}
+
+			// components with the same version are the same component
+			var drifted []Component
+			for _, b := range removed {
+				i := slices.IndexFunc(added, func(a Component) bool { return a.Version == b.Version })
+				if i < 0 {
+					drifted = append(drifted, b)
+					continue
+				}
+				report.addPair(b, added[i])
+				added = slices.Delete(added, i, i+1)
+			}
+			removed = drifted
+
+			for len(removed) > 0 && len(added) > 0 {
+				report.addPair(removed[0], added[0])
+				removed, added = removed[1:], added[1:]
+			}
+			before = append(before, descriptors(removed)...)
+			after = append(after, descriptors(added)...)
+		}
+	}
+
+	sort.SliceStable(report.Changed, func(i, j int) bool { return report.Changed[i].Key < report.Changed[j].Key })
+
+	identity := sbomvalidator.FirstIdentity(resolvers...)
+	report.Removed, report.Unidentified = unmatched(before, identity)
+	added, unidentified := unmatched(after, identity)
+	report.Added = added
+	report.Unidentified += unidentified
+	return report
+}
+
+// addPair records a component found in both SBOMs.
+func (r *Report) addPair(before, after Component) {
+	change := Change{
+		Key:           before.Key,
+		Before:        before,
+		After:         after,
+		VersionDrift:  before.Version != after.Version,
+		LicenseChange: !slices.Equal(before.Licenses, after.Licenses),
+	}
+	if change.VersionDrift || change.LicenseChange {
+		r.Changed = append(r.Changed, change)
+	} else {
+		r.Unchanged++
+	}
+}
+
+// groupByKey groups the components the resolver identifies by identity, each
+// group sorted by version.
+func groupByKey(components []sbomvalidator.ComponentDescriptor, resolver sbomvalidator.IdentityResolver) map[string][]Component {
+	groups := map[string][]Component{}
+	for _, c := range components {
+		if key := resolver.ResolveIdentity(c); key != "" {
+			groups[key] = append(groups[key], newComponent(key, c))
+		}
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Version < group[j].Version })
+	}
+	return groups
+}
+
+// unresolved returns the components the resolver cannot identify.
+func unresolved(components []sbomvalidator.ComponentDescriptor, resolver sbomvalidator.IdentityResolver) []sbomvalidator.ComponentDescriptor {
+	var rest []sbomvalidator.ComponentDescriptor
+	for _, c := range components {
+		if resolver.ResolveIdentity(c) == "" {
+			rest = append(rest, c)
+		}
+	}
+	return rest
+}
+
+// unmatched returns the components that an SBOM alone declares, sorted by
+// key, and counts those without an identity.
+func unmatched(components []sbomvalidator.ComponentDescriptor, identity sbomvalidator.IdentityResolver) ([]Component, int) {
+	var identified []Component
+	unidentified := 0
+	for _, c := range components {
+		key := identity.ResolveIdentity(c)
+		if key == "" {
+			unidentified++
+			continue
+		}
+		identified = append(identified, newComponent(key, c))
+	}
+	sort.SliceStable(identified, func(i, j int) bool {
+		if identified[i].Key != identified[j].Key {
+			return identified[i].Key < identified[j].Key
+		}
+		return identified[i].Version < identified[j].Version
+	})
+	return identified, unidentified
+}
+
+func sortedKeys(groups ...map[string][]Component) []string {
+	var keys []string
+	for _, group := range groups {
+		for key := range group {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newComponent returns the reported form of a component, with its licenses
+// sorted and deduplicated so they compare as a set.
+func newComponent(key string, c sbomvalidator.ComponentDescriptor) Component {
+	licenses := slices.Clone(c.Licenses)
+	sort.Strings(licenses)
+	return Component{
+		Key:        key,
+		Name:       c.Name,
+		Version:    c.Version,
+		PURL:       c.PURL,
+		Licenses:   slices.Compact(licenses),
+		Evidence:   c.Evidence,
+		descriptor: c,
+	}
+}
+
+// descriptors returns the components as they were given.
+func descriptors(components []Component) []sbomvalidator.ComponentDescriptor {
+	list := make([]sbomvalidator.ComponentDescriptor, len(components))
+	for i, c := range components {
+		list[i] = c.descriptor
+	}
+	return list
+}
@@ -0,0 +1,144 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+const declaredCycloneDX = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "components": [
+    {"name": "left-pad", "version": "1.3.0", "purl": "pkg:npm/left-pad@1.3.0", "licenses": [{"license": {"id": "MIT"}}]},
+    {"name": "lodash", "version": "4.17.20", "purl": "pkg:npm/lodash@4.17.20", "licenses": [{"license": {"id": "MIT"}}]},
+    {"name": "chalk", "version": "5.3.0", "purl": "pkg:npm/chalk@5.3.0", "licenses": [{"license": {"id": "MIT"}}]},
+    {"name": "internal-util", "version": "2.0.0"}
+  ]
+}`
+
+const rebuiltSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "packages": [
+    {"SPDXID": "SPDXRef-a", "name": "left-pad", "versionInfo": "1.3.0", "licenseDeclared": "MIT",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/left-pad@1.3.0"}]},
+    {"SPDXID": "SPDXRef-b", "name": "lodash", "versionInfo": "4.17.21", "licenseDeclared": "MIT",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/lodash@4.17.21"}]},
+    {"SPDXID": "SPDXRef-c", "name": "Internal_Util", "versionInfo": "2.0.0", "licenseDeclared": "Apache-2.0"},
+    {"SPDXID": "SPDXRef-d", "name": "debug", "versionInfo": "4.3.4", "licenseDeclared": "MIT",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/debug@4.3.4"}]}
+  ]
+}`
+
+func TestSBOMs(t *testing.T) {
+	report, err := SBOMs([]byte(declaredCycloneDX), []byte(rebuiltSPDX), Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Identical() {
+		t.Error("expected the SBOMs to differ")
+	}
+	if len(report.Added) != 1 || report.Added[0].Key != "pkg:npm/debug" {
+		t.Errorf("unexpected added components: %+v", report.Added)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Key != "pkg:npm/chalk" {
+		t.Errorf("unexpected removed components: %+v", report.Removed)
+	}
+	if report.Unchanged != 1 {
+		t.Errorf("Unchanged = %d, want 1", report.Unchanged)
+	}
+
+	drifts := report.VersionDrifts()
+	if len(drifts) != 1 || drifts[0].Key != "pkg:npm/lodash" || drifts[0].Before.Version != "4.17.20" || drifts[0].After.Version != "4.17.21" {
+		t.Errorf("unexpected version drifts: %+v", drifts)
+	}
+	licenses := report.LicenseChanges()
+	if len(licenses) != 1 || licenses[0].Key != "internal-util" ||
+		len(licenses[0].Before.Licenses) != 0 || !reflect.DeepEqual(licenses[0].After.Licenses, []string{"Apache-2.0"}) {
+		t.Errorf("unexpected license changes: %+v", licenses)
+	}
+}
+
+func TestSBOMsIdentical(t *testing.T) {
+	report, err := SBOMs([]byte(declaredCycloneDX), []byte(declaredCycloneDX), Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Identical() || report.Unchanged != 4 {
+		t.Errorf("expected identical SBOMs, got %+v", report)
+	}
+}
+
+func TestSBOMsInvalid(t *testing.T) {
+	if _, err := SBOMs([]byte(`not an SBOM`), []byte(rebuiltSPDX), Config{}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestComponents(t *testing.T) {
+	component := func(name, version string) sbomvalidator.ComponentDescriptor {
+		return sbomvalidator.ComponentDescriptor{Name: name, Version: version}
+	}
+
+	tests := []struct {
+		name        string
+		before      []sbomvalidator.ComponentDescriptor
+		after       []sbomvalidator.ComponentDescriptor
+		wantAdded   []string
+		wantRemoved []string
+		wantDrifts  []string
+	}{
+		{
+			name:   "Same versions side by side",
+			before: []sbomvalidator.ComponentDescriptor{component("a", "1.0"), component("a", "2.0")},
+			after:  []sbomvalidator.ComponentDescriptor{component("a", "2.0"), component("a", "1.0")},
+		},
+		{
+			name:       "One of two versions drifts",
+			before:     []sbomvalidator.ComponentDescriptor{component("a", "1.0"), component("a", "2.0")},
+			after:      []sbomvalidator.ComponentDescriptor{component("a", "2.0"), component("a", "1.1")},
+			wantDrifts: []string{"1.0 -> 1.1"},
+		},
+		{
+			name:      "Extra version added",
+			before:    []sbomvalidator.ComponentDescriptor{component("a", "1.0")},
+			after:     []sbomvalidator.ComponentDescriptor{component("a", "1.0"), component("a", "2.0")},
+			wantAdded: []string{"a 2.0"},
+		},
+		{
+			name:       "Matched by name when only one side has a purl",
+			before:     []sbomvalidator.ComponentDescriptor{{Name: "a", Version: "1.0", PURL: "pkg:npm/a@1.0"}},
+			after:      []sbomvalidator.ComponentDescriptor{component("a", "1.1")},
+			wantDrifts: []string{"1.0 -> 1.1"},
+		},
+		{
+			name:        "Unidentified components are ignored",
+			before:      []sbomvalidator.ComponentDescriptor{component("", "1.0"), component("b", "1.0")},
+			after:       nil,
+			wantRemoved: []string{"b 1.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Components(tt.before, tt.after, Config{})
+
+			var added, removed, drifts []string
+			for _, c := range report.Added {
+				added = append(added, c.Key+" "+c.Version)
+			}
+			for _, c := range report.Removed {
+				removed = append(removed, c.Key+" "+c.Version)
+			}
+			for _, c := range report.VersionDrifts() {
+				drifts = append(drifts, c.Before.Version+" -> "+c.After.Version)
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) || !reflect.DeepEqual(removed, tt.wantRemoved) || !reflect.DeepEqual(drifts, tt.wantDrifts) {
+				t.Errorf("added %q, removed %q, drifts %q; want %q, %q, %q", added, removed, drifts, tt.wantAdded, tt.wantRemoved, tt.wantDrifts)
+			}
+		})
+	}
+}
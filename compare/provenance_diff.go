@@ -0,0 +1,148 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+)
+
+// EditKind classifies a difference between a submitted SBOM and the SBOM
+// regenerated from the same source.
+type EditKind string
+
+const (
+	// EditAddedWithoutEvidence is a component only the submitted SBOM
+	// declares, with no evidence of how a tool found it: the typical trace of
+	// a hand-written entry.
+	EditAddedWithoutEvidence EditKind = "added-without-evidence"
+	// EditAddedWithEvidence is a component only the submitted SBOM declares
+	// that carries evidence, e.g., found by a tool or a scan the regeneration
+	// did not run.
+	EditAddedWithEvidence EditKind = "added-with-evidence"
+	// EditRemoved is a component the regenerated SBOM declares that the
+	// submitted one leaves out.
+	EditRemoved EditKind = "removed"
+	// EditVersionChanged is a component whose version differs.
+	EditVersionChanged EditKind = "version-changed"
+	// EditLicenseChanged is a component whose declared licenses differ.
+	EditLicenseChanged EditKind = "license-changed"
+)
+
+// Edit is a difference between the submitted SBOM and the regenerated one
+// that a manual edit would explain.
+type Edit struct {
+	Kind EditKind `json:"kind"`
+	Key  string   `json:"key"`
+	// Submitted and Regenerated are the component as each SBOM declares it,
+	// or nil if it is not in that SBOM.
+	Submitted   *Component `json:"submitted,omitempty"`
+	Regenerated *Component `json:"regenerated,omitempty"`
+	// Suspicious reports that the edit is one a tamper review should look
+	// at; every kind is, except components added with evidence.
+	Suspicious bool   `json:"suspicious"`
+	Message    string `json:"message"`
+}
+
+// ProvenanceReport is the outcome of `ProvenanceDiff`. Edits are sorted by
+// key, then kind.
+type ProvenanceReport struct {
+	Edits []Edit `json:"edits,omitempty"`
+	// Unchanged is the number of components both SBOMs declare alike.
+	Unchanged int `json:"unchanged"`
+	// Unidentified is the number of components, across both SBOMs, that could
+	// not be compared.
+	Unidentified int `json:"unidentified,omitempty"`
+}
+
+// Tampered reports whether the submitted SBOM has suspicious edits.
+func (r *ProvenanceReport) Tampered() bool {
+	return len(r.Suspicious()) > 0
+}
+
+// Suspicious returns the edits a tamper review should look at.
+func (r *ProvenanceReport) Suspicious() []Edit {
+	var edits []Edit
+	for _, e := range r.Edits {
+		if e.Suspicious {
+			edits = append(edits, e)
+		}
+	}
+	return edits
+}
+
+// ProvenanceDiff compares a submitted SBOM with an SBOM regenerated from the
+// same source by a trusted tool, to review the submitted SBOM for manual
+// edits: components added without evidence of how they were found, versions
+// and licenses changed, and components left out. The SBOMs may be in any
+// encodings and formats `SBOMs` accepts. Neither is validated.
+//
+// Parameters:
+//   - regenerated: The SBOM regenerated from the source.
+//   - submitted: The SBOM under review.
+//   - cfg: The identity resolvers and decoding options.
+//
+// Returns:
+//   - The ProvenanceReport.
+//   - An error if either SBOM cannot be decoded or its type is unsupported.
+//
+// Example:
+//
+//	report, err := compare.ProvenanceDiff(regenerated, submitted, compare.Config{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, e := range report.Suspicious() {
+//	    fmt.Printf("%s: %s\n", e.Kind, e.Message)
+//	}
+func ProvenanceDiff(regenerated, submitted []byte, cfg Config) (*ProvenanceReport, error) {
+	diff, err := SBOMs(regenerated, submitted, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return provenanceReport(diff), nil
+}
+
+// provenanceReport classifies the differences from the regenerated SBOM
+// (before) to the submitted one (after) as edits.
+func provenanceReport(diff *Report) *ProvenanceReport {
+	report := &ProvenanceReport{Unchanged: diff.Unchanged, Unidentified: diff.Unidentified}
+	add := func(kind EditKind, key string, submitted, regenerated *Component, message string) {
+		report.Edits = append(report.Edits, Edit{
+			Kind:        kind,
+			Key:         key,
+			Submitted:   submitted,
+			Regenerated: regenerated,
+			Suspicious:  kind != EditAddedWithEvidence,
+			Message:     message,
+		})
+	}
+
+	for i := range diff.Added {
+		c := &diff.Added[i]
+		if c.Evidence {
+			add(EditAddedWithEvidence, c.Key, c, nil, fmt.Sprintf("%s %s is not in the regenerated SBOM, but carries evidence", c.Name, c.Version))
+		} else {
+			add(EditAddedWithoutEvidence, c.Key, c, nil, fmt.Sprintf("%s %s is not in the regenerated SBOM and carries no evidence", c.Name, c.Version))
+		}
+	}
+	for i := range diff.Removed {
+		c := &diff.Removed[i]
+		add(EditRemoved, c.Key, nil, c, fmt.Sprintf("%s %s is in the regenerated SBOM but not in the submitted one", c.Name, c.Version))
+	}
+	for i := range diff.Changed {
+		c := &diff.Changed[i]
+		if c.VersionDrift {
+			add(EditVersionChanged, c.Key, &c.After, &c.Before, fmt.Sprintf("%s is version %s, but the regenerated SBOM has %s", c.After.Name, c.After.Version, c.Before.Version))
+		}
+		if c.LicenseChange {
+			add(EditLicenseChanged, c.Key, &c.After, &c.Before, fmt.Sprintf("%s declares licenses %v, but the regenerated SBOM has %v", c.After.Name, c.After.Licenses, c.Before.Licenses))
+		}
+	}
+
+	sort.SliceStable(report.Edits, func(i, j int) bool {
+		if report.Edits[i].Key != report.Edits[j].Key {
+			return report.Edits[i].Key < report.Edits[j].Key
+		}
+		return report.Edits[i].Kind < report.Edits[j].Kind
+	})
+	return report
+}
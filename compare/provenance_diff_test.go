@@ -0,0 +1,76 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+)
+
+const regeneratedCycloneDX = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "components": [
+    {"name": "left-pad", "version": "1.3.0", "purl": "pkg:npm/left-pad@1.3.0", "licenses": [{"license": {"id": "MIT"}}]},
+    {"name": "lodash", "version": "4.17.20", "purl": "pkg:npm/lodash@4.17.20", "licenses": [{"license": {"id": "MIT"}}]},
+    {"name": "minimist", "version": "1.2.8", "purl": "pkg:npm/minimist@1.2.8", "licenses": [{"license": {"id": "MIT"}}]}
+  ]
+}`
+
+const submittedCycloneDX = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "components": [
+    {"name": "left-pad", "version": "1.3.0", "purl": "pkg:npm/left-pad@1.3.0", "licenses": [{"license": {"id": "MIT"}}]},
+    {"name": "lodash", "version": "4.17.21", "purl": "pkg:npm/lodash@4.17.21", "licenses": [{"license": {"id": "Apache-2.0"}}]},
+    {"name": "chalk", "version": "5.3.0", "purl": "pkg:npm/chalk@5.3.0"},
+    {"name": "vendored-zlib", "version": "1.3.1", "purl": "pkg:generic/vendored-zlib@1.3.1",
+     "evidence": {"occurrences": [{"location": "third_party/zlib/zlib.h"}]}}
+  ]
+}`
+
+func TestProvenanceDiff(t *testing.T) {
+	report, err := ProvenanceDiff([]byte(regeneratedCycloneDX), []byte(submittedCycloneDX), Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var edits []string
+	for _, e := range report.Edits {
+		edits = append(edits, string(e.Kind)+" "+e.Key)
+	}
+	want := []string{
+		"added-with-evidence pkg:generic/vendored-zlib",
+		"added-without-evidence pkg:npm/chalk",
+		"license-changed pkg:npm/lodash",
+		"version-changed pkg:npm/lodash",
+		"removed pkg:npm/minimist",
+	}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("edits = %q, want %q", edits, want)
+	}
+	if !report.Tampered() || len(report.Suspicious()) != 4 || report.Unchanged != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	version := report.Edits[3]
+	if version.Submitted.Version != "4.17.21" || version.Regenerated.Version != "4.17.20" ||
+		version.Message != "lodash is version 4.17.21, but the regenerated SBOM has 4.17.20" {
+		t.Errorf("unexpected version edit: %+v", version)
+	}
+	if added := report.Edits[1]; added.Submitted == nil || added.Regenerated != nil {
+		t.Errorf("unexpected added edit: %+v", added)
+	}
+}
+
+func TestProvenanceDiffUntampered(t *testing.T) {
+	report, err := ProvenanceDiff([]byte(regeneratedCycloneDX), []byte(regeneratedCycloneDX), Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Tampered() || len(report.Edits) != 0 || report.Unchanged != 3 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	if _, err := ProvenanceDiff([]byte(`not an SBOM`), []byte(regeneratedCycloneDX), Config{}); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,212 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ComparisonOutcome summarizes how faithfully a convert or merge operation
+// carried its inputs over to its output.
+type ComparisonOutcome string
+
+const (
+	// ComparisonClean means the output is valid and retains every input component.
+	ComparisonClean ComparisonOutcome = "clean"
+	// ComparisonConflict means inputs disagreed about a component, so the
+	// output had to pick one side.
+	ComparisonConflict ComparisonOutcome = "conflict"
+	// ComparisonDataLoss means components or their licenses were dropped.
+	ComparisonDataLoss ComparisonOutcome = "data-loss"
+	// ComparisonInvalidOutput means the output does not validate.
+	ComparisonInvalidOutput ComparisonOutcome = "invalid-output"
+)
+
+// Exit codes for each ComparisonOutcome, so pipelines can distinguish
+// "converted cleanly" from "converted with data loss". Exit codes 1 and 2 are
+// left to the program, as the example CLI uses them for invalid SBOMs and
+// operational errors (unreadable files, unsupported formats).
+const (
+	ExitComparisonClean         = 0
+	ExitComparisonInvalidOutput = 3
+	ExitComparisonDataLoss      = 4
+	ExitComparisonConflict      = 5
+)
+
+// ComponentLoss describes an input component, or part of one, that is missing
+// from the output.
+type ComponentLoss struct {
+	Key    string `json:"key"`
+	Input  int    `json:"input"`
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// ComponentConflict describes a component that several inputs declare with
+// different values for the same field.
+type ComponentConflict struct {
+	Key    string   `json:"key"`
+	Field  string   `json:"field"`
+	Values []string `json:"values"`
+}
+
+// ComparisonReport represents the outcome of CompareConversion.
+//
+// `Outcome` is the most severe finding: an invalid output outranks data loss,
+// which outranks conflicts. Use `ExitCode` to turn it into a process exit code.
+type ComparisonReport struct {
+	Outcome    ComparisonOutcome   `json:"outcome"`
+	Output     *ValidationResult   `json:"output"`
+	Losses     []ComponentLoss     `json:"losses,omitempty"`
+	Conflicts  []ComponentConflict `json:"conflicts,omitempty"`
+	InputCount int                 `json:"inputCount"`
+}
+
+// ExitCode returns the exit code matching the report's outcome.
+func (r *ComparisonReport) ExitCode() int {
+	switch r.Outcome {
+	case ComparisonInvalidOutput:
+		return ExitComparisonInvalidOutput
+	case ComparisonDataLoss:
+		return ExitComparisonDataLoss
+	case ComparisonConflict:
+		return ExitComparisonConflict
+	default:
+		return ExitComparisonClean
+	}
+}
+
+// CompareConversion validates the output of a convert (one input) or merge
+// (several inputs) operation and reports what was lost or in conflict.
+//
+// Components are matched across documents by purl, falling back to CPE and
+// then to name and version, so inputs and output may use different formats
+// (e.g., CycloneDX in, SPDX out). `WithIdentityResolver` replaces this
+// matching; components the resolver cannot identify are not compared. An input component is lost when the output has no component with
+// the same key; its licenses are lost when the output component declares none.
+// Inputs conflict when they declare the same component with different licenses.
+//
+// Parameters:
+//   - output: The SBOM JSON produced by the operation.
+//   - inputs: The SBOM JSON documents given to the operation.
+//   - opts: Optional settings passed on to `ValidateSBOMData` for the output,
+//     and `WithIdentityResolver`.
+//
+// Returns:
+//   - A ComparisonReport describing the outcome.
+//   - An error if any document cannot be parsed or its type is unsupported.
+//
+// Example:
+//
+//	report, err := CompareConversion(converted, [][]byte{original})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.Exit(report.ExitCode())
+func CompareConversion(output []byte, inputs [][]byte, opts ...Option) (*ComparisonReport, error) {
+	validation, err := ValidateSBOMData(output, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate output: %v", err)
+	}
+
+	resolver := newValidationOptions(opts).identityResolver()
+	outputComponents, err := componentsByKey(output, resolver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output: %v", err)
+	}
+
+	report := &ComparisonReport{Output: validation, InputCount: len(inputs)}
+
+	// licenses declared for each key, per input, to detect merge conflicts
+	declared := map[string]map[string]bool{}
+	var keys []string
+
+	for i, input := range inputs {
+		inputComponents, err := componentsByKey(input, resolver)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input %d: %v", i, err)
+		}
+
+		for _, key := range sortedComponentKeys(inputComponents) {
+			component := inputComponents[key]
+
+			if len(component.Licenses) > 0 {
+				if declared[key] == nil {
+					declared[key] = map[string]bool{}
+					keys = append(keys, key)
+				}
+				declared[key][strings.Join(licenseIdentifiers(component.Licenses), " ")] = true
+			}
+
+			converted, ok := outputComponents[key]
+			if !ok {
+				report.Losses = append(report.Losses, ComponentLoss{
+					Key: key, Input: i, Field: "component", Detail: "component missing from output",
+				})
+				continue
+			}
+			if len(component.Licenses) > 0 && len(converted.Licenses) == 0 {
+				report.Losses = append(report.Losses, ComponentLoss{
+					Key: key, Input: i, Field: "licenses",
+					Detail: fmt.Sprintf("declared %v, output has none", component.Licenses),
+				})
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if len(declared[key]) < 2 {
+			continue
+		}
+		values := make([]string, 0, len(declared[key]))
+		for value := range declared[key] {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		report.Conflicts = append(report.Conflicts, ComponentConflict{Key: key, Field: "licenses", Values: values})
+	}
+
+	switch {
+	case !validation.IsValid:
+		report.Outcome = ComparisonInvalidOutput
+	case len(report.Losses) > 0:
+		report.Outcome = ComparisonDataLoss
+	case len(report.Conflicts) > 0:
+		report.Outcome = ComparisonConflict
+	default:
+		report.Outcome = ComparisonClean
+	}
+
+	return report, nil
+}
+
+// componentsByKey indexes the components of an SBOM by the identity the
+// resolver gives them. When several components share an identity, the first
+// one wins; components without one are left out.
+func componentsByKey(content []byte, resolver IdentityResolver) (map[string]sbomComponent, error) {
+	doc, err := parseSBOMDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	components := map[string]sbomComponent{}
+	for _, component := range extractComponents(doc.obj, doc.sbomType) {
+		key := resolver.ResolveIdentity(component.descriptor())
+		if key == "" {
+			continue
+		}
+		if _, ok := components[key]; !ok {
+			components[key] = component
+		}
+	}
+	return components, nil
+}
+
+func sortedComponentKeys(components map[string]sbomComponent) []string {
+	keys := make([]string, 0, len(components))
+	for key := range components {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"testing"
+)
+
+func TestCompareConversion(t *testing.T) {
+	tests := []struct {
+		name          string
+		output        []byte
+		inputs        [][]byte
+		wantOutcome   ComparisonOutcome
+		wantExitCode  int
+		wantLosses    int
+		wantConflicts int
+	}{
+		{
+			name:         "Clean conversion",
+			output:       spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "ISC")),
+			inputs:       [][]byte{spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "ISC"))},
+			wantOutcome:  ComparisonClean,
+			wantExitCode: ExitComparisonClean,
+		},
+		{
+			name:         "Dropped component",
+			output:       spdxDocument(spdxPackage("a", "a", "MIT")),
+			inputs:       [][]byte{spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "ISC"))},
+			wantOutcome:  ComparisonDataLoss,
+			wantExitCode: ExitComparisonDataLoss,
+			wantLosses:   1,
+		},
+		{
+			name:         "Dropped license",
+			output:       spdxDocument(spdxPackage("a", "a", "NOASSERTION")),
+			inputs:       [][]byte{spdxDocument(spdxPackage("a", "a", "MIT"))},
+			wantOutcome:  ComparisonDataLoss,
+			wantExitCode: ExitComparisonDataLoss,
+			wantLosses:   1,
+		},
+		{
+			name:   "Merge conflict",
+			output: spdxDocument(spdxPackage("a", "a", "MIT")),
+			inputs: [][]byte{
+				spdxDocument(spdxPackage("a", "a", "MIT")),
+				spdxDocument(spdxPackage("a", "a", "Apache-2.0")),
+			},
+			wantOutcome:   ComparisonConflict,
+			wantExitCode:  ExitComparisonConflict,
+			wantConflicts: 1,
+		},
+		{
+			name:         "Invalid output",
+			output:       []byte(`{"spdxVersion": "SPDX-2.3", "packages": []}`),
+			inputs:       [][]byte{spdxDocument()},
+			wantOutcome:  ComparisonInvalidOutput,
+			wantExitCode: ExitComparisonInvalidOutput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := CompareConversion(tt.output, tt.inputs)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if report.Outcome != tt.wantOutcome {
+				t.Errorf("Outcome = %q, want %q (output errors: %v)", report.Outcome, tt.wantOutcome, report.Output.ValidationErrors)
+			}
+			if report.ExitCode() != tt.wantExitCode {
+				t.Errorf("ExitCode() = %d, want %d", report.ExitCode(), tt.wantExitCode)
+			}
+			if len(report.Losses) != tt.wantLosses {
+				t.Errorf("Losses = %v, want %d", report.Losses, tt.wantLosses)
+			}
+			if len(report.Conflicts) != tt.wantConflicts {
+				t.Errorf("Conflicts = %v, want %d", report.Conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestCompareConversionInvalidInput(t *testing.T) {
+	if _, err := CompareConversion(spdxDocument(), [][]byte{[]byte("not json")}); err == nil {
+		t.Errorf("expected an error for a malformed input")
+	}
+}
@@ -0,0 +1,195 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// NameCluster groups components of one SBOM that are probably the same
+// package but are named inconsistently, which inflates inventory counts.
+type NameCluster struct {
+	// Key is the normalized name shared by the cluster.
+	Key string `json:"key"`
+	// Names are the distinct spellings, in document order. A CycloneDX
+	// component with a group is spelled "group/name".
+	Names []string `json:"names"`
+	// Paths are the JSON paths of every component in the cluster.
+	Paths []string `json:"paths"`
+}
+
+// ComponentNameClusters reports clusters of components that are likely the
+// same package spelled differently within one SBOM.
+//
+// Names are compared case-insensitively, with "-", "_", "." and spaces treated
+// alike and a leading "@" ignored. A name is split on "/" and ":" and compared
+// together with its group (CycloneDX) or, when it has none, its purl
+// namespace, in any order, so "org.apache.commons:commons-lang3", group
+// "org.apache.commons" with name "commons-lang3", and the group and name
+// swapped all match. Components spelled identically are not a cluster on their
+// own, e.g., two versions of the same package.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM JSON data.
+//
+// Returns:
+//   - The clusters, in order of their first component.
+//   - An error if the SBOM cannot be parsed or its type is unsupported.
+//
+// Example:
+//
+//	clusters, err := ComponentNameClusters(data)
+//	for _, c := range clusters {
+//	    fmt.Printf("%s: %s\n", c.Key, strings.Join(c.Names, ", "))
+//	}
+func ComponentNameClusters(sbomContent []byte) ([]NameCluster, error) {
+	doc, err := parseSBOMDocument(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+	return findNameClusters(doc.obj, doc.sbomType), nil
+}
+
+// checkComponentNaming returns a warning per name cluster, prefixed with the
+// JSON path of its first component.
+func checkComponentNaming(obj map[string]interface{}, sbomType string) []string {
+	var warnings []string
+	for _, cluster := range findNameClusters(obj, sbomType) {
+		var others []string
+		for _, name := range cluster.Names[1:] {
+			others = append(others, fmt.Sprintf("%q", name))
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"%s: %q is also named %s elsewhere in the SBOM (%d components); use one spelling so the package is counted once",
+			cluster.Paths[0], cluster.Names[0], strings.Join(others, ", "), len(cluster.Paths)))
+	}
+	return warnings
+}
+
+// findNameClusters groups the components of an SBOM by normalized name and
+// returns the groups with more than one spelling.
+func findNameClusters(obj map[string]interface{}, sbomType string) []NameCluster {
+	var clusters []*NameCluster
+	byKey := map[string]*NameCluster{}
+
+	add := func(path, group, name, purl string) {
+		if strings.TrimSpace(name) == "" {
+			return
+		}
+
+		display := name
+		if group != "" {
+			display = group + "/" + name
+		} else if parsed, err := parsePackageURL(purl); err == nil {
+			group = parsed.Namespace
+		}
+
+		key := normalizedComponentName(group, name)
+		cluster, ok := byKey[key]
+		if !ok {
+			cluster = &NameCluster{Key: key}
+			byKey[key] = cluster
+			clusters = append(clusters, cluster)
+		}
+		if !slices.Contains(cluster.Names, display) {
+			cluster.Names = append(cluster.Names, display)
+		}
+		cluster.Paths = append(cluster.Paths, path)
+	}
+
+	if sbomType == SBOM_CYCLONEDX {
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			group, _ := component["group"].(string)
+			name, _ := component["name"].(string)
+			purl, _ := component["purl"].(string)
+			add(path, group, name, purl)
+		})
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		packages, _ := obj["packages"].([]interface{})
+		for i, p := range packages {
+			pkg, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := pkg["name"].(string)
+			add(fmt.Sprintf("packages.%d", i), "", name, spdxPackagePURL(pkg))
+		}
+	}
+
+	var result []NameCluster
+	for _, cluster := range clusters {
+		if len(cluster.Names) > 1 {
+			result = append(result, *cluster)
+		}
+	}
+	return result
+}
+
+// normalizedComponentName returns the key under which differently spelled
+// names of the same package compare equal.
+func normalizedComponentName(group, name string) string {
+	var parts []string
+	for _, s := range []string{group, name} {
+		for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '/' || r == ':' }) {
+			if part = normalizeNamePart(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "/")
+}
+
+// normalizeNamePart lowercases a name segment, drops a leading "@" and
+// replaces runs of "-", "_", "." and whitespace with a single "-".
+func normalizeNamePart(part string) string {
+	part = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(part)), "@")
+
+	var b strings.Builder
+	separator := false
+	for _, r := range part {
+		if r == '-' || r == '_' || r == '.' || r == ' ' || r == '\t' {
+			separator = true
+			continue
+		}
+		if separator && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		separator = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// spdxPackagePURL returns the purl external reference of an SPDX package, or
+// "" if it has none.
+func spdxPackagePURL(pkg map[string]interface{}) string {
+	return spdxExternalRef(pkg, "purl")
+}
+
+// spdxPackageCPE returns the CPE of an SPDX package from its externalRefs,
+// preferring CPE 2.3 over CPE 2.2, or "" if it has none.
+func spdxPackageCPE(pkg map[string]interface{}) string {
+	if cpe := spdxExternalRef(pkg, "cpe23Type"); cpe != "" {
+		return cpe
+	}
+	return spdxExternalRef(pkg, "cpe22Type")
+}
+
+// spdxExternalRef returns the locator of the first external reference of an
+// SPDX package with the given type.
+func spdxExternalRef(pkg map[string]interface{}, referenceType string) string {
+	refs, _ := pkg["externalRefs"].([]interface{})
+	for _, r := range refs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if refType, _ := ref["referenceType"].(string); refType == referenceType {
+			locator, _ := ref["referenceLocator"].(string)
+			return locator
+		}
+	}
+	return ""
+}
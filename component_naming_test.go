@@ -0,0 +1,106 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizedComponentName(t *testing.T) {
+	tests := []struct {
+		name  string
+		group string
+		cname string
+		want  string
+	}{
+		{name: "Case and separators", cname: "Jackson_Core", want: "jackson-core"},
+		{name: "Dots and repeated separators", cname: "jackson..core", want: "jackson-core"},
+		{name: "Group and name", group: "org.apache.commons", cname: "commons-lang3", want: "commons-lang3/org-apache-commons"},
+		{name: "Maven coordinate in name", cname: "org.apache.commons:commons-lang3", want: "commons-lang3/org-apache-commons"},
+		{name: "Group and name swapped", group: "commons-lang3", cname: "org.apache.commons", want: "commons-lang3/org-apache-commons"},
+		{name: "npm scope", cname: "@Acme/Billing", want: "acme/billing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizedComponentName(tt.group, tt.cname); got != tt.want {
+				t.Errorf("normalizedComponentName(%q, %q) = %q, want %q", tt.group, tt.cname, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindNameClusters(t *testing.T) {
+	cyclonedx, _ := parseJSON(`{"bomFormat": "CycloneDX", "components": [
+		{"name": "jackson-core", "version": "2.15.0"},
+		{"name": "lodash"},
+		{"name": "app", "components": [{"name": "Jackson_Core", "version": "2.15.0"}]},
+		{"name": "jackson-core", "version": "2.16.0"},
+		{"group": "org.apache.commons", "name": "commons-lang3"},
+		{"name": "commons-lang3", "purl": "pkg:maven/org.apache.commons/commons-lang3@3.14.0"},
+		{"name": "lodash", "version": "4.17.21"}
+	]}`)
+
+	got := findNameClusters(cyclonedx, SBOM_CYCLONEDX)
+	want := []NameCluster{
+		{
+			Key:   "jackson-core",
+			Names: []string{"jackson-core", "Jackson_Core"},
+			Paths: []string{"components.0", "components.2.components.0", "components.3"},
+		},
+		{
+			Key:   "commons-lang3/org-apache-commons",
+			Names: []string{"org.apache.commons/commons-lang3", "commons-lang3"},
+			Paths: []string{"components.4", "components.5"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findNameClusters() = %+v, want %+v", got, want)
+	}
+
+	spdx, _ := parseJSON(string(spdxDocument(
+		spdxPackage("a", "left-pad", "MIT"),
+		spdxPackage("b", "Left_Pad", "MIT"),
+		spdxPackage("c", "right-pad", "MIT"),
+	)))
+	clusters := findNameClusters(spdx, SBOM_SPDX)
+	if len(clusters) != 1 || !reflect.DeepEqual(clusters[0].Paths, []string{"packages.0", "packages.1"}) {
+		t.Errorf("findNameClusters() = %+v, want one cluster of packages.0 and packages.1", clusters)
+	}
+}
+
+func TestComponentNamingOption(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "left-pad", "MIT"), spdxPackage("b", "Left_Pad", "MIT"))
+
+	result, err := ValidateSBOMDataStructured(sbom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("expected no warnings without the option, got %v", result.Warnings)
+	}
+
+	result, err = ValidateSBOMDataStructured(sbom, WithComponentNaming(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Errorf("expected naming warnings not to invalidate the SBOM, got %v", result.ValidationErrors)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], `"left-pad" is also named "Left_Pad"`) {
+		t.Fatalf("expected one naming warning, got %v", result.Warnings)
+	}
+	if result.Findings[len(result.Findings)-1].Rule != RuleComponentNaming {
+		t.Errorf("expected a %s finding, got %+v", RuleComponentNaming, result.Findings)
+	}
+
+	clusters, err := ComponentNameClusters(sbom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(clusters) != 1 || clusters[0].Key != "left-pad" {
+		t.Errorf("ComponentNameClusters() = %+v, want one left-pad cluster", clusters)
+	}
+}
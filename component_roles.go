@@ -0,0 +1,191 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// repackagingPURLTypes are the purl types of distribution packages, which
+// repackage software from upstream projects.
+var repackagingPURLTypes = map[string]bool{
+	"deb":  true,
+	"rpm":  true,
+	"apk":  true,
+	"alpm": true,
+}
+
+// packageRegistries are names of package registries and forges, in lower
+// case. They distribute components, but are rarely their supplier.
+var packageRegistries = map[string]bool{
+	"npm":                  true,
+	"npmjs":                true,
+	"npmjs.com":            true,
+	"pypi":                 true,
+	"pypi.org":             true,
+	"python package index": true,
+	"maven central":        true,
+	"central.sonatype.com": true,
+	"crates.io":            true,
+	"rubygems":             true,
+	"rubygems.org":         true,
+	"nuget":                true,
+	"nuget.org":            true,
+	"packagist":            true,
+	"packagist.org":        true,
+	"pub.dev":              true,
+	"proxy.golang.org":     true,
+	"docker hub":           true,
+	"hub.docker.com":       true,
+	"github":               true,
+	"github.com":           true,
+	"gitlab":               true,
+	"gitlab.com":           true,
+}
+
+// roleComponent is a format-neutral view of the provenance roles of a
+// component. Originator is SPDX-only and publisher CycloneDX-only.
+type roleComponent struct {
+	path       string
+	label      string
+	supplier   string
+	originator string
+	publisher  string
+	// repackaged says why the component is repackaged software, or is "".
+	repackaged string
+}
+
+// checkComponentRoles applies the component-roles profile to a parsed SBOM:
+// the supplier, originator and publisher roles must be used consistently.
+//
+// Errors (RuleComponentRoles):
+//   - an SPDX supplier or originator that is not "Person: ...",
+//     "Organization: ..." or NOASSERTION;
+//   - repackaged software (a distribution purl, or CycloneDX
+//     pedigree.ancestors) without its upstream origin: an SPDX originator, or
+//     a CycloneDX supplier naming the repackager.
+//
+// Warnings (RuleRoleConfusion), heuristics for likely role confusion:
+//   - a supplier, originator or publisher that names a package registry;
+//   - repackaged software whose supplier and originator are the same;
+//   - an originator (SPDX) or publisher (CycloneDX) without a supplier, which
+//     often means the supplier was recorded in the wrong field.
+func checkComponentRoles(obj map[string]interface{}, sbomType string) []Finding {
+	var findings []Finding
+	add := func(level FindingLevel, rule, path, message string) {
+		findings = append(findings, Finding{Level: level, Rule: rule, Path: path, Pointer: jsonPointer(path), Message: message})
+	}
+
+	spdx := strings.HasPrefix(sbomType, SBOM_SPDX)
+	for _, c := range roleComponents(obj, sbomType) {
+		if spdx {
+			for _, role := range []struct{ field, value string }{{"supplier", c.supplier}, {"originator", c.originator}} {
+				if role.value != "" && role.value != "NOASSERTION" && !strings.HasPrefix(role.value, "Person: ") && !strings.HasPrefix(role.value, "Organization: ") {
+					add(LevelError, RuleComponentRoles, c.path+"."+role.field,
+						fmt.Sprintf(`%s %q must be "Person: <name>", "Organization: <name>" or NOASSERTION`, role.field, role.value))
+				}
+			}
+		}
+
+		if c.repackaged != "" {
+			switch {
+			case spdx && (c.originator == "" || c.originator == "NOASSERTION"):
+				add(LevelError, RuleComponentRoles, c.path,
+					fmt.Sprintf("%s is repackaged (%s), so originator must name the upstream author", c.label, c.repackaged))
+			case !spdx && c.supplier == "":
+				add(LevelError, RuleComponentRoles, c.path,
+					fmt.Sprintf("%s is repackaged (%s), so supplier must name the repackager", c.label, c.repackaged))
+			case spdx && c.supplier != "" && roleName(c.supplier) == roleName(c.originator):
+				add(LevelWarning, RuleRoleConfusion, c.path+".originator",
+					fmt.Sprintf("supplier and originator are both %q; for repackaged software the originator is the upstream author and the supplier the repackager",
+						roleName(c.supplier)))
+			}
+		}
+
+		for _, role := range []struct{ field, value string }{{"supplier", c.supplier}, {"originator", c.originator}, {"publisher", c.publisher}} {
+			if name := roleName(role.value); packageRegistries[strings.ToLower(name)] {
+				add(LevelWarning, RuleRoleConfusion, c.path+"."+role.field,
+					fmt.Sprintf("%s %q is a package registry, which distributes the component but does not supply it; name the organization or person that maintains it",
+						role.field, name))
+			}
+		}
+
+		if c.supplier == "" || c.supplier == "NOASSERTION" {
+			if spdx && c.originator != "" && c.originator != "NOASSERTION" && c.repackaged == "" {
+				add(LevelWarning, RuleRoleConfusion, c.path+".originator",
+					fmt.Sprintf("originator %q is set but supplier is not; if %s also distributes the package, it is its supplier", roleName(c.originator), roleName(c.originator)))
+			}
+			if !spdx && c.publisher != "" {
+				add(LevelWarning, RuleRoleConfusion, c.path+".publisher",
+					fmt.Sprintf("publisher %q is set but supplier is not; if %s distributes the component, it is its supplier", c.publisher, c.publisher))
+			}
+		}
+	}
+
+	return findings
+}
+
+// roleName returns the name in an SPDX "Person: <name> (<email>)" or
+// "Organization: <name>" value, or the value itself.
+func roleName(value string) string {
+	for _, prefix := range []string{"Person:", "Organization:"} {
+		if rest, ok := strings.CutPrefix(value, prefix); ok {
+			value = rest
+			break
+		}
+	}
+	if i := strings.Index(value, "("); i > 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
+// roleComponents returns the provenance roles of every component
+// (CycloneDX, including metadata.component) or package (SPDX).
+func roleComponents(obj map[string]interface{}, sbomType string) []roleComponent {
+	var components []roleComponent
+
+	if sbomType == SBOM_CYCLONEDX {
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			name, _ := component["name"].(string)
+			c := roleComponent{path: path, label: fmt.Sprintf("component %q", name)}
+			if supplier, ok := component["supplier"].(map[string]interface{}); ok {
+				c.supplier, _ = supplier["name"].(string)
+			}
+			c.publisher, _ = component["publisher"].(string)
+
+			pedigree, _ := component["pedigree"].(map[string]interface{})
+			purl, _ := component["purl"].(string)
+			if ancestors, _ := pedigree["ancestors"].([]interface{}); len(ancestors) > 0 {
+				c.repackaged = "it has pedigree ancestors"
+			} else if purlType := repackagingPURLType(purl); purlType != "" {
+				c.repackaged = "it is a " + purlType + " package"
+			}
+			components = append(components, c)
+		})
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		packages, _ := obj["packages"].([]interface{})
+		for i, p := range packages {
+			pkg, _ := p.(map[string]interface{})
+			name, _ := pkg["name"].(string)
+			c := roleComponent{path: fmt.Sprintf("packages.%d", i), label: fmt.Sprintf("package %q", name)}
+			c.supplier, _ = pkg["supplier"].(string)
+			c.originator, _ = pkg["originator"].(string)
+			if purlType := repackagingPURLType(spdxPackagePURL(pkg)); purlType != "" {
+				c.repackaged = "it is a " + purlType + " package"
+			}
+			components = append(components, c)
+		}
+	}
+
+	return components
+}
+
+// repackagingPURLType returns the type of a distribution package purl (e.g.,
+// "deb"), or "" for other purls.
+func repackagingPURLType(purl string) string {
+	p, err := parsePackageURL(purl)
+	if err != nil || !repackagingPURLTypes[p.Type] {
+		return ""
+	}
+	return p.Type
+}
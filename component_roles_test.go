@@ -0,0 +1,139 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckComponentRolesSPDX(t *testing.T) {
+	tests := []struct {
+		name       string
+		supplier   string
+		originator string
+		purl       string
+		want       []string
+	}{
+		{
+			name:       "Repackaged with originator",
+			supplier:   "Organization: Debian",
+			originator: "Organization: Zlib project",
+			purl:       "pkg:deb/debian/zlib1g@1.2.13",
+		},
+		{
+			name:     "Repackaged without originator",
+			supplier: "Organization: Debian",
+			purl:     "pkg:deb/debian/zlib1g@1.2.13",
+			want:     []string{`error component-roles packages.0: package "pkg" is repackaged (it is a deb package), so originator must name the upstream author`},
+		},
+		{
+			name:       "Supplier and originator the same",
+			supplier:   "Organization: Debian",
+			originator: "Organization: Debian (debian@example.com)",
+			purl:       "pkg:deb/debian/zlib1g@1.2.13",
+			want: []string{`warning role-confusion packages.0.originator: supplier and originator are both "Debian"; ` +
+				"for repackaged software the originator is the upstream author and the supplier the repackager"},
+		},
+		{
+			name:     "Malformed supplier",
+			supplier: "Acme Corp",
+			purl:     "pkg:npm/left-pad@1.3.0",
+			want:     []string{`error component-roles packages.0.supplier: supplier "Acme Corp" must be "Person: <name>", "Organization: <name>" or NOASSERTION`},
+		},
+		{
+			name:     "Registry as supplier",
+			supplier: "Organization: npm",
+			purl:     "pkg:npm/left-pad@1.3.0",
+			want: []string{`warning role-confusion packages.0.supplier: supplier "npm" is a package registry, ` +
+				"which distributes the component but does not supply it; name the organization or person that maintains it"},
+		},
+		{
+			name:       "Originator without supplier",
+			supplier:   "NOASSERTION",
+			originator: "Person: Jane Doe",
+			purl:       "pkg:npm/left-pad@1.3.0",
+			want:       []string{`warning role-confusion packages.0.originator: originator "Jane Doe" is set but supplier is not; if Jane Doe also distributes the package, it is its supplier`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := map[string]interface{}{
+				"name":         "pkg",
+				"externalRefs": []interface{}{map[string]interface{}{"referenceType": "purl", "referenceLocator": tt.purl}},
+			}
+			if tt.supplier != "" {
+				pkg["supplier"] = tt.supplier
+			}
+			if tt.originator != "" {
+				pkg["originator"] = tt.originator
+			}
+			obj := map[string]interface{}{"spdxVersion": "SPDX-2.3", "packages": []interface{}{pkg}}
+			checkRoleFindings(t, checkComponentRoles(obj, "SPDX-2.3"), tt.want)
+		})
+	}
+}
+
+func TestCheckComponentRolesCycloneDX(t *testing.T) {
+	tests := []struct {
+		name      string
+		component string
+		want      []string
+	}{
+		{
+			name:      "Supplier set",
+			component: `{"name": "a", "supplier": {"name": "Acme"}, "publisher": "Acme", "purl": "pkg:npm/a@1.0.0"}`,
+		},
+		{
+			name:      "Repackaged without supplier",
+			component: `{"name": "a", "pedigree": {"ancestors": [{"name": "a-upstream"}]}}`,
+			want:      []string{`error component-roles components.0: component "a" is repackaged (it has pedigree ancestors), so supplier must name the repackager`},
+		},
+		{
+			name:      "Publisher without supplier",
+			component: `{"name": "a", "publisher": "Acme"}`,
+			want:      []string{`warning role-confusion components.0.publisher: publisher "Acme" is set but supplier is not; if Acme distributes the component, it is its supplier`},
+		},
+		{
+			name:      "Registry as publisher",
+			component: `{"name": "a", "supplier": {"name": "Acme"}, "publisher": "PyPI"}`,
+			want: []string{`warning role-confusion components.0.publisher: publisher "PyPI" is a package registry, ` +
+				"which distributes the component but does not supply it; name the organization or person that maintains it"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [` + tt.component + `]}`)
+			if err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+			checkRoleFindings(t, checkComponentRoles(obj, SBOM_CYCLONEDX), tt.want)
+		})
+	}
+}
+
+func TestProfileComponentRoles(t *testing.T) {
+	sbom := []byte(strings.Replace(string(spdxDocument(spdxPackage("a", "a", "MIT"))), `"name": "a"`, `"name": "a", "supplier": "Organization: npm"`, 1))
+
+	result, err := ValidateSBOMDataStructured(sbom, WithProfiles(ProfileComponentRoles))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	warnings := result.WarningFindings()
+	if !result.IsValid || len(warnings) != 1 || warnings[0].Rule != RuleRoleConfusion {
+		t.Errorf("expected one role confusion warning, got %+v", result.Findings)
+	}
+}
+
+func checkRoleFindings(t *testing.T, findings []Finding, want []string) {
+	t.Helper()
+	var got []string
+	for _, f := range findings {
+		got = append(got, string(f.Level)+" "+f.Rule+" "+f.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,267 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sbomComponent is a format-neutral view of a CycloneDX component or an SPDX
+// package, holding the fields shared by the cross-checks in this package.
+type sbomComponent struct {
+	Name     string
+	Version  string
+	Group    string
+	PURL     string
+	CPE      string
+	Licenses []string
+	Evidence bool
+}
+
+// ExtractComponents returns the format-neutral view of every component
+// (CycloneDX, including nested components) or package (SPDX) an SBOM declares,
+// in document order. The SBOM is not validated.
+//
+// Parameters:
+//   - sbomContent: The SBOM in any supported encoding: JSON, CycloneDX XML or
+//     protobuf, or SPDX tag-value, optionally compressed.
+//   - opts: Optional settings; only `WithContentEncoding` and
+//     `WithDecompressor` apply.
+//
+// Returns:
+//   - The components.
+//   - An error if the SBOM cannot be decoded or its type is unsupported.
+//
+// Example:
+//
+//	components, err := ExtractComponents(data)
+//	for _, c := range components {
+//	    fmt.Println(c.Name, c.Version, c.PURL)
+//	}
+func ExtractComponents(sbomContent []byte, opts ...Option) ([]ComponentDescriptor, error) {
+	jsonContent, err := sbomJSON(sbomContent, newValidationOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseSBOMDocument(jsonContent)
+	if err != nil {
+		return nil, err
+	}
+
+	components := extractComponents(doc.obj, doc.sbomType)
+	descriptors := make([]ComponentDescriptor, len(components))
+	for i, c := range components {
+		descriptors[i] = c.descriptor()
+	}
+	return descriptors, nil
+}
+
+// DecodeSBOM returns the JSON form of an SBOM, as validation sees it: the SBOM
+// is decompressed and CycloneDX XML or protobuf and SPDX tag-value are
+// converted to JSON. JSON input is returned unchanged. The SBOM is not
+// validated.
+//
+// Parameters:
+//   - sbomContent: The SBOM in any supported encoding, optionally compressed.
+//   - opts: Optional settings; only `WithContentEncoding` and
+//     `WithDecompressor` apply.
+//
+// Returns:
+//   - The SBOM JSON.
+//   - An error if the SBOM cannot be decompressed or converted.
+//
+// Example:
+//
+//	jsonContent, err := DecodeSBOM(xmlData)
+func DecodeSBOM(sbomContent []byte, opts ...Option) ([]byte, error) {
+	return sbomJSON(sbomContent, newValidationOptions(opts))
+}
+
+// sbomJSON decompresses an SBOM and converts it to its JSON form, as
+// validation does, but without reporting conversion warnings.
+func sbomJSON(content []byte, options *validationOptions) ([]byte, error) {
+	content, _, err := decompress(content, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if encoding, format := sbomEncoding(content); encoding != "" {
+		converted, _, _, err := convertEncoding(encoding, format, content)
+		return converted, err
+	}
+	return content, nil
+}
+
+// extractComponents returns every component (CycloneDX, including nested
+// components) or package (SPDX) declared in the SBOM.
+func extractComponents(obj map[string]interface{}, sbomType string) []sbomComponent {
+	if sbomType == SBOM_CYCLONEDX {
+		return cycloneDXComponents(obj)
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		return spdxComponents(obj)
+	}
+	return nil
+}
+
+func cycloneDXComponents(obj map[string]interface{}) []sbomComponent {
+	var components []sbomComponent
+
+	var walk func(list []interface{})
+	walk = func(list []interface{}) {
+		for _, c := range list {
+			component, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			entry := sbomComponent{}
+			entry.Name, _ = component["name"].(string)
+			entry.Version, _ = component["version"].(string)
+			entry.Group, _ = component["group"].(string)
+			entry.PURL, _ = component["purl"].(string)
+			entry.CPE, _ = component["cpe"].(string)
+			entry.Evidence = hasCycloneDXEvidence(component)
+
+			licenses, _ := component["licenses"].([]interface{})
+			for _, l := range licenses {
+				choice, ok := l.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if expression, ok := choice["expression"].(string); ok {
+					entry.Licenses = append(entry.Licenses, expression)
+					continue
+				}
+				license, _ := choice["license"].(map[string]interface{})
+				if id, ok := license["id"].(string); ok {
+					entry.Licenses = append(entry.Licenses, id)
+				} else if name, ok := license["name"].(string); ok {
+					entry.Licenses = append(entry.Licenses, name)
+				}
+			}
+
+			components = append(components, entry)
+
+			if nested, ok := component["components"].([]interface{}); ok {
+				walk(nested)
+			}
+		}
+	}
+
+	list, _ := obj["components"].([]interface{})
+	walk(list)
+
+	return components
+}
+
+func spdxComponents(obj map[string]interface{}) []sbomComponent {
+	var components []sbomComponent
+
+	packages, _ := obj["packages"].([]interface{})
+	for _, p := range packages {
+		pkg, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		entry := sbomComponent{}
+		entry.Name, _ = pkg["name"].(string)
+		entry.Version, _ = pkg["versionInfo"].(string)
+
+		entry.PURL = spdxPackagePURL(pkg)
+		entry.CPE = spdxPackageCPE(pkg)
+		_, entry.Evidence = pkg["packageVerificationCode"].(map[string]interface{})
+
+		for _, field := range []string{"licenseDeclared", "licenseConcluded"} {
+			license, _ := pkg[field].(string)
+			if license != "" && license != "NOASSERTION" && license != "NONE" {
+				entry.Licenses = append(entry.Licenses, license)
+				break
+			}
+		}
+
+		components = append(components, entry)
+	}
+
+	return components
+}
+
+// hasCycloneDXEvidence reports whether a CycloneDX component carries evidence
+// of how it was identified: the identity analysis or the occurrences the
+// generating tool found.
+func hasCycloneDXEvidence(component map[string]interface{}) bool {
+	evidence, _ := component["evidence"].(map[string]interface{})
+	for _, field := range []string{"identity", "occurrences", "callstack"} {
+		switch v := evidence[field].(type) {
+		case map[string]interface{}:
+			if len(v) > 0 {
+				return true
+			}
+		case []interface{}:
+			if len(v) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walkCycloneDXComponents calls fn for metadata.component and for every
+// component in a CycloneDX SBOM, including nested components, passing the JSON
+// path of each (e.g., "metadata.component" or "components.0.components.1").
+func walkCycloneDXComponents(obj map[string]interface{}, fn func(path string, component map[string]interface{})) {
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		if component, ok := metadata["component"].(map[string]interface{}); ok {
+			fn("metadata.component", component)
+		}
+	}
+
+	components, _ := obj["components"].([]interface{})
+	for i, c := range components {
+		if component, ok := c.(map[string]interface{}); ok {
+			walkCycloneDXComponent(fmt.Sprintf("components.%d", i), component, fn)
+		}
+	}
+}
+
+// walkCycloneDXComponent calls fn for a component and, recursively, for each
+// of its nested components.
+func walkCycloneDXComponent(path string, component map[string]interface{}, fn func(path string, component map[string]interface{})) {
+	fn(path, component)
+
+	nested, _ := component["components"].([]interface{})
+	for i, c := range nested {
+		if child, ok := c.(map[string]interface{}); ok {
+			walkCycloneDXComponent(fmt.Sprintf("%s.components.%d", path, i), child, fn)
+		}
+	}
+}
+
+// spdxDescribedIDs returns the SPDX IDs of the elements an SPDX document
+// describes, from documentDescribes and from DESCRIBES relationships of the
+// document.
+func spdxDescribedIDs(obj map[string]interface{}) map[string]bool {
+	described := map[string]bool{}
+
+	ids, _ := obj["documentDescribes"].([]interface{})
+	for _, id := range ids {
+		if s, ok := id.(string); ok {
+			described[s] = true
+		}
+	}
+
+	documentID, _ := obj["SPDXID"].(string)
+	if documentID == "" {
+		documentID = "SPDXRef-DOCUMENT"
+	}
+	relationships, _ := obj["relationships"].([]interface{})
+	for _, r := range relationships {
+		rel, _ := r.(map[string]interface{})
+		if rel["spdxElementId"] == documentID && rel["relationshipType"] == "DESCRIBES" {
+			if s, ok := rel["relatedSpdxElement"].(string); ok {
+				described[s] = true
+			}
+		}
+	}
+
+	return described
+}
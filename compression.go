@@ -0,0 +1,119 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Content encodings of compressed SBOMs.
+const (
+	EncodingGzip   = "gzip"
+	EncodingZstd   = "zstd"
+	EncodingBrotli = "br"
+)
+
+// maxDecompressedSize caps the size of a decompressed SBOM, so a small
+// compressed input cannot exhaust memory.
+const maxDecompressedSize = 1 << 30
+
+// compressionExtensions maps file extensions to content encodings.
+var compressionExtensions = map[string]string{
+	".gz":  EncodingGzip,
+	".zst": EncodingZstd,
+	".br":  EncodingBrotli,
+}
+
+// compressionMagic holds the leading bytes of the encodings that have them;
+// brotli streams have none and must be declared with WithContentEncoding.
+var compressionMagic = []struct {
+	encoding string
+	magic    []byte
+}{
+	{EncodingGzip, []byte{0x1f, 0x8b}},
+	{EncodingZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// Decompressor returns a reader of the decompressed content of r.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+// builtinDecompressors are the decompressors available without
+// WithDecompressor.
+var builtinDecompressors = map[string]Decompressor{
+	EncodingGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+}
+
+// EncodingForName returns the content encoding implied by a file name's
+// extension (e.g., EncodingZstd for "app.cdx.json.zst"), or "" for an
+// uncompressed name.
+func EncodingForName(name string) string {
+	return compressionExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// trimCompressionExtension removes a compression extension from a file name,
+// e.g., "app.cdx.json.zst" becomes "app.cdx.json".
+func trimCompressionExtension(name string) string {
+	if EncodingForName(name) == "" {
+		return name
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// detectEncoding returns the declared content encoding or, failing that, the
+// one identified by the content's leading bytes.
+func detectEncoding(content []byte, declared string) string {
+	if declared != "" {
+		return declared
+	}
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(content, m.magic) {
+			return m.encoding
+		}
+	}
+	return ""
+}
+
+// decompress returns the decompressed content of a compressed SBOM and its
+// encoding, or the content unchanged and "" when it is not compressed.
+func decompress(content []byte, options *validationOptions) ([]byte, string, error) {
+	encoding := detectEncoding(content, options.contentEncoding)
+	if encoding == "" || encoding == "identity" {
+		return content, "", nil
+	}
+
+	decompressor, ok := decompressorFor(encoding, options)
+	if !ok {
+		return nil, encoding, fmt.Errorf("no decompressor for %s-compressed SBOM; register one with WithDecompressor", encoding)
+	}
+
+	r, err := decompressor(bytes.NewReader(content))
+	if err != nil {
+		return nil, encoding, fmt.Errorf("failed to decompress %s SBOM: %v", encoding, err)
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(r, maxDecompressedSize+1))
+	if err != nil {
+		return nil, encoding, fmt.Errorf("failed to decompress %s SBOM: %v", encoding, err)
+	}
+	if n > maxDecompressedSize {
+		return nil, encoding, fmt.Errorf("decompressed %s SBOM exceeds %d bytes", encoding, maxDecompressedSize)
+	}
+	return buf.Bytes(), encoding, nil
+}
+
+// decompressorFor returns the decompressor of an encoding, registered with
+// WithDecompressor or built in.
+func decompressorFor(encoding string, options *validationOptions) (Decompressor, bool) {
+	if decompressor, ok := options.decompressors[encoding]; ok {
+		return decompressor, true
+	}
+	decompressor, ok := builtinDecompressors[encoding]
+	return decompressor, ok
+}
@@ -0,0 +1,38 @@
+//go:build !sbomvalidator_no_compression
+
+package sbomvalidator
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdMaxWindowSize caps the window a zstd frame may declare, so a crafted
+// header cannot make the decoder allocate beyond maxDecompressedSize.
+const zstdMaxWindowSize = 64 << 20
+
+func init() {
+	builtinDecompressors[EncodingZstd] = func(r io.Reader) (io.Reader, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstdMaxWindowSize))
+		if err != nil {
+			return nil, err
+		}
+		return zstdReader{d}, nil
+	}
+	builtinDecompressors[EncodingBrotli] = func(r io.Reader) (io.Reader, error) {
+		return brotli.NewReader(r), nil
+	}
+}
+
+// zstdReader releases the resources of a zstd decoder when closed, since its
+// Close method does not return an error and so is not an io.Closer.
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r zstdReader) Close() error {
+	r.Decoder.Close()
+	return nil
+}
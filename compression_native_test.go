@@ -0,0 +1,82 @@
+//go:build !sbomvalidator_no_spdx && !sbomvalidator_no_compression
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestValidateSBOMDataNativeDecompressors(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("Failed to create zstd encoder: %v", err)
+	}
+	zstdSBOM := encoder.EncodeAll(sbom, nil)
+	encoder.Close()
+
+	var brotliSBOM bytes.Buffer
+	w := brotli.NewWriter(&brotliSBOM)
+	w.Write(sbom)
+	w.Close()
+
+	tests := []struct {
+		name            string
+		content         []byte
+		opts            []Option
+		wantCompression string
+		wantErr         string
+	}{
+		{
+			name:            "Zstd",
+			content:         zstdSBOM,
+			wantCompression: EncodingZstd,
+		},
+		{
+			name:            "Declared brotli",
+			content:         brotliSBOM.Bytes(),
+			opts:            []Option{WithContentEncoding(EncodingBrotli)},
+			wantCompression: EncodingBrotli,
+		},
+		{
+			name:            "Corrupt zstd",
+			content:         []byte{0x28, 0xb5, 0x2f, 0xfd, 0, 0},
+			wantCompression: EncodingZstd,
+			wantErr:         "failed to decompress zstd SBOM",
+		},
+		{
+			name:            "Corrupt brotli",
+			content:         sbom,
+			opts:            []Option{WithContentEncoding(EncodingBrotli)},
+			wantCompression: EncodingBrotli,
+			wantErr:         "failed to decompress br SBOM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMData(tt.content, tt.opts...)
+			if result.Compression != tt.wantCompression {
+				t.Errorf("Compression = %q, want %q", result.Compression, tt.wantCompression)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !result.IsValid {
+				t.Errorf("Expected a valid SBOM, got %v", result.ValidationErrors)
+			}
+		})
+	}
+}
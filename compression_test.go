@@ -0,0 +1,134 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// reversed is a stand-in decompressor for encodings without a native one.
+func reversed(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[len(data)-1-i] = b
+	}
+	return bytes.NewReader(out), nil
+}
+
+func reverse(data []byte) []byte {
+	r, _ := reversed(bytes.NewReader(data))
+	out, _ := io.ReadAll(r)
+	return out
+}
+
+func TestValidateSBOMDataCompressed(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+	zstdMagic := []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	tests := []struct {
+		name            string
+		content         []byte
+		opts            []Option
+		wantCompression string
+		wantErr         string
+	}{
+		{
+			name:    "Uncompressed",
+			content: sbom,
+		},
+		{
+			name:            "Gzip",
+			content:         gzipped(t, sbom),
+			wantCompression: EncodingGzip,
+		},
+		{
+			name:            "Declared encoding without decompressor",
+			content:         sbom,
+			opts:            []Option{WithContentEncoding("compress")},
+			wantCompression: "compress",
+			wantErr:         "no decompressor for compress-compressed SBOM",
+		},
+		{
+			name:    "Zstd with registered decompressor",
+			content: append(zstdMagic, sbom...),
+			opts: []Option{WithDecompressor(EncodingZstd, func(r io.Reader) (io.Reader, error) {
+				r.Read(make([]byte, len(zstdMagic)))
+				return r, nil
+			})},
+			wantCompression: EncodingZstd,
+		},
+		{
+			name:            "Declared brotli",
+			content:         reverse(sbom),
+			opts:            []Option{WithContentEncoding(EncodingBrotli), WithDecompressor(EncodingBrotli, reversed)},
+			wantCompression: EncodingBrotli,
+		},
+		{
+			name:            "Corrupt gzip",
+			content:         []byte{0x1f, 0x8b, 0, 0},
+			wantCompression: EncodingGzip,
+			wantErr:         "failed to decompress gzip SBOM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMData(tt.content, tt.opts...)
+			if result.Compression != tt.wantCompression {
+				t.Errorf("Compression = %q, want %q", result.Compression, tt.wantCompression)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !result.IsValid {
+				t.Errorf("Expected a valid SBOM, got %v", result.ValidationErrors)
+			}
+		})
+	}
+}
+
+func TestEncodingForName(t *testing.T) {
+	tests := map[string]string{
+		"app.cdx.json":     "",
+		"app.cdx.json.gz":  EncodingGzip,
+		"app.cdx.json.ZST": EncodingZstd,
+		"app.spdx.br":      EncodingBrotli,
+	}
+	for name, want := range tests {
+		if got := EncodingForName(name); got != want {
+			t.Errorf("EncodingForName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidateSBOMDirCompressed(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+	dir := fstest.MapFS{
+		"a.spdx.json.gz":  {Data: gzipped(t, sbom)},
+		"b.spdx.json.br":  {Data: reverse(sbom)},
+		"c.spdx.json.zst": {Data: []byte{0x28, 0xb5, 0x2f, 0xfd}},
+		"notes.txt.gz":    {Data: gzipped(t, []byte("ignored"))},
+	}
+
+	batch, err := ValidateSBOMDir(dir, WithDecompressor(EncodingBrotli, reversed))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch.Summary.Total != 3 || batch.Summary.Valid != 2 || batch.Summary.Failed != 1 {
+		t.Errorf("Unexpected summary: %+v", batch.Summary)
+	}
+}
@@ -0,0 +1,196 @@
+// Package conformance measures how closely sbom-validator follows the
+// CycloneDX specification, by validating the test corpus the specification
+// publishes: for each spec version, documents that must be accepted
+// ("valid-*.json") and documents that must be rejected ("invalid-*.json").
+//
+// The corpus is vendored in corpus/, with its upstream commit in
+// corpus/PROVENANCE.md; `make cyclonedx-corpus` refreshes both. `Run`
+// reports the share of documents handled as the specification expects, per
+// spec version and overall:
+//
+//	report, err := conformance.Run(ctx, conformance.Corpus())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, v := range report.Versions {
+//	    fmt.Printf("CycloneDX %s: %.1f%% (%d/%d)\n", v.Version, v.Percent(), v.Passed, v.Cases)
+//	}
+package conformance
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+//go:embed corpus
+var corpus embed.FS
+
+// Corpus returns the vendored CycloneDX test corpus, laid out like the
+// specification's tools/src/test/resources directory: one directory per spec
+// version. It is empty until the corpus has been vendored.
+func Corpus() fs.FS {
+	sub, err := fs.Sub(corpus, "corpus")
+	if err != nil {
+		panic(err) // the directory is embedded
+	}
+	return sub
+}
+
+// Report is the outcome of validating a corpus.
+type Report struct {
+	Versions []Version `json:"versions"`
+}
+
+// Version is the outcome of validating the documents of one spec version.
+type Version struct {
+	Version  string    `json:"version"`
+	Cases    int       `json:"cases"`
+	Passed   int       `json:"passed"`
+	Failures []Failure `json:"failures,omitempty"`
+}
+
+// Failure is a document the validator did not handle as the specification
+// expects: a valid document it rejected, or an invalid one it accepted.
+type Failure struct {
+	Path string `json:"path"`
+	// WantValid reports whether the specification expects the document to be
+	// accepted.
+	WantValid bool `json:"wantValid"`
+	// Errors are the validation errors of a rejected document, or Error the
+	// reason it could not be validated at all.
+	Errors []string `json:"errors,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// Percent returns the share of the version's documents that passed, from 0
+// to 100.
+func (v Version) Percent() float64 {
+	return percent(v.Passed, v.Cases)
+}
+
+// Cases returns the number of documents validated across versions.
+func (r *Report) Cases() int {
+	var n int
+	for _, v := range r.Versions {
+		n += v.Cases
+	}
+	return n
+}
+
+// Passed returns the number of documents that passed across versions.
+func (r *Report) Passed() int {
+	var n int
+	for _, v := range r.Versions {
+		n += v.Passed
+	}
+	return n
+}
+
+// Percent returns the share of all documents that passed, from 0 to 100.
+func (r *Report) Percent() float64 {
+	return percent(r.Passed(), r.Cases())
+}
+
+func percent(passed, cases int) float64 {
+	if cases == 0 {
+		return 0
+	}
+	return 100 * float64(passed) / float64(cases)
+}
+
+// Run validates every document of a corpus with the given options and
+// compares the outcome with the one its name calls for. Documents are read
+// from "<version>/valid-*.json" and "<version>/invalid-*.json"; other files,
+// such as the XML and protobuf forms of the corpus, are skipped. A document
+// the validator cannot validate at all counts as rejected.
+//
+// Returns the report, with versions in ascending order, or an error if the
+// corpus cannot be read or ctx is done.
+func Run(ctx context.Context, corpus fs.FS, opts ...sbomvalidator.Option) (*Report, error) {
+	versions := map[string]*Version{}
+	err := fs.WalkDir(corpus, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		dir, base := path.Split(name)
+		version := strings.TrimSuffix(dir, "/")
+		var wantValid bool
+		switch {
+		case path.Ext(base) != ".json" || strings.Contains(version, "/") || version == "":
+			return nil
+		case strings.HasPrefix(base, "valid-"):
+			wantValid = true
+		case strings.HasPrefix(base, "invalid-"):
+		default:
+			return nil
+		}
+
+		data, err := fs.ReadFile(corpus, name)
+		if err != nil {
+			return err
+		}
+		result, err := sbomvalidator.ValidateSBOMDataContext(ctx, data, opts...)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		v := versions[version]
+		if v == nil {
+			v = &Version{Version: version}
+			versions[version] = v
+		}
+		v.Cases++
+		valid := err == nil && result.IsValid
+		if valid == wantValid {
+			v.Passed++
+			return nil
+		}
+		failure := Failure{Path: name, WantValid: wantValid}
+		if err != nil {
+			failure.Error = err.Error()
+		} else {
+			failure.Errors = result.ValidationErrors
+		}
+		v.Failures = append(v.Failures, failure)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run the conformance corpus: %w", err)
+	}
+
+	report := &Report{}
+	for _, v := range versions {
+		report.Versions = append(report.Versions, *v)
+	}
+	slices.SortFunc(report.Versions, func(a, b Version) int {
+		return compareVersions(a.Version, b.Version)
+	})
+	return report, nil
+}
+
+// compareVersions orders spec versions numerically, so "1.10" follows "1.9".
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		if errX != nil || errY != nil {
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return len(as) - len(bs)
+}
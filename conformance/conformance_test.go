@@ -0,0 +1,98 @@
+//go:build !sbomvalidator_no_cyclonedx
+
+package conformance
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// stubSchema stands in for the CycloneDX schemas, which reference schemas
+// that are fetched over the network.
+const stubSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["bomFormat", "specVersion"],
+  "properties": {
+    "bomFormat": {"enum": ["CycloneDX"]},
+    "specVersion": {"type": "string"},
+    "version": {"type": "integer", "minimum": 1}
+  }
+}`
+
+type stubProvider struct{}
+
+func (stubProvider) Schema(format, version string) (string, []byte, error) {
+	if format != sbomvalidator.SBOM_CYCLONEDX {
+		return "", nil, fs.ErrNotExist
+	}
+	return "stub-" + version, []byte(stubSchema), nil
+}
+
+func TestRun(t *testing.T) {
+	corpus := fstest.MapFS{
+		"1.6/valid-bom-1.6.json":          {Data: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1}`)},
+		"1.6/valid-bom-1.6.xml":           {Data: []byte(`<bom/>`)},
+		"1.6/invalid-version-1.6.json":    {Data: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 0}`)},
+		"1.6/invalid-serial-1.6.json":     {Data: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1}`)},
+		"1.10/valid-bom-1.10.json":        {Data: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.10", "version": 1}`)},
+		"1.10/invalid-not-json-1.10.json": {Data: []byte(`{`)},
+		"README.md":                       {Data: []byte(`# corpus`)},
+	}
+
+	report, err := Run(context.Background(), corpus, sbomvalidator.WithSchemaProviders(stubProvider{}))
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if len(report.Versions) != 2 || report.Versions[0].Version != "1.6" || report.Versions[1].Version != "1.10" {
+		t.Fatalf("Run() versions = %+v, want 1.6 and 1.10", report.Versions)
+	}
+
+	v := report.Versions[0]
+	if v.Cases != 3 || v.Passed != 2 || len(v.Failures) != 1 {
+		t.Fatalf("CycloneDX 1.6 = %+v, want 2 of 3 passed", v)
+	}
+	if f := v.Failures[0]; f.Path != "1.6/invalid-serial-1.6.json" || f.WantValid {
+		t.Errorf("failure = %+v, want the accepted invalid document", f)
+	}
+	if report.Versions[1].Percent() != 100 {
+		t.Errorf("CycloneDX 1.10 = %+v, want every document passed", report.Versions[1])
+	}
+	if report.Cases() != 5 || report.Passed() != 4 || report.Percent() != 80 {
+		t.Errorf("Report = %d/%d (%.1f%%), want 4/5 (80%%)", report.Passed(), report.Cases(), report.Percent())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Run(ctx, corpus, sbomvalidator.WithSchemaProviders(stubProvider{})); err == nil {
+		t.Error("Run() with a canceled context expected an error")
+	}
+}
+
+// TestCorpus reports the conformance of the vendored corpus, which CI
+// vendors before running the tests. It fails in CI, where the CI environment
+// variable is set, if the corpus is missing, and is skipped elsewhere.
+func TestCorpus(t *testing.T) {
+	report, err := Run(context.Background(), Corpus())
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if report.Cases() == 0 {
+		if os.Getenv("CI") != "" {
+			t.Fatal("the CycloneDX test corpus is not vendored; run make cyclonedx-corpus")
+		}
+		t.Skip("the CycloneDX test corpus is not vendored; run make cyclonedx-corpus")
+	}
+	for _, v := range report.Versions {
+		t.Logf("CycloneDX %s: %.1f%% (%d/%d)", v.Version, v.Percent(), v.Passed, v.Cases)
+		for _, f := range v.Failures {
+			t.Logf("  %s: wantValid=%v %s%v", f.Path, f.WantValid, f.Error, f.Errors)
+		}
+	}
+	t.Logf("total: %.1f%% (%d/%d)", report.Percent(), report.Passed(), report.Cases())
+}
@@ -0,0 +1,276 @@
+// Package convert converts validated SBOMs between CycloneDX and SPDX.
+//
+// A CycloneDX BOM becomes an SPDX 2.3 document, and an SPDX document becomes
+// a CycloneDX 1.6 BOM. Components and packages, their licenses, hashes and
+// identifiers, the dependency and containment relationships between them,
+// the primary component and the document metadata (creation time, tools and
+// authors) are carried over. What the target format has no place for, such
+// as CycloneDX services and vulnerabilities or SPDX files and snippets, is
+// left out and listed, together with fields carried over only approximately,
+// in the loss report of the result.
+//
+// The input is validated before it is converted, so conversion never
+// launders an invalid SBOM into another format. The output is not validated;
+// pass it to `sbomvalidator.ValidateSBOMData`, or to
+// `sbomvalidator.CompareConversion` with the input, to check it.
+//
+// Example:
+//
+//	result, err := convert.ToSPDX(bom, convert.Config{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, loss := range result.Losses {
+//	    fmt.Println(loss)
+//	}
+//	os.WriteFile("sbom.spdx.json", result.Document, 0o644)
+package convert
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// DefaultNamespace is the URI prefix of the documentNamespace of SPDX output
+// when Config.Namespace is empty.
+const DefaultNamespace = "https://spdx.org/spdxdocs/"
+
+// toolName is the creator recorded for the conversion itself.
+const toolName = "sbom-validator"
+
+// Config configures a conversion.
+type Config struct {
+	// Options are passed to `sbomvalidator.ValidateSBOMData` when the input
+	// is validated, e.g., to register decompressors or apply a policy.
+	Options []sbomvalidator.Option
+	// Namespace is the URI prefix of the documentNamespace of SPDX output;
+	// the document name and a UUID derived from the input are appended.
+	// Defaults to DefaultNamespace.
+	Namespace string
+	// Now returns the creation time recorded when the input declares none.
+	// Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (cfg Config) namespace() string {
+	if cfg.Namespace == "" {
+		return DefaultNamespace
+	}
+	if !strings.HasSuffix(cfg.Namespace, "/") {
+		return cfg.Namespace + "/"
+	}
+	return cfg.Namespace
+}
+
+func (cfg Config) now() time.Time {
+	if cfg.Now == nil {
+		return time.Now()
+	}
+	return cfg.Now()
+}
+
+// Loss is a field of the input that the output leaves out or carries only
+// approximately.
+type Loss struct {
+	// Path locates the field in the input JSON as a dotted path (e.g.,
+	// "components.2.pedigree").
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String returns the loss as "<path>: <message>".
+func (l Loss) String() string {
+	return l.Path + ": " + l.Message
+}
+
+// Result is the outcome of a conversion.
+type Result struct {
+	// Type is the format and spec version of the output.
+	Type sbomvalidator.SBOMType `json:"type"`
+	// Document is the converted SBOM JSON.
+	Document []byte `json:"-"`
+	// Losses lists what the output does not carry, in input order.
+	Losses []Loss `json:"losses,omitempty"`
+}
+
+// Lossless reports whether everything in the input was carried over.
+func (r *Result) Lossless() bool {
+	return len(r.Losses) == 0
+}
+
+// Convert validates an SBOM and converts it to another format.
+//
+// Parameters:
+//   - sbom: The SBOM, in any encoding `sbomvalidator.ValidateSBOMData`
+//     accepts.
+//   - target: The format to convert to, `sbomvalidator.FormatSPDX` or
+//     `sbomvalidator.FormatCycloneDX`.
+//   - cfg: The validation options and output settings.
+//
+// Returns:
+//   - The Result.
+//   - An error if the SBOM cannot be validated or is invalid, or if it is
+//     already in the target format.
+//
+// Example:
+//
+//	result, err := convert.Convert(data, sbomvalidator.FormatCycloneDX, convert.Config{})
+func Convert(sbom []byte, target sbomvalidator.Format, cfg Config) (*Result, error) {
+	if target != sbomvalidator.FormatSPDX && target != sbomvalidator.FormatCycloneDX {
+		return nil, fmt.Errorf("unsupported target format %q", target)
+	}
+
+	validation, err := sbomvalidator.ValidateSBOMData(sbom, cfg.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate the SBOM: %v", err)
+	}
+	if !validation.IsValid {
+		return nil, fmt.Errorf("the SBOM is invalid: %s", strings.Join(validation.ValidationErrors, "; "))
+	}
+
+	jsonContent, err := sbomvalidator.DecodeSBOM(sbom, cfg.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the SBOM: %v", err)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(jsonContent, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse the SBOM: %v", err)
+	}
+
+	source := sbomvalidator.FormatCycloneDX
+	if strings.HasPrefix(validation.SBOMType, sbomvalidator.SBOM_SPDX) {
+		source = sbomvalidator.FormatSPDX
+	}
+	if source == target {
+		return nil, fmt.Errorf("the SBOM is already %s", target)
+	}
+
+	var document interface{}
+	result := &Result{}
+	if target == sbomvalidator.FormatSPDX {
+		result.Type = sbomvalidator.SBOMType{Format: sbomvalidator.FormatSPDX, Version: spdxVersion}
+		document, result.Losses = toSPDX(obj, sbomvalidator.SBOMDigest(jsonContent), cfg)
+	} else {
+		result.Type = sbomvalidator.SBOMType{Format: sbomvalidator.FormatCycloneDX, Version: cycloneDXVersion}
+		document, result.Losses = toCycloneDX(obj, cfg)
+	}
+
+	result.Document, err = json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the %s SBOM: %v", target, err)
+	}
+	return result, nil
+}
+
+// ToSPDX validates a CycloneDX SBOM and converts it to SPDX 2.3 JSON. See
+// Convert.
+func ToSPDX(cyclonedx []byte, cfg Config) (*Result, error) {
+	return Convert(cyclonedx, sbomvalidator.FormatSPDX, cfg)
+}
+
+// ToCycloneDX validates an SPDX SBOM and converts it to CycloneDX 1.6 JSON.
+// See Convert.
+func ToCycloneDX(spdx []byte, cfg Config) (*Result, error) {
+	return Convert(spdx, sbomvalidator.FormatCycloneDX, cfg)
+}
+
+// lossReport collects the losses of a conversion.
+type lossReport []Loss
+
+func (r *lossReport) add(path, format string, args ...interface{}) {
+	*r = append(*r, Loss{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// unmapped reports every field of obj that is not in mapped, in key order.
+func (r *lossReport) unmapped(path string, obj map[string]interface{}, mapped map[string]bool, target sbomvalidator.Format) {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		if !mapped[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		r.add(joinPath(path, key), "has no %s counterpart", target)
+	}
+}
+
+func joinPath(path string, elems ...interface{}) string {
+	for _, e := range elems {
+		if path == "" {
+			path = fmt.Sprint(e)
+		} else {
+			path = fmt.Sprintf("%s.%v", path, e)
+		}
+	}
+	return path
+}
+
+func str(obj map[string]interface{}, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
+
+func objects(obj map[string]interface{}, key string) []map[string]interface{} {
+	items, _ := obj[key].([]interface{})
+	var result []map[string]interface{}
+	for _, item := range items {
+		if o, ok := item.(map[string]interface{}); ok {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// deterministicUUID derives a version 5 style UUID from a seed, so converting
+// the same input twice gives the same identifiers.
+func deterministicUUID(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	sum[6] = 0x50 | sum[6]&0x0f
+	sum[8] = 0x80 | sum[8]&0x3f
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// hashAlgorithms maps CycloneDX hash algorithms to SPDX checksum algorithms.
+var hashAlgorithms = map[string]string{
+	"MD5":         "MD5",
+	"SHA-1":       "SHA1",
+	"SHA-256":     "SHA256",
+	"SHA-384":     "SHA384",
+	"SHA-512":     "SHA512",
+	"SHA3-256":    "SHA3-256",
+	"SHA3-384":    "SHA3-384",
+	"SHA3-512":    "SHA3-512",
+	"BLAKE2b-256": "BLAKE2b-256",
+	"BLAKE2b-384": "BLAKE2b-384",
+	"BLAKE2b-512": "BLAKE2b-512",
+	"BLAKE3":      "BLAKE3",
+}
+
+// packagePurposes maps CycloneDX component types to SPDX package purposes.
+// Types missing here become OTHER.
+var packagePurposes = map[string]string{
+	"application":      "APPLICATION",
+	"framework":        "FRAMEWORK",
+	"library":          "LIBRARY",
+	"container":        "CONTAINER",
+	"operating-system": "OPERATING-SYSTEM",
+	"device":           "DEVICE",
+	"firmware":         "FIRMWARE",
+	"file":             "FILE",
+}
+
+// reverse returns the inverse of a one-to-one map.
+func reverse(m map[string]string) map[string]string {
+	r := make(map[string]string, len(m))
+	for k, v := range m {
+		r[v] = k
+	}
+	return r
+}
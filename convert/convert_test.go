@@ -0,0 +1,265 @@
+//go:build !sbomvalidator_no_cyclonedx && !sbomvalidator_no_spdx
+
+package convert
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+	"github.com/shiftleftcyber/sbom-validator/compare"
+)
+
+const cycloneDX = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+  "version": 1,
+  "metadata": {
+    "timestamp": "2024-10-22T14:00:00.123+02:00",
+    "tools": {"components": [{"type": "application", "name": "syft", "version": "1.14.0"}]},
+    "authors": [{"name": "Jane Doe", "email": "jane@example.com"}],
+    "component": {"type": "application", "bom-ref": "app", "name": "app", "version": "2.0.0",
+      "licenses": [{"license": {"name": "Acme Proprietary", "text": {"content": "All rights reserved."}}}],
+      "components": [{"type": "library", "bom-ref": "app/vendored", "name": "vendored", "version": "0.1.0"}]},
+    "lifecycles": [{"phase": "build"}]
+  },
+  "components": [
+    {"type": "library", "bom-ref": "pkg:npm/left-pad@1.3.0", "name": "left-pad", "version": "1.3.0",
+     "supplier": {"name": "npm"}, "copyright": "Copyright 2016 left-pad authors",
+     "hashes": [{"alg": "SHA-256", "content": "ABCDEF0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF0123456789"}, {"alg": "Streebog-256", "content": "00"}],
+     "licenses": [{"license": {"id": "MIT"}}, {"expression": "Apache-2.0 OR BSD-3-Clause", "acknowledgement": "concluded"}],
+     "purl": "pkg:npm/left-pad@1.3.0", "cpe": "cpe:2.3:a:left-pad:left-pad:1.3.0:*:*:*:*:*:*:*",
+     "externalReferences": [{"type": "website", "url": "https://example.com/left-pad"}, {"type": "vcs", "url": "https://example.com/left-pad.git"}],
+     "properties": [{"name": "internal", "value": "no"}]},
+    {"type": "data", "name": "model-weights", "group": "acme"}
+  ],
+  "services": [{"bom-ref": "api", "name": "api"}],
+  "dependencies": [
+    {"ref": "app", "dependsOn": ["pkg:npm/left-pad@1.3.0", "api"]},
+    {"ref": "api"}
+  ]
+}`
+
+const spdx = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "app-2.0.0",
+  "documentNamespace": "https://example.com/app-2.0.0",
+  "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: syft-1.14.0", "Person: Jane Doe (jane@example.com)"]},
+  "packages": [
+    {"SPDXID": "SPDXRef-app", "name": "app", "versionInfo": "2.0.0", "downloadLocation": "NOASSERTION",
+     "primaryPackagePurpose": "APPLICATION", "licenseConcluded": "NOASSERTION", "licenseDeclared": "Apache-2.0",
+     "copyrightText": "NOASSERTION"},
+    {"SPDXID": "SPDXRef-left-pad", "name": "left-pad", "versionInfo": "1.3.0",
+     "supplier": "Organization: npm", "downloadLocation": "https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz",
+     "checksums": [{"algorithm": "SHA1", "checksumValue": "5b5e2fc4c5a8fae1fe01ed5a3e0f9e0d1c7a5b8e"}, {"algorithm": "ADLER32", "checksumValue": "0a1b2c3d"}],
+     "licenseConcluded": "MIT", "licenseDeclared": "MIT", "copyrightText": "NOASSERTION",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/left-pad@1.3.0"}],
+     "sourceInfo": "built from the release tarball"},
+    {"SPDXID": "SPDXRef-jest", "name": "jest", "versionInfo": "29.7.0", "downloadLocation": "NOASSERTION",
+     "licenseConcluded": "NOASSERTION", "licenseDeclared": "MIT", "copyrightText": "NOASSERTION",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/jest@29.7.0"}]}
+  ],
+  "files": [{"SPDXID": "SPDXRef-file", "fileName": "./index.js", "checksums": [{"algorithm": "SHA1", "checksumValue": "5b5e2fc4c5a8fae1fe01ed5a3e0f9e0d1c7a5b8e"}]}],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-app"},
+    {"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-left-pad"},
+    {"spdxElementId": "SPDXRef-jest", "relationshipType": "DEV_DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-app"},
+    {"spdxElementId": "SPDXRef-app", "relationshipType": "CONTAINS", "relatedSpdxElement": "SPDXRef-file"}
+  ]
+}`
+
+func paths(losses []Loss) []string {
+	var result []string
+	for _, l := range losses {
+		result = append(result, l.Path)
+	}
+	return result
+}
+
+func TestToSPDX(t *testing.T) {
+	// CycloneDX validation needs the network for the JSF schema, so the
+	// mapping is exercised without Convert
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(cycloneDX), &obj); err != nil {
+		t.Fatal(err)
+	}
+	doc, losses := toSPDX(obj, "sha256:0", Config{})
+
+	document, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validation, err := sbomvalidator.ValidateSBOMData(document)
+	if err != nil || !validation.IsValid {
+		t.Fatalf("output is invalid: %+v, %v\n%s", validation, err, document)
+	}
+
+	if doc.Name != "app-2.0.0" || doc.DocumentNamespace != DefaultNamespace+"app-2.0.0-3e671687-395b-41f5-a30f-a58921a69b79" {
+		t.Errorf("name = %q, namespace = %q", doc.Name, doc.DocumentNamespace)
+	}
+	wantCreation := spdxCreationInfo{
+		Created:  "2024-10-22T12:00:00Z",
+		Creators: []string{"Tool: syft-1.14.0", "Tool: sbom-validator", "Person: Jane Doe (jane@example.com)"},
+	}
+	if !reflect.DeepEqual(doc.CreationInfo, wantCreation) {
+		t.Errorf("creationInfo = %+v, want %+v", doc.CreationInfo, wantCreation)
+	}
+
+	leftPad := doc.Packages[2]
+	if leftPad.SPDXID != "SPDXRef-pkg-npm-left-pad-1.3.0" || leftPad.Supplier != "Organization: npm" ||
+		leftPad.LicenseDeclared != "MIT" || leftPad.LicenseConcluded != "(Apache-2.0 OR BSD-3-Clause)" ||
+		leftPad.Homepage != "https://example.com/left-pad" || len(leftPad.ExternalRefs) != 2 ||
+		!reflect.DeepEqual(leftPad.Checksums, []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: strings.Repeat("abcdef0123456789", 4)}}) {
+		t.Errorf("left-pad = %+v", leftPad)
+	}
+	if app := doc.Packages[0]; app.LicenseDeclared != "LicenseRef-Acme-Proprietary" ||
+		!reflect.DeepEqual(doc.HasExtractedLicensingInfos, []spdxExtractedLicense{{LicenseID: "LicenseRef-Acme-Proprietary", Name: "Acme Proprietary", ExtractedText: "All rights reserved."}}) {
+		t.Errorf("app = %+v, extracted = %+v", app, doc.HasExtractedLicensingInfos)
+	}
+
+	wantRelationships := []spdxRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-app"},
+		{SPDXElementID: "SPDXRef-app", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-app-vendored"},
+		{SPDXElementID: "SPDXRef-app", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-pkg-npm-left-pad-1.3.0"},
+	}
+	if !reflect.DeepEqual(doc.Relationships, wantRelationships) {
+		t.Errorf("relationships = %+v, want %+v", doc.Relationships, wantRelationships)
+	}
+
+	wantLosses := []string{
+		"services",
+		"metadata.lifecycles",
+		"components.0.properties",
+		"components.0.hashes.1",
+		"components.0.externalReferences.1",
+		"components.1.group",
+		"components.1.type",
+		"dependencies.0.dependsOn.1",
+		"dependencies.1",
+	}
+	if got := paths(losses); !reflect.DeepEqual(got, wantLosses) {
+		t.Errorf("losses = %v, want %v", losses, wantLosses)
+	}
+}
+
+func TestToSPDXWithoutPrimaryComponent(t *testing.T) {
+	obj := map[string]interface{}{
+		"components": []interface{}{
+			map[string]interface{}{"type": "library", "name": "a"},
+			map[string]interface{}{"type": "library", "name": "a"},
+		},
+	}
+	now := time.Date(2024, 10, 22, 12, 0, 0, 0, time.UTC)
+	doc, _ := toSPDX(obj, "sha256:0", Config{Namespace: "https://example.com/sboms", Now: func() time.Time { return now }})
+
+	if len(doc.Relationships) != 2 || doc.Relationships[1].RelatedSPDXElement != "SPDXRef-Package-a-2" {
+		t.Errorf("relationships = %+v", doc.Relationships)
+	}
+	if doc.Name != "sbom" || !strings.HasPrefix(doc.DocumentNamespace, "https://example.com/sboms/sbom-") ||
+		doc.CreationInfo.Created != "2024-10-22T12:00:00Z" {
+		t.Errorf("document = %+v", doc)
+	}
+}
+
+func TestToCycloneDX(t *testing.T) {
+	result, err := ToCycloneDX([]byte(spdx), Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Type != (sbomvalidator.SBOMType{Format: sbomvalidator.FormatCycloneDX, Version: "1.6"}) || result.Lossless() {
+		t.Errorf("result = %+v", result)
+	}
+
+	var bom cycloneDXBOM
+	if err := json.Unmarshal(result.Document, &bom); err != nil {
+		t.Fatal(err)
+	}
+	if sbomType, err := sbomvalidator.Detect(strings.NewReader(string(result.Document))); err != nil || sbomType != result.Type {
+		t.Errorf("Detect() = %v, %v", sbomType, err)
+	}
+
+	if bom.Metadata.Component == nil || bom.Metadata.Component.BOMRef != "SPDXRef-app" || bom.Metadata.Component.Type != "application" {
+		t.Errorf("metadata.component = %+v", bom.Metadata.Component)
+	}
+	wantAuthors := []cycloneDXContact{{Name: "Jane Doe", Email: "jane@example.com"}}
+	if bom.Metadata.Timestamp != "2024-10-22T12:00:00Z" || !reflect.DeepEqual(bom.Metadata.Authors, wantAuthors) ||
+		bom.Metadata.Tools.Components[0].Name != "syft" || bom.Metadata.Tools.Components[0].Version != "1.14.0" {
+		t.Errorf("metadata = %+v", bom.Metadata)
+	}
+
+	leftPad := bom.Components[0]
+	want := cycloneDXComponent{
+		Type:               "library",
+		BOMRef:             "SPDXRef-left-pad",
+		Supplier:           &cycloneDXEntity{Name: "npm"},
+		Name:               "left-pad",
+		Version:            "1.3.0",
+		Hashes:             []cycloneDXHash{{Alg: "SHA-1", Content: "5b5e2fc4c5a8fae1fe01ed5a3e0f9e0d1c7a5b8e"}},
+		Licenses:           []cycloneDXLicense{{Expression: "MIT", Acknowledgement: "declared"}},
+		PURL:               "pkg:npm/left-pad@1.3.0",
+		ExternalReferences: []cycloneDXExternalReference{{Type: "distribution", URL: "https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz"}},
+	}
+	if !reflect.DeepEqual(leftPad, want) {
+		t.Errorf("left-pad = %+v, want %+v", leftPad, want)
+	}
+
+	wantDependencies := []cycloneDXDependency{{Ref: "SPDXRef-app", DependsOn: []string{"SPDXRef-left-pad", "SPDXRef-jest"}}}
+	if !reflect.DeepEqual(bom.Dependencies, wantDependencies) {
+		t.Errorf("dependencies = %+v, want %+v", bom.Dependencies, wantDependencies)
+	}
+
+	wantLosses := []string{"files", "packages.1.sourceInfo", "packages.1.checksums.1", "relationships.2", "relationships.3"}
+	if got := paths(result.Losses); !reflect.DeepEqual(got, wantLosses) {
+		t.Errorf("losses = %v, want %v", result.Losses, wantLosses)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	result, err := ToCycloneDX([]byte(spdx), Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(result.Document, &obj); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ := toSPDX(obj, "sha256:0", Config{})
+	document, _ := json.Marshal(doc)
+
+	diff, err := compare.SBOMs([]byte(spdx), document, compare.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !diff.Identical() {
+		t.Errorf("round trip changed components: %+v", diff)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sbom    string
+		target  sbomvalidator.Format
+		wantErr string
+	}{
+		{name: "Same format", sbom: spdx, target: sbomvalidator.FormatSPDX, wantErr: "already SPDX"},
+		{name: "Unknown target", sbom: spdx, target: "SWID", wantErr: "unsupported target format"},
+		{name: "Invalid SBOM", sbom: strings.Replace(spdx, `"SPDXID": "SPDXRef-DOCUMENT",`, "", 1), target: sbomvalidator.FormatCycloneDX, wantErr: "the SBOM is invalid"},
+		{name: "Not an SBOM", sbom: "{}", target: sbomvalidator.FormatCycloneDX, wantErr: "failed to validate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Convert([]byte(tt.sbom), tt.target, Config{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Convert() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
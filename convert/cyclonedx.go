@@ -0,0 +1,345 @@
+package convert
+
+import (
+	"strings"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// cycloneDXVersion is the CycloneDX version of converted BOMs.
+const cycloneDXVersion sbomvalidator.SpecVersion = "1.6"
+
+type cycloneDXBOM struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components,omitempty"`
+	Dependencies []cycloneDXDependency `json:"dependencies,omitempty"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp,omitempty"`
+	Tools     *cycloneDXTools     `json:"tools,omitempty"`
+	Authors   []cycloneDXContact  `json:"authors,omitempty"`
+	Component *cycloneDXComponent `json:"component,omitempty"`
+}
+
+type cycloneDXTools struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXContact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+type cycloneDXEntity struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXComponent struct {
+	Type               string                       `json:"type"`
+	BOMRef             string                       `json:"bom-ref,omitempty"`
+	Supplier           *cycloneDXEntity             `json:"supplier,omitempty"`
+	Manufacturer       *cycloneDXEntity             `json:"manufacturer,omitempty"`
+	Authors            []cycloneDXContact           `json:"authors,omitempty"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	Description        string                       `json:"description,omitempty"`
+	Hashes             []cycloneDXHash              `json:"hashes,omitempty"`
+	Licenses           []cycloneDXLicense           `json:"licenses,omitempty"`
+	Copyright          string                       `json:"copyright,omitempty"`
+	CPE                string                       `json:"cpe,omitempty"`
+	PURL               string                       `json:"purl,omitempty"`
+	ExternalReferences []cycloneDXExternalReference `json:"externalReferences,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXLicense struct {
+	Expression      string `json:"expression"`
+	Acknowledgement string `json:"acknowledgement,omitempty"`
+}
+
+type cycloneDXExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// Fields of an SPDX document, its creation info and its packages that
+// toCycloneDX maps; the rest are reported as losses.
+var (
+	spdxMappedFields = map[string]bool{
+		"spdxVersion": true, "dataLicense": true, "SPDXID": true, "name": true, "documentNamespace": true,
+		"creationInfo": true, "packages": true, "relationships": true, "documentDescribes": true,
+	}
+	spdxMappedCreationInfo = map[string]bool{"created": true, "creators": true}
+	spdxMappedPackage      = map[string]bool{
+		"SPDXID": true, "name": true, "versionInfo": true, "supplier": true, "originator": true,
+		"downloadLocation": true, "filesAnalyzed": true, "homepage": true, "checksums": true,
+		"licenseConcluded": true, "licenseDeclared": true, "copyrightText": true, "description": true,
+		"externalRefs": true, "primaryPackagePurpose": true,
+	}
+)
+
+var (
+	checksumAlgorithms = reverse(hashAlgorithms)
+	componentTypes     = reverse(packagePurposes)
+)
+
+// toCycloneDX converts a parsed SPDX document to a CycloneDX BOM.
+func toCycloneDX(obj map[string]interface{}, cfg Config) (*cycloneDXBOM, lossReport) {
+	var losses lossReport
+	losses.unmapped("", obj, spdxMappedFields, sbomvalidator.FormatCycloneDX)
+
+	bom := &cycloneDXBOM{
+		BOMFormat:    sbomvalidator.SBOM_CYCLONEDX,
+		SpecVersion:  string(cycloneDXVersion),
+		SerialNumber: "urn:uuid:" + deterministicUUID(str(obj, "documentNamespace")),
+		Version:      1,
+	}
+
+	info, _ := obj["creationInfo"].(map[string]interface{})
+	losses.unmapped("creationInfo", info, spdxMappedCreationInfo, sbomvalidator.FormatCycloneDX)
+	bom.Metadata.Timestamp = str(info, "created")
+	if bom.Metadata.Timestamp == "" {
+		bom.Metadata.Timestamp = cfg.now().UTC().Format("2006-01-02T15:04:05Z")
+	}
+	tools := &cycloneDXTools{}
+	creators, _ := info["creators"].([]interface{})
+	for _, c := range creators {
+		creator, _ := c.(string)
+		kind, name, _ := strings.Cut(creator, ":")
+		name = strings.TrimSpace(name)
+		switch kind {
+		case "Tool":
+			tools.Components = append(tools.Components, cycloneDXTool(name))
+		case "Person", "Organization":
+			bom.Metadata.Authors = append(bom.Metadata.Authors, cycloneDXPerson(name))
+		}
+	}
+	tools.Components = append(tools.Components, cycloneDXComponent{Type: "application", Name: toolName})
+	bom.Metadata.Tools = tools
+
+	// the first described package is the primary component; the others stay
+	// components
+	described := spdxDescribed(obj)
+	primary := ""
+	if len(described) > 0 {
+		primary = described[0]
+		if len(described) > 1 {
+			losses.add("relationships", "the document describes %d packages; only %s becomes the primary component", len(described), primary)
+		}
+	}
+
+	packageIDs := map[string]bool{}
+	for i, pkg := range objects(obj, "packages") {
+		component := convertPackage(joinPath("packages", i), pkg, &losses)
+		packageIDs[component.BOMRef] = true
+		if component.BOMRef == primary && bom.Metadata.Component == nil {
+			bom.Metadata.Component = &component
+			continue
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	bom.Dependencies = convertRelationships(obj, packageIDs, &losses)
+	return bom, losses
+}
+
+// cycloneDXTool parses an SPDX tool creator, conventionally "name-version".
+func cycloneDXTool(creator string) cycloneDXComponent {
+	tool := cycloneDXComponent{Type: "application", Name: creator}
+	if i := strings.LastIndex(creator, "-"); i > 0 && i+1 < len(creator) && creator[i+1] >= '0' && creator[i+1] <= '9' {
+		tool.Name, tool.Version = creator[:i], creator[i+1:]
+	}
+	return tool
+}
+
+// cycloneDXPerson parses an SPDX person or organization, "name (email)".
+func cycloneDXPerson(creator string) cycloneDXContact {
+	if name, email, ok := strings.Cut(creator, "("); ok && strings.HasSuffix(email, ")") {
+		return cycloneDXContact{Name: strings.TrimSpace(name), Email: strings.TrimSuffix(email, ")")}
+	}
+	return cycloneDXContact{Name: creator}
+}
+
+// spdxDescribed returns the IDs of the elements an SPDX document describes,
+// from documentDescribes and DESCRIBES relationships, in document order.
+func spdxDescribed(obj map[string]interface{}) []string {
+	var described []string
+	seen := map[string]bool{}
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			described = append(described, id)
+		}
+	}
+	documentDescribes, _ := obj["documentDescribes"].([]interface{})
+	for _, d := range documentDescribes {
+		id, _ := d.(string)
+		add(id)
+	}
+	for _, rel := range objects(obj, "relationships") {
+		switch str(rel, "relationshipType") {
+		case "DESCRIBES":
+			if str(rel, "spdxElementId") == str(obj, "SPDXID") {
+				add(str(rel, "relatedSpdxElement"))
+			}
+		case "DESCRIBED_BY":
+			if str(rel, "relatedSpdxElement") == str(obj, "SPDXID") {
+				add(str(rel, "spdxElementId"))
+			}
+		}
+	}
+	return described
+}
+
+// convertPackage maps an SPDX package to a component.
+func convertPackage(path string, pkg map[string]interface{}, losses *lossReport) cycloneDXComponent {
+	losses.unmapped(path, pkg, spdxMappedPackage, sbomvalidator.FormatCycloneDX)
+	if analyzed, _ := pkg["filesAnalyzed"].(bool); analyzed {
+		losses.add(joinPath(path, "filesAnalyzed"), "file analysis results have no CycloneDX counterpart")
+	}
+
+	component := cycloneDXComponent{
+		Type:        "library",
+		BOMRef:      str(pkg, "SPDXID"),
+		Name:        str(pkg, "name"),
+		Version:     str(pkg, "versionInfo"),
+		Description: str(pkg, "description"),
+		Copyright:   spdxValue(str(pkg, "copyrightText")),
+	}
+
+	if purpose := str(pkg, "primaryPackagePurpose"); purpose != "" {
+		if t, ok := componentTypes[purpose]; ok {
+			component.Type = t
+		} else {
+			losses.add(joinPath(path, "primaryPackagePurpose"), "purpose %s has no CycloneDX component type; library is used", purpose)
+		}
+	}
+
+	if supplier := spdxValue(str(pkg, "supplier")); supplier != "" {
+		_, name, _ := strings.Cut(supplier, ":")
+		component.Supplier = &cycloneDXEntity{Name: cycloneDXPerson(strings.TrimSpace(name)).Name}
+	}
+	if originator := spdxValue(str(pkg, "originator")); originator != "" {
+		kind, name, _ := strings.Cut(originator, ":")
+		if kind == "Organization" {
+			component.Manufacturer = &cycloneDXEntity{Name: cycloneDXPerson(strings.TrimSpace(name)).Name}
+		} else {
+			component.Authors = []cycloneDXContact{cycloneDXPerson(strings.TrimSpace(name))}
+		}
+	}
+
+	if location := spdxValue(str(pkg, "downloadLocation")); location != "" {
+		component.ExternalReferences = append(component.ExternalReferences, cycloneDXExternalReference{Type: "distribution", URL: location})
+	}
+	if homepage := spdxValue(str(pkg, "homepage")); homepage != "" {
+		component.ExternalReferences = append(component.ExternalReferences, cycloneDXExternalReference{Type: "website", URL: homepage})
+	}
+
+	for i, checksum := range objects(pkg, "checksums") {
+		algorithm := str(checksum, "algorithm")
+		if alg, ok := checksumAlgorithms[algorithm]; ok {
+			component.Hashes = append(component.Hashes, cycloneDXHash{Alg: alg, Content: str(checksum, "checksumValue")})
+		} else {
+			losses.add(joinPath(path, "checksums", i), "checksum algorithm %s has no CycloneDX counterpart", algorithm)
+		}
+	}
+
+	for i, ref := range objects(pkg, "externalRefs") {
+		locator := str(ref, "referenceLocator")
+		switch refType := str(ref, "referenceType"); {
+		case refType == "purl" && component.PURL == "":
+			component.PURL = locator
+		case (refType == "cpe23Type" || refType == "cpe22Type") && component.CPE == "":
+			component.CPE = locator
+		case refType == "purl" || refType == "cpe23Type" || refType == "cpe22Type":
+			losses.add(joinPath(path, "externalRefs", i), "a CycloneDX component has a single %s", refType)
+		default:
+			losses.add(joinPath(path, "externalRefs", i), "%s reference has no CycloneDX component field", refType)
+		}
+	}
+
+	// a CycloneDX component carries a single license expression
+	declared := spdxValue(str(pkg, "licenseDeclared"))
+	concluded := spdxValue(str(pkg, "licenseConcluded"))
+	switch {
+	case declared != "":
+		component.Licenses = []cycloneDXLicense{{Expression: declared, Acknowledgement: "declared"}}
+		if concluded != "" && concluded != declared {
+			losses.add(joinPath(path, "licenseConcluded"), "only the declared license %s is kept; the concluded license %s is left out", declared, concluded)
+		}
+	case concluded != "":
+		component.Licenses = []cycloneDXLicense{{Expression: concluded, Acknowledgement: "concluded"}}
+	}
+
+	return component
+}
+
+// spdxValue returns value, or "" for NOASSERTION and NONE.
+func spdxValue(value string) string {
+	if value == noAssertion || value == "NONE" {
+		return ""
+	}
+	return value
+}
+
+// convertRelationships maps dependency and containment relationships between
+// packages to the CycloneDX dependency graph, in order of first appearance.
+func convertRelationships(obj map[string]interface{}, packageIDs map[string]bool, losses *lossReport) []cycloneDXDependency {
+	var dependencies []cycloneDXDependency
+	index := map[string]int{}
+	seen := map[[2]string]bool{}
+
+	for i, rel := range objects(obj, "relationships") {
+		path := joinPath("relationships", i)
+		relType := str(rel, "relationshipType")
+		from, to := str(rel, "spdxElementId"), str(rel, "relatedSpdxElement")
+
+		switch {
+		case relType == "DESCRIBES" || relType == "DESCRIBED_BY":
+			continue
+		case relType == "DEPENDS_ON" || relType == "CONTAINS":
+		case relType == "DEPENDENCY_OF" || relType == "CONTAINED_BY" || relType == "RUNTIME_DEPENDENCY_OF":
+			from, to = to, from
+		case strings.HasSuffix(relType, "_DEPENDENCY_OF"):
+			from, to = to, from
+			losses.add(path, "CycloneDX dependencies have no kind; %s becomes a plain dependency", relType)
+		default:
+			losses.add(path, "%s relationships have no CycloneDX counterpart", relType)
+			continue
+		}
+		if !packageIDs[from] || !packageIDs[to] {
+			losses.add(path, "relates %s to %s, which are not both packages", from, to)
+			continue
+		}
+		if relType == "CONTAINS" || relType == "CONTAINED_BY" {
+			losses.add(path, "containment becomes a dependency of %s on %s", from, to)
+		}
+
+		if seen[[2]string{from, to}] {
+			continue
+		}
+		seen[[2]string{from, to}] = true
+		n, ok := index[from]
+		if !ok {
+			n = len(dependencies)
+			index[from] = n
+			dependencies = append(dependencies, cycloneDXDependency{Ref: from})
+		}
+		dependencies[n].DependsOn = append(dependencies[n].DependsOn, to)
+	}
+	return dependencies
+}
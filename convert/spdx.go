@@ -0,0 +1,393 @@
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// spdxVersion is the SPDX version of converted documents.
+const spdxVersion sbomvalidator.SpecVersion = "2.3"
+
+const (
+	spdxDocumentID = "SPDXRef-DOCUMENT"
+	noAssertion    = "NOASSERTION"
+)
+
+type spdxDocument struct {
+	SPDXVersion                string                 `json:"spdxVersion"`
+	DataLicense                string                 `json:"dataLicense"`
+	SPDXID                     string                 `json:"SPDXID"`
+	Name                       string                 `json:"name"`
+	DocumentNamespace          string                 `json:"documentNamespace"`
+	CreationInfo               spdxCreationInfo       `json:"creationInfo"`
+	Packages                   []spdxPackage          `json:"packages"`
+	HasExtractedLicensingInfos []spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+	Relationships              []spdxRelationship     `json:"relationships,omitempty"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	Supplier              string            `json:"supplier,omitempty"`
+	Originator            string            `json:"originator,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Homepage              string            `json:"homepage,omitempty"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded      string            `json:"licenseConcluded"`
+	LicenseDeclared       string            `json:"licenseDeclared"`
+	CopyrightText         string            `json:"copyrightText"`
+	Description           string            `json:"description,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxExtractedLicense struct {
+	LicenseID     string `json:"licenseId"`
+	Name          string `json:"name"`
+	ExtractedText string `json:"extractedText"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// Fields of a CycloneDX BOM, its metadata and its components that toSPDX
+// maps; the rest are reported as losses.
+var (
+	cycloneDXMappedFields = map[string]bool{
+		"$schema": true, "bomFormat": true, "specVersion": true, "serialNumber": true, "version": true,
+		"metadata": true, "components": true, "dependencies": true,
+	}
+	cycloneDXMappedMetadata = map[string]bool{
+		"timestamp": true, "tools": true, "authors": true, "manufacture": true, "manufacturer": true, "component": true,
+	}
+	cycloneDXMappedComponent = map[string]bool{
+		"type": true, "bom-ref": true, "name": true, "version": true, "group": true, "supplier": true, "author": true,
+		"description": true, "copyright": true, "licenses": true, "hashes": true, "purl": true, "cpe": true,
+		"externalReferences": true, "components": true,
+	}
+)
+
+// spdxIDInvalid matches the characters an SPDX ID cannot contain.
+var spdxIDInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// cycloneDXToSPDX holds the state of a conversion from CycloneDX.
+type cycloneDXToSPDX struct {
+	doc    *spdxDocument
+	losses lossReport
+	// ids maps bom-refs to the SPDX IDs of their packages
+	ids map[string]string
+	// used holds the SPDX IDs already given out
+	used map[string]bool
+	// licenseRefs maps license names to their LicenseRef IDs
+	licenseRefs map[string]string
+}
+
+// toSPDX converts a parsed CycloneDX BOM to an SPDX document. digest
+// identifies the input, to derive a namespace when the BOM has no serial
+// number.
+func toSPDX(obj map[string]interface{}, digest string, cfg Config) (*spdxDocument, lossReport) {
+	c := &cycloneDXToSPDX{
+		doc: &spdxDocument{
+			SPDXVersion: sbomvalidator.SBOM_SPDX + "-" + string(spdxVersion),
+			DataLicense: "CC0-1.0",
+			SPDXID:      spdxDocumentID,
+			Packages:    []spdxPackage{},
+		},
+		ids:         map[string]string{},
+		used:        map[string]bool{},
+		licenseRefs: map[string]string{},
+	}
+	c.losses.unmapped("", obj, cycloneDXMappedFields, sbomvalidator.FormatSPDX)
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	c.convertMetadata(metadata, cfg)
+
+	// without a primary component the document describes every top-level
+	// component
+	var described []string
+	primary, hasPrimary := metadata["component"].(map[string]interface{})
+	if hasPrimary {
+		described = append(described, c.convertComponent("metadata.component", primary))
+	}
+	for i, component := range objects(obj, "components") {
+		id := c.convertComponent(joinPath("components", i), component)
+		if !hasPrimary {
+			described = append(described, id)
+		}
+	}
+
+	describes := make([]spdxRelationship, len(described))
+	for i, id := range described {
+		describes[i] = spdxRelationship{SPDXElementID: spdxDocumentID, RelationshipType: "DESCRIBES", RelatedSPDXElement: id}
+	}
+	c.doc.Relationships = append(describes, c.doc.Relationships...)
+
+	c.convertDependencies(obj)
+
+	name := "sbom"
+	if hasPrimary {
+		name = strings.TrimSuffix(str(primary, "name")+"-"+str(primary, "version"), "-")
+	}
+	c.doc.Name = name
+
+	uuid := strings.TrimPrefix(str(obj, "serialNumber"), "urn:uuid:")
+	if uuid == "" {
+		uuid = deterministicUUID(digest)
+	}
+	c.doc.DocumentNamespace = cfg.namespace() + spdxIDInvalid.ReplaceAllString(name, "-") + "-" + uuid
+
+	return c.doc, c.losses
+}
+
+// convertMetadata maps the BOM timestamp, tools, authors and manufacturer to
+// the SPDX creation info.
+func (c *cycloneDXToSPDX) convertMetadata(metadata map[string]interface{}, cfg Config) {
+	c.losses.unmapped("metadata", metadata, cycloneDXMappedMetadata, sbomvalidator.FormatSPDX)
+	info := &c.doc.CreationInfo
+
+	created := cfg.now()
+	if timestamp := str(metadata, "timestamp"); timestamp != "" {
+		if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+			created = t
+		} else {
+			c.losses.add("metadata.timestamp", "%q is not an RFC 3339 timestamp; the conversion time is used", timestamp)
+		}
+	}
+	info.Created = created.UTC().Format(time.RFC3339)
+
+	// tools are a list up to CycloneDX 1.4 and components and services since
+	var tools []map[string]interface{}
+	if legacy, ok := metadata["tools"].([]interface{}); ok {
+		tools = objects(map[string]interface{}{"tools": legacy}, "tools")
+	} else if t, ok := metadata["tools"].(map[string]interface{}); ok {
+		tools = append(objects(t, "components"), objects(t, "services")...)
+	}
+	for _, tool := range tools {
+		if name := str(tool, "name"); name != "" {
+			info.Creators = append(info.Creators, "Tool: "+strings.TrimSuffix(name+"-"+str(tool, "version"), "-"))
+		}
+	}
+	info.Creators = append(info.Creators, "Tool: "+toolName)
+
+	for _, author := range objects(metadata, "authors") {
+		if name := str(author, "name"); name != "" {
+			info.Creators = append(info.Creators, spdxPerson(name, str(author, "email")))
+		}
+	}
+	for _, key := range []string{"manufacturer", "manufacture"} {
+		if org, ok := metadata[key].(map[string]interface{}); ok && str(org, "name") != "" {
+			info.Creators = append(info.Creators, "Organization: "+str(org, "name"))
+		}
+	}
+}
+
+func spdxPerson(name, email string) string {
+	if email == "" {
+		return "Person: " + name
+	}
+	return fmt.Sprintf("Person: %s (%s)", name, email)
+}
+
+// convertComponent adds a component, and recursively its nested components,
+// as packages and returns the SPDX ID of its package.
+func (c *cycloneDXToSPDX) convertComponent(path string, component map[string]interface{}) string {
+	c.losses.unmapped(path, component, cycloneDXMappedComponent, sbomvalidator.FormatSPDX)
+
+	ref := str(component, "bom-ref")
+	id := c.newID(ref, str(component, "name"))
+	if ref != "" {
+		c.ids[ref] = id
+	}
+
+	pkg := spdxPackage{
+		SPDXID:           id,
+		Name:             str(component, "name"),
+		VersionInfo:      str(component, "version"),
+		DownloadLocation: noAssertion,
+		LicenseConcluded: noAssertion,
+		LicenseDeclared:  noAssertion,
+		CopyrightText:    noAssertion,
+		Description:      str(component, "description"),
+	}
+	if group := str(component, "group"); group != "" && str(component, "purl") == "" {
+		c.losses.add(joinPath(path, "group"), "SPDX packages have no group, and no purl carries it")
+	}
+	if supplier, ok := component["supplier"].(map[string]interface{}); ok && str(supplier, "name") != "" {
+		pkg.Supplier = "Organization: " + str(supplier, "name")
+	}
+	if author := str(component, "author"); author != "" {
+		pkg.Originator = "Person: " + author
+	}
+	if copyright := str(component, "copyright"); copyright != "" {
+		pkg.CopyrightText = copyright
+	}
+
+	if t := str(component, "type"); t != "" {
+		pkg.PrimaryPackagePurpose = packagePurposes[t]
+		if pkg.PrimaryPackagePurpose == "" {
+			pkg.PrimaryPackagePurpose = "OTHER"
+			c.losses.add(joinPath(path, "type"), "type %q has no SPDX package purpose; OTHER is used", t)
+		}
+	}
+
+	for i, hash := range objects(component, "hashes") {
+		alg := str(hash, "alg")
+		if algorithm, ok := hashAlgorithms[alg]; ok {
+			pkg.Checksums = append(pkg.Checksums, spdxChecksum{Algorithm: algorithm, ChecksumValue: strings.ToLower(str(hash, "content"))})
+		} else {
+			c.losses.add(joinPath(path, "hashes", i), "hash algorithm %q has no SPDX counterpart", alg)
+		}
+	}
+
+	if purl := str(component, "purl"); purl != "" {
+		pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl})
+	}
+	if cpe := str(component, "cpe"); cpe != "" {
+		refType := "cpe22Type"
+		if strings.HasPrefix(cpe, "cpe:2.3:") {
+			refType = "cpe23Type"
+		}
+		pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{ReferenceCategory: "SECURITY", ReferenceType: refType, ReferenceLocator: cpe})
+	}
+
+	for i, ref := range objects(component, "externalReferences") {
+		switch t := str(ref, "type"); {
+		case t == "website" && pkg.Homepage == "":
+			pkg.Homepage = str(ref, "url")
+		case t == "distribution" && pkg.DownloadLocation == noAssertion:
+			pkg.DownloadLocation = str(ref, "url")
+		default:
+			c.losses.add(joinPath(path, "externalReferences", i), "%s reference has no SPDX package field", t)
+		}
+	}
+
+	c.convertLicenses(path, component, &pkg)
+	c.doc.Packages = append(c.doc.Packages, pkg)
+
+	for i, child := range objects(component, "components") {
+		childID := c.convertComponent(joinPath(path, "components", i), child)
+		c.doc.Relationships = append(c.doc.Relationships, spdxRelationship{SPDXElementID: id, RelationshipType: "CONTAINS", RelatedSPDXElement: childID})
+	}
+	return id
+}
+
+// convertLicenses maps the licenses of a component to its declared and
+// concluded license expressions. Licenses known only by name become
+// LicenseRefs with extracted licensing info.
+func (c *cycloneDXToSPDX) convertLicenses(path string, component map[string]interface{}, pkg *spdxPackage) {
+	var declared, concluded []string
+	for i, choice := range objects(component, "licenses") {
+		var expression, acknowledgement string
+		if e := str(choice, "expression"); e != "" {
+			expression, acknowledgement = e, str(choice, "acknowledgement")
+		} else if license, ok := choice["license"].(map[string]interface{}); ok {
+			acknowledgement = str(license, "acknowledgement")
+			if id := str(license, "id"); id != "" {
+				expression = id
+			} else if name := str(license, "name"); name != "" {
+				expression = c.licenseRef(name, license)
+			}
+		}
+		if expression == "" {
+			c.losses.add(joinPath(path, "licenses", i), "license has neither an ID, a name nor an expression")
+			continue
+		}
+		if strings.Contains(expression, " ") {
+			expression = "(" + expression + ")"
+		}
+		if acknowledgement == "concluded" {
+			concluded = append(concluded, expression)
+		} else {
+			declared = append(declared, expression)
+		}
+	}
+	if len(declared) > 0 {
+		pkg.LicenseDeclared = strings.Join(declared, " AND ")
+	}
+	if len(concluded) > 0 {
+		pkg.LicenseConcluded = strings.Join(concluded, " AND ")
+	}
+}
+
+// licenseRef returns the LicenseRef for a license known only by name,
+// extracting it on first use.
+func (c *cycloneDXToSPDX) licenseRef(name string, license map[string]interface{}) string {
+	if ref, ok := c.licenseRefs[name]; ok {
+		return ref
+	}
+	ref := "LicenseRef-" + strings.Trim(spdxIDInvalid.ReplaceAllString(name, "-"), "-")
+	c.licenseRefs[name] = ref
+
+	text := noAssertion
+	if t, ok := license["text"].(map[string]interface{}); ok && str(t, "content") != "" && str(t, "encoding") == "" {
+		text = str(t, "content")
+	}
+	c.doc.HasExtractedLicensingInfos = append(c.doc.HasExtractedLicensingInfos, spdxExtractedLicense{LicenseID: ref, Name: name, ExtractedText: text})
+	return ref
+}
+
+// convertDependencies maps the dependency graph to DEPENDS_ON relationships.
+func (c *cycloneDXToSPDX) convertDependencies(obj map[string]interface{}) {
+	for i, dependency := range objects(obj, "dependencies") {
+		path := joinPath("dependencies", i)
+		from, ok := c.ids[str(dependency, "ref")]
+		if !ok {
+			c.losses.add(path, "%q is not a component; only dependencies between components are converted", str(dependency, "ref"))
+			continue
+		}
+		if _, ok := dependency["provides"]; ok {
+			c.losses.add(joinPath(path, "provides"), "has no SPDX counterpart")
+		}
+		dependsOn, _ := dependency["dependsOn"].([]interface{})
+		for j, d := range dependsOn {
+			ref, _ := d.(string)
+			to, ok := c.ids[ref]
+			if !ok {
+				c.losses.add(joinPath(path, "dependsOn", j), "%q is not a component; only dependencies between components are converted", ref)
+				continue
+			}
+			c.doc.Relationships = append(c.doc.Relationships, spdxRelationship{SPDXElementID: from, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: to})
+		}
+	}
+}
+
+// newID returns an unused SPDX ID derived from a bom-ref or, without one,
+// from the component name.
+func (c *cycloneDXToSPDX) newID(ref, name string) string {
+	base := ref
+	if base == "" {
+		base = "Package-" + name
+	}
+	base = "SPDXRef-" + strings.Trim(spdxIDInvalid.ReplaceAllString(base, "-"), "-")
+	id := base
+	for n := 2; c.used[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	c.used[id] = true
+	return id
+}
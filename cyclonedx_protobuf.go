@@ -0,0 +1,340 @@
+//go:build !sbomvalidator_no_cyclonedx
+
+package sbomvalidator
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// protoKind is how a protobuf field is decoded into JSON.
+type protoKind int
+
+const (
+	protoString protoKind = iota
+	protoBool
+	protoInt
+	// protoEnum is a varint whose JSON value is taken from protoField.enum.
+	protoEnum
+	// protoMessage is a nested message, decoded by protoField.message.
+	protoMessage
+	// protoTimestamp is a google.protobuf.Timestamp, decoded into RFC 3339.
+	protoTimestamp
+	// protoRef is a nested Dependency message, decoded into its ref alone:
+	// the protobuf encoding nests dependencies where JSON lists their refs.
+	protoRef
+)
+
+// protoField maps a protobuf field of a CycloneDX message to its JSON
+// property.
+type protoField struct {
+	name     string
+	kind     protoKind
+	repeated bool
+	message  string
+	// enum holds the JSON values by enum number; "" marks the unset value.
+	enum []string
+}
+
+var (
+	protoClassifications = []string{"", "application", "framework", "library", "operating-system", "device", "file",
+		"container", "firmware", "device-driver", "platform", "machine-learning-model", "data", "cryptographic-asset"}
+	protoScopes     = []string{"", "required", "optional", "excluded"}
+	protoHashAlgs   = []string{"", "MD5", "SHA-1", "SHA-256", "SHA-384", "SHA-512", "SHA3-256", "SHA3-384", "SHA3-512", "BLAKE2b-256", "BLAKE2b-384", "BLAKE2b-512", "BLAKE3"}
+	protoLifecycles = []string{"design", "pre-build", "build", "post-build", "operations", "discovery", "decommission"}
+	protoRefTypes   = []string{"other", "vcs", "issue-tracker", "website", "advisories", "bom", "mailing-list", "social", "chat",
+		"documentation", "support", "distribution", "license", "build-meta", "build-system", "release-notes", "security-contact",
+		"model-card", "log", "configuration", "evidence", "formulation", "attestation", "threat-model", "adversary-model",
+		"risk-assessment", "distribution-intake", "vulnerability-assertion", "exploitability-statement", "pentest-report",
+		"static-analysis-report", "dynamic-analysis-report", "runtime-analysis-report", "component-analysis-report",
+		"maturity-report", "certification-report", "quality-metrics", "codified-infrastructure", "poam",
+		"source-distribution", "electronic-signature", "digital-signature", "rfc-9116"}
+)
+
+// cycloneDXProtoMessages describes the messages of the CycloneDX protobuf
+// schema (bom-1.x.proto) that are decoded, by field number. Fields of other
+// messages, such as pedigree, evidence and vulnerabilities, are skipped with
+// a warning.
+var cycloneDXProtoMessages = map[string]map[int]protoField{
+	"Bom": {
+		1:  {name: "specVersion", kind: protoString},
+		2:  {name: "version", kind: protoInt},
+		3:  {name: "serialNumber", kind: protoString},
+		4:  {name: "metadata", kind: protoMessage, message: "Metadata"},
+		5:  {name: "components", kind: protoMessage, message: "Component", repeated: true},
+		6:  {name: "services", kind: protoMessage, message: "Service", repeated: true},
+		7:  {name: "externalReferences", kind: protoMessage, message: "ExternalReference", repeated: true},
+		8:  {name: "dependencies", kind: protoMessage, message: "Dependency", repeated: true},
+		12: {name: "properties", kind: protoMessage, message: "Property", repeated: true},
+	},
+	"Metadata": {
+		1:  {name: "timestamp", kind: protoTimestamp},
+		3:  {name: "authors", kind: protoMessage, message: "OrganizationalContact", repeated: true},
+		4:  {name: "component", kind: protoMessage, message: "Component"},
+		5:  {name: "manufacture", kind: protoMessage, message: "OrganizationalEntity"},
+		6:  {name: "supplier", kind: protoMessage, message: "OrganizationalEntity"},
+		7:  {name: "licenses", kind: protoMessage, message: "LicenseChoice", repeated: true},
+		8:  {name: "properties", kind: protoMessage, message: "Property", repeated: true},
+		9:  {name: "lifecycles", kind: protoMessage, message: "Lifecycle", repeated: true},
+		10: {name: "manufacturer", kind: protoMessage, message: "OrganizationalEntity"},
+	},
+	"Component": {
+		1:  {name: "type", kind: protoEnum, enum: protoClassifications},
+		2:  {name: "mime-type", kind: protoString},
+		3:  {name: "bom-ref", kind: protoString},
+		4:  {name: "supplier", kind: protoMessage, message: "OrganizationalEntity"},
+		5:  {name: "author", kind: protoString},
+		6:  {name: "publisher", kind: protoString},
+		7:  {name: "group", kind: protoString},
+		8:  {name: "name", kind: protoString},
+		9:  {name: "version", kind: protoString},
+		10: {name: "description", kind: protoString},
+		11: {name: "scope", kind: protoEnum, enum: protoScopes},
+		12: {name: "hashes", kind: protoMessage, message: "Hash", repeated: true},
+		13: {name: "licenses", kind: protoMessage, message: "LicenseChoice", repeated: true},
+		14: {name: "copyright", kind: protoString},
+		15: {name: "cpe", kind: protoString},
+		16: {name: "purl", kind: protoString},
+		18: {name: "modified", kind: protoBool},
+		20: {name: "externalReferences", kind: protoMessage, message: "ExternalReference", repeated: true},
+		21: {name: "components", kind: protoMessage, message: "Component", repeated: true},
+		22: {name: "properties", kind: protoMessage, message: "Property", repeated: true},
+		28: {name: "manufacturer", kind: protoMessage, message: "OrganizationalEntity"},
+		29: {name: "authors", kind: protoMessage, message: "OrganizationalContact", repeated: true},
+		30: {name: "tags", kind: protoString, repeated: true},
+		31: {name: "omniborId", kind: protoString, repeated: true},
+		32: {name: "swhid", kind: protoString, repeated: true},
+	},
+	"Service": {
+		1:  {name: "bom-ref", kind: protoString},
+		2:  {name: "provider", kind: protoMessage, message: "OrganizationalEntity"},
+		3:  {name: "group", kind: protoString},
+		4:  {name: "name", kind: protoString},
+		5:  {name: "version", kind: protoString},
+		6:  {name: "description", kind: protoString},
+		7:  {name: "endpoints", kind: protoString, repeated: true},
+		8:  {name: "authenticated", kind: protoBool},
+		9:  {name: "x-trust-boundary", kind: protoBool},
+		11: {name: "licenses", kind: protoMessage, message: "LicenseChoice", repeated: true},
+		12: {name: "externalReferences", kind: protoMessage, message: "ExternalReference", repeated: true},
+		13: {name: "services", kind: protoMessage, message: "Service", repeated: true},
+		14: {name: "properties", kind: protoMessage, message: "Property", repeated: true},
+	},
+	"Dependency": {
+		1: {name: "ref", kind: protoString},
+		2: {name: "dependsOn", kind: protoRef, repeated: true},
+	},
+	"Hash": {
+		1: {name: "alg", kind: protoEnum, enum: protoHashAlgs},
+		2: {name: "content", kind: protoString},
+	},
+	"LicenseChoice": {
+		1: {name: "license", kind: protoMessage, message: "License"},
+		2: {name: "expression", kind: protoString},
+	},
+	"License": {
+		1: {name: "id", kind: protoString},
+		2: {name: "name", kind: protoString},
+		3: {name: "text", kind: protoMessage, message: "AttachedText"},
+		4: {name: "url", kind: protoString},
+		5: {name: "bom-ref", kind: protoString},
+	},
+	"AttachedText": {
+		1: {name: "contentType", kind: protoString},
+		2: {name: "encoding", kind: protoString},
+		3: {name: "content", kind: protoString},
+	},
+	"ExternalReference": {
+		1: {name: "type", kind: protoEnum, enum: protoRefTypes},
+		2: {name: "url", kind: protoString},
+		3: {name: "comment", kind: protoString},
+		4: {name: "hashes", kind: protoMessage, message: "Hash", repeated: true},
+	},
+	"OrganizationalEntity": {
+		1: {name: "name", kind: protoString},
+		2: {name: "url", kind: protoString, repeated: true},
+		3: {name: "contact", kind: protoMessage, message: "OrganizationalContact", repeated: true},
+		4: {name: "bom-ref", kind: protoString},
+	},
+	"OrganizationalContact": {
+		1: {name: "name", kind: protoString},
+		2: {name: "email", kind: protoString},
+		3: {name: "phone", kind: protoString},
+		4: {name: "bom-ref", kind: protoString},
+	},
+	"Property": {
+		1: {name: "name", kind: protoString},
+		2: {name: "value", kind: protoString},
+	},
+	"Lifecycle": {
+		1: {name: "phase", kind: protoEnum, enum: protoLifecycles},
+		2: {name: "name", kind: protoString},
+		3: {name: "description", kind: protoString},
+	},
+}
+
+// protoDecoder decodes CycloneDX protobuf messages into their JSON form.
+type protoDecoder struct {
+	// skipped records the unsupported fields seen, so each is reported once.
+	skipped  map[string]bool
+	warnings []string
+}
+
+// cycloneDXProtobufToJSON converts a protobuf-encoded CycloneDX BOM to the
+// equivalent JSON document, so it can be validated against the JSON schema of
+// the same spec version, like an XML BOM.
+//
+// Returns the JSON document and a warning per message field that was skipped
+// because the decoder does not support it (e.g., "Component field 19").
+func cycloneDXProtobufToJSON(data []byte) ([]byte, []string, error) {
+	d := &protoDecoder{skipped: map[string]bool{}}
+	obj, err := d.message(data, "Bom")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode protobuf: %v", err)
+	}
+	obj["bomFormat"] = SBOM_CYCLONEDX
+
+	converted, err := json.Marshal(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	return converted, d.warnings, nil
+}
+
+// message decodes a message of the given type.
+func (d *protoDecoder) message(data []byte, name string) (map[string]interface{}, error) {
+	fields := cycloneDXProtoMessages[name]
+	obj := map[string]interface{}{}
+
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("%s: malformed field tag", name)
+		}
+		data = data[n:]
+		number, wireType := int(tag>>3), int(tag&7)
+		if number == 0 {
+			return nil, fmt.Errorf("%s: invalid field number 0", name)
+		}
+
+		// the value is a varint (wire type 0) or, for the other supported
+		// wire types, a byte string
+		var varint uint64
+		var raw []byte
+		switch wireType {
+		case 0:
+			if varint, n = binary.Uvarint(data); n <= 0 {
+				return nil, fmt.Errorf("%s field %d: malformed varint", name, number)
+			}
+			data = data[n:]
+		case 1, 5:
+			size := 8
+			if wireType == 5 {
+				size = 4
+			}
+			if len(data) < size {
+				return nil, fmt.Errorf("%s field %d: truncated", name, number)
+			}
+			raw, data = data[:size], data[size:]
+		case 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return nil, fmt.Errorf("%s field %d: truncated", name, number)
+			}
+			raw, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return nil, fmt.Errorf("%s field %d: unsupported wire type %d", name, number, wireType)
+		}
+
+		field, ok := fields[number]
+		if !ok {
+			d.skip(name, number)
+			continue
+		}
+		varintKind := field.kind == protoBool || field.kind == protoInt || field.kind == protoEnum
+		if (varintKind && wireType != 0) || (!varintKind && wireType != 2) {
+			return nil, fmt.Errorf("%s field %d (%s): unexpected wire type %d", name, number, field.name, wireType)
+		}
+
+		var value interface{}
+		switch field.kind {
+		case protoString:
+			value = string(raw)
+		case protoBool:
+			value = varint != 0
+		case protoInt:
+			value = int64(varint)
+		case protoEnum:
+			if varint >= uint64(len(field.enum)) {
+				return nil, fmt.Errorf("%s field %d (%s): unknown enum value %d", name, number, field.name, varint)
+			}
+			if field.enum[varint] == "" {
+				continue
+			}
+			value = field.enum[varint]
+		case protoMessage:
+			nested, err := d.message(raw, field.message)
+			if err != nil {
+				return nil, err
+			}
+			value = nested
+		case protoTimestamp:
+			timestamp, err := d.timestamp(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s field %d (%s): %v", name, number, field.name, err)
+			}
+			value = timestamp
+		case protoRef:
+			dependency, err := d.message(raw, "Dependency")
+			if err != nil {
+				return nil, err
+			}
+			value, _ = dependency["ref"].(string)
+		}
+
+		if field.repeated {
+			list, _ := obj[field.name].([]interface{})
+			obj[field.name] = append(list, value)
+		} else {
+			obj[field.name] = value
+		}
+	}
+
+	return obj, nil
+}
+
+// timestamp decodes a google.protobuf.Timestamp.
+func (d *protoDecoder) timestamp(data []byte) (string, error) {
+	var seconds, nanos int64
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 || tag&7 != 0 {
+			return "", errors.New("malformed timestamp")
+		}
+		value, m := binary.Uvarint(data[n:])
+		if m <= 0 {
+			return "", errors.New("malformed timestamp")
+		}
+		data = data[n+m:]
+		switch tag >> 3 {
+		case 1:
+			seconds = int64(value)
+		case 2:
+			nanos = int64(value)
+		}
+	}
+	return time.Unix(seconds, nanos).UTC().Format(time.RFC3339Nano), nil
+}
+
+// skip records an unsupported field, warning about it the first time.
+func (d *protoDecoder) skip(message string, number int) {
+	key := fmt.Sprintf("%s field %d", message, number)
+	if d.skipped[key] {
+		return
+	}
+	d.skipped[key] = true
+	d.warnings = append(d.warnings, fmt.Sprintf("protobuf %s is not supported by this validator and was not validated", key))
+}
@@ -0,0 +1,71 @@
+//go:build !sbomvalidator_no_cyclonedx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCycloneDXProtobufToJSON(t *testing.T) {
+	converted, warnings, err := cycloneDXProtobufToJSON(protoBOM())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"bomFormat":"CycloneDX","components":[{"bom-ref":"pkg:npm/left-pad@1.3.0","hashes":[{"alg":"SHA-256","content":"abc"}],` +
+		`"licenses":[{"license":{"id":"MIT"}}],"name":"left-pad","purl":"pkg:npm/left-pad@1.3.0","type":"library","version":"1.3.0"}],` +
+		`"dependencies":[{"dependsOn":["pkg:npm/left-pad@1.3.0"],"ref":"app"}],` +
+		`"metadata":{"lifecycles":[{"phase":"build"}],"timestamp":"2024-10-22T12:00:00.5Z"},"specVersion":"1.6","version":1}`
+	if string(converted) != want {
+		t.Errorf("cycloneDXProtobufToJSON() =\n%s\nwant\n%s", converted, want)
+	}
+	if len(warnings) != 1 || warnings[0] != "protobuf Component field 19 is not supported by this validator and was not validated" {
+		t.Errorf("unexpected warnings: %q", warnings)
+	}
+}
+
+func TestCycloneDXProtobufToJSONErrors(t *testing.T) {
+	valid := func(fields ...byte) []byte { return append(protoBytes(1, []byte("1.6")), fields...) }
+	tests := []struct {
+		name    string
+		input   []byte
+		wantErr string
+	}{
+		{name: "Truncated field", input: valid(0x2a, 0x10, 0x01), wantErr: "Bom field 5: truncated"},
+		{name: "Wrong wire type", input: valid(protoVarint(8, 1)...), wantErr: "Bom field 8 (dependencies): unexpected wire type 0"},
+		{name: "Unknown enum value", input: valid(protoBytes(5, protoVarint(1, 99))...), wantErr: "Component field 1 (type): unknown enum value 99"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := cycloneDXProtobufToJSON(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDetectSBOMTypeProtobuf(t *testing.T) {
+	sbomType, err := DetectSBOMType(bytes.NewReader(protoBOM()))
+	if err != nil || sbomType != SBOM_CYCLONEDX {
+		t.Errorf("DetectSBOMType() = %q, %v; want CycloneDX", sbomType, err)
+	}
+}
+
+func TestValidateSBOMDataProtobuf(t *testing.T) {
+	result, err := ValidateSBOMData(append(protoBytes(1, []byte("1.6")), protoBytes(5, []byte{0x08})...))
+	if err == nil || !strings.Contains(err.Error(), "failed to parse CycloneDX protobuf") {
+		t.Errorf("expected a protobuf error, got %v", err)
+	}
+	if result.DetectedFormat != "protobuf" || result.SBOMType != SBOM_CYCLONEDX {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	_, err = ValidateSBOMData(protoBOM(), WithFormats(SBOM_SPDX))
+	if err == nil || !strings.Contains(err.Error(), "CycloneDX is not enabled") {
+		t.Errorf("expected a format error, got %v", err)
+	}
+}
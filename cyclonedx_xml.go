@@ -0,0 +1,479 @@
+//go:build !sbomvalidator_no_cyclonedx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// cycloneDXXMLNamespace is the namespace of a CycloneDX XML BOM, followed by
+// its spec version (e.g., "http://cyclonedx.org/schema/bom/1.6").
+const cycloneDXXMLNamespace = "http://cyclonedx.org/schema/bom/"
+
+// xmlElementAliases maps XML element names to the JSON property that holds
+// them when the two encodings name them differently.
+var xmlElementAliases = map[string]string{
+	// <dependency ref="a"><dependency ref="b"/></dependency>
+	"dependency": "dependsOn",
+}
+
+// xmlElement is a parsed XML element of the CycloneDX namespace.
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlElement
+	text     string
+}
+
+// cycloneDXXMLToJSON converts a CycloneDX XML BOM to the equivalent JSON
+// document, so it can be validated against the JSON schema of the same spec
+// version. Both encodings describe the same object model; the JSON schema
+// guides the conversion so that attributes and child elements become
+// properties, container elements (e.g., <components>) become arrays, and
+// numbers and booleans get their JSON types.
+//
+// Elements and attributes from other namespaces (extensions) are dropped.
+// Unknown CycloneDX elements are kept, so the schema reports them.
+//
+// The XSDs are not consulted, so constraints only they express go unchecked:
+// the order of child elements (xs:sequence), the uniqueness of xs:ID
+// attributes and the lexical forms of XSD types the JSON schema does not
+// restrict the same way. CycloneDX 1.0 and 1.1 were only published as XSDs,
+// so documents of those versions are converted but then fail to load a schema.
+func cycloneDXXMLToJSON(data []byte) ([]byte, error) {
+	root, namespace, err := parseXMLRoot(data)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := xmlBOMVersion(root, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// the schema only guides the conversion, so fall back to the latest one
+	// for versions without a JSON schema and let validation report them
+	schema, err := loadSBOMSchema(version, SBOM_CYCLONEDX)
+	if err != nil {
+		latest, latestErr := latestSchemaVersion(SBOM_CYCLONEDX)
+		if latestErr != nil {
+			return nil, latestErr
+		}
+		if schema, err = loadSBOMSchema(latest, SBOM_CYCLONEDX); err != nil {
+			return nil, err
+		}
+	}
+
+	var rootSchema map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &rootSchema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	definitions, _ := rootSchema["definitions"].(map[string]interface{})
+	c := &xmlConverter{definitions: definitions}
+
+	obj, _ := c.convertObject(root, rootSchema).(map[string]interface{})
+	obj["bomFormat"] = SBOM_CYCLONEDX
+	obj["specVersion"] = version
+
+	converted, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+// parseXMLRoot parses an XML document into a tree of the elements in the
+// namespace of its root element, and returns that namespace.
+func parseXMLRoot(data []byte) (*xmlElement, string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root *xmlElement
+	var namespace string
+	var stack []*xmlElement
+	skip := 0 // depth inside an element of another namespace
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root == nil {
+				namespace = t.Name.Space
+			}
+			if skip > 0 || t.Name.Space != namespace {
+				skip++
+				continue
+			}
+
+			el := &xmlElement{name: t.Name.Local}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "" && attr.Name.Local != "xmlns" {
+					el.attrs = append(el.attrs, attr)
+				}
+			}
+			if root == nil {
+				root = el
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, el)
+			}
+			stack = append(stack, el)
+
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if skip == 0 && len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, "", fmt.Errorf("no root element")
+	}
+	return root, namespace, nil
+}
+
+// xmlBOMVersion returns the spec version from the namespace of a <bom> root.
+func xmlBOMVersion(root *xmlElement, namespace string) (string, error) {
+	version, ok := strings.CutPrefix(namespace, cycloneDXXMLNamespace)
+	if root.name != "bom" || !ok || version == "" {
+		return "", fmt.Errorf("not a CycloneDX XML document: root element <%s> in namespace %q", root.name, namespace)
+	}
+	return version, nil
+}
+
+// xmlConverter converts XML elements to JSON values, guided by the JSON
+// schema of the spec version.
+type xmlConverter struct {
+	definitions map[string]interface{}
+}
+
+// resolve follows local "#/definitions/..." references. References to other
+// schema files resolve to nil, which converts values generically.
+func (c *xmlConverter) resolve(schema map[string]interface{}) map[string]interface{} {
+	for i := 0; schema != nil && i < 16; i++ {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return schema
+		}
+		name, ok := strings.CutPrefix(ref, "#/definitions/")
+		if !ok {
+			return nil
+		}
+		schema, _ = c.definitions[name].(map[string]interface{})
+	}
+	return schema
+}
+
+// variants returns the oneOf, anyOf and allOf alternatives of a schema.
+func variants(schema map[string]interface{}) []map[string]interface{} {
+	var result []map[string]interface{}
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		list, _ := schema[keyword].([]interface{})
+		for _, v := range list {
+			if variant, ok := v.(map[string]interface{}); ok {
+				result = append(result, variant)
+			}
+		}
+	}
+	return result
+}
+
+// schemaType returns the JSON type of a schema, or "" if it is unknown.
+func (c *xmlConverter) schemaType(schema map[string]interface{}) string {
+	schema = c.resolve(schema)
+	if schema == nil {
+		return ""
+	}
+
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		if len(t) > 0 {
+			s, _ := t[0].(string)
+			return s
+		}
+	}
+
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	for _, variant := range variants(schema) {
+		if t := c.schemaType(variant); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// properties returns the properties of an object schema, merged across its
+// alternatives.
+func (c *xmlConverter) properties(schema map[string]interface{}) map[string]interface{} {
+	schema = c.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	merged := map[string]interface{}{}
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, prop := range props {
+			merged[name] = prop
+		}
+	}
+	for _, variant := range variants(schema) {
+		for name, prop := range c.properties(variant) {
+			if _, exists := merged[name]; !exists {
+				merged[name] = prop
+			}
+		}
+	}
+	return merged
+}
+
+// items returns the item schema of an array schema, merging tuple and
+// alternative item schemas into one object schema.
+func (c *xmlConverter) items(schema map[string]interface{}) map[string]interface{} {
+	schema = c.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	var candidates []interface{}
+	switch items := schema["items"].(type) {
+	case map[string]interface{}:
+		candidates = append(candidates, items)
+	case []interface{}:
+		candidates = append(candidates, items...)
+	}
+	for _, variant := range variants(schema) {
+		if item := c.items(variant); item != nil {
+			candidates = append(candidates, item)
+		}
+	}
+
+	if len(candidates) == 1 {
+		item, _ := candidates[0].(map[string]interface{})
+		return item
+	}
+	if len(candidates) > 1 {
+		return map[string]interface{}{"anyOf": candidates}
+	}
+	return nil
+}
+
+// chooseVariant picks the alternative of a schema whose shape matches the
+// element: an object alternative with a property named after one of the
+// children, otherwise an array alternative.
+func (c *xmlConverter) chooseVariant(el *xmlElement, schema map[string]interface{}) map[string]interface{} {
+	resolved := c.resolve(schema)
+	if resolved == nil || resolved["type"] != nil {
+		return schema
+	}
+
+	alternatives := variants(resolved)
+	for _, variant := range alternatives {
+		if c.schemaType(variant) != "object" {
+			continue
+		}
+		props := c.properties(variant)
+		for _, child := range el.children {
+			if _, ok := props[child.name]; ok {
+				return variant
+			}
+		}
+	}
+	for _, variant := range alternatives {
+		if c.schemaType(variant) == "array" {
+			return variant
+		}
+	}
+	return schema
+}
+
+// convert returns the JSON value of an element.
+func (c *xmlConverter) convert(el *xmlElement, schema map[string]interface{}) interface{} {
+	schema = c.chooseVariant(el, schema)
+
+	switch c.schemaType(schema) {
+	case "array":
+		return c.convertArray(el.children, schema)
+	case "object":
+		return c.convertObject(el, schema)
+	}
+
+	if len(el.children) > 0 {
+		return c.convertObject(el, nil)
+	}
+	return c.convertScalar(el, schema)
+}
+
+// convertScalar returns the JSON value of a text-only element.
+func (c *xmlConverter) convertScalar(el *xmlElement, schema map[string]interface{}) interface{} {
+	text := strings.TrimSpace(el.text)
+
+	// references are attributes in XML, e.g. <dependency ref="a"/>
+	if text == "" {
+		for _, attr := range el.attrs {
+			if attr.Name.Local == "ref" {
+				return attr.Value
+			}
+		}
+	}
+	return scalarValue(text, c.schemaType(schema))
+}
+
+// scalarValue converts text to the JSON type of a schema. Text that is not
+// valid for the type is kept as a string, so the schema reports it.
+func scalarValue(text, schemaType string) interface{} {
+	switch schemaType {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	}
+	return text
+}
+
+// convertArray returns the JSON array for the children of a container
+// element. When the item schema wraps a single property named after the
+// child (e.g., <licenses><license/></licenses> becomes
+// [{"license": {...}}]) the child is wrapped in that property.
+func (c *xmlConverter) convertArray(children []*xmlElement, schema map[string]interface{}) []interface{} {
+	items := c.items(schema)
+	itemProps := c.properties(items)
+
+	result := []interface{}{}
+	for _, child := range children {
+		prop, wrapped := itemProps[child.name]
+		if !wrapped {
+			result = append(result, c.convert(child, items))
+			continue
+		}
+
+		item := map[string]interface{}{child.name: c.convert(child, prop.(map[string]interface{}))}
+		if t := c.schemaType(prop.(map[string]interface{})); t != "object" && t != "array" {
+			// attributes of a wrapped scalar belong to the wrapper,
+			// e.g. <expression bom-ref="x">MIT</expression>
+			for _, attr := range child.attrs {
+				item[jsonPropertyName(attr.Name.Local, itemProps)] = attr.Value
+			}
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// convertObject returns the JSON object of an element, taking properties from
+// its attributes, its child elements and its text.
+func (c *xmlConverter) convertObject(el *xmlElement, schema map[string]interface{}) interface{} {
+	props := c.properties(schema)
+	obj := map[string]interface{}{}
+
+	for _, attr := range el.attrs {
+		name := jsonPropertyName(attr.Name.Local, props)
+		prop, _ := props[name].(map[string]interface{})
+		obj[name] = scalarValue(attr.Value, c.schemaType(prop))
+	}
+
+	if text := strings.TrimSpace(el.text); text != "" && len(el.children) == 0 {
+		key := "content"
+		for _, candidate := range []string{"content", "value", "text"} {
+			if _, ok := props[candidate]; ok {
+				key = candidate
+				break
+			}
+		}
+		obj[key] = text
+	}
+
+	for _, child := range el.children {
+		name := jsonPropertyName(child.name, props)
+		if _, ok := props[name]; !ok {
+			if alias, ok := xmlElementAliases[child.name]; ok {
+				if _, ok := props[alias]; ok {
+					name = alias
+				}
+			}
+		}
+		prop, _ := props[name].(map[string]interface{})
+		prop = c.chooseVariant(child, prop)
+
+		if c.schemaType(prop) != "array" {
+			obj[name] = c.convert(child, prop)
+			continue
+		}
+
+		existing, _ := obj[name].([]interface{})
+		if existing == nil {
+			existing = []interface{}{}
+		}
+		if isXMLContainer(child) {
+			obj[name] = append(existing, c.convertArray(child.children, prop)...)
+		} else {
+			// repeated elements without a container, e.g. <omniborId>
+			obj[name] = append(existing, c.convert(child, c.items(prop)))
+		}
+	}
+
+	return obj
+}
+
+// isXMLContainer reports whether an element wraps the items of an array (e.g.,
+// <components>) rather than being an item itself (e.g., <omniborId>). Empty
+// elements such as <dependsOn/> are empty containers. Containers hold
+// elements of a single name.
+func isXMLContainer(el *xmlElement) bool {
+	if len(el.children) == 0 {
+		return strings.TrimSpace(el.text) == "" && len(el.attrs) == 0
+	}
+	for _, child := range el.children {
+		if child.name != el.children[0].name {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonPropertyName returns the JSON property for an XML name, which is the
+// same name or its camel-cased form (e.g., "content-type" and "contentType").
+func jsonPropertyName(name string, props map[string]interface{}) string {
+	if _, ok := props[name]; ok {
+		return name
+	}
+
+	parts := strings.Split(name, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	if camel := strings.Join(parts, ""); camel != name {
+		if _, ok := props[camel]; ok {
+			return camel
+		}
+	}
+	return name
+}
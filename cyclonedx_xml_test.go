@@ -0,0 +1,173 @@
+//go:build !sbomvalidator_no_cyclonedx
+
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCycloneDXXMLToJSON(t *testing.T) {
+	xmlBOM := `<?xml version="1.0" encoding="UTF-8"?>
+<bom xmlns="http://cyclonedx.org/schema/bom/1.6" xmlns:ext="urn:example:ext"
+     serialNumber="urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79" version="2">
+  <metadata>
+    <timestamp>2024-10-22T12:00:00Z</timestamp>
+    <tools>
+      <components>
+        <component type="application"><name>generator</name></component>
+      </components>
+    </tools>
+  </metadata>
+  <components>
+    <component type="library" bom-ref="lib-a">
+      <name>lib-a</name>
+      <version>1.0.0</version>
+      <hashes>
+        <hash alg="SHA-256">d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592</hash>
+      </hashes>
+      <licenses>
+        <expression>MIT OR Apache-2.0</expression>
+      </licenses>
+      <omniborId>gitoid:blob:sha1:261eeb9e9f8b2b4b0d119366dda99c6fd7d35c64</omniborId>
+      <omniborId>gitoid:blob:sha256:9a8a2f7b0d3b4f2d2a7b3d6e2f1c0b9a8d7c6e5f4a3b2c1d0e9f8a7b6c5d4e3f</omniborId>
+      <properties>
+        <property name="build">release</property>
+      </properties>
+      <ext:internal>dropped</ext:internal>
+    </component>
+    <component type="library" bom-ref="lib-b">
+      <name>lib-b</name>
+    </component>
+  </components>
+  <dependencies>
+    <dependency ref="lib-a">
+      <dependency ref="lib-b"/>
+    </dependency>
+    <dependency ref="lib-b"/>
+  </dependencies>
+</bom>`
+
+	got, err := cycloneDXXMLToJSON([]byte(xmlBOM))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.6",
+		"serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+		"version": 2,
+		"metadata": {
+			"timestamp": "2024-10-22T12:00:00Z",
+			"tools": {"components": [{"type": "application", "name": "generator"}]}
+		},
+		"components": [
+			{
+				"type": "library",
+				"bom-ref": "lib-a",
+				"name": "lib-a",
+				"version": "1.0.0",
+				"hashes": [{"alg": "SHA-256", "content": "d7a8fbb307d7809469ca9abcb0082e4f8d5651e46d3cdb762d02d0bf37c9e592"}],
+				"licenses": [{"expression": "MIT OR Apache-2.0"}],
+				"omniborId": [
+					"gitoid:blob:sha1:261eeb9e9f8b2b4b0d119366dda99c6fd7d35c64",
+					"gitoid:blob:sha256:9a8a2f7b0d3b4f2d2a7b3d6e2f1c0b9a8d7c6e5f4a3b2c1d0e9f8a7b6c5d4e3f"
+				],
+				"properties": [{"name": "build", "value": "release"}]
+			},
+			{"type": "library", "bom-ref": "lib-b", "name": "lib-b"}
+		],
+		"dependencies": [
+			{"ref": "lib-a", "dependsOn": ["lib-b"]},
+			{"ref": "lib-b"}
+		]
+	}`
+
+	var gotObj, wantObj interface{}
+	if err := json.Unmarshal(got, &gotObj); err != nil {
+		t.Fatalf("Converted document is not JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantObj); err != nil {
+		t.Fatalf("Invalid expected document: %v", err)
+	}
+	if !reflect.DeepEqual(gotObj, wantObj) {
+		t.Errorf("cycloneDXXMLToJSON() = %s", got)
+	}
+}
+
+func TestCycloneDXXMLToJSONSample(t *testing.T) {
+	data, err := os.ReadFile("sample-sboms/sample-1.6.cdx.xml")
+	if err != nil {
+		t.Fatalf("Failed to read sample: %v", err)
+	}
+
+	got, err := cycloneDXXMLToJSON(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(got, &obj); err != nil {
+		t.Fatalf("Converted document is not JSON: %v", err)
+	}
+	components, _ := obj["components"].([]interface{})
+	if len(components) != 1 {
+		t.Fatalf("Expected 1 component, got %d", len(components))
+	}
+	licenses, _ := components[0].(map[string]interface{})["licenses"].([]interface{})
+	if len(licenses) != 1 || !reflect.DeepEqual(licenses[0], map[string]interface{}{"license": map[string]interface{}{"id": "MIT"}}) {
+		t.Errorf("Unexpected licenses: %v", licenses)
+	}
+}
+
+func TestCycloneDXXMLToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "Malformed XML", input: `<bom xmlns="http://cyclonedx.org/schema/bom/1.6"><components>`, wantErr: "EOF"},
+		{name: "Not a BOM", input: `<project xmlns="http://maven.apache.org/POM/4.0.0"/>`, wantErr: "not a CycloneDX XML document"},
+		{name: "No namespace", input: `<bom/>`, wantErr: "not a CycloneDX XML document"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cycloneDXXMLToJSON([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateSBOMDataXML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "Not CycloneDX", input: `<spdx/>`, wantErr: "failed to parse CycloneDX XML"},
+		{name: "Format not enabled", input: `<bom xmlns="http://cyclonedx.org/schema/bom/1.6"/>`,
+			opts: []Option{WithFormats(SBOM_SPDX)}, wantErr: "CycloneDX is not enabled"},
+		{name: "XSD-only version", input: `<bom xmlns="http://cyclonedx.org/schema/bom/1.1" version="1"/>`,
+			wantErr: "no schema for CycloneDX version 1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMData([]byte(tt.input), tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if result.DetectedFormat != "XML" {
+				t.Errorf("DetectedFormat = %q, want XML", result.DetectedFormat)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Package daemon serves sbom-validator over a Unix domain socket with a
+// line protocol, so local tools such as pre-commit hooks and editors get
+// validation from a warm process, with schemas already compiled, instead of
+// paying process startup and schema compilation on every run.
+//
+// Each request is one line; each response is one line of JSON:
+//
+//	VALIDATE <path>    validates the file at path, which should be absolute
+//	DATA <n>           validates the n bytes following the line, e.g. an
+//	                   unsaved editor buffer
+//	PING               replies {"pong":true}
+//	QUIT               closes the connection
+//
+// Validation responses are {"result": <ValidationResult>} or, when the SBOM
+// cannot be validated, {"error": "<message>"}. A connection may send any
+// number of requests.
+//
+// Example:
+//
+//	$ printf 'VALIDATE %s\n' "$PWD/app.cdx.json" | nc -U /tmp/sbom-validator.sock
+//	{"result":{"isValid":true,"sbomType":"CycloneDX","sbomVersion":"1.6",...}}
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// DefaultMaxDataBytes caps the size of a DATA request when Config.MaxDataBytes
+// is zero.
+const DefaultMaxDataBytes = 256 << 20
+
+// Config configures a Server.
+type Config struct {
+	// MaxDataBytes caps the size of a DATA request.
+	MaxDataBytes int64
+	// Timeout bounds the validation of each SBOM; an SBOM that takes longer
+	// fails with a deadline error. Zero means no limit.
+	Timeout time.Duration
+	// Options are passed to `sbomvalidator.ValidateSBOMDataContext` for every
+	// SBOM.
+	Options []sbomvalidator.Option
+	// Logger receives connection failures and the diagnostics of validation
+	// (see `sbomvalidator.WithLogger`). Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// Response is the line written for each request.
+type Response struct {
+	Result *sbomvalidator.ValidationResult `json:"result,omitempty"`
+	Error  string                          `json:"error,omitempty"`
+	Pong   bool                            `json:"pong,omitempty"`
+}
+
+// Server validates SBOMs for clients of a listener. Create it with New.
+type Server struct {
+	cfg Config
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    sync.WaitGroup
+}
+
+// New returns a Server configured by cfg.
+func New(cfg Config) *Server {
+	if cfg.MaxDataBytes <= 0 {
+		cfg.MaxDataBytes = DefaultMaxDataBytes
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	// first, so that Options may replace it
+	cfg.Options = append([]sbomvalidator.Option{sbomvalidator.WithLogger(cfg.Logger)}, cfg.Options...)
+	return &Server{cfg: cfg}
+}
+
+// ListenAndServe listens on the Unix domain socket at path and serves clients
+// until Close is called. A stale socket file left by a previous daemon is
+// replaced; the socket is removed on Close.
+//
+// Example:
+//
+//	d := daemon.New(daemon.Config{})
+//	if err := d.ListenAndServe("/tmp/sbom-validator.sock"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *Server) ListenAndServe(path string) error {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket: %v", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	// only the owner may talk to the daemon
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener and serves each of them on its own
+// goroutine, until Close is called. It then waits for open connections to
+// finish and returns nil.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				s.conns.Wait()
+				return nil
+			}
+			return err
+		}
+
+		s.conns.Add(1)
+		go func() {
+			defer s.conns.Done()
+			defer conn.Close()
+			if err := s.ServeConn(conn); err != nil {
+				s.cfg.Logger.Error("daemon connection failed", "error", err)
+			}
+		}()
+	}
+}
+
+// Close stops accepting connections. Connections already open are served
+// until their clients disconnect.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// ServeConn serves the requests of a single client until it sends QUIT or
+// closes the connection.
+func (s *Server) ServeConn(rw io.ReadWriter) error {
+	reader := bufio.NewReader(rw)
+	encoder := json.NewEncoder(rw)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		var response Response
+		switch strings.ToUpper(command) {
+		case "VALIDATE":
+			response = s.validateFile(arg)
+		case "DATA":
+			var fatal error
+			response, fatal = s.validateData(reader, arg)
+			if fatal != nil {
+				encoder.Encode(response)
+				return fatal
+			}
+		case "PING":
+			response = Response{Pong: true}
+		case "QUIT":
+			return nil
+		case "":
+			continue
+		default:
+			response = Response{Error: fmt.Sprintf("unknown command %q", command)}
+		}
+
+		if err := encoder.Encode(response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) validateFile(path string) Response {
+	if path == "" {
+		return Response{Error: "usage: VALIDATE <path>"}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	return s.validate(content)
+}
+
+// validateData reads and validates the content of a DATA request. A size
+// that cannot be honoured leaves the stream out of sync, so it is returned
+// as a fatal error that ends the connection.
+func (s *Server) validateData(reader *bufio.Reader, arg string) (Response, error) {
+	size, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || size < 0 {
+		err := fmt.Errorf("usage: DATA <bytes>")
+		return Response{Error: err.Error()}, err
+	}
+	if size > s.cfg.MaxDataBytes {
+		err := fmt.Errorf("request has %d bytes, the limit is %d", size, s.cfg.MaxDataBytes)
+		return Response{Error: err.Error()}, err
+	}
+
+	content := make([]byte, size)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return Response{Error: err.Error()}, err
+	}
+	return s.validate(content), nil
+}
+
+func (s *Server) validate(content []byte) Response {
+	ctx := context.Background()
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	result, err := sbomvalidator.ValidateSBOMDataContext(ctx, content, s.cfg.Options...)
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	return Response{Result: result}
+}
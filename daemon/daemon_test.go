@@ -0,0 +1,144 @@
+//go:build !sbomvalidator_no_spdx
+
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "test",
+  "documentNamespace": "https://example.com/test",
+  "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: test"]}
+}`
+
+func TestServeConn(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.spdx.json")
+	if err := os.WriteFile(path, []byte(validSPDX), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	requests := "PING\n" +
+		"VALIDATE " + path + "\n" +
+		"VALIDATE " + filepath.Join(dir, "missing.json") + "\n" +
+		fmt.Sprintf("DATA %d\n%s", len(`{"spdxVersion": "SPDX-2.3"}`), `{"spdxVersion": "SPDX-2.3"}`) +
+		"\n" +
+		"BOGUS\n" +
+		"QUIT\n" +
+		"PING\n"
+
+	var out strings.Builder
+	s := New(Config{})
+	if err := s.ServeConn(readWriter{strings.NewReader(requests), &out}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	responses := decodeResponses(t, out.String())
+	if len(responses) != 5 {
+		t.Fatalf("Expected 5 responses (none after QUIT), got %d: %s", len(responses), out.String())
+	}
+	if !responses[0].Pong {
+		t.Errorf("Expected a pong, got %+v", responses[0])
+	}
+	if r := responses[1].Result; r == nil || !r.IsValid {
+		t.Errorf("Expected a valid result, got %+v", responses[1])
+	}
+	if responses[2].Error == "" {
+		t.Errorf("Expected an error for a missing file, got %+v", responses[2])
+	}
+	if r := responses[3].Result; r == nil || r.IsValid {
+		t.Errorf("Expected an invalid result, got %+v", responses[3])
+	}
+	if !strings.Contains(responses[4].Error, "unknown command") {
+		t.Errorf("Expected an unknown command error, got %+v", responses[4])
+	}
+}
+
+func TestServeConnDataTooLarge(t *testing.T) {
+	var out strings.Builder
+	s := New(Config{MaxDataBytes: 10})
+	err := s.ServeConn(readWriter{strings.NewReader("DATA 11\n" + validSPDX), &out})
+	if err == nil {
+		t.Fatalf("Expected the connection to be closed")
+	}
+	if responses := decodeResponses(t, out.String()); len(responses) != 1 || responses[0].Error == "" {
+		t.Errorf("Expected an error response, got %s", out.String())
+	}
+}
+
+func TestListenAndServe(t *testing.T) {
+	// socket paths are limited to ~100 bytes, which t.TempDir may exceed
+	dir, err := os.MkdirTemp("", "sbomd")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "d.sock")
+
+	s := New(Config{})
+	served := make(chan error, 1)
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	go func() { served <- s.Serve(listener) }()
+
+	if err := New(Config{}).ListenAndServe(socket); err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("Expected a second daemon to be refused, got %v", err)
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fmt.Fprintf(conn, "DATA %d\n%s", len(validSPDX), validSPDX)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if responses := decodeResponses(t, line); len(responses) != 1 || responses[0].Result == nil || !responses[0].Result.IsValid {
+		t.Errorf("Unexpected response: %s", line)
+	}
+	conn.Close()
+
+	s.Close()
+	if err := <-served; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+type readWriter struct {
+	*strings.Reader
+	w *strings.Builder
+}
+
+func (rw readWriter) Write(p []byte) (int, error) {
+	return rw.w.Write(p)
+}
+
+func decodeResponses(t *testing.T, out string) []Response {
+	t.Helper()
+	var responses []Response
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		var r Response
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Invalid response %q: %v", line, err)
+		}
+		responses = append(responses, r)
+	}
+	return responses
+}
@@ -0,0 +1,131 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// publicRegistryTypes are purl types that resolve, by default, against a
+// public package registry where anyone can publish a package name.
+var publicRegistryTypes = map[string]bool{
+	"cargo":     true,
+	"cocoapods": true,
+	"composer":  true,
+	"conda":     true,
+	"gem":       true,
+	"hex":       true,
+	"maven":     true,
+	"npm":       true,
+	"nuget":     true,
+	"pub":       true,
+	"pypi":      true,
+}
+
+// checkDependencyConfusion flags components whose purl is a dependency
+// confusion indicator given the organisation's internal namespaces:
+//
+//   - an internal name that resolves against a public registry (a public purl
+//     type without a `repository_url` qualifier), which an attacker can claim;
+//   - a package served from an internal registry (a `repository_url`
+//     qualifier) whose name is outside the internal namespaces, so the same
+//     name on the public registry could shadow it.
+//
+// A namespace entry matches a purl namespace equal to it or nested under it
+// (e.g., "com.acme" matches "com.acme.billing", "@acme" matches "@acme").
+// An entry ending in "*" instead matches names with that prefix, for
+// ecosystems without namespaces (e.g., "acme-*" for PyPI).
+//
+// Returns a warning message per component, prefixed with the JSON path of its
+// purl.
+func checkDependencyConfusion(obj map[string]interface{}, sbomType string, namespaces []string) []string {
+	if len(namespaces) == 0 {
+		return nil
+	}
+
+	var warnings []string
+	check := func(path, purl string) {
+		if warning := dependencyConfusionWarning(purl, namespaces); warning != "" {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", path, warning))
+		}
+	}
+
+	if sbomType == SBOM_CYCLONEDX {
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			if purl, ok := component["purl"].(string); ok {
+				check(path+".purl", purl)
+			}
+		})
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		packages, _ := obj["packages"].([]interface{})
+		for i, p := range packages {
+			pkg, _ := p.(map[string]interface{})
+			refs, _ := pkg["externalRefs"].([]interface{})
+			for j, r := range refs {
+				ref, _ := r.(map[string]interface{})
+				if refType, _ := ref["referenceType"].(string); refType != "purl" {
+					continue
+				}
+				if purl, ok := ref["referenceLocator"].(string); ok {
+					check(fmt.Sprintf("packages.%d.externalRefs.%d.referenceLocator", i, j), purl)
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// dependencyConfusionWarning returns the warning for a single purl, or "" if
+// it is not an indicator.
+func dependencyConfusionWarning(purl string, namespaces []string) string {
+	p, err := parsePackageURL(purl)
+	if err != nil {
+		return ""
+	}
+
+	internalName := isInternalPackage(p, namespaces)
+	internalRegistry := p.Qualifiers["repository_url"] != ""
+
+	if internalName && !internalRegistry && publicRegistryTypes[p.Type] {
+		return fmt.Sprintf("internal package %q resolves against the public %s registry (possible dependency confusion)",
+			purlDisplayName(p), p.Type)
+	}
+	if !internalName && internalRegistry && publicRegistryTypes[p.Type] {
+		return fmt.Sprintf("package %q is served from internal registry %s but is not in an internal namespace (possible dependency confusion)",
+			purlDisplayName(p), p.Qualifiers["repository_url"])
+	}
+	return ""
+}
+
+// isInternalPackage reports whether the purl's namespace or name matches one
+// of the internal namespaces.
+func isInternalPackage(p *packageURL, namespaces []string) bool {
+	namespace := strings.ToLower(p.Namespace)
+	name := strings.ToLower(p.Name)
+
+	for _, entry := range namespaces {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+			continue
+		}
+
+		if namespace == entry || strings.HasPrefix(namespace, entry+".") || strings.HasPrefix(namespace, entry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func purlDisplayName(p *packageURL) string {
+	if p.Namespace != "" {
+		return p.Namespace + "/" + p.Name
+	}
+	return p.Name
+}
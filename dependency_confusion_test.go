@@ -0,0 +1,122 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDependencyConfusionWarning(t *testing.T) {
+	namespaces := []string{"@acme", "com.acme", "acme-*"}
+
+	tests := []struct {
+		name string
+		purl string
+		want string
+	}{
+		{name: "Internal npm scope on public registry", purl: "pkg:npm/%40acme/billing@1.0.0", want: "resolves against the public npm registry"},
+		{name: "Nested maven namespace on public registry", purl: "pkg:maven/com.acme.billing/core@1.0.0", want: "resolves against the public maven registry"},
+		{name: "Name prefix on public registry", purl: "pkg:pypi/acme-utils@1.0.0", want: "resolves against the public pypi registry"},
+		{name: "Internal name from internal registry", purl: "pkg:npm/%40acme/billing@1.0.0?repository_url=https://npm.acme.internal", want: ""},
+		{name: "Public name from internal registry", purl: "pkg:pypi/billing-utils@1.0.0?repository_url=https://pypi.acme.internal", want: "is served from internal registry"},
+		{name: "Public package", purl: "pkg:npm/lodash@4.17.21", want: ""},
+		{name: "Similar but different namespace", purl: "pkg:maven/com.acmecorp/core@1.0.0", want: ""},
+		{name: "Non-registry type", purl: "pkg:github/acme/billing@1.0.0", want: ""},
+		{name: "Invalid purl", purl: "not-a-purl", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dependencyConfusionWarning(tt.purl, namespaces)
+			if tt.want == "" && got != "" {
+				t.Errorf("expected no warning, got %q", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("warning = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDependencyConfusion(t *testing.T) {
+	cyclonedx, _ := parseJSON(`{"bomFormat": "CycloneDX", "components": [
+		{"name": "lodash", "purl": "pkg:npm/lodash@4.17.21"},
+		{"name": "app", "components": [{"name": "billing", "purl": "pkg:npm/%40acme/billing@1.0.0"}]}
+	]}`)
+	spdx, _ := parseJSON(`{"spdxVersion": "SPDX-2.3", "packages": [
+		{"name": "billing", "externalRefs": [
+			{"referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:acme:billing:1.0.0:*:*:*:*:*:*:*"},
+			{"referenceType": "purl", "referenceLocator": "pkg:npm/%40acme/billing@1.0.0"}
+		]}
+	]}`)
+
+	tests := []struct {
+		name       string
+		obj        map[string]interface{}
+		sbomType   string
+		namespaces []string
+		want       []string
+	}{
+		{
+			name:       "CycloneDX nested component",
+			obj:        cyclonedx,
+			sbomType:   SBOM_CYCLONEDX,
+			namespaces: []string{"@acme"},
+			want:       []string{"components.1.components.0.purl: "},
+		},
+		{
+			name:       "SPDX package",
+			obj:        spdx,
+			sbomType:   "SPDX-2.3",
+			namespaces: []string{"@acme"},
+			want:       []string{"packages.0.externalRefs.1.referenceLocator: "},
+		},
+		{
+			name:     "Disabled without namespaces",
+			obj:      cyclonedx,
+			sbomType: SBOM_CYCLONEDX,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := checkDependencyConfusion(tt.obj, tt.sbomType, tt.namespaces)
+			if len(warnings) != len(tt.want) {
+				t.Fatalf("got %v, want %d warnings", warnings, len(tt.want))
+			}
+			for i, prefix := range tt.want {
+				if !strings.HasPrefix(warnings[i], prefix) {
+					t.Errorf("warning %q does not start with %q", warnings[i], prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestWithInternalNamespaces(t *testing.T) {
+	sbom := spdxDocument(`{"SPDXID": "SPDXRef-a", "name": "billing", "downloadLocation": "NOASSERTION",
+		"externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl",
+			"referenceLocator": "pkg:npm/%40acme/billing@1.0.0"}]}`)
+
+	result, err := ValidateSBOMData(sbom, WithInternalNamespaces("@acme"), WithControlMappings(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Errorf("dependency confusion should not invalidate the SBOM: %v", result.ValidationErrors)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected 1 warning, got %v", result.Warnings)
+	}
+
+	found := false
+	for _, control := range result.Controls {
+		if control.Control == "CWE-427" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected dependency confusion controls in %v", result.Controls)
+	}
+}
@@ -0,0 +1,192 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DependencyCoveragePolicy sets the minimum dependency relationship coverage
+// an SBOM must have, to reject flat SBOMs that list components without saying
+// how they relate.
+type DependencyCoveragePolicy struct {
+	// MinCoverage is the fraction of components, from 0 to 1, that must
+	// appear in the dependency graph (e.g., 0.8 for 80%). Zero disables the
+	// requirement.
+	MinCoverage float64
+	// RequirePrimaryDependency requires the primary component (CycloneDX
+	// metadata.component, or the package an SPDX document describes) to have
+	// at least one direct dependency.
+	RequirePrimaryDependency bool
+	// MaxUnreachable is the fraction of components, from 0 to 1, that may
+	// not be reachable from the primary component through the dependency
+	// graph (e.g., 0.1 for 10%). Many unreachable components indicate SBOMs
+	// pasted together. Zero disables the requirement.
+	MaxUnreachable float64
+}
+
+// spdxDependencyRelationships are the SPDX relationship types that place both
+// of their elements in the dependency graph.
+var spdxDependencyRelationships = map[string]bool{
+	"DEPENDS_ON":    true,
+	"DEPENDENCY_OF": true,
+	"CONTAINS":      true,
+	"CONTAINED_BY":  true,
+}
+
+// dependencyGraph is a format-neutral view of the dependency relationships of
+// an SBOM.
+type dependencyGraph struct {
+	// components are the IDs of all components; an empty ID is a component
+	// that cannot be referenced.
+	components []string
+	primary    string
+	// inGraph holds the IDs that take part in at least one relationship.
+	inGraph map[string]bool
+	// direct maps an ID to the IDs it directly depends on or contains.
+	direct map[string][]string
+}
+
+// checkDependencyCoverage applies a dependency coverage policy to a parsed
+// SBOM. Returns an error message per requirement not met.
+func checkDependencyCoverage(obj map[string]interface{}, sbomType string, policy DependencyCoveragePolicy) []string {
+	var graph *dependencyGraph
+	if sbomType == SBOM_CYCLONEDX {
+		graph = cycloneDXDependencyGraph(obj)
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		graph = spdxDependencyGraph(obj)
+	} else {
+		return nil
+	}
+
+	var errors []string
+	field := "dependencies"
+	if sbomType != SBOM_CYCLONEDX {
+		field = "relationships"
+	}
+
+	if policy.MinCoverage > 0 && len(graph.components) > 0 {
+		covered := 0
+		for _, id := range graph.components {
+			if id != "" && graph.inGraph[id] {
+				covered++
+			}
+		}
+		coverage := float64(covered) / float64(len(graph.components))
+		if coverage < policy.MinCoverage {
+			errors = append(errors, fmt.Sprintf("%s: %d of %d components (%.0f%%) appear in the dependency graph; the policy requires at least %.0f%%",
+				field, covered, len(graph.components), coverage*100, policy.MinCoverage*100))
+		}
+	}
+
+	// reachability is measured from the primary component, so both
+	// requirements need one
+	if policy.RequirePrimaryDependency || policy.MaxUnreachable > 0 {
+		switch {
+		case graph.primary == "" && sbomType == SBOM_CYCLONEDX:
+			errors = append(errors, "metadata.component: the primary component is missing or has no bom-ref, so its dependencies cannot be declared")
+		case graph.primary == "":
+			errors = append(errors, "documentDescribes: the document does not describe a primary package")
+		case policy.RequirePrimaryDependency && len(graph.direct[graph.primary]) == 0:
+			errors = append(errors, fmt.Sprintf("%s: the primary component %q has no direct dependencies", field, graph.primary))
+		}
+	}
+
+	if policy.MaxUnreachable > 0 && graph.primary != "" {
+		reachable := reachableFrom(graph.direct, graph.primary)
+		unreachable, total := 0, 0
+		for _, id := range graph.components {
+			if id == graph.primary {
+				continue
+			}
+			total++
+			if !reachable[id] {
+				unreachable++
+			}
+		}
+		if total > 0 && float64(unreachable)/float64(total) > policy.MaxUnreachable {
+			errors = append(errors, fmt.Sprintf("%s: %d of %d components (%.0f%%) are not reachable from the primary component %q; the policy allows at most %.0f%%",
+				field, unreachable, total, float64(unreachable)/float64(total)*100, graph.primary, policy.MaxUnreachable*100))
+		}
+	}
+
+	return errors
+}
+
+// cycloneDXDependencyGraph builds the dependency graph of a CycloneDX SBOM
+// from its dependencies, keyed by bom-ref.
+func cycloneDXDependencyGraph(obj map[string]interface{}) *dependencyGraph {
+	graph := &dependencyGraph{inGraph: map[string]bool{}, direct: map[string][]string{}}
+
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		ref, _ := component["bom-ref"].(string)
+		if path == "metadata.component" {
+			graph.primary = ref
+		} else {
+			graph.components = append(graph.components, ref)
+		}
+	})
+
+	dependencies, _ := obj["dependencies"].([]interface{})
+	for _, d := range dependencies {
+		dependency, _ := d.(map[string]interface{})
+		ref, _ := dependency["ref"].(string)
+		if ref == "" {
+			continue
+		}
+		graph.inGraph[ref] = true
+
+		dependsOn, _ := dependency["dependsOn"].([]interface{})
+		for _, target := range dependsOn {
+			if id, ok := target.(string); ok {
+				graph.inGraph[id] = true
+				graph.direct[ref] = append(graph.direct[ref], id)
+			}
+		}
+	}
+
+	return graph
+}
+
+// spdxDependencyGraph builds the dependency graph of an SPDX SBOM from its
+// dependency and containment relationships, keyed by SPDX ID.
+func spdxDependencyGraph(obj map[string]interface{}) *dependencyGraph {
+	graph := &dependencyGraph{inGraph: map[string]bool{}, direct: map[string][]string{}}
+
+	packages, _ := obj["packages"].([]interface{})
+	for _, p := range packages {
+		pkg, _ := p.(map[string]interface{})
+		id, _ := pkg["SPDXID"].(string)
+		graph.components = append(graph.components, id)
+	}
+
+	described := spdxDescribedIDs(obj)
+	for _, id := range graph.components {
+		if described[id] {
+			graph.primary = id
+			break
+		}
+	}
+
+	relationships, _ := obj["relationships"].([]interface{})
+	for _, r := range relationships {
+		rel, _ := r.(map[string]interface{})
+		relType, _ := rel["relationshipType"].(string)
+		from, _ := rel["spdxElementId"].(string)
+		to, _ := rel["relatedSpdxElement"].(string)
+
+		dependencyOf := strings.HasSuffix(relType, "_DEPENDENCY_OF")
+		if !spdxDependencyRelationships[relType] && !dependencyOf {
+			continue
+		}
+		graph.inGraph[from] = true
+		graph.inGraph[to] = true
+
+		// normalize to "from depends on or contains to"
+		if relType == "DEPENDENCY_OF" || relType == "CONTAINED_BY" || dependencyOf {
+			from, to = to, from
+		}
+		graph.direct[from] = append(graph.direct[from], to)
+	}
+
+	return graph
+}
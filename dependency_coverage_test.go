@@ -0,0 +1,149 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDependencyCoverageCycloneDX(t *testing.T) {
+	components := `[{"bom-ref": "a", "name": "a"}, {"bom-ref": "b", "name": "b"}, {"bom-ref": "c", "name": "c"}, {"name": "d"}]`
+
+	tests := []struct {
+		name         string
+		dependencies string
+		policy       DependencyCoveragePolicy
+		want         []string
+	}{
+		{
+			name:         "Full coverage",
+			dependencies: `[{"ref": "app", "dependsOn": ["a", "b"]}, {"ref": "b", "dependsOn": ["c"]}, {"ref": "c"}]`,
+			policy:       DependencyCoveragePolicy{MinCoverage: 0.75, RequirePrimaryDependency: true},
+		},
+		{
+			name:         "Flat SBOM",
+			dependencies: `[]`,
+			policy:       DependencyCoveragePolicy{MinCoverage: 0.8, RequirePrimaryDependency: true},
+			want: []string{
+				"dependencies: 0 of 4 components (0%) appear in the dependency graph; the policy requires at least 80%",
+				`dependencies: the primary component "app" has no direct dependencies`,
+			},
+		},
+		{
+			name:         "Below threshold",
+			dependencies: `[{"ref": "app", "dependsOn": ["a"]}, {"ref": "a"}]`,
+			policy:       DependencyCoveragePolicy{MinCoverage: 0.5},
+			want:         []string{"dependencies: 1 of 4 components (25%) appear in the dependency graph"},
+		},
+		{
+			name:         "Pasted together",
+			dependencies: `[{"ref": "app", "dependsOn": ["a"]}, {"ref": "b", "dependsOn": ["c"]}]`,
+			policy:       DependencyCoveragePolicy{MaxUnreachable: 0.5},
+			want:         []string{`dependencies: 3 of 4 components (75%) are not reachable from the primary component "app"; the policy allows at most 50%`},
+		},
+		{
+			name:         "Unreachable within limit",
+			dependencies: `[{"ref": "app", "dependsOn": ["a", "b"]}, {"ref": "b", "dependsOn": ["c"]}]`,
+			policy:       DependencyCoveragePolicy{MaxUnreachable: 0.25},
+		},
+		{
+			name:         "Coverage not required",
+			dependencies: `[]`,
+			policy:       DependencyCoveragePolicy{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "metadata": {"component": {"bom-ref": "app", "name": "app"}},
+				"components": ` + components + `, "dependencies": ` + tt.dependencies + `}`)
+			if err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+			checkCoverageErrors(t, checkDependencyCoverage(obj, SBOM_CYCLONEDX, tt.policy), tt.want)
+		})
+	}
+}
+
+func TestCheckDependencyCoverageSPDX(t *testing.T) {
+	tests := []struct {
+		name          string
+		describes     string
+		relationships string
+		want          []string
+	}{
+		{
+			name:          "Depends on",
+			describes:     `["SPDXRef-app"]`,
+			relationships: `[{"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-lib"}]`,
+		},
+		{
+			name:          "Dependency of",
+			describes:     `["SPDXRef-app"]`,
+			relationships: `[{"spdxElementId": "SPDXRef-lib", "relationshipType": "DEV_DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-app"}]`,
+		},
+		{
+			name:          "Wrong direction",
+			describes:     `["SPDXRef-app"]`,
+			relationships: `[{"spdxElementId": "SPDXRef-lib", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-app"}]`,
+			want:          []string{`relationships: the primary component "SPDXRef-app" has no direct dependencies`},
+		},
+		{
+			name:          "Only non-dependency relationships",
+			describes:     `["SPDXRef-app"]`,
+			relationships: `[{"spdxElementId": "SPDXRef-app", "relationshipType": "GENERATED_FROM", "relatedSpdxElement": "SPDXRef-lib"}]`,
+			want: []string{
+				"relationships: 0 of 2 components (0%) appear in the dependency graph",
+				`relationships: the primary component "SPDXRef-app" has no direct dependencies`,
+			},
+		},
+		{
+			name:          "No primary package",
+			describes:     `[]`,
+			relationships: `[{"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-lib"}]`,
+			want:          []string{"documentDescribes: the document does not describe a primary package"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(`{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "documentDescribes": ` + tt.describes + `,
+				"packages": [{"SPDXID": "SPDXRef-app", "name": "app"}, {"SPDXID": "SPDXRef-lib", "name": "lib"}],
+				"relationships": ` + tt.relationships + `}`)
+			if err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+			policy := DependencyCoveragePolicy{MinCoverage: 1, RequirePrimaryDependency: true}
+			checkCoverageErrors(t, checkDependencyCoverage(obj, "SPDX-2.3", policy), tt.want)
+		})
+	}
+}
+
+func checkCoverageErrors(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("Error %d = %q, want prefix %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestValidateSBOMDataDependencyCoverage(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "MIT"))
+
+	result, err := ValidateSBOMData(sbom, WithDependencyCoverage(DependencyCoveragePolicy{MinCoverage: 0.8}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Errorf("Expected a flat SBOM to be rejected")
+	}
+	if len(result.ValidationErrors) != 1 || !strings.HasPrefix(result.ValidationErrors[0], "relationships: 0 of 2 components") {
+		t.Errorf("Unexpected errors: %v", result.ValidationErrors)
+	}
+}
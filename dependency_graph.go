@@ -0,0 +1,324 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DependencyGraph is the dependency graph of an SBOM: its components, keyed
+// by CycloneDX bom-ref or SPDX ID, and what each directly depends on or
+// contains. It is available from `StructuredResult.Graph`.
+type DependencyGraph struct {
+	// Components are the IDs of the components that have one, in document
+	// order. For CycloneDX this includes services, which can be depended on.
+	Components []string `json:"components"`
+	// Primary is the ID of the primary component (CycloneDX
+	// metadata.component, or the package an SPDX document describes), if any.
+	Primary string `json:"primary,omitempty"`
+	// Dependencies maps an ID to the IDs it directly depends on or contains.
+	// SPDX DEPENDENCY_OF and CONTAINED_BY relationships are reversed to fit.
+	Dependencies map[string][]string `json:"dependencies,omitempty"`
+}
+
+// DependsOn returns the IDs the component directly depends on.
+func (g *DependencyGraph) DependsOn(id string) []string {
+	return g.Dependencies[id]
+}
+
+// Roots returns the components that take part in the graph but that no other
+// component depends on, in document order. The primary component of a
+// well-formed SBOM is its only root.
+func (g *DependencyGraph) Roots() []string {
+	dependedOn := g.dependedOn()
+	var roots []string
+	for _, id := range g.Components {
+		if !dependedOn[id] && len(g.Dependencies[id]) > 0 {
+			roots = append(roots, id)
+		}
+	}
+	return roots
+}
+
+// Orphans returns the components, other than the primary component, that
+// take part in no dependency relationship, in document order.
+func (g *DependencyGraph) Orphans() []string {
+	dependedOn := g.dependedOn()
+	var orphans []string
+	for _, id := range g.Components {
+		if id != g.Primary && !dependedOn[id] && len(g.Dependencies[id]) == 0 {
+			orphans = append(orphans, id)
+		}
+	}
+	return orphans
+}
+
+// Cycles returns the dependency cycles of the graph, each as the IDs along
+// the cycle with the first repeated at the end (e.g., ["a", "b", "a"]). Each
+// cycle is reported once, starting from the component that comes first in
+// document order.
+func (g *DependencyGraph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var cycles [][]string
+	var stack []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dep := range g.Dependencies[id] {
+			switch state[dep] {
+			case visiting:
+				start := slices.Index(stack, dep)
+				cycle := append(slices.Clone(stack[start:]), dep)
+				cycles = append(cycles, cycle)
+			case unvisited:
+				visit(dep)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, id := range g.Components {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return cycles
+}
+
+// Reachable returns the components that can be reached from the primary
+// component by following dependencies, including the primary component
+// itself. It is empty if the SBOM has no primary component.
+func (g *DependencyGraph) Reachable() map[string]bool {
+	return reachableFrom(g.Dependencies, g.Primary)
+}
+
+// Unreachable returns the components, other than the primary component, that
+// cannot be reached from it by following dependencies, in document order. A
+// well-formed SBOM describes one product, so its components are all
+// reachable; many unreachable components suggest SBOMs pasted together.
+// Without a primary component every component is unreachable.
+func (g *DependencyGraph) Unreachable() []string {
+	reachable := g.Reachable()
+	var unreachable []string
+	for _, id := range g.Components {
+		if id != g.Primary && !reachable[id] {
+			unreachable = append(unreachable, id)
+		}
+	}
+	return unreachable
+}
+
+// reachableFrom returns the IDs reachable from root through dependencies,
+// root included, or nil if root is empty.
+func reachableFrom(dependencies map[string][]string, root string) map[string]bool {
+	if root == "" {
+		return nil
+	}
+	reachable := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, dep := range dependencies[id] {
+			if !reachable[dep] {
+				reachable[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return reachable
+}
+
+func (g *DependencyGraph) dependedOn() map[string]bool {
+	dependedOn := map[string]bool{}
+	for _, deps := range g.Dependencies {
+		for _, dep := range deps {
+			dependedOn[dep] = true
+		}
+	}
+	return dependedOn
+}
+
+// graphNode is a component of a dependency graph, with the JSON path where it
+// is declared.
+type graphNode struct {
+	id   string
+	path string
+	// label names the node in messages, e.g. `component "lodash"`.
+	label string
+}
+
+// buildDependencyGraph builds the dependency graph of a parsed SBOM. It also
+// returns the nodes, including those without an ID, for the graph analysis.
+func buildDependencyGraph(obj map[string]interface{}, sbomType string) (*DependencyGraph, []graphNode) {
+	graph := &DependencyGraph{Dependencies: map[string][]string{}}
+	var nodes []graphNode
+
+	if sbomType == SBOM_CYCLONEDX {
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			ref, _ := component["bom-ref"].(string)
+			name, _ := component["name"].(string)
+			if path == "metadata.component" {
+				graph.Primary = ref
+			}
+			nodes = append(nodes, graphNode{id: ref, path: path, label: fmt.Sprintf("component %q", name)})
+		})
+		walkCycloneDXServices(obj, func(path string, service map[string]interface{}) {
+			ref, _ := service["bom-ref"].(string)
+			name, _ := service["name"].(string)
+			nodes = append(nodes, graphNode{id: ref, path: path, label: fmt.Sprintf("service %q", name)})
+		})
+
+		dependencies, _ := obj["dependencies"].([]interface{})
+		for _, d := range dependencies {
+			dependency, _ := d.(map[string]interface{})
+			ref, _ := dependency["ref"].(string)
+			dependsOn, _ := dependency["dependsOn"].([]interface{})
+			for _, target := range dependsOn {
+				if id, ok := target.(string); ok && ref != "" {
+					graph.Dependencies[ref] = append(graph.Dependencies[ref], id)
+				}
+			}
+		}
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		described := spdxDescribedIDs(obj)
+		packages, _ := obj["packages"].([]interface{})
+		for i, p := range packages {
+			pkg, _ := p.(map[string]interface{})
+			id, _ := pkg["SPDXID"].(string)
+			if graph.Primary == "" && described[id] {
+				graph.Primary = id
+			}
+			nodes = append(nodes, graphNode{id: id, path: fmt.Sprintf("packages.%d", i), label: fmt.Sprintf("package %q", id)})
+		}
+
+		spdx := spdxDependencyGraph(obj)
+		for from, deps := range spdx.direct {
+			graph.Dependencies[from] = deps
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		if node.id != "" && !seen[node.id] {
+			seen[node.id] = true
+			graph.Components = append(graph.Components, node.id)
+		}
+	}
+	return graph, nodes
+}
+
+// walkCycloneDXServices calls fn for each service of a CycloneDX document
+// and, recursively, for each of its nested services.
+func walkCycloneDXServices(obj map[string]interface{}, fn func(path string, service map[string]interface{})) {
+	var walk func(path string, services []interface{})
+	walk = func(path string, services []interface{}) {
+		for i, s := range services {
+			service, ok := s.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			servicePath := fmt.Sprintf("%s.%d", path, i)
+			fn(servicePath, service)
+			nested, _ := service["services"].([]interface{})
+			walk(servicePath+".services", nested)
+		}
+	}
+	services, _ := obj["services"].([]interface{})
+	walk("services", services)
+}
+
+// checkDependencyGraph analyzes the dependency graph of a parsed SBOM.
+//
+// Errors:
+//   - an ID (CycloneDX bom-ref or SPDX ID) declared by more than one
+//     component;
+//   - a CycloneDX dependency that refers to an undefined bom-ref. Undefined
+//     SPDX elements are reported by the SPDX relationship checks.
+//
+// Warnings:
+//   - dependency cycles;
+//   - components that take part in no dependency relationship, or, if the
+//     SBOM declares no dependencies at all, a single warning saying so.
+//
+// Returns the graph and the findings.
+func checkDependencyGraph(obj map[string]interface{}, sbomType string) (*DependencyGraph, []Finding) {
+	graph, nodes := buildDependencyGraph(obj, sbomType)
+	var findings []Finding
+	add := func(level FindingLevel, path, message string) {
+		findings = append(findings, Finding{Level: level, Rule: RuleDependencyGraph, Path: path, Pointer: jsonPointer(path), Message: message})
+	}
+
+	idField := "bom-ref"
+	if sbomType != SBOM_CYCLONEDX {
+		idField = "SPDXID"
+	}
+	declared := map[string]string{}
+	for _, node := range nodes {
+		if node.id == "" {
+			continue
+		}
+		if first, ok := declared[node.id]; ok {
+			add(LevelError, node.path+"."+idField, fmt.Sprintf("%s %q is already declared at %s", idField, node.id, first))
+			continue
+		}
+		declared[node.id] = node.path
+	}
+
+	if sbomType == SBOM_CYCLONEDX {
+		dependencies, _ := obj["dependencies"].([]interface{})
+		for i, d := range dependencies {
+			dependency, _ := d.(map[string]interface{})
+			if ref, ok := dependency["ref"].(string); ok && declared[ref] == "" {
+				add(LevelError, fmt.Sprintf("dependencies.%d.ref", i), fmt.Sprintf("%q does not refer to a component or service", ref))
+			}
+			dependsOn, _ := dependency["dependsOn"].([]interface{})
+			for j, target := range dependsOn {
+				if id, ok := target.(string); ok && declared[id] == "" {
+					add(LevelError, fmt.Sprintf("dependencies.%d.dependsOn.%d", i, j), fmt.Sprintf("%q does not refer to a component or service", id))
+				}
+			}
+		}
+	}
+
+	field := "dependencies"
+	if sbomType != SBOM_CYCLONEDX {
+		field = "relationships"
+	}
+	for _, cycle := range graph.Cycles() {
+		add(LevelWarning, field, "dependency cycle: "+strings.Join(cycle, " -> "))
+	}
+
+	if len(graph.Dependencies) == 0 {
+		if len(nodes) > 1 {
+			add(LevelWarning, field, "the SBOM declares no dependency relationships between its components")
+		}
+		return graph, findings
+	}
+
+	orphans := map[string]bool{}
+	for _, id := range graph.Orphans() {
+		orphans[id] = true
+	}
+	for _, node := range nodes {
+		switch {
+		case node.path == "metadata.component":
+		case node.id == "":
+			add(LevelWarning, node.path, fmt.Sprintf("%s has no %s, so it cannot take part in dependency relationships", node.label, idField))
+		case orphans[node.id]:
+			add(LevelWarning, node.path, fmt.Sprintf("%s is never referenced in %s", node.label, field))
+			// report a duplicated ID once
+			delete(orphans, node.id)
+		}
+	}
+
+	return graph, findings
+}
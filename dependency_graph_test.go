@@ -0,0 +1,161 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDependencyGraphCycloneDX(t *testing.T) {
+	tests := []struct {
+		name         string
+		components   string
+		dependencies string
+		want         []string
+	}{
+		{
+			name:         "Consistent",
+			components:   `[{"bom-ref": "a", "name": "a"}, {"bom-ref": "b", "name": "b", "components": [{"bom-ref": "c", "name": "c"}]}]`,
+			dependencies: `[{"ref": "app", "dependsOn": ["a", "b"]}, {"ref": "b", "dependsOn": ["c"]}]`,
+		},
+		{
+			name:         "Dangling references",
+			components:   `[{"bom-ref": "a", "name": "a"}]`,
+			dependencies: `[{"ref": "app", "dependsOn": ["a", "missing"]}, {"ref": "gone"}]`,
+			want: []string{
+				`error dependencies.0.dependsOn.1: "missing" does not refer to a component or service`,
+				`error dependencies.1.ref: "gone" does not refer to a component or service`,
+			},
+		},
+		{
+			name:         "Duplicate bom-refs",
+			components:   `[{"bom-ref": "a", "name": "a"}, {"bom-ref": "a", "name": "a2"}]`,
+			dependencies: `[{"ref": "app", "dependsOn": ["a"]}]`,
+			want:         []string{`error components.1.bom-ref: bom-ref "a" is already declared at components.0`},
+		},
+		{
+			name:         "Cycle",
+			components:   `[{"bom-ref": "a", "name": "a"}, {"bom-ref": "b", "name": "b"}]`,
+			dependencies: `[{"ref": "app", "dependsOn": ["a"]}, {"ref": "a", "dependsOn": ["b"]}, {"ref": "b", "dependsOn": ["a"]}]`,
+			want:         []string{"warning dependencies: dependency cycle: a -> b -> a"},
+		},
+		{
+			name:         "Unreferenced components",
+			components:   `[{"bom-ref": "a", "name": "a"}, {"bom-ref": "b", "name": "b"}, {"name": "c"}]`,
+			dependencies: `[{"ref": "app", "dependsOn": ["a"]}]`,
+			want: []string{
+				`warning components.1: component "b" is never referenced in dependencies`,
+				`warning components.2: component "c" has no bom-ref, so it cannot take part in dependency relationships`,
+			},
+		},
+		{
+			name:         "No dependencies",
+			components:   `[{"bom-ref": "a", "name": "a"}, {"bom-ref": "b", "name": "b"}]`,
+			dependencies: `[]`,
+			want:         []string{"warning dependencies: the SBOM declares no dependency relationships between its components"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "metadata": {"component": {"bom-ref": "app", "name": "app"}},
+				"components": ` + tt.components + `, "dependencies": ` + tt.dependencies + `}`)
+			if err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+			_, findings := checkDependencyGraph(obj, SBOM_CYCLONEDX)
+			checkGraphFindings(t, findings, tt.want)
+		})
+	}
+}
+
+func TestCheckDependencyGraphSPDX(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("app", "app", "MIT"), spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "MIT"), spdxPackage("a", "a", "MIT"))
+	obj, err := parseJSON(strings.Replace(string(sbom), `"packages": [`, `"documentDescribes": ["SPDXRef-app"],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-a"},
+    {"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-a"}
+  ],
+  "packages": [`, 1))
+	if err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	graph, findings := checkDependencyGraph(obj, "SPDX-2.3")
+	checkGraphFindings(t, findings, []string{
+		`error packages.3.SPDXID: SPDXID "SPDXRef-a" is already declared at packages.1`,
+		"warning relationships: dependency cycle: SPDXRef-app -> SPDXRef-a -> SPDXRef-app",
+		`warning packages.2: package "SPDXRef-b" is never referenced in relationships`,
+	})
+	if got := strings.Join(graph.Components, ","); got != "SPDXRef-app,SPDXRef-a,SPDXRef-b" {
+		t.Errorf("Components = %q", got)
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	graph := &DependencyGraph{
+		Components: []string{"app", "a", "b", "c", "tool"},
+		Primary:    "app",
+		Dependencies: map[string][]string{
+			"app":  {"a"},
+			"a":    {"b"},
+			"tool": {"b"},
+		},
+	}
+
+	if got := strings.Join(graph.Roots(), ","); got != "app,tool" {
+		t.Errorf("Roots() = %q, want app,tool", got)
+	}
+	if got := strings.Join(graph.Orphans(), ","); got != "c" {
+		t.Errorf("Orphans() = %q, want c", got)
+	}
+	if got := strings.Join(graph.DependsOn("a"), ","); got != "b" {
+		t.Errorf("DependsOn(a) = %q, want b", got)
+	}
+	if cycles := graph.Cycles(); len(cycles) != 0 {
+		t.Errorf("Cycles() = %v, want none", cycles)
+	}
+	if got := strings.Join(graph.Unreachable(), ","); got != "c,tool" {
+		t.Errorf("Unreachable() = %q, want c,tool", got)
+	}
+	if reachable := graph.Reachable(); !reachable["app"] || !reachable["b"] || reachable["tool"] {
+		t.Errorf("Reachable() = %v", reachable)
+	}
+
+	graph.Primary = ""
+	if got := len(graph.Unreachable()); got != 5 {
+		t.Errorf("Unreachable() without a primary component = %d components, want 5", got)
+	}
+}
+
+func TestWithGraphAnalysis(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("a", "a", "MIT"))
+
+	result, err := ValidateSBOMDataStructured(sbom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid || result.Graph() == nil {
+		t.Errorf("expected a valid result with a graph, got %+v", result.ValidationResult)
+	}
+
+	result, err = ValidateSBOMDataStructured(sbom, WithGraphAnalysis(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid || len(result.Errors()) != 1 || result.Errors()[0].Rule != RuleDependencyGraph {
+		t.Errorf("expected a duplicate SPDXID error, got %+v", result.Findings)
+	}
+}
+
+func checkGraphFindings(t *testing.T, findings []Finding, want []string) {
+	t.Helper()
+	var got []string
+	for _, f := range findings {
+		got = append(got, string(f.Level)+" "+f.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
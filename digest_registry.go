@@ -0,0 +1,123 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DigestRecord is published for each SBOM that passes validation.
+type DigestRecord struct {
+	Digest      string    `json:"digest"`
+	SBOMType    string    `json:"sbomType"`
+	SBOMVersion string    `json:"sbomVersion"`
+	ValidatedAt time.Time `json:"validatedAt"`
+}
+
+// DigestPublisher records the digests of validated SBOMs, e.g., in a
+// transparency log or an internal registry, so a received SBOM can later be
+// checked to be the exact one that was validated.
+type DigestPublisher interface {
+	Publish(record DigestRecord) error
+}
+
+// SBOMDigest returns the digest under which an SBOM is published, in the
+// form "sha256:<hex>". It is computed over the exact bytes given, so any
+// change to the document, including whitespace, yields a different digest.
+func SBOMDigest(sbomContent []byte) string {
+	sum := sha256.Sum256(sbomContent)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// HTTPDigestRegistry is a DigestPublisher backed by an HTTP endpoint.
+//
+// Records are published with a JSON `POST` to `Endpoint`. A digest is looked
+// up with a `GET` to `Endpoint/<digest>`, which must answer 200 when the digest
+// is known and 404 otherwise. When `Token` is set it is sent as a bearer token.
+type HTTPDigestRegistry struct {
+	Client   *http.Client
+	Endpoint string
+	Token    string
+}
+
+// NewHTTPDigestRegistry returns an HTTPDigestRegistry for the given endpoint.
+func NewHTTPDigestRegistry(endpoint string) *HTTPDigestRegistry {
+	return &HTTPDigestRegistry{
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+	}
+}
+
+// Publish sends a record to the registry.
+func (r *HTTPDigestRegistry) Publish(record DigestRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("digest registry returned %s", resp.Status)
+	}
+	return nil
+}
+
+// IsPublished reports whether the digest of an SBOM has been published, i.e.
+// whether this exact document was validated before.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM as received.
+//
+// Returns:
+//   - true if the registry knows the digest, false otherwise.
+//   - An error if the registry cannot be queried.
+func (r *HTTPDigestRegistry) IsPublished(sbomContent []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, r.Endpoint+"/"+url.PathEscape(SBOMDigest(sbomContent)), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := r.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("digest registry returned %s", resp.Status)
+}
+
+func (r *HTTPDigestRegistry) do(req *http.Request) (*http.Response, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req.Header.Set("User-Agent", "sbom-validator")
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,117 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingDigestPublisher struct {
+	records []DigestRecord
+	err     error
+}
+
+func (p *recordingDigestPublisher) Publish(record DigestRecord) error {
+	p.records = append(p.records, record)
+	return p.err
+}
+
+func TestWithDigestPublisher(t *testing.T) {
+	valid := spdxDocument(spdxPackage("a", "a", "MIT"))
+
+	tests := []struct {
+		name        string
+		sbomData    []byte
+		publishErr  error
+		wantRecords int
+		wantErr     bool
+	}{
+		{name: "Valid SBOM is published", sbomData: valid, wantRecords: 1},
+		{name: "Invalid SBOM is not published", sbomData: []byte(`{"spdxVersion": "SPDX-2.3"}`)},
+		{name: "Publishing failure", sbomData: valid, publishErr: errors.New("unavailable"), wantRecords: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingDigestPublisher{err: tt.publishErr}
+
+			result, err := ValidateSBOMData(tt.sbomData, WithDigestPublisher(publisher))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error state: got %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(publisher.records) != tt.wantRecords {
+				t.Fatalf("published %d records, want %d", len(publisher.records), tt.wantRecords)
+			}
+			if tt.wantRecords == 0 || tt.wantErr {
+				return
+			}
+
+			record := publisher.records[0]
+			if record.Digest != SBOMDigest(tt.sbomData) || result.Digest != record.Digest {
+				t.Errorf("unexpected digest %q (result %q)", record.Digest, result.Digest)
+			}
+			if record.SBOMType != SBOM_SPDX || record.SBOMVersion != "2.3" {
+				t.Errorf("unexpected record %+v", record)
+			}
+		})
+	}
+}
+
+func TestHTTPDigestRegistry(t *testing.T) {
+	var mu sync.Mutex
+	published := map[string]bool{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPost:
+			var record DigestRecord
+			if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			published[record.Digest] = true
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			if !published[strings.TrimPrefix(r.URL.Path, "/digests/")] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}
+	}))
+	defer server.Close()
+
+	registry := NewHTTPDigestRegistry(server.URL + "/digests/")
+	registry.Token = "secret"
+	sbom := []byte(`{"spdxVersion": "SPDX-2.3"}`)
+
+	if ok, err := registry.IsPublished(sbom); err != nil || ok {
+		t.Fatalf("IsPublished() before publishing = %v, %v", ok, err)
+	}
+	if err := registry.Publish(DigestRecord{Digest: SBOMDigest(sbom)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ok, err := registry.IsPublished(sbom); err != nil || !ok {
+		t.Errorf("IsPublished() after publishing = %v, %v", ok, err)
+	}
+	if ok, _ := registry.IsPublished(append(sbom, ' ')); ok {
+		t.Errorf("a modified SBOM should not be published")
+	}
+
+	registry.Token = ""
+	if err := registry.Publish(DigestRecord{Digest: SBOMDigest(sbom)}); err == nil {
+		t.Errorf("expected an error for an unauthorized request")
+	}
+}
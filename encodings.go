@@ -0,0 +1,112 @@
+package sbomvalidator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Encodings of SBOMs besides JSON, as reported in
+// `ValidationResult.DetectedFormat`. Each belongs to one format and is
+// converted to the format's JSON form to be validated. They are recognized in
+// every build, but converted only by builds that include their format.
+const (
+	encodingXML      = "XML"
+	encodingProtobuf = "protobuf"
+	encodingTagValue = "tag-value"
+)
+
+// encodingConverter converts an SBOM from a non-JSON encoding to its JSON
+// form. Returns the JSON form, the syntax errors of the source that the JSON
+// form hides, and warnings about parts of the source it leaves out.
+type encodingConverter func(data []byte) (jsonContent []byte, syntaxErrors, warnings []string, err error)
+
+// encodingConverters maps encodings to their converters. Formats register the
+// converters of their encodings alongside their schemas (see formatSchemas).
+var encodingConverters = map[string]encodingConverter{}
+
+// sbomEncoding returns the non-JSON encoding of an SBOM and its format, or ""
+// for JSON and unrecognized content.
+func sbomEncoding(data []byte) (encoding, format string) {
+	switch {
+	case isXML(data):
+		// only CycloneDX has an XML encoding
+		return encodingXML, SBOM_CYCLONEDX
+	case isCycloneDXProtobuf(data):
+		return encodingProtobuf, SBOM_CYCLONEDX
+	case isSPDXTagValue(data):
+		return encodingTagValue, SBOM_SPDX
+	}
+	return "", ""
+}
+
+// convertEncoding converts an SBOM from a non-JSON encoding to its JSON form,
+// as described for encodingConverter. It fails if the encoding's format is
+// not compiled in.
+func convertEncoding(encoding, format string, data []byte) ([]byte, []string, []string, error) {
+	convert, ok := encodingConverters[encoding]
+	if !ok {
+		return nil, nil, nil, errFormatNotCompiled(format)
+	}
+	jsonContent, syntaxErrors, warnings, err := convert(data)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse %s %s: %v", format, encoding, err)
+	}
+	return jsonContent, syntaxErrors, warnings, nil
+}
+
+// isXML reports whether data looks like an XML document, i.e. its first
+// non-blank character opens a tag.
+func isXML(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '<'
+}
+
+// protoSpecVersionPattern matches the spec version a protobuf BOM starts
+// with.
+var protoSpecVersionPattern = regexp.MustCompile(`^1\.[0-9]+$`)
+
+// protoSniffLength is the number of bytes isCycloneDXProtobuf needs at most.
+const protoSniffLength = 2 + 127
+
+// isCycloneDXProtobuf reports whether data looks like a protobuf-encoded
+// CycloneDX BOM. Protobuf has no magic number, but encoders write fields in
+// field number order, so a BOM starts with its spec_version (field 1), e.g.
+// 0x0a 0x03 "1.6".
+func isCycloneDXProtobuf(data []byte) bool {
+	if len(data) < 2 || data[0] != 0x0a || data[1] >= 0x80 {
+		return false
+	}
+	n := int(data[1])
+	return len(data) >= 2+n && protoSpecVersionPattern.Match(data[2:2+n])
+}
+
+// tagLinePattern matches a "Tag: value" line.
+var tagLinePattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*):\s?(.*)$`)
+
+// isSPDXTagValue reports whether data looks like an SPDX tag-value document:
+// its first line that is neither blank nor a comment is a "Tag: value" pair,
+// and it declares an SPDXVersion.
+func isSPDXTagValue(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if first && !tagLinePattern.MatchString(line) {
+			return false
+		}
+		first = false
+		if strings.HasPrefix(line, "SPDXVersion:") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// protoBytes encodes a length-delimited protobuf field.
+func protoBytes(number int, value []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(number<<3|2))
+	out = binary.AppendUvarint(out, uint64(len(value)))
+	return append(out, value...)
+}
+
+// protoVarint encodes a varint protobuf field.
+func protoVarint(number int, value uint64) []byte {
+	out := binary.AppendUvarint(nil, uint64(number<<3))
+	return binary.AppendUvarint(out, value)
+}
+
+func protoBOM() []byte {
+	component := bytes.Join([][]byte{
+		protoVarint(1, 3), // library
+		protoBytes(3, []byte("pkg:npm/left-pad@1.3.0")),
+		protoBytes(8, []byte("left-pad")),
+		protoBytes(9, []byte("1.3.0")),
+		protoBytes(12, bytes.Join([][]byte{protoVarint(1, 3), protoBytes(2, []byte("abc"))}, nil)),
+		protoBytes(13, protoBytes(1, protoBytes(1, []byte("MIT")))),
+		protoBytes(16, []byte("pkg:npm/left-pad@1.3.0")),
+		protoBytes(19, []byte{}), // pedigree, not decoded
+	}, nil)
+	metadata := bytes.Join([][]byte{
+		protoBytes(1, bytes.Join([][]byte{protoVarint(1, 1729598400), protoVarint(2, 500000000)}, nil)),
+		protoBytes(9, protoVarint(1, 2)), // build
+	}, nil)
+	dependency := bytes.Join([][]byte{
+		protoBytes(1, []byte("app")),
+		protoBytes(2, protoBytes(1, []byte("pkg:npm/left-pad@1.3.0"))),
+	}, nil)
+
+	return bytes.Join([][]byte{
+		protoBytes(1, []byte("1.6")),
+		protoVarint(2, 1),
+		protoBytes(4, metadata),
+		protoBytes(5, component),
+		protoBytes(8, dependency),
+	}, nil)
+}
+
+func TestIsCycloneDXProtobuf(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  bool
+	}{
+		{name: "Protobuf BOM", input: protoBOM(), want: true},
+		{name: "JSON", input: []byte(`{"bomFormat": "CycloneDX"}`)},
+		{name: "Leading newline", input: []byte("\n{\"bomFormat\": \"CycloneDX\"}")},
+		{name: "Other protobuf", input: protoBytes(1, []byte("hello"))},
+		{name: "Truncated", input: []byte{0x0a, 0x03, '1'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCycloneDXProtobuf(tt.input); got != tt.want {
+				t.Errorf("isCycloneDXProtobuf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const tagValueHeader = `SPDXVersion: SPDX-2.3
+DataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+DocumentName: test
+DocumentNamespace: https://example.com/test
+Creator: Tool: test
+Created: 2024-10-22T12:00:00Z
+`
+
+func TestIsSPDXTagValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "Tag-value document", input: tagValueHeader, want: true},
+		{name: "Leading comment", input: "# generated\n\n" + tagValueHeader, want: true},
+		{name: "No SPDXVersion", input: "DocumentName: test\n", want: false},
+		{name: "Not tag-value", input: "hello world\nSPDXVersion: SPDX-2.3\n", want: false},
+		{name: "JSON", input: `{"spdxVersion": "SPDX-2.3"}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSPDXTagValue([]byte(tt.input)); got != tt.want {
+				t.Errorf("isSPDXTagValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsXML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "XML declaration", input: `<?xml version="1.0"?><bom/>`, want: true},
+		{name: "Leading whitespace and BOM", input: "\xef\xbb\xbf\n  <bom/>", want: true},
+		{name: "JSON", input: `{"bomFormat": "CycloneDX"}`, want: false},
+		{name: "Empty", input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isXML([]byte(tt.input)); got != tt.want {
+				t.Errorf("isXML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/shiftleftcyber/sbom-validator"
+)
+
+// generateInvalid mutates a valid SBOM to violate a rule and writes it to
+// stdout or -out, for testing how pipelines handle each class of failure.
+// With -list, it prints the mutations instead.
+func generateInvalid(args []string) int {
+	flags := flag.NewFlagSet("generate-invalid", flag.ExitOnError)
+	mutation := flags.String("mutation", "", "Mutation to apply (see -list)")
+	outPath := flags.String("out", "", "Path to write the invalid SBOM to (default stdout)")
+	list := flags.Bool("list", false, "List the mutations")
+	flags.Parse(args)
+
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, m := range sbomvalidator.Mutations() {
+			fmt.Fprintf(w, "%s\t%s\n", m.Name, m.Description)
+		}
+		w.Flush()
+		return exitValid
+	}
+
+	if *mutation == "" || flags.NArg() != 1 {
+		fatalf("Usage: %s generate-invalid -mutation=<name> [-out=<path>] <sbom> | -list", programName())
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fatalf("Failed to read SBOM: %v", err)
+	}
+	invalid, err := sbomvalidator.GenerateInvalidSBOM(data, *mutation)
+	if err != nil {
+		fatalf("Failed to generate an invalid SBOM: %v", err)
+	}
+
+	if *outPath == "" {
+		fmt.Println(string(invalid.Content))
+	} else if err := os.WriteFile(*outPath, append(invalid.Content, '\n'), 0o644); err != nil {
+		fatalf("Failed to write invalid SBOM: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "violates rule %s\n", invalid.Rule)
+	return exitValid
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/shiftleftcyber/sbom-validator"
+	"github.com/shiftleftcyber/sbom-validator/conformance"
+	"github.com/shiftleftcyber/sbom-validator/vex"
+)
+
+// detection is the outcome of detecting the type of one file, as printed with
+// -output=json.
+type detection struct {
+	File           string `json:"file"`
+	DetectedFormat string `json:"detectedFormat,omitempty"`
+	SBOMType       string `json:"sbomType,omitempty"`
+	SBOMVersion    string `json:"sbomVersion,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// detect prints the encoding, type and spec version of SBOM and VEX files, without
+// reporting validation errors. Returns exitError if any file is not an SBOM.
+func detect(args []string) int {
+	flags := flag.NewFlagSet("detect", flag.ExitOnError)
+	output := flags.String("output", "text", "Output format: text or json")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fatalf("Usage: %s detect [-output=text|json] <sbom>...", programName())
+	}
+
+	exitCode := exitValid
+	detections := make([]detection, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		d := detection{File: path}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var result *sbomvalidator.ValidationResult
+			// unknown versions are still detected
+			result, err = sbomvalidator.ValidateSBOMData(data, sbomvalidator.WithAllowUnknownVersion(true))
+			d.DetectedFormat, d.SBOMType, d.SBOMVersion = result.DetectedFormat, result.SBOMType, result.SBOMVersion
+			// VEX documents are reported as such, including OpenVEX, which is
+			// not an SBOM
+			if format, version, vexErr := vex.Detect(data); vexErr == nil {
+				d.SBOMType, d.SBOMVersion, err = format, version, nil
+			}
+		}
+		if err != nil && d.SBOMVersion == "" {
+			d.Error = err.Error()
+			exitCode = exitError
+		}
+		detections = append(detections, d)
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(detections, "", " ")
+		fmt.Println(string(data))
+		return exitCode
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, d := range detections {
+		if d.Error != "" {
+			fmt.Fprintf(w, "%s\terror: %s\n", d.File, d.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.File, d.SBOMType, d.SBOMVersion, d.DetectedFormat)
+	}
+	w.Flush()
+	return exitCode
+}
+
+// schemas runs the schema subcommands; only "list" is defined.
+func schemas(args []string) int {
+	if len(args) == 0 || args[0] != "list" {
+		fatalf("Usage: %s schemas list [-output=text|json]", programName())
+	}
+
+	flags := flag.NewFlagSet("schemas list", flag.ExitOnError)
+	output := flags.String("output", "text", "Output format: text or json")
+	flags.Parse(args[1:])
+
+	revisions, err := sbomvalidator.SchemaRevisions()
+	if err != nil {
+		fatalf("Failed to list schemas: %v", err)
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(revisions, "", " ")
+		fmt.Println(string(data))
+		return exitValid
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FORMAT\tSCHEMA\tDIGEST\tSTATUS")
+	for _, r := range revisions {
+		status := "archived"
+		if r.Current {
+			status = "current"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Format, r.Path, r.Digest, status)
+	}
+	w.Flush()
+	return exitValid
+}
+
+// scoredFile is the score of one file, as printed with -output=json.
+type scoredFile struct {
+	File   string                     `json:"file"`
+	Report *sbomvalidator.ScoreReport `json:"report,omitempty"`
+	Error  string                     `json:"error,omitempty"`
+}
+
+// score grades the completeness of SBOM files (see `Score`). It returns
+// exitInvalid if a score is below -min-score, so SBOM quality can gate a
+// pipeline.
+func score(args []string) int {
+	flags := flag.NewFlagSet("score", flag.ExitOnError)
+	profileName := flags.String("profile", "default", "Scoring profile: default, ntia, or the path of a JSON profile")
+	minScore := flags.Float64("min-score", 0, "Minimum score (0-100) below which the command fails")
+	output := flags.String("output", "text", "Output format: text or json")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fatalf("Usage: %s score [-profile=<name|path>] [-min-score=<n>] [-output=text|json] <sbom>...", programName())
+	}
+
+	var profile sbomvalidator.ScoringProfile
+	switch *profileName {
+	case sbomvalidator.DefaultScoringProfile.Name:
+		profile = sbomvalidator.DefaultScoringProfile
+	case sbomvalidator.NTIAScoringProfile.Name:
+		profile = sbomvalidator.NTIAScoringProfile
+	default:
+		data, err := os.ReadFile(*profileName)
+		if err != nil {
+			fatalf("Failed to read scoring profile: %v", err)
+		}
+		if profile, err = sbomvalidator.ParseScoringProfile(data); err != nil {
+			fatalf("Failed to parse scoring profile: %v", err)
+		}
+	}
+
+	exitCode := exitValid
+	scores := make([]scoredFile, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		s := scoredFile{File: path}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			s.Report, err = sbomvalidator.Score(data, sbomvalidator.WithScoringProfile(profile))
+		}
+		switch {
+		case err != nil:
+			s.Error = err.Error()
+			exitCode = exitError
+		case s.Report.Score < *minScore && exitCode == exitValid:
+			exitCode = exitInvalid
+		}
+		scores = append(scores, s)
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(scores, "", " ")
+		fmt.Println(string(data))
+		return exitCode
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, s := range scores {
+		if s.Error != "" {
+			fmt.Fprintf(w, "%s\terror: %s\n", s.File, s.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%.1f\t%s\n", s.File, s.Report.Score, s.Report.Grade)
+		for _, c := range s.Report.Categories {
+			fmt.Fprintf(w, "  %s\t%.1f\t\n", c.Category, c.Score)
+		}
+	}
+	w.Flush()
+	return exitCode
+}
+
+// selftest validates the CycloneDX specification's test corpus (see package
+// conformance) and prints the share of documents handled as the
+// specification expects, per spec version. It returns exitInvalid if the
+// overall share is below -min-percent, and exitError if the corpus is empty.
+func selftest(args []string) int {
+	flags := flag.NewFlagSet("selftest", flag.ExitOnError)
+	corpusDir := flags.String("corpus", "", "Directory of the corpus (default: the corpus built into the binary)")
+	minPercent := flags.Float64("min-percent", 0, "Minimum conformance (0-100) below which the command fails")
+	verbose := flags.Bool("v", false, "List the documents that did not conform")
+	output := flags.String("output", "text", "Output format: text or json")
+	flags.Parse(args)
+
+	corpus := conformance.Corpus()
+	if *corpusDir != "" {
+		corpus = os.DirFS(*corpusDir)
+	}
+	report, err := conformance.Run(context.Background(), corpus)
+	if err != nil {
+		fatalf("Self-test failed: %v", err)
+	}
+	if report.Cases() == 0 {
+		fatalf("The corpus has no test documents; vendor it with `make cyclonedx-corpus` or pass -corpus")
+	}
+
+	exitCode := exitValid
+	if report.Percent() < *minPercent {
+		exitCode = exitInvalid
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(report, "", " ")
+		fmt.Println(string(data))
+		return exitCode
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tPASSED\tCASES\tCONFORMANCE")
+	for _, v := range report.Versions {
+		fmt.Fprintf(w, "CycloneDX %s\t%d\t%d\t%.1f%%\n", v.Version, v.Passed, v.Cases, v.Percent())
+	}
+	fmt.Fprintf(w, "total\t%d\t%d\t%.1f%%\n", report.Passed(), report.Cases(), report.Percent())
+	w.Flush()
+
+	if *verbose {
+		for _, v := range report.Versions {
+			for _, f := range v.Failures {
+				want := "rejected"
+				if f.WantValid {
+					want = "accepted"
+				}
+				fmt.Fprintf(os.Stderr, "%s: should be %s", f.Path, want)
+				switch {
+				case f.Error != "":
+					fmt.Fprintf(os.Stderr, ": %s", f.Error)
+				case len(f.Errors) > 0:
+					fmt.Fprintf(os.Stderr, ": %s", strings.Join(f.Errors, "; "))
+				}
+				fmt.Fprintln(os.Stderr)
+			}
+		}
+	}
+	return exitCode
+}
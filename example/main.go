@@ -1,68 +1,565 @@
 package main
 
 import (
+	"archive/zip"
+	"crypto"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
 	"github.com/shiftleftcyber/sbom-validator"
+	sbomcompare "github.com/shiftleftcyber/sbom-validator/compare"
+	sbomconvert "github.com/shiftleftcyber/sbom-validator/convert"
+	"github.com/shiftleftcyber/sbom-validator/daemon"
+	"github.com/shiftleftcyber/sbom-validator/lsp"
+	"github.com/shiftleftcyber/sbom-validator/server"
 )
 
-// main serves as a reference implementation for SBOM validation.
+// Exit codes, so the CLI can gate CI pipelines.
+const (
+	exitValid   = 0 // every SBOM is valid
+	exitInvalid = 1 // at least one SBOM is invalid
+	exitError   = 2 // an SBOM could not be validated, or the command was misused
+)
+
+// commands maps each subcommand to its implementation, which returns the
+// process exit code.
+var commands = map[string]func(args []string) int{
+	"validate": validate,
+	"detect":   detect,
+	"score":    score,
+	"schemas":  schemas,
+	"compare":  compare,
+	"diff":     diff,
+	"convert":  convert,
+	"bundle":   bundle,
+	"sign":     sign,
+	"serve":    serve,
+	"daemon":   runDaemon,
+	"lsp":      serveLSP,
+	"vex":      validateVEX,
+	"selftest": selftest,
+
+	"self-update":      selfUpdate,
+	"generate-invalid": generateInvalid,
+	"provenance-diff":  provenanceDiff,
+}
+
+// main is a command line interface to the sbomvalidator package, and serves
+// as a reference implementation for using it.
 //
-// This function demonstrates how to use the sbomvalidator package to:
-//  1. Read an SBOM JSON file from a provided path.
-//  2. Detect the SBOM type using the "bomFormat" field.
-//  3. Extract the SBOM schema version.
-//  4. Load the corresponding JSON schema.
-//  5. Validate the SBOM JSON against the loaded schema.
-//  6. Print validation results, limiting the number of reported errors.
+// Usage:
 //
-// This implementation is primarily for demonstration purposes. Other projects
-// can integrate the sbomvalidator package directly without using this main function.
+//	sbom-validator validate [flags] <sbom>... | oci://<image>
+//	sbom-validator detect [-output=text|json] <sbom>...
+//	sbom-validator vex [-sbom=<sbom>] [-output=text|json] <vex>...
+//	sbom-validator score [-profile=<name|path>] [-min-score=<n>] <sbom>...
+//	sbom-validator schemas list [-output=text|json]
+//	sbom-validator compare -output=<result.json> <input.json>...
+//	sbom-validator diff [-output=text|json] <before> <after>
+//	sbom-validator provenance-diff [-output=text|json] <regenerated> <submitted>
+//	sbom-validator convert -to=spdx|cyclonedx [-out=<path>] [-fail-on-loss] <sbom>
+//	sbom-validator bundle -dir=<sboms> -out=<bundle.zip> | -verify=<bundle.zip>
+//	sbom-validator sign -file=<sbom.json> -key=<key.pem> -out=<signed.json>
+//	sbom-validator serve -addr=:8080
+//	sbom-validator daemon -socket=<path>
+//	sbom-validator lsp
+//	sbom-validator self-update -trusted-keys=<release.pem>
+//	sbom-validator generate-invalid -mutation=<name> [-out=<path>] <sbom>
+//	sbom-validator selftest [-corpus=<dir>] [-min-percent=<n>] [-v] [-output=text|json]
 //
-// Usage:
+// `validate` validates SBOMs (JSON, CycloneDX XML or SPDX tag-value) and writes
+// a report as text, JSON, SARIF or JUnit XML to stdout (or -output-file), while
+// findings go to stderr; run `sbom-validator validate -h` for the checks it can
+// add. Given oci://<image>, it validates the SBOMs attached to the image in its
+// registry (see `ValidateOCIImage`). `detect` prints the encoding, type and spec version of SBOMs and VEX
+// documents, and `schemas list` the embedded schemas and their digests. `vex`
+// validates CycloneDX VEX and OpenVEX documents (see package vex). `score`
+// grades the completeness of SBOMs from 0 to 100 (see `Score`) and exits with
+// 1 when one scores below -min-score. `compare` checks the
+// result of a convert or merge operation against its inputs and exits with a
+// code describing the outcome (see `CompareConversion`). `diff` lists the
+// components added, removed and changed between two SBOMs, in any formats, and
+// exits with 1 when they differ (see package compare). `provenance-diff`
+// reviews a submitted SBOM for manual edits against one regenerated from the
+// same source and exits with 1 when it finds suspicious ones (see
+// `compare.ProvenanceDiff`). `convert` validates an
+// SBOM and converts it between CycloneDX and SPDX, listing what the target
+// format cannot carry on stderr (see package convert). `bundle` writes or
+// validates an SBOM bundle (see `ValidateBundle`). `sign` validates, normalizes
+// and signs an SBOM (see `SignSBOM`). `serve` serves the HTTP API (see package
+// server), `daemon` the line protocol of package daemon on a Unix domain
+// socket, and `lsp` a Language Server Protocol server on stdin/stdout that
+// publishes diagnostics for open *.cdx.json and *.spdx.json files.
+// `self-update` replaces the binary with the latest release after verifying
+// its checksum and the signature of the release's checksums file.
+// `generate-invalid` mutates a valid SBOM to violate a rule (see
+// `GenerateInvalidSBOM`), for testing how pipelines handle each failure
+// class; `generate-invalid -list` lists the mutations. `selftest` validates
+// the CycloneDX specification's test corpus and prints the conformance per
+// spec version (see package conformance), exiting with 1 below -min-percent.
 //
-//	go run main.go -file=<path-to-sbom.json>
+// Validating commands exit with 0 when every SBOM is valid, 1 when one is
+// invalid and 2 when one cannot be validated at all or the command is
+// misused. For compatibility, flags without a subcommand (e.g.,
+// `sbom-validator -file=<sbom.json>`) run `validate`.
 //
 // Example:
 //
-//	go run main.go -file=samples/juice-shop-17.1.1.cdx.json
+//	go run ./example validate sample-sboms/sample-1.6.cdx.json
 func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "-help" || os.Args[1] == "help" {
+		usage()
+		os.Exit(exitError)
+	}
 
-	sbomPath := flag.String("file", "", "Path to the SBOM JSON file")
-	flag.Parse()
+	if command, ok := commands[os.Args[1]]; ok {
+		os.Exit(command(os.Args[2:]))
+	}
 
-	// Ensure the file path is provided
-	if *sbomPath == "" {
-		log.Fatal("Usage: go run main.go -file=<path-to-sbom.json>")
+	if os.Args[1][0] == '-' {
+		os.Exit(validate(os.Args[1:]))
 	}
 
-	// Read SBOM file
-	jsonData, err := os.ReadFile(*sbomPath)
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(exitError)
+}
+
+func usage() {
+	name := programName()
+	fmt.Fprintf(os.Stderr, `Usage:
+  %[1]s validate [flags] <sbom>...              validate SBOMs
+  %[1]s validate [flags] oci://<image>          validate the SBOMs attached to an image
+  %[1]s detect [-output=text|json] <sbom>...    print the type and version of SBOMs
+  %[1]s vex [-sbom=<sbom>] <vex>...             validate VEX documents
+  %[1]s score [-min-score=<n>] <sbom>...         grade the completeness of SBOMs
+  %[1]s schemas list [-output=text|json]        list the embedded schemas
+  %[1]s compare -output=<result> <input>...     check a convert or merge result
+  %[1]s diff [-output=text|json] <old> <new>    list component changes between SBOMs
+  %[1]s provenance-diff <regenerated> <submitted> flag manual edits of a submitted SBOM
+  %[1]s convert -to=spdx|cyclonedx <sbom>       convert a valid SBOM to another format
+  %[1]s bundle -dir=<dir> -out=<zip>            write an SBOM bundle
+  %[1]s bundle -verify=<zip>                    validate an SBOM bundle
+  %[1]s sign -file=<sbom> -key=<pem> -out=<out> sign a valid SBOM
+  %[1]s serve [-addr=:8080]                     serve the HTTP API
+  %[1]s daemon [-socket=<path>]                 serve validation on a Unix socket
+  %[1]s lsp                                     run the language server
+  %[1]s self-update -trusted-keys=<pem>         install the latest release
+  %[1]s generate-invalid -mutation=<name> <sbom> write an SBOM that violates a rule
+  %[1]s selftest [-min-percent=<n>]             measure conformance to the CycloneDX test corpus
+
+Exit codes: 0 valid, 1 invalid, 2 error; compare exits 3 for an invalid
+output, 4 for data loss and 5 for conflicts.
+`, name)
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// fatalf logs an error and exits with exitError.
+func fatalf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+	os.Exit(exitError)
+}
+
+// openOutput returns the writer for a command's report: the file at path, or
+// stdout when path is empty. Diagnostics always go to stderr, so the report
+// can be piped.
+func openOutput(path string) (io.Writer, func()) {
+	if path == "" {
+		return os.Stdout, func() {}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		fatalf("Failed to create output file: %v", err)
+	}
+	return f, func() {
+		if err := f.Close(); err != nil {
+			fatalf("Failed to write output file: %v", err)
+		}
+	}
+}
+
+// serveLSP runs the language server on stdin/stdout.
+func serveLSP(args []string) int {
+	if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+		fatalf("LSP server failed: %v", err)
+	}
+	return exitValid
+}
+
+// compare checks the output of a convert or merge operation against its
+// inputs, prints the report and returns the exit code for its outcome.
+func compare(args []string) int {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	outputPath := flags.String("output", "", "Path to the converted or merged SBOM")
+	flags.Parse(args)
+
+	if *outputPath == "" || flags.NArg() == 0 {
+		fatalf("Usage: %s compare -output=<result.json> <input.json>...", programName())
+	}
+
+	output, err := os.ReadFile(*outputPath)
+	if err != nil {
+		fatalf("Failed to read output file: %v", err)
+	}
+
+	inputs := make([][]byte, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		input, err := os.ReadFile(path)
+		if err != nil {
+			fatalf("Failed to read input file: %v", err)
+		}
+		inputs = append(inputs, input)
+	}
+
+	report, err := sbomvalidator.CompareConversion(output, inputs)
 	if err != nil {
-		log.Fatalf("Failed to read SBOM file: %v", err)
+		fatalf("Error during comparison - %v", err)
+	}
+
+	data, _ := json.MarshalIndent(report, "", " ")
+	fmt.Println(string(data))
+
+	return report.ExitCode()
+}
+
+// diff compares two SBOMs, prints the added, removed and changed components
+// and returns exitValid when they declare the same components, exitInvalid
+// otherwise.
+func diff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	output := flags.String("output", "text", "Report format: text or json")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 || (*output != "text" && *output != "json") {
+		fatalf("Usage: %s diff [-output=text|json] <before> <after>", programName())
 	}
 
-	result, err := sbomvalidator.ValidateSBOMData(jsonData)
+	before, err := os.ReadFile(flags.Arg(0))
 	if err != nil {
-		log.Fatalf("Error during validation - %v", err)
+		fatalf("Failed to read SBOM: %v", err)
+	}
+	after, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		fatalf("Failed to read SBOM: %v", err)
 	}
 
-	if result.IsValid {
-		output, _ := json.MarshalIndent(result, "", " ")
-		fmt.Println(string(output))
+	report, err := sbomcompare.SBOMs(before, after, sbomcompare.Config{})
+	if err != nil {
+		fatalf("Error during comparison - %v", err)
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(report, "", " ")
+		fmt.Println(string(data))
 	} else {
-		fmt.Printf("Validation failed! Showing up to %d errors:\n", 10)
+		for _, c := range report.Removed {
+			fmt.Printf("- %s %s\n", c.Key, c.Version)
+		}
+		for _, c := range report.Added {
+			fmt.Printf("+ %s %s\n", c.Key, c.Version)
+		}
+		for _, c := range report.Changed {
+			fmt.Printf("~ %s", c.Key)
+			if c.VersionDrift {
+				fmt.Printf(" %s -> %s", c.Before.Version, c.After.Version)
+			}
+			if c.LicenseChange {
+				fmt.Printf(" licenses %v -> %v", c.Before.Licenses, c.After.Licenses)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("%d added, %d removed, %d changed, %d unchanged\n",
+			len(report.Added), len(report.Removed), len(report.Changed), report.Unchanged)
+	}
+
+	if !report.Identical() {
+		return exitInvalid
+	}
+	return exitValid
+}
+
+// provenanceDiff compares a submitted SBOM with one regenerated from the same
+// source and lists the edits, marking suspicious ones with "!". It returns
+// exitInvalid when there are suspicious edits.
+func provenanceDiff(args []string) int {
+	flags := flag.NewFlagSet("provenance-diff", flag.ExitOnError)
+	output := flags.String("output", "text", "Report format: text or json")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 || (*output != "text" && *output != "json") {
+		fatalf("Usage: %s provenance-diff [-output=text|json] <regenerated> <submitted>", programName())
+	}
+
+	regenerated, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fatalf("Failed to read SBOM: %v", err)
+	}
+	submitted, err := os.ReadFile(flags.Arg(1))
+	if err != nil {
+		fatalf("Failed to read SBOM: %v", err)
+	}
 
-		for i, errMsg := range result.ValidationErrors {
-			if i >= 10 {
-				fmt.Printf("...and %d more errors.\n", len(result.ValidationErrors)-10)
-				break
+	report, err := sbomcompare.ProvenanceDiff(regenerated, submitted, sbomcompare.Config{})
+	if err != nil {
+		fatalf("Error during comparison - %v", err)
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(report, "", " ")
+		fmt.Println(string(data))
+	} else {
+		for _, e := range report.Edits {
+			marker := " "
+			if e.Suspicious {
+				marker = "!"
 			}
-			fmt.Printf("- %s\n", errMsg)
+			fmt.Printf("%s %s %s: %s\n", marker, e.Kind, e.Key, e.Message)
 		}
+		fmt.Printf("%d edits, %d suspicious, %d unchanged\n", len(report.Edits), len(report.Suspicious()), report.Unchanged)
+	}
+
+	if report.Tampered() {
+		return exitInvalid
+	}
+	return exitValid
+}
+
+// convert validates an SBOM, writes it converted to another format and logs
+// what the conversion lost. It returns exitInvalid when something was lost
+// and -fail-on-loss is set.
+func convert(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := flags.String("to", "", "Target format: spdx or cyclonedx")
+	outPath := flags.String("out", "", "Path to write the converted SBOM to (default stdout)")
+	failOnLoss := flags.Bool("fail-on-loss", false, "Exit with 1 when the target format cannot carry everything in the SBOM")
+	flags.Parse(args)
+
+	targets := map[string]sbomvalidator.Format{"spdx": sbomvalidator.FormatSPDX, "cyclonedx": sbomvalidator.FormatCycloneDX}
+	target, ok := targets[*to]
+	if !ok || flags.NArg() != 1 {
+		fatalf("Usage: %s convert -to=spdx|cyclonedx [-out=<path>] [-fail-on-loss] <sbom>", programName())
+	}
+
+	content, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fatalf("Failed to read SBOM: %v", err)
+	}
+
+	result, err := sbomconvert.Convert(content, target, sbomconvert.Config{})
+	if err != nil {
+		fatalf("Error during conversion - %v", err)
+	}
+	for _, loss := range result.Losses {
+		log.Printf("Not converted: %s", loss)
+	}
+
+	out, done := openOutput(*outPath)
+	fmt.Fprintln(out, string(result.Document))
+	done()
+
+	if *failOnLoss && !result.Lossless() {
+		return exitInvalid
+	}
+	return exitValid
+}
+
+// bundle either writes a directory of SBOMs as a ZIP bundle with a generated
+// manifest, or validates an existing bundle.
+func bundle(args []string) int {
+	flags := flag.NewFlagSet("bundle", flag.ExitOnError)
+	dir := flags.String("dir", "", "Directory of SBOMs to bundle")
+	outPath := flags.String("out", "", "Path to write the bundle to")
+	verifyPath := flags.String("verify", "", "Path of a bundle to validate")
+	flags.Parse(args)
+
+	if *verifyPath != "" {
+		archive, err := zip.OpenReader(*verifyPath)
+		if err != nil {
+			fatalf("Failed to open bundle: %v", err)
+		}
+		defer archive.Close()
+
+		result, err := sbomvalidator.ValidateBundle(archive)
+		if err != nil {
+			fatalf("Error during bundle validation - %v", err)
+		}
+		data, _ := json.MarshalIndent(result, "", " ")
+		fmt.Println(string(data))
+		if !result.IsValid {
+			return exitInvalid
+		}
+		return exitValid
+	}
+
+	if *dir == "" || *outPath == "" {
+		fatalf("Usage: %s bundle -dir=<sboms> -out=<bundle.zip> | -verify=<bundle.zip>", programName())
+	}
+
+	out, err := os.Create(*outPath)
+	if err != nil {
+		fatalf("Failed to create bundle: %v", err)
+	}
+	defer out.Close()
+
+	manifest, err := sbomvalidator.WriteBundle(out, os.DirFS(*dir))
+	if err != nil {
+		fatalf("Failed to write bundle: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d SBOMs to %s\n", len(manifest.Documents), *outPath)
+	return exitValid
+}
+
+// sign validates, normalizes and signs an SBOM, writing the signed document
+// and, for SPDX, the detached sigstore bundle next to it.
+func sign(args []string) int {
+	flags := flag.NewFlagSet("sign", flag.ExitOnError)
+	sbomPath := flags.String("file", "", "Path to the SBOM JSON file")
+	keyPath := flags.String("key", "", "Path to a PEM encoded private key (ECDSA, Ed25519 or RSA)")
+	outPath := flags.String("out", "", "Path to write the signed SBOM to")
+	flags.Parse(args)
+
+	if *sbomPath == "" || *keyPath == "" || *outPath == "" {
+		fatalf("Usage: %s sign -file=<sbom.json> -key=<key.pem> -out=<signed.json>", programName())
+	}
+
+	jsonData, err := os.ReadFile(*sbomPath)
+	if err != nil {
+		fatalf("Failed to read SBOM file: %v", err)
+	}
+
+	signer, err := loadSigner(*keyPath)
+	if err != nil {
+		fatalf("Failed to load signing key: %v", err)
+	}
+
+	signed, err := sbomvalidator.SignSBOM(jsonData, signer)
+	if err != nil {
+		fatalf("Error during signing - %v", err)
+	}
+
+	if err := os.WriteFile(*outPath, signed.Document, 0o644); err != nil {
+		fatalf("Failed to write signed SBOM: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Signed SBOM (%s) written to %s\n", signed.Algorithm, *outPath)
+
+	if signed.Bundle != nil {
+		bundlePath := *outPath + ".sigstore.json"
+		if err := os.WriteFile(bundlePath, signed.Bundle, 0o644); err != nil {
+			fatalf("Failed to write signature bundle: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Signature bundle written to %s\n", bundlePath)
+	}
+	return exitValid
+}
+
+// loadSigner reads a PEM encoded PKCS#8, SEC 1 (EC) or PKCS#1 (RSA) private key.
+func loadSigner(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type: %T", key)
+	}
+	return signer, nil
+}
+
+// serve runs the HTTP API until the process is stopped.
+func serve(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "Address to listen on")
+	workers := flags.Int("workers", 0, "Number of SBOMs validated concurrently (default: number of CPUs)")
+	queueSize := flags.Int("queue", server.DefaultQueueSize, "Number of files that may be queued at once before returning 429")
+	timeout := flags.Duration("timeout", 0, "Abort the validation of a file that takes longer than this (0 for no limit)")
+	channelsPath := flags.String("channels", "", "JSON file of submission channels, each with a policy pack, accepted formats, webhooks and quarantine")
+	quarantine := flags.String("quarantine", "", "Directory to store SBOMs submitted to the default endpoints that fail validation in")
+	cacheSize := flags.Int("cache-size", server.DefaultCacheSize, "Number of results cached per channel for ETag revalidation (negative to disable)")
+	cacheTTL := flags.Duration("cache-ttl", server.DefaultCacheTTL, "How long a result is cached")
+	flags.Parse(args)
+
+	var channels []server.Channel
+	if *channelsPath != "" {
+		data, err := os.ReadFile(*channelsPath)
+		if err != nil {
+			fatalf("Failed to read channels: %v", err)
+		}
+		channels, err = server.ParseChannels(data)
+		if err != nil {
+			fatalf("Invalid channels in %s: %v", *channelsPath, err)
+		}
+	}
+
+	cfg := server.Config{Workers: *workers, QueueSize: *queueSize, Timeout: *timeout, Channels: channels, CacheSize: *cacheSize, CacheTTL: *cacheTTL}
+	if *quarantine != "" {
+		cfg.Quarantine = sbomvalidator.DirQuarantine{Dir: *quarantine}
+	}
+	s := server.New(cfg)
+	defer s.Close()
+
+	log.Printf("Listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, s); err != nil {
+		fatalf("Server failed: %v", err)
+	}
+	return exitValid
+}
+
+// runDaemon serves validation on a Unix domain socket until the process is
+// interrupted, with the schemas compiled up front.
+func runDaemon(args []string) int {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	socket := flags.String("socket", filepath.Join(os.TempDir(), "sbom-validator.sock"), "Path of the Unix domain socket")
+	timeout := flags.Duration("timeout", 0, "Abort the validation of an SBOM that takes longer than this (0 for no limit)")
+	flags.Parse(args)
+
+	if err := sbomvalidator.PrecompileSchemas(); err != nil {
+		log.Printf("Some schemas could not be precompiled and will be compiled on first use: %v", err)
+	}
+
+	d := daemon.New(daemon.Config{Timeout: *timeout})
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupted
+		d.Close()
+	}()
+
+	log.Printf("Listening on %s", *socket)
+	if err := d.ListenAndServe(*socket); err != nil {
+		fatalf("Daemon failed: %v", err)
 	}
+	return exitValid
 }
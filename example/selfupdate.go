@@ -0,0 +1,348 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shiftleftcyber/sbom-validator"
+)
+
+// version is the release this binary was built from, set by GoReleaser with
+// -ldflags "-X main.version=<version>".
+var version = "dev"
+
+// releaseEndpoint is the GitHub API endpoint describing the latest release.
+const releaseEndpoint = "https://api.github.com/repos/shiftleftcyber/sbom-validator/releases/latest"
+
+// maxReleaseAssetSize bounds the size of a downloaded release asset.
+const maxReleaseAssetSize = 256 << 20
+
+// release is the subset of a GitHub release that self-update needs.
+type release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// asset returns the release asset with the given name, or nil.
+func (r *release) asset(name string) *releaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// selfUpdate replaces the running binary with the latest release for this
+// platform. The release archive must match the SHA-256 listed in the
+// release's checksums file, and the checksums file must carry a detached
+// signature (<checksums>.sig, with its certificate in <checksums>.pem, if
+// any) from one of the trusted keys or roots.
+func selfUpdate(args []string) int {
+	flags := flag.NewFlagSet("self-update", flag.ExitOnError)
+	endpoint := flags.String("endpoint", releaseEndpoint, "Release endpoint (GitHub API \"latest release\" JSON)")
+	checkOnly := flags.Bool("check", false, "Only report whether a newer release is available")
+	force := flags.Bool("force", false, "Update even if this build is current or a development build")
+	trustedKeys := flags.String("trusted-keys", "", "Comma-separated PEM public keys the checksums file must be signed with")
+	trustedRoots := flags.String("trusted-roots", "", "PEM bundle of CA certificates the checksums signing certificate must chain to")
+	signers := flags.String("signers", "", "Comma-separated signer identities to accept")
+	insecure := flags.Bool("insecure-skip-signature", false, "Update with only the checksum verified (not recommended)")
+	flags.Parse(args)
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	latest, err := fetchRelease(client, *endpoint)
+	if err != nil {
+		fatalf("Failed to check for updates: %v", err)
+	}
+
+	latestVersion := strings.TrimPrefix(latest.TagName, "v")
+	switch {
+	case version == "dev" && !*force:
+		fmt.Fprintf(os.Stderr, "This is a development build; the latest release is %s (use -force to install it)\n", latestVersion)
+		return exitValid
+	case version != "dev" && !newerRelease(latestVersion, version) && !*force:
+		fmt.Fprintf(os.Stderr, "%s is up to date\n", version)
+		return exitValid
+	case *checkOnly:
+		fmt.Fprintf(os.Stderr, "%s is available (current: %s)\n", latestVersion, version)
+		return exitValid
+	}
+
+	if *trustedKeys == "" && *trustedRoots == "" && !*insecure {
+		fatalf("self-update verifies the release signature: pass -trusted-keys or -trusted-roots (or -insecure-skip-signature)")
+	}
+	binary, err := downloadRelease(client, latest, latestVersion, *insecure,
+		signaturePolicy(*trustedKeys, *trustedRoots, *signers))
+	if err != nil {
+		fatalf("Failed to download %s: %v", latestVersion, err)
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		fatalf("Failed to locate the running binary: %v", err)
+	}
+	if err := replaceExecutable(executable, binary); err != nil {
+		fatalf("Failed to install %s: %v", latestVersion, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Updated %s from %s to %s\n", executable, version, latestVersion)
+	return exitValid
+}
+
+// fetchRelease reads the release description from the endpoint.
+func fetchRelease(client *http.Client, endpoint string) (*release, error) {
+	data, err := download(client, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var r release
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid release description: %v", err)
+	}
+	if r.TagName == "" {
+		return nil, fmt.Errorf("release description has no tag_name")
+	}
+	return &r, nil
+}
+
+// downloadRelease downloads the release archive for this platform, verifies
+// it, and returns the binary it contains.
+func downloadRelease(client *http.Client, r *release, releaseVersion string, insecure bool, policy sbomvalidator.SignaturePolicy) ([]byte, error) {
+	// GoReleaser's default archive and checksums names
+	base := fmt.Sprintf("sbom-validator_%s_%s_%s", releaseVersion, runtime.GOOS, runtime.GOARCH)
+	archiveAsset := r.asset(base + ".tar.gz")
+	if runtime.GOOS == "windows" || archiveAsset == nil {
+		archiveAsset = r.asset(base + ".zip")
+	}
+	if archiveAsset == nil {
+		return nil, fmt.Errorf("release has no archive for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	checksumsName := fmt.Sprintf("sbom-validator_%s_checksums.txt", releaseVersion)
+	checksumsAsset := r.asset(checksumsName)
+	if checksumsAsset == nil {
+		return nil, fmt.Errorf("release has no %s", checksumsName)
+	}
+
+	checksums, err := download(client, checksumsAsset.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !insecure {
+		if err := verifyChecksumsSignature(client, r, checksumsName, checksums, policy); err != nil {
+			return nil, err
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: not verifying the signature of %s\n", checksumsName)
+	}
+
+	archive, err := download(client, archiveAsset.URL)
+	if err != nil {
+		return nil, err
+	}
+	want, err := releaseChecksum(checksums, archiveAsset.Name)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("%s has SHA-256 %s, but the checksums file lists %s", archiveAsset.Name, got, want)
+	}
+
+	return extractBinary(archiveAsset.Name, archive)
+}
+
+// verifyChecksumsSignature verifies the detached signature of the checksums
+// file, as produced by `cosign sign-blob`.
+func verifyChecksumsSignature(client *http.Client, r *release, checksumsName string, checksums []byte, policy sbomvalidator.SignaturePolicy) error {
+	signatureAsset := r.asset(checksumsName + ".sig")
+	if signatureAsset == nil {
+		return fmt.Errorf("release has no %s.sig", checksumsName)
+	}
+	signature, err := download(client, signatureAsset.URL)
+	if err != nil {
+		return err
+	}
+	policy.DetachedSignature = signature
+
+	if certificateAsset := r.asset(checksumsName + ".pem"); certificateAsset != nil {
+		if policy.Certificate, err = download(client, certificateAsset.URL); err != nil {
+			return err
+		}
+	}
+
+	sig, err := sbomvalidator.VerifySBOMSignature(checksums, policy)
+	if err != nil {
+		return fmt.Errorf("signature of %s: %v", checksumsName, err)
+	}
+	fmt.Fprintf(os.Stderr, "%s signed by %s\n", checksumsName, sig.Signer)
+	return nil
+}
+
+// releaseChecksum returns the SHA-256 listed for a file in a checksums file
+// ("<hex>  <name>" lines, as written by sha256sum).
+func releaseChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("checksums file does not list %s", name)
+}
+
+// extractBinary returns the sbom-validator executable from a release archive.
+func extractBinary(archiveName string, archive []byte) ([]byte, error) {
+	binaryName := "sbom-validator"
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+
+	if strings.HasSuffix(archiveName, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if path.Base(f.Name) != binaryName {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxReleaseAssetSize))
+		}
+		return nil, fmt.Errorf("%s does not contain %s", archiveName, binaryName)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s does not contain %s", archiveName, binaryName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binaryName {
+			return io.ReadAll(io.LimitReader(tr, maxReleaseAssetSize))
+		}
+	}
+}
+
+// replaceExecutable swaps the binary at path for a new one. The new binary is
+// written next to it and renamed into place, so an interrupted update leaves
+// the old binary working. The running binary is moved aside first, which
+// Windows requires; the old copy is removed where the platform allows it.
+func replaceExecutable(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sbom-validator-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+
+	old := path + ".old"
+	if err := os.Rename(path, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		// put the old binary back
+		os.Rename(old, path)
+		return err
+	}
+	os.Remove(old)
+	return nil
+}
+
+// download fetches a URL, failing on error statuses and oversized bodies.
+func download(client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "sbom-validator/"+version)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReleaseAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxReleaseAssetSize {
+		return nil, fmt.Errorf("GET %s: response exceeds %d bytes", url, maxReleaseAssetSize)
+	}
+	return data, nil
+}
+
+// newerRelease reports whether release version a is newer than b, comparing
+// dot-separated numeric components ("1.10.0" > "1.9.2"). Pre-release suffixes
+// are ignored.
+func newerRelease(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(strings.SplitN(as[i], "-", 2)[0])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(strings.SplitN(bs[i], "-", 2)[0])
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
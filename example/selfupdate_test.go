@@ -0,0 +1,188 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/shiftleftcyber/sbom-validator"
+)
+
+// releaseArchive returns a GoReleaser-style tar.gz archive containing the
+// sbom-validator binary.
+func releaseArchive(t *testing.T, binary []byte) []byte {
+	t.Helper()
+	name := "sbom-validator"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// serveRelease serves the assets of a release and returns its description.
+func serveRelease(t *testing.T, assets map[string][]byte) *release {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := assets[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	r := &release{TagName: "v1.2.0"}
+	for name := range assets {
+		r.Assets = append(r.Assets, releaseAsset{Name: name, URL: server.URL + "/" + name})
+	}
+	return r
+}
+
+func signChecksums(t *testing.T, key *ecdsa.PrivateKey, checksums []byte) []byte {
+	t.Helper()
+	digest := sha256.Sum256(checksums)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(base64.StdEncoding.EncodeToString(signature))
+}
+
+func TestDownloadRelease(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	untrusted, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	policy := sbomvalidator.SignaturePolicy{TrustedKeys: map[string]crypto.PublicKey{"release": key.Public()}}
+
+	binary := []byte("new sbom-validator")
+	archive := releaseArchive(t, binary)
+	base := fmt.Sprintf("sbom-validator_1.2.0_%s_%s", runtime.GOOS, runtime.GOARCH)
+	otherPlatform := "sbom-validator_1.2.0_plan9_mips.tar.gz"
+	checksumsName := "sbom-validator_1.2.0_checksums.txt"
+	checksumsFor := func(archive []byte) []byte {
+		sum := sha256.Sum256(archive)
+		return []byte(fmt.Sprintf("%s  %s\n%s  %s\n", strings.Repeat("0", 64), otherPlatform, hex.EncodeToString(sum[:]), base+".tar.gz"))
+	}
+	checksums := checksumsFor(archive)
+
+	tests := []struct {
+		name     string
+		assets   map[string][]byte
+		insecure bool
+		wantErr  string
+	}{
+		{
+			name: "Signed release",
+			assets: map[string][]byte{
+				base + ".tar.gz": archive, otherPlatform: []byte("other"),
+				checksumsName: checksums, checksumsName + ".sig": signChecksums(t, key, checksums),
+			},
+		},
+		{
+			name:    "No archive for this platform",
+			assets:  map[string][]byte{otherPlatform: []byte("other"), checksumsName: checksums},
+			wantErr: "release has no archive for " + runtime.GOOS + "/" + runtime.GOARCH,
+		},
+		{
+			name:    "No checksums file",
+			assets:  map[string][]byte{base + ".tar.gz": archive},
+			wantErr: "release has no " + checksumsName,
+		},
+		{
+			name: "Checksum mismatch",
+			assets: map[string][]byte{
+				base + ".tar.gz": releaseArchive(t, []byte("tampered")),
+				checksumsName:    checksums, checksumsName + ".sig": signChecksums(t, key, checksums),
+			},
+			wantErr: "but the checksums file lists",
+		},
+		{
+			name: "Checksum mismatch without signature verification",
+			assets: map[string][]byte{
+				base + ".tar.gz": releaseArchive(t, []byte("tampered")),
+				checksumsName:    checksums,
+			},
+			insecure: true,
+			wantErr:  "but the checksums file lists",
+		},
+		{
+			name:    "No signature",
+			assets:  map[string][]byte{base + ".tar.gz": archive, checksumsName: checksums},
+			wantErr: "release has no " + checksumsName + ".sig",
+		},
+		{
+			name: "Signed by an untrusted key",
+			assets: map[string][]byte{
+				base + ".tar.gz": archive,
+				checksumsName:    checksums, checksumsName + ".sig": signChecksums(t, untrusted, checksums),
+			},
+			wantErr: "signature of " + checksumsName,
+		},
+		{
+			name: "Checksums changed after signing",
+			assets: map[string][]byte{
+				base + ".tar.gz":       releaseArchive(t, []byte("tampered")),
+				checksumsName:          checksumsFor(releaseArchive(t, []byte("tampered"))),
+				checksumsName + ".sig": signChecksums(t, key, checksums),
+			},
+			wantErr: "signature of " + checksumsName,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := downloadRelease(http.DefaultClient, serveRelease(t, tt.assets), "1.2.0", tt.insecure, policy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("downloadRelease() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("downloadRelease() unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, binary) {
+				t.Errorf("downloadRelease() = %q, want %q", got, binary)
+			}
+		})
+	}
+}
+
+func TestNewerRelease(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "1.10.0", b: "1.9.2", want: true},
+		{a: "1.9.2", b: "1.10.0"},
+		{a: "1.2.0", b: "1.2.0"},
+		{a: "1.2.1", b: "1.2", want: true},
+		{a: "1.3.0-rc1", b: "1.2.0", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := newerRelease(tt.a, tt.b); got != tt.want {
+			t.Errorf("newerRelease(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
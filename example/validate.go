@@ -0,0 +1,935 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shiftleftcyber/sbom-validator"
+	"github.com/shiftleftcyber/sbom-validator/report"
+)
+
+// fileResult is the outcome of validating one file, as printed with
+// -output=json.
+type fileResult struct {
+	File     string                          `json:"file"`
+	Checksum string                          `json:"checksum,omitempty"`
+	Result   *sbomvalidator.StructuredResult `json:"result,omitempty"`
+	Error    string                          `json:"error,omitempty"`
+	// Verdict is set for files validated one at a time.
+	Verdict *sbomvalidator.Verdict `json:"verdict,omitempty"`
+}
+
+// validate validates one or more SBOM files, prints the results and returns
+// exitValid, exitInvalid or exitError.
+func validate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	sbomPath := flags.String("file", "", "Path to the SBOM file (JSON, CycloneDX XML or SPDX tag-value, optionally .gz, .zst or .br compressed); files may also be given as arguments")
+	dir := flags.String("dir", "", "Directory whose SBOMs (*.json, *.xml, *.spdx) are validated concurrently, instead of files")
+	archive := flags.String("archive", "", "Archive (.zip, .tar or .tar.gz, e.g., an OCI image layout) whose SBOMs are validated concurrently, instead of files")
+	checksums := flags.String("checksums", "", "SHA256SUMS manifest to verify -dir or -archive files against (default: SHA256SUMS at their root, if present)")
+	quarantine := flags.String("quarantine", "",
+		"Directory to copy SBOMs of -dir, -archive or an image that fail validation to, each with its JSON report")
+	quarantineMove := flags.Bool("quarantine-move", false, "With -quarantine and -dir, move failing SBOMs out of the directory instead of copying them")
+	concurrency := flags.Int("concurrency", 0, "Number of SBOMs validated at once with -dir, -archive or an image (default: number of CPUs)")
+	groupBy := flags.String("group-by", "", "SBOM property (e.g., internal:team) whose value groups the results of -dir, -archive or an image")
+	groupDir := flags.String("group-dir", "", "With -group-by, directory to write a report per group to, named after the group")
+	output := flags.String("output", "text", "Report format: text, json, sarif or junit")
+	outputFile := flags.String("output-file", "", "Path to write the report to instead of stdout")
+	maxErrors := flags.Int("max-errors", 10, "Maximum number of errors printed per file in text output (0 for all)")
+	allowUnknownVersion := flags.Bool("allow-unknown-version", false,
+		"Validate SBOMs declaring a newer spec version against the latest known schema (best effort)")
+	versionMode := flags.String("version-mode", "strict",
+		"What to do with spec versions without a schema: strict (fail) or lenient (validate against the closest supported version)")
+	formatAssertion := flags.Bool("format-assertion", true,
+		"Treat schema format violations (e.g., date-time) as errors; false reports them as warnings")
+	checkHashes := flags.Bool("check-hashes", false,
+		"Check that declared hashes name algorithms of the SBOM's format and have digests of the right length")
+	artifactsPath := flags.String("artifacts", "", "Directory or .zip archive of artifacts to verify declared hashes against")
+	provenancePath := flags.String("provenance", "", "SLSA provenance file to cross-check the SBOM against")
+	controls := flags.Bool("controls", false, "Include NIST SSDF / ISO 27001 / CWE control mappings in the report")
+	online := flags.Bool("online", false, "Cross-check declared licenses against package registries")
+	cacheDir := flags.String("cache-dir", "", "Directory for caching registry lookups and fetched schemas across runs")
+	licenseSample := flags.Int("license-sample", 25, "Number of components to cross-check in online mode (0 for all)")
+	internalNamespaces := flags.String("internal-namespaces", "",
+		"Comma-separated internal package namespaces (e.g., @acme,com.acme,acme-*) to check for dependency confusion")
+	componentNaming := flags.Bool("component-naming", false,
+		"Warn about components that are probably the same package spelled differently")
+	checkLicenses := flags.Bool("check-licenses", false,
+		"Check license expressions against the embedded SPDX License List (malformed expressions are errors)")
+	licenseStats := flags.Bool("license-stats", false,
+		"Report license statistics: components per license, unknown licenses and the copyleft/permissive breakdown")
+	scanSecrets := flags.Bool("scan-secrets", false, "Fail SBOMs whose fields contain secrets such as access keys and CI tokens")
+	secretPatterns := flags.String("secret-patterns", "",
+		"File of \"name = regular expression\" lines to scan for instead of the built-in secret patterns (implies -scan-secrets)")
+	checkReferences := flags.Bool("check-references", false, "Warn about malformed external reference URLs and purls")
+	resolveReferences := flags.Bool("resolve-references", false,
+		"With -check-references, also warn about external reference URLs that are unreachable (makes network requests)")
+	nestedBOMHosts := flags.String("nested-bom-hosts", "",
+		"Comma-separated hosts (e.g., sbom.example.com,*.acme.io) to fetch and validate BOMs referenced with externalReferences of type bom from")
+	nestedBOMDepth := flags.Int("nested-bom-depth", 1, "With -nested-bom-hosts, the number of levels of nested BOMs to validate")
+	minCoverage := flags.Float64("min-dependency-coverage", 0, "Fraction of components that must appear in the dependency graph (e.g., 0.8)")
+	requirePrimaryDependency := flags.Bool("require-primary-dependency", false, "Require the primary component to have a direct dependency")
+	maxUnreachable := flags.Float64("max-unreachable", 0,
+		"Fraction of components that may be unreachable from the primary component through the dependency graph (e.g., 0.1)")
+	checkGraph := flags.Bool("check-graph", false,
+		"Check the dependency graph for duplicate and dangling references, cycles and unreferenced components")
+	quality := flags.String("quality", "", "Comma-separated NTIA quality checks to run, or \"all\"")
+	profiles := flags.String("profiles", "", "Comma-separated profiles to enforce (e.g., ml-bom,firmware,build-phase,component-roles)")
+	binaryAnalyzer := flags.String("binary-analyzer", "",
+		"Command that reads the SBOM's components as JSON on stdin and prints findings as JSON")
+	digestRegistry := flags.String("publish-digest", "", "Registry endpoint to publish the digest of a valid SBOM to")
+	fixPath := flags.String("fix", "", "Path to write the SBOM to with every deterministic fix applied")
+	schemaDir := flags.String("schema-dir", "",
+		"Directory of schemas (cyclonedx/bom-<version>.schema.json, spdx/spdx-<version>.schema.json) overriding the embedded ones")
+	fetchSchemas := flags.Bool("fetch-schemas", false, "Fetch schemas for spec versions newer than this build from cyclonedx.org and spdx.org")
+	schemaPins := flags.String("schema-pins", "",
+		"File listing the schema digests to validate against, one per line, as recorded in earlier results")
+	trustedKeys := flags.String("trusted-keys", "",
+		"Comma-separated PEM public keys or certificates; SBOMs must be signed with one of them")
+	trustedRoots := flags.String("trusted-roots", "", "PEM bundle of CA certificates that signing certificates must chain to")
+	signers := flags.String("signers", "", "Comma-separated signer identities (key file names, certificate emails or URIs) to accept")
+	policyPath := flags.String("policy", "",
+		"YAML or JSON policy file declaring the rules to run, severity overrides, allowed licenses, minimum spec versions and required fields")
+	failOnWarnings := flags.Bool("fail-on-warnings", false, "Treat SBOMs with warnings as invalid")
+	failOn := flags.String("fail-on", "",
+		"Fail SBOMs with findings at this SARIF level or above: error, warning or note (high and critical warnings are errors, low ones notes)")
+	ignoreRules := flags.String("ignore-rules", "", "Comma-separated rule IDs whose findings are dropped (e.g., schema-format,identifier-mismatch)")
+	debug := flags.Bool("debug", false, "Log diagnostics (SBOM type detected, schema selected, duration) to stderr")
+	maxSBOMAge := flags.Duration("max-sbom-age", -1,
+		"Warn about SBOMs created longer ago than this (e.g., 2160h for 90 days; 0 only warns about creation times in the future)")
+	now := flags.String("now", "", "RFC 3339 time to check SBOM ages against and stamp results with, instead of the current time")
+	deterministic := flags.Bool("deterministic", false,
+		"Leave timing fields (validatedAt, duration) out of reports, so that reports of the same SBOMs are identical")
+	timeout := flags.Duration("timeout", 0, "Abort validation that takes longer than this (e.g., 30s or 5m; 0 for no limit)")
+	flags.Parse(args)
+
+	paths := flags.Args()
+	if *sbomPath != "" {
+		paths = append([]string{*sbomPath}, paths...)
+	}
+	// an image reference is validated like an archive: every SBOM attached to
+	// the image is validated concurrently
+	var image string
+	for _, path := range paths {
+		if strings.HasPrefix(path, "oci://") {
+			if len(paths) > 1 {
+				fatalf("An oci:// image must be validated on its own")
+			}
+			image, paths = path, nil
+		}
+	}
+	sources := 0
+	for _, given := range []bool{len(paths) > 0, *dir != "", *archive != "", image != ""} {
+		if given {
+			sources++
+		}
+	}
+	if sources != 1 {
+		fatalf("Usage: %s validate [flags] <sbom>... | oci://<image> | -dir=<dir> | -archive=<archive>", programName())
+	}
+	if *versionMode != string(sbomvalidator.VersionStrict) && *versionMode != string(sbomvalidator.VersionLenient) {
+		fatalf("Unknown version mode %q; expected strict or lenient", *versionMode)
+	}
+	if !reportFormats[*output] {
+		fatalf("Unknown output format %q; expected text, json, sarif or junit", *output)
+	}
+	if *failOn != "" {
+		if _, err := report.Fails(nil, *failOn); err != nil {
+			fatalf("Invalid -fail-on: %v", err)
+		}
+	}
+	singleFileChecks := *fixPath != "" || *artifactsPath != "" || *provenancePath != "" || *online
+	verifySignatures := *trustedKeys != "" || *trustedRoots != ""
+	if verifySignatures && len(paths) == 0 {
+		fatalf("-trusted-keys and -trusted-roots apply to SBOM files, not -dir, -archive or images")
+	}
+	if *quarantine != "" && len(paths) > 0 {
+		fatalf("-quarantine applies to -dir, -archive or images, not SBOM files")
+	}
+	if *groupBy != "" && len(paths) > 0 {
+		fatalf("-group-by applies to -dir, -archive or images, not SBOM files")
+	}
+	if *groupDir != "" && *groupBy == "" {
+		fatalf("-group-dir requires -group-by")
+	}
+	if singleFileChecks && len(paths) != 1 {
+		fatalf("-fix, -artifacts, -provenance and -online apply to a single SBOM")
+	}
+
+	limits := sbomvalidator.ValidationOptions{FailOnWarnings: *failOnWarnings}
+	if *ignoreRules != "" {
+		limits.IgnoreRules = strings.Split(*ignoreRules, ",")
+	}
+
+	opts := []sbomvalidator.Option{
+		sbomvalidator.WithValidationOptions(limits),
+		sbomvalidator.WithAllowUnknownVersion(*allowUnknownVersion),
+		sbomvalidator.WithVersionMode(sbomvalidator.VersionMode(*versionMode)),
+		sbomvalidator.WithFormatAssertion(*formatAssertion),
+		sbomvalidator.WithControlMappings(*controls),
+		sbomvalidator.WithComponentNaming(*componentNaming),
+		sbomvalidator.WithLicenseValidation(*checkLicenses),
+		sbomvalidator.WithGraphAnalysis(*checkGraph),
+		sbomvalidator.WithLicenseStats(*licenseStats),
+		sbomvalidator.WithDeterministic(*deterministic),
+	}
+	if *now != "" {
+		at, err := time.Parse(time.RFC3339, *now)
+		if err != nil {
+			fatalf("Invalid -now time: %v", err)
+		}
+		opts = append(opts, sbomvalidator.WithClock(sbomvalidator.FixedClock(at)))
+	}
+	if *maxSBOMAge >= 0 {
+		opts = append(opts, sbomvalidator.WithMaxSBOMAge(*maxSBOMAge))
+	}
+	if *debug {
+		opts = append(opts, sbomvalidator.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+	if *policyPath != "" {
+		policy, err := sbomvalidator.LoadPolicy(*policyPath)
+		if err != nil {
+			fatalf("Invalid policy: %v", err)
+		}
+		opts = append(opts, sbomvalidator.WithPolicy(policy))
+	}
+	if *internalNamespaces != "" {
+		opts = append(opts, sbomvalidator.WithInternalNamespaces(strings.Split(*internalNamespaces, ",")...))
+	}
+	if *profiles != "" {
+		var enabled []sbomvalidator.Profile
+		for _, profile := range strings.Split(*profiles, ",") {
+			enabled = append(enabled, sbomvalidator.Profile(strings.TrimSpace(profile)))
+		}
+		opts = append(opts, sbomvalidator.WithProfiles(enabled...))
+	}
+	if *secretPatterns != "" {
+		data, err := os.ReadFile(*secretPatterns)
+		if err != nil {
+			fatalf("Failed to read secret patterns: %v", err)
+		}
+		patterns, err := sbomvalidator.ParseSecretPatterns(data)
+		if err != nil {
+			fatalf("Invalid secret patterns in %s: %v", *secretPatterns, err)
+		}
+		opts = append(opts, sbomvalidator.WithSecretScanning(patterns...))
+	} else if *scanSecrets {
+		opts = append(opts, sbomvalidator.WithSecretScanning())
+	}
+	if *checkHashes {
+		opts = append(opts, sbomvalidator.WithHashChecks(sbomvalidator.HashCheckPolicy{}))
+	}
+	if *checkReferences || *resolveReferences {
+		opts = append(opts, sbomvalidator.WithReferenceCheck(sbomvalidator.ReferenceCheckPolicy{Resolve: *resolveReferences}))
+	}
+	if *nestedBOMHosts != "" {
+		opts = append(opts, sbomvalidator.WithNestedBOMs(sbomvalidator.NestedBOMPolicy{
+			AllowedHosts: strings.Split(*nestedBOMHosts, ","),
+			MaxDepth:     *nestedBOMDepth,
+		}))
+	}
+	if *minCoverage > 0 || *requirePrimaryDependency || *maxUnreachable > 0 {
+		opts = append(opts, sbomvalidator.WithDependencyCoverage(sbomvalidator.DependencyCoveragePolicy{
+			MinCoverage:              *minCoverage,
+			RequirePrimaryDependency: *requirePrimaryDependency,
+			MaxUnreachable:           *maxUnreachable,
+		}))
+	}
+	if *quality == "all" {
+		opts = append(opts, sbomvalidator.WithQualityChecks(sbomvalidator.QualityChecks()...))
+	} else if *quality != "" {
+		opts = append(opts, sbomvalidator.WithQualityChecks(strings.Split(*quality, ",")...))
+	}
+	if *binaryAnalyzer != "" {
+		opts = append(opts, sbomvalidator.WithBinaryAnalyzers(commandAnalyzer{command: *binaryAnalyzer}))
+	}
+	if *digestRegistry != "" {
+		registry := sbomvalidator.NewHTTPDigestRegistry(*digestRegistry)
+		registry.Token = os.Getenv("SBOM_DIGEST_REGISTRY_TOKEN")
+		opts = append(opts, sbomvalidator.WithDigestPublisher(registry))
+	}
+	var providers []sbomvalidator.SchemaProvider
+	if *schemaDir != "" {
+		providers = append(providers, sbomvalidator.NewDirSchemaProvider(*schemaDir))
+	}
+	if *fetchSchemas {
+		providers = append(providers, newerSchemas{sbomvalidator.NewHTTPSchemaProvider(schemaCacheDir(*cacheDir))})
+	}
+	if len(providers) > 0 {
+		opts = append(opts, sbomvalidator.WithSchemaProviders(providers...))
+	}
+	if *schemaPins != "" {
+		pins, err := os.ReadFile(*schemaPins)
+		if err != nil {
+			fatalf("Failed to read schema pins: %v", err)
+		}
+		opts = append(opts, sbomvalidator.WithPinnedSchemas(strings.Fields(string(pins))...))
+	}
+
+	var policy sbomvalidator.SignaturePolicy
+	if verifySignatures {
+		policy = signaturePolicy(*trustedKeys, *trustedRoots, *signers)
+	}
+
+	out, closeOutput := openOutput(*outputFile)
+	defer closeOutput()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	if *dir != "" || *archive != "" || image != "" {
+		opts = append(opts, sbomvalidator.WithConcurrency(*concurrency))
+		if *checksums != "" {
+			f, err := os.Open(*checksums)
+			if err != nil {
+				fatalf("Failed to open checksums: %v", err)
+			}
+			sums, err := sbomvalidator.ParseChecksums(f)
+			f.Close()
+			if err != nil {
+				fatalf("Invalid checksums: %v", err)
+			}
+			opts = append(opts, sbomvalidator.WithChecksums(sums))
+		}
+		if *quarantine != "" {
+			opts = append(opts, sbomvalidator.WithQuarantine(sbomvalidator.QuarantinePolicy{
+				Quarantine: sbomvalidator.DirQuarantine{Dir: *quarantine},
+				Move:       *quarantineMove,
+			}))
+		}
+		if *groupBy != "" {
+			opts = append(opts, sbomvalidator.WithGroupBy(*groupBy))
+		}
+		batchReport := batchReport{output: *output, maxErrors: *maxErrors, failOn: *failOn, groupDir: *groupDir}
+		if *archive != "" {
+			return validateArchive(ctx, out, *archive, batchReport, opts)
+		}
+		if image != "" {
+			return validateImage(ctx, out, image, batchReport, opts)
+		}
+		return validateDir(ctx, out, *dir, *quarantineMove, batchReport, opts)
+	}
+
+	// the single-file checks run in the same pass as validation
+	var runOpts sbomvalidator.RunOptions
+	if singleFileChecks {
+		if *artifactsPath != "" {
+			artifacts, closeArtifacts := openArtifacts(*artifactsPath)
+			defer closeArtifacts()
+			runOpts.Artifacts = artifacts
+		}
+		if *provenancePath != "" {
+			provenance, err := os.ReadFile(*provenancePath)
+			if err != nil {
+				fatalf("Failed to read provenance file: %v", err)
+			}
+			runOpts.Provenance = provenance
+		}
+		if *online {
+			runOpts.LicenseLookup = licenseLookup(*cacheDir)
+			runOpts.LicenseSample = *licenseSample
+		}
+	}
+	exitCode := exitValid
+	results := make([]fileResult, 0, len(paths))
+	var run *sbomvalidator.RunResult
+	for _, path := range paths {
+		r := fileResult{File: path}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			runOpts.Options = append(opts, sbomvalidator.WithContentEncoding(sbomvalidator.EncodingForName(path)))
+			if verifySignatures {
+				runOpts.Options = append(runOpts.Options, sbomvalidator.WithSignatureVerification(detachedSignature(path, policy)))
+			}
+			run, err = sbomvalidator.Run(ctx, data, runOpts)
+			r.Result = run.Validation
+			verdict := sbomvalidator.NewVerdict(r.Result, err)
+			r.Verdict = &verdict
+		}
+		if err != nil {
+			r.Error = err.Error()
+			exitCode = exitError
+		} else if !r.Result.IsValid && exitCode == exitValid {
+			exitCode = exitInvalid
+		}
+		results = append(results, r)
+
+		printFindings(r, *maxErrors)
+		if *output == "text" {
+			printVerdict(out, r)
+		}
+	}
+
+	writeReport(out, *output, results, results)
+
+	if singleFileChecks && results[0].Error == "" {
+		passed := true
+		if *fixPath != "" {
+			data, _ := os.ReadFile(paths[0])
+			fixSBOM(data, *fixPath, opts)
+		}
+		if run.Hashes != nil {
+			passed = printHashes(run.Hashes) && passed
+		}
+		if run.Provenance != nil {
+			passed = printProvenance(run.Provenance) && passed
+		}
+		if run.Licenses != nil {
+			printLicenses(run.Licenses)
+		}
+		if !passed && exitCode == exitValid {
+			exitCode = exitInvalid
+		}
+	}
+
+	return gate(exitCode, results, *failOn)
+}
+
+// validateDir validates every SBOM in a directory tree concurrently, prints
+// the findings and writes the report, with a summary, to out. SBOMs not
+// validated before ctx is done are reported as errors. With removable, a
+// quarantine moves failing SBOMs out of the directory.
+func validateDir(ctx context.Context, out io.Writer, dir string, removable bool, r batchReport, opts []sbomvalidator.Option) int {
+	fsys := os.DirFS(dir)
+	if removable {
+		fsys = removableDir{FS: fsys, root: dir}
+	}
+	batch, err := sbomvalidator.ValidateSBOMDirContext(ctx, fsys, opts...)
+	if batch == nil {
+		fatalf("Error during validation - %v", err)
+	}
+	return r.write(out, dir, batch)
+}
+
+// removableDir is a directory whose files can be removed, for a quarantine
+// that moves SBOMs.
+type removableDir struct {
+	fs.FS
+	root string
+}
+
+func (d removableDir) Remove(name string) error {
+	return os.Remove(filepath.Join(d.root, filepath.FromSlash(name)))
+}
+
+// validateArchive validates every SBOM in a .zip, .tar or .tar.gz archive
+// concurrently, like validateDir.
+func validateArchive(ctx context.Context, out io.Writer, archive string, r batchReport, opts []sbomvalidator.Option) int {
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		fatalf("Failed to read archive: %v", err)
+	}
+	batch, err := sbomvalidator.ValidateSBOMArchiveContext(ctx, data, opts...)
+	if batch == nil {
+		fatalf("Error during validation - %v", err)
+	}
+	return r.write(out, archive, batch)
+}
+
+// validateImage validates every SBOM attached to an image in an OCI registry
+// concurrently, like validateDir. Registry credentials are read from
+// SBOM_REGISTRY_USERNAME and SBOM_REGISTRY_PASSWORD.
+func validateImage(ctx context.Context, out io.Writer, ref string, r batchReport, opts []sbomvalidator.Option) int {
+	fetcher := sbomvalidator.NewOCIFetcher()
+	fetcher.Username = os.Getenv("SBOM_REGISTRY_USERNAME")
+	fetcher.Password = os.Getenv("SBOM_REGISTRY_PASSWORD")
+
+	batch, err := sbomvalidator.ValidateOCIImage(ctx, fetcher, ref, opts...)
+	if batch == nil {
+		fatalf("Error during validation - %v", err)
+	}
+	return r.write(out, "", batch)
+}
+
+// batchReport is how the results of a batch are reported: in the -output
+// format, with at most maxErrors errors printed per file, the -fail-on gate
+// and, with -group-dir, a report per group.
+type batchReport struct {
+	output    string
+	maxErrors int
+	failOn    string
+	groupDir  string
+}
+
+// groupReportExtensions are the file extensions of the reports per group, by
+// -output format.
+var groupReportExtensions = map[string]string{"text": ".txt", "json": ".json", "sarif": ".sarif", "junit": ".xml"}
+
+// write prints the findings of a batch whose files are named relative to
+// root, writes the report, with a summary, to out and, with a group
+// directory, to a file per group, and returns the exit code, with the
+// -fail-on gate applied.
+func (r batchReport) write(out io.Writer, root string, batch *sbomvalidator.BatchResult) int {
+	results := batchFiles(root, batch.Results)
+	for _, fr := range results {
+		printFindings(fr, r.maxErrors)
+	}
+	r.writeBatch(out, results, batch.Summary, batch)
+
+	if r.groupDir != "" {
+		if err := os.MkdirAll(r.groupDir, 0o755); err != nil {
+			fatalf("Failed to create group directory: %v", err)
+		}
+		for _, group := range batch.Groups() {
+			path := filepath.Join(r.groupDir, groupFileName(group.Name)+groupReportExtensions[r.output])
+			f, err := os.Create(path)
+			if err != nil {
+				fatalf("Failed to create group report: %v", err)
+			}
+			r.writeBatch(f, batchFiles(root, group.Results), group.Summary, group)
+			if err := f.Close(); err != nil {
+				fatalf("Failed to write group report: %v", err)
+			}
+		}
+	}
+
+	exitCode := exitValid
+	switch summary := batch.Summary; {
+	case summary.Failed > 0:
+		exitCode = exitError
+	case summary.Invalid+summary.Tampered+summary.Unlisted+summary.Missing > 0:
+		exitCode = exitInvalid
+	}
+	return gate(exitCode, results, r.failOn)
+}
+
+// writeBatch writes the report of the results of a batch, or of one of its
+// groups, to out: jsonReport as JSON, or the results, with the summary in
+// text output.
+func (r batchReport) writeBatch(out io.Writer, results []fileResult, summary sbomvalidator.BatchSummary, jsonReport interface{}) {
+	if r.output == "text" {
+		for _, fr := range results {
+			printVerdict(out, fr)
+		}
+	}
+	writeReport(out, r.output, results, jsonReport)
+	if r.output == "text" {
+		fmt.Fprintf(out, "%d SBOMs: %d valid, %d invalid, %d failed", summary.Total, summary.Valid, summary.Invalid, summary.Failed)
+		if summary.Tampered+summary.Unlisted+summary.Missing > 0 {
+			fmt.Fprintf(out, ", %d tampered, %d unlisted, %d missing", summary.Tampered, summary.Unlisted, summary.Missing)
+		}
+		if summary.Quarantined > 0 {
+			fmt.Fprintf(out, "; %d quarantined", summary.Quarantined)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// batchFiles converts the results of a batch whose files are named relative
+// to root to file results.
+func batchFiles(root string, batch []sbomvalidator.BatchFileResult) []fileResult {
+	results := make([]fileResult, 0, len(batch))
+	for _, r := range batch {
+		results = append(results, fileResult{File: filepath.Join(root, r.Name), Checksum: r.Checksum, Result: r.Result, Error: r.Error})
+	}
+	return results
+}
+
+// groupFileName returns the name of the report file of a group: its name,
+// with characters other than ASCII letters, digits, '.', '-' and '_'
+// replaced by '_' and no leading '.', or "ungrouped" for the SBOMs without a
+// group.
+func groupFileName(name string) string {
+	if name == "" {
+		return "ungrouped"
+	}
+	var b strings.Builder
+	for i, r := range name {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || (r == '.' && i > 0)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// gate applies -fail-on to an exit code: files with findings at the level or
+// above, as the SARIF report levels them, make a valid run invalid.
+func gate(exitCode int, results []fileResult, failOn string) int {
+	if failOn == "" || exitCode != exitValid {
+		return exitCode
+	}
+	if failed, _ := report.Fails(reportFiles(results), failOn); failed {
+		return exitInvalid
+	}
+	return exitCode
+}
+
+// reportFormats are the formats of -output.
+var reportFormats = map[string]bool{"text": true, "json": true, "sarif": true, "junit": true}
+
+// writeReport writes the report for the machine-readable formats: jsonReport
+// as JSON, or the results as SARIF or JUnit XML. Text reports are written
+// per file as they are validated.
+func writeReport(out io.Writer, format string, results []fileResult, jsonReport interface{}) {
+	files := reportFiles(results)
+
+	var err error
+	switch format {
+	case "json":
+		data, _ := json.MarshalIndent(jsonReport, "", " ")
+		_, err = fmt.Fprintln(out, string(data))
+	case "sarif":
+		err = report.WriteSARIF(out, files)
+	case "junit":
+		err = report.WriteJUnit(out, files)
+	}
+	if err != nil {
+		fatalf("Failed to write report: %v", err)
+	}
+}
+
+// reportFiles converts results to the files of a report.
+func reportFiles(results []fileResult) []report.File {
+	var files []report.File
+	for _, r := range results {
+		f := report.File{Name: r.File, Result: r.Result, Error: r.Error}
+		if r.Error != "" {
+			f.Result = nil
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// printVerdict writes the report line for one file: whether it is valid, or
+// why it could not be validated, and which checks rejected it.
+func printVerdict(out io.Writer, r fileResult) {
+	if r.Checksum != "" && r.Checksum != sbomvalidator.ChecksumVerified {
+		fmt.Fprintf(out, "%s: %s: %s\n", r.File, r.Checksum, r.Error)
+		return
+	}
+	if r.Error != "" {
+		fmt.Fprintf(out, "%s: error: %s\n", r.File, r.Error)
+		return
+	}
+
+	result := r.Result
+	signed := ""
+	if result.Signature != nil {
+		signed = ", signed by " + result.Signature.Signer
+	}
+	if result.IsValid {
+		fmt.Fprintf(out, "%s: valid (%s %s, %s)%s\n", r.File, result.SBOMType, result.SBOMVersion, result.DetectedFormat, signed)
+	} else {
+		fmt.Fprintf(out, "%s: invalid (%s %s, %s)%s, %d errors\n", r.File, result.SBOMType, result.SBOMVersion,
+			result.DetectedFormat, signed, len(result.ValidationErrors))
+	}
+	if r.Verdict != nil && !r.Verdict.Passed() {
+		fmt.Fprintf(out, "  %s\n", r.Verdict.Explanation)
+	}
+}
+
+// printFindings prints the findings for one file to stderr, with up to
+// maxErrors errors located by line and column.
+func printFindings(r fileResult, maxErrors int) {
+	result := r.Result
+	if result == nil {
+		return
+	}
+
+	if result.UnknownVersion {
+		fmt.Fprintf(os.Stderr, "%s: warning: %s %s is newer than any known schema; validated best effort against %s\n",
+			r.File, result.SBOMType, result.SBOMVersion, result.SchemaUsed)
+	}
+
+	for i, errMsg := range result.ValidationErrors {
+		if maxErrors > 0 && i >= maxErrors {
+			fmt.Fprintf(os.Stderr, "%s: ...and %d more errors.\n", r.File, len(result.ValidationErrors)-maxErrors)
+			break
+		}
+		if line, col := result.Locate(errMsg); line > 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: error: %s\n", r.File, line, col, errMsg)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: error: %s\n", r.File, errMsg)
+		}
+		if ref, ok := result.SpecReferenceFor(errMsg); ok {
+			fmt.Fprintf(os.Stderr, "  see %s: %s\n", ref.Section, ref.URL)
+		}
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "%s: warning: %s\n", r.File, warning)
+	}
+	for _, info := range result.Info {
+		fmt.Fprintf(os.Stderr, "%s: info: %s\n", r.File, info)
+	}
+	for _, s := range result.Suppressed {
+		fmt.Fprintf(os.Stderr, "%s: suppressed until %s: %s (%s)\n", r.File, s.Suppression.Expires, s.Finding, s.Suppression.Justification)
+	}
+
+	if len(result.NestedBOMs) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: nested BOMs:\n", r.File)
+		printNestedBOMs(result.NestedBOMs, "  ")
+	}
+
+	if stats := result.Licenses; stats != nil {
+		fmt.Fprintf(os.Stderr, "%s: licenses: %d components; %d permissive, %d weak copyleft, %d copyleft, %d other, %d unknown (%.1f%%)\n",
+			r.File, stats.Components, stats.ByCategory[sbomvalidator.LicensePermissive], stats.ByCategory[sbomvalidator.LicenseWeakCopyleft],
+			stats.ByCategory[sbomvalidator.LicenseCopyleft], stats.ByCategory[sbomvalidator.LicenseOther],
+			stats.ByCategory[sbomvalidator.LicenseUnknown], stats.UnknownPercent)
+		for _, note := range stats.Notes {
+			fmt.Fprintf(os.Stderr, "  %s\n", note)
+		}
+	}
+}
+
+// printNestedBOMs prints the tree of nested BOM results to stderr, indenting
+// each level further.
+func printNestedBOMs(nested []sbomvalidator.NestedBOMResult, indent string) {
+	for _, n := range nested {
+		switch {
+		case n.Skipped != "":
+			fmt.Fprintf(os.Stderr, "%s%s: skipped, %s\n", indent, n.URL, n.Skipped)
+		case n.Error != "":
+			fmt.Fprintf(os.Stderr, "%s%s: error: %s\n", indent, n.URL, n.Error)
+		case n.Result.IsValid:
+			fmt.Fprintf(os.Stderr, "%s%s: valid (%s %s)\n", indent, n.URL, n.Result.SBOMType, n.Result.SBOMVersion)
+		default:
+			fmt.Fprintf(os.Stderr, "%s%s: invalid (%s %s), %d errors\n", indent, n.URL, n.Result.SBOMType,
+				n.Result.SBOMVersion, len(n.Result.ValidationErrors))
+		}
+		if n.Result != nil {
+			printNestedBOMs(n.Result.NestedBOMs, indent+"  ")
+		}
+	}
+}
+
+// signaturePolicy loads the trusted keys and roots for signature
+// verification. Keys are named after their file, e.g., release.pem is
+// "release".
+func signaturePolicy(keyPaths, rootsPath, signers string) sbomvalidator.SignaturePolicy {
+	var policy sbomvalidator.SignaturePolicy
+	if keyPaths != "" {
+		policy.TrustedKeys = map[string]crypto.PublicKey{}
+		for _, keyPath := range strings.Split(keyPaths, ",") {
+			data, err := os.ReadFile(keyPath)
+			if err != nil {
+				fatalf("Failed to read trusted key: %v", err)
+			}
+			key, err := sbomvalidator.ParsePublicKeyPEM(data)
+			if err != nil {
+				fatalf("Failed to parse trusted key %s: %v", keyPath, err)
+			}
+			name := strings.TrimSuffix(filepath.Base(keyPath), filepath.Ext(keyPath))
+			policy.TrustedKeys[name] = key
+		}
+	}
+	if rootsPath != "" {
+		data, err := os.ReadFile(rootsPath)
+		if err != nil {
+			fatalf("Failed to read trusted roots: %v", err)
+		}
+		policy.Roots = x509.NewCertPool()
+		if !policy.Roots.AppendCertsFromPEM(data) {
+			fatalf("No certificates found in %s", rootsPath)
+		}
+	}
+	if signers != "" {
+		policy.Identities = strings.Split(signers, ",")
+	}
+	return policy
+}
+
+// detachedSignature adds the detached signature distributed alongside an SBOM
+// to the policy: <sbom>.sigstore.json, as written by the sign command, or
+// <sbom>.sig with its certificate, if any, in <sbom>.pem. Without either, the
+// SBOM must carry an embedded signature.
+func detachedSignature(path string, policy sbomvalidator.SignaturePolicy) sbomvalidator.SignaturePolicy {
+	for _, suffix := range []string{".sigstore.json", ".sig"} {
+		if signature, err := os.ReadFile(path + suffix); err == nil {
+			policy.DetachedSignature = signature
+			break
+		}
+	}
+	if certificate, err := os.ReadFile(path + ".pem"); err == nil {
+		policy.Certificate = certificate
+	}
+	return policy
+}
+
+// fixSBOM applies the fixes of every finding that has one and writes the
+// result, listing the changes made.
+func fixSBOM(jsonData []byte, fixPath string, opts []sbomvalidator.Option) {
+	result, err := sbomvalidator.ValidateSBOMDataStructured(jsonData, opts...)
+	if err != nil {
+		fatalf("Error during validation - %v", err)
+	}
+
+	var fixable []sbomvalidator.Finding
+	for _, f := range result.Findings {
+		if len(f.Fix) > 0 {
+			fixable = append(fixable, f)
+			patch, _ := json.Marshal(f.Fix)
+			fmt.Fprintf(os.Stderr, "fix %s: %s\n", f, patch)
+		}
+	}
+
+	fixed, err := sbomvalidator.ApplyFixes(jsonData, fixable)
+	if err != nil {
+		fatalf("Failed to apply fixes: %v", err)
+	}
+	if err := os.WriteFile(fixPath, fixed, 0o644); err != nil {
+		fatalf("Failed to write fixed SBOM: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Applied %d fixes to %s\n", len(fixable), fixPath)
+}
+
+// openArtifacts opens a directory or zip archive of artifacts to verify
+// declared hashes against.
+func openArtifacts(artifactsPath string) (fs.FS, func()) {
+	if !strings.HasSuffix(strings.ToLower(artifactsPath), ".zip") {
+		return os.DirFS(artifactsPath), func() {}
+	}
+
+	archive, err := zip.OpenReader(artifactsPath)
+	if err != nil {
+		fatalf("Failed to open artifacts archive: %v", err)
+	}
+	return archive, func() { archive.Close() }
+}
+
+// printHashes prints the artifact hash mismatches and reports whether all
+// hashes match.
+func printHashes(hashResult *sbomvalidator.HashVerificationResult) bool {
+	for _, check := range hashResult.Checks {
+		if check.Status != sbomvalidator.HashStatusMatch {
+			fmt.Fprintf(os.Stderr, "- %s (%s): %s %s\n", check.Path, check.Algorithm, check.Status, check.Actual)
+		}
+	}
+
+	if hashResult.IsValid {
+		fmt.Fprintf(os.Stderr, "Artifact hashes verified (%d checks)\n", len(hashResult.Checks))
+	} else {
+		fmt.Fprintln(os.Stderr, "Artifact hash verification failed!")
+	}
+	return hashResult.IsValid
+}
+
+// printProvenance prints the discrepancies between the SBOM and its
+// provenance and reports whether they are consistent.
+func printProvenance(provenanceResult *sbomvalidator.ProvenanceResult) bool {
+	if provenanceResult.IsConsistent {
+		fmt.Fprintf(os.Stderr, "SBOM is consistent with provenance from %s\n", provenanceResult.Builder)
+		return true
+	}
+
+	fmt.Fprintln(os.Stderr, "SBOM does not match provenance:")
+	for _, d := range provenanceResult.Discrepancies {
+		fmt.Fprintf(os.Stderr, "- %s: %s (sbom=%q, provenance=%q)\n", d.Field, d.Message, d.SBOMValue, d.ProvenanceValue)
+	}
+	return false
+}
+
+// newerSchemas serves schemas from a remote provider only for spec versions
+// that are not embedded, so -fetch-schemas does not replace schemas the build
+// already has.
+type newerSchemas struct {
+	remote *sbomvalidator.HTTPSchemaProvider
+}
+
+func (p newerSchemas) Schema(format, version string) (string, []byte, error) {
+	return p.SchemaContext(context.Background(), format, version)
+}
+
+func (p newerSchemas) SchemaContext(ctx context.Context, format, version string) (string, []byte, error) {
+	if _, _, err := sbomvalidator.EmbeddedSchemas().Schema(format, version); err == nil {
+		return "", nil, fmt.Errorf("%s %s is embedded: %w", format, version, fs.ErrNotExist)
+	}
+	return p.remote.SchemaContext(ctx, format, version)
+}
+
+// schemaCacheDir returns the directory fetched schemas are cached in: under
+// -cache-dir if given, otherwise under the user's cache directory. Caching is
+// disabled if neither is available.
+func schemaCacheDir(cacheDir string) string {
+	if cacheDir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return ""
+		}
+		cacheDir = filepath.Join(userCache, "sbom-validator")
+	}
+	return filepath.Join(cacheDir, "schemas")
+}
+
+// licenseLookup returns the registry lookup for -online, cached in cacheDir
+// when set.
+func licenseLookup(cacheDir string) sbomvalidator.LicenseLookup {
+	var lookup sbomvalidator.LicenseLookup = sbomvalidator.NewRegistryLicenseLookup()
+	if cacheDir != "" {
+		cached, err := sbomvalidator.NewCachedLicenseLookup(lookup, cacheDir, 24*time.Hour)
+		if err != nil {
+			fatalf("Failed to open lookup cache: %v", err)
+		}
+		lookup = cached
+	}
+	return lookup
+}
+
+// printLicenses prints the outcome of the license cross-check.
+func printLicenses(licenseResult *sbomvalidator.LicenseCrossCheckResult) {
+	fmt.Fprintf(os.Stderr, "License cross-check: %d checked, %d skipped, %d discrepancies\n",
+		licenseResult.Checked, licenseResult.Skipped, len(licenseResult.Discrepancies))
+	for _, d := range licenseResult.Discrepancies {
+		fmt.Fprintf(os.Stderr, "- %s: declared %v, registry %v\n", d.PURL, d.Declared, d.Registry)
+	}
+}
+
+// commandAnalyzer is a BinaryAnalyzer that runs an external command. The
+// command receives the components as a JSON array on stdin and prints a JSON
+// array of findings ({"path", "message", "severity"}) on stdout.
+type commandAnalyzer struct {
+	command string
+}
+
+func (c commandAnalyzer) Name() string {
+	if fields := strings.Fields(c.command); len(fields) > 0 {
+		return filepath.Base(fields[0])
+	}
+	return c.command
+}
+
+func (c commandAnalyzer) Analyze(components []sbomvalidator.AnalyzedComponent) ([]sbomvalidator.AnalysisFinding, error) {
+	input, err := json.Marshal(components)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("sh", "-c", c.command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []sbomvalidator.AnalysisFinding
+	if err := json.Unmarshal(output, &findings); err != nil {
+		return nil, fmt.Errorf("invalid findings: %v", err)
+	}
+	return findings, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/shiftleftcyber/sbom-validator/vex"
+)
+
+// vexFileResult is the outcome of validating one VEX document, as printed
+// with -output=json.
+type vexFileResult struct {
+	File   string      `json:"file"`
+	Result *vex.Result `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// validateVEX validates CycloneDX VEX and OpenVEX documents, optionally
+// against the SBOM they describe (see package vex).
+func validateVEX(args []string) int {
+	flags := flag.NewFlagSet("vex", flag.ExitOnError)
+	sbomPath := flags.String("sbom", "", "SBOM the VEX documents describe; products must be its components")
+	output := flags.String("output", "text", "Output format: text or json")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fatalf("Usage: %s vex [-sbom=<sbom>] [-output=text|json] <vex>...", programName())
+	}
+
+	var config vex.Config
+	if *sbomPath != "" {
+		data, err := os.ReadFile(*sbomPath)
+		if err != nil {
+			fatalf("Failed to read SBOM: %v", err)
+		}
+		config.SBOM = data
+	}
+
+	exitCode := exitValid
+	results := make([]vexFileResult, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		r := vexFileResult{File: path}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			r.Result, err = vex.Validate(data, config)
+		}
+		switch {
+		case err != nil:
+			r.Error = err.Error()
+			exitCode = exitError
+		case !r.Result.IsValid && exitCode == exitValid:
+			exitCode = exitInvalid
+		}
+		results = append(results, r)
+	}
+
+	if *output == "json" {
+		data, _ := json.MarshalIndent(results, "", " ")
+		fmt.Println(string(data))
+		return exitCode
+	}
+
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Printf("%s: error: %s\n", r.File, r.Error)
+			continue
+		}
+		verdict := "valid"
+		if !r.Result.IsValid {
+			verdict = "invalid"
+		}
+		fmt.Printf("%s: %s %s, %d statements, %s\n", r.File, r.Result.Format, r.Result.Version, r.Result.Statements, verdict)
+		for _, f := range r.Result.Findings {
+			fmt.Printf("  %s: %s\n", f.Level, f)
+		}
+	}
+	return exitCode
+}
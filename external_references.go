@@ -0,0 +1,232 @@
+package sbomvalidator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReferenceCheckPolicy configures the external reference check enabled by
+// `WithReferenceCheck`.
+//
+// By default the check makes no network requests: it only verifies that
+// reference URLs and purls are well-formed. Set `Resolve` to also verify that
+// http and https URLs are reachable.
+type ReferenceCheckPolicy struct {
+	// Resolve sends a request to every distinct http(s) URL and reports those
+	// that fail or answer with an error status.
+	Resolve bool
+	// Client is used for the requests; by default a client with `Timeout`.
+	Client *http.Client
+	// Concurrency is the number of URLs resolved at once (8 by default).
+	Concurrency int
+	// Timeout bounds each request (10 seconds by default). It is ignored when
+	// `Client` is set.
+	Timeout time.Duration
+}
+
+// externalReference is a URL or purl found in an SBOM, with the JSON path of
+// the value.
+type externalReference struct {
+	path  string
+	value string
+	purl  bool
+}
+
+// checkExternalReferences checks the external reference URLs and purls of an
+// SBOM: CycloneDX `externalReferences` (of the BOM, its components and its
+// services) and component purls, and SPDX package download locations,
+// homepages and purl external references.
+//
+// Returns a warning message per malformed or, when the policy resolves URLs,
+// unreachable reference, prefixed with the JSON path of the value. URLs are
+// no longer resolved once ctx is done.
+func checkExternalReferences(ctx context.Context, obj map[string]interface{}, sbomType string, policy ReferenceCheckPolicy) []string {
+	refs := collectExternalReferences(obj, sbomType)
+
+	var warnings []string
+	var resolvable []externalReference
+	for _, ref := range refs {
+		if ref.purl {
+			if _, err := parsePackageURL(ref.value); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: %v", ref.path, err))
+			}
+			continue
+		}
+
+		u, err := url.Parse(ref.value)
+		if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+			warnings = append(warnings, fmt.Sprintf("%s: %q is not a well-formed absolute URL", ref.path, ref.value))
+			continue
+		}
+		if u.Scheme == "http" || u.Scheme == "https" {
+			resolvable = append(resolvable, ref)
+		}
+	}
+
+	if policy.Resolve {
+		unreachable := resolveURLs(ctx, resolvable, policy)
+		for _, ref := range resolvable {
+			if reason, ok := unreachable[ref.value]; ok {
+				warnings = append(warnings, fmt.Sprintf("%s: %s is unreachable: %s", ref.path, ref.value, reason))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// collectExternalReferences returns the URLs and purls an SBOM declares, in
+// document order.
+func collectExternalReferences(obj map[string]interface{}, sbomType string) []externalReference {
+	var refs []externalReference
+
+	addURLs := func(path string, holder map[string]interface{}) {
+		list, _ := holder["externalReferences"].([]interface{})
+		for i, r := range list {
+			ref, _ := r.(map[string]interface{})
+			if u, ok := ref["url"].(string); ok {
+				refs = append(refs, externalReference{path: fmt.Sprintf("%sexternalReferences.%d.url", path, i), value: u})
+			}
+		}
+	}
+
+	if sbomType == SBOM_CYCLONEDX {
+		addURLs("", obj)
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			addURLs(path+".", component)
+			if purl, ok := component["purl"].(string); ok {
+				refs = append(refs, externalReference{path: path + ".purl", value: purl, purl: true})
+			}
+		})
+		walkCycloneDXTools(obj, func(path string, tool map[string]interface{}) {
+			addURLs(path+".", tool)
+			if purl, ok := tool["purl"].(string); ok {
+				refs = append(refs, externalReference{path: path + ".purl", value: purl, purl: true})
+			}
+		})
+		services, _ := obj["services"].([]interface{})
+		for i, s := range services {
+			if service, ok := s.(map[string]interface{}); ok {
+				addURLs(fmt.Sprintf("services.%d.", i), service)
+			}
+		}
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		packages, _ := obj["packages"].([]interface{})
+		for i, p := range packages {
+			pkg, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, field := range []string{"downloadLocation", "homepage"} {
+				// NONE and NOASSERTION are valid values, not references
+				if u, _ := pkg[field].(string); u != "" && u != "NONE" && u != "NOASSERTION" {
+					refs = append(refs, externalReference{path: fmt.Sprintf("packages.%d.%s", i, field), value: u})
+				}
+			}
+			externalRefs, _ := pkg["externalRefs"].([]interface{})
+			for j, r := range externalRefs {
+				ref, _ := r.(map[string]interface{})
+				if refType, _ := ref["referenceType"].(string); refType != "purl" {
+					continue
+				}
+				if purl, ok := ref["referenceLocator"].(string); ok {
+					refs = append(refs, externalReference{
+						path:  fmt.Sprintf("packages.%d.externalRefs.%d.referenceLocator", i, j),
+						value: purl,
+						purl:  true,
+					})
+				}
+			}
+		}
+	}
+
+	return refs
+}
+
+// resolveURLs requests every distinct URL once, with at most
+// policy.Concurrency requests in flight, and returns the reason each
+// unreachable URL failed. No further URLs are requested once ctx is done.
+func resolveURLs(ctx context.Context, refs []externalReference, policy ReferenceCheckPolicy) map[string]string {
+	client := policy.Client
+	if client == nil {
+		timeout := policy.Timeout
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+	workers := policy.Concurrency
+	if workers <= 0 {
+		workers = 8
+	}
+
+	seen := map[string]bool{}
+	jobs := make(chan string)
+	unreachable := map[string]string{}
+	var mu sync.Mutex
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range jobs {
+				if reason := resolveURL(ctx, client, u); reason != "" {
+					mu.Lock()
+					unreachable[u] = reason
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+dispatch:
+	for _, ref := range refs {
+		if seen[ref.value] {
+			continue
+		}
+		seen[ref.value] = true
+		select {
+		case jobs <- ref.value:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return unreachable
+}
+
+// resolveURL returns why a URL is unreachable, or "" if it is reachable. A
+// HEAD request is tried first and a GET if the server does not support HEAD.
+func resolveURL(ctx context.Context, client *http.Client, u string) string {
+	status := 0
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return err.Error()
+		}
+		req.Header.Set("User-Agent", "sbom-validator")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err.Error()
+		}
+		resp.Body.Close()
+
+		status = resp.StatusCode
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	if status >= 400 {
+		return fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckExternalReferences(t *testing.T) {
+	cyclonedx, _ := parseJSON(`{"bomFormat": "CycloneDX",
+  "externalReferences": [{"type": "website", "url": "https://example.com"}],
+  "metadata": {"component": {"name": "app", "purl": "pkg:generic/app@1.0"}},
+  "components": [
+    {"name": "a", "purl": "npm/a@1.0.0",
+     "externalReferences": [{"type": "vcs", "url": "git+https://github.com/acme/a.git"},
+                            {"type": "website", "url": "example.com/a"}]},
+    {"name": "b", "components": [{"name": "c", "externalReferences": [{"type": "other", "url": "ht tp://bad"}]}]}
+  ],
+  "services": [{"name": "api", "externalReferences": [{"type": "documentation", "url": "/docs"}]}]}`)
+
+	spdx, _ := parseJSON(strings.Replace(string(spdxDocument(
+		spdxPackage("a", "a", "MIT"),
+		strings.Replace(spdxPackage("b", "b", "MIT"), `"NOASSERTION"`, `"git+https://github.com/acme/b.git@v1"`, 1),
+		strings.Replace(spdxPackage("c", "c", "MIT"), `"NOASSERTION"`, `"downloads"`, 1),
+	)), "pkg:npm/a@1.0.0", "pkg:/a", 1))
+
+	tests := []struct {
+		name     string
+		obj      map[string]interface{}
+		sbomType string
+		want     []string
+	}{
+		{
+			name:     "CycloneDX",
+			obj:      cyclonedx,
+			sbomType: SBOM_CYCLONEDX,
+			want: []string{
+				`components.0.externalReferences.1.url: "example.com/a" is not a well-formed absolute URL`,
+				"components.0.purl: invalid purl",
+				`components.1.components.0.externalReferences.0.url: "ht tp://bad" is not a well-formed absolute URL`,
+				`services.0.externalReferences.0.url: "/docs" is not a well-formed absolute URL`,
+			},
+		},
+		{
+			name:     "SPDX",
+			obj:      spdx,
+			sbomType: SBOM_SPDX,
+			want: []string{
+				"packages.0.externalRefs.0.referenceLocator: invalid purl",
+				`packages.2.downloadLocation: "downloads" is not a well-formed absolute URL`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkExternalReferences(context.Background(), tt.obj, tt.sbomType, ReferenceCheckPolicy{})
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d warnings, got %d: %v", len(tt.want), len(got), got)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("warning %d = %q, want prefix %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveExternalReferences(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/ok":
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	obj, _ := parseJSON(`{"bomFormat": "CycloneDX", "components": [
+    {"name": "a", "externalReferences": [{"type": "website", "url": "` + server.URL + `/ok"},
+                                         {"type": "website", "url": "` + server.URL + `/get-only"}]},
+    {"name": "b", "externalReferences": [{"type": "website", "url": "` + server.URL + `/gone"}]},
+    {"name": "c", "externalReferences": [{"type": "website", "url": "` + server.URL + `/gone"}]}
+  ]}`)
+
+	// without Resolve no requests are made
+	if got := checkExternalReferences(context.Background(), obj, SBOM_CYCLONEDX, ReferenceCheckPolicy{}); len(got) != 0 {
+		t.Errorf("expected no warnings, got %v", got)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Fatalf("expected no requests without Resolve, got %d", n)
+	}
+
+	got := checkExternalReferences(context.Background(), obj, SBOM_CYCLONEDX, ReferenceCheckPolicy{Resolve: true, Client: server.Client(), Concurrency: 2})
+	want := []string{
+		"components.1.externalReferences.0.url: " + server.URL + "/gone is unreachable: 404 Not Found",
+		"components.2.externalReferences.0.url: " + server.URL + "/gone is unreachable: 404 Not Found",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got warnings %v, want %v", got, want)
+	}
+	// /ok and /gone once each, /get-only twice
+	if n := requests.Load(); n != 4 {
+		t.Errorf("expected 4 requests, got %d", n)
+	}
+}
+
+func TestWithReferenceCheck(t *testing.T) {
+	sbom := spdxDocument(strings.Replace(spdxPackage("a", "a", "MIT"), "pkg:npm/a@1.0.0", "a@1.0.0", 1))
+	// the malformed purl is an identifier-syntax warning too
+	ignoreSyntax := WithValidationOptions(ValidationOptions{IgnoreRules: []string{RuleIdentifierSyntax}})
+
+	result, err := ValidateSBOMDataStructured(sbom, ignoreSyntax)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("expected no warnings without the option, got %v", result.Warnings)
+	}
+
+	result, err = ValidateSBOMDataStructured(sbom, ignoreSyntax, WithReferenceCheck(ReferenceCheckPolicy{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Errorf("expected reference warnings not to invalidate the SBOM")
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "packages.0.externalRefs.0.referenceLocator") {
+		t.Errorf("expected one purl warning, got %v", result.Warnings)
+	}
+}
@@ -0,0 +1,174 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FindingLevel says whether a finding makes an SBOM invalid.
+type FindingLevel string
+
+// Finding levels. Errors make an SBOM invalid; warnings do not, unless
+// `ValidationOptions.FailOnWarnings` is set; info findings never do.
+const (
+	LevelError   FindingLevel = "error"
+	LevelWarning FindingLevel = "warning"
+	LevelInfo    FindingLevel = "info"
+)
+
+// Finding is a single validation error or warning with its machine-usable
+// details.
+type Finding struct {
+	Level FindingLevel `json:"level"`
+	// Severity ranks findings of rules that grade them (e.g., weak
+	// cryptography or binary analysis); it is empty otherwise.
+	Severity Severity `json:"severity,omitempty"`
+	// Rule is the ID of the rule that produced the finding (see
+	// `RuleCatalog`).
+	Rule string `json:"rule"`
+	// Path is the dotted path of the value in error, as used in messages
+	// (e.g., "components.0.purl", or "(root)" for the document).
+	Path string `json:"path,omitempty"`
+	// Pointer is the RFC 6901 JSON pointer of the value (e.g.,
+	// "/components/0/purl", or "" for the document).
+	Pointer string `json:"pointer"`
+	// Keyword is the JSON schema keyword that failed (e.g., "required" or
+	// "enum"), for schema findings.
+	Keyword string `json:"keyword,omitempty"`
+	// Message describes the finding, without the path.
+	Message string `json:"message"`
+	// Fix is a JSON Patch that remediates the finding, for findings with a
+	// deterministic fix. See ApplyFixes.
+	Fix []PatchOperation `json:"fix,omitempty"`
+
+	// render returns Message, for findings whose message is only rendered
+	// once they are known to be reported (see renderMessage).
+	render func() string
+}
+
+// String returns the finding in the form used by `ValidationErrors` and
+// `Warnings`, i.e. "path: message".
+func (f Finding) String() string {
+	message := f.Message
+	if f.render != nil {
+		message = f.render()
+	}
+	if f.Path == "" {
+		return message
+	}
+	return f.Path + ": " + message
+}
+
+// renderMessage sets the message of a finding whose rendering was deferred.
+// Schema validation can produce thousands of errors, most of which MaxErrors
+// drops, so their messages are rendered only for the findings reported.
+func (f *Finding) renderMessage() {
+	if f.render != nil {
+		f.Message = f.render()
+		f.render = nil
+	}
+}
+
+// StructuredResult is the outcome of ValidateSBOMDataStructured. It carries
+// everything in ValidationResult, where errors and warnings are plain
+// strings, plus each of them as a structured Finding.
+type StructuredResult struct {
+	ValidationResult
+	Findings []Finding `json:"findings,omitempty"`
+	// Suppressed are the findings dropped by suppressions, with the
+	// suppression that dropped each.
+	Suppressed []SuppressedFinding `json:"suppressed,omitempty"`
+
+	graph *DependencyGraph
+	// evaluatedRules are the rules validation evaluated, for NewVerdict.
+	evaluatedRules []string
+}
+
+// Graph returns the dependency graph of the SBOM, or nil if validation failed
+// before the SBOM was parsed. See `WithGraphAnalysis` for checks on it.
+func (r *StructuredResult) Graph() *DependencyGraph {
+	return r.graph
+}
+
+// Errors returns the findings that make the SBOM invalid.
+func (r *StructuredResult) Errors() []Finding {
+	return r.findingsAt(LevelError)
+}
+
+// WarningFindings returns the warnings.
+func (r *StructuredResult) WarningFindings() []Finding {
+	return r.findingsAt(LevelWarning)
+}
+
+// InfoFindings returns the informational findings.
+func (r *StructuredResult) InfoFindings() []Finding {
+	return r.findingsAt(LevelInfo)
+}
+
+func (r *StructuredResult) findingsAt(level FindingLevel) []Finding {
+	var findings []Finding
+	for _, f := range r.Findings {
+		if f.Level == level {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// setFindings records the findings on the result, together with their string
+// forms in ValidationErrors, Warnings and Info. The SBOM is valid when no
+// finding is an error.
+func (r *StructuredResult) setFindings(findings []Finding) {
+	r.Findings = findings
+	r.ValidationErrors = nil
+	r.Warnings = nil
+	r.Info = nil
+	for _, f := range findings {
+		switch f.Level {
+		case LevelError:
+			r.ValidationErrors = append(r.ValidationErrors, f.String())
+		case LevelInfo:
+			r.Info = append(r.Info, f.String())
+		default:
+			r.Warnings = append(r.Warnings, f.String())
+		}
+	}
+	r.IsValid = len(r.ValidationErrors) == 0
+}
+
+// messageFindings turns "path: message" strings produced by a rule into
+// findings.
+func messageFindings(level FindingLevel, rule string, messages []string) []Finding {
+	findings := make([]Finding, 0, len(messages))
+	for _, msg := range messages {
+		path, message, ok := strings.Cut(msg, ": ")
+		if !ok {
+			path, message = "", msg
+		}
+		findings = append(findings, Finding{
+			Level:   level,
+			Rule:    rule,
+			Path:    path,
+			Pointer: jsonPointer(path),
+			Message: message,
+		})
+	}
+	return findings
+}
+
+// jsonPointer converts a dotted path (e.g., "components.0.purl") to a JSON
+// pointer ("/components/0/purl"). The document itself, "(root)" or "", is the
+// empty pointer.
+func jsonPointer(path string) string {
+	if path == "" || path == "(root)" {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, segment := range strings.Split(path, ".") {
+		segment = strings.ReplaceAll(segment, "~", "~0")
+		segment = strings.ReplaceAll(segment, "/", "~1")
+		fmt.Fprintf(&b, "/%s", segment)
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: ""},
+		{path: "(root)", want: ""},
+		{path: "components.0.purl", want: "/components/0/purl"},
+		{path: "components.0.properties.a/b~c", want: "/components/0/properties/a~1b~0c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := jsonPointer(tt.path); got != tt.want {
+				t.Errorf("jsonPointer(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindingString(t *testing.T) {
+	tests := []struct {
+		name    string
+		finding Finding
+		want    string
+	}{
+		{name: "With path", finding: Finding{Path: "components.0", Message: "name is required"}, want: "components.0: name is required"},
+		{name: "Without path", finding: Finding{Message: `line 3: unknown tag "Foo"`}, want: `line 3: unknown tag "Foo"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.finding.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSBOMDataStructured(t *testing.T) {
+	var doc map[string]interface{}
+	json.Unmarshal(spdxDocument(`{"SPDXID": "SPDXRef-a", "name": "a", "downloadLocation": "NONE", "primaryPackagePurpose": "GADGET"}`), &doc)
+	delete(doc, "documentNamespace")
+	sbom, _ := json.Marshal(doc)
+
+	result, err := ValidateSBOMDataStructured(sbom)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Fatalf("Expected the SBOM to be invalid")
+	}
+
+	want := map[string]Finding{
+		"required": {Level: LevelError, Rule: RuleSchema, Path: "(root)", Pointer: "", Keyword: "required",
+			Message: "documentNamespace is required"},
+		"enum": {Level: LevelError, Rule: RuleSchema, Path: "packages.0.primaryPackagePurpose",
+			Pointer: "/packages/0/primaryPackagePurpose", Keyword: "enum"},
+	}
+	for _, f := range result.Errors() {
+		expected, ok := want[f.Keyword]
+		if !ok {
+			continue
+		}
+		if expected.Message == "" {
+			expected.Message = f.Message
+		}
+		if !reflect.DeepEqual(f, expected) {
+			t.Errorf("Finding = %+v, want %+v", f, expected)
+		}
+		delete(want, f.Keyword)
+	}
+	if len(want) > 0 {
+		t.Errorf("Missing findings %v in %+v", want, result.Findings)
+	}
+
+	// the legacy result carries the same errors as strings
+	legacy, err := ValidateSBOMData(sbom)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var strs []string
+	for _, f := range result.Errors() {
+		strs = append(strs, f.String())
+	}
+	if !reflect.DeepEqual(legacy.ValidationErrors, strs) {
+		t.Errorf("ValidationErrors = %v, want %v", legacy.ValidationErrors, strs)
+	}
+}
+
+func TestValidateSBOMDataStructuredValid(t *testing.T) {
+	result, err := ValidateSBOMDataStructured(spdxDocument(spdxPackage("a", "a", "MIT")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.IsValid || len(result.Findings) != 0 || result.ValidationErrors != nil {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestValidateSBOMDataStructuredTagValueSyntax(t *testing.T) {
+	result, err := ValidateSBOMDataStructured([]byte(tagValueHeader + "Colour: blue\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	errors := result.Errors()
+	if len(errors) != 1 || errors[0].Rule != RuleDocument || errors[0].Path != "" ||
+		errors[0].Message != `line 8: unknown tag "Colour"` {
+		t.Errorf("Unexpected findings: %+v", errors)
+	}
+}
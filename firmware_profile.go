@@ -0,0 +1,158 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AnalyzedComponent is the view of a component handed to a BinaryAnalyzer.
+type AnalyzedComponent struct {
+	// Path is the JSON path of the component, e.g. "components.3".
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+	// Hashes maps upper-case algorithm names without dashes (e.g., "SHA256")
+	// to lower-case hex digests.
+	Hashes map[string]string `json:"hashes,omitempty"`
+}
+
+// AnalysisFinding is a finding contributed by a BinaryAnalyzer.
+//
+// Findings of critical or high severity are merged into the validation errors
+// and make the SBOM invalid; low severity ones are reported as info findings
+// and others are merged into the warnings.
+type AnalysisFinding struct {
+	// Path is the JSON path of the component the finding is about, or "" for
+	// the document.
+	Path     string   `json:"path,omitempty"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+}
+
+// BinaryAnalyzer is implemented by external binary analysis tools that compare
+// an SBOM with the firmware image it describes, e.g. to report components
+// that are claimed in the SBOM but not present in the image.
+type BinaryAnalyzer interface {
+	// Name identifies the analyzer in merged findings.
+	Name() string
+	// Analyze inspects the components declared in the SBOM.
+	Analyze(components []AnalyzedComponent) ([]AnalysisFinding, error)
+}
+
+// checkFirmwareProfile applies the firmware profile to a parsed SBOM: every
+// component (CycloneDX, including metadata.component) or package (SPDX) must
+// declare at least one hash, since binary analysis matches components by
+// digest.
+//
+// Returns an error message per component without hashes, prefixed with its
+// JSON path.
+func checkFirmwareProfile(obj map[string]interface{}, sbomType string) []string {
+	var errors []string
+	for _, component := range analyzedComponents(obj, sbomType) {
+		if len(component.Hashes) == 0 {
+			errors = append(errors, fmt.Sprintf("%s: firmware component %q has no hashes", component.Path, component.Name))
+		}
+	}
+	return errors
+}
+
+// runBinaryAnalyzers runs each analyzer over the SBOM's components and merges
+// their findings: critical and high severity ones as validation errors, low
+// severity ones as info findings and others as warnings.
+func runBinaryAnalyzers(obj map[string]interface{}, sbomType string, analyzers []BinaryAnalyzer) ([]Finding, error) {
+	var merged []Finding
+
+	components := analyzedComponents(obj, sbomType)
+	for _, analyzer := range analyzers {
+		findings, err := analyzer.Analyze(components)
+		if err != nil {
+			return nil, fmt.Errorf("binary analyzer %s failed: %v", analyzer.Name(), err)
+		}
+
+		for _, finding := range findings {
+			path := finding.Path
+			if path == "" {
+				path = "(root)"
+			}
+
+			level := LevelWarning
+			switch finding.Severity {
+			case SeverityCritical, SeverityHigh:
+				level = LevelError
+			case SeverityLow:
+				level = LevelInfo
+			}
+			merged = append(merged, Finding{
+				Level:    level,
+				Severity: finding.Severity,
+				Rule:     RuleBinaryAnalysis,
+				Path:     path,
+				Pointer:  jsonPointer(path),
+				Message:  fmt.Sprintf("%s (reported by %s)", finding.Message, analyzer.Name()),
+			})
+		}
+	}
+
+	return merged, nil
+}
+
+// analyzedComponents returns every component of a parsed SBOM with its path
+// and hashes.
+func analyzedComponents(obj map[string]interface{}, sbomType string) []AnalyzedComponent {
+	var components []AnalyzedComponent
+
+	if sbomType == SBOM_CYCLONEDX {
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			c := AnalyzedComponent{Path: path, Hashes: map[string]string{}}
+			c.Name, _ = component["name"].(string)
+			c.Version, _ = component["version"].(string)
+			c.PURL, _ = component["purl"].(string)
+
+			hashes, _ := component["hashes"].([]interface{})
+			for _, h := range hashes {
+				entry, _ := h.(map[string]interface{})
+				alg, _ := entry["alg"].(string)
+				content, _ := entry["content"].(string)
+				if alg != "" && content != "" {
+					c.Hashes[normalizeHashAlgorithm(alg)] = strings.ToLower(content)
+				}
+			}
+			components = append(components, c)
+		})
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		packages, _ := obj["packages"].([]interface{})
+		for i, p := range packages {
+			pkg, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			c := AnalyzedComponent{Path: fmt.Sprintf("packages.%d", i), Hashes: map[string]string{}}
+			c.Name, _ = pkg["name"].(string)
+			c.Version, _ = pkg["versionInfo"].(string)
+
+			refs, _ := pkg["externalRefs"].([]interface{})
+			for _, r := range refs {
+				ref, _ := r.(map[string]interface{})
+				if refType, _ := ref["referenceType"].(string); refType == "purl" {
+					c.PURL, _ = ref["referenceLocator"].(string)
+					break
+				}
+			}
+
+			checksums, _ := pkg["checksums"].([]interface{})
+			for _, cs := range checksums {
+				entry, _ := cs.(map[string]interface{})
+				alg, _ := entry["algorithm"].(string)
+				value, _ := entry["checksumValue"].(string)
+				if alg != "" && value != "" {
+					c.Hashes[normalizeHashAlgorithm(alg)] = strings.ToLower(value)
+				}
+			}
+			components = append(components, c)
+		}
+	}
+
+	return components
+}
@@ -0,0 +1,130 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeBinaryAnalyzer struct {
+	findings   []AnalysisFinding
+	err        error
+	components []AnalyzedComponent
+}
+
+func (f *fakeBinaryAnalyzer) Name() string { return "fake-analyzer" }
+
+func (f *fakeBinaryAnalyzer) Analyze(components []AnalyzedComponent) ([]AnalysisFinding, error) {
+	f.components = components
+	return f.findings, f.err
+}
+
+func TestCheckFirmwareProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		sbom     string
+		sbomType string
+		want     []string
+	}{
+		{
+			name: "CycloneDX component without hashes",
+			sbom: `{"bomFormat": "CycloneDX",
+				"metadata": {"component": {"name": "image", "hashes": [{"alg": "SHA-256", "content": "AB"}]}},
+				"components": [{"name": "busybox"}, {"name": "openssl", "hashes": [{"alg": "SHA-1", "content": "cd"}]}]}`,
+			sbomType: SBOM_CYCLONEDX,
+			want:     []string{`components.0: firmware component "busybox" has no hashes`},
+		},
+		{
+			name: "SPDX package without checksums",
+			sbom: `{"spdxVersion": "SPDX-2.3", "packages": [
+				{"name": "busybox", "checksums": [{"algorithm": "SHA256", "checksumValue": "ab"}]},
+				{"name": "openssl"}]}`,
+			sbomType: "SPDX-2.3",
+			want:     []string{`packages.1: firmware component "openssl" has no hashes`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(tt.sbom)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := checkFirmwareProfile(obj, tt.sbomType)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("checkFirmwareProfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBinaryAnalyzers(t *testing.T) {
+	obj, _ := parseJSON(`{"bomFormat": "CycloneDX", "components": [
+		{"name": "busybox", "version": "1.36.1", "purl": "pkg:generic/busybox@1.36.1",
+		 "hashes": [{"alg": "SHA-256", "content": "ABCD"}]}]}`)
+
+	analyzer := &fakeBinaryAnalyzer{findings: []AnalysisFinding{
+		{Path: "components.0", Message: "component claimed but not present in image", Severity: SeverityHigh},
+		{Message: "image contains 3 unlisted binaries", Severity: SeverityMedium},
+		{Path: "components.0", Message: "binary is stripped", Severity: SeverityLow},
+	}}
+
+	findings, err := runBinaryAnalyzers(obj, SBOM_CYCLONEDX, []BinaryAnalyzer{analyzer})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var errs, warnings, info []string
+	for _, f := range findings {
+		if f.Rule != RuleBinaryAnalysis {
+			t.Errorf("unexpected rule %q", f.Rule)
+		}
+		switch f.Level {
+		case LevelError:
+			errs = append(errs, f.String())
+		case LevelInfo:
+			info = append(info, f.String())
+		default:
+			warnings = append(warnings, f.String())
+		}
+	}
+
+	if len(analyzer.components) != 1 || analyzer.components[0].Hashes["SHA256"] != "abcd" || analyzer.components[0].Version != "1.36.1" {
+		t.Errorf("unexpected components passed to analyzer: %+v", analyzer.components)
+	}
+	if len(errs) != 1 || errs[0] != "components.0: component claimed but not present in image (reported by fake-analyzer)" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "(root): image contains") {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+	if len(info) != 1 || !strings.HasPrefix(info[0], "components.0: binary is stripped") {
+		t.Errorf("unexpected info findings %v", info)
+	}
+
+	failing := &fakeBinaryAnalyzer{err: errors.New("image not found")}
+	if _, err := runBinaryAnalyzers(obj, SBOM_CYCLONEDX, []BinaryAnalyzer{failing}); err == nil {
+		t.Errorf("expected an error from a failing analyzer")
+	}
+}
+
+func TestWithFirmwareProfile(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "busybox", "GPL-2.0-only"))
+	analyzer := &fakeBinaryAnalyzer{findings: []AnalysisFinding{
+		{Path: "packages.0", Message: "component claimed but not present in image", Severity: SeverityCritical},
+	}}
+
+	result, err := ValidateSBOMData(sbom, WithProfiles(ProfileFirmware), WithBinaryAnalyzers(analyzer))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Errorf("expected the SBOM to be invalid")
+	}
+	if len(result.ValidationErrors) != 2 {
+		t.Errorf("expected a missing hash and an analyzer error, got %v", result.ValidationErrors)
+	}
+}
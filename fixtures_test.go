@@ -0,0 +1,53 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// This file holds the fixtures shared by tests of several formats, so that
+// it builds whichever formats are compiled in.
+
+// spdxDocument builds a minimal valid SPDX 2.3 document from package JSON.
+func spdxDocument(packages ...string) []byte {
+	return []byte(fmt.Sprintf(`{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "test",
+  "documentNamespace": "https://example.com/test",
+  "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: test"]},
+  "packages": [%s]
+}`, strings.Join(packages, ",")))
+}
+
+func spdxPackage(id, name, license string) string {
+	return fmt.Sprintf(`{"SPDXID": "SPDXRef-%s", "name": %q, "versionInfo": "1.0.0",
+  "downloadLocation": "NOASSERTION", "licenseDeclared": %q,
+  "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl",
+    "referenceLocator": "pkg:npm/%s@1.0.0"}]}`, id, name, license, name)
+}
+
+func gzipped(t *testing.T, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(content); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+type fakeLicenseLookup map[string][]string
+
+func (f fakeLicenseLookup) Licenses(purl string) ([]string, error) {
+	licenses, ok := f[purl]
+	if !ok {
+		return nil, ErrUnsupportedRegistry
+	}
+	return licenses, nil
+}
@@ -0,0 +1,100 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// formatSchemas maps each SBOM format compiled into the binary to its embedded
+// schemas. Formats register themselves from files guarded by build tags, so an
+// embedder can leave out formats it does not need:
+//
+//	go build -tags sbomvalidator_no_spdx ./...
+//
+// leaves out SPDX support: its schemas and the converters of its non-JSON
+// encodings (see encodingConverters). The tag for a format is
+// "sbomvalidator_no_" followed by its lower-cased name.
+var formatSchemas = map[string]fs.FS{}
+
+// schemaFS reads embedded schema files across all registered formats.
+var schemaFS embeddedSchemas
+
+type embeddedSchemas struct{}
+
+// ReadFile reads an embedded schema by its path, e.g.
+// "schemas/cyclonedx/bom-1.6.schema.json".
+func (embeddedSchemas) ReadFile(name string) ([]byte, error) {
+	for _, schemas := range formatSchemas {
+		if data, err := fs.ReadFile(schemas, name); err == nil {
+			return data, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir lists an embedded schema directory, e.g. "schemas/spdx".
+func (embeddedSchemas) ReadDir(name string) ([]fs.DirEntry, error) {
+	for _, schemas := range formatSchemas {
+		if entries, err := fs.ReadDir(schemas, name); err == nil {
+			return entries, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// SupportedFormats returns the SBOM formats compiled into this build, e.g.
+// ["CycloneDX", "SPDX"].
+func SupportedFormats() []string {
+	formats := make([]string, 0, len(formatSchemas))
+	for format := range formatSchemas {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// sbomFormat returns the format name for a detected SBOM type, folding
+// versioned SPDX types (e.g., "SPDX-2.3") into "SPDX".
+func sbomFormat(sbomType string) string {
+	if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		return SBOM_SPDX
+	}
+	return sbomType
+}
+
+// knownFormats are the SBOM formats the module supports, whether or not they
+// are compiled into this build.
+var knownFormats = []string{SBOM_CYCLONEDX, SBOM_SPDX}
+
+// errFormatNotCompiled is the error for an SBOM of a known format left out of
+// the build by its build tag.
+func errFormatNotCompiled(format string) error {
+	return fmt.Errorf("%s support is not compiled in (built with the sbomvalidator_no_%s tag)", format, strings.ToLower(format))
+}
+
+// checkFormatEnabled returns an error if the format of sbomType is not one
+// the module supports, is not compiled in, or is not in enabled (when
+// enabled is non-empty).
+func checkFormatEnabled(sbomType string, enabled []string) error {
+	format := sbomFormat(sbomType)
+
+	if !slices.Contains(knownFormats, format) {
+		return fmt.Errorf("unsupported SBOM format %q", format)
+	}
+	if _, ok := formatSchemas[format]; !ok {
+		return errFormatNotCompiled(format)
+	}
+
+	if len(enabled) == 0 {
+		return nil
+	}
+	for _, f := range enabled {
+		if strings.EqualFold(f, format) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not enabled; enabled formats: %s", format, strings.Join(enabled, ", "))
+}
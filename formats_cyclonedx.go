@@ -0,0 +1,20 @@
+//go:build !sbomvalidator_no_cyclonedx
+
+package sbomvalidator
+
+import "embed"
+
+//go:embed schemas/cyclonedx
+var cycloneDXSchemaFS embed.FS
+
+func init() {
+	formatSchemas[SBOM_CYCLONEDX] = cycloneDXSchemaFS
+	encodingConverters[encodingXML] = func(data []byte) ([]byte, []string, []string, error) {
+		jsonContent, err := cycloneDXXMLToJSON(data)
+		return jsonContent, nil, nil, err
+	}
+	encodingConverters[encodingProtobuf] = func(data []byte) ([]byte, []string, []string, error) {
+		jsonContent, warnings, err := cycloneDXProtobufToJSON(data)
+		return jsonContent, nil, warnings, err
+	}
+}
@@ -0,0 +1,45 @@
+//go:build sbomvalidator_no_cyclonedx || sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidateExcludedFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		sbom   []byte
+	}{
+		{name: "CycloneDX JSON", format: SBOM_CYCLONEDX, sbom: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6"}`)},
+		{name: "CycloneDX XML", format: SBOM_CYCLONEDX, sbom: []byte(`<bom xmlns="http://cyclonedx.org/schema/bom/1.6" version="1"/>`)},
+		{name: "CycloneDX protobuf", format: SBOM_CYCLONEDX, sbom: protoBOM()},
+		{name: "SPDX JSON", format: SBOM_SPDX, sbom: spdxDocument(spdxPackage("a", "a", "MIT"))},
+		{name: "SPDX tag-value", format: SBOM_SPDX, sbom: []byte(tagValueHeader)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateSBOMData(tt.sbom)
+			notCompiled := errFormatNotCompiled(tt.format).Error()
+			if slices.Contains(SupportedFormats(), tt.format) {
+				if err != nil && err.Error() == notCompiled {
+					t.Errorf("Unexpected error for a compiled in format: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != notCompiled {
+				t.Errorf("ValidateSBOMData() error = %v, want %q", err, notCompiled)
+			}
+		})
+	}
+}
+
+func TestValidateUnsupportedFormatExcludedBuild(t *testing.T) {
+	_, err := ValidateSBOMData([]byte(`{"bomFormat": "SWID", "specVersion": "1.0"}`))
+	if err == nil || err.Error() != `unsupported SBOM format "SWID"` {
+		t.Errorf(`expected an unsupported SBOM format "SWID" error, got %v`, err)
+	}
+}
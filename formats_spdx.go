@@ -0,0 +1,16 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import "embed"
+
+//go:embed schemas/spdx
+var spdxSchemaFS embed.FS
+
+func init() {
+	formatSchemas[SBOM_SPDX] = spdxSchemaFS
+	encodingConverters[encodingTagValue] = func(data []byte) ([]byte, []string, []string, error) {
+		jsonContent, syntaxErrors, err := spdxTagValueToJSON(data)
+		return jsonContent, syntaxErrors, nil, err
+	}
+}
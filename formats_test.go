@@ -0,0 +1,70 @@
+//go:build !sbomvalidator_no_cyclonedx && !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSupportedFormats(t *testing.T) {
+	formats := SupportedFormats()
+	if strings.Join(formats, ",") != "CycloneDX,SPDX" {
+		t.Errorf("SupportedFormats() = %v, want [CycloneDX SPDX]", formats)
+	}
+}
+
+func TestWithFormats(t *testing.T) {
+	sbom := []byte(`{"spdxVersion": "SPDX-2.3"}`)
+
+	tests := []struct {
+		name    string
+		formats []string
+		wantErr bool
+	}{
+		{name: "All formats by default"},
+		{name: "Format enabled", formats: []string{SBOM_SPDX}},
+		{name: "Format enabled case-insensitively", formats: []string{"spdx"}},
+		{name: "Format not enabled", formats: []string{SBOM_CYCLONEDX}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateSBOMData(sbom, WithFormats(tt.formats...))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Unexpected error state: got %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckFormatEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		sbomType string
+		enabled  []string
+		wantErr  string
+	}{
+		{name: "Compiled in", sbomType: SBOM_CYCLONEDX},
+		{name: "Versioned SPDX type", sbomType: "SPDX-2.3", enabled: []string{SBOM_SPDX}},
+		{name: "Not enabled", sbomType: SBOM_SPDX, enabled: []string{SBOM_CYCLONEDX}, wantErr: "SPDX is not enabled; enabled formats: CycloneDX"},
+		{name: "Unsupported format", sbomType: "SWID", wantErr: `unsupported SBOM format "SWID"`},
+		{name: "Unsupported format even if enabled", sbomType: "SWID", enabled: []string{"SWID"}, wantErr: `unsupported SBOM format "SWID"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFormatEnabled(tt.sbomType, tt.enabled)
+			if err == nil && tt.wantErr != "" || err != nil && err.Error() != tt.wantErr {
+				t.Errorf("checkFormatEnabled() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUnsupportedFormat(t *testing.T) {
+	_, err := ValidateSBOMData([]byte(`{"bomFormat": "SWID", "specVersion": "1.0"}`))
+	if err == nil || err.Error() != `unsupported SBOM format "SWID"` || strings.Contains(err.Error(), "compiled") {
+		t.Errorf(`expected an unsupported SBOM format "SWID" error, got %v`, err)
+	}
+}
@@ -0,0 +1,182 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// HashCheckPolicy configures the hash integrity check enabled by
+// `WithHashChecks`.
+type HashCheckPolicy struct {
+	// Artifacts, when set, holds the artifacts the SBOM describes, as a
+	// directory (`os.DirFS`) or an archive (e.g., `*zip.Reader`). The files
+	// it declares hashes for are hashed and compared, as by
+	// VerifyArtifactHashes. Without it only the declared hashes themselves
+	// are checked.
+	Artifacts fs.FS
+}
+
+// cycloneDXHashAlgorithms and spdxChecksumAlgorithms are the algorithm names
+// each format defines.
+var (
+	cycloneDXHashAlgorithms = []string{
+		"MD5", "SHA-1", "SHA-256", "SHA-384", "SHA-512", "SHA3-256", "SHA3-384", "SHA3-512",
+		"BLAKE2b-256", "BLAKE2b-384", "BLAKE2b-512", "BLAKE3", "Streebog-256", "Streebog-512",
+	}
+	spdxChecksumAlgorithms = []string{
+		"SHA1", "SHA224", "SHA256", "SHA384", "SHA512", "SHA3-256", "SHA3-384", "SHA3-512",
+		"BLAKE2b-256", "BLAKE2b-384", "BLAKE2b-512", "BLAKE3", "MD2", "MD4", "MD5", "MD6", "ADLER32",
+	}
+)
+
+// hashDigestLengths maps normalized algorithm names to the number of hex
+// digits of their digests. Algorithms with a variable output length (BLAKE3,
+// MD6) are missing, so any length is accepted for them.
+var hashDigestLengths = map[string]int{
+	"MD2": 32, "MD4": 32, "MD5": 32, "ADLER32": 8,
+	"SHA1": 40, "SHA224": 56, "SHA256": 64, "SHA384": 96, "SHA512": 128,
+	"SHA3256": 64, "SHA3384": 96, "SHA3512": 128,
+	"BLAKE2B256": 64, "BLAKE2B384": 96, "BLAKE2B512": 128,
+	"STREEBOG256": 64, "STREEBOG512": 128,
+}
+
+var hexDigest = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// declaredHash is a hash declared in an SBOM, with the JSON paths of its
+// algorithm and digest.
+type declaredHash struct {
+	algorithm, digest         string
+	algorithmPath, digestPath string
+}
+
+// checkHashes checks that every hash declared in an SBOM names an algorithm
+// of its format and carries a hexadecimal digest of the length the algorithm
+// produces and, when the policy sets Artifacts, that the artifacts match.
+func checkHashes(doc *sbomDocument, policy HashCheckPolicy) ([]Finding, error) {
+	var hashes []declaredHash
+	algorithms := cycloneDXHashAlgorithms
+	if doc.sbomType == SBOM_CYCLONEDX {
+		hashes = cycloneDXDeclaredHashes(doc.obj)
+	} else if strings.HasPrefix(doc.sbomType, SBOM_SPDX) {
+		hashes = spdxDeclaredHashes(doc.obj)
+		algorithms = spdxChecksumAlgorithms
+	} else {
+		return nil, nil
+	}
+
+	var errors []string
+	for _, h := range hashes {
+		if !slices.Contains(algorithms, h.algorithm) {
+			message := fmt.Sprintf("%s: %s does not define hash algorithm %q", h.algorithmPath, sbomFormat(doc.sbomType), h.algorithm)
+			for _, alg := range algorithms {
+				if normalizeHashAlgorithm(alg) == normalizeHashAlgorithm(h.algorithm) {
+					message += fmt.Sprintf("; use %q", alg)
+				}
+			}
+			errors = append(errors, message)
+		}
+
+		switch want, fixed := hashDigestLengths[normalizeHashAlgorithm(h.algorithm)]; {
+		case !hexDigest.MatchString(h.digest):
+			errors = append(errors, fmt.Sprintf("%s: digest %q is not hexadecimal", h.digestPath, h.digest))
+		case fixed && len(h.digest) != want:
+			errors = append(errors, fmt.Sprintf("%s: %s digest has %d hex digits; %s digests have %d",
+				h.digestPath, h.algorithm, len(h.digest), h.algorithm, want))
+		}
+	}
+	findings := messageFindings(LevelError, RuleHashFormat, errors)
+
+	if policy.Artifacts == nil {
+		return findings, nil
+	}
+	result, err := verifyArtifactHashes(doc, policy.Artifacts)
+	if err != nil {
+		return nil, fmt.Errorf("hash verification failed: %v", err)
+	}
+	for _, check := range result.Checks {
+		var level FindingLevel
+		var message string
+		switch check.Status {
+		case HashStatusMismatch:
+			level, message = LevelError, fmt.Sprintf("%s digest of %s is %s, but the SBOM declares %s", check.Algorithm, check.Path, check.Actual, check.Expected)
+		case HashStatusMissing:
+			level, message = LevelWarning, fmt.Sprintf("artifact %s was not found, so its %s digest was not verified", check.Path, check.Algorithm)
+		case HashStatusUnsupported:
+			level, message = LevelInfo, fmt.Sprintf("%s digests cannot be verified, so %s was not checked against it", check.Algorithm, check.Path)
+		default:
+			continue
+		}
+		findings = append(findings, messageFindings(level, RuleArtifactHash, []string{check.location + ": " + message})...)
+	}
+	return findings, nil
+}
+
+// cycloneDXDeclaredHashes returns the hashes of every component and of the
+// external references of the BOM and its components.
+func cycloneDXDeclaredHashes(obj map[string]interface{}) []declaredHash {
+	var hashes []declaredHash
+	collect := func(path string, element map[string]interface{}) {
+		list, _ := element["hashes"].([]interface{})
+		for i, h := range list {
+			entry, ok := h.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			alg, _ := entry["alg"].(string)
+			content, _ := entry["content"].(string)
+			hashPath := fmt.Sprintf("%s.hashes.%d", path, i)
+			hashes = append(hashes, declaredHash{algorithm: alg, digest: content, algorithmPath: hashPath + ".alg", digestPath: hashPath + ".content"})
+		}
+	}
+	collectReferences := func(path string, element map[string]interface{}) {
+		refs, _ := element["externalReferences"].([]interface{})
+		for i, r := range refs {
+			if ref, ok := r.(map[string]interface{}); ok {
+				collect(fmt.Sprintf("%s.externalReferences.%d", path, i), ref)
+			}
+		}
+	}
+
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		collect(path, component)
+		collectReferences(path, component)
+	})
+	walkCycloneDXTools(obj, func(path string, tool map[string]interface{}) {
+		collect(path, tool)
+		collectReferences(path, tool)
+	})
+	refs, _ := obj["externalReferences"].([]interface{})
+	for i, r := range refs {
+		if ref, ok := r.(map[string]interface{}); ok {
+			collect(fmt.Sprintf("externalReferences.%d", i), ref)
+		}
+	}
+	return hashes
+}
+
+// spdxDeclaredHashes returns the checksums of every package and file.
+func spdxDeclaredHashes(obj map[string]interface{}) []declaredHash {
+	var hashes []declaredHash
+	for _, field := range []string{"packages", "files"} {
+		elements, _ := obj[field].([]interface{})
+		for i, e := range elements {
+			element, _ := e.(map[string]interface{})
+			list, _ := element["checksums"].([]interface{})
+			for j, c := range list {
+				entry, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				alg, _ := entry["algorithm"].(string)
+				value, _ := entry["checksumValue"].(string)
+				checksumPath := fmt.Sprintf("%s.%d.checksums.%d", field, i, j)
+				hashes = append(hashes, declaredHash{algorithm: alg, digest: value,
+					algorithmPath: checksumPath + ".algorithm", digestPath: checksumPath + ".checksumValue"})
+			}
+		}
+	}
+	return hashes
+}
@@ -0,0 +1,125 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCheckHashes(t *testing.T) {
+	// sha256("hello")
+	const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	tests := []struct {
+		name  string
+		sbom  string
+		want  []string
+		rules []string
+	}{
+		{
+			name: "CycloneDX valid hashes",
+			sbom: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+				{"name": "a", "hashes": [{"alg": "SHA-256", "content": "` + helloSHA256 + `"}, {"alg": "BLAKE3", "content": "abcd"}]}
+			]}`,
+		},
+		{
+			name: "CycloneDX SPDX-style algorithm name",
+			sbom: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+				{"name": "a", "hashes": [{"alg": "SHA256", "content": "` + helloSHA256 + `"}]}
+			]}`,
+			want:  []string{`components.0.hashes.0.alg: CycloneDX does not define hash algorithm "SHA256"; use "SHA-256"`},
+			rules: []string{RuleHashFormat},
+		},
+		{
+			name: "CycloneDX digest length and hex",
+			sbom: `{"bomFormat": "CycloneDX", "specVersion": "1.6",
+				"metadata": {"component": {"name": "app", "hashes": [{"alg": "SHA-1", "content": "` + helloSHA256 + `"}]}},
+				"components": [{"name": "a", "externalReferences": [{"type": "distribution", "url": "https://example.com/a.tgz",
+					"hashes": [{"alg": "MD5", "content": "not-a-digest"}]}]}]}`,
+			want: []string{
+				"metadata.component.hashes.0.content: SHA-1 digest has 64 hex digits; SHA-1 digests have 40",
+				`components.0.externalReferences.0.hashes.0.content: digest "not-a-digest" is not hexadecimal`,
+			},
+			rules: []string{RuleHashFormat, RuleHashFormat},
+		},
+		{
+			name: "SPDX checksums",
+			sbom: `{"spdxVersion": "SPDX-2.3", "packages": [
+				{"name": "a", "checksums": [{"algorithm": "SHA-256", "checksumValue": "` + helloSHA256 + `"}]}
+			], "files": [
+				{"fileName": "./a", "checksums": [{"algorithm": "SHA1", "checksumValue": "aaf4"}]}
+			]}`,
+			want: []string{
+				`packages.0.checksums.0.algorithm: SPDX does not define hash algorithm "SHA-256"; use "SHA256"`,
+				"files.0.checksums.0.checksumValue: SHA1 digest has 4 hex digits; SHA1 digests have 40",
+			},
+			rules: []string{RuleHashFormat, RuleHashFormat},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseSBOMDocument([]byte(tt.sbom))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			findings, err := checkHashes(doc, HashCheckPolicy{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got, rules []string
+			for _, f := range findings {
+				got = append(got, f.String())
+				rules = append(rules, f.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("findings = %q (%q), want %q (%q)", got, rules, tt.want, tt.rules)
+			}
+		})
+	}
+}
+
+func TestWithHashChecks(t *testing.T) {
+	artifacts := fstest.MapFS{
+		"bin/app": &fstest.MapFile{Data: []byte("hello")},
+	}
+	sbom := spdxDocument(
+		`{"SPDXID": "SPDXRef-app", "name": "app", "packageFileName": "bin/app", "downloadLocation": "NOASSERTION",
+			"checksums": [{"algorithm": "SHA256", "checksumValue": "0000000000000000000000000000000000000000000000000000000000000000"}]}`,
+		`{"SPDXID": "SPDXRef-lib", "name": "lib", "packageFileName": "lib/lib.so", "downloadLocation": "NOASSERTION",
+			"checksums": [{"algorithm": "SHA1", "checksumValue": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}]}`,
+	)
+
+	result, err := ValidateSBOMDataStructured(sbom, WithHashChecks(HashCheckPolicy{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Errorf("well-formed checksums are invalid without artifacts: %v", result.ValidationErrors)
+	}
+
+	result, err = ValidateSBOMDataStructured(sbom, WithHashChecks(HashCheckPolicy{Artifacts: artifacts}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Error("an SBOM whose artifact does not match its checksum is valid")
+	}
+	var got []string
+	for _, f := range result.Findings {
+		if f.Rule == RuleArtifactHash {
+			got = append(got, string(f.Level)+" "+f.Path)
+		}
+	}
+	want := []string{"error packages.0", "warning packages.1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("artifact findings = %q, want %q", got, want)
+	}
+	if !strings.Contains(result.ValidationErrors[0], "2cf24dba") {
+		t.Errorf("mismatch error does not report the actual digest: %q", result.ValidationErrors[0])
+	}
+}
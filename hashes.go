@@ -0,0 +1,356 @@
+package sbomvalidator
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HashStatus describes the outcome of checking a single declared hash.
+type HashStatus string
+
+const (
+	HashStatusMatch       HashStatus = "match"
+	HashStatusMismatch    HashStatus = "mismatch"
+	HashStatusMissing     HashStatus = "missing"
+	HashStatusUnsupported HashStatus = "unsupported"
+)
+
+// HashCheck is the result of comparing one hash declared in an SBOM with the
+// hash computed from the corresponding artifact.
+type HashCheck struct {
+	Component string     `json:"component"`
+	Path      string     `json:"path"`
+	Algorithm string     `json:"algorithm"`
+	Expected  string     `json:"expected"`
+	Actual    string     `json:"actual,omitempty"`
+	Status    HashStatus `json:"status"`
+
+	// location is the dotted JSON path of the element declaring the hash
+	location string
+}
+
+// HashVerificationResult represents the outcome of verifying the hashes
+// declared in an SBOM against the artifacts it describes.
+//
+// `IsValid` is false when at least one declared hash does not match the
+// artifact. Artifacts that cannot be found and algorithms that are not
+// supported are reported in `Checks` but do not make the result invalid.
+type HashVerificationResult struct {
+	IsValid bool        `json:"isValid"`
+	Checks  []HashCheck `json:"checks,omitempty"`
+}
+
+// gitoidBlobPrefix is the algorithm prefix used for OmniBOR identifiers, so
+// "gitoid:blob:sha256:<hex>" is checked as algorithm "GITOID:BLOB:SHA256".
+const gitoidBlobPrefix = "GITOID:BLOB:"
+
+// hashTarget groups all hashes declared for a single artifact path.
+type hashTarget struct {
+	component string
+	path      string
+	hashes    map[string]string // normalized algorithm -> expected hex digest
+	location  string            // JSON path of the declaring element
+}
+
+// VerifyArtifactHashes verifies that the file hashes declared in an SBOM match
+// the actual artifacts.
+//
+// Artifacts are looked up in the provided file system, which can be a directory
+// (`os.DirFS`) or an archive (e.g., `*zip.Reader`). Files are hashed in
+// parallel and each file is read only once, regardless of how many algorithms
+// are declared for it.
+//
+// For CycloneDX, hashes and OmniBOR identifiers (gitoids) of components of
+// type "file" are checked, using the component name as the path. For SPDX,
+// hashes of `files` (by `fileName`) and of `packages` that declare a
+// `packageFileName` are checked.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM JSON data.
+//   - artifacts: The file system containing the described artifacts.
+//
+// Returns:
+//   - A HashVerificationResult listing every check performed.
+//   - An error if the SBOM is not JSON or its type cannot be detected.
+//
+// Example:
+//
+//	result, err := VerifyArtifactHashes(sbomBytes, os.DirFS("dist"))
+//	if err != nil {
+//	    log.Fatalf("Hash verification failed: %v", err)
+//	}
+//	if !result.IsValid {
+//	    fmt.Println("Artifacts do not match the SBOM")
+//	}
+func VerifyArtifactHashes(sbomContent []byte, artifacts fs.FS) (*HashVerificationResult, error) {
+	doc, err := parseSBOMDocument(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+	return verifyArtifactHashes(doc, artifacts)
+}
+
+// verifyArtifactHashes verifies the hashes declared in a parsed SBOM.
+func verifyArtifactHashes(doc *sbomDocument, artifacts fs.FS) (*HashVerificationResult, error) {
+	obj, sbomType := doc.obj, doc.sbomType
+
+	var targets []hashTarget
+	if sbomType == SBOM_CYCLONEDX {
+		targets = cycloneDXHashTargets(obj)
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		targets = spdxHashTargets(obj)
+	} else {
+		return nil, fmt.Errorf("unsupported SBOM type: %s", sbomType)
+	}
+
+	checks := make([][]HashCheck, len(targets))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				checks[i] = verifyHashTarget(artifacts, targets[i])
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := &HashVerificationResult{IsValid: true}
+	for _, c := range checks {
+		for _, check := range c {
+			if check.Status == HashStatusMismatch {
+				result.IsValid = false
+			}
+			result.Checks = append(result.Checks, check)
+		}
+	}
+
+	return result, nil
+}
+
+// verifyHashTarget hashes a single artifact with every supported algorithm
+// declared for it and compares the digests.
+func verifyHashTarget(artifacts fs.FS, target hashTarget) []HashCheck {
+	algorithms := make([]string, 0, len(target.hashes))
+	for alg := range target.hashes {
+		algorithms = append(algorithms, alg)
+	}
+	sort.Strings(algorithms)
+
+	checks := make([]HashCheck, 0, len(algorithms))
+	hashers := map[string]hash.Hash{}
+	for _, alg := range algorithms {
+		check := HashCheck{
+			Component: target.component,
+			Path:      target.path,
+			Algorithm: alg,
+			Expected:  target.hashes[alg],
+			location:  target.location,
+		}
+		if h := newHasher(alg); h != nil {
+			hashers[alg] = h
+		} else {
+			check.Status = HashStatusUnsupported
+		}
+		checks = append(checks, check)
+	}
+
+	if len(hashers) > 0 {
+		err := hashFile(artifacts, target.path, hashers)
+		for i := range checks {
+			h, ok := hashers[checks[i].Algorithm]
+			if !ok {
+				continue
+			}
+			if err != nil {
+				checks[i].Status = HashStatusMissing
+				continue
+			}
+
+			checks[i].Actual = hex.EncodeToString(h.Sum(nil))
+			if strings.EqualFold(checks[i].Actual, checks[i].Expected) {
+				checks[i].Status = HashStatusMatch
+			} else {
+				checks[i].Status = HashStatusMismatch
+			}
+		}
+	}
+
+	return checks
+}
+
+// hashFile streams a file through all provided hashers in a single pass.
+func hashFile(artifacts fs.FS, name string, hashers map[string]hash.Hash) error {
+	f, err := artifacts.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	writers := make([]io.Writer, 0, len(hashers))
+	for alg, h := range hashers {
+		// a gitoid is the git object hash, which covers a "blob <size>\0" header
+		if strings.HasPrefix(alg, gitoidBlobPrefix) {
+			fmt.Fprintf(h, "blob %d\x00", info.Size())
+		}
+		writers = append(writers, h)
+	}
+
+	_, err = io.Copy(io.MultiWriter(writers...), f)
+	return err
+}
+
+// newHasher returns a hash implementation for a normalized algorithm name, or
+// nil if the algorithm is not supported.
+func newHasher(algorithm string) hash.Hash {
+	switch algorithm {
+	case "MD5":
+		return md5.New()
+	case "SHA1":
+		return sha1.New()
+	case "SHA256":
+		return sha256.New()
+	case "SHA384":
+		return sha512.New384()
+	case "SHA512":
+		return sha512.New()
+	case gitoidBlobPrefix + "SHA1":
+		return sha1.New()
+	case gitoidBlobPrefix + "SHA256":
+		return sha256.New()
+	}
+	return nil
+}
+
+// normalizeHashAlgorithm maps CycloneDX ("SHA-256") and SPDX ("SHA256")
+// algorithm names onto a single form.
+func normalizeHashAlgorithm(algorithm string) string {
+	return strings.ToUpper(strings.ReplaceAll(algorithm, "-", ""))
+}
+
+// normalizeArtifactPath converts an SBOM file name into a path usable with fs.FS.
+func normalizeArtifactPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+}
+
+func cycloneDXHashTargets(obj map[string]interface{}) []hashTarget {
+	var targets []hashTarget
+
+	var walk func(path string, components []interface{})
+	walk = func(path string, components []interface{}) {
+		for i, c := range components {
+			component, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name, _ := component["name"].(string)
+			componentType, _ := component["type"].(string)
+			if componentType == "file" && name != "" {
+				hashes := map[string]string{}
+				list, _ := component["hashes"].([]interface{})
+				for _, h := range list {
+					entry, ok := h.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					alg, _ := entry["alg"].(string)
+					content, _ := entry["content"].(string)
+					if alg != "" && content != "" {
+						hashes[normalizeHashAlgorithm(alg)] = content
+					}
+				}
+				ids, _ := component["omniborId"].([]interface{})
+				for _, v := range ids {
+					id, _ := v.(string)
+					if strings.HasPrefix(id, "gitoid:blob:") && gitoidPattern.MatchString(id) {
+						i := strings.LastIndex(id, ":")
+						hashes[strings.ToUpper(id[:i])] = id[i+1:]
+					}
+				}
+				if len(hashes) > 0 {
+					targets = append(targets, hashTarget{component: name, path: normalizeArtifactPath(name), hashes: hashes,
+						location: fmt.Sprintf("%s.%d", path, i)})
+				}
+			}
+
+			if nested, ok := component["components"].([]interface{}); ok {
+				walk(fmt.Sprintf("%s.%d.components", path, i), nested)
+			}
+		}
+	}
+
+	components, _ := obj["components"].([]interface{})
+	walk("components", components)
+
+	return targets
+}
+
+func spdxHashTargets(obj map[string]interface{}) []hashTarget {
+	var targets []hashTarget
+
+	collect := func(field string, elements []interface{}, nameField, fileField string) {
+		for i, e := range elements {
+			element, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			fileName, _ := element[fileField].(string)
+			if fileName == "" {
+				continue
+			}
+			name, _ := element[nameField].(string)
+
+			hashes := map[string]string{}
+			list, _ := element["checksums"].([]interface{})
+			for _, c := range list {
+				entry, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				alg, _ := entry["algorithm"].(string)
+				value, _ := entry["checksumValue"].(string)
+				if alg != "" && value != "" {
+					hashes[normalizeHashAlgorithm(alg)] = value
+				}
+			}
+			if len(hashes) > 0 {
+				targets = append(targets, hashTarget{component: name, path: normalizeArtifactPath(fileName), hashes: hashes,
+					location: fmt.Sprintf("%s.%d", field, i)})
+			}
+		}
+	}
+
+	files, _ := obj["files"].([]interface{})
+	collect("files", files, "fileName", "fileName")
+
+	packages, _ := obj["packages"].([]interface{})
+	collect("packages", packages, "name", "packageFileName")
+
+	return targets
+}
@@ -0,0 +1,97 @@
+package sbomvalidator
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestVerifyArtifactHashes(t *testing.T) {
+	// sha256("hello") and sha1("hello")
+	const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	const helloSHA1 = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+
+	artifacts := fstest.MapFS{
+		"bin/app": &fstest.MapFile{Data: []byte("hello")},
+	}
+
+	tests := []struct {
+		name       string
+		sbomData   string
+		wantValid  bool
+		wantStatus []HashStatus
+		expectErr  bool
+	}{
+		{
+			name: "CycloneDX file hash matches",
+			sbomData: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+				{"type": "file", "name": "./bin/app", "hashes": [
+					{"alg": "SHA-256", "content": "` + helloSHA256 + `"},
+					{"alg": "SHA-1", "content": "` + helloSHA1 + `"}
+				]}
+			]}`,
+			wantValid:  true,
+			wantStatus: []HashStatus{HashStatusMatch, HashStatusMatch},
+		},
+		{
+			name: "CycloneDX file hash mismatch",
+			sbomData: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+				{"type": "file", "name": "bin/app", "hashes": [{"alg": "SHA-256", "content": "deadbeef"}]}
+			]}`,
+			wantValid:  false,
+			wantStatus: []HashStatus{HashStatusMismatch},
+		},
+		{
+			name: "CycloneDX missing artifact and unsupported algorithm",
+			sbomData: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+				{"type": "file", "name": "bin/other", "hashes": [{"alg": "SHA-256", "content": "deadbeef"}]},
+				{"type": "file", "name": "bin/app", "hashes": [{"alg": "BLAKE3", "content": "deadbeef"}]}
+			]}`,
+			wantValid:  true,
+			wantStatus: []HashStatus{HashStatusMissing, HashStatusUnsupported},
+		},
+		{
+			name: "CycloneDX OmniBOR identifier matches",
+			sbomData: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+				{"type": "file", "name": "bin/app", "omniborId": ["gitoid:blob:sha1:b6fc4c620b67d95f953a5c1c1230aaab5db5a1b0"]}
+			]}`,
+			wantValid:  true,
+			wantStatus: []HashStatus{HashStatusMatch},
+		},
+		{
+			name: "SPDX file checksum matches",
+			sbomData: `{"spdxVersion": "SPDX-2.3", "files": [
+				{"fileName": "./bin/app", "checksums": [{"algorithm": "SHA256", "checksumValue": "` + helloSHA256 + `"}]}
+			]}`,
+			wantValid:  true,
+			wantStatus: []HashStatus{HashStatusMatch},
+		},
+		{
+			name:      "Unknown SBOM type",
+			sbomData:  `{"foo": "bar"}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := VerifyArtifactHashes([]byte(tt.sbomData), artifacts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("VerifyArtifactHashes() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if result.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v", result.IsValid, tt.wantValid)
+			}
+			if len(result.Checks) != len(tt.wantStatus) {
+				t.Fatalf("got %d checks, want %d: %+v", len(result.Checks), len(tt.wantStatus), result.Checks)
+			}
+			for i, check := range result.Checks {
+				if check.Status != tt.wantStatus[i] {
+					t.Errorf("check %d status = %q, want %q", i, check.Status, tt.wantStatus[i])
+				}
+			}
+		})
+	}
+}
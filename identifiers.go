@@ -0,0 +1,273 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shiftleftcyber/sbom-validator/identifiers"
+)
+
+// gitoidPattern matches a gitoid URI as registered with IANA, e.g.
+// "gitoid:blob:sha1:a94a8fe5ccb19ba61c4c0873d391e987982fbbd3".
+var gitoidPattern = regexp.MustCompile(`^gitoid:(blob|tree|commit|tag):(sha1:[0-9a-f]{40}|sha256:[0-9a-f]{64})$`)
+
+// componentIdentity is the name and version a single identifier (purl, CPE or
+// SWID tag) claims for a component.
+type componentIdentity struct {
+	source  string
+	name    string
+	version string
+}
+
+// parseCPE extracts the product and version from a CPE 2.3 formatted string
+// ("cpe:2.3:a:vendor:product:version:...") or a CPE 2.2 URI
+// ("cpe:/a:vendor:product:version").
+func parseCPE(cpe string) (product string, version string, err error) {
+	var parts []string
+	if rest, ok := strings.CutPrefix(cpe, "cpe:2.3:"); ok {
+		parts = strings.Split(rest, ":")
+	} else if rest, ok := strings.CutPrefix(cpe, "cpe:/"); ok {
+		parts = strings.Split(rest, ":")
+	} else {
+		return "", "", fmt.Errorf("invalid CPE %q", cpe)
+	}
+
+	// part:vendor:product[:version...]
+	if len(parts) < 3 || parts[2] == "" {
+		return "", "", fmt.Errorf("invalid CPE %q: missing product", cpe)
+	}
+	product = parts[2]
+	if len(parts) > 3 {
+		version = parts[3]
+	}
+
+	return product, version, nil
+}
+
+// componentIdentities returns the identities claimed by each identifier present
+// on a CycloneDX component. Identifiers that cannot be parsed are ignored here;
+// they are reported by checkIdentifiers.
+func componentIdentities(component map[string]interface{}) []componentIdentity {
+	var identities []componentIdentity
+
+	if purl, ok := component["purl"].(string); ok {
+		if p, err := parsePackageURL(purl); err == nil {
+			identities = append(identities, componentIdentity{source: "purl", name: p.Name, version: p.Version})
+		}
+	}
+
+	if cpe, ok := component["cpe"].(string); ok {
+		if product, version, err := parseCPE(cpe); err == nil {
+			identities = append(identities, componentIdentity{source: "cpe", name: product, version: version})
+		}
+	}
+
+	if swid, ok := component["swid"].(map[string]interface{}); ok {
+		name, _ := swid["name"].(string)
+		version, _ := swid["version"].(string)
+		if name != "" {
+			identities = append(identities, componentIdentity{source: "swid", name: name, version: version})
+		}
+	}
+
+	return identities
+}
+
+// checkComponentIdentifiers warns about CycloneDX components that carry more
+// than one of purl, cpe and swid where the identifiers disagree on the
+// component name or version. Downstream matchers typically pick one
+// identifier arbitrarily, so a disagreement leads to inconsistent results.
+//
+// Names are compared ignoring case and punctuation. Versions are compared
+// with identifiers.NormalizeVersion; CPE wildcard versions ("*" and "-") match
+// anything.
+//
+// Returns a warning message per disagreement, prefixed with the JSON path of
+// the component (e.g., "components.2").
+func checkComponentIdentifiers(obj map[string]interface{}) []string {
+	var warnings []string
+
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		warnings = append(warnings, componentIdentifierWarnings(path, component)...)
+	})
+	walkCycloneDXTools(obj, func(path string, tool map[string]interface{}) {
+		warnings = append(warnings, componentIdentifierWarnings(path, tool)...)
+	})
+
+	return warnings
+}
+
+// componentIdentifierWarnings applies the checkComponentIdentifiers rule to a
+// single component, without descending into nested components.
+func componentIdentifierWarnings(path string, component map[string]interface{}) []string {
+	var warnings []string
+
+	identities := componentIdentities(component)
+	for i := 0; i < len(identities); i++ {
+		for j := i + 1; j < len(identities); j++ {
+			a, b := identities[i], identities[j]
+			if normalizeIdentifierName(a.name) != normalizeIdentifierName(b.name) {
+				warnings = append(warnings, fmt.Sprintf("%s: %s name %q disagrees with %s name %q",
+					path, a.source, a.name, b.source, b.name))
+			}
+			if !identifierVersionsMatch(a.version, b.version) {
+				warnings = append(warnings, fmt.Sprintf("%s: %s version %q disagrees with %s version %q",
+					path, a.source, a.version, b.source, b.version))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// normalizeIdentifierName lower-cases a name and strips everything but letters
+// and digits, so "Foo_Bar", "foo-bar" and "foobar" compare equal.
+func normalizeIdentifierName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// identifierVersionsMatch compares two identifier versions. Missing versions
+// and CPE wildcards are treated as matching anything.
+func identifierVersionsMatch(a, b string) bool {
+	if a == "" || b == "" || a == "*" || b == "*" || a == "-" || b == "-" {
+		return true
+	}
+	return identifiers.NormalizeVersion(a) == identifiers.NormalizeVersion(b)
+}
+
+// checkIdentifiers checks the purl, CPE and SWID identifiers of every
+// component (CycloneDX components and tools, SPDX package external
+// references) with the identifiers package: an identifier that does not
+// conform to its specification is an identifier-syntax warning, and one
+// whose version disagrees with the component's an identifier-consistency
+// warning. Neither makes the SBOM invalid by default: the schemas accept any
+// string, and policy files can raise their severity.
+func checkIdentifiers(obj map[string]interface{}, sbomType string) []Finding {
+	var findings []Finding
+	check := func(c identifiers.Component) {
+		for _, f := range identifiers.Check(c) {
+			rule := RuleIdentifierConsistency
+			if f.Malformed {
+				rule = RuleIdentifierSyntax
+			}
+			findings = append(findings, Finding{Level: LevelWarning, Rule: rule, Path: f.Path, Pointer: jsonPointer(f.Path), Message: f.Message})
+		}
+	}
+
+	if sbomType == SBOM_CYCLONEDX {
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			check(cycloneDXIdentifiers(path, component))
+		})
+		walkCycloneDXTools(obj, func(path string, tool map[string]interface{}) {
+			check(cycloneDXIdentifiers(path, tool))
+		})
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		packages, _ := obj["packages"].([]interface{})
+		for i, p := range packages {
+			if pkg, ok := p.(map[string]interface{}); ok {
+				check(spdxIdentifiers(fmt.Sprintf("packages.%d", i), pkg))
+			}
+		}
+	}
+
+	return findings
+}
+
+// cycloneDXIdentifiers returns the version and the purl, cpe and swid of a
+// CycloneDX component.
+func cycloneDXIdentifiers(path string, component map[string]interface{}) identifiers.Component {
+	c := identifiers.Component{}
+	c.Version, _ = component["version"].(string)
+	if purl, ok := component["purl"].(string); ok {
+		c.Identifiers = append(c.Identifiers, identifiers.Identifier{Kind: identifiers.KindPURL, Path: path + ".purl", Value: purl})
+	}
+	if cpe, ok := component["cpe"].(string); ok {
+		c.Identifiers = append(c.Identifiers, identifiers.Identifier{Kind: identifiers.KindCPE, Path: path + ".cpe", Value: cpe})
+	}
+	if swid, ok := component["swid"].(map[string]interface{}); ok {
+		tag := &identifiers.SWID{}
+		tag.TagID, _ = swid["tagId"].(string)
+		tag.Name, _ = swid["name"].(string)
+		tag.Version, _ = swid["version"].(string)
+		tag.URL, _ = swid["url"].(string)
+		tag.Patch, _ = swid["patch"].(bool)
+		if tagVersion, ok := swid["tagVersion"].(float64); ok {
+			tag.TagVersion = int(tagVersion)
+		}
+		c.Identifiers = append(c.Identifiers, identifiers.Identifier{Kind: identifiers.KindSWID, Path: path + ".swid", SWID: tag})
+	}
+	return c
+}
+
+// spdxIdentifiers returns the version of an SPDX package and its external
+// references of types purl, cpe22Type, cpe23Type and swid.
+func spdxIdentifiers(path string, pkg map[string]interface{}) identifiers.Component {
+	kinds := map[string]identifiers.Kind{
+		"purl":      identifiers.KindPURL,
+		"cpe22Type": identifiers.KindCPE,
+		"cpe23Type": identifiers.KindCPE,
+		"swid":      identifiers.KindSWID,
+	}
+
+	c := identifiers.Component{}
+	c.Version, _ = pkg["versionInfo"].(string)
+	externalRefs, _ := pkg["externalRefs"].([]interface{})
+	for i, r := range externalRefs {
+		ref, _ := r.(map[string]interface{})
+		refType, _ := ref["referenceType"].(string)
+		locator, ok := ref["referenceLocator"].(string)
+		if kind, known := kinds[refType]; known && ok {
+			c.Identifiers = append(c.Identifiers, identifiers.Identifier{
+				Kind:  kind,
+				Path:  fmt.Sprintf("%s.externalRefs.%d.referenceLocator", path, i),
+				Value: locator,
+			})
+		}
+	}
+	return c
+}
+
+// checkOmniBORIDs validates the format of every `omniborId` (CycloneDX 1.6+)
+// declared on metadata.component and on components.
+//
+// The schema only requires the identifiers to be strings, while the
+// specification requires them to be valid gitoid URIs with a digest of the
+// correct length for the hash algorithm.
+//
+// Returns an error message per invalid identifier, prefixed with its JSON path
+// (e.g., "components.0.omniborId.1").
+func checkOmniBORIDs(obj map[string]interface{}) []string {
+	var errors []string
+
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		errors = append(errors, componentOmniBORErrors(path, component)...)
+	})
+	walkCycloneDXTools(obj, func(path string, tool map[string]interface{}) {
+		errors = append(errors, componentOmniBORErrors(path, tool)...)
+	})
+
+	return errors
+}
+
+// componentOmniBORErrors applies the checkOmniBORIDs rule to a single
+// component, without descending into nested components.
+func componentOmniBORErrors(path string, component map[string]interface{}) []string {
+	var errors []string
+
+	ids, _ := component["omniborId"].([]interface{})
+	for i, v := range ids {
+		id, _ := v.(string)
+		if !gitoidPattern.MatchString(id) {
+			errors = append(errors, fmt.Sprintf("%s.omniborId.%d: invalid gitoid %q", path, i, id))
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,161 @@
+package identifiers
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// CPE bindings recognized by ParseCPE.
+const (
+	// CPE22 is the URI binding of CPE 2.2, e.g., "cpe:/a:apache:log4j:2.14.1".
+	CPE22 = "2.2"
+	// CPE23 is the formatted string binding of CPE 2.3, e.g.,
+	// "cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*".
+	CPE23 = "2.3"
+)
+
+// cpe23Attributes names the eleven attributes of a CPE 2.3 formatted string,
+// in binding order.
+var cpe23Attributes = []string{
+	"part", "vendor", "product", "version", "update", "edition",
+	"language", "sw_edition", "target_sw", "target_hw", "other",
+}
+
+var (
+	// cpe23Value matches an attribute value of a CPE 2.3 formatted string: a
+	// logical value ("*" or "-"), or characters, quoted punctuation and
+	// leading and trailing wildcards (NISTIR 7695, section 6.2).
+	cpe23Value = regexp.MustCompile(`^(\*|-|(\?*|\*?)([a-zA-Z0-9\-._]|\\[\\*?!"#$%&'()+,/:;<=>@\[\]^` + "`" + `{|}~])+(\?*|\*?))$`)
+	// cpe23Language matches the language attribute: an RFC 5646 language and
+	// optional region, or a logical value.
+	cpe23Language = regexp.MustCompile(`^(\*|-|[a-zA-Z]{2,3}(-([a-zA-Z]{2}|[0-9]{3}))?)$`)
+	// cpe22Component matches a component of a CPE 2.2 URI.
+	cpe22Component = regexp.MustCompile(`^[A-Za-z0-9._\-~%]*$`)
+)
+
+// CPE is a parsed CPE name. Attribute values are unquoted (2.3) or
+// percent-decoded (2.2); attributes a CPE 2.2 URI leaves out are empty.
+type CPE struct {
+	Binding  string
+	Part     string
+	Vendor   string
+	Product  string
+	Version  string
+	Update   string
+	Edition  string
+	Language string
+}
+
+// ParseCPE parses a CPE 2.3 formatted string or a CPE 2.2 URI, checking it
+// against the syntax of its binding.
+func ParseCPE(cpe string) (*CPE, error) {
+	if rest, ok := strings.CutPrefix(cpe, "cpe:2.3:"); ok {
+		return parseCPE23(cpe, rest)
+	}
+	// the scheme of a CPE 2.2 URI is case-insensitive
+	if len(cpe) >= 5 && strings.EqualFold(cpe[:5], "cpe:/") {
+		return parseCPE22(cpe, cpe[5:])
+	}
+	return nil, fmt.Errorf("invalid CPE %q: expected a \"cpe:2.3:\" formatted string or a \"cpe:/\" URI", cpe)
+}
+
+// parseCPE23 parses the attributes of a CPE 2.3 formatted string, rest being
+// what follows "cpe:2.3:".
+func parseCPE23(cpe, rest string) (*CPE, error) {
+	values := splitUnescaped(rest)
+	if len(values) != len(cpe23Attributes) {
+		return nil, fmt.Errorf("invalid CPE %q: a CPE 2.3 name has %d attributes, found %d", cpe, len(cpe23Attributes), len(values))
+	}
+	for i, value := range values {
+		var valid bool
+		switch cpe23Attributes[i] {
+		case "part":
+			valid = value == "a" || value == "h" || value == "o" || value == "*" || value == "-"
+		case "language":
+			valid = cpe23Language.MatchString(value)
+		default:
+			valid = cpe23Value.MatchString(value)
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid CPE %q: invalid %s %q", cpe, cpe23Attributes[i], value)
+		}
+	}
+	return &CPE{
+		Binding:  CPE23,
+		Part:     values[0],
+		Vendor:   unquoteCPE23(values[1]),
+		Product:  unquoteCPE23(values[2]),
+		Version:  unquoteCPE23(values[3]),
+		Update:   unquoteCPE23(values[4]),
+		Edition:  unquoteCPE23(values[5]),
+		Language: values[6],
+	}, nil
+}
+
+// parseCPE22 parses the components of a CPE 2.2 URI, rest being what follows
+// "cpe:/".
+func parseCPE22(cpe, rest string) (*CPE, error) {
+	components := strings.Split(rest, ":")
+	if len(components) > 7 {
+		return nil, fmt.Errorf("invalid CPE %q: a CPE 2.2 URI has at most 7 components, found %d", cpe, len(components))
+	}
+	values := make([]string, 7)
+	for i, component := range components {
+		if !cpe22Component.MatchString(component) {
+			return nil, fmt.Errorf("invalid CPE %q: invalid character in %s %q", cpe, cpe23Attributes[i], component)
+		}
+		value, err := url.PathUnescape(component)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPE %q: invalid percent-encoding in %s %q", cpe, cpe23Attributes[i], component)
+		}
+		values[i] = value
+	}
+	if part := strings.ToLower(values[0]); part != "" && part != "a" && part != "h" && part != "o" {
+		return nil, fmt.Errorf("invalid CPE %q: invalid part %q", cpe, values[0])
+	}
+	return &CPE{
+		Binding:  CPE22,
+		Part:     strings.ToLower(values[0]),
+		Vendor:   values[1],
+		Product:  values[2],
+		Version:  values[3],
+		Update:   values[4],
+		Edition:  values[5],
+		Language: values[6],
+	}, nil
+}
+
+// splitUnescaped splits a CPE 2.3 formatted string on the colons that are
+// not quoted with a backslash.
+func splitUnescaped(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ':':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquoteCPE23 removes the backslashes quoting punctuation in a CPE 2.3
+// attribute value.
+func unquoteCPE23(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
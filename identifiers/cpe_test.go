@@ -0,0 +1,50 @@
+package identifiers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCPE(t *testing.T) {
+	tests := []struct {
+		cpe         string
+		wantBinding string
+		wantProduct string
+		wantVersion string
+		wantErr     string
+	}{
+		{cpe: "cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*", wantBinding: CPE23, wantProduct: "log4j", wantVersion: "2.14.1"},
+		{cpe: `cpe:2.3:a:microsoft:visual_c\+\+:2019:*:*:en-us:*:*:x64:*`, wantBinding: CPE23, wantProduct: "visual_c++", wantVersion: "2019"},
+		{cpe: `cpe:2.3:a:example:tool:1.0\:beta:*:*:*:*:*:*:*`, wantBinding: CPE23, wantProduct: "tool", wantVersion: "1.0:beta"},
+		{cpe: "cpe:2.3:o:linux:linux_kernel:5.*:*:*:*:*:*:*:*", wantBinding: CPE23, wantProduct: "linux_kernel", wantVersion: "5.*"},
+		{cpe: "cpe:/a:apache:log4j:2.14.1", wantBinding: CPE22, wantProduct: "log4j", wantVersion: "2.14.1"},
+		{cpe: "CPE:/a:apache:log4j", wantBinding: CPE22, wantProduct: "log4j"},
+		{cpe: "cpe:/a:example:tool%2b:1.0", wantBinding: CPE22, wantProduct: "tool+", wantVersion: "1.0"},
+		{cpe: "cpe:2.3:a:apache:log4j:2.14.1", wantErr: "has 11 attributes, found 4"},
+		{cpe: "cpe:2.3:x:apache:log4j:2.14.1:*:*:*:*:*:*:*", wantErr: `invalid part "x"`},
+		{cpe: "cpe:2.3:a:apache:log 4j:2.14.1:*:*:*:*:*:*:*", wantErr: `invalid product "log 4j"`},
+		{cpe: "cpe:2.3:a:apache:log4j:2.14.1:*:*:english:*:*:*:*", wantErr: `invalid language "english"`},
+		{cpe: "cpe:/a:apache:log4j:2.14.1:a:b:c:d", wantErr: "at most 7 components"},
+		{cpe: "cpe:/x:apache:log4j", wantErr: `invalid part "x"`},
+		{cpe: "cpe:/a:apache:log4j:2.14.1%zz", wantErr: "invalid percent-encoding"},
+		{cpe: "log4j", wantErr: "expected a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cpe, func(t *testing.T) {
+			cpe, err := ParseCPE(tt.cpe)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseCPE(%q) error = %v, want %q", tt.cpe, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCPE(%q) unexpected error: %v", tt.cpe, err)
+			}
+			if cpe.Binding != tt.wantBinding || cpe.Product != tt.wantProduct || cpe.Version != tt.wantVersion {
+				t.Errorf("ParseCPE(%q) = %+v", tt.cpe, cpe)
+			}
+		})
+	}
+}
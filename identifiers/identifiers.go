@@ -0,0 +1,163 @@
+// Package identifiers validates the identifiers SBOMs give components: CPE
+// names in their 2.2 URI and 2.3 formatted string bindings, SWID tags, and
+// Package URLs (purls).
+//
+// The SBOM schemas only require these identifiers to be strings (or, for
+// SWID tags, an object with a tag ID and a name). `Check` reports the
+// identifiers of a component that do not conform to their own
+// specification, and those that disagree with the component they identify,
+// e.g., a purl whose version is not the component's version. The validator
+// runs it on every component, as the identifier-syntax and
+// identifier-consistency rules.
+//
+// Example:
+//
+//	findings := identifiers.Check(identifiers.Component{
+//	    Version: "2.17.1",
+//	    Identifiers: []identifiers.Identifier{
+//	        {Kind: identifiers.KindPURL, Path: "purl", Value: "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+//	    },
+//	})
+//	for _, f := range findings {
+//	    fmt.Printf("%s: %s\n", f.Path, f.Message)
+//	}
+package identifiers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of an identifier.
+type Kind string
+
+// Kinds of identifiers.
+const (
+	KindPURL Kind = "purl"
+	KindCPE  Kind = "cpe"
+	KindSWID Kind = "swid"
+)
+
+// Identifier is an identifier of a component, as an SBOM declares it.
+type Identifier struct {
+	Kind Kind
+	// Path is where the SBOM declares the identifier, e.g.,
+	// "components.0.purl"; findings about the identifier are reported there.
+	Path string
+	// Value is the purl or the CPE name or, for a SWID tag referenced by its
+	// tag ID, the "swid:" locator.
+	Value string
+	// SWID is the SWID tag, for tags declared in full.
+	SWID *SWID
+}
+
+// Component is the version of a component of an SBOM and its identifiers.
+type Component struct {
+	Version     string
+	Identifiers []Identifier
+}
+
+// Finding is a problem with an identifier.
+type Finding struct {
+	Kind Kind
+	// Path is the path of the identifier, or of its field at fault.
+	Path string
+	// Malformed reports that the identifier does not conform to its
+	// specification; otherwise it is well-formed but disagrees with the
+	// component.
+	Malformed bool
+	Message   string
+}
+
+// FieldError is a problem with a field of a structured identifier, such as
+// a SWID tag.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Check validates each identifier of a component against its specification
+// and, for those that are well-formed, compares the version it claims with
+// the component's. Versions are compared with NormalizeVersion; CPE logical
+// values ("*" and "-") and missing versions match anything.
+//
+// Returns the findings, in the order of the identifiers.
+func Check(c Component) []Finding {
+	var findings []Finding
+	malformed := func(id Identifier, path, message string) {
+		findings = append(findings, Finding{Kind: id.Kind, Path: path, Malformed: true, Message: message})
+	}
+
+	for _, id := range c.Identifiers {
+		var version string
+		switch id.Kind {
+		case KindPURL:
+			p, err := ParsePURL(id.Value)
+			if err != nil {
+				malformed(id, id.Path, err.Error())
+				continue
+			}
+			version = p.Version
+		case KindCPE:
+			cpe, err := ParseCPE(id.Value)
+			if err != nil {
+				malformed(id, id.Path, err.Error())
+				continue
+			}
+			version = cpe.Version
+		case KindSWID:
+			if id.SWID == nil {
+				if err := ValidateSWIDLocator(id.Value); err != nil {
+					malformed(id, id.Path, err.Error())
+				}
+				continue
+			}
+			errs := ValidateSWID(*id.SWID)
+			for _, err := range errs {
+				malformed(id, id.Path+"."+err.Field, err.Message)
+			}
+			if len(errs) > 0 {
+				continue
+			}
+			version = id.SWID.Version
+		default:
+			continue
+		}
+
+		if !versionsMatch(version, c.Version) {
+			findings = append(findings, Finding{
+				Kind:    id.Kind,
+				Path:    id.Path,
+				Message: fmt.Sprintf("%s version %q disagrees with component version %q", id.Kind, version, c.Version),
+			})
+		}
+	}
+
+	return findings
+}
+
+// versionsMatch compares an identifier's version with a component's.
+func versionsMatch(identifier, component string) bool {
+	if identifier == "" || component == "" || identifier == "*" || identifier == "-" {
+		return true
+	}
+	return NormalizeVersion(identifier) == NormalizeVersion(component)
+}
+
+// NormalizeVersion lower-cases a version and strips a leading "v" or "go"
+// followed by a digit, so "v1.20", "go1.20" and "1.20" compare equal. Go
+// toolchains record their version as "go1.20" where purls and CPEs use
+// "1.20".
+func NormalizeVersion(version string) string {
+	version = strings.ToLower(version)
+	for _, prefix := range []string{"go", "v"} {
+		if rest, ok := strings.CutPrefix(version, prefix); ok && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			return rest
+		}
+	}
+	return version
+}
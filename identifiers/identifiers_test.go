@@ -0,0 +1,125 @@
+package identifiers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePURL(t *testing.T) {
+	tests := []struct {
+		purl    string
+		want    *PURL
+		wantErr string
+	}{
+		{
+			purl: "pkg:npm/%40angular/core@16.0.0?repository_url=https%3A%2F%2Fexample.com&arch=#src/lib",
+			want: &PURL{Type: "npm", Namespace: "@angular", Name: "core", Version: "16.0.0",
+				Qualifiers: map[string]string{"repository_url": "https://example.com", "arch": ""}, Subpath: "src/lib"},
+		},
+		{
+			purl: "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+			want: &PURL{Type: "maven", Namespace: "org.apache.logging.log4j", Name: "log4j-core", Version: "2.14.1", Qualifiers: map[string]string{}},
+		},
+		{purl: "left-pad@1.3.0", wantErr: `missing "pkg:" scheme`},
+		{purl: "pkg:1npm/left-pad", wantErr: `invalid type "1npm"`},
+		{purl: "pkg:npm/left-pad@", wantErr: "empty version"},
+		{purl: "pkg:npm/@1.0.0", wantErr: "missing name"},
+		{purl: "pkg:npm/left-pad?os=linux&OS=darwin", wantErr: `qualifier "os" is repeated`},
+		{purl: "pkg:npm/left-pad?9arch=x86", wantErr: `invalid qualifier key "9arch"`},
+		{purl: "pkg:npm/left%zzpad", wantErr: "invalid percent-encoding in name"},
+		{purl: "pkg:maven/log4j-core@2.14.1", wantErr: "type maven requires a namespace"},
+		{purl: "pkg:swift/github.com/Alamofire/Alamofire", wantErr: "type swift requires a version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.purl, func(t *testing.T) {
+			got, err := ParsePURL(tt.purl)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePURL(%q) error = %v, want %q", tt.purl, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePURL(%q) unexpected error: %v", tt.purl, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePURL(%q) = %+v, want %+v", tt.purl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSWID(t *testing.T) {
+	valid := SWID{TagID: "swidgen-242eb18a-503e-ca37-393b-cf156ef09691_9.1.1", Name: "Acme Application", Version: "9.1.1", URL: "https://example.com/swid"}
+	if errs := ValidateSWID(valid); len(errs) != 0 {
+		t.Errorf("ValidateSWID() = %v, want none", errs)
+	}
+
+	errs := ValidateSWID(SWID{TagID: "acme app", Name: " ", TagVersion: -1, URL: "/relative"})
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	if want := []string{"tagId", "name", "tagVersion", "url"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("ValidateSWID() fields = %q, want %q", fields, want)
+	}
+
+	if err := ValidateSWIDLocator("swid:2df9de35-0aff-4a86-ace6-f7dddd1ade4c"); err != nil {
+		t.Errorf("ValidateSWIDLocator() unexpected error: %v", err)
+	}
+	if err := ValidateSWIDLocator("2df9de35-0aff-4a86-ace6-f7dddd1ade4c"); err == nil {
+		t.Error("ValidateSWIDLocator() expected an error")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	c := Component{
+		Version: "2.17.1",
+		Identifiers: []Identifier{
+			{Kind: KindPURL, Path: "components.0.purl", Value: "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+			{Kind: KindCPE, Path: "components.0.cpe", Value: "cpe:2.3:a:apache:log4j:v2.17.1:*:*:*:*:*:*:*"},
+			{Kind: KindSWID, Path: "components.0.swid", SWID: &SWID{TagID: "log4j", Version: "2.17.1"}},
+		},
+	}
+	want := []Finding{
+		{Kind: KindPURL, Path: "components.0.purl", Message: `purl version "2.14.1" disagrees with component version "2.17.1"`},
+		{Kind: KindSWID, Path: "components.0.swid.name", Malformed: true, Message: "SWID tag has no software name"},
+	}
+	if got := Check(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %+v, want %+v", got, want)
+	}
+
+	c = Component{
+		Version: "1.0.0",
+		Identifiers: []Identifier{
+			{Kind: KindCPE, Path: "packages.0.externalRefs.0.referenceLocator", Value: "cpe:2.3:a:acme:app:*:*:*:*:*:*:*:*"},
+			{Kind: KindCPE, Path: "packages.0.externalRefs.1.referenceLocator", Value: "cpe:/a:acme:app:1.0.0"},
+			{Kind: KindSWID, Path: "packages.0.externalRefs.2.referenceLocator", Value: "acme-app"},
+		},
+	}
+	got := Check(c)
+	if len(got) != 1 || !got[0].Malformed || got[0].Path != "packages.0.externalRefs.2.referenceLocator" {
+		t.Errorf("Check() = %+v, want a malformed SWID locator", got)
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		identifier, component string
+		want                  bool
+	}{
+		{"1.20", "go1.20", true},
+		{"1.20", "v1.20", true},
+		{"V1.20", "1.20", true},
+		{"1.20", "go1.21", false},
+		{"golang", "lang", false},
+		{"vnext", "next", false},
+	}
+	for _, tt := range tests {
+		if got := NormalizeVersion(tt.identifier) == NormalizeVersion(tt.component); got != tt.want {
+			t.Errorf("NormalizeVersion(%q) == NormalizeVersion(%q) is %v, want %v", tt.identifier, tt.component, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package identifiers
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// purlType matches a purl type: ASCII letters, digits, '.', '+' and '-',
+	// not starting with a digit.
+	purlType = regexp.MustCompile(`^[a-zA-Z.+-][a-zA-Z0-9.+-]*$`)
+	// purlQualifierKey matches a qualifier key: ASCII letters, digits, '.',
+	// '-' and '_', not starting with a digit.
+	purlQualifierKey = regexp.MustCompile(`^[a-zA-Z.\-_][a-zA-Z0-9.\-_]*$`)
+)
+
+// purlTypeRequirements lists the components the purl type definitions make
+// mandatory for some types.
+var purlTypeRequirements = map[string]struct{ namespace, version bool }{
+	"bitbucket": {namespace: true},
+	"composer":  {namespace: true},
+	"cran":      {version: true},
+	"github":    {namespace: true},
+	"maven":     {namespace: true},
+	"swift":     {namespace: true, version: true},
+}
+
+// PURL is a parsed Package URL. Components are percent-decoded.
+type PURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers map[string]string
+	Subpath    string
+}
+
+// ParsePURL parses a Package URL, checking it against the purl
+// specification: the scheme, the characters allowed in the type and
+// qualifier keys, percent-encoding, the mandatory name, unique qualifiers,
+// and the namespace and version that some types require.
+func ParsePURL(purl string) (*PURL, error) {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return nil, fmt.Errorf("invalid purl %q: missing \"pkg:\" scheme", purl)
+	}
+	rest = strings.TrimLeft(rest, "/")
+	p := &PURL{Qualifiers: map[string]string{}}
+
+	if before, subpath, ok := strings.Cut(rest, "#"); ok {
+		value, err := unescapePURL(purl, "subpath", strings.Trim(subpath, "/"))
+		if err != nil {
+			return nil, err
+		}
+		p.Subpath, rest = value, before
+	}
+
+	if before, qualifiers, ok := strings.Cut(rest, "?"); ok {
+		for _, pair := range strings.Split(qualifiers, "&") {
+			if pair == "" {
+				continue
+			}
+			key, value, _ := strings.Cut(pair, "=")
+			if !purlQualifierKey.MatchString(key) {
+				return nil, fmt.Errorf("invalid purl %q: invalid qualifier key %q", purl, key)
+			}
+			key = strings.ToLower(key)
+			if _, dup := p.Qualifiers[key]; dup {
+				return nil, fmt.Errorf("invalid purl %q: qualifier %q is repeated", purl, key)
+			}
+			unescaped, err := unescapePURL(purl, "qualifier "+key, value)
+			if err != nil {
+				return nil, err
+			}
+			p.Qualifiers[key] = unescaped
+		}
+		rest = before
+	}
+
+	typ, rest, _ := strings.Cut(rest, "/")
+	if !purlType.MatchString(typ) {
+		return nil, fmt.Errorf("invalid purl %q: invalid type %q", purl, typ)
+	}
+	p.Type = strings.ToLower(typ)
+
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		if rest[i+1:] == "" {
+			return nil, fmt.Errorf("invalid purl %q: empty version", purl)
+		}
+		version, err := unescapePURL(purl, "version", rest[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		p.Version, rest = version, rest[:i]
+	}
+
+	rest = strings.Trim(rest, "/")
+	namespace, name := "", rest
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		namespace, name = rest[:i], rest[i+1:]
+	}
+	var err error
+	if p.Namespace, err = unescapePURL(purl, "namespace", namespace); err != nil {
+		return nil, err
+	}
+	if p.Name, err = unescapePURL(purl, "name", name); err != nil {
+		return nil, err
+	}
+	if p.Name == "" {
+		return nil, fmt.Errorf("invalid purl %q: missing name", purl)
+	}
+
+	required := purlTypeRequirements[p.Type]
+	if required.namespace && p.Namespace == "" {
+		return nil, fmt.Errorf("invalid purl %q: type %s requires a namespace", purl, p.Type)
+	}
+	if required.version && p.Version == "" {
+		return nil, fmt.Errorf("invalid purl %q: type %s requires a version", purl, p.Type)
+	}
+
+	return p, nil
+}
+
+// unescapePURL percent-decodes a purl component, naming the component if its
+// encoding is invalid.
+func unescapePURL(purl, component, value string) (string, error) {
+	unescaped, err := url.PathUnescape(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid purl %q: invalid percent-encoding in %s %q", purl, component, value)
+	}
+	return unescaped, nil
+}
@@ -0,0 +1,57 @@
+package identifiers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// SWID is a SWID tag (ISO/IEC 19770-2) as SBOMs reference it, e.g., the
+// CycloneDX component `swid` object.
+type SWID struct {
+	TagID      string
+	Name       string
+	Version    string
+	TagVersion int
+	Patch      bool
+	URL        string
+}
+
+// ValidateSWID checks the structure of a SWID tag: the tag ID and software
+// name are required and not blank, the tag version is not negative, and the
+// URL, if any, is absolute.
+//
+// Returns an error per problem, each naming the field at fault.
+func ValidateSWID(tag SWID) []FieldError {
+	var errs []FieldError
+	if strings.TrimSpace(tag.TagID) == "" {
+		errs = append(errs, FieldError{Field: "tagId", Message: "SWID tag has no tag ID"})
+	} else if strings.ContainsAny(tag.TagID, " \t\r\n") {
+		errs = append(errs, FieldError{Field: "tagId", Message: fmt.Sprintf("SWID tag ID %q contains whitespace", tag.TagID)})
+	}
+	if strings.TrimSpace(tag.Name) == "" {
+		errs = append(errs, FieldError{Field: "name", Message: "SWID tag has no software name"})
+	}
+	if tag.TagVersion < 0 {
+		errs = append(errs, FieldError{Field: "tagVersion", Message: fmt.Sprintf("SWID tag version %d is negative", tag.TagVersion)})
+	}
+	if tag.URL != "" {
+		if u, err := url.Parse(tag.URL); err != nil || !u.IsAbs() {
+			errs = append(errs, FieldError{Field: "url", Message: fmt.Sprintf("SWID tag URL %q is not an absolute URL", tag.URL)})
+		}
+	}
+	return errs
+}
+
+// ValidateSWIDLocator checks a reference to a SWID tag by its tag ID, as SPDX
+// external references of type swid make it: "swid:" followed by the tag ID.
+func ValidateSWIDLocator(locator string) error {
+	tagID, ok := strings.CutPrefix(locator, "swid:")
+	if !ok {
+		return fmt.Errorf("invalid SWID locator %q: missing \"swid:\" scheme", locator)
+	}
+	if strings.TrimSpace(tagID) == "" || strings.ContainsAny(tagID, " \t\r\n") {
+		return fmt.Errorf("invalid SWID locator %q: invalid tag ID", locator)
+	}
+	return nil
+}
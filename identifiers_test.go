@@ -0,0 +1,200 @@
+package sbomvalidator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCPE(t *testing.T) {
+	tests := []struct {
+		cpe         string
+		wantProduct string
+		wantVersion string
+		expectErr   bool
+	}{
+		{cpe: "cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*", wantProduct: "log4j", wantVersion: "2.14.1"},
+		{cpe: "cpe:/a:apache:log4j:2.14.1", wantProduct: "log4j", wantVersion: "2.14.1"},
+		{cpe: "cpe:/a:apache:log4j", wantProduct: "log4j"},
+		{cpe: "cpe:2.3:a:apache", expectErr: true},
+		{cpe: "log4j", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cpe, func(t *testing.T) {
+			product, version, err := parseCPE(tt.cpe)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("parseCPE(%q) error = %v, expectErr %v", tt.cpe, err, tt.expectErr)
+			}
+			if product != tt.wantProduct || version != tt.wantVersion {
+				t.Errorf("parseCPE(%q) = (%q, %q), want (%q, %q)", tt.cpe, product, version, tt.wantProduct, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestCheckComponentIdentifiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonData string
+		want     []string
+	}{
+		{
+			name: "Agreeing identifiers",
+			jsonData: `{"components": [{"name": "log4j-core",
+				"purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+				"cpe": "cpe:2.3:a:apache:log4j_core:2.14.1:*:*:*:*:*:*:*"}]}`,
+		},
+		{
+			name: "CPE wildcard version",
+			jsonData: `{"components": [{"name": "openssl",
+				"purl": "pkg:generic/openssl@3.0.0",
+				"cpe": "cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*"}]}`,
+		},
+		{
+			name: "Version disagreement on metadata component",
+			jsonData: `{"metadata": {"component": {"name": "app",
+				"purl": "pkg:npm/app@1.0.0",
+				"swid": {"tagId": "app", "name": "app", "version": "v1.1.0"}}}}`,
+			want: []string{`metadata.component: purl version "1.0.0" disagrees with swid version "v1.1.0"`},
+		},
+		{
+			name: "Name disagreement on nested component",
+			jsonData: `{"components": [{"name": "parent", "components": [{"name": "child",
+				"purl": "pkg:npm/left-pad@1.3.0",
+				"cpe": "cpe:2.3:a:example:right-pad:1.3.0:*:*:*:*:*:*:*"}]}]}`,
+			want: []string{`components.0.components.0: purl name "left-pad" disagrees with cpe name "right-pad"`},
+		},
+		{
+			name:     "Single identifier",
+			jsonData: `{"components": [{"name": "a", "purl": "pkg:npm/a@1.0.0"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(tt.jsonData)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := checkComponentIdentifiers(obj)
+			if len(got) != len(tt.want) {
+				t.Fatalf("checkComponentIdentifiers() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("warning %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckOmniBORIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonData string
+		want     []string
+	}{
+		{
+			name: "Valid sha1 and sha256 gitoids",
+			jsonData: `{"components": [{"name": "a", "omniborId": [
+				"gitoid:blob:sha1:a94a8fe5ccb19ba61c4c0873d391e987982fbbd3",
+				"gitoid:blob:sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+			]}]}`,
+		},
+		{
+			name: "Wrong digest length for algorithm",
+			jsonData: `{"components": [{"name": "a", "omniborId": [
+				"gitoid:blob:sha256:a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"
+			]}]}`,
+			want: []string{`components.0.omniborId.0: invalid gitoid "gitoid:blob:sha256:a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"`},
+		},
+		{
+			name:     "Missing scheme on metadata component",
+			jsonData: `{"metadata": {"component": {"name": "app", "omniborId": ["a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"]}}}`,
+			want:     []string{`metadata.component.omniborId.0: invalid gitoid "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(tt.jsonData)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := checkOmniBORIDs(obj)
+			if len(got) != len(tt.want) {
+				t.Fatalf("checkOmniBORIDs() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("error %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckIdentifiers(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonData string
+		sbomType string
+		want     []string
+	}{
+		{
+			name: "CycloneDX component",
+			jsonData: `{"components": [{"name": "log4j-core", "version": "2.17.1",
+				"purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+				"cpe": "cpe:2.3:a:apache:log4j:2.17.1",
+				"swid": {"tagId": "log4j core", "name": "log4j-core", "version": "2.17.1"}}]}`,
+			sbomType: SBOM_CYCLONEDX,
+			want: []string{
+				`identifier-consistency components.0.purl: purl version "2.14.1" disagrees with component version "2.17.1"`,
+				`identifier-syntax components.0.cpe: invalid CPE "cpe:2.3:a:apache:log4j:2.17.1": a CPE 2.3 name has 11 attributes, found 4`,
+				`identifier-syntax components.0.swid.tagId: SWID tag ID "log4j core" contains whitespace`,
+			},
+		},
+		{
+			name:     "CycloneDX tool",
+			jsonData: `{"metadata": {"tools": [{"name": "scanner", "version": "2.0", "purl": "pkg:generic/scanner@1.0"}]}}`,
+			sbomType: SBOM_CYCLONEDX,
+			want:     []string{`identifier-consistency metadata.tools.0.purl: purl version "1.0" disagrees with component version "2.0"`},
+		},
+		{
+			name: "SPDX external references",
+			jsonData: `{"packages": [{"name": "openssl", "versionInfo": "3.0.7", "externalRefs": [
+				{"referenceCategory": "SECURITY", "referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:openssl:openssl:3.0.7:*:*:*:*:*:*:*"},
+				{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:generic/openssl@3.0.8"},
+				{"referenceCategory": "SECURITY", "referenceType": "advisory", "referenceLocator": "https://example.com"}]}]}`,
+			sbomType: "SPDX-2.3",
+			want:     []string{`identifier-consistency packages.0.externalRefs.1.referenceLocator: purl version "3.0.8" disagrees with component version "3.0.7"`},
+		},
+		{
+			name: "SPDX Go toolchain",
+			jsonData: `{"packages": [{"name": "stdlib", "versionInfo": "go1.20", "externalRefs": [
+				{"referenceCategory": "SECURITY", "referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:golang:go:1.20:-:*:*:*:*:*:*"},
+				{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:golang/stdlib@1.20"}]}]}`,
+			sbomType: "SPDX-2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(tt.jsonData)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var got []string
+			for _, f := range checkIdentifiers(obj, tt.sbomType) {
+				if f.Level != LevelWarning {
+					t.Errorf("finding %v has level %s, want warning", f, f.Level)
+				}
+				got = append(got, f.Rule+" "+f.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkIdentifiers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package sbomvalidator
+
+import "strings"
+
+// ComponentDescriptor is the format-neutral view of a CycloneDX component or
+// an SPDX package that an IdentityResolver matches on.
+type ComponentDescriptor struct {
+	Name    string
+	Version string
+	// Group is the CycloneDX group; it is empty for SPDX packages.
+	Group string
+	// PURL and CPE are the component's package URL and CPE (2.3 or 2.2), or
+	// "" if it has none. For SPDX they come from the package's externalRefs.
+	PURL string
+	CPE  string
+	// Licenses are the license IDs or expressions the component declares (for
+	// SPDX, licenseDeclared, or else licenseConcluded). Identity resolvers
+	// normally ignore them.
+	Licenses []string
+	// Evidence reports that the component carries evidence of how the tool
+	// generating the SBOM found it: a CycloneDX evidence identity, occurrences
+	// or call stack, or an SPDX packageVerificationCode over analyzed files.
+	Evidence bool
+}
+
+// IdentityResolver decides which components are the same across SBOMs, e.g.
+// between the inputs and output of a convert or merge (see
+// `CompareConversion`). Components resolving to the same identity are the
+// same component; the identity is also the key reports refer to them by.
+//
+// Adopters whose artifact naming defeats the built-in resolvers can plug in
+// their own with `WithIdentityResolver`.
+type IdentityResolver interface {
+	// ResolveIdentity returns the identity of a component, or "" if it cannot
+	// be identified. Unidentified components are left out of the comparison.
+	ResolveIdentity(component ComponentDescriptor) string
+}
+
+// IdentityResolverFunc adapts a function to an IdentityResolver.
+type IdentityResolverFunc func(component ComponentDescriptor) string
+
+// ResolveIdentity calls f(component).
+func (f IdentityResolverFunc) ResolveIdentity(component ComponentDescriptor) string {
+	return f(component)
+}
+
+// Built-in identity resolvers, to be combined with FirstIdentity.
+var (
+	// PURLIdentity identifies a component by its purl, without qualifiers
+	// or subpath, which tools fill in inconsistently.
+	PURLIdentity IdentityResolver = IdentityResolverFunc(func(c ComponentDescriptor) string {
+		purl, _, _ := strings.Cut(c.PURL, "#")
+		purl, _, _ = strings.Cut(purl, "?")
+		return purl
+	})
+
+	// PackageIdentity identifies a component by the type, namespace and name
+	// of its purl, leaving out the version, so different versions of a
+	// package have the same identity. It suits comparing a component over
+	// time rather than across formats.
+	PackageIdentity IdentityResolver = IdentityResolverFunc(func(c ComponentDescriptor) string {
+		p, err := parsePackageURL(c.PURL)
+		if err != nil {
+			return ""
+		}
+		if p.Namespace != "" {
+			return "pkg:" + p.Type + "/" + p.Namespace + "/" + p.Name
+		}
+		return "pkg:" + p.Type + "/" + p.Name
+	})
+
+	// CPEIdentity identifies a component by its CPE.
+	CPEIdentity IdentityResolver = IdentityResolverFunc(func(c ComponentDescriptor) string {
+		return c.CPE
+	})
+
+	// NameVersionIdentity identifies a component by its name and version,
+	// comparing names as the component naming check does: ignoring case,
+	// treating "-", "_" and "." alike, and with the group in either order.
+	NameVersionIdentity IdentityResolver = IdentityResolverFunc(func(c ComponentDescriptor) string {
+		name := normalizedComponentName(c.Group, c.Name)
+		if name == "" {
+			return ""
+		}
+		return name + "@" + c.Version
+	})
+
+	// NameIdentity identifies a component by its name alone, compared as by
+	// NameVersionIdentity, so different versions of a package have the same
+	// identity.
+	NameIdentity IdentityResolver = IdentityResolverFunc(func(c ComponentDescriptor) string {
+		return normalizedComponentName(c.Group, c.Name)
+	})
+)
+
+// FirstIdentity returns a resolver that tries each resolver in turn and uses
+// the first identity found.
+//
+// Example:
+//
+//	// match on purl, then on an in-house build ID property
+//	resolver := FirstIdentity(PURLIdentity, IdentityResolverFunc(func(c ComponentDescriptor) string {
+//	    return buildIDs[c.Name]
+//	}))
+func FirstIdentity(resolvers ...IdentityResolver) IdentityResolver {
+	return IdentityResolverFunc(func(c ComponentDescriptor) string {
+		for _, resolver := range resolvers {
+			if identity := resolver.ResolveIdentity(c); identity != "" {
+				return identity
+			}
+		}
+		return ""
+	})
+}
+
+// DefaultIdentityResolver returns the resolver used unless
+// `WithIdentityResolver` is set: purl first, then CPE, then name and version.
+func DefaultIdentityResolver() IdentityResolver {
+	return FirstIdentity(PURLIdentity, CPEIdentity, NameVersionIdentity)
+}
+
+// descriptor returns the view of a component passed to identity resolvers.
+func (c sbomComponent) descriptor() ComponentDescriptor {
+	return ComponentDescriptor{Name: c.Name, Version: c.Version, Group: c.Group, PURL: c.PURL, CPE: c.CPE, Licenses: c.Licenses, Evidence: c.Evidence}
+}
@@ -0,0 +1,118 @@
+//go:build !sbomvalidator_no_cyclonedx && !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIdentityResolvers(t *testing.T) {
+	component := ComponentDescriptor{
+		Name:    "Jackson_Core",
+		Version: "2.17.0",
+		Group:   "com.fasterxml.jackson.core",
+		PURL:    "pkg:maven/com.fasterxml.jackson.core/jackson-core@2.17.0?type=jar#src",
+		CPE:     "cpe:2.3:a:fasterxml:jackson-core:2.17.0:*:*:*:*:*:*:*",
+	}
+
+	tests := []struct {
+		name      string
+		resolver  IdentityResolver
+		component ComponentDescriptor
+		want      string
+	}{
+		{name: "purl without qualifiers", resolver: PURLIdentity, component: component, want: "pkg:maven/com.fasterxml.jackson.core/jackson-core@2.17.0"},
+		{name: "purl without version", resolver: PackageIdentity, component: component, want: "pkg:maven/com.fasterxml.jackson.core/jackson-core"},
+		{name: "purl without namespace", resolver: PackageIdentity, component: ComponentDescriptor{PURL: "pkg:npm/left-pad@1.3.0"}, want: "pkg:npm/left-pad"},
+		{name: "No purl", resolver: PackageIdentity, component: ComponentDescriptor{Name: "left-pad"}, want: ""},
+		{name: "CPE", resolver: CPEIdentity, component: component, want: component.CPE},
+		{name: "Name and version", resolver: NameVersionIdentity, component: component, want: "com-fasterxml-jackson-core/jackson-core@2.17.0"},
+		{name: "Name", resolver: NameIdentity, component: component, want: "com-fasterxml-jackson-core/jackson-core"},
+		{name: "No name", resolver: NameVersionIdentity, component: ComponentDescriptor{Version: "1.0"}, want: ""},
+		{name: "Default prefers purl", resolver: DefaultIdentityResolver(), component: component, want: "pkg:maven/com.fasterxml.jackson.core/jackson-core@2.17.0"},
+		{
+			name:      "Default falls back to CPE",
+			resolver:  DefaultIdentityResolver(),
+			component: ComponentDescriptor{Name: "openssl", CPE: "cpe:/a:openssl:openssl:3.0.13"},
+			want:      "cpe:/a:openssl:openssl:3.0.13",
+		},
+		{
+			name:      "Default falls back to name and version",
+			resolver:  DefaultIdentityResolver(),
+			component: ComponentDescriptor{Name: "Left_Pad", Version: "1.3.0"},
+			want:      "left-pad@1.3.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resolver.ResolveIdentity(tt.component); got != tt.want {
+				t.Errorf("ResolveIdentity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareConversionWithIdentityResolver(t *testing.T) {
+	// the output renamed the package and its purl, e.g. after a registry move
+	input := spdxDocument(spdxPackage("a", "left-pad", "MIT"))
+	output := spdxDocument(spdxPackage("a", "@acme/left-pad", "MIT"))
+
+	report, err := CompareConversion(output, [][]byte{input})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Outcome != ComparisonDataLoss {
+		t.Fatalf("expected the renamed package to be reported lost, got %s", report.Outcome)
+	}
+
+	byBaseName := IdentityResolverFunc(func(c ComponentDescriptor) string {
+		return c.Name[strings.LastIndex(c.Name, "/")+1:] + "@" + c.Version
+	})
+	report, err = CompareConversion(output, [][]byte{input}, WithIdentityResolver(byBaseName))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Outcome != ComparisonClean {
+		t.Errorf("expected the custom resolver to match the package, got %s: %+v", report.Outcome, report.Losses)
+	}
+}
+
+func TestExtractComponents(t *testing.T) {
+	components, err := ExtractComponents(spdxDocument(spdxPackage("a", "left-pad", "MIT")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := ComponentDescriptor{Name: "left-pad", Version: "1.0.0", PURL: "pkg:npm/left-pad@1.0.0", Licenses: []string{"MIT"}}
+	if len(components) != 1 || !reflect.DeepEqual(components[0], want) {
+		t.Errorf("ExtractComponents() = %+v, want %+v", components, want)
+	}
+
+	components, err = ExtractComponents(protoBOM())
+	if err != nil || len(components) != 1 || components[0].PURL != "pkg:npm/left-pad@1.3.0" {
+		t.Errorf("ExtractComponents() = %+v, %v for a protobuf BOM", components, err)
+	}
+
+	if _, err := ExtractComponents([]byte("not an SBOM")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestDecodeSBOM(t *testing.T) {
+	jsonContent, err := DecodeSBOM(protoBOM())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sbomType, err := Detect(bytes.NewReader(jsonContent)); err != nil || sbomType.Format != FormatCycloneDX || !json.Valid(jsonContent) {
+		t.Errorf("DecodeSBOM() = %s, detected %v, %v", jsonContent, sbomType, err)
+	}
+
+	spdx := spdxDocument(spdxPackage("a", "left-pad", "MIT"))
+	if jsonContent, err := DecodeSBOM(spdx); err != nil || !bytes.Equal(jsonContent, spdx) {
+		t.Errorf("DecodeSBOM() changed JSON input: %s, %v", jsonContent, err)
+	}
+}
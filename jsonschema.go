@@ -0,0 +1,44 @@
+package sbomvalidator
+
+// JSONSchema is a compiled JSON schema, for validating documents published
+// alongside SBOMs (e.g., VEX statements) the way SBOMs are validated. It is
+// safe for concurrent use.
+type JSONSchema struct {
+	compiled *compiledSchema
+}
+
+// CompileJSONSchema compiles a JSON schema of draft 4, 6, 7, 2019-09 or
+// 2020-12, as its `$schema` declares, or of draft 7 if it declares none.
+//
+// Parameters:
+//   - schema: The JSON schema.
+//
+// Returns:
+//   - The compiled schema.
+//   - An error if the schema is malformed or a `$ref` cannot be resolved.
+//
+// Example:
+//
+//	schema, err := CompileJSONSchema(schemaData)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	findings, err := schema.Validate(document)
+func CompileJSONSchema(schema []byte) (*JSONSchema, error) {
+	compiled, err := newCompiledSchema(string(schema))
+	if err != nil {
+		return nil, err
+	}
+	return &JSONSchema{compiled: compiled}, nil
+}
+
+// Validate validates a JSON document against the schema and returns a
+// finding of rule RuleSchema per violation, or none if the document
+// conforms. The error is only set if the document is not JSON.
+func (s *JSONSchema) Validate(document []byte) ([]Finding, error) {
+	findings, err := s.compiled.validate(string(document))
+	for i := range findings {
+		findings[i].renderMessage()
+	}
+	return findings, err
+}
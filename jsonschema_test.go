@@ -0,0 +1,43 @@
+package sbomvalidator
+
+import "testing"
+
+func TestCompileJSONSchema(t *testing.T) {
+	schema, err := CompileJSONSchema([]byte(`{"type": "object", "required": ["id"], "properties": {"id": {"type": "string"}}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		document     string
+		wantFindings int
+		expectErr    bool
+	}{
+		{name: "Valid", document: `{"id": "a"}`},
+		{name: "Invalid", document: `{"id": 1}`, wantFindings: 1},
+		{name: "Missing", document: `{}`, wantFindings: 1},
+		{name: "Not JSON", document: `{`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := schema.Validate([]byte(tt.document))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Validate() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if len(findings) != tt.wantFindings {
+				t.Errorf("findings = %+v, want %d", findings, tt.wantFindings)
+			}
+			for _, f := range findings {
+				if f.Rule != RuleSchema || f.Level != LevelError {
+					t.Errorf("unexpected finding %+v", f)
+				}
+			}
+		})
+	}
+
+	if _, err := CompileJSONSchema([]byte(`{"type": 1}`)); err == nil {
+		t.Error("expected an error for a malformed schema")
+	}
+}
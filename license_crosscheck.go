@@ -0,0 +1,273 @@
+package sbomvalidator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LicenseLookup resolves the licenses a package registry reports for a
+// package, identified by its purl.
+//
+// Implementations return `ErrUnsupportedRegistry` for purl types they do not
+// know how to query.
+type LicenseLookup interface {
+	Licenses(purl string) ([]string, error)
+}
+
+// ErrUnsupportedRegistry is returned by a LicenseLookup for purl types it
+// cannot resolve.
+var ErrUnsupportedRegistry = errors.New("unsupported package registry")
+
+// RegistryLicenseLookup is a LicenseLookup that queries public ecosystem
+// registries over HTTP. It currently supports npm, PyPI and crates.io.
+//
+// The base URLs can be overridden to point at mirrors.
+type RegistryLicenseLookup struct {
+	Client        *http.Client
+	NPMBaseURL    string
+	PyPIBaseURL   string
+	CratesBaseURL string
+}
+
+// NewRegistryLicenseLookup returns a RegistryLicenseLookup configured for the
+// public npm, PyPI and crates.io registries.
+func NewRegistryLicenseLookup() *RegistryLicenseLookup {
+	return &RegistryLicenseLookup{
+		Client:        &http.Client{Timeout: 10 * time.Second},
+		NPMBaseURL:    "https://registry.npmjs.org",
+		PyPIBaseURL:   "https://pypi.org/pypi",
+		CratesBaseURL: "https://crates.io/api/v1/crates",
+	}
+}
+
+// Licenses returns the licenses the registry reports for the package.
+func (r *RegistryLicenseLookup) Licenses(purl string) ([]string, error) {
+	p, err := parsePackageURL(purl)
+	if err != nil {
+		return nil, err
+	}
+	if p.Version == "" {
+		return nil, fmt.Errorf("purl %q has no version", purl)
+	}
+
+	switch p.Type {
+	case "npm":
+		name := p.Name
+		if p.Namespace != "" {
+			name = p.Namespace + "/" + p.Name
+		}
+		var body struct {
+			License interface{} `json:"license"`
+		}
+		if err := r.getJSON(fmt.Sprintf("%s/%s/%s", r.NPMBaseURL, name, url.PathEscape(p.Version)), &body); err != nil {
+			return nil, err
+		}
+		switch license := body.License.(type) {
+		case string:
+			return []string{license}, nil
+		case map[string]interface{}:
+			if t, ok := license["type"].(string); ok {
+				return []string{t}, nil
+			}
+		}
+		return nil, nil
+
+	case "pypi":
+		var body struct {
+			Info struct {
+				License           string `json:"license"`
+				LicenseExpression string `json:"license_expression"`
+			} `json:"info"`
+		}
+		if err := r.getJSON(fmt.Sprintf("%s/%s/%s/json", r.PyPIBaseURL, url.PathEscape(p.Name), url.PathEscape(p.Version)), &body); err != nil {
+			return nil, err
+		}
+		if body.Info.LicenseExpression != "" {
+			return []string{body.Info.LicenseExpression}, nil
+		}
+		// the free-text license field sometimes holds the whole license text
+		if body.Info.License != "" && !strings.Contains(body.Info.License, "\n") {
+			return []string{body.Info.License}, nil
+		}
+		return nil, nil
+
+	case "cargo":
+		var body struct {
+			Version struct {
+				License string `json:"license"`
+			} `json:"version"`
+		}
+		if err := r.getJSON(fmt.Sprintf("%s/%s/%s", r.CratesBaseURL, url.PathEscape(p.Name), url.PathEscape(p.Version)), &body); err != nil {
+			return nil, err
+		}
+		if body.Version.License != "" {
+			return []string{body.Version.License}, nil
+		}
+		return nil, nil
+	}
+
+	return nil, ErrUnsupportedRegistry
+}
+
+func (r *RegistryLicenseLookup) getJSON(endpoint string, v interface{}) error {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	// crates.io rejects requests without a User-Agent
+	req.Header.Set("User-Agent", "sbom-validator")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry returned %s for %s", resp.Status, endpoint)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// LicenseDiscrepancy describes a component whose declared licenses share
+// nothing with the licenses reported by its package registry.
+type LicenseDiscrepancy struct {
+	Component string   `json:"component"`
+	PURL      string   `json:"purl"`
+	Declared  []string `json:"declared"`
+	Registry  []string `json:"registry"`
+}
+
+// LicenseCrossCheckResult represents the outcome of comparing declared
+// component licenses with registry metadata.
+//
+// `Checked` counts components whose licenses could be compared. Components
+// with an unsupported registry, or whose lookup failed or returned no license,
+// are counted in `Skipped`. Components without a purl or declared license are
+// not looked up at all.
+type LicenseCrossCheckResult struct {
+	Checked       int                  `json:"checked"`
+	Skipped       int                  `json:"skipped"`
+	Discrepancies []LicenseDiscrepancy `json:"discrepancies,omitempty"`
+}
+
+// CrossCheckLicenses compares the licenses declared for components in an SBOM
+// with the licenses reported by their package registries.
+//
+// Because this requires network access, it only looks at a sample of the
+// components: up to sampleSize components spread evenly across the SBOM (all
+// components when sampleSize is zero or negative). A component is reported as a
+// discrepancy when none of its declared license identifiers appear in the
+// registry's licenses, which usually means the generating tool guessed.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM JSON data.
+//   - lookup: The registry client, typically `NewRegistryLicenseLookup()`.
+//   - sampleSize: The maximum number of components to look up.
+//
+// Returns:
+//   - A LicenseCrossCheckResult describing any discrepancies found.
+//   - An error if the SBOM cannot be parsed or its type is unsupported.
+func CrossCheckLicenses(sbomContent []byte, lookup LicenseLookup, sampleSize int) (*LicenseCrossCheckResult, error) {
+	doc, err := parseSBOMDocument(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+	return crossCheckLicenses(context.Background(), doc, lookup, sampleSize)
+}
+
+// crossCheckLicenses cross-checks the licenses of a parsed SBOM, stopping
+// early when ctx is done.
+func crossCheckLicenses(ctx context.Context, doc *sbomDocument, lookup LicenseLookup, sampleSize int) (*LicenseCrossCheckResult, error) {
+	obj, sbomType := doc.obj, doc.sbomType
+
+	var candidates []sbomComponent
+	for _, component := range extractComponents(obj, sbomType) {
+		if component.PURL != "" && len(component.Licenses) > 0 {
+			candidates = append(candidates, component)
+		}
+	}
+
+	result := &LicenseCrossCheckResult{}
+	for _, component := range sampleComponents(candidates, sampleSize) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		registryLicenses, err := lookup.Licenses(component.PURL)
+		if err != nil || len(registryLicenses) == 0 {
+			result.Skipped++
+			continue
+		}
+
+		result.Checked++
+		if !licensesOverlap(component.Licenses, registryLicenses) {
+			result.Discrepancies = append(result.Discrepancies, LicenseDiscrepancy{
+				Component: component.Name,
+				PURL:      component.PURL,
+				Declared:  component.Licenses,
+				Registry:  registryLicenses,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// sampleComponents picks up to n components spread evenly across the list so
+// the result is deterministic for a given SBOM.
+func sampleComponents(components []sbomComponent, n int) []sbomComponent {
+	if n <= 0 || n >= len(components) {
+		return components
+	}
+
+	sample := make([]sbomComponent, 0, n)
+	for i := 0; i < n; i++ {
+		sample = append(sample, components[i*len(components)/n])
+	}
+	return sample
+}
+
+// licensesOverlap reports whether any license identifier appears in both lists.
+// License expressions are split into their individual identifiers.
+func licensesOverlap(a, b []string) bool {
+	ids := map[string]bool{}
+	for _, id := range licenseIdentifiers(a) {
+		ids[id] = true
+	}
+	for _, id := range licenseIdentifiers(b) {
+		if ids[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// licenseIdentifiers splits license expressions into upper-cased identifiers,
+// dropping operators and parentheses.
+func licenseIdentifiers(licenses []string) []string {
+	var ids []string
+	for _, license := range licenses {
+		fields := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(license))
+		for _, field := range fields {
+			upper := strings.ToUpper(field)
+			if upper == "AND" || upper == "OR" || upper == "WITH" {
+				continue
+			}
+			ids = append(ids, upper)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,126 @@
+package sbomvalidator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCrossCheckLicenses(t *testing.T) {
+	lookup := fakeLicenseLookup{
+		"pkg:npm/left-pad@1.3.0":   {"WTFPL"},
+		"pkg:npm/lodash@4.17.21":   {"MIT"},
+		"pkg:cargo/serde@1.0.190":  {"MIT OR Apache-2.0"},
+		"pkg:pypi/requests@2.31.0": {"Apache-2.0"},
+	}
+
+	tests := []struct {
+		name              string
+		sbomData          string
+		sampleSize        int
+		wantChecked       int
+		wantSkipped       int
+		wantDiscrepancies []string
+	}{
+		{
+			name: "CycloneDX with one guessed license",
+			sbomData: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+				{"name": "left-pad", "purl": "pkg:npm/left-pad@1.3.0", "licenses": [{"license": {"id": "MIT"}}]},
+				{"name": "lodash", "purl": "pkg:npm/lodash@4.17.21", "licenses": [{"license": {"id": "MIT"}}]},
+				{"name": "serde", "purl": "pkg:cargo/serde@1.0.190", "licenses": [{"expression": "Apache-2.0"}]},
+				{"name": "unknown", "purl": "pkg:gem/rails@7.0.0", "licenses": [{"license": {"id": "MIT"}}]},
+				{"name": "no-license", "purl": "pkg:npm/lodash@4.17.21"}
+			]}`,
+			wantChecked:       3,
+			wantSkipped:       1,
+			wantDiscrepancies: []string{"left-pad"},
+		},
+		{
+			name: "SPDX package",
+			sbomData: `{"spdxVersion": "SPDX-2.3", "packages": [
+				{"name": "requests", "licenseDeclared": "MIT",
+				 "externalRefs": [{"referenceType": "purl", "referenceLocator": "pkg:pypi/requests@2.31.0"}]}
+			]}`,
+			wantChecked:       1,
+			wantDiscrepancies: []string{"requests"},
+		},
+		{
+			name: "Sample size limits lookups",
+			sbomData: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+				{"name": "left-pad", "purl": "pkg:npm/left-pad@1.3.0", "licenses": [{"license": {"id": "WTFPL"}}]},
+				{"name": "lodash", "purl": "pkg:npm/lodash@4.17.21", "licenses": [{"license": {"id": "MIT"}}]}
+			]}`,
+			sampleSize:  1,
+			wantChecked: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CrossCheckLicenses([]byte(tt.sbomData), lookup, tt.sampleSize)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Checked != tt.wantChecked {
+				t.Errorf("Checked = %d, want %d", result.Checked, tt.wantChecked)
+			}
+			if result.Skipped != tt.wantSkipped {
+				t.Errorf("Skipped = %d, want %d", result.Skipped, tt.wantSkipped)
+			}
+			if len(result.Discrepancies) != len(tt.wantDiscrepancies) {
+				t.Fatalf("got %d discrepancies, want %d: %+v", len(result.Discrepancies), len(tt.wantDiscrepancies), result.Discrepancies)
+			}
+			for i, d := range result.Discrepancies {
+				if d.Component != tt.wantDiscrepancies[i] {
+					t.Errorf("discrepancy %d = %q, want %q", i, d.Component, tt.wantDiscrepancies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryLicenseLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/npm/@angular/core/16.0.0":
+			w.Write([]byte(`{"license": "MIT"}`))
+		case "/pypi/requests/2.31.0/json":
+			w.Write([]byte(`{"info": {"license": "Apache 2.0"}}`))
+		case "/crates/serde/1.0.190":
+			w.Write([]byte(`{"version": {"license": "MIT OR Apache-2.0"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	lookup := NewRegistryLicenseLookup()
+	lookup.NPMBaseURL = server.URL + "/npm"
+	lookup.PyPIBaseURL = server.URL + "/pypi"
+	lookup.CratesBaseURL = server.URL + "/crates"
+
+	tests := []struct {
+		purl      string
+		want      string
+		expectErr bool
+	}{
+		{purl: "pkg:npm/%40angular/core@16.0.0", want: "MIT"},
+		{purl: "pkg:pypi/requests@2.31.0", want: "Apache 2.0"},
+		{purl: "pkg:cargo/serde@1.0.190", want: "MIT OR Apache-2.0"},
+		{purl: "pkg:npm/missing@1.0.0", expectErr: true},
+		{purl: "pkg:gem/rails@7.0.0", expectErr: true},
+		{purl: "pkg:npm/no-version", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.purl, func(t *testing.T) {
+			got, err := lookup.Licenses(tt.purl)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Licenses(%q) error = %v, expectErr %v", tt.purl, err, tt.expectErr)
+			}
+			if !tt.expectErr && (len(got) != 1 || got[0] != tt.want) {
+				t.Errorf("Licenses(%q) = %v, want [%s]", tt.purl, got, tt.want)
+			}
+		})
+	}
+}
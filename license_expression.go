@@ -0,0 +1,412 @@
+package sbomvalidator
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// LicenseListVersion is the version of the SPDX License List embedded in the
+// build, against which license identifiers are checked.
+const LicenseListVersion = "3.25"
+
+//go:embed licenses/spdx.txt
+var spdxLicenseListData string
+
+// deprecatedLicense is a deprecated SPDX license identifier and the
+// identifier or expression that replaces it, if any.
+type deprecatedLicense struct {
+	id          string
+	replacement string
+}
+
+// spdxLicenseList holds the embedded SPDX License List, keyed by lower-cased
+// identifier since identifiers are matched case-insensitively.
+type spdxLicenseList struct {
+	licenses   map[string]string
+	deprecated map[string]deprecatedLicense
+	exceptions map[string]string
+}
+
+var (
+	licenseListOnce sync.Once
+	licenseList     spdxLicenseList
+)
+
+// loadLicenseList parses the embedded SPDX License List on first use.
+func loadLicenseList() *spdxLicenseList {
+	licenseListOnce.Do(func() {
+		licenseList = spdxLicenseList{
+			licenses:   map[string]string{},
+			deprecated: map[string]deprecatedLicense{},
+			exceptions: map[string]string{},
+		}
+		section := ""
+		for _, line := range strings.Split(spdxLicenseListData, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if strings.HasPrefix(line, "[") {
+				section = strings.Trim(line, "[]")
+				continue
+			}
+			id, replacement, _ := strings.Cut(line, " ")
+			switch section {
+			case "licenses":
+				licenseList.licenses[strings.ToLower(id)] = id
+			case "deprecated":
+				licenseList.deprecated[strings.ToLower(id)] = deprecatedLicense{id: id, replacement: replacement}
+			case "exceptions":
+				licenseList.exceptions[strings.ToLower(id)] = id
+			}
+		}
+	})
+	return &licenseList
+}
+
+// licenseRefPattern matches user-defined license references, optionally
+// prefixed with the external document defining them.
+var licenseRefPattern = regexp.MustCompile(`^(DocumentRef-[A-Za-z0-9.-]+:)?LicenseRef-[A-Za-z0-9.-]+$`)
+
+// licenseToken is a word or parenthesis of a license expression, with its
+// offset in the expression.
+type licenseToken struct {
+	text  string
+	start int
+}
+
+// licenseTerm is a license of an expression, with the "or later" suffix and
+// the exception applied to it, if any.
+type licenseTerm struct {
+	license   licenseToken
+	orLater   bool
+	exception *licenseToken
+}
+
+// tokenizeLicenseExpression splits a license expression into words and
+// parentheses.
+func tokenizeLicenseExpression(expression string) []licenseToken {
+	var tokens []licenseToken
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			tokens = append(tokens, licenseToken{text: expression[start:end], start: start})
+			start = -1
+		}
+	}
+	for i, r := range expression {
+		switch {
+		case r == '(' || r == ')':
+			flush(i)
+			tokens = append(tokens, licenseToken{text: string(r), start: i})
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush(i)
+		case start < 0:
+			start = i
+		}
+	}
+	flush(len(expression))
+	return tokens
+}
+
+// licenseExpressionParser is a recursive descent parser for the SPDX license
+// expression grammar (SPDX 2.3 Annex D):
+//
+//	compound = and *("OR" and)
+//	and      = with *("AND" with)
+//	with     = simple ["WITH" exception] / "(" compound ")"
+//	simple   = license-id ["+"] / license-ref
+type licenseExpressionParser struct {
+	tokens []licenseToken
+	pos    int
+	terms  []licenseTerm
+}
+
+// parseLicenseExpression returns the licenses of an SPDX license expression,
+// or an error if the expression is malformed.
+func parseLicenseExpression(expression string) ([]licenseTerm, error) {
+	p := &licenseExpressionParser{tokens: tokenizeLicenseExpression(expression)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("license expression is empty")
+	}
+	if err := p.compound(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.unexpected("AND, OR or WITH")
+	}
+	return p.terms, nil
+}
+
+func (p *licenseExpressionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+func (p *licenseExpressionParser) compound() error {
+	if err := p.and(); err != nil {
+		return err
+	}
+	for p.peek() == "OR" {
+		p.pos++
+		if err := p.and(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *licenseExpressionParser) and() error {
+	if err := p.with(); err != nil {
+		return err
+	}
+	for p.peek() == "AND" {
+		p.pos++
+		if err := p.with(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *licenseExpressionParser) with() error {
+	switch p.peek() {
+	case "":
+		return fmt.Errorf("license expression ends where a license was expected")
+	case "(":
+		p.pos++
+		if err := p.compound(); err != nil {
+			return err
+		}
+		if p.peek() != ")" {
+			return p.unexpected(`")"`)
+		}
+		p.pos++
+		return nil
+	case ")", "AND", "OR", "WITH":
+		return p.unexpected("a license")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+	term := licenseTerm{license: token}
+	if strings.HasSuffix(token.text, "+") && !licenseRefPattern.MatchString(token.text) {
+		term.license.text = strings.TrimSuffix(token.text, "+")
+		term.orLater = true
+	}
+	if p.peek() == "WITH" {
+		p.pos++
+		switch p.peek() {
+		case "", "(", ")", "AND", "OR", "WITH":
+			return p.unexpected("a license exception")
+		}
+		exception := p.tokens[p.pos]
+		term.exception = &exception
+		p.pos++
+	}
+	p.terms = append(p.terms, term)
+	return nil
+}
+
+// unexpected returns the error for the current token when something else was
+// expected, pointing out lower-case operators, which SPDX does not allow.
+func (p *licenseExpressionParser) unexpected(expected string) error {
+	if p.pos >= len(p.tokens) {
+		return fmt.Errorf("license expression ends where %s was expected", expected)
+	}
+	token := p.tokens[p.pos].text
+	switch operator := strings.ToUpper(token); operator {
+	case "AND", "OR", "WITH":
+		return fmt.Errorf("operator %q must be written %q", token, operator)
+	}
+	return fmt.Errorf("unexpected %q where %s was expected", token, expected)
+}
+
+// checkLicenseExpression checks an SPDX license expression against the
+// embedded SPDX License List.
+//
+// Returns an error if the expression is malformed, otherwise a warning
+// message per unknown or deprecated identifier, and the expression with every
+// deprecated identifier that has a replacement replaced ("" if there are
+// none). definedRefs, when not nil, are the LicenseRef identifiers the
+// document defines; other local LicenseRefs are reported.
+func checkLicenseExpression(expression string, definedRefs map[string]bool) (warnings []string, fixed string, err error) {
+	terms, err := parseLicenseExpression(expression)
+	if err != nil {
+		return nil, "", err
+	}
+
+	list := loadLicenseList()
+	var replacements []licenseReplacement
+	for _, term := range terms {
+		id := term.license.text
+		switch {
+		case licenseRefPattern.MatchString(id):
+			if definedRefs != nil && strings.HasPrefix(id, "LicenseRef-") && !definedRefs[id] {
+				warnings = append(warnings, fmt.Sprintf("license %q is not defined in hasExtractedLicensingInfos", id))
+			}
+		case term.orLater && list.deprecated[strings.ToLower(id+"+")].id != "":
+			// GPL-2.0+ and the like are deprecated identifiers in their own right
+			deprecated := list.deprecated[strings.ToLower(id+"+")]
+			warnings = append(warnings, deprecatedLicenseWarning(deprecated))
+			if deprecated.replacement != "" && term.exception == nil {
+				replacements = append(replacements, licenseReplacement{term.license.start, len(id) + 1, deprecated.replacement})
+			}
+		case list.deprecated[strings.ToLower(id)].id != "":
+			deprecated := list.deprecated[strings.ToLower(id)]
+			warnings = append(warnings, deprecatedLicenseWarning(deprecated))
+			if deprecated.replacement != "" && !term.orLater && (term.exception == nil || !strings.Contains(deprecated.replacement, " WITH ")) {
+				replacements = append(replacements, licenseReplacement{term.license.start, len(id), deprecated.replacement})
+			}
+		case list.licenses[strings.ToLower(id)] == "":
+			if exception, ok := list.exceptions[strings.ToLower(id)]; ok {
+				warnings = append(warnings, fmt.Sprintf("%q is a license exception, not a license; use it after WITH", exception))
+			} else {
+				warnings = append(warnings, fmt.Sprintf("license %q is not on the SPDX License List %s", id, LicenseListVersion))
+			}
+		}
+
+		if term.exception != nil {
+			exception := term.exception.text
+			if _, ok := list.exceptions[strings.ToLower(exception)]; !ok {
+				warnings = append(warnings, fmt.Sprintf("license exception %q is not on the SPDX License List %s", exception, LicenseListVersion))
+			}
+		}
+	}
+
+	if len(replacements) > 0 {
+		fixed = applyLicenseReplacements(expression, replacements)
+	}
+	return warnings, fixed, nil
+}
+
+func deprecatedLicenseWarning(deprecated deprecatedLicense) string {
+	if deprecated.replacement == "" {
+		return fmt.Sprintf("license %q is deprecated", deprecated.id)
+	}
+	return fmt.Sprintf("license %q is deprecated; use %q", deprecated.id, deprecated.replacement)
+}
+
+// licenseReplacement replaces the n bytes of an expression at start.
+type licenseReplacement struct {
+	start, n int
+	text     string
+}
+
+// applyLicenseReplacements applies replacements, given in expression order.
+func applyLicenseReplacements(expression string, replacements []licenseReplacement) string {
+	var b strings.Builder
+	last := 0
+	for _, r := range replacements {
+		b.WriteString(expression[last:r.start])
+		b.WriteString(r.text)
+		last = r.start + r.n
+	}
+	b.WriteString(expression[last:])
+	return b.String()
+}
+
+// checkLicenseExpressions checks the license expressions of an SBOM: the
+// `expression` of CycloneDX licenses (of the BOM metadata and of every
+// component), and the `licenseDeclared` and `licenseConcluded` of SPDX
+// packages and files.
+//
+// Returns a finding per expression that is malformed (an error) or that
+// references unknown or deprecated identifiers (a warning). Deprecated
+// identifiers with a replacement carry a fix.
+func checkLicenseExpressions(obj map[string]interface{}, sbomType string) []Finding {
+	var findings []Finding
+	check := func(path, expression string, definedRefs map[string]bool) {
+		warnings, fixed, err := checkLicenseExpression(expression, definedRefs)
+		if err != nil {
+			findings = append(findings, Finding{Level: LevelError, Rule: RuleLicenseExpression, Path: path, Pointer: jsonPointer(path),
+				Message: fmt.Sprintf("malformed license expression %q: %v", expression, err)})
+			return
+		}
+		for _, warning := range warnings {
+			f := Finding{Level: LevelWarning, Rule: RuleLicenseExpression, Path: path, Pointer: jsonPointer(path), Message: warning}
+			if fixed != "" {
+				f.Fix = []PatchOperation{{Op: PatchReplace, Path: jsonPointer(path), Value: fixed}}
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	if sbomType == SBOM_CYCLONEDX {
+		checkLicenses := func(path string, holder map[string]interface{}) {
+			licenses, _ := holder["licenses"].([]interface{})
+			for i, l := range licenses {
+				license, _ := l.(map[string]interface{})
+				if expression, ok := license["expression"].(string); ok {
+					check(fmt.Sprintf("%slicenses.%d.expression", path, i), expression, nil)
+				}
+			}
+		}
+		if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+			checkLicenses("metadata.", metadata)
+		}
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			checkLicenses(path+".", component)
+		})
+		walkCycloneDXTools(obj, func(path string, tool map[string]interface{}) {
+			checkLicenses(path+".", tool)
+		})
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		definedRefs := map[string]bool{}
+		infos, _ := obj["hasExtractedLicensingInfos"].([]interface{})
+		for _, i := range infos {
+			info, _ := i.(map[string]interface{})
+			if id, ok := info["licenseId"].(string); ok {
+				definedRefs[id] = true
+			}
+		}
+
+		for _, collection := range []string{"packages", "files"} {
+			elements, _ := obj[collection].([]interface{})
+			for i, e := range elements {
+				element, _ := e.(map[string]interface{})
+				for _, field := range []string{"licenseDeclared", "licenseConcluded"} {
+					// NONE and NOASSERTION are valid values, not expressions
+					if expression, _ := element[field].(string); expression != "" && expression != "NONE" && expression != "NOASSERTION" {
+						check(fmt.Sprintf("%s.%d.%s", collection, i, field), expression, definedRefs)
+					}
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+// ValidateLicenseExpression checks an SPDX license expression: it must be
+// well-formed, and every license and exception identifier must be on the
+// embedded SPDX License List (see `LicenseListVersion`) and not deprecated.
+// LicenseRef identifiers are accepted as they are.
+//
+// Parameters:
+//   - expression: The license expression, e.g. "MIT OR Apache-2.0".
+//
+// Returns:
+//   - An error describing the first problem found, or nil.
+//
+// Example:
+//
+//	if err := ValidateLicenseExpression("GPL-2.0+ OR MIT"); err != nil {
+//	    fmt.Println(err) // license "GPL-2.0+" is deprecated; use "GPL-2.0-or-later"
+//	}
+func ValidateLicenseExpression(expression string) error {
+	warnings, _, err := checkLicenseExpression(expression, nil)
+	if err != nil {
+		return err
+	}
+	if len(warnings) > 0 {
+		return errors.New(warnings[0])
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckLicenseExpression(t *testing.T) {
+	tests := []struct {
+		name         string
+		expression   string
+		wantErr      string
+		wantWarnings []string
+		wantFixed    string
+	}{
+		{name: "Single license", expression: "MIT"},
+		{name: "Case-insensitive identifiers", expression: "apache-2.0 OR mit"},
+		{name: "Compound", expression: "(MIT OR Apache-2.0) AND BSD-3-Clause"},
+		{name: "Exception", expression: "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{name: "Or later", expression: "MPL-1.1+"},
+		{name: "LicenseRef", expression: "LicenseRef-acme AND DocumentRef-other:LicenseRef-x"},
+		{name: "Empty", expression: "  ", wantErr: "license expression is empty"},
+		{name: "Lower-case operator", expression: "MIT or Apache-2.0", wantErr: `operator "or" must be written "OR"`},
+		{name: "Missing operator", expression: "MIT Apache-2.0", wantErr: `unexpected "Apache-2.0" where AND, OR or WITH was expected`},
+		{name: "Dangling operator", expression: "MIT AND", wantErr: "license expression ends where a license was expected"},
+		{name: "Unbalanced parentheses", expression: "(MIT OR ISC", wantErr: `license expression ends where ")" was expected`},
+		{name: "Missing exception", expression: "GPL-2.0-only WITH", wantErr: "license expression ends where a license exception was expected"},
+		{
+			name:         "Unknown license",
+			expression:   "MIT OR Acme-Proprietary",
+			wantWarnings: []string{`license "Acme-Proprietary" is not on the SPDX License List ` + LicenseListVersion},
+		},
+		{
+			name:         "Unknown exception",
+			expression:   "GPL-2.0-only WITH Acme-exception",
+			wantWarnings: []string{`license exception "Acme-exception" is not on the SPDX License List ` + LicenseListVersion},
+		},
+		{
+			name:         "Exception used as a license",
+			expression:   "Classpath-exception-2.0",
+			wantWarnings: []string{`"Classpath-exception-2.0" is a license exception, not a license; use it after WITH`},
+		},
+		{
+			name:         "Deprecated licenses",
+			expression:   "(GPL-2.0+ OR LGPL-2.1) AND MIT",
+			wantWarnings: []string{`license "GPL-2.0+" is deprecated; use "GPL-2.0-or-later"`, `license "LGPL-2.1" is deprecated; use "LGPL-2.1-only"`},
+			wantFixed:    "(GPL-2.0-or-later OR LGPL-2.1-only) AND MIT",
+		},
+		{
+			name:         "Deprecated license with an exception",
+			expression:   "GPL-2.0-with-classpath-exception",
+			wantWarnings: []string{`license "GPL-2.0-with-classpath-exception" is deprecated; use "GPL-2.0-only WITH Classpath-exception-2.0"`},
+			wantFixed:    "GPL-2.0-only WITH Classpath-exception-2.0",
+		},
+		{
+			name:         "Deprecated license without a replacement",
+			expression:   "Net-SNMP",
+			wantWarnings: []string{`license "Net-SNMP" is deprecated`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, fixed, err := checkLicenseExpression(tt.expression, nil)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(warnings, "\n") != strings.Join(tt.wantWarnings, "\n") {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+			if fixed != tt.wantFixed {
+				t.Errorf("fixed = %q, want %q", fixed, tt.wantFixed)
+			}
+		})
+	}
+}
+
+func TestCheckLicenseExpressions(t *testing.T) {
+	cyclonedx, _ := parseJSON(`{"bomFormat": "CycloneDX",
+  "metadata": {"licenses": [{"expression": "MIT"}]},
+  "components": [
+    {"name": "a", "licenses": [{"expression": "GPL-3.0"}]},
+    {"name": "b", "licenses": [{"license": {"id": "MIT"}}],
+     "components": [{"name": "c", "licenses": [{"expression": "MIT and ISC"}]}]}
+  ]}`)
+
+	findings := checkLicenseExpressions(cyclonedx, SBOM_CYCLONEDX)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %+v", findings)
+	}
+	if f := findings[0]; f.Level != LevelWarning || f.Path != "components.0.licenses.0.expression" ||
+		len(f.Fix) != 1 || f.Fix[0].Value != "GPL-3.0-only" {
+		t.Errorf("expected a fixable deprecation warning, got %+v", f)
+	}
+	if f := findings[1]; f.Level != LevelError || f.Path != "components.1.components.0.licenses.0.expression" {
+		t.Errorf("expected a malformed expression error, got %+v", f)
+	}
+
+	spdx, _ := parseJSON(strings.Replace(string(spdxDocument(
+		spdxPackage("a", "a", "LicenseRef-acme"),
+		spdxPackage("b", "b", "LicenseRef-undefined"),
+		spdxPackage("c", "c", "NOASSERTION"),
+	)), `"packages"`, `"hasExtractedLicensingInfos": [{"licenseId": "LicenseRef-acme", "extractedText": "..."}],
+  "packages"`, 1))
+
+	findings = checkLicenseExpressions(spdx, SBOM_SPDX)
+	if len(findings) != 1 || findings[0].Path != "packages.1.licenseDeclared" ||
+		findings[0].Message != `license "LicenseRef-undefined" is not defined in hasExtractedLicensingInfos` {
+		t.Errorf("expected one undefined LicenseRef warning, got %+v", findings)
+	}
+}
+
+func TestWithLicenseValidation(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "GPL-2.0"), spdxPackage("b", "b", "MIT OR"))
+
+	result, err := ValidateSBOMDataStructured(sbom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Errorf("expected license expressions not to be checked without the option")
+	}
+
+	result, err = ValidateSBOMDataStructured(sbom, WithLicenseValidation(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid || len(result.Errors()) != 1 || len(result.WarningFindings()) != 1 {
+		t.Fatalf("expected one error and one warning, got %+v", result.Findings)
+	}
+
+	fixed, err := ApplyFixes(sbom, result.Findings)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(fixed), `"GPL-2.0-only"`) {
+		t.Errorf("expected the deprecated license to be replaced, got %s", fixed)
+	}
+}
+
+func TestValidateLicenseExpression(t *testing.T) {
+	if err := ValidateLicenseExpression("MIT OR Apache-2.0"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateLicenseExpression("GPL-2.0+ OR MIT"); err == nil || !strings.Contains(err.Error(), "deprecated") {
+		t.Errorf("expected a deprecation error, got %v", err)
+	}
+	if err := ValidateLicenseExpression("MIT OR"); err == nil {
+		t.Errorf("expected a malformed expression error")
+	}
+}
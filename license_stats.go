@@ -0,0 +1,289 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LicenseCategory classifies a license by the obligations it places on
+// software that uses it.
+type LicenseCategory string
+
+// License categories, from least to most restrictive.
+const (
+	// LicensePermissive licenses, such as MIT or Apache-2.0, allow use in
+	// proprietary software with attribution.
+	LicensePermissive LicenseCategory = "permissive"
+	// LicenseWeakCopyleft licenses, such as LGPL-2.1-only or MPL-2.0,
+	// require changes to the licensed files, but not software using them,
+	// to be shared under the same terms.
+	LicenseWeakCopyleft LicenseCategory = "weak-copyleft"
+	// LicenseCopyleft licenses, such as GPL-3.0-only or AGPL-3.0-only,
+	// require derived works to be shared under the same terms.
+	LicenseCopyleft LicenseCategory = "copyleft"
+	// LicenseOther licenses are on the SPDX License List, or LicenseRefs,
+	// but not classified (e.g., non-commercial or proprietary terms).
+	LicenseOther LicenseCategory = "other"
+	// LicenseUnknown components declare no license, or one that is not a
+	// well-formed expression of SPDX License List identifiers.
+	LicenseUnknown LicenseCategory = "unknown"
+)
+
+// licenseCategoryRank orders the categories from least to most restrictive.
+var licenseCategoryRank = map[LicenseCategory]int{
+	LicensePermissive:   0,
+	LicenseWeakCopyleft: 1,
+	LicenseCopyleft:     2,
+	LicenseOther:        3,
+	LicenseUnknown:      4,
+}
+
+// licenseCategoryPrefixes classifies SPDX License List identifiers by prefix.
+// The first matching prefix wins, so more specific prefixes come first.
+var licenseCategoryPrefixes = []struct {
+	prefix   string
+	category LicenseCategory
+}{
+	{"AGPL-", LicenseCopyleft},
+	{"GPL-", LicenseCopyleft},
+	{"SSPL-", LicenseCopyleft},
+	{"OSL-", LicenseCopyleft},
+	{"EUPL-", LicenseCopyleft},
+	{"CC-BY-SA-", LicenseCopyleft},
+	{"Sleepycat", LicenseCopyleft},
+	{"RPL-", LicenseCopyleft},
+
+	{"LGPL-", LicenseWeakCopyleft},
+	{"MPL-", LicenseWeakCopyleft},
+	{"EPL-", LicenseWeakCopyleft},
+	{"CDDL-", LicenseWeakCopyleft},
+	{"CPL-", LicenseWeakCopyleft},
+	{"MS-RL", LicenseWeakCopyleft},
+	{"APSL-", LicenseWeakCopyleft},
+	{"ErlPL-", LicenseWeakCopyleft},
+
+	{"CC-BY-NC", LicenseOther},
+	{"CC-BY-ND", LicenseOther},
+
+	{"0BSD", LicensePermissive},
+	{"AFL-", LicensePermissive},
+	{"Apache-", LicensePermissive},
+	{"Artistic-2.0", LicensePermissive},
+	{"BlueOak-", LicensePermissive},
+	{"BSD-", LicensePermissive},
+	{"BSL-1.0", LicensePermissive},
+	{"CC-BY-", LicensePermissive},
+	{"CC0-", LicensePermissive},
+	{"curl", LicensePermissive},
+	{"HPND", LicensePermissive},
+	{"ISC", LicensePermissive},
+	{"Libpng", LicensePermissive},
+	{"MIT", LicensePermissive},
+	{"MS-PL", LicensePermissive},
+	{"NCSA", LicensePermissive},
+	{"OpenSSL", LicensePermissive},
+	{"PHP-", LicensePermissive},
+	{"PostgreSQL", LicensePermissive},
+	{"PSF-", LicensePermissive},
+	{"Python-", LicensePermissive},
+	{"Ruby", LicensePermissive},
+	{"Unicode-", LicensePermissive},
+	{"Unlicense", LicensePermissive},
+	{"UPL-", LicensePermissive},
+	{"W3C", LicensePermissive},
+	{"WTFPL", LicensePermissive},
+	{"X11", LicensePermissive},
+	{"Zlib", LicensePermissive},
+}
+
+// LicenseStats summarizes the licenses of an SBOM's components. See
+// `WithLicenseStats`.
+type LicenseStats struct {
+	// Components is the number of components (CycloneDX components or SPDX
+	// packages), excluding the primary CycloneDX metadata.component.
+	Components int `json:"components"`
+	// ByLicense counts the components each license identifier appears in,
+	// keyed by its SPDX License List spelling or LicenseRef.
+	ByLicense map[string]int `json:"byLicense,omitempty"`
+	// ByCategory counts the components in each `LicenseCategory`. A
+	// component with a dual license ("A OR B") is counted under the least
+	// restrictive choice, and one with several licenses ("A AND B") under the
+	// most restrictive.
+	ByCategory map[LicenseCategory]int `json:"byCategory"`
+	// UnknownPercent is the percentage of components whose license is
+	// unknown, from 0 to 100.
+	UnknownPercent float64 `json:"unknownPercent"`
+	// Notes explain how each dual license whose choices differ in category
+	// was resolved.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// licenseCategoryOf classifies a single license identifier.
+func licenseCategoryOf(id string) LicenseCategory {
+	if licenseRefPattern.MatchString(id) {
+		return LicenseOther
+	}
+	list := loadLicenseList()
+	canonical := list.licenses[strings.ToLower(id)]
+	if canonical == "" {
+		canonical = list.deprecated[strings.ToLower(id)].id
+	}
+	if canonical == "" {
+		return LicenseUnknown
+	}
+	for _, entry := range licenseCategoryPrefixes {
+		if strings.HasPrefix(canonical, entry.prefix) {
+			return entry.category
+		}
+	}
+	return LicenseOther
+}
+
+// licenseCategoryEvaluator computes the category of a well-formed license
+// expression, following the grammar of licenseExpressionParser.
+type licenseCategoryEvaluator struct {
+	tokens []licenseToken
+	pos    int
+	// dual is set when an OR joins choices of different categories.
+	dual bool
+}
+
+func (e *licenseCategoryEvaluator) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos].text
+	}
+	return ""
+}
+
+func (e *licenseCategoryEvaluator) compound() LicenseCategory {
+	category := e.and()
+	for e.peek() == "OR" {
+		e.pos++
+		choice := e.and()
+		if choice != category {
+			e.dual = true
+		}
+		if licenseCategoryRank[choice] < licenseCategoryRank[category] {
+			category = choice
+		}
+	}
+	return category
+}
+
+func (e *licenseCategoryEvaluator) and() LicenseCategory {
+	category := e.with()
+	for e.peek() == "AND" {
+		e.pos++
+		if other := e.with(); licenseCategoryRank[other] > licenseCategoryRank[category] {
+			category = other
+		}
+	}
+	return category
+}
+
+func (e *licenseCategoryEvaluator) with() LicenseCategory {
+	if e.peek() == "(" {
+		e.pos++
+		category := e.compound()
+		e.pos++ // ")"
+		return category
+	}
+	id := strings.TrimSuffix(e.tokens[e.pos].text, "+")
+	e.pos++
+	if e.peek() == "WITH" {
+		e.pos += 2
+	}
+	return licenseCategoryOf(id)
+}
+
+// licenseExpressionCategory classifies a license expression. Returns
+// LicenseUnknown for malformed expressions, and whether the expression is a
+// dual license with choices of different categories.
+func licenseExpressionCategory(expression string) (LicenseCategory, bool) {
+	if _, err := parseLicenseExpression(expression); err != nil {
+		return LicenseUnknown, false
+	}
+	e := &licenseCategoryEvaluator{tokens: tokenizeLicenseExpression(expression)}
+	category := e.compound()
+	return category, e.dual
+}
+
+// computeLicenseStats summarizes the licenses of a parsed SBOM's components.
+// A component's licenses are combined with AND: CycloneDX components may
+// list several, and SPDX packages contribute their concluded license or,
+// failing that, their declared license.
+func computeLicenseStats(obj map[string]interface{}, sbomType string) *LicenseStats {
+	stats := &LicenseStats{ByLicense: map[string]int{}, ByCategory: map[LicenseCategory]int{}}
+	list := loadLicenseList()
+
+	for _, component := range licensedComponents(obj, sbomType) {
+		stats.Components++
+
+		var parts []string
+		counted := map[string]bool{}
+		for _, license := range component.Licenses {
+			parts = append(parts, "("+license+")")
+			terms, err := parseLicenseExpression(license)
+			if err != nil {
+				continue
+			}
+			for _, term := range terms {
+				id := term.license.text
+				if canonical := list.licenses[strings.ToLower(id)]; canonical != "" {
+					id = canonical
+				} else if deprecated := list.deprecated[strings.ToLower(id)]; deprecated.id != "" {
+					id = deprecated.id
+				} else if !licenseRefPattern.MatchString(id) {
+					continue
+				}
+				if !counted[id] {
+					counted[id] = true
+					stats.ByLicense[id]++
+				}
+			}
+		}
+
+		category := LicenseUnknown
+		if len(parts) > 0 {
+			expression := strings.Join(parts, " AND ")
+			var dual bool
+			category, dual = licenseExpressionCategory(expression)
+			if dual {
+				stats.Notes = append(stats.Notes, fmt.Sprintf("%s: %q is a dual license; counted as %s, its least restrictive choice",
+					component.Name, strings.Join(component.Licenses, " AND "), category))
+			}
+		}
+		stats.ByCategory[category]++
+	}
+
+	if stats.Components > 0 {
+		unknown := float64(stats.ByCategory[LicenseUnknown]) / float64(stats.Components) * 100
+		stats.UnknownPercent = math.Round(unknown*10) / 10
+	}
+	return stats
+}
+
+// licensedComponents returns the components of a parsed SBOM with the
+// licenses that apply to them. Unlike extractComponents, SPDX packages use
+// their concluded license when there is one.
+func licensedComponents(obj map[string]interface{}, sbomType string) []sbomComponent {
+	components := extractComponents(obj, sbomType)
+	if !strings.HasPrefix(sbomType, SBOM_SPDX) {
+		return components
+	}
+
+	packages, _ := obj["packages"].([]interface{})
+	i := 0
+	for _, p := range packages {
+		pkg, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if concluded, _ := pkg["licenseConcluded"].(string); concluded != "" && concluded != "NOASSERTION" && concluded != "NONE" {
+			components[i].Licenses = []string{concluded}
+		}
+		i++
+	}
+	return components
+}
@@ -0,0 +1,101 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLicenseExpressionCategory(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       LicenseCategory
+		wantDual   bool
+	}{
+		{expression: "MIT", want: LicensePermissive},
+		{expression: "GPL-2.0-or-later", want: LicenseCopyleft},
+		{expression: "GPL-2.0+", want: LicenseCopyleft},
+		{expression: "LGPL-2.1-only", want: LicenseWeakCopyleft},
+		{expression: "GPL-2.0-only WITH Classpath-exception-2.0", want: LicenseCopyleft},
+		{expression: "GPL-2.0-only OR MIT", want: LicensePermissive, wantDual: true},
+		{expression: "MIT OR Apache-2.0", want: LicensePermissive},
+		{expression: "MIT AND MPL-2.0", want: LicenseWeakCopyleft},
+		{expression: "(MIT AND GPL-3.0-only) OR LGPL-3.0-only", want: LicenseWeakCopyleft, wantDual: true},
+		{expression: "LicenseRef-proprietary", want: LicenseOther},
+		{expression: "CC-BY-NC-4.0", want: LicenseOther},
+		{expression: "Not-A-License", want: LicenseUnknown},
+		{expression: "MIT or Apache-2.0", want: LicenseUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, dual := licenseExpressionCategory(tt.expression)
+			if got != tt.want || dual != tt.wantDual {
+				t.Errorf("licenseExpressionCategory(%q) = %q, %v; want %q, %v", tt.expression, got, dual, tt.want, tt.wantDual)
+			}
+		})
+	}
+}
+
+func TestComputeLicenseStats(t *testing.T) {
+	t.Run("CycloneDX", func(t *testing.T) {
+		obj, err := parseJSON(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [
+			{"name": "a", "licenses": [{"license": {"id": "MIT"}}]},
+			{"name": "b", "licenses": [{"expression": "GPL-3.0-only OR MIT"}]},
+			{"name": "c", "licenses": [{"license": {"id": "MIT"}}, {"license": {"id": "GPL-3.0-only"}}]},
+			{"name": "d", "licenses": [{"license": {"name": "Some custom license"}}]},
+			{"name": "e"}
+		]}`)
+		if err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+
+		stats := computeLicenseStats(obj, SBOM_CYCLONEDX)
+		if stats.Components != 5 || stats.ByLicense["MIT"] != 3 || stats.ByLicense["GPL-3.0-only"] != 2 {
+			t.Errorf("unexpected counts: %+v", stats)
+		}
+		if stats.ByCategory[LicensePermissive] != 2 || stats.ByCategory[LicenseCopyleft] != 1 || stats.ByCategory[LicenseUnknown] != 2 {
+			t.Errorf("unexpected categories: %v", stats.ByCategory)
+		}
+		if stats.UnknownPercent != 40 {
+			t.Errorf("UnknownPercent = %v, want 40", stats.UnknownPercent)
+		}
+		if len(stats.Notes) != 1 || stats.Notes[0] != `b: "GPL-3.0-only OR MIT" is a dual license; counted as permissive, its least restrictive choice` {
+			t.Errorf("unexpected notes: %q", stats.Notes)
+		}
+	})
+
+	t.Run("SPDX prefers the concluded license", func(t *testing.T) {
+		sbom := spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "NOASSERTION"))
+		obj, err := parseJSON(strings.Replace(string(sbom), `"licenseDeclared": "MIT"`, `"licenseDeclared": "MIT", "licenseConcluded": "LGPL-2.1-or-later"`, 1))
+		if err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+
+		stats := computeLicenseStats(obj, "SPDX-2.3")
+		if stats.ByCategory[LicenseWeakCopyleft] != 1 || stats.ByCategory[LicenseUnknown] != 1 || stats.ByLicense["MIT"] != 0 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+}
+
+func TestWithLicenseStats(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "Apache-2.0"))
+
+	result, err := ValidateSBOMData(sbom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Licenses != nil {
+		t.Errorf("expected no license stats by default, got %+v", result.Licenses)
+	}
+
+	result, err = ValidateSBOMData(sbom, WithLicenseStats(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Licenses == nil || result.Licenses.ByCategory[LicensePermissive] != 2 || result.Licenses.UnknownPercent != 0 {
+		t.Errorf("expected two permissive licenses, got %+v", result.Licenses)
+	}
+}
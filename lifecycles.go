@@ -0,0 +1,150 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// lifecyclePhases are the pre-defined lifecycle phases of CycloneDX 1.5 and
+// later.
+var lifecyclePhases = map[string]bool{
+	"design":       true,
+	"pre-build":    true,
+	"build":        true,
+	"post-build":   true,
+	"operations":   true,
+	"discovery":    true,
+	"decommission": true,
+}
+
+// checkLifecycles checks the metadata.lifecycles of a parsed CycloneDX
+// document (spec 1.5 and later). Each lifecycle must be either a pre-defined
+// phase or a custom lifecycle with a non-blank name, optionally described:
+//
+//   - a phase must be one of the pre-defined phases;
+//   - a lifecycle must not declare both a phase and a name;
+//   - a custom name must not be blank or shadow a pre-defined phase;
+//   - a description, when present, must not be blank;
+//   - a lifecycle must not be declared twice.
+//
+// The schema rejects most of these too, but only with a generic "oneOf"
+// message; these errors say what is wrong. Returns a finding per problem, at
+// the JSON path of the lifecycle. Descriptions that are blank or not allowed,
+// repeated lifecycles and custom names that merely restate a phase come with
+// a fix.
+func checkLifecycles(obj map[string]interface{}) []Finding {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	lifecycles, _ := metadata["lifecycles"].([]interface{})
+
+	var errors []Finding
+	add := func(path, message string, fix []PatchOperation) {
+		errors = append(errors, Finding{Level: LevelError, Rule: RuleLifecycle, Path: path, Pointer: jsonPointer(path), Message: message, Fix: fix})
+	}
+	seen := map[string]bool{}
+
+	for i, l := range lifecycles {
+		path := fmt.Sprintf("metadata.lifecycles.%d", i)
+		lifecycle, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		phase, hasPhase := lifecycle["phase"].(string)
+		name, hasName := lifecycle["name"].(string)
+		_, hasDescription := lifecycle["description"]
+
+		var key string
+		switch {
+		case hasPhase && hasName:
+			add(path, fmt.Sprintf("lifecycle declares both phase %q and name %q; use one or the other", phase, name), nil)
+			continue
+
+		case hasPhase:
+			if !lifecyclePhases[phase] {
+				add(path+".phase", fmt.Sprintf("unknown lifecycle phase %q; expected one of %s (or a custom name)",
+					phase, strings.Join(sortedLifecyclePhases(), ", ")), nil)
+				continue
+			}
+			if hasDescription {
+				add(path, "description is only allowed on custom lifecycles", removeFix(path+".description"))
+			}
+			key = phase
+
+		case hasName:
+			if strings.TrimSpace(name) == "" {
+				add(path+".name", "custom lifecycle name is blank", nil)
+				continue
+			}
+			if shadowed := strings.ToLower(strings.TrimSpace(name)); lifecyclePhases[shadowed] {
+				var fix []PatchOperation
+				if !hasDescription {
+					fix = []PatchOperation{{Op: PatchReplace, Path: jsonPointer(path), Value: map[string]interface{}{"phase": shadowed}}}
+				}
+				add(path+".name", fmt.Sprintf("custom lifecycle %q shadows the pre-defined phase; use \"phase\" instead", name), fix)
+			}
+			if description, ok := lifecycle["description"].(string); ok && strings.TrimSpace(description) == "" {
+				add(path+".description", "custom lifecycle description is blank", removeFix(path+".description"))
+			}
+			key = "name:" + name
+
+		default:
+			add(path, "lifecycle declares neither a phase nor a name", nil)
+			continue
+		}
+
+		if seen[key] {
+			add(path, fmt.Sprintf("lifecycle %q is declared more than once", strings.TrimPrefix(key, "name:")), removeFix(path))
+		}
+		seen[key] = true
+	}
+
+	return errors
+}
+
+// checkBuildPhaseProfile requires a CycloneDX document to declare the build
+// lifecycle phase, as expected of SBOMs produced by a CI pipeline. Where
+// lifecycles are defined, the finding's fix adds the phase.
+func checkBuildPhaseProfile(obj map[string]interface{}) []Finding {
+	metadata, hasMetadata := obj["metadata"].(map[string]interface{})
+	lifecycles, hasLifecycles := metadata["lifecycles"].([]interface{})
+
+	for _, l := range lifecycles {
+		if lifecycle, ok := l.(map[string]interface{}); ok && lifecycle["phase"] == "build" {
+			return nil
+		}
+	}
+
+	if specVersion, _ := obj["specVersion"].(string); specVersion != "" && !SpecVersion(specVersion).AtLeast("1.5") {
+		msg := fmt.Sprintf("the build lifecycle phase is required, but lifecycles are only defined from CycloneDX 1.5 (got %s)", specVersion)
+		return []Finding{{Level: LevelError, Rule: RuleBuildPhase, Path: "metadata", Pointer: "/metadata", Message: msg}}
+	}
+
+	build := map[string]interface{}{"phase": "build"}
+	var fix PatchOperation
+	switch {
+	case hasLifecycles:
+		fix = PatchOperation{Op: PatchAdd, Path: "/metadata/lifecycles/-", Value: build}
+	case hasMetadata:
+		fix = PatchOperation{Op: PatchAdd, Path: "/metadata/lifecycles", Value: []interface{}{build}}
+	default:
+		fix = PatchOperation{Op: PatchAdd, Path: "/metadata", Value: map[string]interface{}{"lifecycles": []interface{}{build}}}
+	}
+	return []Finding{{
+		Level:   LevelError,
+		Rule:    RuleBuildPhase,
+		Path:    "metadata.lifecycles",
+		Pointer: "/metadata/lifecycles",
+		Message: `the build lifecycle phase is not declared (add {"phase": "build"})`,
+		Fix:     []PatchOperation{fix},
+	}}
+}
+
+func sortedLifecyclePhases() []string {
+	phases := make([]string, 0, len(lifecyclePhases))
+	for phase := range lifecyclePhases {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	return phases
+}
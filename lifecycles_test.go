@@ -0,0 +1,120 @@
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckLifecycles(t *testing.T) {
+	tests := []struct {
+		name       string
+		lifecycles string
+		want       []string
+	}{
+		{name: "No lifecycles", lifecycles: `[]`},
+		{name: "Pre-defined phases", lifecycles: `[{"phase": "build"}, {"phase": "post-build"}]`},
+		{name: "Custom lifecycle", lifecycles: `[{"name": "platform-integration", "description": "Integrated into the platform image"}]`},
+		{
+			name:       "Unknown phase",
+			lifecycles: `[{"phase": "biuld"}]`,
+			want:       []string{`metadata.lifecycles.0.phase: unknown lifecycle phase "biuld"`},
+		},
+		{
+			name:       "Phase and name",
+			lifecycles: `[{"phase": "build", "name": "ci"}]`,
+			want:       []string{"metadata.lifecycles.0: lifecycle declares both phase"},
+		},
+		{
+			name:       "Blank custom name",
+			lifecycles: `[{"name": "  "}]`,
+			want:       []string{"metadata.lifecycles.0.name: custom lifecycle name is blank"},
+		},
+		{
+			name:       "Custom name shadowing a phase",
+			lifecycles: `[{"name": "Build"}]`,
+			want:       []string{`metadata.lifecycles.0.name: custom lifecycle "Build" shadows the pre-defined phase`},
+		},
+		{
+			name:       "Blank description",
+			lifecycles: `[{"name": "staging", "description": ""}]`,
+			want:       []string{"metadata.lifecycles.0.description: custom lifecycle description is blank"},
+		},
+		{
+			name:       "Description on a phase",
+			lifecycles: `[{"phase": "build", "description": "CI"}]`,
+			want:       []string{"metadata.lifecycles.0: description is only allowed on custom lifecycles"},
+		},
+		{
+			name:       "Neither phase nor name",
+			lifecycles: `[{"description": "something"}]`,
+			want:       []string{"metadata.lifecycles.0: lifecycle declares neither a phase nor a name"},
+		},
+		{
+			name:       "Duplicate phase",
+			lifecycles: `[{"phase": "build"}, {"phase": "operations"}, {"phase": "build"}]`,
+			want:       []string{`metadata.lifecycles.2: lifecycle "build" is declared more than once`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "metadata": {"lifecycles": ` + tt.lifecycles + `}}`)
+			if err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+
+			got := checkLifecycles(obj)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.want), len(got), got)
+			}
+			for i := range tt.want {
+				if !strings.HasPrefix(got[i].String(), tt.want[i]) {
+					t.Errorf("Error %d = %q, want prefix %q", i, got[i].String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckBuildPhaseProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		sbom string
+		want string
+	}{
+		{name: "Build phase declared", sbom: `{"specVersion": "1.6", "metadata": {"lifecycles": [{"phase": "pre-build"}, {"phase": "build"}]}}`},
+		{name: "Other phases only", sbom: `{"specVersion": "1.6", "metadata": {"lifecycles": [{"phase": "operations"}]}}`,
+			want: "metadata.lifecycles: the build lifecycle phase is not declared"},
+		{name: "No metadata", sbom: `{"specVersion": "1.5"}`, want: "metadata.lifecycles: the build lifecycle phase is not declared"},
+		{name: "Custom lifecycle named build", sbom: `{"specVersion": "1.6", "metadata": {"lifecycles": [{"name": "build"}]}}`,
+			want: "metadata.lifecycles: the build lifecycle phase is not declared"},
+		{name: "Spec version without lifecycles", sbom: `{"specVersion": "1.4"}`,
+			want: "metadata: the build lifecycle phase is required, but lifecycles are only defined from CycloneDX 1.5 (got 1.4)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(tt.sbom)
+			if err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+
+			got, rules, err := checkProfiles(obj, SBOM_CYCLONEDX, []Profile{ProfileBuildPhase})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(rules) != 1 || rules[0] != RuleBuildPhase {
+				t.Errorf("Unexpected rules: %v", rules)
+			}
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Errorf("Expected no errors, got %v", got)
+				}
+				return
+			}
+			if len(got) != 1 || !strings.HasPrefix(got[0].String(), tt.want) {
+				t.Errorf("Expected error %q, got %v", tt.want, got)
+			}
+		})
+	}
+}
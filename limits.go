@@ -0,0 +1,74 @@
+package sbomvalidator
+
+import "slices"
+
+// ValidationOptions control which findings a result reports and what makes
+// it invalid. Set them with WithValidationOptions.
+type ValidationOptions struct {
+	// MaxErrors caps the number of errors reported; the rest are counted in
+	// `ValidationResult.OmittedErrors`. Warnings and info findings are not
+	// capped. Zero means no limit.
+	MaxErrors int
+	// FailOnWarnings makes an SBOM with warnings invalid, as well as one with
+	// errors.
+	FailOnWarnings bool
+	// IgnoreRules are the IDs of rules whose findings are dropped, so that
+	// they neither appear in the result nor affect its validity (see
+	// `RuleCatalog`).
+	IgnoreRules []string
+	// Levels override the level of the findings of rules, e.g. to demote
+	// the errors of a rule to warnings or promote its warnings to errors.
+	Levels map[string]FindingLevel
+}
+
+// ignores reports whether findings of the rule are dropped.
+func (v ValidationOptions) ignores(rule string) bool {
+	return slices.Contains(v.IgnoreRules, rule)
+}
+
+// level returns the level of a finding once Levels is applied.
+func (v ValidationOptions) level(finding Finding) FindingLevel {
+	if level, ok := v.Levels[finding.Rule]; ok {
+		return level
+	}
+	return finding.Level
+}
+
+// apply overrides the levels of findings and drops the findings of ignored rules and the errors beyond MaxErrors,
+// returning the findings kept and the number of errors omitted.
+func (v ValidationOptions) apply(findings []Finding) ([]Finding, int) {
+	filter := &findingFilter{limits: v}
+	var kept []Finding
+	for _, f := range findings {
+		if f, ok := filter.keep(f); ok {
+			kept = append(kept, f)
+		}
+	}
+	return kept, filter.omitted
+}
+
+// findingFilter applies ValidationOptions to findings in the order they are
+// produced.
+type findingFilter struct {
+	limits       ValidationOptions
+	suppressions *suppressionIndex
+	errors       int
+	omitted      int
+}
+
+// keep returns the finding at its overridden level and whether it is kept.
+func (f *findingFilter) keep(finding Finding) (Finding, bool) {
+	if f.limits.ignores(finding.Rule) || f.suppressions.suppresses(finding) {
+		return finding, false
+	}
+	finding.Level = f.limits.level(finding)
+	if finding.Level == LevelError {
+		if f.limits.MaxErrors > 0 && f.errors >= f.limits.MaxErrors {
+			f.omitted++
+			return finding, false
+		}
+		f.errors++
+	}
+	finding.renderMessage()
+	return finding, true
+}
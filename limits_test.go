@@ -0,0 +1,109 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import "testing"
+
+func TestWithValidationOptions(t *testing.T) {
+	incomplete := []byte(`{"spdxVersion": "SPDX-2.3"}`)
+	misspelled := spdxDocument(spdxPackage("a", "left-pad", "MIT"), spdxPackage("b", "Left_Pad", "MIT"))
+
+	tests := []struct {
+		name         string
+		sbom         []byte
+		opts         []Option
+		wantValid    bool
+		wantErrors   int
+		wantOmitted  bool
+		wantWarnings int
+	}{
+		{
+			name:        "Error limit",
+			sbom:        incomplete,
+			opts:        []Option{WithValidationOptions(ValidationOptions{MaxErrors: 2})},
+			wantErrors:  2,
+			wantOmitted: true,
+		},
+		{
+			name:      "Ignored rule",
+			sbom:      incomplete,
+			opts:      []Option{WithValidationOptions(ValidationOptions{IgnoreRules: []string{RuleSchema}})},
+			wantValid: true,
+		},
+		{
+			name:         "Warnings allowed",
+			sbom:         misspelled,
+			opts:         []Option{WithComponentNaming(true)},
+			wantValid:    true,
+			wantWarnings: 1,
+		},
+		{
+			name:         "Fail on warnings",
+			sbom:         misspelled,
+			opts:         []Option{WithComponentNaming(true), WithValidationOptions(ValidationOptions{FailOnWarnings: true})},
+			wantWarnings: 1,
+		},
+		{
+			name:      "Ignored warnings do not fail",
+			sbom:      misspelled,
+			opts:      []Option{WithComponentNaming(true), WithValidationOptions(ValidationOptions{FailOnWarnings: true, IgnoreRules: []string{RuleComponentNaming}})},
+			wantValid: true,
+		},
+		{
+			name:       "Promoted warnings",
+			sbom:       misspelled,
+			opts:       []Option{WithComponentNaming(true), WithValidationOptions(ValidationOptions{Levels: map[string]FindingLevel{RuleComponentNaming: LevelError}})},
+			wantErrors: 1,
+		},
+		{
+			name:         "Demoted errors",
+			sbom:         incomplete,
+			opts:         []Option{WithValidationOptions(ValidationOptions{Levels: map[string]FindingLevel{RuleSchema: LevelWarning}})},
+			wantValid:    true,
+			wantWarnings: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMDataStructured(tt.sbom, tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v", result.IsValid, tt.wantValid)
+			}
+			if len(result.ValidationErrors) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d: %v", len(result.ValidationErrors), tt.wantErrors, result.ValidationErrors)
+			}
+			if (result.OmittedErrors > 0) != tt.wantOmitted {
+				t.Errorf("OmittedErrors = %d", result.OmittedErrors)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("got %d warnings, want %d: %v", len(result.Warnings), tt.wantWarnings, result.Warnings)
+			}
+		})
+	}
+}
+
+func TestFindingFilterRendersMessages(t *testing.T) {
+	var rendered int
+	deferred := func(path string) Finding {
+		return Finding{Level: LevelError, Rule: RuleSchema, Path: path, render: func() string {
+			rendered++
+			return "name is required"
+		}}
+	}
+
+	filter := &findingFilter{limits: ValidationOptions{MaxErrors: 1}}
+	kept, ok := filter.keep(deferred("packages.0"))
+	if !ok || kept.Message != "name is required" || kept.render != nil {
+		t.Errorf("Expected the kept finding to be rendered, got %+v", kept)
+	}
+	if _, ok := filter.keep(deferred("packages.1")); ok {
+		t.Errorf("Expected the second error to be omitted")
+	}
+	if rendered != 1 {
+		t.Errorf("Rendered %d messages, want only that of the kept finding", rendered)
+	}
+}
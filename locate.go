@@ -0,0 +1,147 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sourceLocator maps JSON paths in a document to their position in the source.
+//
+// The index is built on first use, so validation does not pay for it unless a
+// caller actually asks for a location.
+type sourceLocator struct {
+	source []byte
+
+	once       sync.Once
+	offsets    map[string]int64
+	lineStarts []int64
+}
+
+func newSourceLocator(source []byte) *sourceLocator {
+	return &sourceLocator{source: source}
+}
+
+// Locate returns the 1-based line and column in the original document of the
+// value at the given JSON path.
+//
+// Paths use the dotted form found at the start of validation errors and
+// warnings, e.g. "components.0.name", with "(root)" or "" denoting the
+// document itself. A full message such as "components.0: name is required" may
+// also be passed; everything from the first ": " is ignored. For object
+// members the position of the key is returned.
+//
+// Returns (0, 0) if the path does not exist or the result has no source
+// document (e.g., non-JSON input).
+//
+// Example:
+//
+//	for _, msg := range result.ValidationErrors {
+//	    line, col := result.Locate(msg)
+//	    fmt.Printf("sbom.json:%d:%d: %s\n", line, col, msg)
+//	}
+func (r *ValidationResult) Locate(path string) (line, col int) {
+	if r == nil || r.locator == nil {
+		return 0, 0
+	}
+	return r.locator.locate(path)
+}
+
+func (l *sourceLocator) locate(path string) (line, col int) {
+	l.once.Do(l.build)
+
+	if i := strings.Index(path, ": "); i >= 0 {
+		path = path[:i]
+	}
+	if path == "(root)" {
+		path = ""
+	}
+
+	offset, ok := l.offsets[path]
+	if !ok {
+		return 0, 0
+	}
+
+	i := sort.Search(len(l.lineStarts), func(i int) bool { return l.lineStarts[i] > offset }) - 1
+	return i + 1, int(offset-l.lineStarts[i]) + 1
+}
+
+// build tokenizes the source once, recording the offset of every value.
+// Malformed documents are indexed up to the first syntax error.
+func (l *sourceLocator) build() {
+	l.offsets = map[string]int64{}
+	l.lineStarts = []int64{0}
+	for i, b := range l.source {
+		if b == '\n' {
+			l.lineStarts = append(l.lineStarts, int64(i+1))
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(l.source))
+	_ = l.indexValue(dec, "", l.valueStart(0))
+}
+
+// indexValue records the start of the value at path and, for objects and
+// arrays, recurses into their members.
+func (l *sourceLocator) indexValue(dec *json.Decoder, path string, start int64) error {
+	l.offsets[path] = start
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		for dec.More() {
+			keyStart := l.valueStart(dec.InputOffset())
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyTok.(string)
+			if err := l.indexValue(dec, joinJSONPath(path, key), keyStart); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if err := l.indexValue(dec, joinJSONPath(path, strconv.Itoa(i)), l.valueStart(dec.InputOffset())); err != nil {
+				return err
+			}
+		}
+	}
+
+	// consume the closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+// valueStart skips whitespace and separators following offset, returning the
+// offset of the next token.
+func (l *sourceLocator) valueStart(offset int64) int64 {
+	for offset < int64(len(l.source)) {
+		switch l.source[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func joinJSONPath(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
@@ -0,0 +1,69 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"testing"
+)
+
+func TestLocate(t *testing.T) {
+	source := []byte(`{
+  "spdxVersion": "SPDX-2.3",
+  "packages": [
+    {"name": "a"},
+    {
+      "name": "b",
+      "versionInfo": 1
+    }
+  ]
+}`)
+
+	result := &ValidationResult{locator: newSourceLocator(source)}
+
+	tests := []struct {
+		path     string
+		wantLine int
+		wantCol  int
+	}{
+		{path: "(root)", wantLine: 1, wantCol: 1},
+		{path: "", wantLine: 1, wantCol: 1},
+		{path: "spdxVersion", wantLine: 2, wantCol: 3},
+		{path: "packages.0", wantLine: 4, wantCol: 5},
+		{path: "packages.0.name", wantLine: 4, wantCol: 6},
+		{path: "packages.1", wantLine: 5, wantCol: 5},
+		{path: "packages.1.versionInfo", wantLine: 7, wantCol: 7},
+		{path: "packages.1.versionInfo: Invalid type. Expected: string, given: integer", wantLine: 7, wantCol: 7},
+		{path: "packages.2", wantLine: 0, wantCol: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			line, col := result.Locate(tt.path)
+			if line != tt.wantLine || col != tt.wantCol {
+				t.Errorf("Locate(%q) = (%d, %d), want (%d, %d)", tt.path, line, col, tt.wantLine, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestLocateWithoutSource(t *testing.T) {
+	var result *ValidationResult
+	if line, col := result.Locate("components.0"); line != 0 || col != 0 {
+		t.Errorf("Locate() on nil result = (%d, %d), want (0, 0)", line, col)
+	}
+
+	result = &ValidationResult{}
+	if line, col := result.Locate("components.0"); line != 0 || col != 0 {
+		t.Errorf("Locate() without source = (%d, %d), want (0, 0)", line, col)
+	}
+}
+
+func TestLocateFromValidation(t *testing.T) {
+	result, err := ValidateSBOMData([]byte("{\n  \"spdxVersion\": \"SPDX-2.3\",\n  \"name\": 5\n}"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if line, _ := result.Locate("name"); line != 3 {
+		t.Errorf("Locate(\"name\") line = %d, want 3", line)
+	}
+}
@@ -0,0 +1,26 @@
+package sbomvalidator
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardLogger drops every record, so the library is silent unless a
+// logger is set with WithLogger.
+var discardLogger = slog.New(discardHandler{})
+
+// discardHandler is a slog.Handler that is never enabled.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// log returns the configured logger, or one that discards everything.
+func (o *validationOptions) log() *slog.Logger {
+	if o.logger != nil {
+		return o.logger
+	}
+	return discardLogger
+}
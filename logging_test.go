@@ -0,0 +1,78 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+
+	// nothing reaches the standard logger by default
+	var std bytes.Buffer
+	log.SetOutput(&std)
+	defer log.SetOutput(os.Stderr)
+	if _, err := ValidateSBOMData(sbom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if std.Len() > 0 {
+		t.Errorf("validation logged to the standard logger: %s", std.String())
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, err := ValidateSBOMData(sbom, WithLogger(logger)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`msg="SBOM type detected" type=SPDX-2.3 version=SPDX-2.3 encoding=JSON`,
+		`msg="schema selected" schema=schemas/spdx/spdx-2.3.schema.json`,
+		`msg="SBOM validated" type=SPDX version=2.3 valid=true errors=0`,
+		"duration=",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log does not contain %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if _, err := ValidateSBOMData([]byte(`{"hello": "world"}`), WithLogger(logger)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(buf.String(), `msg="SBOM validation failed" error=`) {
+		t.Errorf("failure not logged:\n%s", buf.String())
+	}
+}
+
+func TestValidator(t *testing.T) {
+	var buf bytes.Buffer
+	validator, err := New(ValidatorConfig{Options: []Option{
+		WithLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithComponentNaming(true),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	misspelled := spdxDocument(spdxPackage("a", "left-pad", "MIT"), spdxPackage("b", "Left_Pad", "MIT"))
+
+	result, err := validator.Validate(context.Background(), misspelled)
+	if err != nil || !result.IsValid || len(result.Warnings) != 1 {
+		t.Errorf("Validate() = %+v, %v", result, err)
+	}
+	if !strings.Contains(buf.String(), "SBOM validated") {
+		t.Errorf("the validator's logger was not used:\n%s", buf.String())
+	}
+
+	// options of a call apply after the validator's
+	structured, err := validator.ValidateStructured(context.Background(), misspelled, WithComponentNaming(false))
+	if err != nil || len(structured.Findings) != 0 {
+		t.Errorf("ValidateStructured() = %+v, %v", structured, err)
+	}
+}
@@ -0,0 +1,112 @@
+package sbomvalidator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CachedLicenseLookup wraps a LicenseLookup with a persistent on-disk cache
+// keyed by purl, so repeated runs (e.g., nightly batch validation) do not
+// query upstream registries again for packages seen recently.
+//
+// Each entry is stored as a small JSON file in the cache directory. Entries
+// are written atomically, so several processes can share one directory.
+// Failed lookups are not cached.
+type CachedLicenseLookup struct {
+	next LicenseLookup
+	dir  string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+type licenseCacheEntry struct {
+	PURL      string    `json:"purl"`
+	Licenses  []string  `json:"licenses"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// NewCachedLicenseLookup returns a LicenseLookup that serves results from dir
+// when they are younger than ttl and otherwise delegates to next.
+//
+// Parameters:
+//   - next: The lookup to delegate cache misses to.
+//   - dir: The cache directory; it is created if it does not exist.
+//   - ttl: How long a cached result stays valid.
+//
+// Returns:
+//   - The cached lookup.
+//   - An error if the cache directory cannot be created.
+func NewCachedLicenseLookup(next LicenseLookup, dir string, ttl time.Duration) (*CachedLicenseLookup, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &CachedLicenseLookup{next: next, dir: dir, ttl: ttl, now: time.Now}, nil
+}
+
+// Licenses returns the cached licenses for purl, or looks them up and caches
+// the result.
+func (c *CachedLicenseLookup) Licenses(purl string) ([]string, error) {
+	file := c.entryPath(purl)
+
+	if data, err := os.ReadFile(file); err == nil {
+		var entry licenseCacheEntry
+		// a corrupt or colliding entry is treated as a miss
+		if json.Unmarshal(data, &entry) == nil && entry.PURL == purl && c.now().Sub(entry.FetchedAt) < c.ttl {
+			return entry.Licenses, nil
+		}
+	}
+
+	licenses, err := c.next.Licenses(purl)
+	if err != nil {
+		return nil, err
+	}
+
+	// caching is best effort; a failed write only costs a future lookup
+	_ = c.store(file, licenseCacheEntry{PURL: purl, Licenses: licenses, FetchedAt: c.now()})
+
+	return licenses, nil
+}
+
+func (c *CachedLicenseLookup) entryPath(purl string) string {
+	sum := sha256.Sum256([]byte(purl))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// store writes an entry atomically, so readers never observe a partial write.
+func (c *CachedLicenseLookup) store(file string, entry licenseCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(file, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to name and renames it
+// into place, so readers never observe a partial write.
+func writeFileAtomic(name string, data []byte) error {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,80 @@
+package sbomvalidator
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingLicenseLookup struct {
+	calls    int
+	licenses []string
+	err      error
+}
+
+func (c *countingLicenseLookup) Licenses(purl string) ([]string, error) {
+	c.calls++
+	return c.licenses, c.err
+}
+
+func TestCachedLicenseLookup(t *testing.T) {
+	dir := t.TempDir()
+	next := &countingLicenseLookup{licenses: []string{"MIT"}}
+
+	cache, err := NewCachedLicenseLookup(next, dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Date(2024, 10, 22, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		licenses, err := cache.Licenses("pkg:npm/lodash@4.17.21")
+		if err != nil || len(licenses) != 1 || licenses[0] != "MIT" {
+			t.Fatalf("Licenses() = %v, %v", licenses, err)
+		}
+	}
+	if next.calls != 1 {
+		t.Errorf("expected 1 upstream call, got %d", next.calls)
+	}
+
+	// a new instance sharing the directory reuses the entry across runs
+	other, err := NewCachedLicenseLookup(next, dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	other.now = func() time.Time { return now.Add(30 * time.Minute) }
+	if _, err := other.Licenses("pkg:npm/lodash@4.17.21"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if next.calls != 1 {
+		t.Errorf("expected cached result across instances, got %d upstream calls", next.calls)
+	}
+
+	// expired entries are refreshed
+	other.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, err := other.Licenses("pkg:npm/lodash@4.17.21"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if next.calls != 2 {
+		t.Errorf("expected expired entry to be refreshed, got %d upstream calls", next.calls)
+	}
+}
+
+func TestCachedLicenseLookupDoesNotCacheErrors(t *testing.T) {
+	next := &countingLicenseLookup{err: errors.New("registry unavailable")}
+
+	cache, err := NewCachedLicenseLookup(next, t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Licenses("pkg:npm/lodash@4.17.21"); err == nil {
+			t.Fatalf("expected an error")
+		}
+	}
+	if next.calls != 2 {
+		t.Errorf("expected errors not to be cached, got %d upstream calls", next.calls)
+	}
+}
@@ -0,0 +1,515 @@
+// Package lsp implements a minimal Language Server Protocol server that
+// publishes sbom-validator diagnostics for open SBOM documents.
+//
+// Only the parts of the protocol needed for diagnostics are implemented:
+// full document sync, publishDiagnostics and quick-fix code actions. The
+// server speaks JSON-RPC 2.0 with Content-Length framing over the provided
+// reader and writer, which is normally stdin/stdout.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// Rule IDs reported as the diagnostic code. They are the IDs from the
+// sbomvalidator rule catalog.
+const (
+	RuleSchema             = sbomvalidator.RuleSchema
+	RuleOmniBORID          = sbomvalidator.RuleOmniBORID
+	RuleIdentifierMismatch = sbomvalidator.RuleIdentifierMismatch
+	RuleUnknownSpecVersion = sbomvalidator.RuleUnknownSpecVersion
+	RuleDocument           = sbomvalidator.RuleDocument
+	RuleWeakCrypto         = sbomvalidator.RuleWeakCrypto
+	RuleLifecycle          = sbomvalidator.RuleLifecycle
+)
+
+// LSP diagnostic severities.
+const (
+	severityError       = 1
+	severityWarning     = 2
+	severityInformation = 3
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// Position is a zero-based line and character offset. Offsets are counted in
+// bytes, which matches the protocol's UTF-16 offsets for ASCII documents.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span of text in a document.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Diagnostic is a single problem reported for a document.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+	// CodeDescription links to the specification clause the diagnostic violates.
+	CodeDescription *CodeDescription `json:"codeDescription,omitempty"`
+	Data            *quickFixTarget  `json:"data,omitempty"`
+}
+
+// CodeDescription holds a link describing a diagnostic.
+type CodeDescription struct {
+	Href string `json:"href"`
+}
+
+// quickFixTarget is attached to diagnostics that have a quick fix.
+type quickFixTarget struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+	Title   string `json:"title"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+}
+
+type codeActionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Context struct {
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	} `json:"context"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Server writes responses and notifications for a single client connection.
+type Server struct {
+	out     io.Writer
+	writeMu sync.Mutex
+}
+
+// Serve runs an LSP server reading requests from in and writing responses and
+// notifications to out. It returns when the client sends "exit" or in is
+// closed.
+//
+// Example:
+//
+//	if err := lsp.Serve(os.Stdin, os.Stdout); err != nil {
+//	    log.Fatal(err)
+//	}
+func Serve(in io.Reader, out io.Writer) error {
+	s := &Server{out: out}
+	reader := bufio.NewReader(in)
+
+	for {
+		body, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			// the ID of a message that is not JSON is unknown, so the
+			// error is sent with a null ID, as JSON-RPC specifies
+			if err := s.write(response{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage("null"),
+				Error:   &responseError{Code: -32700, Message: "parse error: " + err.Error()},
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req request) error {
+	switch req.Method {
+	case "initialize":
+		return s.reply(req.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// 1 = full document sync
+				"textDocumentSync":   1,
+				"codeActionProvider": map[string]interface{}{"codeActionKinds": []string{"quickfix"}},
+			},
+			"serverInfo": map[string]string{"name": "sbom-validator"},
+		})
+
+	case "shutdown":
+		return s.reply(req.ID, nil)
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		return s.publish(params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		// with full sync the last change holds the whole document
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.publish(params.TextDocument.URI, text)
+
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/codeAction":
+		var params codeActionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return s.reply(req.ID, []interface{}{})
+		}
+		return s.reply(req.ID, codeActions(params))
+	}
+
+	// reply to unknown requests so clients do not wait forever;
+	// notifications (no ID) are ignored
+	if len(req.ID) > 0 {
+		return s.write(response{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &responseError{Code: -32601, Message: "method not found: " + req.Method},
+		})
+	}
+	return nil
+}
+
+// publish validates a document and sends its diagnostics. Documents that are
+// not SBOMs (by file name) are ignored.
+func (s *Server) publish(uri string, text string) error {
+	if !isSBOMFile(uri) {
+		return nil
+	}
+
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: Diagnose([]byte(text)),
+	})
+}
+
+// isSBOMFile reports whether a document URI looks like a JSON SBOM.
+func isSBOMFile(uri string) bool {
+	name := strings.ToLower(path.Base(uri))
+	return strings.HasSuffix(name, ".cdx.json") || strings.HasSuffix(name, ".spdx.json")
+}
+
+var schemaVersionPattern = regexp.MustCompile(`-(\d+(?:\.\d+)*)\.schema\.json$`)
+
+// Diagnose validates an SBOM document and converts the outcome into LSP
+// diagnostics, one per finding, whose code is the finding's rule.
+func Diagnose(text []byte) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	lines := strings.Split(string(text), "\n")
+
+	result, err := sbomvalidator.ValidateSBOMDataStructured(text, sbomvalidator.WithAllowUnknownVersion(true))
+	if err != nil {
+		line, col := 0, 0
+		if result != nil {
+			line, col = result.Locate("(root)")
+		}
+		return append(diagnostics, newDiagnostic(lines, line, col, severityError, RuleDocument, err.Error()))
+	}
+
+	for _, f := range result.Findings {
+		msg := f.String()
+		line, col := result.Locate(msg)
+		if f.Rule == RuleUnknownSpecVersion {
+			line, col = result.Locate(specVersionField(result.SBOMType))
+		}
+		d := newDiagnostic(lines, line, col, findingSeverity(f.Level), f.Rule, msg)
+
+		if f.Level == sbomvalidator.LevelError {
+			if ref, ok := result.SpecReferenceFor(msg); ok {
+				d.Message = fmt.Sprintf("%s (%s)", msg, ref.Section)
+				d.CodeDescription = &CodeDescription{Href: ref.URL}
+			}
+		}
+		switch {
+		case f.Rule == RuleUnknownSpecVersion:
+			d.Data = specVersionFix(lines, line, col, &result.ValidationResult)
+		case len(f.Fix) > 0:
+			d.Data = documentFix(text, lines, f)
+		}
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics
+}
+
+// findingSeverity returns the diagnostic severity of a finding level.
+func findingSeverity(level sbomvalidator.FindingLevel) int {
+	switch level {
+	case sbomvalidator.LevelError:
+		return severityError
+	case sbomvalidator.LevelWarning:
+		return severityWarning
+	}
+	return severityInformation
+}
+
+// specVersionField returns the field holding the spec version of an SBOM type.
+func specVersionField(sbomType string) string {
+	if sbomType == sbomvalidator.SBOM_SPDX {
+		return "spdxVersion"
+	}
+	return "specVersion"
+}
+
+// specVersionFix returns a quick fix setting the spec version value located
+// at (line, col) to the version of the schema the SBOM was validated against.
+func specVersionFix(lines []string, line, col int, result *sbomvalidator.ValidationResult) *quickFixTarget {
+	m := schemaVersionPattern.FindStringSubmatch(result.SchemaUsed)
+	if m == nil {
+		return nil
+	}
+	field := specVersionField(result.SBOMType)
+	latest := m[1]
+	if field == "spdxVersion" {
+		latest = sbomvalidator.SBOM_SPDX + "-" + latest
+	}
+	r, ok := stringValueRange(lines, line, col)
+	if !ok {
+		return nil
+	}
+	return &quickFixTarget{
+		Range:   r,
+		NewText: strconv.Quote(latest),
+		Title:   fmt.Sprintf("Set %s to %s", field, latest),
+	}
+}
+
+// documentFix returns a quick fix applying the JSON Patch of a finding. The
+// patched document replaces the whole text, since the patch is applied to its
+// JSON form rather than to the text.
+func documentFix(text []byte, lines []string, f sbomvalidator.Finding) *quickFixTarget {
+	fixed, err := sbomvalidator.ApplyFixes(text, []sbomvalidator.Finding{f})
+	if err != nil {
+		return nil
+	}
+	titles := make([]string, 0, len(f.Fix))
+	for _, op := range f.Fix {
+		titles = append(titles, op.Op+" "+op.Path)
+	}
+	last := len(lines) - 1
+	return &quickFixTarget{
+		Range: Range{
+			End: Position{Line: last, Character: len(lines[last])},
+		},
+		NewText: string(fixed),
+		Title:   "Fix: " + strings.Join(titles, ", "),
+	}
+}
+
+// newDiagnostic builds a diagnostic spanning from the located position to the
+// end of its line. Unknown positions (0, 0) are reported on the first line.
+func newDiagnostic(lines []string, line, col int, severity int, code, message string) Diagnostic {
+	if line < 1 {
+		line, col = 1, 1
+	}
+	end := col
+	if line-1 < len(lines) {
+		end = len(strings.TrimRight(lines[line-1], "\r")) + 1
+	}
+
+	return Diagnostic{
+		Range: Range{
+			Start: Position{Line: line - 1, Character: col - 1},
+			End:   Position{Line: line - 1, Character: end - 1},
+		},
+		Severity: severity,
+		Code:     code,
+		Source:   "sbom-validator",
+		Message:  message,
+	}
+}
+
+// stringValueRange finds the range of the quoted string value following the
+// key located at (line, col), e.g. the `"1.9"` in `"specVersion": "1.9"`.
+func stringValueRange(lines []string, line, col int) (Range, bool) {
+	if line < 1 || line > len(lines) {
+		return Range{}, false
+	}
+	text := lines[line-1]
+	if col-1 > len(text) {
+		return Range{}, false
+	}
+
+	rest := text[col-1:]
+	colon := strings.Index(rest, ":")
+	if colon < 0 {
+		return Range{}, false
+	}
+	open := strings.Index(rest[colon:], `"`)
+	if open < 0 {
+		return Range{}, false
+	}
+	start := col - 1 + colon + open
+	closing := strings.Index(text[start+1:], `"`)
+	if closing < 0 {
+		return Range{}, false
+	}
+	end := start + 1 + closing + 1
+
+	return Range{
+		Start: Position{Line: line - 1, Character: start},
+		End:   Position{Line: line - 1, Character: end},
+	}, true
+}
+
+// codeActions returns quick fixes for the diagnostics in the request that
+// carry a fix target.
+func codeActions(params codeActionParams) []interface{} {
+	actions := []interface{}{}
+	for _, d := range params.Context.Diagnostics {
+		if d.Data == nil {
+			continue
+		}
+		actions = append(actions, map[string]interface{}{
+			"title":       d.Data.Title,
+			"kind":        "quickfix",
+			"diagnostics": []Diagnostic{d},
+			"edit": map[string]interface{}{
+				"changes": map[string]interface{}{
+					params.TextDocument.URI: []map[string]interface{}{
+						{"range": d.Data.Range, "newText": d.Data.NewText},
+					},
+				},
+			},
+		})
+	}
+	return actions
+}
+
+func (s *Server) reply(id json.RawMessage, result interface{}) error {
+	return s.write(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) write(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = s.out.Write(body)
+	return err
+}
+
+// maxMessageSize bounds the Content-Length of a message, so a corrupt or
+// hostile header cannot make the server allocate an arbitrary amount of
+// memory. It leaves room for the largest SBOMs an editor would open.
+const maxMessageSize = 256 << 20
+
+// readMessage reads one Content-Length framed message.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %w", err)
+	}
+	if length < 0 || length > maxMessageSize {
+		return nil, fmt.Errorf("invalid Content-Length header: %d is not between 0 and %d", length, maxMessageSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,193 @@
+//go:build !sbomvalidator_no_spdx
+
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func frame(t *testing.T, msg interface{}) string {
+	t.Helper()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantCodes []string
+		wantLine  int
+		wantFix   string
+	}{
+		{
+			name:      "Not JSON",
+			text:      "<bom/>",
+			wantCodes: []string{RuleDocument},
+		},
+		{
+			name:      "Schema error",
+			text:      "{\n  \"spdxVersion\": \"SPDX-2.3\",\n  \"name\": 5\n}",
+			wantCodes: []string{RuleSchema},
+		},
+		{
+			name:      "Unknown spec version with quick fix",
+			text:      "{\n  \"spdxVersion\": \"SPDX-2.9\"\n}",
+			wantCodes: []string{RuleUnknownSpecVersion},
+			wantLine:  1,
+			wantFix:   `"SPDX-2.3"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := Diagnose([]byte(tt.text))
+
+			codes := map[string]bool{}
+			for _, d := range diagnostics {
+				codes[d.Code] = true
+			}
+			for _, code := range tt.wantCodes {
+				if !codes[code] {
+					t.Errorf("expected a %q diagnostic, got %+v", code, diagnostics)
+				}
+			}
+
+			if tt.wantFix == "" {
+				return
+			}
+			for _, d := range diagnostics {
+				if d.Code != RuleUnknownSpecVersion {
+					continue
+				}
+				if d.Range.Start.Line != tt.wantLine {
+					t.Errorf("diagnostic line = %d, want %d", d.Range.Start.Line, tt.wantLine)
+				}
+				if d.Data == nil || d.Data.NewText != tt.wantFix {
+					t.Fatalf("quick fix = %+v, want new text %s", d.Data, tt.wantFix)
+				}
+				if d.Data.Range.Start.Character != 17 || d.Data.Range.End.Character != 27 {
+					t.Errorf("quick fix range = %+v, want characters 17-27", d.Data.Range)
+				}
+			}
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	input := frame(t, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]interface{}{}}) +
+		frame(t, map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": "file:///tmp/app.spdx.json", "text": `{"spdxVersion": "SPDX-2.3", "name": 5}`},
+		}}) +
+		frame(t, map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": "file:///tmp/package.json", "text": `{}`},
+		}}) +
+		frame(t, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "workspace/unknown"}) +
+		"Content-Length: 5\r\n\r\n{oops" +
+		frame(t, map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "shutdown"}) +
+		frame(t, map[string]interface{}{"jsonrpc": "2.0", "method": "exit"})
+
+	var out bytes.Buffer
+	if err := Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	reader := bufio.NewReader(&out)
+	var messages []map[string]interface{}
+	for {
+		body, err := readMessage(reader)
+		if err != nil {
+			break
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid output message: %v", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	// initialize reply, diagnostics for the SBOM only, method-not-found,
+	// parse error, shutdown reply
+	if len(messages) != 5 {
+		t.Fatalf("got %d messages, want 5: %v", len(messages), messages)
+	}
+	if messages[1]["method"] != "textDocument/publishDiagnostics" {
+		t.Errorf("message 1 = %v, want publishDiagnostics", messages[1])
+	}
+	if messages[2]["error"] == nil {
+		t.Errorf("message 2 = %v, want method-not-found error", messages[2])
+	}
+	if parseErr, _ := messages[3]["error"].(map[string]interface{}); parseErr["code"] != float64(-32700) || messages[3]["id"] != nil {
+		t.Errorf("message 3 = %v, want a parse error with a null ID", messages[3])
+	}
+}
+
+func TestDiagnoseUnknownSpecVersion(t *testing.T) {
+	diagnostics := Diagnose([]byte("{\n  \"spdxVersion\": \"SPDX-2.9\"\n}"))
+
+	var unknown int
+	for _, d := range diagnostics {
+		switch d.Code {
+		case RuleUnknownSpecVersion:
+			unknown++
+			if d.Severity != severityWarning {
+				t.Errorf("severity = %d, want %d", d.Severity, severityWarning)
+			}
+		case RuleIdentifierMismatch:
+			t.Errorf("unexpected identifier mismatch diagnostic: %+v", d)
+		}
+	}
+	if unknown != 1 {
+		t.Errorf("got %d %s diagnostics, want 1: %+v", unknown, RuleUnknownSpecVersion, diagnostics)
+	}
+}
+
+func TestDiagnoseFix(t *testing.T) {
+	text := "{\n  \"spdxVersion\": \"SPDX-2.3\",\n  \"colour\": \"red\"\n}"
+	for _, d := range Diagnose([]byte(text)) {
+		if d.Code != RuleSchema || !strings.Contains(d.Message, "colour") {
+			continue
+		}
+		if d.Data == nil || strings.Contains(d.Data.NewText, "colour") || d.Data.Range.End.Line != 3 {
+			t.Fatalf("quick fix = %+v, want the document without colour", d.Data)
+		}
+		return
+	}
+	t.Fatal("no diagnostic for the additional property")
+}
+
+func TestReadMessageContentLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "Valid", input: "Content-Length: 2\r\n\r\n{}"},
+		{name: "Negative", input: "Content-Length: -1\r\n\r\n", wantErr: "is not between"},
+		{name: "Too large", input: fmt.Sprintf("Content-Length: %d\r\n\r\n", maxMessageSize+1), wantErr: "is not between"},
+		{name: "Not a number", input: "Content-Length: x\r\n\r\n", wantErr: "invalid Content-Length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := readMessage(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readMessage() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(body) != "{}" {
+				t.Errorf("readMessage() = %q, %v", body, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package sbomvalidator
+
+// checkMLBOMProfile applies the ML-BOM profile to every machine learning model
+// component of a parsed CycloneDX document. Each model must:
+//
+//   - reference at least one dataset in modelCard.modelParameters.datasets;
+//   - declare a license;
+//   - report at least one performance metric in
+//     modelCard.quantitativeAnalysis.performanceMetrics.
+//
+// Returns an error per missing requirement, at the JSON path of the
+// component.
+func checkMLBOMProfile(obj map[string]interface{}) []Finding {
+	var errors []Finding
+
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		if componentType, _ := component["type"].(string); componentType != "machine-learning-model" {
+			return
+		}
+
+		modelCard, _ := component["modelCard"].(map[string]interface{})
+		parameters, _ := modelCard["modelParameters"].(map[string]interface{})
+		analysis, _ := modelCard["quantitativeAnalysis"].(map[string]interface{})
+
+		add := func(rule, message string) {
+			errors = append(errors, Finding{Level: LevelError, Rule: rule, Path: path, Pointer: jsonPointer(path), Message: message})
+		}
+
+		if datasets, _ := parameters["datasets"].([]interface{}); len(datasets) == 0 {
+			add(RuleMLDataset, "machine learning model has no dataset references (modelCard.modelParameters.datasets)")
+		}
+		if licenses, _ := component["licenses"].([]interface{}); len(licenses) == 0 {
+			add(RuleMLLicense, "machine learning model has no license")
+		}
+		if metrics, _ := analysis["performanceMetrics"].([]interface{}); len(metrics) == 0 {
+			add(RuleMLQuantitativeAnalysis, "machine learning model has no quantitative analysis (modelCard.quantitativeAnalysis.performanceMetrics)")
+		}
+	})
+
+	return errors
+}
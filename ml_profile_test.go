@@ -0,0 +1,81 @@
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckMLBOMProfile(t *testing.T) {
+	complete := `{"type": "machine-learning-model", "name": "classifier",
+		"licenses": [{"license": {"id": "Apache-2.0"}}],
+		"modelCard": {
+			"modelParameters": {"datasets": [{"ref": "dataset-1"}]},
+			"quantitativeAnalysis": {"performanceMetrics": [{"type": "accuracy", "value": "0.93"}]}
+		}}`
+
+	tests := []struct {
+		name      string
+		component string
+		want      []string
+	}{
+		{name: "Complete model", component: complete},
+		{
+			name:      "Model without model card or license",
+			component: `{"type": "machine-learning-model", "name": "classifier"}`,
+			want:      []string{"no dataset references", "no license", "no quantitative analysis"},
+		},
+		{
+			name: "Model with empty datasets",
+			component: `{"type": "machine-learning-model", "name": "classifier",
+				"licenses": [{"license": {"id": "MIT"}}],
+				"modelCard": {"modelParameters": {"datasets": []},
+					"quantitativeAnalysis": {"performanceMetrics": [{"type": "f1"}]}}}`,
+			want: []string{"no dataset references"},
+		},
+		{name: "Not a model", component: `{"type": "library", "name": "numpy"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(`{"bomFormat": "CycloneDX", "components": [` + tt.component + `]}`)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			errors := checkMLBOMProfile(obj)
+			if len(errors) != len(tt.want) {
+				t.Fatalf("got %v, want %d errors", errors, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if msg := errors[i].String(); !strings.HasPrefix(msg, "components.0: ") || !strings.Contains(msg, want) {
+					t.Errorf("error %q does not mention %q", msg, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckProfiles(t *testing.T) {
+	obj, _ := parseJSON(`{"bomFormat": "CycloneDX", "components": [{"type": "machine-learning-model", "name": "m"}]}`)
+
+	errors, rules, err := checkProfiles(obj, SBOM_CYCLONEDX, []Profile{ProfileMLBOM})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(errors) != 3 {
+		t.Errorf("expected 3 errors, got %v", errors)
+	}
+	if strings.Join(rules, ",") != "ml-dataset,ml-license,ml-quantitative-analysis" {
+		t.Errorf("unexpected rules %v", rules)
+	}
+
+	if _, _, err := checkProfiles(obj, SBOM_CYCLONEDX, []Profile{"no-such-profile"}); err == nil {
+		t.Errorf("expected an error for an unknown profile")
+	}
+}
+
+func TestWithProfilesUnknown(t *testing.T) {
+	if _, err := ValidateSBOMData(spdxDocument(), WithProfiles("no-such-profile")); err == nil {
+		t.Errorf("expected an error for an unknown profile")
+	}
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+// CycloneDXDocument is a CycloneDX BOM.
+type CycloneDXDocument struct {
+	BOMFormat          string                       `json:"bomFormat"`
+	SpecVersion        string                       `json:"specVersion"`
+	SerialNumber       string                       `json:"serialNumber,omitempty"`
+	Version            int                          `json:"version,omitempty"`
+	Metadata           *CycloneDXMetadata           `json:"metadata,omitempty"`
+	Components         []CycloneDXComponent         `json:"components,omitempty"`
+	Dependencies       []CycloneDXDependency        `json:"dependencies,omitempty"`
+	ExternalReferences []CycloneDXExternalReference `json:"externalReferences,omitempty"`
+	Properties         []CycloneDXProperty          `json:"properties,omitempty"`
+}
+
+// CycloneDXMetadata describes a BOM: when and by what it was created and the
+// component it describes.
+type CycloneDXMetadata struct {
+	// Timestamp is an RFC 3339 date-time.
+	Timestamp    string                   `json:"timestamp,omitempty"`
+	Lifecycles   []CycloneDXLifecycle     `json:"lifecycles,omitempty"`
+	Tools        *CycloneDXTools          `json:"tools,omitempty"`
+	Authors      []CycloneDXContact       `json:"authors,omitempty"`
+	Component    *CycloneDXComponent      `json:"component,omitempty"`
+	Manufacturer *CycloneDXOrganization   `json:"manufacturer,omitempty"`
+	Supplier     *CycloneDXOrganization   `json:"supplier,omitempty"`
+	Licenses     []CycloneDXLicenseChoice `json:"licenses,omitempty"`
+	Properties   []CycloneDXProperty      `json:"properties,omitempty"`
+}
+
+// CycloneDXLifecycle is a pre-defined lifecycle phase (e.g., "build") or a
+// custom one, with a name.
+type CycloneDXLifecycle struct {
+	Phase       string `json:"phase,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// CycloneDXTools are the tools that created a BOM.
+type CycloneDXTools struct {
+	Components []CycloneDXComponent `json:"components,omitempty"`
+	Services   []CycloneDXService   `json:"services,omitempty"`
+}
+
+// UnmarshalJSON accepts both the tools object of CycloneDX 1.5 and later and
+// the deprecated array of tools, whose entries become components with the
+// tool's vendor as publisher.
+func (t *CycloneDXTools) UnmarshalJSON(data []byte) error {
+	var legacy []struct {
+		Vendor  string          `json:"vendor"`
+		Name    string          `json:"name"`
+		Version string          `json:"version"`
+		Hashes  []CycloneDXHash `json:"hashes"`
+	}
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		*t = CycloneDXTools{}
+		for _, tool := range legacy {
+			t.Components = append(t.Components, CycloneDXComponent{
+				Type:      "application",
+				Publisher: tool.Vendor,
+				Name:      tool.Name,
+				Version:   tool.Version,
+				Hashes:    tool.Hashes,
+			})
+		}
+		return nil
+	}
+
+	type tools CycloneDXTools
+	return json.Unmarshal(data, (*tools)(t))
+}
+
+// CycloneDXService is a service, such as a tool run as a service.
+type CycloneDXService struct {
+	BOMRef    string                 `json:"bom-ref,omitempty"`
+	Provider  *CycloneDXOrganization `json:"provider,omitempty"`
+	Group     string                 `json:"group,omitempty"`
+	Name      string                 `json:"name"`
+	Version   string                 `json:"version,omitempty"`
+	Endpoints []string               `json:"endpoints,omitempty"`
+}
+
+// CycloneDXComponent is a component, with its nested components.
+type CycloneDXComponent struct {
+	Type               string                       `json:"type"`
+	MIMEType           string                       `json:"mime-type,omitempty"`
+	BOMRef             string                       `json:"bom-ref,omitempty"`
+	Supplier           *CycloneDXOrganization       `json:"supplier,omitempty"`
+	Manufacturer       *CycloneDXOrganization       `json:"manufacturer,omitempty"`
+	Authors            []CycloneDXContact           `json:"authors,omitempty"`
+	Author             string                       `json:"author,omitempty"`
+	Publisher          string                       `json:"publisher,omitempty"`
+	Group              string                       `json:"group,omitempty"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	Description        string                       `json:"description,omitempty"`
+	Scope              string                       `json:"scope,omitempty"`
+	Hashes             []CycloneDXHash              `json:"hashes,omitempty"`
+	Licenses           []CycloneDXLicenseChoice     `json:"licenses,omitempty"`
+	Copyright          string                       `json:"copyright,omitempty"`
+	CPE                string                       `json:"cpe,omitempty"`
+	PURL               string                       `json:"purl,omitempty"`
+	OmniBORID          []string                     `json:"omniborId,omitempty"`
+	SWHID              []string                     `json:"swhid,omitempty"`
+	ExternalReferences []CycloneDXExternalReference `json:"externalReferences,omitempty"`
+	Properties         []CycloneDXProperty          `json:"properties,omitempty"`
+	Components         []CycloneDXComponent         `json:"components,omitempty"`
+}
+
+// CycloneDXOrganization is an organization, such as a supplier.
+type CycloneDXOrganization struct {
+	Name    string             `json:"name,omitempty"`
+	URL     []string           `json:"url,omitempty"`
+	Contact []CycloneDXContact `json:"contact,omitempty"`
+}
+
+// CycloneDXContact is an individual, such as an author.
+type CycloneDXContact struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// CycloneDXHash is a hash, e.g., {Alg: "SHA-256", Content: "9f86d0..."}.
+type CycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// CycloneDXLicenseChoice is either a license or an SPDX license expression.
+type CycloneDXLicenseChoice struct {
+	License    *CycloneDXLicense `json:"license,omitempty"`
+	Expression string            `json:"expression,omitempty"`
+	// Acknowledgement of an expression: "declared" or "concluded".
+	Acknowledgement string `json:"acknowledgement,omitempty"`
+}
+
+// CycloneDXLicense is a license, identified by its SPDX license ID or by
+// name.
+type CycloneDXLicense struct {
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name,omitempty"`
+	URL             string `json:"url,omitempty"`
+	Acknowledgement string `json:"acknowledgement,omitempty"`
+}
+
+// CycloneDXExternalReference is a reference to an external resource, such as
+// a VCS repository or a distribution.
+type CycloneDXExternalReference struct {
+	Type    string          `json:"type"`
+	URL     string          `json:"url"`
+	Comment string          `json:"comment,omitempty"`
+	Hashes  []CycloneDXHash `json:"hashes,omitempty"`
+}
+
+// CycloneDXDependency lists the components or services that the one with
+// bom-ref Ref depends on.
+type CycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+	Provides  []string `json:"provides,omitempty"`
+}
+
+// CycloneDXProperty is a name-value pair.
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// AllComponents returns every component of the BOM, including nested
+// components, depth first, but not the metadata component.
+func (d *CycloneDXDocument) AllComponents() []*CycloneDXComponent {
+	var all []*CycloneDXComponent
+	var walk func(components []CycloneDXComponent)
+	walk = func(components []CycloneDXComponent) {
+		for i := range components {
+			all = append(all, &components[i])
+			walk(components[i].Components)
+		}
+	}
+	walk(d.Components)
+	return all
+}
+
+// Component returns the component with the given bom-ref, which may be the
+// metadata component or a nested one, or nil.
+func (d *CycloneDXDocument) Component(ref string) *CycloneDXComponent {
+	if d.Metadata != nil && d.Metadata.Component != nil && d.Metadata.Component.BOMRef == ref {
+		return d.Metadata.Component
+	}
+	for _, c := range d.AllComponents() {
+		if c.BOMRef == ref {
+			return c
+		}
+	}
+	return nil
+}
+
+// DependsOn returns the bom-refs that ref depends on.
+func (d *CycloneDXDocument) DependsOn(ref string) []string {
+	for _, dep := range d.Dependencies {
+		if dep.Ref == ref {
+			return dep.DependsOn
+		}
+	}
+	return nil
+}
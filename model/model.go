@@ -0,0 +1,125 @@
+// Package model unmarshals validated SBOMs into typed Go structs, so
+// downstream code can consume an SBOM without traversing
+// map[string]interface{} values.
+//
+// CycloneDXDocument covers the metadata, components, dependencies, licenses,
+// hashes and external references of a CycloneDX BOM; SPDXDocument the
+// creation info, packages, files, relationships and licensing of an SPDX
+// document. Fields outside the model are dropped; use
+// `sbomvalidator.DecodeSBOM` for the whole document.
+//
+// The input is validated first, as by `sbomvalidator.ValidateSBOMData`, so
+// the model is never populated from an invalid SBOM, and may be in any
+// encoding validation accepts, such as CycloneDX XML or SPDX tag-value.
+//
+// Example:
+//
+//	bom, err := model.ParseCycloneDX(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, c := range bom.AllComponents() {
+//	    fmt.Println(c.Name, c.Version, c.PURL)
+//	}
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// Document is an SBOM of either format. Exactly one of CycloneDX and SPDX is
+// set, according to Type.
+type Document struct {
+	Type      sbomvalidator.SBOMType
+	CycloneDX *CycloneDXDocument
+	SPDX      *SPDXDocument
+}
+
+// Parse validates an SBOM and unmarshals it into the model of its format.
+//
+// Parameters:
+//   - sbom: The SBOM, in any encoding `sbomvalidator.ValidateSBOMData`
+//     accepts.
+//   - opts: Optional settings, passed to `sbomvalidator.ValidateSBOMData`.
+//
+// Returns:
+//   - The Document.
+//   - An error if the SBOM cannot be validated or is invalid.
+//
+// Example:
+//
+//	doc, err := model.Parse(data)
+//	if err == nil && doc.SPDX != nil {
+//	    fmt.Println(doc.SPDX.DocumentNamespace)
+//	}
+func Parse(sbom []byte, opts ...sbomvalidator.Option) (*Document, error) {
+	validation, err := sbomvalidator.ValidateSBOMData(sbom, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate the SBOM: %v", err)
+	}
+	if !validation.IsValid {
+		return nil, fmt.Errorf("the SBOM is invalid: %s", strings.Join(validation.ValidationErrors, "; "))
+	}
+
+	jsonContent, err := sbomvalidator.DecodeSBOM(sbom, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the SBOM: %v", err)
+	}
+	return decode(jsonContent, validation.SBOMType, validation.SBOMVersion)
+}
+
+// decode unmarshals the JSON form of a validated SBOM of the given type
+// (e.g., `sbomvalidator.SBOM_CYCLONEDX`) and spec version.
+func decode(jsonContent []byte, sbomType, version string) (*Document, error) {
+	doc := &Document{Type: sbomvalidator.SBOMType{
+		Format:  sbomvalidator.FormatCycloneDX,
+		Version: sbomvalidator.SpecVersion(version),
+	}}
+	var target interface{}
+	if strings.HasPrefix(sbomType, sbomvalidator.SBOM_SPDX) {
+		doc.Type.Format = sbomvalidator.FormatSPDX
+		doc.SPDX = &SPDXDocument{}
+		target = doc.SPDX
+	} else {
+		doc.CycloneDX = &CycloneDXDocument{}
+		target = doc.CycloneDX
+	}
+	if err := json.Unmarshal(jsonContent, target); err != nil {
+		return nil, fmt.Errorf("failed to parse the %s SBOM: %v", doc.Type.Format, err)
+	}
+	return doc, nil
+}
+
+// ParseCycloneDX validates a CycloneDX SBOM and unmarshals it. See Parse.
+//
+// Returns an error if the SBOM cannot be validated, is invalid or is not
+// CycloneDX.
+func ParseCycloneDX(sbom []byte, opts ...sbomvalidator.Option) (*CycloneDXDocument, error) {
+	doc, err := Parse(sbom, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if doc.CycloneDX == nil {
+		return nil, fmt.Errorf("the SBOM is %s, not %s", doc.Type.Format, sbomvalidator.FormatCycloneDX)
+	}
+	return doc.CycloneDX, nil
+}
+
+// ParseSPDX validates an SPDX SBOM and unmarshals it. See Parse.
+//
+// Returns an error if the SBOM cannot be validated, is invalid or is not
+// SPDX.
+func ParseSPDX(sbom []byte, opts ...sbomvalidator.Option) (*SPDXDocument, error) {
+	doc, err := Parse(sbom, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if doc.SPDX == nil {
+		return nil, fmt.Errorf("the SBOM is %s, not %s", doc.Type.Format, sbomvalidator.FormatSPDX)
+	}
+	return doc.SPDX, nil
+}
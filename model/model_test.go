@@ -0,0 +1,194 @@
+//go:build !sbomvalidator_no_cyclonedx && !sbomvalidator_no_spdx
+
+package model
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+const cycloneDX = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+  "version": 1,
+  "metadata": {
+    "timestamp": "2024-10-22T12:00:00Z",
+    "tools": {"components": [{"type": "application", "name": "syft", "version": "1.14.0"}]},
+    "component": {"type": "application", "bom-ref": "app", "name": "app", "version": "2.0.0"},
+    "lifecycles": [{"phase": "build"}]
+  },
+  "components": [
+    {"type": "library", "bom-ref": "left-pad", "name": "left-pad", "version": "1.3.0", "supplier": {"name": "npm"},
+     "hashes": [{"alg": "SHA-256", "content": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}],
+     "licenses": [{"license": {"id": "MIT"}}, {"expression": "Apache-2.0 OR BSD-3-Clause", "acknowledgement": "concluded"}],
+     "purl": "pkg:npm/left-pad@1.3.0",
+     "components": [{"type": "file", "bom-ref": "left-pad/index.js", "name": "index.js"}]}
+  ],
+  "dependencies": [
+    {"ref": "app", "dependsOn": ["left-pad"]},
+    {"ref": "left-pad"}
+  ]
+}`
+
+const spdx = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "app-2.0.0",
+  "documentNamespace": "https://example.com/app-2.0.0",
+  "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: syft-1.14.0"]},
+  "packages": [
+    {"SPDXID": "SPDXRef-app", "name": "app", "versionInfo": "2.0.0", "downloadLocation": "NOASSERTION", "filesAnalyzed": false},
+    {"SPDXID": "SPDXRef-left-pad", "name": "left-pad", "versionInfo": "1.3.0", "downloadLocation": "NOASSERTION",
+     "licenseConcluded": "MIT", "licenseDeclared": "MIT",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/left-pad@1.3.0"}]}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-app"},
+    {"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-left-pad"}
+  ]
+}`
+
+// decodeCycloneDX decodes a CycloneDX SBOM without validating it, since
+// CycloneDX validation needs the network for the JSF schema.
+func decodeCycloneDX(t *testing.T, data []byte) *CycloneDXDocument {
+	t.Helper()
+	jsonContent, err := sbomvalidator.DecodeSBOM(data)
+	if err != nil {
+		t.Fatalf("failed to decode the SBOM: %v", err)
+	}
+	doc, err := decode(jsonContent, sbomvalidator.SBOM_CYCLONEDX, "1.6")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return doc.CycloneDX
+}
+
+func TestCycloneDXDocument(t *testing.T) {
+	bom := decodeCycloneDX(t, []byte(cycloneDX))
+
+	if bom.SpecVersion != "1.6" || bom.Version != 1 || bom.Metadata.Component.Name != "app" ||
+		bom.Metadata.Lifecycles[0].Phase != "build" || bom.Metadata.Tools.Components[0].Name != "syft" {
+		t.Errorf("unexpected BOM: %+v", bom)
+	}
+
+	var refs []string
+	for _, c := range bom.AllComponents() {
+		refs = append(refs, c.BOMRef)
+	}
+	if want := []string{"left-pad", "left-pad/index.js"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("AllComponents() = %q, want %q", refs, want)
+	}
+
+	leftPad := bom.Component("left-pad")
+	if leftPad == nil || leftPad.Supplier.Name != "npm" || leftPad.Hashes[0].Alg != "SHA-256" || leftPad.PURL != "pkg:npm/left-pad@1.3.0" {
+		t.Fatalf("Component(left-pad) = %+v", leftPad)
+	}
+	wantLicenses := []CycloneDXLicenseChoice{
+		{License: &CycloneDXLicense{ID: "MIT"}},
+		{Expression: "Apache-2.0 OR BSD-3-Clause", Acknowledgement: "concluded"},
+	}
+	if !reflect.DeepEqual(leftPad.Licenses, wantLicenses) {
+		t.Errorf("licenses = %+v, want %+v", leftPad.Licenses, wantLicenses)
+	}
+	if bom.Component("app") != bom.Metadata.Component || bom.Component("missing") != nil {
+		t.Error("Component() does not find the metadata component, or finds a missing one")
+	}
+	if deps := bom.DependsOn("app"); !reflect.DeepEqual(deps, []string{"left-pad"}) {
+		t.Errorf("DependsOn(app) = %q", deps)
+	}
+}
+
+func TestCycloneDXDocumentXML(t *testing.T) {
+	data, err := os.ReadFile("../sample-sboms/sample-1.6.cdx.xml")
+	if err != nil {
+		t.Fatalf("Failed to read sample: %v", err)
+	}
+	bom := decodeCycloneDX(t, data)
+
+	// the sample uses the legacy tools array
+	tool := bom.Metadata.Tools.Components[0]
+	if tool.Publisher != "ACME Corp" || tool.Name != "SBOM Generator" || tool.Version != "1.0.0" {
+		t.Errorf("unexpected tool: %+v", tool)
+	}
+	if len(bom.Components) != 1 || bom.Components[0].Licenses[0].License.ID != "MIT" {
+		t.Errorf("unexpected components: %+v", bom.Components)
+	}
+}
+
+func TestParseSPDX(t *testing.T) {
+	doc, err := ParseSPDX([]byte(spdx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	app := doc.Package("SPDXRef-app")
+	if app == nil || app.FilesAnalyzed == nil || *app.FilesAnalyzed {
+		t.Fatalf("Package(SPDXRef-app) = %+v", app)
+	}
+	leftPad := doc.Package("SPDXRef-left-pad")
+	if leftPad == nil || leftPad.FilesAnalyzed != nil || leftPad.LicenseDeclared != "MIT" || leftPad.PURL() != "pkg:npm/left-pad@1.3.0" {
+		t.Errorf("Package(SPDXRef-left-pad) = %+v", leftPad)
+	}
+	if deps := doc.Related("SPDXRef-app", "DEPENDS_ON"); !reflect.DeepEqual(deps, []string{"SPDXRef-left-pad"}) {
+		t.Errorf("Related(SPDXRef-app, DEPENDS_ON) = %q", deps)
+	}
+
+	tagValue, err := os.ReadFile("../sample-sboms/sample-2.3.spdx")
+	if err != nil {
+		t.Fatalf("Failed to read sample: %v", err)
+	}
+	doc, err = ParseSPDX(tagValue)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(doc.Packages) != 1 || doc.Packages[0].Checksums[0].Algorithm != "SHA256" || doc.CreationInfo.Created != "2024-10-22T12:00:00Z" {
+		t.Errorf("unexpected tag-value document: %+v", doc)
+	}
+}
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(spdx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := sbomvalidator.SBOMType{Format: sbomvalidator.FormatSPDX, Version: "2.3"}
+	if doc.Type != want || doc.SPDX == nil || doc.CycloneDX != nil {
+		t.Errorf("Parse() = %+v, want an %s document", doc, want)
+	}
+
+	tests := []struct {
+		name    string
+		parse   func() error
+		wantErr string
+	}{
+		{
+			name:    "Invalid SBOM",
+			parse:   func() error { _, err := Parse([]byte(`{"spdxVersion": "SPDX-2.3"}`)); return err },
+			wantErr: "the SBOM is invalid",
+		},
+		{
+			name:    "Not an SBOM",
+			parse:   func() error { _, err := Parse([]byte(`{"hello": "world"}`)); return err },
+			wantErr: "failed to validate",
+		},
+		{
+			name:    "SPDX as CycloneDX",
+			parse:   func() error { _, err := ParseCycloneDX([]byte(spdx)); return err },
+			wantErr: "the SBOM is SPDX, not CycloneDX",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.parse(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
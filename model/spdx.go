@@ -0,0 +1,146 @@
+package model
+
+// SPDXDocument is an SPDX 2.x document.
+type SPDXDocument struct {
+	SPDXVersion                string                    `json:"spdxVersion"`
+	DataLicense                string                    `json:"dataLicense"`
+	SPDXID                     string                    `json:"SPDXID"`
+	Name                       string                    `json:"name"`
+	DocumentNamespace          string                    `json:"documentNamespace"`
+	Comment                    string                    `json:"comment,omitempty"`
+	CreationInfo               SPDXCreationInfo          `json:"creationInfo"`
+	ExternalDocumentRefs       []SPDXExternalDocumentRef `json:"externalDocumentRefs,omitempty"`
+	DocumentDescribes          []string                  `json:"documentDescribes,omitempty"`
+	Packages                   []SPDXPackage             `json:"packages,omitempty"`
+	Files                      []SPDXFile                `json:"files,omitempty"`
+	Relationships              []SPDXRelationship        `json:"relationships,omitempty"`
+	HasExtractedLicensingInfos []SPDXExtractedLicense    `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+// SPDXCreationInfo records when and by whom a document was created.
+type SPDXCreationInfo struct {
+	// Created is an RFC 3339 date-time.
+	Created string `json:"created"`
+	// Creators are "Tool: ...", "Organization: ..." or "Person: ..."
+	// entries.
+	Creators           []string `json:"creators"`
+	LicenseListVersion string   `json:"licenseListVersion,omitempty"`
+	Comment            string   `json:"comment,omitempty"`
+}
+
+// SPDXExternalDocumentRef refers to another SPDX document, whose elements
+// are named "DocumentRef-<id>:<element>".
+type SPDXExternalDocumentRef struct {
+	ExternalDocumentID string       `json:"externalDocumentId"`
+	SPDXDocument       string       `json:"spdxDocument"`
+	Checksum           SPDXChecksum `json:"checksum"`
+}
+
+// SPDXPackage is a package. License fields hold SPDX license expressions,
+// "NOASSERTION" or "NONE".
+type SPDXPackage struct {
+	SPDXID           string `json:"SPDXID"`
+	Name             string `json:"name"`
+	VersionInfo      string `json:"versionInfo,omitempty"`
+	PackageFileName  string `json:"packageFileName,omitempty"`
+	Supplier         string `json:"supplier,omitempty"`
+	Originator       string `json:"originator,omitempty"`
+	DownloadLocation string `json:"downloadLocation"`
+	// FilesAnalyzed is nil when the document leaves it out, which means
+	// true.
+	FilesAnalyzed         *bool             `json:"filesAnalyzed,omitempty"`
+	Homepage              string            `json:"homepage,omitempty"`
+	SourceInfo            string            `json:"sourceInfo,omitempty"`
+	Checksums             []SPDXChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded      string            `json:"licenseConcluded,omitempty"`
+	LicenseDeclared       string            `json:"licenseDeclared,omitempty"`
+	LicenseInfoFromFiles  []string          `json:"licenseInfoFromFiles,omitempty"`
+	CopyrightText         string            `json:"copyrightText,omitempty"`
+	Summary               string            `json:"summary,omitempty"`
+	Description           string            `json:"description,omitempty"`
+	Comment               string            `json:"comment,omitempty"`
+	ExternalRefs          []SPDXExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	ReleaseDate           string            `json:"releaseDate,omitempty"`
+	BuiltDate             string            `json:"builtDate,omitempty"`
+	ValidUntilDate        string            `json:"validUntilDate,omitempty"`
+}
+
+// SPDXFile is a file.
+type SPDXFile struct {
+	SPDXID             string         `json:"SPDXID"`
+	FileName           string         `json:"fileName"`
+	FileTypes          []string       `json:"fileTypes,omitempty"`
+	Checksums          []SPDXChecksum `json:"checksums,omitempty"`
+	LicenseConcluded   string         `json:"licenseConcluded,omitempty"`
+	LicenseInfoInFiles []string       `json:"licenseInfoInFiles,omitempty"`
+	CopyrightText      string         `json:"copyrightText,omitempty"`
+	Comment            string         `json:"comment,omitempty"`
+}
+
+// SPDXChecksum is a checksum, e.g., {Algorithm: "SHA256", ChecksumValue:
+// "9f86d0..."}.
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// SPDXExternalRef is an external reference of a package, such as its purl
+// ({ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl"}) or CPE.
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+	Comment           string `json:"comment,omitempty"`
+}
+
+// SPDXRelationship relates two elements, e.g., {SPDXElementID:
+// "SPDXRef-app", RelationshipType: "DEPENDS_ON", RelatedSPDXElement:
+// "SPDXRef-lib"}.
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+	Comment            string `json:"comment,omitempty"`
+}
+
+// SPDXExtractedLicense is a license that is not on the SPDX License List,
+// referred to in license expressions as "LicenseRef-...".
+type SPDXExtractedLicense struct {
+	LicenseID     string   `json:"licenseId"`
+	Name          string   `json:"name,omitempty"`
+	ExtractedText string   `json:"extractedText"`
+	SeeAlsos      []string `json:"seeAlsos,omitempty"`
+}
+
+// Package returns the package with the given SPDX ID, or nil.
+func (d *SPDXDocument) Package(id string) *SPDXPackage {
+	for i := range d.Packages {
+		if d.Packages[i].SPDXID == id {
+			return &d.Packages[i]
+		}
+	}
+	return nil
+}
+
+// Related returns the IDs of the elements that id has relationships of the
+// given type to (e.g., "DEPENDS_ON" or "CONTAINS"), in document order.
+func (d *SPDXDocument) Related(id, relationshipType string) []string {
+	var related []string
+	for _, r := range d.Relationships {
+		if r.SPDXElementID == id && r.RelationshipType == relationshipType {
+			related = append(related, r.RelatedSPDXElement)
+		}
+	}
+	return related
+}
+
+// PURL returns the purl of a package, or "" if it has none.
+func (p SPDXPackage) PURL() string {
+	for _, ref := range p.ExternalRefs {
+		if ref.ReferenceType == "purl" {
+			return ref.ReferenceLocator
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateInvalidSBOM(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "left-pad", "MIT"))
+
+	for _, m := range Mutations() {
+		if m.Name == MutationBadTimestamp {
+			// covered by TestGenerateInvalidSBOMCycloneDX
+			continue
+		}
+		t.Run(m.Name, func(t *testing.T) {
+			invalid, err := GenerateInvalidSBOM(sbom, m.Name)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			original, err := ValidateSBOMDataStructured(sbom, invalid.Options...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, f := range original.Findings {
+				if f.Rule == invalid.Rule {
+					t.Fatalf("original SBOM already violates %s: %s", invalid.Rule, f)
+				}
+			}
+
+			mutated, err := ValidateSBOMDataStructured(invalid.Content, invalid.Options...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			found := false
+			for _, f := range mutated.Findings {
+				found = found || f.Rule == invalid.Rule
+			}
+			if !found {
+				t.Errorf("mutated SBOM does not violate %s: %+v", invalid.Rule, mutated.Findings)
+			}
+		})
+	}
+}
+
+func TestGenerateInvalidSBOMCycloneDX(t *testing.T) {
+	sbom := []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1,
+  "components": [{"type": "library", "name": "left-pad", "bom-ref": "left-pad"}]}`)
+
+	tests := []struct {
+		mutation string
+		wantRule string
+		want     string
+	}{
+		{mutation: MutationDropField, wantRule: RuleSchema, want: `"bom-ref": "left-pad"`},
+		{mutation: MutationCorruptPURL, wantRule: RuleExternalReference, want: `"purl": "npm/corrupt@1.0.0"`},
+		{mutation: MutationBrokenRef, wantRule: RuleDependencyGraph, want: `"ref": "` + missingRef + `"`},
+		{mutation: MutationInvalidLicense, wantRule: RuleLicenseExpression, want: `"expression": "MIT AND"`},
+		{mutation: MutationBadTimestamp, wantRule: RuleSchemaFormat, want: `"timestamp": "yesterday"`},
+		{mutation: MutationLeakSecret, wantRule: RuleSecret, want: leakedSecret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mutation, func(t *testing.T) {
+			invalid, err := GenerateInvalidSBOM(sbom, tt.mutation)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if invalid.Rule != tt.wantRule {
+				t.Errorf("Rule = %q, want %q", invalid.Rule, tt.wantRule)
+			}
+			if !strings.Contains(string(invalid.Content), tt.want) {
+				t.Errorf("mutated SBOM lacks %s:\n%s", tt.want, invalid.Content)
+			}
+			if tt.mutation == MutationDropField && strings.Contains(string(invalid.Content), `"name"`) {
+				t.Errorf("mutated SBOM still has a name:\n%s", invalid.Content)
+			}
+		})
+	}
+}
+
+func TestGenerateInvalidSBOMErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		sbom     []byte
+		mutation string
+	}{
+		{name: "Unknown mutation", sbom: spdxDocument(spdxPackage("a", "a", "MIT")), mutation: "unknown"},
+		{name: "No packages", sbom: spdxDocument(), mutation: MutationDropField},
+		{name: "SPDX timestamp", sbom: spdxDocument(), mutation: MutationBadTimestamp},
+		{name: "Not an SBOM", sbom: []byte(`{"name": "app"}`), mutation: MutationBrokenRef},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateInvalidSBOM(tt.sbom, tt.mutation); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxNestedBOMSize caps the size of a fetched nested BOM.
+const maxNestedBOMSize = 64 << 20
+
+// NestedBOMPolicy configures the validation of nested BOMs enabled by
+// `WithNestedBOMs`: the BOMs a CycloneDX SBOM references with
+// externalReferences of type "bom", which are fetched and validated in turn.
+//
+// Fetching follows URLs from the SBOM being validated, so only hosts on the
+// allow-list are contacted; with an empty list nothing is fetched.
+type NestedBOMPolicy struct {
+	// AllowedHosts are the hosts nested BOMs may be fetched from, e.g.
+	// "sbom.example.com". "*.example.com" matches any subdomain and "*"
+	// every host.
+	AllowedHosts []string
+	// MaxDepth is the number of levels of nested BOMs fetched: 1 (the
+	// default) fetches the BOMs the SBOM references, 2 also theirs, and so
+	// on.
+	MaxDepth int
+	// Client is used for the requests; by default a client with `Timeout`
+	// that only follows redirects to allowed hosts.
+	Client *http.Client
+	// Timeout bounds each request (10 seconds by default). It is ignored when
+	// `Client` is set.
+	Timeout time.Duration
+}
+
+// NestedBOMResult is the outcome for a nested BOM. Its Result lists the
+// BOMs nested in it in turn, forming a tree rooted at the validated SBOM.
+type NestedBOMResult struct {
+	// URL is the URL of the nested BOM, as referenced.
+	URL string `json:"url"`
+	// Path is the dotted path of the reference in the referencing BOM (e.g.,
+	// "components.0.externalReferences.1.url").
+	Path string `json:"path"`
+	// Result is the result of validating the nested BOM, or nil if it was
+	// not validated.
+	Result *ValidationResult `json:"result,omitempty"`
+	// Skipped says why the nested BOM was not fetched, e.g., its host is not
+	// allowed or the depth limit is reached.
+	Skipped string `json:"skipped,omitempty"`
+	// Error says why the nested BOM could not be fetched or validated.
+	Error string `json:"error,omitempty"`
+}
+
+// validateNestedBOMs fetches and validates the nested BOMs of a parsed SBOM
+// with the options it was validated with, except for those that only apply
+// to the SBOM itself (see nestedBOMOptions).
+// options.nestedTrail lists the URLs of the BOMs the SBOM is itself nested
+// in, for the depth limit and to skip cycles.
+//
+// Returns the nested BOM results and findings: an error per invalid nested
+// BOM, a warning per BOM that could not be fetched or validated and an info
+// finding per BOM skipped. Returns an error only if ctx is done.
+func validateNestedBOMs(ctx context.Context, obj map[string]interface{}, sbomType string, options *validationOptions) ([]NestedBOMResult, []Finding, error) {
+	if sbomType != SBOM_CYCLONEDX {
+		return nil, nil, nil
+	}
+	policy := options.nestedBOMs
+	maxDepth := policy.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 1
+	}
+	client := policy.Client
+	if client == nil {
+		client = nestedBOMClient(*policy)
+	}
+
+	var results []NestedBOMResult
+	var findings []Finding
+	add := func(level FindingLevel, path, message string) {
+		findings = append(findings, Finding{Level: level, Rule: RuleNestedBOM, Path: path, Pointer: jsonPointer(path), Message: message})
+	}
+
+	for _, ref := range collectBOMReferences(obj) {
+		nested := NestedBOMResult{URL: ref.value, Path: ref.path}
+		u, err := url.Parse(ref.value)
+		switch {
+		case err != nil || (u.Scheme != "http" && u.Scheme != "https"):
+			// e.g., a BOM-Link (urn:cdx:...) to a BOM that is not online
+			nested.Skipped = "not an http(s) URL"
+		case !nestedBOMHostAllowed(u.Hostname(), policy.AllowedHosts):
+			nested.Skipped = fmt.Sprintf("host %q is not allowed", u.Hostname())
+		case slices.Contains(options.nestedTrail, ref.value):
+			nested.Skipped = "it references a BOM it is nested in"
+		case len(options.nestedTrail) >= maxDepth:
+			nested.Skipped = fmt.Sprintf("the depth limit of %d is reached", maxDepth)
+		}
+		if nested.Skipped != "" {
+			add(LevelInfo, ref.path, fmt.Sprintf("nested BOM %s was not validated: %s", ref.value, nested.Skipped))
+			results = append(results, nested)
+			continue
+		}
+
+		content, err := fetchNestedBOM(ctx, client, ref.value)
+		if err == nil {
+			var result *StructuredResult
+			result, _, err = runValidation(ctx, content, nestedBOMOptions(options, ref.value))
+			if err == nil {
+				nested.Result = &result.ValidationResult
+			}
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+
+		switch {
+		case err != nil:
+			nested.Error = err.Error()
+			add(LevelWarning, ref.path, fmt.Sprintf("nested BOM %s could not be validated: %v", ref.value, err))
+		case !nested.Result.IsValid:
+			add(LevelError, ref.path, fmt.Sprintf("nested BOM %s is invalid (%d errors)", ref.value, len(nested.Result.ValidationErrors)))
+		}
+		results = append(results, nested)
+	}
+	return results, findings, nil
+}
+
+// nestedBOMOptions returns the options a BOM nested in one validated with
+// options, and fetched from url, is validated with. Options describing the
+// parent document rather than how to validate are reset: its signature, which
+// the nested BOM is not covered by, its content encoding, its checksum
+// manifest, the artifacts its hashes are verified against, its quarantine,
+// and progress reporting and digest publishing.
+func nestedBOMOptions(options *validationOptions, url string) *validationOptions {
+	nested := *options
+	nested.nestedTrail = append(slices.Clip(options.nestedTrail), url)
+	nested.signaturePolicy = nil
+	nested.contentEncoding = ""
+	nested.checksums = nil
+	if options.hashCheck != nil {
+		nested.hashCheck = &HashCheckPolicy{}
+	}
+	nested.quarantine = nil
+	nested.progress = nil
+	nested.digestPublisher = nil
+	return &nested
+}
+
+// collectBOMReferences returns the URLs of the externalReferences of type
+// "bom" of a CycloneDX SBOM, its components and its services, in document
+// order.
+func collectBOMReferences(obj map[string]interface{}) []externalReference {
+	var refs []externalReference
+	add := func(path string, holder map[string]interface{}) {
+		list, _ := holder["externalReferences"].([]interface{})
+		for i, r := range list {
+			ref, _ := r.(map[string]interface{})
+			if refType, _ := ref["type"].(string); refType != "bom" {
+				continue
+			}
+			if u, ok := ref["url"].(string); ok {
+				refs = append(refs, externalReference{path: fmt.Sprintf("%sexternalReferences.%d.url", path, i), value: u})
+			}
+		}
+	}
+
+	add("", obj)
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		add(path+".", component)
+	})
+	services, _ := obj["services"].([]interface{})
+	for i, s := range services {
+		if service, ok := s.(map[string]interface{}); ok {
+			add(fmt.Sprintf("services.%d.", i), service)
+		}
+	}
+	return refs
+}
+
+// nestedBOMHostAllowed reports whether host matches one of the allowed host
+// patterns.
+func nestedBOMHostAllowed(host string, allowed []string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		switch {
+		case pattern == "*" || pattern == host:
+			return true
+		case strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+// nestedBOMClient returns the default client for fetching nested BOMs, which
+// refuses redirects to hosts that are not allowed.
+func nestedBOMClient(policy NestedBOMPolicy) *http.Client {
+	timeout := policy.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if !nestedBOMHostAllowed(req.URL.Hostname(), policy.AllowedHosts) {
+				return fmt.Errorf("redirect to host %q, which is not allowed", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+}
+
+// fetchNestedBOM downloads a nested BOM.
+func fetchNestedBOM(ctx context.Context, client *http.Client, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "sbom-validator")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(resp.Body, maxNestedBOMSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > maxNestedBOMSize {
+		return nil, fmt.Errorf("exceeds %d bytes", maxNestedBOMSize)
+	}
+	return buf.Bytes(), nil
+}
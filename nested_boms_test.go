@@ -0,0 +1,198 @@
+//go:build !sbomvalidator_no_cyclonedx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// bomWithReferences returns a CycloneDX 1.6 BOM referencing other BOMs with
+// externalReferences of type bom.
+func bomWithReferences(urls ...string) []byte {
+	var refs []string
+	for _, u := range urls {
+		refs = append(refs, fmt.Sprintf(`{"type": "bom", "url": %q}`, u))
+	}
+	return []byte(fmt.Sprintf(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1,
+  "externalReferences": [{"type": "website", "url": "https://example.com"}, %s]}`, strings.Join(refs, ", ")))
+}
+
+func TestWithNestedBOMs(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a.json":
+			w.Write(bomWithReferences(server.URL+"/b.json", server.URL+"/a.json"))
+		case "/b.json":
+			w.Write(bomWithReferences(server.URL + "/c.json"))
+		case "/invalid.json":
+			w.Write([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [{"name": "untyped"}]}`))
+		case "/redirect.json":
+			http.Redirect(w, r, "https://sbom.example.org/a.json", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	host, _ := url.Parse(server.URL)
+
+	sbom := bomWithReferences(
+		server.URL+"/a.json",
+		server.URL+"/invalid.json",
+		server.URL+"/missing.json",
+		server.URL+"/redirect.json",
+		"urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1",
+		"https://sbom.example.org/a.json",
+	)
+	result, err := ValidateSBOMDataStructured(sbom,
+		WithNestedBOMs(NestedBOMPolicy{AllowedHosts: []string{host.Hostname()}, MaxDepth: 2}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Error("an SBOM with an invalid nested BOM is valid")
+	}
+
+	var outcomes []string
+	var walk func(prefix string, nested []NestedBOMResult)
+	walk = func(prefix string, nested []NestedBOMResult) {
+		for _, n := range nested {
+			outcome := strings.TrimPrefix(n.URL, server.URL) + ": "
+			switch {
+			case n.Skipped != "":
+				outcome += "skipped, " + n.Skipped
+			case n.Error != "":
+				outcome += "error"
+			default:
+				outcome += fmt.Sprintf("valid=%v", n.Result.IsValid)
+			}
+			outcomes = append(outcomes, prefix+outcome)
+			if n.Result != nil {
+				walk(prefix+"  ", n.Result.NestedBOMs)
+			}
+		}
+	}
+	walk("", result.NestedBOMs)
+	want := []string{
+		"/a.json: valid=true",
+		"  /b.json: valid=true",
+		"    /c.json: skipped, the depth limit of 2 is reached",
+		"  /a.json: skipped, it references a BOM it is nested in",
+		"/invalid.json: valid=false",
+		"/missing.json: error",
+		"/redirect.json: error",
+		"urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1: skipped, not an http(s) URL",
+		`https://sbom.example.org/a.json: skipped, host "sbom.example.org" is not allowed`,
+	}
+	if strings.Join(outcomes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("nested BOMs:\n%s\nwant:\n%s", strings.Join(outcomes, "\n"), strings.Join(want, "\n"))
+	}
+
+	levels := map[FindingLevel]int{}
+	for _, f := range result.Findings {
+		if f.Rule == RuleNestedBOM {
+			levels[f.Level]++
+		}
+	}
+	if levels[LevelError] != 1 || levels[LevelWarning] != 2 || levels[LevelInfo] != 2 {
+		t.Errorf("nested-bom findings by level = %v", levels)
+	}
+
+	// the tree is part of the JSON result
+	data, err := json.Marshal(result.ValidationResult)
+	if err != nil || !strings.Contains(string(data), `"nestedBoms":[{"url":"`+server.URL+`/a.json"`) {
+		t.Errorf("JSON result = %s, %v", data, err)
+	}
+}
+
+func TestWithNestedBOMsDefaults(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(bomWithReferences("http://" + r.Host + "/next.json"))
+	}))
+	defer server.Close()
+
+	// no host is allowed by default
+	result, err := ValidateSBOMDataContext(context.Background(), bomWithReferences(server.URL+"/a.json"),
+		WithNestedBOMs(NestedBOMPolicy{}))
+	if err != nil || requests != 0 || result.NestedBOMs[0].Skipped == "" {
+		t.Errorf("result = %+v, %v; %d requests", result, err, requests)
+	}
+
+	// one level is fetched by default
+	result, err = ValidateSBOMData(bomWithReferences(server.URL+"/a.json"),
+		WithNestedBOMs(NestedBOMPolicy{AllowedHosts: []string{"*"}}))
+	if err != nil || !result.IsValid || requests != 1 {
+		t.Fatalf("result = %+v, %v; %d requests", result, err, requests)
+	}
+	if next := result.NestedBOMs[0].Result.NestedBOMs[0]; !strings.Contains(next.Skipped, "depth limit of 1") {
+		t.Errorf("unexpected nested BOM: %+v", next)
+	}
+}
+
+func TestWithNestedBOMsDetachedSignature(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1}`))
+	}))
+	defer server.Close()
+
+	// the parent's signature, encoding and artifacts do not apply to the
+	// nested BOM, which is valid
+	var sbom bytes.Buffer
+	zw := gzip.NewWriter(&sbom)
+	zw.Write(bomWithReferences(server.URL + "/a.json"))
+	zw.Close()
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	policy := SignaturePolicy{
+		TrustedKeys:       map[string]crypto.PublicKey{"release": public},
+		DetachedSignature: []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, sbom.Bytes()))),
+	}
+	result, err := ValidateSBOMData(sbom.Bytes(),
+		WithSignatureVerification(policy),
+		WithContentEncoding(EncodingGzip),
+		WithHashChecks(HashCheckPolicy{Artifacts: fstest.MapFS{}}),
+		WithNestedBOMs(NestedBOMPolicy{AllowedHosts: []string{"*"}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Signature == nil || len(result.NestedBOMs) != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+	if nested := result.NestedBOMs[0]; nested.Error != "" || nested.Result == nil || !nested.Result.IsValid || nested.Result.Signature != nil {
+		t.Errorf("nested BOM = %+v, want it validated without the parent's signature", nested)
+	}
+}
+
+func TestNestedBOMHostAllowed(t *testing.T) {
+	allowed := []string{"sbom.example.com", "*.acme.io"}
+	tests := map[string]bool{
+		"sbom.example.com":  true,
+		"SBOM.example.com":  true,
+		"example.com":       false,
+		"boms.acme.io":      true,
+		"a.b.acme.io":       true,
+		"acme.io":           false,
+		"evilacme.io":       false,
+		"sbom.example.com.": false,
+	}
+	for host, want := range tests {
+		if got := nestedBOMHostAllowed(host, allowed); got != want {
+			t.Errorf("nestedBOMHostAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,651 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources of the SBOMs attached to an image, as reported in
+// AttachedSBOM.Source.
+const (
+	// OCISourceReferrer is an artifact whose subject is the image, listed by
+	// the OCI referrers API (e.g., `oras attach` or `cosign attest` with the
+	// OCI 1.1 registry mode).
+	OCISourceReferrer = "referrer"
+	// OCISourceCosignSBOM is a `cosign attach sbom` artifact, tagged
+	// "sha256-<image digest>.sbom".
+	OCISourceCosignSBOM = "cosign-sbom"
+	// OCISourceCosignAttestation is a `cosign attest` attestation, tagged
+	// "sha256-<image digest>.att".
+	OCISourceCosignAttestation = "cosign-attestation"
+)
+
+const (
+	// maxOCIManifestSize bounds the manifests and indexes read from a
+	// registry.
+	maxOCIManifestSize = 4 << 20
+	// maxOCIBlobSize bounds the SBOM blobs read from a registry.
+	maxOCIBlobSize = 256 << 20
+)
+
+// Media types of OCI and Docker manifests and of attestations.
+const (
+	ociIndexMediaType           = "application/vnd.oci.image.index.v1+json"
+	ociManifestMediaType        = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	dockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+	dsseMediaType               = "application/vnd.dsse.envelope.v1+json"
+	inTotoMediaType             = "application/vnd.in-toto+json"
+	sigstoreBundlePrefix        = "application/vnd.dev.sigstore.bundle"
+)
+
+// sbomPredicateTypes are the prefixes of the in-toto predicate types whose
+// predicate is an SBOM.
+var sbomPredicateTypes = []string{"https://cyclonedx.org/bom", "https://spdx.dev/Document"}
+
+var (
+	ociRepositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	ociTagPattern        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	ociDigestPattern     = regexp.MustCompile(`^(sha256:[0-9a-f]{64}|sha512:[0-9a-f]{128})$`)
+)
+
+// OCIReference is a reference to an image in an OCI registry, such as
+// "ghcr.io/org/app:1.2" or "ghcr.io/org/app@sha256:...".
+type OCIReference struct {
+	// Registry is the registry host, with its port if any (e.g., "ghcr.io").
+	Registry string
+	// Repository is the repository path (e.g., "org/app").
+	Repository string
+	// Tag is the tag, if the reference has one.
+	Tag string
+	// Digest is the manifest digest, if the reference has one.
+	Digest string
+}
+
+// String returns the reference in its canonical form.
+func (r OCIReference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// ParseOCIReference parses an image reference, optionally prefixed with
+// "oci://". As with `docker pull`, a reference without a registry is on
+// Docker Hub ("alpine" is "docker.io/library/alpine") and a reference with
+// neither tag nor digest is tagged "latest".
+//
+// Parameters:
+//   - ref: The image reference, e.g., "oci://ghcr.io/org/app:1.2".
+//
+// Returns:
+//   - The parsed reference.
+//   - An error if the repository, tag or digest is malformed.
+//
+// Example:
+//
+//	ref, err := ParseOCIReference("oci://ghcr.io/org/app:1.2")
+//	fmt.Println(ref.Registry, ref.Repository, ref.Tag) // ghcr.io org/app 1.2
+func ParseOCIReference(ref string) (OCIReference, error) {
+	var r OCIReference
+	name := strings.TrimPrefix(ref, "oci://")
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, r.Digest = name[:i], name[i+1:]
+		if !ociDigestPattern.MatchString(r.Digest) {
+			return OCIReference{}, fmt.Errorf("invalid image reference %q: malformed digest", ref)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, r.Tag = name[:i], name[i+1:]
+		if !ociTagPattern.MatchString(r.Tag) {
+			return OCIReference{}, fmt.Errorf("invalid image reference %q: malformed tag", ref)
+		}
+	}
+
+	// the first component is a registry if it looks like a host name
+	r.Registry, r.Repository = "docker.io", name
+	if i := strings.Index(name, "/"); i >= 0 {
+		if host := name[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			r.Registry, r.Repository = host, name[i+1:]
+		}
+	}
+	if r.Registry == "docker.io" && !strings.Contains(r.Repository, "/") {
+		r.Repository = "library/" + r.Repository
+	}
+	if !ociRepositoryPattern.MatchString(r.Repository) {
+		return OCIReference{}, fmt.Errorf("invalid image reference %q: malformed repository", ref)
+	}
+
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+	return r, nil
+}
+
+// AttachedSBOM is an SBOM attached to an image in an OCI registry.
+type AttachedSBOM struct {
+	// Name identifies the SBOM by its blob in the image's repository, e.g.,
+	// "ghcr.io/org/app@sha256:...".
+	Name string
+	// Source is how the SBOM is attached: OCISourceReferrer,
+	// OCISourceCosignSBOM or OCISourceCosignAttestation.
+	Source string
+	// MediaType is the media type of the blob; for an attestation, the SBOM
+	// is its predicate.
+	MediaType string
+	// Content is the SBOM.
+	Content []byte
+}
+
+// OCIFetcher pulls the SBOMs attached to images from OCI registries using
+// the OCI distribution API.
+//
+// Registries are accessed anonymously unless `Username` and `Password` are
+// set; they are sent to the registry's token service, or to the registry
+// itself when it asks for basic authentication. Registries on localhost, and
+// every registry when `PlainHTTP` is set, are accessed over plain HTTP.
+type OCIFetcher struct {
+	Client    *http.Client
+	Username  string
+	Password  string
+	PlainHTTP bool
+
+	mu sync.Mutex
+	// tokens caches the bearer tokens by registry and repository
+	tokens map[string]string
+}
+
+// NewOCIFetcher returns an OCIFetcher accessing registries anonymously.
+//
+// Example:
+//
+//	fetcher := NewOCIFetcher()
+//	fetcher.Username, fetcher.Password = "org", os.Getenv("GHCR_TOKEN")
+//	sboms, err := fetcher.FetchSBOMs(ctx, "ghcr.io/org/app:1.2")
+func NewOCIFetcher() *OCIFetcher {
+	return &OCIFetcher{Client: &http.Client{Timeout: 60 * time.Second}}
+}
+
+// FetchSBOMs pulls the SBOMs attached to an image.
+//
+// The image is resolved to its manifest digest, and the SBOMs are collected
+// from the artifacts listed for it by the OCI referrers API and from the
+// "sha256-<digest>.sbom" and "sha256-<digest>.att" tags cosign attaches them
+// under. Attestations (in-toto statements, optionally in a DSSE envelope or
+// a Sigstore bundle) contribute their predicate when its type is CycloneDX
+// or SPDX; other attestations, such as SLSA provenance, are skipped.
+// Attestation signatures are not verified. Every blob is checked against its
+// digest, and an SBOM attached in several ways is returned once.
+//
+// Parameters:
+//   - ctx: Bounds the registry requests.
+//   - ref: The image reference, as for ParseOCIReference.
+//
+// Returns:
+//   - The attached SBOMs, which may be none.
+//   - An error if the reference is malformed, the image does not exist or
+//     the registry cannot be read.
+//
+// Example:
+//
+//	sboms, err := NewOCIFetcher().FetchSBOMs(ctx, "oci://ghcr.io/org/app:1.2")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, s := range sboms {
+//	    fmt.Println(s.Name, s.Source)
+//	}
+func (f *OCIFetcher) FetchSBOMs(ctx context.Context, ref string) ([]AttachedSBOM, error) {
+	r, err := ParseOCIReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := r.Digest
+	if digest == "" {
+		data, header, err := f.get(ctx, r, "manifests/"+r.Tag, maxOCIManifestSize,
+			ociIndexMediaType, ociManifestMediaType, dockerManifestListMediaType, dockerManifestMediaType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", r, err)
+		}
+		if digest = header.Get("Docker-Content-Digest"); !ociDigestPattern.MatchString(digest) {
+			sum := sha256.Sum256(data)
+			digest = "sha256:" + hex.EncodeToString(sum[:])
+		}
+	}
+
+	var sboms []AttachedSBOM
+	seen := map[string]bool{}
+	add := func(source string, layers []ociDescriptor) error {
+		for _, layer := range layers {
+			if seen[layer.Digest] || !isAttachedSBOMMediaType(layer.MediaType, source) {
+				continue
+			}
+			data, err := f.blob(ctx, r, layer)
+			if err != nil {
+				return err
+			}
+			content, ok, err := attachedSBOMContent(layer.MediaType, data)
+			if err != nil {
+				return fmt.Errorf("blob %s: %w", layer.Digest, err)
+			}
+			if ok {
+				seen[layer.Digest] = true
+				sboms = append(sboms, AttachedSBOM{
+					Name:      fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, layer.Digest),
+					Source:    source,
+					MediaType: layer.MediaType,
+					Content:   content,
+				})
+			}
+		}
+		return nil
+	}
+
+	// registries without the referrers API answer 404
+	data, _, err := f.get(ctx, r, "referrers/"+digest, maxOCIManifestSize, ociIndexMediaType)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list the referrers of %s: %w", r, err)
+	}
+	if err == nil {
+		var index ociManifest
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("invalid referrers index for %s: %w", r, err)
+		}
+		for _, referrer := range index.Manifests {
+			if !isAttachedSBOMMediaType(referrer.ArtifactType, OCISourceReferrer) {
+				continue
+			}
+			manifest, err := f.manifest(ctx, r, referrer.Digest)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(OCISourceReferrer, manifest.Layers); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tag := strings.Replace(digest, ":", "-", 1)
+	for _, cosign := range []struct{ suffix, source string }{
+		{".sbom", OCISourceCosignSBOM},
+		{".att", OCISourceCosignAttestation},
+	} {
+		manifest, err := f.manifest(ctx, r, tag+cosign.suffix)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := add(cosign.source, manifest.Layers); err != nil {
+			return nil, err
+		}
+	}
+
+	return sboms, nil
+}
+
+// ValidateOCIImage validates the SBOMs attached to an image, as pulled by
+// fetcher.FetchSBOMs, concurrently like ValidateSBOMBatchContext. Names in
+// the results are the AttachedSBOM names.
+//
+// Parameters:
+//   - ctx: Bounds the registry requests and the validation.
+//   - fetcher: The fetcher to pull with, or nil for NewOCIFetcher().
+//   - ref: The image reference, as for ParseOCIReference.
+//   - opts: Optional settings, applied to every SBOM as for ValidateSBOMData.
+//
+// Returns:
+//   - A BatchResult with a result per attached SBOM, or nil if the SBOMs
+//     could not be pulled.
+//   - An error if the SBOMs could not be pulled, none is attached, or ctx was
+//     done before every SBOM was validated.
+//
+// Example:
+//
+//	batch, err := ValidateOCIImage(ctx, nil, "oci://ghcr.io/org/app:1.2")
+//	if batch == nil {
+//	    log.Fatal(err)
+//	}
+func ValidateOCIImage(ctx context.Context, fetcher *OCIFetcher, ref string, opts ...Option) (*BatchResult, error) {
+	if fetcher == nil {
+		fetcher = NewOCIFetcher()
+	}
+	sboms, err := fetcher.FetchSBOMs(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(sboms) == 0 {
+		return nil, fmt.Errorf("no SBOMs are attached to %s", strings.TrimPrefix(ref, "oci://"))
+	}
+
+	inputs := make([]BatchInput, 0, len(sboms))
+	for _, s := range sboms {
+		inputs = append(inputs, BatchReader(s.Name, bytes.NewReader(s.Content)))
+	}
+	return ValidateSBOMBatchContext(ctx, inputs, opts...)
+}
+
+// ociDescriptor describes a manifest or blob.
+type ociDescriptor struct {
+	MediaType    string `json:"mediaType"`
+	ArtifactType string `json:"artifactType"`
+	Digest       string `json:"digest"`
+	Size         int64  `json:"size"`
+}
+
+// ociManifest holds the fields read from image manifests and indexes.
+type ociManifest struct {
+	Manifests []ociDescriptor `json:"manifests"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+// isAttachedSBOMMediaType reports whether an artifact or layer of the given
+// media type may hold an SBOM. `cosign attach sbom` uses media types such as
+// "text/spdx+json" and "application/vnd.cyclonedx+xml".
+func isAttachedSBOMMediaType(mediaType, source string) bool {
+	mediaType = strings.ToLower(mediaType)
+	switch {
+	case strings.Contains(mediaType, "spdx"), strings.Contains(mediaType, "cyclonedx"):
+		return true
+	case source == OCISourceCosignSBOM:
+		return false
+	}
+	return isAttestationMediaType(mediaType)
+}
+
+// isAttestationMediaType reports whether a media type is that of an in-toto
+// statement, a DSSE envelope or a Sigstore bundle.
+func isAttestationMediaType(mediaType string) bool {
+	return mediaType == dsseMediaType || mediaType == inTotoMediaType ||
+		strings.HasPrefix(mediaType, sigstoreBundlePrefix)
+}
+
+// attachedSBOMContent returns the SBOM in a blob: the blob itself, or the
+// predicate of an attestation. ok is false for attestations of other
+// predicate types.
+func attachedSBOMContent(mediaType string, data []byte) (content []byte, ok bool, err error) {
+	var envelope struct {
+		DSSEEnvelope  json.RawMessage `json:"dsseEnvelope"`
+		Payload       string          `json:"payload"`
+		PredicateType string          `json:"predicateType"`
+		Predicate     json.RawMessage `json:"predicate"`
+	}
+	if !isJSON(data) || json.Unmarshal(data, &envelope) != nil {
+		// SBOMs may be XML, tag-value or malformed, attestations may not
+		if isAttestationMediaType(mediaType) {
+			return nil, false, fmt.Errorf("attestation is not JSON")
+		}
+		return data, true, nil
+	}
+
+	// a Sigstore bundle wraps a DSSE envelope, which wraps an in-toto statement
+	if len(envelope.DSSEEnvelope) > 0 {
+		return attachedSBOMContent(dsseMediaType, envelope.DSSEEnvelope)
+	}
+	if envelope.Payload != "" {
+		payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid DSSE payload: %w", err)
+		}
+		return attachedSBOMContent(inTotoMediaType, payload)
+	}
+	if envelope.PredicateType == "" {
+		if isAttestationMediaType(mediaType) {
+			return nil, false, fmt.Errorf("attestation is not an in-toto statement")
+		}
+		return data, true, nil
+	}
+
+	for _, prefix := range sbomPredicateTypes {
+		if strings.HasPrefix(envelope.PredicateType, prefix) {
+			// cosign attests SPDX tag-value as a JSON string
+			var text string
+			if json.Unmarshal(envelope.Predicate, &text) == nil {
+				return []byte(text), true, nil
+			}
+			return envelope.Predicate, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// manifest reads an image manifest by tag or digest.
+func (f *OCIFetcher) manifest(ctx context.Context, r OCIReference, reference string) (*ociManifest, error) {
+	data, _, err := f.get(ctx, r, "manifests/"+reference, maxOCIManifestSize, ociManifestMediaType, dockerManifestMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", reference, err)
+	}
+	if ociDigestPattern.MatchString(reference) {
+		if err := verifyOCIDigest(reference, data); err != nil {
+			return nil, fmt.Errorf("manifest %s: %w", reference, err)
+		}
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", reference, err)
+	}
+	return &manifest, nil
+}
+
+// blob reads a blob and checks it against its digest.
+func (f *OCIFetcher) blob(ctx context.Context, r OCIReference, layer ociDescriptor) ([]byte, error) {
+	if !ociDigestPattern.MatchString(layer.Digest) {
+		return nil, fmt.Errorf("blob has a malformed digest %q", layer.Digest)
+	}
+	data, _, err := f.get(ctx, r, "blobs/"+layer.Digest, maxOCIBlobSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", layer.Digest, err)
+	}
+	if err := verifyOCIDigest(layer.Digest, data); err != nil {
+		return nil, fmt.Errorf("blob %s: %w", layer.Digest, err)
+	}
+	return data, nil
+}
+
+// verifyOCIDigest checks content against a "sha256:" or "sha512:" digest.
+func verifyOCIDigest(digest string, content []byte) error {
+	var sum []byte
+	if strings.HasPrefix(digest, "sha512:") {
+		s := sha512.Sum512(content)
+		sum = s[:]
+	} else {
+		s := sha256.Sum256(content)
+		sum = s[:]
+	}
+	if got := digest[:strings.Index(digest, ":")+1] + hex.EncodeToString(sum); got != digest {
+		return fmt.Errorf("content has digest %s", got)
+	}
+	return nil
+}
+
+// get reads "/v2/<repository>/<path>" from the registry, authenticating when
+// challenged. The error wraps fs.ErrNotExist when the registry answers 404.
+func (f *OCIFetcher) get(ctx context.Context, r OCIReference, path string, limit int64, accept ...string) ([]byte, http.Header, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	host := r.Registry
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	if f.PlainHTTP || isLocalRegistry(host) {
+		scheme = "http"
+	}
+	endpoint := fmt.Sprintf("%s://%s/v2/%s/%s", scheme, host, r.Repository, path)
+	tokenKey := r.Registry + "/" + r.Repository
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("User-Agent", "sbom-validator")
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		f.mu.Lock()
+		token := f.tokens[tokenKey]
+		f.mu.Unlock()
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		} else if attempt > 0 && f.Username != "" {
+			req.SetBasicAuth(f.Username, f.Password)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			if err := f.authenticate(ctx, client, tokenKey, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, nil, err
+			}
+			continue
+		case resp.StatusCode == http.StatusNotFound:
+			return nil, nil, fmt.Errorf("%s: %w", endpoint, fs.ErrNotExist)
+		case resp.StatusCode != http.StatusOK:
+			return nil, nil, fmt.Errorf("%s: %s", endpoint, resp.Status)
+		case err != nil:
+			return nil, nil, fmt.Errorf("%s: %v", endpoint, err)
+		case int64(len(data)) > limit:
+			return nil, nil, fmt.Errorf("%s exceeds %d bytes", endpoint, limit)
+		}
+		return data, resp.Header, nil
+	}
+}
+
+// authenticate answers a WWW-Authenticate challenge. For a bearer challenge
+// a token is requested from the realm and cached under tokenKey; a basic
+// challenge needs credentials, which get then sends.
+func (f *OCIFetcher) authenticate(ctx context.Context, client *http.Client, tokenKey, challenge string) error {
+	scheme, params := parseAuthChallenge(challenge)
+	switch scheme {
+	case "basic":
+		if f.Username == "" {
+			return fmt.Errorf("registry requires credentials")
+		}
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("unsupported registry authentication %q", challenge)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid registry token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "sbom-validator")
+	if f.Username != "" {
+		req.SetBasicAuth(f.Username, f.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate with the registry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to authenticate with the registry: %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOCIManifestSize)).Decode(&token); err != nil {
+		return fmt.Errorf("invalid registry token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return fmt.Errorf("registry token service returned no token")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tokens == nil {
+		f.tokens = map[string]string{}
+	}
+	f.tokens[tokenKey] = token.Token
+	return nil
+}
+
+// parseAuthChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="..."` into
+// its lower-cased scheme and parameters.
+func parseAuthChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				break
+			}
+			params[key], rest = value[1:end+1], value[end+2:]
+		} else {
+			params[key], rest, _ = strings.Cut(value, ",")
+		}
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return strings.ToLower(scheme), params
+}
+
+// isLocalRegistry reports whether a registry host is on the local machine.
+func isLocalRegistry(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,206 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testRegistry is an in-memory OCI registry serving manifests and blobs by
+// path under /v2/org/app/, behind a bearer token service.
+type testRegistry struct {
+	*httptest.Server
+	content map[string][]byte
+	// referrers, when false, answers 404 to the referrers API
+	referrers bool
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	reg := &testRegistry{content: map[string][]byte{}, referrers: true}
+	reg.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:org/app:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token": "secret"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:org/app:pull"`, reg.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v2/org/app/")
+		data, ok := reg.content[path]
+		if !ok || (strings.HasPrefix(path, "referrers/") && !reg.referrers) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(reg.Close)
+	return reg
+}
+
+// put stores content under a digest path and returns its descriptor.
+func (reg *testRegistry) put(kind, mediaType string, content []byte) string {
+	digest := SBOMDigest(content)
+	reg.content[kind+"/"+digest] = content
+	return fmt.Sprintf(`{"mediaType": %q, "digest": %q, "size": %d}`, mediaType, digest, len(content))
+}
+
+func (reg *testRegistry) ref(tag string) string {
+	return "oci://" + strings.TrimPrefix(reg.URL, "http://") + "/org/app:" + tag
+}
+
+// attestation returns a DSSE envelope for an in-toto statement.
+func attestation(predicateType string, predicate []byte) []byte {
+	statement := fmt.Sprintf(`{"_type": "https://in-toto.io/Statement/v1", "predicateType": %q, "predicate": %s}`, predicateType, predicate)
+	return []byte(fmt.Sprintf(`{"payloadType": "application/vnd.in-toto+json", "payload": %q, "signatures": []}`,
+		base64.StdEncoding.EncodeToString([]byte(statement))))
+}
+
+// publishImage stores an image tagged 1.2 with an SBOM attached both as a
+// referrer and as a cosign attestation, next to a signature and a provenance
+// attestation, and returns the image digest.
+func publishImage(reg *testRegistry, sbom []byte) string {
+	image := []byte(`{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", "layers": []}`)
+	reg.content["manifests/1.2"] = image
+	digest := SBOMDigest(image)
+
+	sbomLayer := reg.put("blobs", "application/spdx+json", sbom)
+	referrer := []byte(fmt.Sprintf(`{"schemaVersion": 2, "artifactType": "application/spdx+json", "layers": [%s]}`, sbomLayer))
+	referrerDesc := strings.Replace(reg.put("manifests", ociManifestMediaType, referrer), "{", `{"artifactType": "application/spdx+json", `, 1)
+	signature := reg.put("manifests", ociManifestMediaType, []byte(`{"layers": []}`))
+	signature = strings.Replace(signature, "{", `{"artifactType": "application/vnd.dev.cosign.artifact.sig.v1+json", `, 1)
+	reg.content["referrers/"+digest] = []byte(fmt.Sprintf(`{"schemaVersion": 2, "manifests": [%s, %s]}`, referrerDesc, signature))
+
+	att := reg.put("blobs", dsseMediaType, attestation("https://spdx.dev/Document/v2.3", sbom))
+	provenance := reg.put("blobs", dsseMediaType, attestation("https://slsa.dev/provenance/v1", []byte(`{}`)))
+	reg.content["manifests/"+strings.Replace(digest, ":", "-", 1)+".att"] = []byte(fmt.Sprintf(`{"layers": [%s, %s]}`, att, provenance))
+	return digest
+}
+
+func TestFetchSBOMs(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+
+	t.Run("Referrers and attestations", func(t *testing.T) {
+		reg := newTestRegistry(t)
+		publishImage(reg, sbom)
+
+		sboms, err := NewOCIFetcher().FetchSBOMs(context.Background(), reg.ref("1.2"))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(sboms) != 2 || sboms[0].Source != OCISourceReferrer || sboms[1].Source != OCISourceCosignAttestation {
+			t.Fatalf("sboms = %+v", sboms)
+		}
+		for _, s := range sboms {
+			if _, err := Detect(strings.NewReader(string(s.Content))); err != nil {
+				t.Errorf("%s: %v", s.Name, err)
+			}
+		}
+	})
+
+	t.Run("Registry without referrers API", func(t *testing.T) {
+		reg := newTestRegistry(t)
+		reg.referrers = false
+		digest := publishImage(reg, sbom)
+
+		sboms, err := NewOCIFetcher().FetchSBOMs(context.Background(), strings.Replace(reg.ref("1.2"), ":1.2", "@"+digest, 1))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(sboms) != 1 || sboms[0].Source != OCISourceCosignAttestation {
+			t.Fatalf("sboms = %+v", sboms)
+		}
+	})
+
+	t.Run("Tampered blob", func(t *testing.T) {
+		reg := newTestRegistry(t)
+		publishImage(reg, sbom)
+		for path := range reg.content {
+			if strings.HasPrefix(path, "blobs/") {
+				reg.content[path] = []byte(`{}`)
+			}
+		}
+
+		if _, err := NewOCIFetcher().FetchSBOMs(context.Background(), reg.ref("1.2")); err == nil || !strings.Contains(err.Error(), "has digest") {
+			t.Errorf("expected a digest mismatch, got %v", err)
+		}
+	})
+
+	t.Run("Unknown image", func(t *testing.T) {
+		reg := newTestRegistry(t)
+		if _, err := NewOCIFetcher().FetchSBOMs(context.Background(), reg.ref("9.9")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected fs.ErrNotExist, got %v", err)
+		}
+	})
+}
+
+func TestValidateOCIImage(t *testing.T) {
+	reg := newTestRegistry(t)
+	publishImage(reg, spdxDocument(spdxPackage("a", "a", "MIT")))
+
+	batch, err := ValidateOCIImage(context.Background(), nil, reg.ref("1.2"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch.Summary.Total != 2 || batch.Summary.Valid != 2 {
+		t.Errorf("summary = %+v, results %+v", batch.Summary, batch.Results)
+	}
+
+	empty := newTestRegistry(t)
+	empty.content["manifests/1.2"] = []byte(`{"layers": []}`)
+	if _, err := ValidateOCIImage(context.Background(), nil, empty.ref("1.2")); err == nil {
+		t.Error("expected an error for an image without SBOMs")
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		ref       string
+		want      OCIReference
+		expectErr bool
+	}{
+		{ref: "oci://ghcr.io/org/app:1.2", want: OCIReference{Registry: "ghcr.io", Repository: "org/app", Tag: "1.2"}},
+		{ref: "alpine", want: OCIReference{Registry: "docker.io", Repository: "library/alpine", Tag: "latest"}},
+		{ref: "localhost:5000/app", want: OCIReference{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{
+			ref:  "org/app@sha256:" + strings.Repeat("a", 64),
+			want: OCIReference{Registry: "docker.io", Repository: "org/app", Digest: "sha256:" + strings.Repeat("a", 64)},
+		},
+		{ref: "ghcr.io/Org/app", expectErr: true},
+		{ref: "ghcr.io/org/app:-bad", expectErr: true},
+		{ref: "ghcr.io/org/app@sha256:abc", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseOCIReference(tt.ref)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseOCIReference() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOCIReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAuthChallenge(t *testing.T) {
+	scheme, params := parseAuthChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:org/app:pull"`)
+	if scheme != "bearer" || params["realm"] != "https://ghcr.io/token" || params["service"] != "ghcr.io" ||
+		params["scope"] != "repository:org/app:pull" {
+		t.Errorf("parseAuthChallenge() = %q, %v", scheme, params)
+	}
+}
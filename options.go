@@ -0,0 +1,496 @@
+package sbomvalidator
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Option configures optional behaviour of ValidateSBOMData.
+//
+// Options are applied in the order they are passed, so a later option
+// overrides an earlier one that sets the same field.
+type Option func(*validationOptions)
+
+// validationOptions holds the settings collected from Option values.
+type validationOptions struct {
+	allowUnknownVersion bool
+	componentNaming     bool
+	clock               Clock
+	concurrency         int
+	contentEncoding     string
+	controlMappings     bool
+	decompressors       map[string]Decompressor
+	dependencyCoverage  *DependencyCoveragePolicy
+	deterministic       bool
+	digestPublisher     DigestPublisher
+	formats             []string
+	graphAnalysis       bool
+	groupBy             string
+	hashCheck           *HashCheckPolicy
+	noFormatAssertion   bool
+	identity            IdentityResolver
+	internalNamespaces  []string
+	licenseStats        bool
+	licenseValidation   bool
+	limits              ValidationOptions
+	logger              *slog.Logger
+	maxSBOMAge          *time.Duration
+	nestedBOMs          *NestedBOMPolicy
+	nestedTrail         []string
+	profiles            []Profile
+	progress            func(ProgressEvent)
+	binaryAnalyzers     []BinaryAnalyzer
+	checksums           Checksums
+	pinnedSchemas       []string
+	policy              *Policy
+	preloaded           map[string]preloadedSchema
+	qualityChecks       []string
+	quarantine          *QuarantinePolicy
+	referenceCheck      *ReferenceCheckPolicy
+	schemaProviders     []SchemaProvider
+	scoringProfile      *ScoringProfile
+	secretPatterns      []SecretPattern
+	signaturePolicy     *SignaturePolicy
+	suppressions        []Suppression
+	versionMode         VersionMode
+}
+
+// WithAllowUnknownVersion controls what happens when an SBOM declares a
+// specification version newer than any embedded schema.
+//
+// When enabled, the SBOM is validated against the latest known schema for its
+// type and the result is flagged with `UnknownVersion` so callers can treat the
+// outcome as best effort. When disabled (the default), validation fails.
+func WithAllowUnknownVersion(allow bool) Option {
+	return func(o *validationOptions) {
+		o.allowUnknownVersion = allow
+	}
+}
+
+// WithChecksums verifies the SHA-256 digest of every SBOM in a batch against
+// a checksums manifest (see `ParseChecksums`) before validating it. Files that
+// do not match or are not listed are not validated, and listed SBOMs missing
+// from the batch are reported; see `BatchFileResult.Checksum`. It has no
+// effect on ValidateSBOMData.
+func WithChecksums(sums Checksums) Option {
+	return func(o *validationOptions) {
+		o.checksums = sums
+	}
+}
+
+// WithClock sets the clock that checks depending on the current time, such
+// as `WithMaxSBOMAge`, and the stamping of results (`ValidatedAt` and
+// `Duration`) use. Defaults to `SystemClock`.
+func WithClock(clock Clock) Option {
+	return func(o *validationOptions) {
+		o.clock = clock
+	}
+}
+
+// WithComponentNaming enables the component naming check: components that
+// are probably the same package but are spelled differently within the SBOM
+// (case, "-" vs "_" or ".", or group and name swapped) are reported as one
+// warning per cluster. See `ComponentNameClusters` for the clusters
+// themselves.
+func WithComponentNaming(enabled bool) Option {
+	return func(o *validationOptions) {
+		o.componentNaming = enabled
+	}
+}
+
+// WithConcurrency sets the number of SBOMs ValidateSBOMBatch and
+// ValidateSBOMDir validate at once. It defaults to the number of CPUs and has
+// no effect on ValidateSBOMData.
+func WithConcurrency(workers int) Option {
+	return func(o *validationOptions) {
+		o.concurrency = workers
+	}
+}
+
+// WithContentEncoding declares the compression of the SBOM content (e.g.,
+// `EncodingBrotli`), for encodings that cannot be recognized from the content
+// itself. Gzip and zstd are detected without it; "identity" disables
+// detection. `EncodingForName` derives the encoding from a file name.
+func WithContentEncoding(encoding string) Option {
+	return func(o *validationOptions) {
+		o.contentEncoding = encoding
+	}
+}
+
+// WithControlMappings adds the control framework mappings (NIST SSDF, ISO/IEC
+// 27001, CWE) of the rules evaluated during validation to the result's
+// `Controls`, so the report can be imported as tagged evidence by GRC tooling.
+// See `RuleCatalog` for the mappings.
+func WithControlMappings(enabled bool) Option {
+	return func(o *validationOptions) {
+		o.controlMappings = enabled
+	}
+}
+
+// WithDependencyCoverage rejects SBOMs whose dependency relationships fall
+// short of the policy, e.g., fewer than 80% of components in the dependency
+// graph or a primary component without direct dependencies. Shortfalls are
+// validation errors.
+func WithDependencyCoverage(policy DependencyCoveragePolicy) Option {
+	return func(o *validationOptions) {
+		o.dependencyCoverage = &policy
+	}
+}
+
+// WithDeterministic leaves the timing fields (`ValidatedAt` and `Duration`)
+// out of results, so that validating the same SBOM twice gives identical
+// reports, e.g., for golden-file tests. Checks against the current time still
+// use the clock; pin it with `WithClock`.
+func WithDeterministic(enabled bool) Option {
+	return func(o *validationOptions) {
+		o.deterministic = enabled
+	}
+}
+
+// WithDecompressor registers the decompressor for a content encoding, such
+// as `EncodingZstd` or `EncodingBrotli`, which are recognized but not
+// decompressed by the library itself. Gzip is decompressed natively.
+//
+// Example:
+//
+//	// import "github.com/klauspost/compress/zstd"
+//	sbomvalidator.WithDecompressor(sbomvalidator.EncodingZstd, func(r io.Reader) (io.Reader, error) {
+//	    return zstd.NewReader(r)
+//	})
+func WithDecompressor(encoding string, decompressor Decompressor) Option {
+	return func(o *validationOptions) {
+		if o.decompressors == nil {
+			o.decompressors = map[string]Decompressor{}
+		}
+		o.decompressors[encoding] = decompressor
+	}
+}
+
+// WithDigestPublisher publishes the digest of the SBOM (see `SBOMDigest`) to
+// the given publisher when, and only when, it validates. A publishing failure
+// is returned as an error from ValidateSBOMData.
+func WithDigestPublisher(publisher DigestPublisher) Option {
+	return func(o *validationOptions) {
+		o.digestPublisher = publisher
+	}
+}
+
+// WithFormatAssertion controls whether schema `format` keywords (e.g.,
+// "date-time" or "iri-reference") are assertions. When enabled (the default),
+// a value that violates its format is a validation error; when disabled, it
+// is reported as a warning, as with format-as-annotation validators. Either
+// way, the result's `Formats` lists which formats were checked.
+func WithFormatAssertion(enabled bool) Option {
+	return func(o *validationOptions) {
+		o.noFormatAssertion = !enabled
+	}
+}
+
+// WithFormats restricts validation to the given SBOM formats (e.g.,
+// `SBOM_CYCLONEDX`); SBOMs of any other format are rejected with an error. By
+// default every format compiled into the build (see `SupportedFormats`) is
+// accepted. To leave a format out of the binary altogether, build with its
+// `sbomvalidator_no_<format>` tag.
+func WithFormats(formats ...string) Option {
+	return func(o *validationOptions) {
+		o.formats = formats
+	}
+}
+
+// WithGraphAnalysis enables the dependency graph analysis: duplicate IDs
+// (CycloneDX bom-refs or SPDX IDs) and dependencies on undefined bom-refs make
+// the SBOM invalid, while dependency cycles and components that are never
+// referenced are reported as warnings. The graph itself is available from
+// `StructuredResult.Graph` whether or not the analysis is enabled.
+func WithGraphAnalysis(enabled bool) Option {
+	return func(o *validationOptions) {
+		o.graphAnalysis = enabled
+	}
+}
+
+// WithGroupBy records the value of an SBOM property, such as
+// "internal:team", in each result's `Group`, so that the results of a batch
+// can be split with `BatchResult.Groups`. The property is looked up in the
+// CycloneDX metadata, then in the properties of its component.
+func WithGroupBy(property string) Option {
+	return func(o *validationOptions) {
+		o.groupBy = property
+	}
+}
+
+// WithIdentityResolver sets how components are matched across SBOMs by
+// `CompareConversion`. It defaults to `DefaultIdentityResolver`.
+func WithIdentityResolver(resolver IdentityResolver) Option {
+	return func(o *validationOptions) {
+		o.identity = resolver
+	}
+}
+
+// WithInternalNamespaces enables the dependency confusion check: components
+// using one of the organisation's internal namespaces but resolving against a
+// public registry, or served from an internal registry under a non-internal
+// name, are reported as warnings.
+//
+// Entries match purl namespaces (e.g., "@acme" or "com.acme", including nested
+// namespaces); an entry ending in "*" matches package names by prefix (e.g.,
+// "acme-*") for ecosystems without namespaces.
+func WithInternalNamespaces(namespaces ...string) Option {
+	return func(o *validationOptions) {
+		o.internalNamespaces = namespaces
+	}
+}
+
+// WithProfiles enables additional requirements for particular kinds of SBOM,
+// such as `ProfileMLBOM`. Profile violations make the SBOM invalid. Unknown
+// profiles cause ValidateSBOMData to return an error.
+func WithProfiles(profiles ...Profile) Option {
+	return func(o *validationOptions) {
+		o.profiles = profiles
+	}
+}
+
+// WithProgress calls handler as each validation stage finishes (see
+// `ProgressEvent`), from the goroutine running the validation, so that
+// findings of a large SBOM can be shown before the result is complete. It
+// has no effect on ValidateSBOMBatch and ValidateSBOMDir.
+func WithProgress(handler func(ProgressEvent)) Option {
+	return func(o *validationOptions) {
+		o.progress = handler
+	}
+}
+
+// WithBinaryAnalyzers runs external binary analysis tools over the SBOM's
+// components and merges their findings into the result: critical and high
+// severity findings become validation errors, low severity ones info findings
+// and the rest warnings. An analyzer failure is returned as an error from
+// ValidateSBOMData.
+func WithBinaryAnalyzers(analyzers ...BinaryAnalyzer) Option {
+	return func(o *validationOptions) {
+		o.binaryAnalyzers = analyzers
+	}
+}
+
+// WithLicenseStats adds license statistics to the result (see
+// `LicenseStats`): the number of components per license identifier, the
+// share with an unknown license, the breakdown into permissive and copyleft
+// licenses, and how dual licenses were resolved. They are computed from the
+// document already parsed for validation and do not affect validity.
+func WithLicenseStats(enabled bool) Option {
+	return func(o *validationOptions) {
+		o.licenseStats = enabled
+	}
+}
+
+// WithLicenseValidation enables license expression validation: CycloneDX
+// license expressions and SPDX `licenseDeclared` and `licenseConcluded`
+// values must be well-formed SPDX license expressions, or the SBOM is
+// invalid. Identifiers missing from the embedded SPDX License List and
+// deprecated identifiers are reported as warnings, the latter with a fix.
+func WithLicenseValidation(enabled bool) Option {
+	return func(o *validationOptions) {
+		o.licenseValidation = enabled
+	}
+}
+
+// WithLogger routes the library's diagnostics to logger: the SBOM type and
+// version detected, the schema selected, and the outcome and duration of
+// each validation at debug level, and fallbacks to the schema of another
+// spec version at info level. Without it, or with a nil logger, nothing is
+// logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *validationOptions) {
+		o.logger = logger
+	}
+}
+
+// WithMaxSBOMAge enables the sbom-age check: a warning when the SBOM's
+// creation time (CycloneDX metadata.timestamp, SPDX creationInfo.created) is
+// more than maxAge before the current time of `WithClock`, or more than five
+// minutes after it. A maxAge of 0 only checks for creation times in the
+// future.
+func WithMaxSBOMAge(maxAge time.Duration) Option {
+	return func(o *validationOptions) {
+		o.maxSBOMAge = &maxAge
+	}
+}
+
+// WithNestedBOMs fetches the BOMs a CycloneDX SBOM references with
+// externalReferences of type "bom" from the policy's allowed hosts and
+// validates them, transitively up to the policy's depth, with the same
+// options, except those describing the SBOM itself: its signature, content
+// encoding, checksums and hash artifacts. The results form a tree in
+// `ValidationResult.NestedBOMs`; invalid nested BOMs are errors of the SBOM,
+// and BOMs that cannot be fetched are warnings.
+func WithNestedBOMs(policy NestedBOMPolicy) Option {
+	return func(o *validationOptions) {
+		o.nestedBOMs = &policy
+	}
+}
+
+// WithPinnedSchemas validates against exact schema revisions, identified by
+// the digests recorded in `SchemaDigest` of earlier results (see
+// `SchemaRevisions`), so an old SBOM re-validated years later gets the verdict
+// it originally got even if the embedded schema has since been revised.
+//
+// A pin only applies to SBOMs of the spec version it is a revision of; others
+// use the current schema. A digest that matches no embedded revision causes
+// ValidateSBOMData to return an error.
+func WithPinnedSchemas(digests ...string) Option {
+	return func(o *validationOptions) {
+		o.pinnedSchemas = digests
+	}
+}
+
+// WithPolicy applies a validation policy (see `Policy` and `LoadPolicy`): it
+// enables the policy's rules and profiles, overrides the level of findings
+// as its severities say, and checks the SBOM's licenses, spec version and
+// fields against its requirements. Rules, profiles and limits set by other
+// options are kept; pass it after WithValidationOptions, which replaces the
+// limits.
+func WithPolicy(policy Policy) Option {
+	return func(o *validationOptions) {
+		policy.apply(o)
+		o.policy = &policy
+	}
+}
+
+// WithQualityChecks enables checks beyond the schema for the NTIA minimum
+// elements (supplier, component name, version, unique identifier, dependency
+// relationships, author and timestamp). Pass IDs from `QualityChecks` to pick
+// individual checks, or all of them. Failures are reported as warnings, and the
+// outcome of each check is listed in the result's `Quality`. Unknown checks
+// cause ValidateSBOMData to return an error.
+func WithQualityChecks(checks ...string) Option {
+	return func(o *validationOptions) {
+		o.qualityChecks = checks
+	}
+}
+
+// WithScoringProfile sets the profile that weights the criteria of Score. It
+// defaults to DefaultScoringProfile and has no effect on validation.
+func WithScoringProfile(profile ScoringProfile) Option {
+	return func(o *validationOptions) {
+		o.scoringProfile = &profile
+	}
+}
+
+// WithHashChecks enables the hash integrity check: every CycloneDX `hashes`
+// and SPDX `checksums` entry must name an algorithm defined by the format and
+// carry a hexadecimal digest of the length the algorithm produces. When the
+// policy sets `Artifacts`, the files the SBOM declares hashes for are also
+// hashed, and mismatches are errors and missing files warnings, under the
+// `artifact-hash` rule.
+func WithHashChecks(policy HashCheckPolicy) Option {
+	return func(o *validationOptions) {
+		o.hashCheck = &policy
+	}
+}
+
+// WithQuarantine hands the SBOMs of a batch that are invalid, fail checksum
+// verification or cannot be validated to a quarantine (see `DirQuarantine`)
+// with their report, and optionally removes them from the source; see
+// `QuarantinePolicy`. SBOMs abandoned because the batch's context is done are
+// not quarantined. It has no effect on ValidateSBOMData.
+func WithQuarantine(policy QuarantinePolicy) Option {
+	return func(o *validationOptions) {
+		o.quarantine = &policy
+	}
+}
+
+// WithReferenceCheck enables the external reference check: malformed
+// reference URLs and purls are reported as warnings and, when the policy sets
+// `Resolve`, so are unreachable http(s) URLs. Without `Resolve` the check
+// makes no network requests.
+func WithReferenceCheck(policy ReferenceCheckPolicy) Option {
+	return func(o *validationOptions) {
+		o.referenceCheck = &policy
+	}
+}
+
+// WithSchemaProviders validates against schemas from the given providers,
+// such as `NewDirSchemaProvider` for internal schema forks or
+// `NewHTTPSchemaProvider` for spec versions released after this build.
+// Providers are tried in order and the embedded schemas last, so a provider
+// overrides the embedded schema of the same spec version. A provider error
+// other than a missing schema causes ValidateSBOMData to return an error.
+func WithSchemaProviders(providers ...SchemaProvider) Option {
+	return func(o *validationOptions) {
+		o.schemaProviders = providers
+	}
+}
+
+// WithSecretScanning scans every string in the SBOM, including descriptions,
+// properties and URLs, for secrets such as CI tokens and cloud access keys,
+// which generators occasionally copy from the build environment. Each match
+// is an error. Without patterns, DefaultSecretPatterns are used; see
+// `ParseSecretPatterns` for loading a custom set.
+func WithSecretScanning(patterns ...SecretPattern) Option {
+	return func(o *validationOptions) {
+		if len(patterns) == 0 {
+			patterns = DefaultSecretPatterns
+		}
+		o.secretPatterns = patterns
+	}
+}
+
+// WithSignatureVerification verifies the SBOM's signature (see
+// `VerifySBOMSignature`) before validating it, and reports the signer in the
+// result's `Signature`. An SBOM that is unsigned or whose signature is not
+// trusted is not validated: ValidateSBOMData returns an error.
+//
+// A detached signature covers the content as passed to ValidateSBOMData,
+// before decompression; an embedded one the decompressed document.
+func WithSignatureVerification(policy SignaturePolicy) Option {
+	return func(o *validationOptions) {
+		o.signaturePolicy = &policy
+	}
+}
+
+// WithSuppressions adds suppressions: temporary exceptions to a rule for one
+// component, each with a justification and an expiry date (see
+// `Suppression`). Suppressed findings are left out of the result's findings
+// and listed in its `Suppressed`; invalid and expired suppressions are
+// reported as warnings of the suppression rule.
+func WithSuppressions(suppressions ...Suppression) Option {
+	return func(o *validationOptions) {
+		o.suppressions = append(slices.Clip(o.suppressions), suppressions...)
+	}
+}
+
+// WithValidationOptions sets the limits applied to the findings of a
+// validation: how many errors are reported, whether warnings make an SBOM
+// invalid and which rules are ignored. See `ValidationOptions`.
+func WithValidationOptions(limits ValidationOptions) Option {
+	return func(o *validationOptions) {
+		o.limits = limits
+	}
+}
+
+// WithVersionMode selects what happens when an SBOM declares a spec version
+// without a schema. In `VersionStrict` mode (the default) validation fails
+// with the list of supported versions; see `SupportedVersions`. In
+// `VersionLenient` mode the SBOM is validated against the closest supported
+// version (the newest one before it, or the oldest one for older SBOMs), the
+// result is flagged with `UnknownVersion` and a warning is reported.
+func WithVersionMode(mode VersionMode) Option {
+	return func(o *validationOptions) {
+		o.versionMode = mode
+	}
+}
+
+func newValidationOptions(opts []Option) *validationOptions {
+	o := &validationOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// identityResolver returns the configured identity resolver, or the default.
+func (o *validationOptions) identityResolver() IdentityResolver {
+	if o.identity != nil {
+		return o.identity
+	}
+	return DefaultIdentityResolver()
+}
@@ -0,0 +1,493 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy is a validation policy kept apart from code, e.g. in a YAML or JSON
+// file under version control, so security teams can review and change it
+// without touching the programs that validate SBOMs. It declares which rules
+// run, the level of their findings, and requirements on licenses, spec
+// versions and fields. Read it with LoadPolicy or ParsePolicy and apply it
+// with WithPolicy.
+//
+// Example:
+//
+//	rules: [license-expression, dependency-graph, quality-supplier]
+//	profiles: [build-phase]
+//	severity:
+//	  dependency-graph: warning
+//	  identifier-mismatch: ignore
+//	allowedLicenses: [MIT, Apache-2.0, BSD-3-Clause]
+//	minSpecVersion:
+//	  CycloneDX: "1.5"
+//	  SPDX: "2.3"
+//	requiredFields:
+//	  CycloneDX: [metadata.timestamp, "components[].supplier.name"]
+//	  SPDX: ["packages[].supplier"]
+//	failOnWarnings: true
+//	suppressions:
+//	  - rule: policy-license
+//	    component: pkg:npm/left-pad
+//	    justification: Replaced by an in-house package in JIRA-1234
+//	    expires: 2025-06-30
+type Policy struct {
+	// Rules are the IDs of optional rules to run (see `PolicyRules`), on top
+	// of those run on every SBOM.
+	Rules []string `json:"rules,omitempty" yaml:"rules"`
+	// Profiles are the profiles to apply, as with WithProfiles.
+	Profiles []Profile `json:"profiles,omitempty" yaml:"profiles"`
+	// Severity overrides the level of the findings of rules: "error",
+	// "warning", "info", or "ignore" to drop them.
+	Severity map[string]string `json:"severity,omitempty" yaml:"severity"`
+	// AllowedLicenses are the SPDX license IDs, LicenseRefs or "<license>
+	// WITH <exception>" terms components may be licensed under. A license
+	// expression is allowed when a choice of its licenses is; license names
+	// without an ID never are. Empty means any license.
+	AllowedLicenses []string `json:"allowedLicenses,omitempty" yaml:"allowedLicenses"`
+	// MinSpecVersion is the oldest spec version accepted per format, e.g.
+	// {"CycloneDX": "1.5"}.
+	MinSpecVersion map[Format]SpecVersion `json:"minSpecVersion,omitempty" yaml:"minSpecVersion"`
+	// RequiredFields are the fields an SBOM must declare per format, as
+	// dotted paths in its JSON form (e.g., "metadata.timestamp"). A segment
+	// ending in "[]" applies the rest of the path to every element of the
+	// array, e.g. "components[].supplier.name". Empty values count as
+	// missing.
+	RequiredFields map[Format][]string `json:"requiredFields,omitempty" yaml:"requiredFields"`
+	// FailOnWarnings makes an SBOM with warnings invalid, as with
+	// `ValidationOptions.FailOnWarnings`.
+	FailOnWarnings bool `json:"failOnWarnings,omitempty" yaml:"failOnWarnings"`
+	// Suppressions are temporary exceptions to rules for single components,
+	// as with WithSuppressions.
+	Suppressions []Suppression `json:"suppressions,omitempty" yaml:"suppressions"`
+}
+
+// policyRules are the optional rules a policy can enable, besides quality
+// checks.
+var policyRules = []string{
+	RuleComponentNaming,
+	RuleDependencyGraph,
+	RuleExternalReference,
+	RuleHashFormat,
+	RuleLicenseExpression,
+	RuleSecret,
+}
+
+// policySeverities maps the severities of a policy to finding levels; ""
+// means the findings are dropped.
+var policySeverities = map[string]FindingLevel{
+	"error":   LevelError,
+	"warning": LevelWarning,
+	"info":    LevelInfo,
+	"ignore":  "",
+}
+
+// requiredFieldPattern matches the paths of Policy.RequiredFields.
+var requiredFieldPattern = regexp.MustCompile(`^[A-Za-z0-9@_-]+(\[\])?(\.[A-Za-z0-9@_-]+(\[\])?)*$`)
+
+// PolicyRules returns the IDs of the rules a policy can enable with
+// `Policy.Rules`: the optional rules, which are otherwise enabled with their
+// own options, and every quality check.
+func PolicyRules() []string {
+	return append(slices.Clone(policyRules), qualityChecks...)
+}
+
+// ParsePolicy reads a policy from YAML or JSON.
+//
+// Parameters:
+//   - data: The policy.
+//
+// Returns:
+//   - The policy.
+//   - An error if the policy is malformed, has unknown fields, names an
+//     unknown rule, profile, severity, format, license or field path, or has
+//     a suppression without a justification or expiry date.
+//
+// Example:
+//
+//	policy, err := ParsePolicy([]byte("rules: [license-expression]\nallowedLicenses: [MIT]"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result, err := ValidateSBOMData(jsonData, WithPolicy(policy))
+func ParsePolicy(data []byte) (Policy, error) {
+	var policy Policy
+	// YAML is a superset of JSON, so one decoder reads both. This is the
+	// module's only dependency besides the schema library: the standard
+	// library has no YAML parser, and unlike the protobuf wire format a YAML
+	// parser is too large to write by hand safely.
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return Policy{}, fmt.Errorf("invalid policy: %v", err)
+	}
+
+	for _, rule := range policy.Rules {
+		if !slices.Contains(policyRules, rule) && !isQualityCheck(rule) {
+			return Policy{}, fmt.Errorf("rule %q cannot be enabled by a policy; use one of %s", rule, strings.Join(PolicyRules(), ", "))
+		}
+	}
+	for _, profile := range policy.Profiles {
+		if _, ok := profileRules[profile]; !ok {
+			return Policy{}, fmt.Errorf("unknown profile %q", profile)
+		}
+	}
+	for rule, severity := range policy.Severity {
+		if _, ok := LookupRule(rule); !ok {
+			return Policy{}, fmt.Errorf("unknown rule %q in severity", rule)
+		}
+		if _, ok := policySeverities[severity]; !ok {
+			return Policy{}, fmt.Errorf("invalid severity %q for %q; use error, warning, info or ignore", severity, rule)
+		}
+	}
+	for _, license := range policy.AllowedLicenses {
+		if terms, err := parseLicenseExpression(license); err != nil || len(terms) != 1 {
+			return Policy{}, fmt.Errorf("allowed license %q is not a single license", license)
+		}
+		if err := ValidateLicenseExpression(license); err != nil {
+			return Policy{}, fmt.Errorf("allowed license %q: %v", license, err)
+		}
+	}
+	for format, version := range policy.MinSpecVersion {
+		if err := checkPolicyFormat(format); err != nil {
+			return Policy{}, err
+		}
+		if _, err := ParseSpecVersion(string(version)); err != nil {
+			return Policy{}, fmt.Errorf("minimum %s version: %v", format, err)
+		}
+	}
+	for format, fields := range policy.RequiredFields {
+		if err := checkPolicyFormat(format); err != nil {
+			return Policy{}, err
+		}
+		for _, field := range fields {
+			if !requiredFieldPattern.MatchString(field) {
+				return Policy{}, fmt.Errorf("invalid required %s field %q", format, field)
+			}
+		}
+	}
+	for i, s := range policy.Suppressions {
+		if err := s.Validate(); err != nil {
+			return Policy{}, fmt.Errorf("suppression %d: %s", i+1, strings.ReplaceAll(err.Error(), "\n", "; "))
+		}
+	}
+	return policy, nil
+}
+
+func checkPolicyFormat(format Format) error {
+	if format != FormatCycloneDX && format != FormatSPDX {
+		return fmt.Errorf("unknown format %q; use %s or %s", format, FormatCycloneDX, FormatSPDX)
+	}
+	return nil
+}
+
+// LoadPolicy reads a policy file in YAML or JSON. See ParsePolicy.
+//
+// Example:
+//
+//	policy, err := LoadPolicy("sbom-policy.yaml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result, err := ValidateSBOMData(jsonData, WithPolicy(policy))
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read policy: %v", err)
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return Policy{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return policy, nil
+}
+
+// apply enables the rules and profiles of the policy and merges its
+// severities into the limits, keeping settings made by other options.
+func (p Policy) apply(o *validationOptions) {
+	for _, rule := range p.Rules {
+		switch rule {
+		case RuleComponentNaming:
+			o.componentNaming = true
+		case RuleDependencyGraph:
+			o.graphAnalysis = true
+		case RuleExternalReference:
+			if o.referenceCheck == nil {
+				o.referenceCheck = &ReferenceCheckPolicy{}
+			}
+		case RuleHashFormat:
+			if o.hashCheck == nil {
+				o.hashCheck = &HashCheckPolicy{}
+			}
+		case RuleLicenseExpression:
+			o.licenseValidation = true
+		case RuleSecret:
+			if len(o.secretPatterns) == 0 {
+				o.secretPatterns = DefaultSecretPatterns
+			}
+		default:
+			if !slices.Contains(o.qualityChecks, rule) {
+				o.qualityChecks = append(o.qualityChecks, rule)
+			}
+		}
+	}
+	for _, profile := range p.Profiles {
+		if !slices.Contains(o.profiles, profile) {
+			o.profiles = append(o.profiles, profile)
+		}
+	}
+
+	rules := make([]string, 0, len(p.Severity))
+	for rule := range p.Severity {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	levels := maps.Clone(o.limits.Levels)
+	for _, rule := range rules {
+		level := policySeverities[p.Severity[rule]]
+		if level == "" {
+			o.limits.IgnoreRules = append(slices.Clip(o.limits.IgnoreRules), rule)
+			continue
+		}
+		if levels == nil {
+			levels = map[string]FindingLevel{}
+		}
+		levels[rule] = level
+	}
+	o.limits.Levels = levels
+	o.limits.FailOnWarnings = o.limits.FailOnWarnings || p.FailOnWarnings
+	o.suppressions = append(slices.Clip(o.suppressions), p.Suppressions...)
+}
+
+// checkPolicy applies the license, spec version and field requirements of a
+// policy to a parsed SBOM.
+//
+// Returns the findings, which are errors unless the policy overrides their
+// level, and the IDs of the rules evaluated.
+func checkPolicy(obj map[string]interface{}, sbomType string, policy *Policy) ([]Finding, []string) {
+	var findings []Finding
+	var rules []string
+	format := Format(sbomFormat(sbomType))
+	add := func(rule, path, message string) {
+		findings = append(findings, Finding{Level: LevelError, Rule: rule, Path: path, Pointer: jsonPointer(path), Message: message})
+	}
+
+	if len(policy.AllowedLicenses) > 0 {
+		rules = append(rules, RulePolicyLicense)
+		allowed := map[string]bool{}
+		for _, license := range policy.AllowedLicenses {
+			allowed[normalizeLicenseTerm(license)] = true
+		}
+		walkLicenses(obj, sbomType, func(path, expression string) {
+			if !licenseAllowed(expression, allowed) {
+				add(RulePolicyLicense, path, fmt.Sprintf("license %q is not allowed by the policy", expression))
+			}
+		})
+	}
+
+	if min, ok := policy.MinSpecVersion[format]; ok {
+		rules = append(rules, RulePolicySpecVersion)
+		path, declared := "specVersion", sbomType
+		if format == FormatSPDX {
+			path = "spdxVersion"
+		} else {
+			declared, _ = obj["specVersion"].(string)
+		}
+		if version, err := ParseSpecVersion(declared); err == nil && !version.AtLeast(min) {
+			add(RulePolicySpecVersion, path, fmt.Sprintf("spec version %s is older than %s, the oldest the policy allows", version, min))
+		}
+	}
+
+	if fields := policy.RequiredFields[format]; len(fields) > 0 {
+		rules = append(rules, RulePolicyRequiredField)
+		for _, field := range fields {
+			missingFields(obj, "", strings.Split(field, "."), func(path string) {
+				add(RulePolicyRequiredField, path, "missing field required by the policy")
+			})
+		}
+	}
+
+	return findings, rules
+}
+
+// walkLicenses calls fn with the path and value of every license of a parsed
+// SBOM: CycloneDX license IDs, names and expressions, and SPDX declared and
+// concluded licenses other than NONE and NOASSERTION.
+func walkLicenses(obj map[string]interface{}, sbomType string, fn func(path, license string)) {
+	if sbomType == SBOM_CYCLONEDX {
+		visit := func(path string, holder map[string]interface{}) {
+			licenses, _ := holder["licenses"].([]interface{})
+			for i, l := range licenses {
+				choice, _ := l.(map[string]interface{})
+				if expression, ok := choice["expression"].(string); ok {
+					fn(fmt.Sprintf("%slicenses.%d.expression", path, i), expression)
+					continue
+				}
+				license, _ := choice["license"].(map[string]interface{})
+				for _, field := range []string{"id", "name"} {
+					if value, ok := license[field].(string); ok {
+						fn(fmt.Sprintf("%slicenses.%d.license.%s", path, i, field), value)
+						break
+					}
+				}
+			}
+		}
+		if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+			visit("metadata.", metadata)
+		}
+		walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+			visit(path+".", component)
+		})
+		return
+	}
+
+	for _, collection := range []string{"packages", "files"} {
+		elements, _ := obj[collection].([]interface{})
+		for i, e := range elements {
+			element, _ := e.(map[string]interface{})
+			for _, field := range []string{"licenseDeclared", "licenseConcluded"} {
+				if license, _ := element[field].(string); license != "" && license != "NONE" && license != "NOASSERTION" {
+					fn(fmt.Sprintf("%s.%d.%s", collection, i, field), license)
+				}
+			}
+		}
+	}
+}
+
+// normalizeLicenseTerm returns the form of a license, or of a "<license> WITH
+// <exception>" term, that allowed lists are keyed by.
+func normalizeLicenseTerm(term string) string {
+	return strings.ToLower(strings.Join(strings.Fields(term), " "))
+}
+
+// licenseAllowed reports whether a license expression can be satisfied with
+// allowed licenses. Malformed expressions, reported by the license-expression
+// rule, are taken literally.
+func licenseAllowed(expression string, allowed map[string]bool) bool {
+	if _, err := parseLicenseExpression(expression); err != nil {
+		return allowed[normalizeLicenseTerm(expression)]
+	}
+	e := &licenseAllowedEvaluator{tokens: tokenizeLicenseExpression(expression), allowed: allowed}
+	return e.compound()
+}
+
+// licenseAllowedEvaluator evaluates a well-formed license expression against
+// an allowed list, following the grammar of licenseExpressionParser: an OR is
+// allowed when either side is, an AND when both are.
+type licenseAllowedEvaluator struct {
+	tokens  []licenseToken
+	pos     int
+	allowed map[string]bool
+}
+
+func (e *licenseAllowedEvaluator) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos].text
+	}
+	return ""
+}
+
+func (e *licenseAllowedEvaluator) compound() bool {
+	allowed := e.and()
+	for e.peek() == "OR" {
+		e.pos++
+		// evaluated first, so that the choice is consumed
+		choice := e.and()
+		allowed = allowed || choice
+	}
+	return allowed
+}
+
+func (e *licenseAllowedEvaluator) and() bool {
+	allowed := e.with()
+	for e.peek() == "AND" {
+		e.pos++
+		other := e.with()
+		allowed = allowed && other
+	}
+	return allowed
+}
+
+func (e *licenseAllowedEvaluator) with() bool {
+	if e.peek() == "(" {
+		e.pos++
+		allowed := e.compound()
+		e.pos++ // ")"
+		return allowed
+	}
+	id := e.tokens[e.pos].text
+	e.pos++
+	// "or later" may be satisfied with the version named
+	ids := []string{id}
+	if strings.HasSuffix(id, "+") && !licenseRefPattern.MatchString(id) {
+		ids = append(ids, strings.TrimSuffix(id, "+"))
+	}
+	exception := ""
+	if e.peek() == "WITH" {
+		exception = e.tokens[e.pos+1].text
+		e.pos += 2
+	}
+	for _, id := range ids {
+		// an exception only grants permissions, so the license alone suffices
+		if e.allowed[normalizeLicenseTerm(id)] || (exception != "" && e.allowed[normalizeLicenseTerm(id+" WITH "+exception)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingFields calls fn with the dotted path of every field of a required
+// field path (split at ".") that is missing or empty in value.
+func missingFields(value interface{}, prefix string, segments []string, fn func(path string)) {
+	if len(segments) == 0 {
+		return
+	}
+	obj, _ := value.(map[string]interface{})
+	name, each := strings.CutSuffix(segments[0], "[]")
+	path := name
+	if prefix != "" {
+		path = prefix + "." + name
+	}
+
+	field := obj[name]
+	switch v := field.(type) {
+	case nil:
+		fn(path)
+		return
+	case string:
+		if v == "" {
+			fn(path)
+			return
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fn(path)
+			return
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			fn(path)
+			return
+		}
+	}
+
+	if !each {
+		missingFields(field, path, segments[1:], fn)
+		return
+	}
+	elements, _ := field.([]interface{})
+	for i, element := range elements {
+		missingFields(element, fmt.Sprintf("%s.%d", path, i), segments[1:], fn)
+	}
+}
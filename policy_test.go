@@ -0,0 +1,213 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPolicy = `# reviewed by the security team
+rules: [license-expression, quality-supplier]
+profiles: [component-roles]
+severity:
+  policy-license: warning
+  spdx-relationship: ignore
+allowedLicenses: [MIT, Apache-2.0, "GPL-2.0-only WITH Classpath-exception-2.0"]
+minSpecVersion:
+  CycloneDX: 1.5
+  SPDX: "2.3"
+requiredFields:
+  SPDX: ["packages[].supplier"]
+failOnWarnings: true
+suppressions:
+  - rule: policy-license
+    component: pkg:npm/left-pad
+    justification: Replaced by an in-house package in JIRA-1234
+    expires: 2025-06-30
+`
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Policy{
+		Rules:           []string{RuleLicenseExpression, RuleQualitySupplier},
+		Profiles:        []Profile{ProfileComponentRoles},
+		Severity:        map[string]string{RulePolicyLicense: "warning", RuleSPDXRelationship: "ignore"},
+		AllowedLicenses: []string{"MIT", "Apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		MinSpecVersion:  map[Format]SpecVersion{FormatCycloneDX: "1.5", FormatSPDX: "2.3"},
+		RequiredFields:  map[Format][]string{FormatSPDX: {"packages[].supplier"}},
+		FailOnWarnings:  true,
+		Suppressions: []Suppression{{Rule: RulePolicyLicense, Component: "pkg:npm/left-pad",
+			Justification: "Replaced by an in-house package in JIRA-1234", Expires: "2025-06-30"}},
+	}
+	if !reflect.DeepEqual(policy, want) {
+		t.Errorf("ParsePolicy() = %+v, want %+v", policy, want)
+	}
+
+	policy, err = ParsePolicy([]byte(`{"rules": ["dependency-graph"], "minSpecVersion": {"SPDX": "2.2"}}`))
+	if err != nil || policy.Rules[0] != RuleDependencyGraph || policy.MinSpecVersion[FormatSPDX] != "2.2" {
+		t.Errorf("JSON policy = %+v, %v", policy, err)
+	}
+
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr string
+	}{
+		{name: "Unknown field", policy: "allowed: [MIT]", wantErr: "field allowed not found"},
+		{name: "Rule not enabled by policies", policy: "rules: [schema]", wantErr: `rule "schema" cannot be enabled`},
+		{name: "Unknown profile", policy: "profiles: [medical]", wantErr: `unknown profile "medical"`},
+		{name: "Unknown rule severity", policy: "severity: {nope: warning}", wantErr: `unknown rule "nope"`},
+		{name: "Invalid severity", policy: "severity: {schema: fatal}", wantErr: `invalid severity "fatal"`},
+		{name: "License expression", policy: "allowedLicenses: [MIT OR ISC]", wantErr: "not a single license"},
+		{name: "Unknown license", policy: "allowedLicenses: [Nope-1.0]", wantErr: "not on the SPDX License List"},
+		{name: "Unknown format", policy: "minSpecVersion: {SWID: 1.0}", wantErr: `unknown format "SWID"`},
+		{name: "Invalid version", policy: "minSpecVersion: {SPDX: latest}", wantErr: "invalid spec version"},
+		{name: "Invalid field", policy: "requiredFields: {SPDX: [packages..name]}", wantErr: "invalid required SPDX field"},
+		{name: "Suppression without expiry", policy: "suppressions: [{rule: policy-license, component: pkg:npm/left-pad, justification: Accepted}]", wantErr: "suppression 1: expiry date is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePolicy([]byte(tt.policy)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testPolicy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if policy, err := LoadPolicy(path); err != nil || !policy.FailOnWarnings {
+		t.Errorf("LoadPolicy() = %+v, %v", policy, err)
+	}
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestLicenseAllowed(t *testing.T) {
+	allowed := map[string]bool{}
+	for _, license := range []string{"MIT", "Apache-2.0", "GPL-2.0-only WITH Classpath-exception-2.0", "LicenseRef-acme"} {
+		allowed[normalizeLicenseTerm(license)] = true
+	}
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{expression: "MIT", want: true},
+		{expression: "mit", want: true},
+		{expression: "GPL-3.0-only", want: false},
+		{expression: "MIT OR GPL-3.0-only", want: true},
+		{expression: "MIT AND GPL-3.0-only", want: false},
+		{expression: "(GPL-3.0-only OR MIT) AND Apache-2.0", want: true},
+		{expression: "Apache-2.0 WITH LLVM-exception", want: true},
+		{expression: "GPL-2.0-only WITH Classpath-exception-2.0", want: true},
+		{expression: "GPL-2.0-only", want: false},
+		{expression: "Apache-2.0+", want: true},
+		{expression: "LicenseRef-acme", want: true},
+		{expression: "Apache License 2.0", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			if got := licenseAllowed(tt.expression, allowed); got != tt.want {
+				t.Errorf("licenseAllowed(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	obj, err := parseJSON(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.4",
+  "metadata": {"timestamp": "2024-10-22T12:00:00Z"},
+  "components": [
+    {"type": "library", "name": "a", "supplier": {"name": "Acme"}, "licenses": [{"license": {"id": "MIT"}}]},
+    {"type": "library", "name": "b", "supplier": {"name": ""}, "licenses": [{"license": {"name": "Proprietary"}}],
+     "components": [{"type": "library", "name": "c", "licenses": [{"expression": "MIT AND ISC"}]}]}
+  ]
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &Policy{
+		AllowedLicenses: []string{"MIT"},
+		MinSpecVersion:  map[Format]SpecVersion{FormatCycloneDX: "1.5", FormatSPDX: "2.3"},
+		RequiredFields: map[Format][]string{
+			FormatCycloneDX: {"metadata.timestamp", "metadata.component", "components[].supplier.name"},
+		},
+	}
+
+	findings, rules := checkPolicy(obj, SBOM_CYCLONEDX, policy)
+	if want := []string{RulePolicyLicense, RulePolicySpecVersion, RulePolicyRequiredField}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules = %q, want %q", rules, want)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Rule+" "+f.String())
+	}
+	want := []string{
+		`policy-license components.1.licenses.0.license.name: license "Proprietary" is not allowed by the policy`,
+		`policy-license components.1.components.0.licenses.0.expression: license "MIT AND ISC" is not allowed by the policy`,
+		`policy-spec-version specVersion: spec version 1.4 is older than 1.5, the oldest the policy allows`,
+		`policy-required-field metadata.component: missing field required by the policy`,
+		`policy-required-field components.1.supplier.name: missing field required by the policy`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "GPL-3.0-only"))
+
+	result, err := ValidateSBOMDataStructured(sbom, WithPolicy(policy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	levels := map[string]FindingLevel{}
+	for _, f := range result.Findings {
+		levels[f.Rule] = f.Level
+	}
+	// license findings are demoted to warnings, which fail the SBOM
+	if levels[RulePolicyLicense] != LevelWarning || levels[RulePolicyRequiredField] != LevelError || result.IsValid {
+		t.Errorf("unexpected findings: %+v", result.Findings)
+	}
+	if _, ok := levels[RuleQualitySupplier]; !ok {
+		t.Errorf("the quality check of the policy did not run: %+v", result.Findings)
+	}
+	if _, ok := levels[RuleSPDXRelationship]; ok {
+		t.Errorf("findings of an ignored rule were reported: %+v", result.Findings)
+	}
+
+	// the limits of other options are kept
+	options := newValidationOptions([]Option{
+		WithValidationOptions(ValidationOptions{MaxErrors: 3, IgnoreRules: []string{RuleSchemaFormat}}),
+		WithPolicy(policy),
+	})
+	want := ValidationOptions{
+		MaxErrors:      3,
+		FailOnWarnings: true,
+		IgnoreRules:    []string{RuleSchemaFormat, RuleSPDXRelationship},
+		Levels:         map[string]FindingLevel{RulePolicyLicense: LevelWarning},
+	}
+	if !reflect.DeepEqual(options.limits, want) {
+		t.Errorf("limits = %+v, want %+v", options.limits, want)
+	}
+}
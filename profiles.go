@@ -0,0 +1,65 @@
+package sbomvalidator
+
+import "fmt"
+
+// Profile is a set of additional requirements for a kind of SBOM, on top of
+// the schema. Profile violations are validation errors.
+type Profile string
+
+// Profiles that can be enabled with WithProfiles.
+const (
+	// ProfileMLBOM requires machine learning model components to reference
+	// their datasets, declare a license and report quantitative analysis.
+	ProfileMLBOM Profile = "ml-bom"
+	// ProfileFirmware requires every component of a firmware SBOM to declare
+	// hashes. Pair it with WithBinaryAnalyzers to merge findings from binary
+	// analysis of the image.
+	ProfileFirmware Profile = "firmware"
+	// ProfileBuildPhase requires a CycloneDX SBOM to declare the build
+	// lifecycle phase, as expected of SBOMs produced by a CI pipeline.
+	ProfileBuildPhase Profile = "build-phase"
+	// ProfileComponentRoles requires supplier, originator and publisher to
+	// be used consistently, e.g., an originator for repackaged open source,
+	// and warns about likely role confusion.
+	ProfileComponentRoles Profile = "component-roles"
+)
+
+// profileRules lists the rules each profile evaluates.
+var profileRules = map[Profile][]string{
+	ProfileMLBOM:          {RuleMLDataset, RuleMLLicense, RuleMLQuantitativeAnalysis},
+	ProfileFirmware:       {RuleFirmwareHash},
+	ProfileBuildPhase:     {RuleBuildPhase},
+	ProfileComponentRoles: {RuleComponentRoles, RuleRoleConfusion},
+}
+
+// checkProfiles applies the requirements of each profile to a parsed SBOM.
+//
+// Returns the findings, which are validation errors except for the
+// component-roles heuristics, and the IDs of the rules evaluated, or
+// an error if a profile is unknown.
+func checkProfiles(obj map[string]interface{}, sbomType string, profiles []Profile) ([]Finding, []string, error) {
+	var errors []Finding
+	var rules []string
+
+	for _, profile := range profiles {
+		switch profile {
+		case ProfileMLBOM:
+			if sbomType == SBOM_CYCLONEDX {
+				errors = append(errors, checkMLBOMProfile(obj)...)
+			}
+		case ProfileFirmware:
+			errors = append(errors, messageFindings(LevelError, RuleFirmwareHash, checkFirmwareProfile(obj, sbomType))...)
+		case ProfileBuildPhase:
+			if sbomType == SBOM_CYCLONEDX {
+				errors = append(errors, checkBuildPhaseProfile(obj)...)
+			}
+		case ProfileComponentRoles:
+			errors = append(errors, checkComponentRoles(obj, sbomType)...)
+		default:
+			return nil, nil, fmt.Errorf("unknown profile %q", profile)
+		}
+		rules = append(rules, profileRules[profile]...)
+	}
+
+	return errors, rules, nil
+}
@@ -0,0 +1,43 @@
+package sbomvalidator
+
+// Stages reported to a progress handler, in the order they run. The schema
+// and rules stages are always reported; the others only when enabled.
+const (
+	StageSchema         = "schema"
+	StageRules          = "rules"
+	StageProfiles       = "profiles"
+	StageBinaryAnalysis = "binary-analysis"
+	StageQuality        = "quality"
+	StagePolicy         = "policy"
+)
+
+// ProgressEvent reports a validation stage that has finished and the findings
+// it produced. Across the events of one validation, the findings are those of
+// the final result, so a UI can render them as they arrive instead of waiting
+// for the whole document to be validated.
+type ProgressEvent struct {
+	Stage    string    `json:"stage"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// progressReporter hands the findings appended since its last report, less
+// those the result leaves out, to a progress handler.
+type progressReporter struct {
+	handler  func(ProgressEvent)
+	filter   findingFilter
+	reported int
+}
+
+func (p *progressReporter) report(stage string, findings []Finding) {
+	if p.handler == nil {
+		return
+	}
+	event := ProgressEvent{Stage: stage}
+	for _, f := range findings[p.reported:] {
+		if f, ok := p.filter.keep(f); ok {
+			event.Findings = append(event.Findings, f)
+		}
+	}
+	p.reported = len(findings)
+	p.handler(event)
+}
@@ -0,0 +1,62 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithProgress(t *testing.T) {
+	tests := []struct {
+		name       string
+		sbom       []byte
+		opts       []Option
+		wantStages []string
+	}{
+		{
+			name:       "Schema and rules",
+			sbom:       spdxDocument(spdxPackage("a", "left-pad", "MIT")),
+			wantStages: []string{StageSchema, StageRules},
+		},
+		{
+			name:       "Schema errors",
+			sbom:       []byte(`{"spdxVersion": "SPDX-2.3"}`),
+			wantStages: []string{StageSchema, StageRules},
+		},
+		{
+			name:       "Error limit",
+			sbom:       []byte(`{"spdxVersion": "SPDX-2.3"}`),
+			opts:       []Option{WithValidationOptions(ValidationOptions{MaxErrors: 1})},
+			wantStages: []string{StageSchema, StageRules},
+		},
+		{
+			name:       "Quality checks",
+			sbom:       spdxDocument(spdxPackage("a", "left-pad", "MIT")),
+			opts:       []Option{WithQualityChecks(RuleQualitySupplier, RuleQualityAuthor)},
+			wantStages: []string{StageSchema, StageRules, StageQuality},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stages []string
+			var findings []Finding
+			opts := append(tt.opts, WithProgress(func(event ProgressEvent) {
+				stages = append(stages, event.Stage)
+				findings = append(findings, event.Findings...)
+			}))
+
+			result, err := ValidateSBOMDataStructured(tt.sbom, opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(stages, tt.wantStages) {
+				t.Errorf("stages = %q, want %q", stages, tt.wantStages)
+			}
+			if !reflect.DeepEqual(findings, result.Findings) {
+				t.Errorf("reported findings %+v, result has %+v", findings, result.Findings)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package sbomvalidator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ProvenanceDiscrepancy describes a single inconsistency between an SBOM and
+// the build provenance it is checked against.
+type ProvenanceDiscrepancy struct {
+	Field           string `json:"field"`
+	SBOMValue       string `json:"sbomValue,omitempty"`
+	ProvenanceValue string `json:"provenanceValue,omitempty"`
+	Message         string `json:"message"`
+}
+
+// ProvenanceResult represents the outcome of cross-checking an SBOM against
+// SLSA build provenance.
+//
+// Besides the list of discrepancies, it exposes the key facts extracted from
+// the provenance so they can be reported alongside the validation result.
+type ProvenanceResult struct {
+	IsConsistent  bool                    `json:"isConsistent"`
+	PredicateType string                  `json:"predicateType,omitempty"`
+	Builder       string                  `json:"builder,omitempty"`
+	Commit        string                  `json:"commit,omitempty"`
+	BuildStarted  string                  `json:"buildStarted,omitempty"`
+	BuildFinished string                  `json:"buildFinished,omitempty"`
+	Discrepancies []ProvenanceDiscrepancy `json:"discrepancies,omitempty"`
+}
+
+// buildProvenance holds the fields extracted from an in-toto statement that
+// are relevant for cross-checking.
+type buildProvenance struct {
+	predicateType string
+	builder       string
+	commit        string
+	started       string
+	finished      string
+	subjects      []provenanceSubject
+}
+
+type provenanceSubject struct {
+	name    string
+	digests map[string]string // normalized algorithm -> lowercase hex digest
+}
+
+var gitCommitPattern = regexp.MustCompile(`\b[0-9a-fA-F]{40}\b`)
+
+// VerifyProvenance cross-checks an SBOM against SLSA build provenance.
+//
+// The provenance can be a bare in-toto statement or a DSSE envelope wrapping
+// one, with either a SLSA v0.2 or v1 predicate. The following checks are
+// performed:
+//   - Every provenance subject digest must match a hash declared in the SBOM,
+//     provided the SBOM declares hashes using the same algorithm.
+//   - If the SBOM references source commits (VCS external references or
+//     commit properties), one of them must match the provenance commit.
+//   - The SBOM creation timestamp must not be earlier than the build start.
+//
+// The builder identity is extracted and reported but not compared, since
+// neither CycloneDX nor SPDX has a standard field for it.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM JSON data.
+//   - provenanceContent: A byte slice containing the provenance JSON data.
+//
+// Returns:
+//   - A ProvenanceResult describing any discrepancies found.
+//   - An error if either document cannot be parsed.
+func VerifyProvenance(sbomContent []byte, provenanceContent []byte) (*ProvenanceResult, error) {
+	doc, err := parseSBOMDocument(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+	return verifyProvenance(doc, provenanceContent)
+}
+
+// verifyProvenance cross-checks a parsed SBOM against SLSA provenance.
+func verifyProvenance(doc *sbomDocument, provenanceContent []byte) (*ProvenanceResult, error) {
+	obj, sbomType := doc.obj, doc.sbomType
+
+	provenance, err := parseProvenance(provenanceContent)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProvenanceResult{
+		PredicateType: provenance.predicateType,
+		Builder:       provenance.builder,
+		Commit:        provenance.commit,
+		BuildStarted:  provenance.started,
+		BuildFinished: provenance.finished,
+	}
+
+	var sbomDigests map[string]map[string]bool
+	var sbomCommits []string
+	var sbomTimestamp string
+
+	if sbomType == SBOM_CYCLONEDX {
+		sbomDigests = cycloneDXDigests(obj)
+		sbomCommits = cycloneDXCommits(obj)
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		sbomTimestamp, _ = metadata["timestamp"].(string)
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		sbomDigests = spdxDigests(obj)
+		creationInfo, _ := obj["creationInfo"].(map[string]interface{})
+		sbomTimestamp, _ = creationInfo["created"].(string)
+	} else {
+		return nil, fmt.Errorf("unsupported SBOM type: %s", sbomType)
+	}
+
+	for _, subject := range provenance.subjects {
+		comparable, matched := false, false
+		for alg, digest := range subject.digests {
+			if known, ok := sbomDigests[alg]; ok {
+				comparable = true
+				if known[digest] {
+					matched = true
+				}
+			}
+		}
+		if comparable && !matched {
+			result.Discrepancies = append(result.Discrepancies, ProvenanceDiscrepancy{
+				Field:           "subject",
+				ProvenanceValue: subject.name,
+				Message:         "provenance subject digest does not match any hash declared in the SBOM",
+			})
+		}
+	}
+
+	if provenance.commit != "" && len(sbomCommits) > 0 {
+		matched := false
+		for _, commit := range sbomCommits {
+			if strings.EqualFold(commit, provenance.commit) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			result.Discrepancies = append(result.Discrepancies, ProvenanceDiscrepancy{
+				Field:           "commit",
+				SBOMValue:       strings.Join(sbomCommits, ", "),
+				ProvenanceValue: provenance.commit,
+				Message:         "SBOM source commit does not match the provenance commit",
+			})
+		}
+	}
+
+	if sbomTimestamp != "" && provenance.started != "" {
+		created, err1 := time.Parse(time.RFC3339, sbomTimestamp)
+		started, err2 := time.Parse(time.RFC3339, provenance.started)
+		if err1 == nil && err2 == nil && created.Before(started) {
+			result.Discrepancies = append(result.Discrepancies, ProvenanceDiscrepancy{
+				Field:           "timestamp",
+				SBOMValue:       sbomTimestamp,
+				ProvenanceValue: provenance.started,
+				Message:         "SBOM was created before the build started",
+			})
+		}
+	}
+
+	result.IsConsistent = len(result.Discrepancies) == 0
+
+	return result, nil
+}
+
+// parseProvenance extracts the relevant fields from an in-toto statement,
+// unwrapping a DSSE envelope first if necessary.
+func parseProvenance(content []byte) (*buildProvenance, error) {
+	obj, err := parseJSON(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid provenance: %w", err)
+	}
+
+	// DSSE envelope: the statement is base64 encoded in the payload
+	if payload, ok := obj["payload"].(string); ok {
+		decoded, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid provenance envelope payload: %w", err)
+		}
+		var statement map[string]interface{}
+		if err := json.Unmarshal(decoded, &statement); err != nil {
+			return nil, fmt.Errorf("invalid provenance envelope payload: %w", err)
+		}
+		obj = statement
+	}
+
+	predicateType, _ := obj["predicateType"].(string)
+	if !strings.HasPrefix(predicateType, "https://slsa.dev/provenance/") {
+		return nil, fmt.Errorf("unsupported provenance predicate type: %q", predicateType)
+	}
+
+	provenance := &buildProvenance{predicateType: predicateType}
+
+	subjects, _ := obj["subject"].([]interface{})
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := subject["name"].(string)
+		digests := map[string]string{}
+		digestMap, _ := subject["digest"].(map[string]interface{})
+		for alg, value := range digestMap {
+			if v, ok := value.(string); ok {
+				digests[normalizeHashAlgorithm(alg)] = strings.ToLower(v)
+			}
+		}
+		provenance.subjects = append(provenance.subjects, provenanceSubject{name: name, digests: digests})
+	}
+
+	predicate, _ := obj["predicate"].(map[string]interface{})
+
+	if strings.HasSuffix(predicateType, "/v0.2") {
+		builder, _ := predicate["builder"].(map[string]interface{})
+		provenance.builder, _ = builder["id"].(string)
+
+		metadata, _ := predicate["metadata"].(map[string]interface{})
+		provenance.started, _ = metadata["buildStartedOn"].(string)
+		provenance.finished, _ = metadata["buildFinishedOn"].(string)
+
+		invocation, _ := predicate["invocation"].(map[string]interface{})
+		configSource, _ := invocation["configSource"].(map[string]interface{})
+		digest, _ := configSource["digest"].(map[string]interface{})
+		provenance.commit, _ = digest["sha1"].(string)
+	} else {
+		runDetails, _ := predicate["runDetails"].(map[string]interface{})
+		builder, _ := runDetails["builder"].(map[string]interface{})
+		provenance.builder, _ = builder["id"].(string)
+
+		metadata, _ := runDetails["metadata"].(map[string]interface{})
+		provenance.started, _ = metadata["startedOn"].(string)
+		provenance.finished, _ = metadata["finishedOn"].(string)
+
+		buildDefinition, _ := predicate["buildDefinition"].(map[string]interface{})
+		dependencies, _ := buildDefinition["resolvedDependencies"].([]interface{})
+		for _, d := range dependencies {
+			dependency, ok := d.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			digest, _ := dependency["digest"].(map[string]interface{})
+			if commit, ok := digest["gitCommit"].(string); ok {
+				provenance.commit = commit
+				break
+			}
+			if commit, ok := digest["sha1"].(string); ok {
+				provenance.commit = commit
+				break
+			}
+		}
+	}
+
+	return provenance, nil
+}
+
+// cycloneDXDigests collects every hash declared on metadata.component and on
+// components (recursively), grouped by normalized algorithm.
+func cycloneDXDigests(obj map[string]interface{}) map[string]map[string]bool {
+	digests := map[string]map[string]bool{}
+
+	addHashes := func(component map[string]interface{}) {
+		list, _ := component["hashes"].([]interface{})
+		for _, h := range list {
+			entry, ok := h.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			alg, _ := entry["alg"].(string)
+			content, _ := entry["content"].(string)
+			if alg == "" || content == "" {
+				continue
+			}
+			alg = normalizeHashAlgorithm(alg)
+			if digests[alg] == nil {
+				digests[alg] = map[string]bool{}
+			}
+			digests[alg][strings.ToLower(content)] = true
+		}
+	}
+
+	var walk func(components []interface{})
+	walk = func(components []interface{}) {
+		for _, c := range components {
+			component, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			addHashes(component)
+			if nested, ok := component["components"].([]interface{}); ok {
+				walk(nested)
+			}
+		}
+	}
+
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		if component, ok := metadata["component"].(map[string]interface{}); ok {
+			addHashes(component)
+		}
+	}
+	components, _ := obj["components"].([]interface{})
+	walk(components)
+
+	return digests
+}
+
+// cycloneDXCommits collects git commit hashes referenced by metadata.component
+// through VCS external references or properties whose name mentions "commit".
+func cycloneDXCommits(obj map[string]interface{}) []string {
+	var commits []string
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	component, _ := metadata["component"].(map[string]interface{})
+
+	references, _ := component["externalReferences"].([]interface{})
+	for _, r := range references {
+		reference, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if refType, _ := reference["type"].(string); refType != "vcs" {
+			continue
+		}
+		url, _ := reference["url"].(string)
+		commits = append(commits, gitCommitPattern.FindAllString(url, -1)...)
+	}
+
+	for _, source := range []map[string]interface{}{metadata, component} {
+		properties, _ := source["properties"].([]interface{})
+		for _, p := range properties {
+			property, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := property["name"].(string)
+			value, _ := property["value"].(string)
+			if strings.Contains(strings.ToLower(name), "commit") {
+				commits = append(commits, gitCommitPattern.FindAllString(value, -1)...)
+			}
+		}
+	}
+
+	return commits
+}
+
+// spdxDigests collects every checksum declared on packages and files, grouped
+// by normalized algorithm.
+func spdxDigests(obj map[string]interface{}) map[string]map[string]bool {
+	digests := map[string]map[string]bool{}
+
+	for _, key := range []string{"packages", "files"} {
+		elements, _ := obj[key].([]interface{})
+		for _, e := range elements {
+			element, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			checksums, _ := element["checksums"].([]interface{})
+			for _, c := range checksums {
+				entry, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				alg, _ := entry["algorithm"].(string)
+				value, _ := entry["checksumValue"].(string)
+				if alg == "" || value == "" {
+					continue
+				}
+				alg = normalizeHashAlgorithm(alg)
+				if digests[alg] == nil {
+					digests[alg] = map[string]bool{}
+				}
+				digests[alg][strings.ToLower(value)] = true
+			}
+		}
+	}
+
+	return digests
+}
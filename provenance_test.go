@@ -0,0 +1,123 @@
+package sbomvalidator
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestVerifyProvenance(t *testing.T) {
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+
+	provenanceV02 := `{
+		"_type": "https://in-toto.io/Statement/v0.1",
+		"predicateType": "https://slsa.dev/provenance/v0.2",
+		"subject": [{"name": "app", "digest": {"sha256": "aaaa"}}],
+		"predicate": {
+			"builder": {"id": "https://github.com/actions/runner"},
+			"metadata": {"buildStartedOn": "2024-10-22T10:00:00Z", "buildFinishedOn": "2024-10-22T10:05:00Z"},
+			"invocation": {"configSource": {"uri": "git+https://example.com/app", "digest": {"sha1": "` + commit + `"}}}
+		}
+	}`
+
+	provenanceV1 := `{
+		"_type": "https://in-toto.io/Statement/v1",
+		"predicateType": "https://slsa.dev/provenance/v1",
+		"subject": [{"name": "app", "digest": {"sha256": "aaaa"}}],
+		"predicate": {
+			"buildDefinition": {"resolvedDependencies": [{"uri": "git+https://example.com/app", "digest": {"gitCommit": "` + commit + `"}}]},
+			"runDetails": {
+				"builder": {"id": "https://example.com/builder"},
+				"metadata": {"startedOn": "2024-10-22T10:00:00Z"}
+			}
+		}
+	}`
+
+	envelope := `{"payloadType": "application/vnd.in-toto+json", "payload": "` +
+		base64.StdEncoding.EncodeToString([]byte(provenanceV1)) + `", "signatures": []}`
+
+	consistentSBOM := `{"bomFormat": "CycloneDX", "specVersion": "1.6", "metadata": {
+		"timestamp": "2024-10-22T12:00:00Z",
+		"component": {"type": "application", "name": "app",
+			"hashes": [{"alg": "SHA-256", "content": "AAAA"}],
+			"externalReferences": [{"type": "vcs", "url": "https://example.com/app/commit/` + commit + `"}]}
+	}}`
+
+	inconsistentSBOM := `{"bomFormat": "CycloneDX", "specVersion": "1.6", "metadata": {
+		"timestamp": "2024-10-21T12:00:00Z",
+		"component": {"type": "application", "name": "app",
+			"hashes": [{"alg": "SHA-256", "content": "bbbb"}],
+			"properties": [{"name": "git:commit", "value": "fedcba9876543210fedcba9876543210fedcba98"}]}
+	}}`
+
+	tests := []struct {
+		name           string
+		sbomData       string
+		provenance     string
+		wantConsistent bool
+		wantFields     []string
+		wantBuilder    string
+		expectErr      bool
+	}{
+		{
+			name:           "Consistent SBOM with v0.2 provenance",
+			sbomData:       consistentSBOM,
+			provenance:     provenanceV02,
+			wantConsistent: true,
+			wantBuilder:    "https://github.com/actions/runner",
+		},
+		{
+			name:           "Consistent SBOM with enveloped v1 provenance",
+			sbomData:       consistentSBOM,
+			provenance:     envelope,
+			wantConsistent: true,
+			wantBuilder:    "https://example.com/builder",
+		},
+		{
+			name:           "Inconsistent SBOM",
+			sbomData:       inconsistentSBOM,
+			provenance:     provenanceV02,
+			wantConsistent: false,
+			wantFields:     []string{"subject", "commit", "timestamp"},
+			wantBuilder:    "https://github.com/actions/runner",
+		},
+		{
+			name:           "SPDX without comparable data",
+			sbomData:       `{"spdxVersion": "SPDX-2.3", "creationInfo": {"created": "2024-10-22T12:00:00Z"}}`,
+			provenance:     provenanceV1,
+			wantConsistent: true,
+			wantBuilder:    "https://example.com/builder",
+		},
+		{
+			name:       "Unsupported predicate",
+			sbomData:   consistentSBOM,
+			provenance: `{"predicateType": "https://example.com/other"}`,
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := VerifyProvenance([]byte(tt.sbomData), []byte(tt.provenance))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("VerifyProvenance() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if result.IsConsistent != tt.wantConsistent {
+				t.Errorf("IsConsistent = %v, want %v: %+v", result.IsConsistent, tt.wantConsistent, result.Discrepancies)
+			}
+			if result.Builder != tt.wantBuilder {
+				t.Errorf("Builder = %q, want %q", result.Builder, tt.wantBuilder)
+			}
+			if len(result.Discrepancies) != len(tt.wantFields) {
+				t.Fatalf("got %d discrepancies, want %d: %+v", len(result.Discrepancies), len(tt.wantFields), result.Discrepancies)
+			}
+			for i, d := range result.Discrepancies {
+				if d.Field != tt.wantFields[i] {
+					t.Errorf("discrepancy %d field = %q, want %q", i, d.Field, tt.wantFields[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// packageURL is a parsed Package URL (purl), e.g.
+// "pkg:npm/%40angular/core@16.0.0?repository_url=...#sub/path".
+type packageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers map[string]string
+	Subpath    string
+}
+
+// ValidatePackageURL checks that purl is a structurally valid Package URL,
+// e.g., "pkg:npm/left-pad@1.3.0". Type-specific rules are not enforced.
+func ValidatePackageURL(purl string) error {
+	_, err := parsePackageURL(purl)
+	return err
+}
+
+// parsePackageURL parses a purl string as described by the purl specification.
+//
+// Only the structure is validated; type-specific rules are not enforced.
+func parsePackageURL(purl string) (*packageURL, error) {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return nil, fmt.Errorf("invalid purl %q: missing \"pkg:\" scheme", purl)
+	}
+	rest = strings.TrimLeft(rest, "/")
+
+	p := &packageURL{Qualifiers: map[string]string{}}
+
+	if i := strings.LastIndex(rest, "#"); i >= 0 {
+		p.Subpath = strings.Trim(rest[i+1:], "/")
+		rest = rest[:i]
+	}
+
+	if i := strings.LastIndex(rest, "?"); i >= 0 {
+		for _, pair := range strings.Split(rest[i+1:], "&") {
+			key, value, _ := strings.Cut(pair, "=")
+			if key == "" {
+				continue
+			}
+			unescaped, err := url.PathUnescape(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid purl %q: %w", purl, err)
+			}
+			p.Qualifiers[strings.ToLower(key)] = unescaped
+		}
+		rest = rest[:i]
+	}
+
+	typ, rest, ok := strings.Cut(rest, "/")
+	if !ok || typ == "" {
+		return nil, fmt.Errorf("invalid purl %q: missing type", purl)
+	}
+	p.Type = strings.ToLower(typ)
+
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		version, err := url.PathUnescape(rest[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid purl %q: %w", purl, err)
+		}
+		p.Version = version
+		rest = rest[:i]
+	}
+
+	rest = strings.Trim(rest, "/")
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		namespace, err := url.PathUnescape(rest[:i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid purl %q: %w", purl, err)
+		}
+		p.Namespace = namespace
+		rest = rest[i+1:]
+	}
+
+	name, err := url.PathUnescape(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid purl %q: %w", purl, err)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("invalid purl %q: missing name", purl)
+	}
+	p.Name = name
+
+	return p, nil
+}
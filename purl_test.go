@@ -0,0 +1,69 @@
+package sbomvalidator
+
+import (
+	"testing"
+)
+
+func TestParsePackageURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		purl      string
+		want      packageURL
+		expectErr bool
+	}{
+		{
+			name: "Simple purl",
+			purl: "pkg:pypi/requests@2.31.0",
+			want: packageURL{Type: "pypi", Name: "requests", Version: "2.31.0"},
+		},
+		{
+			name: "Scoped npm purl",
+			purl: "pkg:npm/%40angular/core@16.0.0",
+			want: packageURL{Type: "npm", Namespace: "@angular", Name: "core", Version: "16.0.0"},
+		},
+		{
+			name: "Qualifiers and subpath",
+			purl: "pkg:maven/org.apache/commons-lang3@3.12.0?type=jar&classifier=sources#src/main",
+			want: packageURL{
+				Type: "maven", Namespace: "org.apache", Name: "commons-lang3", Version: "3.12.0",
+				Qualifiers: map[string]string{"type": "jar", "classifier": "sources"}, Subpath: "src/main",
+			},
+		},
+		{
+			name: "No version",
+			purl: "pkg:golang/github.com/xeipuuv/gojsonschema",
+			want: packageURL{Type: "golang", Namespace: "github.com/xeipuuv", Name: "gojsonschema"},
+		},
+		{
+			name:      "Missing scheme",
+			purl:      "npm/left-pad@1.0.0",
+			expectErr: true,
+		},
+		{
+			name:      "Missing name",
+			purl:      "pkg:npm/",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePackageURL(tt.purl)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("parsePackageURL(%q) error = %v, expectErr %v", tt.purl, err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if got.Type != tt.want.Type || got.Namespace != tt.want.Namespace ||
+				got.Name != tt.want.Name || got.Version != tt.want.Version || got.Subpath != tt.want.Subpath {
+				t.Errorf("parsePackageURL(%q) = %+v, want %+v", tt.purl, *got, tt.want)
+			}
+			for k, v := range tt.want.Qualifiers {
+				if got.Qualifiers[k] != v {
+					t.Errorf("qualifier %q = %q, want %q", k, got.Qualifiers[k], v)
+				}
+			}
+		})
+	}
+}
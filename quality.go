@@ -0,0 +1,272 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quality check IDs. Quality checks go beyond the schema and report whether
+// an SBOM carries the NTIA minimum elements. They are only evaluated when
+// enabled with WithQualityChecks, and their findings are warnings.
+const (
+	RuleQualitySupplier     = "quality-supplier"
+	RuleQualityName         = "quality-component-name"
+	RuleQualityVersion      = "quality-version"
+	RuleQualityIdentifier   = "quality-unique-identifier"
+	RuleQualityDependencies = "quality-dependencies"
+	RuleQualityAuthor       = "quality-author"
+	RuleQualityTimestamp    = "quality-timestamp"
+)
+
+// qualityChecks lists the quality checks in reporting order.
+var qualityChecks = []string{
+	RuleQualitySupplier,
+	RuleQualityName,
+	RuleQualityVersion,
+	RuleQualityIdentifier,
+	RuleQualityDependencies,
+	RuleQualityAuthor,
+	RuleQualityTimestamp,
+}
+
+// QualityChecks returns the IDs of every quality check, for use with
+// WithQualityChecks.
+func QualityChecks() []string {
+	checks := make([]string, len(qualityChecks))
+	copy(checks, qualityChecks)
+	return checks
+}
+
+// QualityCheckResult reports the outcome of a single quality check.
+type QualityCheckResult struct {
+	Rule     string `json:"rule"`
+	Passed   bool   `json:"passed"`
+	Failures int    `json:"failures,omitempty"`
+}
+
+// identifierRefTypes are the SPDX external reference types that uniquely
+// identify a package.
+var identifierRefTypes = map[string]bool{
+	"purl":      true,
+	"cpe22Type": true,
+	"cpe23Type": true,
+	"swid":      true,
+	"gitoid":    true,
+}
+
+// checkQuality runs the given quality checks on a parsed SBOM.
+//
+// Returns the findings, all of them warnings, the outcome of each check in
+// the order requested, or an error if a check is unknown.
+func checkQuality(obj map[string]interface{}, sbomType string, checks []string) ([]Finding, []QualityCheckResult, error) {
+	for _, check := range checks {
+		if !isQualityCheck(check) {
+			return nil, nil, fmt.Errorf("unknown quality check %q", check)
+		}
+	}
+
+	var messages map[string][]string
+	if sbomType == SBOM_CYCLONEDX {
+		messages = cycloneDXQuality(obj)
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		messages = spdxQuality(obj)
+	}
+
+	var findings []Finding
+	results := make([]QualityCheckResult, 0, len(checks))
+	for _, check := range checks {
+		findings = append(findings, messageFindings(LevelWarning, check, messages[check])...)
+		results = append(results, QualityCheckResult{
+			Rule:     check,
+			Passed:   len(messages[check]) == 0,
+			Failures: len(messages[check]),
+		})
+	}
+	return findings, results, nil
+}
+
+func isQualityCheck(id string) bool {
+	for _, check := range qualityChecks {
+		if check == id {
+			return true
+		}
+	}
+	return false
+}
+
+// cycloneDXQuality evaluates every quality check on a CycloneDX SBOM and
+// returns the messages of each, keyed by rule ID.
+func cycloneDXQuality(obj map[string]interface{}) map[string][]string {
+	messages := map[string][]string{}
+	add := func(rule, path, msg string) {
+		messages[rule] = append(messages[rule], path+": "+msg)
+	}
+
+	declared := map[string]bool{}
+	dependencies, _ := obj["dependencies"].([]interface{})
+	for _, d := range dependencies {
+		if dependency, ok := d.(map[string]interface{}); ok {
+			if ref, ok := dependency["ref"].(string); ok {
+				declared[ref] = true
+			}
+		}
+	}
+
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		supplier, _ := component["supplier"].(map[string]interface{})
+		supplierName, _ := supplier["name"].(string)
+		publisher, _ := component["publisher"].(string)
+		if isBlank(supplierName) && isBlank(publisher) {
+			add(RuleQualitySupplier, path, "component does not declare a supplier")
+		}
+
+		if name, _ := component["name"].(string); isBlank(name) {
+			add(RuleQualityName, path, "component name is blank")
+		}
+
+		if version, _ := component["version"].(string); isBlank(version) {
+			add(RuleQualityVersion, path, "component does not declare a version")
+		}
+
+		if !hasCycloneDXIdentifier(component) {
+			add(RuleQualityIdentifier, path, "component has no purl, CPE, SWID tag or OmniBOR identifier")
+		}
+
+		ref, _ := component["bom-ref"].(string)
+		if ref == "" {
+			add(RuleQualityDependencies, path, "component has no bom-ref, so its dependencies cannot be declared")
+		} else if !declared[ref] {
+			add(RuleQualityDependencies, path, fmt.Sprintf("dependencies of %q are not declared", ref))
+		}
+	})
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	authors, _ := metadata["authors"].([]interface{})
+	if len(authors) == 0 && !hasCycloneDXTools(metadata) {
+		add(RuleQualityAuthor, "metadata", "SBOM declares neither authors nor the tools that generated it")
+	}
+	if timestamp, _ := metadata["timestamp"].(string); isBlank(timestamp) {
+		add(RuleQualityTimestamp, "metadata", "SBOM does not declare a timestamp")
+	}
+
+	return messages
+}
+
+// hasCycloneDXIdentifier reports whether a component declares an identifier
+// that is unique beyond its name and version.
+func hasCycloneDXIdentifier(component map[string]interface{}) bool {
+	for _, field := range []string{"purl", "cpe"} {
+		if value, _ := component[field].(string); !isBlank(value) {
+			return true
+		}
+	}
+	if swid, ok := component["swid"].(map[string]interface{}); ok {
+		if tagID, _ := swid["tagId"].(string); !isBlank(tagID) {
+			return true
+		}
+	}
+	omniborIDs, _ := component["omniborId"].([]interface{})
+	return len(omniborIDs) > 0
+}
+
+// hasCycloneDXTools reports whether metadata.tools lists a tool, in either the
+// legacy array form or the components/services form of CycloneDX 1.5.
+func hasCycloneDXTools(metadata map[string]interface{}) bool {
+	switch tools := metadata["tools"].(type) {
+	case []interface{}:
+		return len(tools) > 0
+	case map[string]interface{}:
+		components, _ := tools["components"].([]interface{})
+		services, _ := tools["services"].([]interface{})
+		return len(components) > 0 || len(services) > 0
+	}
+	return false
+}
+
+// spdxQuality evaluates every quality check on an SPDX SBOM and returns the
+// messages of each, keyed by rule ID.
+func spdxQuality(obj map[string]interface{}) map[string][]string {
+	messages := map[string][]string{}
+	add := func(rule, path, msg string) {
+		messages[rule] = append(messages[rule], path+": "+msg)
+	}
+
+	related := map[string]bool{}
+	relationships, _ := obj["relationships"].([]interface{})
+	for _, r := range relationships {
+		if relationship, ok := r.(map[string]interface{}); ok {
+			for _, field := range []string{"spdxElementId", "relatedSpdxElement"} {
+				if id, ok := relationship[field].(string); ok {
+					related[id] = true
+				}
+			}
+		}
+	}
+	described, _ := obj["documentDescribes"].([]interface{})
+	for _, d := range described {
+		if id, ok := d.(string); ok {
+			related[id] = true
+		}
+	}
+
+	packages, _ := obj["packages"].([]interface{})
+	for i, p := range packages {
+		pkg, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("packages.%d", i)
+
+		if supplier, _ := pkg["supplier"].(string); isBlank(supplier) || supplier == "NOASSERTION" {
+			add(RuleQualitySupplier, path, "package does not declare a supplier")
+		}
+
+		if name, _ := pkg["name"].(string); isBlank(name) {
+			add(RuleQualityName, path, "package name is blank")
+		}
+
+		if version, _ := pkg["versionInfo"].(string); isBlank(version) {
+			add(RuleQualityVersion, path, "package does not declare a version")
+		}
+
+		if !hasSPDXIdentifier(pkg) {
+			add(RuleQualityIdentifier, path, "package has no purl, CPE, SWID or gitoid external reference")
+		}
+
+		if id, _ := pkg["SPDXID"].(string); !related[id] {
+			add(RuleQualityDependencies, path, fmt.Sprintf("package %q is not part of any relationship", id))
+		}
+	}
+
+	creationInfo, _ := obj["creationInfo"].(map[string]interface{})
+	if creators, _ := creationInfo["creators"].([]interface{}); len(creators) == 0 {
+		add(RuleQualityAuthor, "creationInfo", "SBOM does not declare its creators")
+	}
+	if created, _ := creationInfo["created"].(string); isBlank(created) {
+		add(RuleQualityTimestamp, "creationInfo", "SBOM does not declare when it was created")
+	}
+
+	return messages
+}
+
+// hasSPDXIdentifier reports whether a package has an external reference that
+// uniquely identifies it.
+func hasSPDXIdentifier(pkg map[string]interface{}) bool {
+	refs, _ := pkg["externalRefs"].([]interface{})
+	for _, r := range refs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		refType, _ := ref["referenceType"].(string)
+		locator, _ := ref["referenceLocator"].(string)
+		if identifierRefTypes[refType] && !isBlank(locator) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
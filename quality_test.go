@@ -0,0 +1,198 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckQualityCycloneDX(t *testing.T) {
+	complete := `{"bom-ref": "a", "name": "a", "version": "1.0", "supplier": {"name": "Acme"}, "purl": "pkg:npm/a@1.0"}`
+
+	tests := []struct {
+		name       string
+		metadata   string
+		components string
+		want       map[string]string
+	}{
+		{
+			name:       "Complete",
+			metadata:   `{"timestamp": "2024-10-22T12:00:00Z", "authors": [{"name": "Jane"}]}`,
+			components: complete,
+		},
+		{
+			name:       "Tools instead of authors",
+			metadata:   `{"timestamp": "2024-10-22T12:00:00Z", "tools": {"components": [{"type": "application", "name": "syft"}]}}`,
+			components: complete,
+		},
+		{
+			name:       "Missing metadata",
+			metadata:   `{}`,
+			components: complete,
+			want: map[string]string{
+				RuleQualityAuthor:    "metadata: SBOM declares neither authors nor the tools",
+				RuleQualityTimestamp: "metadata: SBOM does not declare a timestamp",
+			},
+		},
+		{
+			name:       "Bare component",
+			metadata:   `{"timestamp": "2024-10-22T12:00:00Z", "authors": [{"name": "Jane"}]}`,
+			components: `{"name": " "}`,
+			want: map[string]string{
+				RuleQualitySupplier:     "components.0: component does not declare a supplier",
+				RuleQualityName:         "components.0: component name is blank",
+				RuleQualityVersion:      "components.0: component does not declare a version",
+				RuleQualityIdentifier:   "components.0: component has no purl",
+				RuleQualityDependencies: "components.0: component has no bom-ref",
+			},
+		},
+		{
+			name:       "Undeclared dependencies",
+			metadata:   `{"timestamp": "2024-10-22T12:00:00Z", "authors": [{"name": "Jane"}]}`,
+			components: `{"bom-ref": "b", "name": "b", "version": "1.0", "publisher": "Acme", "cpe": "cpe:2.3:a:acme:b:1.0:*:*:*:*:*:*:*"}`,
+			want: map[string]string{
+				RuleQualityDependencies: `components.0: dependencies of "b" are not declared`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "metadata": ` + tt.metadata +
+				`, "components": [` + tt.components + `], "dependencies": [{"ref": "a"}]}`)
+			if err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+
+			findings, results, err := checkQuality(obj, SBOM_CYCLONEDX, QualityChecks())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			checkQualityFindings(t, findings, results, tt.want)
+		})
+	}
+}
+
+func TestCheckQualitySPDX(t *testing.T) {
+	tests := []struct {
+		name          string
+		pkg           string
+		relationships string
+		want          map[string]string
+	}{
+		{
+			name:          "Complete",
+			pkg:           `{"SPDXID": "SPDXRef-a", "name": "a", "versionInfo": "1.0", "supplier": "Organization: Acme", "externalRefs": [{"referenceCategory": "SECURITY", "referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:acme:a:1.0:*:*:*:*:*:*:*"}]}`,
+			relationships: `[{"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-a"}]`,
+		},
+		{
+			name:          "Bare package",
+			pkg:           `{"SPDXID": "SPDXRef-a", "name": "a", "supplier": "NOASSERTION"}`,
+			relationships: `[]`,
+			want: map[string]string{
+				RuleQualitySupplier:     "packages.0: package does not declare a supplier",
+				RuleQualityVersion:      "packages.0: package does not declare a version",
+				RuleQualityIdentifier:   "packages.0: package has no purl",
+				RuleQualityDependencies: `packages.0: package "SPDXRef-a" is not part of any relationship`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(`{"spdxVersion": "SPDX-2.3", "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: test"]},
+				"packages": [` + tt.pkg + `], "relationships": ` + tt.relationships + `}`)
+			if err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+
+			findings, results, err := checkQuality(obj, "SPDX-2.3", QualityChecks())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			checkQualityFindings(t, findings, results, tt.want)
+		})
+	}
+}
+
+// checkQualityFindings checks that each rule in want failed once with a
+// message starting with the given prefix, and that every other check passed.
+func checkQualityFindings(t *testing.T, findings []Finding, results []QualityCheckResult, want map[string]string) {
+	t.Helper()
+
+	if len(findings) != len(want) {
+		t.Fatalf("Expected %d findings, got %d: %v", len(want), len(findings), findings)
+	}
+	for _, f := range findings {
+		if f.Level != LevelWarning {
+			t.Errorf("Finding %v is not a warning", f)
+		}
+		if !strings.HasPrefix(f.String(), want[f.Rule]) || want[f.Rule] == "" {
+			t.Errorf("Finding %q, want prefix %q", f.String(), want[f.Rule])
+		}
+	}
+	for _, r := range results {
+		if _, failed := want[r.Rule]; r.Passed == failed {
+			t.Errorf("Check %s passed = %v", r.Rule, r.Passed)
+		}
+	}
+}
+
+func TestCheckQualitySelection(t *testing.T) {
+	obj, err := parseJSON(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [{"name": "a"}]}`)
+	if err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+
+	findings, results, err := checkQuality(obj, SBOM_CYCLONEDX, []string{RuleQualityVersion})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []QualityCheckResult{{Rule: RuleQualityVersion, Passed: false, Failures: 1}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %+v, want %+v", results, want)
+	}
+	if len(findings) != 1 || findings[0].Rule != RuleQualityVersion {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+
+	if _, _, err := checkQuality(obj, SBOM_CYCLONEDX, []string{"quality-colour"}); err == nil {
+		t.Errorf("Expected an error for an unknown check")
+	}
+}
+
+func TestValidateSBOMDataQuality(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+
+	result, err := ValidateSBOMData(sbom)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Quality != nil || len(result.Warnings) != 0 {
+		t.Errorf("Expected no quality checks by default, got %+v", result)
+	}
+
+	result, err = ValidateSBOMData(sbom, WithQualityChecks(RuleQualitySupplier, RuleQualityTimestamp))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Errorf("Quality findings must not make the SBOM invalid: %v", result.ValidationErrors)
+	}
+	want := []QualityCheckResult{
+		{Rule: RuleQualitySupplier, Passed: false, Failures: 1},
+		{Rule: RuleQualityTimestamp, Passed: true},
+	}
+	if !reflect.DeepEqual(result.Quality, want) {
+		t.Errorf("Quality = %+v, want %+v", result.Quality, want)
+	}
+	if len(result.Warnings) != 1 || result.Warnings[0] != "packages.0: package does not declare a supplier" {
+		t.Errorf("Unexpected warnings: %v", result.Warnings)
+	}
+
+	if _, err := ValidateSBOMData(sbom, WithQualityChecks("quality-colour")); err == nil {
+		t.Errorf("Expected an error for an unknown check")
+	}
+}
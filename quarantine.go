@@ -0,0 +1,77 @@
+package sbomvalidator
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// QuarantineReportSuffix is appended to the name of a quarantined SBOM to
+// name its report.
+const QuarantineReportSuffix = ".report.json"
+
+// QuarantinedSBOM is an SBOM that failed validation, handed to a Quarantine.
+type QuarantinedSBOM struct {
+	// Name identifies the SBOM, e.g., its path within the batch. It may
+	// contain slashes.
+	Name string
+	// Content is the SBOM as it was read, before decompression.
+	Content []byte
+	// Report is the JSON outcome of its validation, e.g., a BatchFileResult.
+	Report []byte
+}
+
+// Quarantine stores SBOMs that failed validation, with their reports, away
+// from the validated set, so downstream ingestion only consumes SBOMs that
+// passed. DirQuarantine stores them in a directory; implement Quarantine to
+// store them elsewhere, such as in an object storage bucket.
+type Quarantine interface {
+	Quarantine(ctx context.Context, sbom QuarantinedSBOM) error
+}
+
+// DirQuarantine stores quarantined SBOMs under a directory, each next to its
+// report (named with QuarantineReportSuffix). The directory structure of
+// names is kept, but names cannot escape the directory: leading slashes and
+// ".." elements are dropped.
+type DirQuarantine struct {
+	Dir string
+}
+
+// Quarantine writes the SBOM and its report, creating directories as needed.
+func (q DirQuarantine) Quarantine(ctx context.Context, sbom QuarantinedSBOM) error {
+	name := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(sbom.Name)), "/")
+	if name == "" {
+		return fmt.Errorf("invalid quarantine name %q", sbom.Name)
+	}
+	target := filepath.Join(q.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, sbom.Content, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(target+QuarantineReportSuffix, sbom.Report, 0o644)
+}
+
+// QuarantinePolicy configures the quarantine enabled by `WithQuarantine`.
+type QuarantinePolicy struct {
+	// Quarantine stores the SBOMs that are invalid, fail checksum
+	// verification or cannot be validated.
+	Quarantine Quarantine
+	// Move removes quarantined SBOMs from their source once they are
+	// quarantined, so the source only holds SBOMs that passed. It applies to
+	// inputs that can be removed: those of BatchFiles, and of ValidateSBOMDir
+	// when the directory is a RemoveFS. Others are copied.
+	Move bool
+}
+
+// RemoveFS is a file system whose files can be removed, such as a directory
+// opened for ValidateSBOMDir with a quarantine that moves SBOMs.
+type RemoveFS interface {
+	fs.FS
+	Remove(name string) error
+}
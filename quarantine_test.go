@@ -0,0 +1,117 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDirQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	q := DirQuarantine{Dir: dir}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "a/bom.json", want: "a/bom.json"},
+		{name: "/var/sboms/bom.json", want: "var/sboms/bom.json"},
+		{name: "../../etc/bom.json", want: "etc/bom.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := q.Quarantine(context.Background(), QuarantinedSBOM{Name: tt.name, Content: []byte("sbom"), Report: []byte("{}")})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			target := filepath.Join(dir, filepath.FromSlash(tt.want))
+			if content, err := os.ReadFile(target); err != nil || string(content) != "sbom" {
+				t.Errorf("SBOM = %q, %v; want it at %s", content, err, tt.want)
+			}
+			if report, err := os.ReadFile(target + QuarantineReportSuffix); err != nil || string(report) != "{}" {
+				t.Errorf("report = %q, %v; want it next to the SBOM", report, err)
+			}
+		})
+	}
+
+	if err := q.Quarantine(context.Background(), QuarantinedSBOM{Name: "/"}); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
+
+type failingQuarantine struct{}
+
+func (failingQuarantine) Quarantine(ctx context.Context, sbom QuarantinedSBOM) error {
+	return errors.New("bucket unavailable")
+}
+
+func TestWithQuarantine(t *testing.T) {
+	source := t.TempDir()
+	files := map[string][]byte{
+		"valid.spdx.json":   spdxDocument(spdxPackage("a", "a", "MIT")),
+		"invalid.spdx.json": []byte(`{"spdxVersion": "SPDX-2.3"}`),
+		"notes.json":        []byte(`{"hello": "world"}`),
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(source, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	quarantine := t.TempDir()
+	batch := ValidateSBOMBatch(BatchFiles(paths...), WithQuarantine(QuarantinePolicy{
+		Quarantine: DirQuarantine{Dir: quarantine},
+		Move:       true,
+	}))
+
+	quarantined := map[string]bool{}
+	for _, r := range batch.Results {
+		if r.Quarantined {
+			quarantined[filepath.Base(r.Name)] = true
+		}
+		if r.QuarantineError != "" {
+			t.Errorf("%s: %s", r.Name, r.QuarantineError)
+		}
+	}
+	if want := map[string]bool{"invalid.spdx.json": true, "notes.json": true}; !reflect.DeepEqual(quarantined, want) {
+		t.Errorf("quarantined = %v, want %v", quarantined, want)
+	}
+	if batch.Summary.Quarantined != 2 || batch.Summary.Invalid != 1 || batch.Summary.Failed != 1 {
+		t.Errorf("unexpected summary: %+v", batch.Summary)
+	}
+
+	for name := range files {
+		_, err := os.Stat(filepath.Join(source, name))
+		if moved := errors.Is(err, os.ErrNotExist); moved != quarantined[name] {
+			t.Errorf("%s: moved = %v, want %v", name, moved, quarantined[name])
+		}
+	}
+
+	// names of BatchFiles are absolute paths, kept below the quarantine
+	target := filepath.Join(quarantine, source, "invalid.spdx.json")
+	var report BatchFileResult
+	data, err := os.ReadFile(target + QuarantineReportSuffix)
+	if err != nil {
+		t.Fatalf("failed to read the report: %v", err)
+	}
+	if err := json.Unmarshal(data, &report); err != nil || report.Result == nil || report.Result.IsValid {
+		t.Errorf("report = %s, %v; want the invalid result", data, err)
+	}
+
+	batch = ValidateSBOMBatch([]BatchInput{BatchReader("bad.json", strings.NewReader(`{}`))},
+		WithQuarantine(QuarantinePolicy{Quarantine: failingQuarantine{}}))
+	if r := batch.Results[0]; r.Quarantined || r.QuarantineError != "bucket unavailable" {
+		t.Errorf("unexpected result of a failed quarantine: %+v", r)
+	}
+}
@@ -0,0 +1,277 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch operations used in fixes.
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchTest    = "test"
+)
+
+// PatchOperation is a single RFC 6902 JSON Patch operation. Its path is a
+// JSON pointer into the JSON form of the SBOM.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON encodes the operation, keeping the value of add, replace and
+// test operations even when it is a zero value such as false or "".
+func (p PatchOperation) MarshalJSON() ([]byte, error) {
+	if p.Op == PatchRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{p.Op, p.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{p.Op, p.Path, p.Value})
+}
+
+// ApplyFixes applies the fixes of the given findings to an SBOM and returns
+// the fixed document. Callers choose which remediations to apply by passing
+// only the findings they want fixed; findings without a fix are ignored.
+//
+// Test operations are checked first, against the unmodified document, so a
+// fix is not applied to a document that has changed since it was validated.
+// Removals are applied last, from the end of the document backwards, so that
+// removing one array element does not shift the elements other fixes point
+// to. The output is indented JSON with object keys sorted.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM JSON data.
+//   - findings: The findings to remediate, e.g., from ValidateSBOMDataStructured.
+//
+// Returns:
+//   - The fixed SBOM.
+//   - An error if the SBOM is not JSON or a fix cannot be applied.
+//
+// Example:
+//
+//	result, _ := ValidateSBOMDataStructured(sbomBytes)
+//	fixed, err := ApplyFixes(sbomBytes, result.Findings)
+//	if err != nil {
+//	    log.Fatalf("Failed to apply fixes: %v", err)
+//	}
+func ApplyFixes(sbomContent []byte, findings []Finding) ([]byte, error) {
+	obj, err := parseJSONPreservingNumbers(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var tests, updates, removals []PatchOperation
+	seen := map[string]bool{}
+	for _, f := range findings {
+		for _, op := range f.Fix {
+			key := op.Op + " " + op.Path
+			if op.Op == PatchRemove && seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			switch op.Op {
+			case PatchTest:
+				tests = append(tests, op)
+			case PatchRemove:
+				removals = append(removals, op)
+			case PatchAdd, PatchReplace:
+				updates = append(updates, op)
+			default:
+				return nil, fmt.Errorf("unsupported patch operation %q", op.Op)
+			}
+		}
+	}
+
+	// later elements first, and children before their parents
+	sort.SliceStable(removals, func(i, j int) bool {
+		return comparePointers(removals[i].Path, removals[j].Path) > 0
+	})
+
+	var doc interface{} = obj
+	for _, ops := range [][]PatchOperation{tests, updates, removals} {
+		for _, op := range ops {
+			if doc, err = applyPatchOperation(doc, op); err != nil {
+				return nil, fmt.Errorf("failed to apply %s %s: %v", op.Op, op.Path, err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to serialize JSON: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// applyPatchOperation applies one operation to a document and returns the
+// updated document.
+func applyPatchOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	value, err := normalizePatchValue(op.Value)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := pointerSegments(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	return patchAt(doc, segments, op.Op, value)
+}
+
+// patchAt applies an operation at the location the segments point to,
+// relative to node, and returns the updated node.
+func patchAt(node interface{}, segments []string, op string, value interface{}) (interface{}, error) {
+	if len(segments) == 0 {
+		switch op {
+		case PatchAdd, PatchReplace:
+			return value, nil
+		case PatchTest:
+			if !reflect.DeepEqual(node, value) {
+				return nil, fmt.Errorf("test failed: the document has changed")
+			}
+			return node, nil
+		}
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	key, rest := segments[0], segments[1:]
+	switch container := node.(type) {
+	case map[string]interface{}:
+		child, ok := container[key]
+		if len(rest) > 0 || op != PatchAdd {
+			if !ok {
+				return nil, fmt.Errorf("%q does not exist", key)
+			}
+		}
+		if len(rest) == 0 && op == PatchRemove {
+			delete(container, key)
+			return container, nil
+		}
+		updated, err := patchAt(child, rest, op, value)
+		if err != nil {
+			return nil, err
+		}
+		container[key] = updated
+		return container, nil
+
+	case []interface{}:
+		if len(rest) == 0 && op == PatchAdd {
+			index := len(container)
+			if key != "-" {
+				var err error
+				if index, err = arrayIndex(key, len(container)+1); err != nil {
+					return nil, err
+				}
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+
+		index, err := arrayIndex(key, len(container))
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 && op == PatchRemove {
+			return append(container[:index], container[index+1:]...), nil
+		}
+		updated, err := patchAt(container[index], rest, op, value)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = updated
+		return container, nil
+	}
+
+	return nil, fmt.Errorf("%q is not inside an object or array", key)
+}
+
+// arrayIndex parses an array index segment and checks it is below limit.
+func arrayIndex(segment string, limit int) (int, error) {
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 || index >= limit || (len(segment) > 1 && segment[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", segment)
+	}
+	return index, nil
+}
+
+// normalizePatchValue converts a value to the representation used by
+// parseJSONPreservingNumbers, so it can be compared with and inserted into a
+// parsed document.
+func normalizePatchValue(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var normalized interface{}
+	if err := dec.Decode(&normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// pointerSegments splits a JSON pointer into its unescaped reference tokens.
+func pointerSegments(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	segments := strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		segment = strings.ReplaceAll(segment, "~1", "/")
+		segments[i] = strings.ReplaceAll(segment, "~0", "~")
+	}
+	return segments, nil
+}
+
+// comparePointers orders JSON pointers by document position, comparing array
+// indices numerically. A pointer sorts before the pointers inside it.
+func comparePointers(a, b string) int {
+	as, _ := pointerSegments(a)
+	bs, _ := pointerSegments(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		ai, aErr := strconv.Atoi(as[i])
+		bi, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			if ai < bi {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(as[i], bs[i])
+	}
+	return len(as) - len(bs)
+}
+
+// removeFix returns the fix that removes the value at a dotted path.
+func removeFix(path string) []PatchOperation {
+	return []PatchOperation{{Op: PatchRemove, Path: jsonPointer(path)}}
+}
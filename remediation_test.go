@@ -0,0 +1,220 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestApplyFixes(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		fixes   [][]PatchOperation
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Remove property",
+			doc:   `{"a": 1, "b": 2}`,
+			fixes: [][]PatchOperation{{{Op: PatchRemove, Path: "/b"}}},
+			want:  `{"a":1}`,
+		},
+		{
+			name:  "Replace and append",
+			doc:   `{"a": [{"x": 1}], "n": 12345678901234567890}`,
+			fixes: [][]PatchOperation{{{Op: PatchReplace, Path: "/a/0/x", Value: false}}, {{Op: PatchAdd, Path: "/a/-", Value: map[string]int{"y": 2}}}},
+			want:  `{"a":[{"x":false},{"y":2}],"n":12345678901234567890}`,
+		},
+		{
+			name: "Removals in any order",
+			doc:  `{"a": ["p", "q", "r", "s"]}`,
+			fixes: [][]PatchOperation{
+				{{Op: PatchRemove, Path: "/a/1"}},
+				{{Op: PatchRemove, Path: "/a/3"}},
+				{{Op: PatchRemove, Path: "/a/1"}},
+			},
+			want: `{"a":["p","r"]}`,
+		},
+		{
+			name: "Child before parent",
+			doc:  `{"a": [{"b": 1}, {"c": 2}]}`,
+			fixes: [][]PatchOperation{
+				{{Op: PatchRemove, Path: "/a/1"}},
+				{{Op: PatchRemove, Path: "/a/1/c"}},
+			},
+			want: `{"a":[{"b":1}]}`,
+		},
+		{
+			name:  "Escaped pointer",
+			doc:   `{"a/b": {"c~d": 1}}`,
+			fixes: [][]PatchOperation{{{Op: PatchRemove, Path: "/a~1b/c~0d"}}},
+			want:  `{"a/b":{}}`,
+		},
+		{
+			name:  "Passing test",
+			doc:   `{"a": {"b": 1}}`,
+			fixes: [][]PatchOperation{{{Op: PatchTest, Path: "/a", Value: map[string]int{"b": 1}}, {Op: PatchRemove, Path: "/a"}}},
+			want:  `{}`,
+		},
+		{
+			name:    "Failing test",
+			doc:     `{"a": {"b": 2}}`,
+			fixes:   [][]PatchOperation{{{Op: PatchTest, Path: "/a", Value: map[string]int{"b": 1}}, {Op: PatchRemove, Path: "/a"}}},
+			wantErr: "test failed",
+		},
+		{
+			name:    "Missing path",
+			doc:     `{"a": 1}`,
+			fixes:   [][]PatchOperation{{{Op: PatchRemove, Path: "/b"}}},
+			wantErr: `"b" does not exist`,
+		},
+		{
+			name:    "Unsupported operation",
+			doc:     `{"a": 1}`,
+			fixes:   [][]PatchOperation{{{Op: "move", Path: "/a"}}},
+			wantErr: `unsupported patch operation "move"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var findings []Finding
+			for _, fix := range tt.fixes {
+				findings = append(findings, Finding{Fix: fix})
+			}
+
+			fixed, err := ApplyFixes([]byte(tt.doc), findings)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			compact, _ := canonicalJSON(mustParse(t, fixed))
+			if string(compact) != tt.want {
+				t.Errorf("ApplyFixes() = %s, want %s", compact, tt.want)
+			}
+		})
+	}
+}
+
+func mustParse(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	obj, err := parseJSONPreservingNumbers(data)
+	if err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	return obj
+}
+
+func TestPatchOperationJSON(t *testing.T) {
+	data, _ := json.Marshal([]PatchOperation{
+		{Op: PatchRemove, Path: "/a"},
+		{Op: PatchReplace, Path: "/b", Value: false},
+	})
+	want := `[{"op":"remove","path":"/a"},{"op":"replace","path":"/b","value":false}]`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestLifecycleFixes(t *testing.T) {
+	doc := []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "metadata": {"lifecycles": [
+		{"phase": "build", "description": "CI"},
+		{"name": "Operations"},
+		{"name": "staging", "description": " "},
+		{"phase": "build"},
+		{"phase": "biuld"}
+	]}}`)
+
+	obj, _ := parseJSON(string(doc))
+	findings := checkLifecycles(obj)
+
+	fixed, err := ApplyFixes(doc, findings)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	obj, _ = parseJSON(string(fixed))
+	remaining := checkLifecycles(obj)
+	if len(remaining) != 1 || !strings.HasPrefix(remaining[0].String(), `metadata.lifecycles.3.phase: unknown lifecycle phase "biuld"`) {
+		t.Errorf("Expected only the unfixable finding to remain, got %v", remaining)
+	}
+
+	lifecycles, _ := json.Marshal(obj["metadata"].(map[string]interface{})["lifecycles"])
+	want := `[{"phase":"build"},{"phase":"operations"},{"name":"staging"},{"phase":"biuld"}]`
+	if string(lifecycles) != want {
+		t.Errorf("lifecycles = %s, want %s", lifecycles, want)
+	}
+}
+
+func TestBuildPhaseFix(t *testing.T) {
+	tests := []struct {
+		name string
+		sbom string
+	}{
+		{name: "Other phases", sbom: `{"specVersion": "1.6", "metadata": {"lifecycles": [{"phase": "operations"}]}}`},
+		{name: "No lifecycles", sbom: `{"specVersion": "1.6", "metadata": {"timestamp": "2024-10-22T12:00:00Z"}}`},
+		{name: "No metadata", sbom: `{"specVersion": "1.6"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, _ := parseJSON(tt.sbom)
+			findings := checkBuildPhaseProfile(obj)
+			if len(findings) != 1 || len(findings[0].Fix) == 0 {
+				t.Fatalf("Expected a finding with a fix, got %+v", findings)
+			}
+
+			fixed, err := ApplyFixes([]byte(tt.sbom), findings)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			obj, _ = parseJSON(string(fixed))
+			if remaining := checkBuildPhaseProfile(obj); len(remaining) != 0 {
+				t.Errorf("Fix did not resolve the finding: %s", fixed)
+			}
+		})
+	}
+}
+
+func TestSchemaFixes(t *testing.T) {
+	sbom := spdxDocument(strings.Replace(spdxPackage("a", "a", "MIT"), `"name"`, `"colour": "blue", "name"`, 1))
+
+	result, err := ValidateSBOMDataStructured(sbom)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Fatalf("Expected the unknown property to be rejected")
+	}
+
+	var fixable []Finding
+	for _, f := range result.Errors() {
+		if len(f.Fix) > 0 {
+			fixable = append(fixable, f)
+		}
+	}
+	if len(fixable) != 1 || fixable[0].Fix[0] != (PatchOperation{Op: PatchRemove, Path: "/packages/0/colour"}) {
+		t.Fatalf("Unexpected fixable findings: %+v", fixable)
+	}
+
+	fixed, err := ApplyFixes(sbom, fixable)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	result, err = ValidateSBOMDataStructured(fixed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Errorf("Expected the fixed SBOM to be valid, got %v", result.ValidationErrors)
+	}
+}
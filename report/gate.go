@@ -0,0 +1,47 @@
+package report
+
+import "fmt"
+
+// levelRanks orders the SARIF levels, from least to most severe.
+var levelRanks = map[string]int{LevelNote: 1, LevelWarning: 2, LevelError: 3}
+
+// Fails reports whether files fail a gate at a SARIF level: whether any
+// finding is reported at failOn or above (see `Level`), or any file could
+// not be validated.
+//
+// Parameters:
+//   - files: The results to gate.
+//   - failOn: The least severe level that fails: "error", "warning" or
+//     "note".
+//
+// Returns:
+//   - Whether the files fail the gate.
+//   - An error if failOn is not a SARIF level.
+//
+// Example:
+//
+//	failed, err := report.Fails(files, report.LevelWarning)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if failed {
+//	    os.Exit(1)
+//	}
+func Fails(files []File, failOn string) (bool, error) {
+	threshold, ok := levelRanks[failOn]
+	if !ok {
+		return false, fmt.Errorf("unknown level %q; expected error, warning or note", failOn)
+	}
+
+	for _, file := range files {
+		if file.Result == nil {
+			return true, nil
+		}
+		for _, f := range file.Result.Findings {
+			if levelRanks[Level(f)] >= threshold {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"testing"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		level    sbomvalidator.FindingLevel
+		severity sbomvalidator.Severity
+		want     string
+	}{
+		{level: sbomvalidator.LevelError, want: LevelError},
+		{level: sbomvalidator.LevelError, severity: sbomvalidator.SeverityLow, want: LevelError},
+		{level: sbomvalidator.LevelWarning, want: LevelWarning},
+		{level: sbomvalidator.LevelWarning, severity: sbomvalidator.SeverityCritical, want: LevelError},
+		{level: sbomvalidator.LevelWarning, severity: sbomvalidator.SeverityHigh, want: LevelError},
+		{level: sbomvalidator.LevelWarning, severity: sbomvalidator.SeverityMedium, want: LevelWarning},
+		{level: sbomvalidator.LevelWarning, severity: sbomvalidator.SeverityLow, want: LevelNote},
+		{level: sbomvalidator.LevelInfo, severity: sbomvalidator.SeverityHigh, want: LevelNote},
+	}
+
+	for _, tt := range tests {
+		if got := Level(sbomvalidator.Finding{Level: tt.level, Severity: tt.severity}); got != tt.want {
+			t.Errorf("Level(%s, %q) = %q, want %q", tt.level, tt.severity, got, tt.want)
+		}
+	}
+}
+
+func TestFails(t *testing.T) {
+	withFindings := func(findings ...sbomvalidator.Finding) []File {
+		return []File{{Name: "sbom.cdx.json", Result: &sbomvalidator.StructuredResult{Findings: findings}}}
+	}
+	lowWarning := sbomvalidator.Finding{Level: sbomvalidator.LevelWarning, Rule: sbomvalidator.RuleWeakCrypto, Severity: sbomvalidator.SeverityLow}
+	highWarning := sbomvalidator.Finding{Level: sbomvalidator.LevelWarning, Rule: sbomvalidator.RuleWeakCrypto, Severity: sbomvalidator.SeverityHigh}
+	warning := sbomvalidator.Finding{Level: sbomvalidator.LevelWarning, Rule: sbomvalidator.RuleIdentifierMismatch}
+
+	tests := []struct {
+		name   string
+		files  []File
+		failOn string
+		want   bool
+	}{
+		{name: "No findings", files: withFindings(), failOn: LevelNote},
+		{name: "Warning below error gate", files: withFindings(warning, lowWarning), failOn: LevelError},
+		{name: "High warning at error gate", files: withFindings(highWarning), failOn: LevelError, want: true},
+		{name: "Warning at warning gate", files: withFindings(warning), failOn: LevelWarning, want: true},
+		{name: "Low warning below warning gate", files: withFindings(lowWarning), failOn: LevelWarning},
+		{name: "Low warning at note gate", files: withFindings(lowWarning), failOn: LevelNote, want: true},
+		{name: "File not validated", files: []File{{Name: "notes.txt", Error: "unsupported"}}, failOn: LevelError, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Fails(tt.files, tt.failOn)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Fails() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Fails(nil, "critical"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,126 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit writes the results of files as a JUnit XML report with one test
+// case per file. An invalid SBOM is a failure listing its errors by line and
+// column; an SBOM that could not be validated is an error. Warnings, and
+// suppressed findings with their justification and expiry date, are written
+// to the test case's system-out.
+//
+// Parameters:
+//   - w: The writer to write the report to.
+//   - files: The results to report.
+//
+// Returns:
+//   - An error if writing fails.
+//
+// Example:
+//
+//	f, _ := os.Create("sbom-validation.xml")
+//	defer f.Close()
+//	report.WriteJUnit(f, files)
+func WriteJUnit(w io.Writer, files []File) error {
+	suite := junitTestSuite{Name: toolName, Tests: len(files)}
+
+	for _, file := range files {
+		tc := junitTestCase{ClassName: toolName, Name: file.Name}
+
+		if file.Result == nil {
+			tc.Error = &junitProblem{Message: file.Error, Type: "error"}
+			suite.Errors++
+			suite.TestCases = append(suite.TestCases, tc)
+			continue
+		}
+
+		var errors, warnings strings.Builder
+		for _, f := range file.Result.Findings {
+			line, col := location(file.Result, f)
+			entry := fmt.Sprintf("%s:%d:%d: [%s] %s\n", file.Name, line, col, f.Rule, f)
+			if f.Level == sbomvalidator.LevelError {
+				errors.WriteString(entry)
+			} else {
+				warnings.WriteString(entry)
+			}
+		}
+		for _, s := range file.Result.Suppressed {
+			line, col := location(file.Result, s.Finding)
+			fmt.Fprintf(&warnings, "%s:%d:%d: [%s] %s (suppressed until %s: %s)\n",
+				file.Name, line, col, s.Finding.Rule, s.Finding, s.Suppression.Expires, s.Suppression.Justification)
+		}
+
+		if !file.Result.IsValid {
+			tc.Failure = &junitProblem{
+				Message: fmt.Sprintf("%d validation errors", len(file.Result.ValidationErrors)),
+				Type:    "invalid",
+				Text:    errors.String(),
+			}
+			suite.Failures++
+		}
+		if warnings.Len() > 0 {
+			tc.SystemOut = &junitOutput{Text: warnings.String()}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	suites := junitTestSuites{
+		Name:     toolName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
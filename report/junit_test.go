@@ -0,0 +1,40 @@
+//go:build !sbomvalidator_no_spdx
+
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, testFiles(t)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("Expected an XML header: %s", buf.String())
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("Invalid XML: %v", err)
+	}
+	if suites.Tests != 3 || suites.Failures != 1 || suites.Errors != 1 || len(suites.Suites) != 1 {
+		t.Fatalf("Unexpected counts: %s", buf.String())
+	}
+
+	cases := suites.Suites[0].TestCases
+	if cases[0].Name != "sboms/valid.spdx.json" || cases[0].Failure != nil || cases[0].Error != nil {
+		t.Errorf("Expected the valid SBOM to pass: %+v", cases[0])
+	}
+	if f := cases[1].Failure; f == nil || f.Message != "1 validation errors" ||
+		!strings.Contains(f.Text, "sboms/invalid.spdx.json:8:") || !strings.Contains(f.Text, "[schema] packages.0: name is required") {
+		t.Errorf("Unexpected failure: %+v", cases[1].Failure)
+	}
+	if e := cases[2].Error; e == nil || e.Message == "" {
+		t.Errorf("Expected an error for a file that is not an SBOM: %+v", cases[2])
+	}
+}
@@ -0,0 +1,40 @@
+// Package report serializes sbom-validator results into formats understood by
+// other tools: SARIF 2.1.0, for GitHub code scanning and other static
+// analysis dashboards, and JUnit XML, for CI test reports.
+//
+// Both formatters take the results of any number of files, so a whole batch
+// can be reported at once:
+//
+//	result, err := sbomvalidator.ValidateSBOMDataStructured(data)
+//	files := []report.File{{Name: "sbom.cdx.json", Result: result}}
+//	if err != nil {
+//	    files[0].Error = err.Error()
+//	}
+//	report.WriteSARIF(os.Stdout, files)
+package report
+
+import (
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// toolName identifies sbom-validator in reports.
+const toolName = "sbom-validator"
+
+// File is the outcome of validating one SBOM file. Name is reported as the
+// file's path. Result is nil, and Error set, when the file could not be
+// validated.
+type File struct {
+	Name   string
+	Result *sbomvalidator.StructuredResult
+	Error  string
+}
+
+// location returns the line and column of a finding, or 1, 1 when it cannot
+// be located, since both formats count from 1.
+func location(result *sbomvalidator.StructuredResult, f sbomvalidator.Finding) (line, col int) {
+	line, col = result.Locate(f.String())
+	if line < 1 {
+		return 1, 1
+	}
+	return line, col
+}
@@ -0,0 +1,257 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// SARIF format identifiers.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolURI      = "https://github.com/shiftleftcyber/sbom-validator"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string           `json:"id"`
+	ShortDescription *sarifMessage    `json:"shortDescription,omitempty"`
+	Properties       *sarifProperties `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifProperties struct {
+	Tags     []string               `json:"tags,omitempty"`
+	Severity sbomvalidator.Severity `json:"severity,omitempty"`
+	// SecuritySeverity is the score GitHub code scanning ranks the results
+	// of a rule by, from "0.0" to "10.0".
+	SecuritySeverity string                         `json:"security-severity,omitempty"`
+	Keyword          string                         `json:"keyword,omitempty"`
+	Fix              []sbomvalidator.PatchOperation `json:"fix,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+	Properties   *sarifProperties   `json:"properties,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string                     `json:"kind"`
+	Status        string                     `json:"status"`
+	Justification string                     `json:"justification"`
+	Properties    sarifSuppressionProperties `json:"properties"`
+}
+
+type sarifSuppressionProperties struct {
+	Expires string `json:"expires"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// WriteSARIF writes the findings of files as a SARIF 2.1.0 log with a single
+// run. Each finding is a result of its rule, at the level `Level` maps it to,
+// located by line and column in its file and by JSON pointer; files that
+// could not be validated are reported as errors of the "document" rule.
+// Suppressed findings are results too, with an accepted external
+// suppression carrying its justification and expiry date, which code
+// scanning shows as dismissed. The
+// rules referenced by results are described from the rule catalog, tagged
+// with their control mappings and, for rules whose findings are graded, the
+// security severity of their most severe finding.
+//
+// Parameters:
+//   - w: The writer to write the log to.
+//   - files: The results to report.
+//
+// Returns:
+//   - An error if writing fails.
+//
+// Example:
+//
+//	f, _ := os.Create("sbom.sarif")
+//	defer f.Close()
+//	report.WriteSARIF(f, files)
+func WriteSARIF(w io.Writer, files []File) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationURI: toolURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndexes := map[string]int{}
+
+	add := func(uri string, f sbomvalidator.Finding, line, col int) {
+		index, ok := ruleIndexes[f.Rule]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndexes[f.Rule] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newSARIFRule(f.Rule))
+		}
+		if score, ok := securitySeverities[f.Severity]; ok {
+			rule := &run.Tool.Driver.Rules[index]
+			if rule.Properties == nil {
+				rule.Properties = &sarifProperties{}
+			}
+			if rule.Properties.SecuritySeverity < score {
+				rule.Properties.SecuritySeverity = score
+			}
+		}
+
+		result := sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: index,
+			Level:     Level(f),
+			Message:   sarifMessage{Text: f.String()},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: uri},
+					Region:           sarifRegion{StartLine: line, StartColumn: col},
+				},
+			}},
+		}
+		if f.Path != "" {
+			result.Locations[0].LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: f.Pointer, Kind: "member"}}
+		}
+		if f.Severity != "" || f.Keyword != "" || len(f.Fix) > 0 {
+			result.Properties = &sarifProperties{Severity: f.Severity, Keyword: f.Keyword, Fix: f.Fix}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	for _, file := range files {
+		uri := filepath.ToSlash(file.Name)
+		if file.Result == nil {
+			add(uri, sbomvalidator.Finding{Level: sbomvalidator.LevelError, Rule: sbomvalidator.RuleDocument, Message: file.Error}, 1, 1)
+			continue
+		}
+		for _, f := range file.Result.Findings {
+			line, col := location(file.Result, f)
+			add(uri, f, line, col)
+		}
+		for _, s := range file.Result.Suppressed {
+			line, col := location(file.Result, s.Finding)
+			add(uri, s.Finding, line, col)
+			run.Results[len(run.Results)-1].Suppressions = []sarifSuppression{{
+				Kind:          "external",
+				Status:        "accepted",
+				Justification: s.Suppression.Justification,
+				Properties:    sarifSuppressionProperties{Expires: s.Suppression.Expires},
+			}}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// newSARIFRule describes a rule from the rule catalog.
+func newSARIFRule(id string) sarifRule {
+	rule := sarifRule{ID: id}
+	catalogRule, ok := sbomvalidator.LookupRule(id)
+	if !ok {
+		return rule
+	}
+
+	rule.ShortDescription = &sarifMessage{Text: catalogRule.Title}
+	if len(catalogRule.Controls) > 0 {
+		rule.Properties = &sarifProperties{}
+		for _, c := range catalogRule.Controls {
+			rule.Properties.Tags = append(rule.Properties.Tags, c.Framework+" "+c.Control)
+		}
+	}
+	return rule
+}
+
+// SARIF levels, from most to least severe.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// securitySeverities maps finding severities to the security-severity
+// scores GitHub code scanning reads, in the middle of the band it displays
+// as that severity.
+var securitySeverities = map[sbomvalidator.Severity]string{
+	sbomvalidator.SeverityCritical: "9.5",
+	sbomvalidator.SeverityHigh:     "8.0",
+	sbomvalidator.SeverityMedium:   "5.5",
+	sbomvalidator.SeverityLow:      "2.0",
+}
+
+// Level returns the SARIF level a finding is reported at. Errors are errors
+// and info findings notes; warnings are graded by their severity, if any:
+// critical and high warnings are errors, low ones notes, and the others
+// warnings. `Fails` gates on the same levels, so that a pipeline fails on
+// the results code scanning displays at or above a level.
+func Level(f sbomvalidator.Finding) string {
+	switch f.Level {
+	case sbomvalidator.LevelError:
+		return LevelError
+	case sbomvalidator.LevelInfo:
+		return LevelNote
+	}
+	switch f.Severity {
+	case sbomvalidator.SeverityCritical, sbomvalidator.SeverityHigh:
+		return LevelError
+	case sbomvalidator.SeverityLow:
+		return LevelNote
+	}
+	return LevelWarning
+}
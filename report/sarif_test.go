@@ -0,0 +1,160 @@
+//go:build !sbomvalidator_no_spdx
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+const validSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "test",
+  "documentNamespace": "https://example.com/test",
+  "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: test"]}
+}`
+
+const invalidSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "test",
+  "documentNamespace": "https://example.com/test",
+  "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: test"]},
+  "packages": [{"SPDXID": "SPDXRef-a", "downloadLocation": "NOASSERTION"}]
+}`
+
+// testFiles returns the results of a valid SBOM, an invalid one and a file
+// that is not an SBOM.
+func testFiles(t *testing.T) []File {
+	t.Helper()
+
+	var files []File
+	for _, f := range []struct{ name, content string }{
+		{"sboms/valid.spdx.json", validSPDX},
+		{"sboms/invalid.spdx.json", invalidSPDX},
+		{"notes.txt", "hello"},
+	} {
+		result, err := sbomvalidator.ValidateSBOMDataStructured([]byte(f.content))
+		file := File{Name: f.name, Result: result}
+		if err != nil {
+			file.Result, file.Error = nil, err.Error()
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, testFiles(t)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected log: %s", buf.String())
+	}
+
+	run := log.Runs[0]
+	if len(run.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d: %s", len(run.Results), buf.String())
+	}
+
+	schema := run.Results[0]
+	if schema.RuleID != sbomvalidator.RuleSchema || schema.Level != "error" ||
+		schema.Message.Text != "packages.0: name is required" {
+		t.Errorf("Unexpected schema result: %+v", schema)
+	}
+	location := schema.Locations[0]
+	if location.PhysicalLocation.ArtifactLocation.URI != "sboms/invalid.spdx.json" ||
+		location.PhysicalLocation.Region.StartLine != 8 ||
+		location.LogicalLocations[0].FullyQualifiedName != "/packages/0" {
+		t.Errorf("Unexpected location: %+v", location)
+	}
+
+	document := run.Results[1]
+	if document.RuleID != sbomvalidator.RuleDocument || document.Locations[0].PhysicalLocation.Region.StartLine != 1 {
+		t.Errorf("Unexpected document result: %+v", document)
+	}
+
+	rules := run.Tool.Driver.Rules
+	if len(rules) != 2 || rules[schema.RuleIndex].ID != schema.RuleID || rules[document.RuleIndex].ID != document.RuleID {
+		t.Fatalf("Unexpected rules: %+v", rules)
+	}
+	if rules[0].ShortDescription == nil || rules[0].Properties == nil || len(rules[0].Properties.Tags) == 0 {
+		t.Errorf("Expected the schema rule to be described from the catalog: %+v", rules[0])
+	}
+}
+
+func TestWriteSARIFEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("Expected an empty results array: %s", buf.String())
+	}
+}
+
+func TestWriteSARIFSeverities(t *testing.T) {
+	files := []File{{Name: "cbom.cdx.json", Result: &sbomvalidator.StructuredResult{Findings: []sbomvalidator.Finding{
+		{Level: sbomvalidator.LevelWarning, Rule: sbomvalidator.RuleWeakCrypto, Path: "components.0", Message: "HMAC-SHA1 is weak", Severity: sbomvalidator.SeverityLow},
+		{Level: sbomvalidator.LevelWarning, Rule: sbomvalidator.RuleWeakCrypto, Path: "components.1", Message: "MD5 is broken", Severity: sbomvalidator.SeverityCritical},
+	}}}}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, files); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	run := log.Runs[0]
+	if run.Results[0].Level != "note" || run.Results[1].Level != "error" {
+		t.Errorf("Unexpected levels %q and %q", run.Results[0].Level, run.Results[1].Level)
+	}
+	if rule := run.Tool.Driver.Rules[0]; rule.Properties == nil || rule.Properties.SecuritySeverity != "9.5" {
+		t.Errorf("Expected the rule to have the security severity of its critical finding: %+v", rule)
+	}
+}
+
+func TestWriteSARIFSuppressed(t *testing.T) {
+	suppression := sbomvalidator.Suppression{Rule: sbomvalidator.RulePolicyLicense, Component: "pkg:npm/left-pad",
+		Justification: "Replaced in JIRA-1234", Expires: "2025-06-30"}
+	files := []File{{Name: "app.spdx.json", Result: &sbomvalidator.StructuredResult{Suppressed: []sbomvalidator.SuppressedFinding{{
+		Finding:     sbomvalidator.Finding{Level: sbomvalidator.LevelError, Rule: sbomvalidator.RulePolicyLicense, Path: "packages.0.licenseDeclared", Message: "GPL-3.0-only is not allowed"},
+		Suppression: suppression,
+	}}}}}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, files); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	results := log.Runs[0].Results
+	if len(results) != 1 || len(results[0].Suppressions) != 1 {
+		t.Fatalf("Expected one suppressed result, got %+v", results)
+	}
+	if s := results[0].Suppressions[0]; s.Kind != "external" || s.Status != "accepted" ||
+		s.Justification != suppression.Justification || s.Properties.Expires != suppression.Expires {
+		t.Errorf("Unexpected suppression %+v", s)
+	}
+	if failed, _ := Fails(files, LevelNote); failed {
+		t.Error("Expected suppressed findings not to fail the gate")
+	}
+}
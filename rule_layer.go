@@ -0,0 +1,163 @@
+package sbomvalidator
+
+import (
+	"context"
+	"strings"
+)
+
+// evaluateRules runs the semantic rules enabled by options on a parsed SBOM
+// and returns findings, extended with theirs, and the IDs of the rules
+// evaluated. Results beyond findings, such as the dependency graph and
+// relationship counts, are recorded on result.
+//
+// Rules only ever see the document model: the JSON form that every encoding
+// (JSON, CycloneDX XML and protobuf, SPDX tag-value) is converted to before
+// validation. They must not depend on the source encoding, so that each
+// serialization of an SBOM gets the same findings; parity_test.go checks
+// this for every rule.
+func evaluateRules(ctx context.Context, doc *sbomDocument, options *validationOptions, result *StructuredResult, progress *progressReporter, findings []Finding) ([]Finding, []string, error) {
+	obj, sbomType := doc.obj, doc.sbomType
+	var evaluatedRules []string
+
+	if sbomType == SBOM_CYCLONEDX {
+		evaluatedRules = append(evaluatedRules, RuleOmniBORID, RuleIdentifierMismatch, RuleWeakCrypto, RuleLifecycle, RuleLegacyTools)
+		findings = append(findings, messageFindings(LevelError, RuleOmniBORID, checkOmniBORIDs(obj))...)
+		findings = append(findings, checkLifecycles(obj)...)
+		findings = append(findings, checkLegacyTools(obj)...)
+		findings = append(findings, messageFindings(LevelWarning, RuleIdentifierMismatch, checkComponentIdentifiers(obj))...)
+		findings = append(findings, checkWeakCrypto(obj)...)
+	}
+
+	if sbomType == SBOM_CYCLONEDX || strings.HasPrefix(sbomType, SBOM_SPDX) {
+		evaluatedRules = append(evaluatedRules, RuleIdentifierSyntax, RuleIdentifierConsistency)
+		findings = append(findings, checkIdentifiers(obj, sbomType)...)
+	}
+
+	if len(options.internalNamespaces) > 0 {
+		evaluatedRules = append(evaluatedRules, RuleDependencyConfusion)
+		findings = append(findings, messageFindings(LevelWarning, RuleDependencyConfusion,
+			checkDependencyConfusion(obj, sbomType, options.internalNamespaces))...)
+	}
+
+	if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		evaluatedRules = append(evaluatedRules, RuleSPDXRelationship)
+		warnings, counts := checkSPDXRelationships(obj)
+		findings = append(findings, messageFindings(LevelWarning, RuleSPDXRelationship, warnings)...)
+		result.Relationships = counts
+	}
+
+	if options.maxSBOMAge != nil {
+		evaluatedRules = append(evaluatedRules, RuleSBOMAge)
+		findings = append(findings, messageFindings(LevelWarning, RuleSBOMAge,
+			checkSBOMAge(obj, sbomType, options.now(), *options.maxSBOMAge))...)
+	}
+
+	if options.componentNaming {
+		evaluatedRules = append(evaluatedRules, RuleComponentNaming)
+		findings = append(findings, messageFindings(LevelWarning, RuleComponentNaming,
+			checkComponentNaming(obj, sbomType))...)
+	}
+
+	if options.licenseValidation {
+		evaluatedRules = append(evaluatedRules, RuleLicenseExpression)
+		findings = append(findings, checkLicenseExpressions(obj, sbomType)...)
+	}
+
+	if len(options.secretPatterns) > 0 {
+		evaluatedRules = append(evaluatedRules, RuleSecret)
+		findings = append(findings, checkSecrets(obj, options.secretPatterns)...)
+	}
+
+	if options.hashCheck != nil {
+		hashFindings, err := checkHashes(doc, *options.hashCheck)
+		if err != nil {
+			return nil, nil, err
+		}
+		evaluatedRules = append(evaluatedRules, RuleHashFormat)
+		if options.hashCheck.Artifacts != nil {
+			evaluatedRules = append(evaluatedRules, RuleArtifactHash)
+		}
+		findings = append(findings, hashFindings...)
+	}
+
+	if options.referenceCheck != nil {
+		evaluatedRules = append(evaluatedRules, RuleExternalReference)
+		findings = append(findings, messageFindings(LevelWarning, RuleExternalReference,
+			checkExternalReferences(ctx, obj, sbomType, *options.referenceCheck))...)
+		// requests cut short by ctx would otherwise be reported as unreachable
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if options.nestedBOMs != nil {
+		nested, nestedFindings, err := validateNestedBOMs(ctx, obj, sbomType, options)
+		if err != nil {
+			return nil, nil, err
+		}
+		evaluatedRules = append(evaluatedRules, RuleNestedBOM)
+		findings = append(findings, nestedFindings...)
+		result.NestedBOMs = nested
+	}
+
+	graph, graphFindings := checkDependencyGraph(obj, sbomType)
+	result.graph = graph
+	if options.graphAnalysis {
+		evaluatedRules = append(evaluatedRules, RuleDependencyGraph)
+		findings = append(findings, graphFindings...)
+	}
+
+	if options.dependencyCoverage != nil {
+		evaluatedRules = append(evaluatedRules, RuleDependencyCoverage)
+		findings = append(findings, messageFindings(LevelError, RuleDependencyCoverage,
+			checkDependencyCoverage(obj, sbomType, *options.dependencyCoverage))...)
+	}
+	progress.report(StageRules, findings)
+
+	if len(options.profiles) > 0 {
+		profileFindings, profileRules, err := checkProfiles(obj, sbomType, options.profiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		evaluatedRules = append(evaluatedRules, profileRules...)
+		findings = append(findings, profileFindings...)
+		progress.report(StageProfiles, findings)
+	}
+
+	if len(options.binaryAnalyzers) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		analysisFindings, err := runBinaryAnalyzers(obj, sbomType, options.binaryAnalyzers)
+		if err != nil {
+			return nil, nil, err
+		}
+		evaluatedRules = append(evaluatedRules, RuleBinaryAnalysis)
+		findings = append(findings, analysisFindings...)
+		progress.report(StageBinaryAnalysis, findings)
+	}
+
+	if len(options.qualityChecks) > 0 {
+		qualityFindings, quality, err := checkQuality(obj, sbomType, options.qualityChecks)
+		if err != nil {
+			return nil, nil, err
+		}
+		evaluatedRules = append(evaluatedRules, options.qualityChecks...)
+		findings = append(findings, qualityFindings...)
+		result.Quality = quality
+		progress.report(StageQuality, findings)
+	}
+
+	if options.policy != nil {
+		policyFindings, policyRules := checkPolicy(obj, sbomType, options.policy)
+		evaluatedRules = append(evaluatedRules, policyRules...)
+		findings = append(findings, policyFindings...)
+		progress.report(StagePolicy, findings)
+	}
+
+	if options.licenseStats {
+		result.Licenses = computeLicenseStats(obj, sbomType)
+	}
+
+	return findings, evaluatedRules, nil
+}
@@ -0,0 +1,466 @@
+package sbomvalidator
+
+// Rule IDs identify the checks performed by this package. They are stable and
+// suitable for use in reports, suppressions and editor diagnostics.
+const (
+	RuleDocument              = "document"
+	RuleSchema                = "schema"
+	RuleSchemaFormat          = "schema-format"
+	RuleUnknownSpecVersion    = "unknown-spec-version"
+	RuleOmniBORID             = "omnibor-id"
+	RuleIdentifierMismatch    = "identifier-mismatch"
+	RuleIdentifierSyntax      = "identifier-syntax"
+	RuleIdentifierConsistency = "identifier-consistency"
+	RuleArtifactHash          = "artifact-hash"
+	RuleHashFormat            = "hash-format"
+	RuleProvenance            = "provenance"
+	RuleRegistryLicense       = "registry-license"
+	RuleDependencyConfusion   = "dependency-confusion"
+	RuleWeakCrypto            = "weak-crypto"
+	RuleLifecycle             = "lifecycle"
+	RuleLegacyTools           = "legacy-tools"
+	RuleDependencyCoverage    = "dependency-coverage"
+	RuleDependencyGraph       = "dependency-graph"
+	RuleComponentNaming       = "component-naming"
+	RuleSPDXRelationship      = "spdx-relationship"
+	RuleExternalReference     = "external-reference"
+	RuleNestedBOM             = "nested-bom"
+	RuleLicenseExpression     = "license-expression"
+	RuleSecret                = "embedded-secret"
+	RuleSBOMAge               = "sbom-age"
+	RuleSuppression           = "suppression"
+
+	RuleMLDataset              = "ml-dataset"
+	RuleMLLicense              = "ml-license"
+	RuleMLQuantitativeAnalysis = "ml-quantitative-analysis"
+
+	RuleFirmwareHash   = "firmware-component-hash"
+	RuleBinaryAnalysis = "binary-analysis"
+
+	RuleBuildPhase = "build-phase"
+
+	RuleComponentRoles = "component-roles"
+	RuleRoleConfusion  = "role-confusion"
+
+	RulePolicyLicense       = "policy-license"
+	RulePolicySpecVersion   = "policy-spec-version"
+	RulePolicyRequiredField = "policy-required-field"
+
+	RuleVEXProduct   = "vex-product"
+	RuleVEXStatus    = "vex-status"
+	RuleVEXTimestamp = "vex-timestamp"
+)
+
+// Severity ranks findings that do not by themselves make an SBOM invalid.
+type Severity string
+
+// Severity levels, from most to least severe.
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+)
+
+// Control frameworks used in rule mappings.
+const (
+	FrameworkNISTSSDF = "NIST SSDF"
+	FrameworkISO27001 = "ISO/IEC 27001:2022"
+	FrameworkCWE      = "CWE"
+	FrameworkNTIA     = "NTIA SBOM Minimum Elements"
+)
+
+// ControlMapping links a rule to a control or weakness in an external
+// framework, so findings can be tagged as evidence in GRC tooling.
+type ControlMapping struct {
+	Framework string `json:"framework"`
+	Control   string `json:"control"`
+}
+
+// Rule describes a single check in the rule catalog. Controls is optional and
+// may be empty for rules without a meaningful mapping.
+type Rule struct {
+	ID       string           `json:"id"`
+	Title    string           `json:"title"`
+	Controls []ControlMapping `json:"controls,omitempty"`
+}
+
+var ruleCatalog = []Rule{
+	{
+		ID:    RuleDocument,
+		Title: "Document is a well-formed SBOM of a supported type",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+		},
+	},
+	{
+		ID:    RuleSchema,
+		Title: "Document conforms to the official schema for its declared version",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+			{Framework: FrameworkISO27001, Control: "A.5.21"},
+		},
+	},
+	{
+		ID:    RuleSchemaFormat,
+		Title: "Every format the schema declares is checked",
+		Controls: []ControlMapping{
+			{Framework: FrameworkCWE, Control: "CWE-20"},
+		},
+	},
+	{
+		ID:    RuleUnknownSpecVersion,
+		Title: "Declared spec version has a known schema",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+		},
+	},
+	{
+		ID:    RuleOmniBORID,
+		Title: "OmniBOR identifiers are valid gitoids",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.2.1"},
+		},
+	},
+	{
+		ID:    RuleIdentifierMismatch,
+		Title: "purl, CPE and SWID identifiers of a component agree",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+			{Framework: FrameworkISO27001, Control: "A.5.9"},
+		},
+	},
+	{
+		ID:    RuleIdentifierSyntax,
+		Title: "purl, CPE and SWID identifiers conform to their specifications",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+			{Framework: FrameworkCWE, Control: "CWE-20"},
+		},
+	},
+	{
+		ID:    RuleIdentifierConsistency,
+		Title: "Identifier versions agree with the component version",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+			{Framework: FrameworkISO27001, Control: "A.5.9"},
+		},
+	},
+	{
+		ID:    RuleArtifactHash,
+		Title: "Declared hashes match the described artifacts",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.2.1"},
+			{Framework: FrameworkISO27001, Control: "A.5.21"},
+			{Framework: FrameworkCWE, Control: "CWE-353"},
+		},
+	},
+	{
+		ID:    RuleHashFormat,
+		Title: "Declared hashes use algorithms of the SBOM's format and digests of the right length",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.2.1"},
+			{Framework: FrameworkCWE, Control: "CWE-20"},
+		},
+	},
+	{
+		ID:    RuleProvenance,
+		Title: "SBOM is consistent with the build provenance",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.1"},
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+		},
+	},
+	{
+		ID:    RuleRegistryLicense,
+		Title: "Declared licenses match package registry metadata",
+		Controls: []ControlMapping{
+			{Framework: FrameworkISO27001, Control: "A.5.32"},
+		},
+	},
+	{
+		ID:    RuleSecret,
+		Title: "SBOM fields do not contain secrets such as access keys and tokens",
+		Controls: []ControlMapping{
+			{Framework: FrameworkISO27001, Control: "A.8.12"},
+			{Framework: FrameworkCWE, Control: "CWE-538"},
+		},
+	},
+	{
+		ID:    RuleDependencyConfusion,
+		Title: "Internal package names do not resolve against public registries",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PW.4.1"},
+			{Framework: FrameworkISO27001, Control: "A.5.19"},
+			{Framework: FrameworkCWE, Control: "CWE-427"},
+		},
+	},
+	{
+		ID:    RuleWeakCrypto,
+		Title: "Cryptographic assets do not use deprecated algorithms, key sizes or protocols",
+		Controls: []ControlMapping{
+			{Framework: FrameworkISO27001, Control: "A.8.24"},
+			{Framework: FrameworkCWE, Control: "CWE-326"},
+			{Framework: FrameworkCWE, Control: "CWE-327"},
+		},
+	},
+	{
+		ID:    RuleLifecycle,
+		Title: "Lifecycles use pre-defined phases or well-formed custom names",
+	},
+	{
+		ID:    RuleLegacyTools,
+		Title: "Tools are listed as components and services from CycloneDX 1.5",
+	},
+	{
+		ID:    RuleDependencyCoverage,
+		Title: "Dependency relationships cover enough of the SBOM's components",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+			{Framework: FrameworkNTIA, Control: "Dependency Relationship"},
+		},
+	},
+	{
+		ID:    RuleDependencyGraph,
+		Title: "The dependency graph has unique IDs, no dangling references, no cycles and no unreferenced components",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+			{Framework: FrameworkNTIA, Control: "Dependency Relationship"},
+		},
+	},
+	{
+		ID:    RuleComponentNaming,
+		Title: "Each package is named consistently across the SBOM's components",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Component Name"},
+		},
+	},
+	{
+		ID:    RuleSPDXRelationship,
+		Title: "SPDX relationships relate defined elements of the kinds their type requires",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Dependency Relationship"},
+		},
+	},
+	{
+		ID:    RuleExternalReference,
+		Title: "External reference URLs and purls are well-formed and, optionally, reachable",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Other Unique Identifiers"},
+		},
+	},
+	{
+		ID:    RuleNestedBOM,
+		Title: "BOMs referenced with externalReferences of type bom are valid",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+		},
+	},
+	{
+		ID:    RuleSBOMAge,
+		Title: "The SBOM was created recently, and not in the future",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+			{Framework: FrameworkNTIA, Control: "Timestamp"},
+		},
+	},
+	{
+		ID:    RuleSuppression,
+		Title: "Suppressions are justified and have not expired",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "RV.2.2"},
+		},
+	},
+	{
+		ID:    RuleLicenseExpression,
+		Title: "License expressions are well-formed and use current SPDX License List identifiers",
+		Controls: []ControlMapping{
+			{Framework: FrameworkISO27001, Control: "A.5.32"},
+		},
+	},
+	{
+		ID:    RuleMLDataset,
+		Title: "Machine learning models reference their training datasets (ML-BOM profile)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkISO27001, Control: "A.5.9"},
+		},
+	},
+	{
+		ID:    RuleMLLicense,
+		Title: "Machine learning models declare a license (ML-BOM profile)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkISO27001, Control: "A.5.32"},
+		},
+	},
+	{
+		ID:    RuleMLQuantitativeAnalysis,
+		Title: "Machine learning models report quantitative analysis (ML-BOM profile)",
+	},
+	{
+		ID:    RuleFirmwareHash,
+		Title: "Every firmware component declares a hash (firmware profile)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.2.1"},
+			{Framework: FrameworkCWE, Control: "CWE-353"},
+		},
+	},
+	{
+		ID:    RuleBinaryAnalysis,
+		Title: "SBOM agrees with external binary analysis of the image",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+		},
+	},
+	{
+		ID:    RuleBuildPhase,
+		Title: "The SBOM declares the build lifecycle phase (build-phase profile)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+		},
+	},
+	{
+		ID:    RuleComponentRoles,
+		Title: "Supplier and originator are well-formed and repackaged software records its origin (component-roles profile)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Supplier Name"},
+			{Framework: FrameworkISO27001, Control: "A.5.21"},
+		},
+	},
+	{
+		ID:    RuleRoleConfusion,
+		Title: "Supplier, originator and publisher are not likely confused (component-roles profile)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Supplier Name"},
+		},
+	},
+	{
+		ID:    RuleVEXProduct,
+		Title: "VEX statements refer to well-formed product identifiers that are declared (VEX)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "RV.1.1"},
+		},
+	},
+	{
+		ID:    RuleVEXStatus,
+		Title: "VEX statuses are legal and carry the justification or action their status requires (VEX)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "RV.2.2"},
+		},
+	},
+	{
+		ID:    RuleVEXTimestamp,
+		Title: "VEX timestamps are well-formed, ordered and not in the future (VEX)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkCWE, Control: "CWE-20"},
+		},
+	},
+	{
+		ID:    RulePolicyLicense,
+		Title: "Licenses are on the allowed list of the policy (policy)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkISO27001, Control: "A.5.32"},
+		},
+	},
+	{
+		ID:    RulePolicySpecVersion,
+		Title: "The SBOM uses at least the spec version the policy requires (policy)",
+	},
+	{
+		ID:    RulePolicyRequiredField,
+		Title: "The SBOM declares the fields the policy requires (policy)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+		},
+	},
+	{
+		ID:    RuleQualitySupplier,
+		Title: "Components declare their supplier (quality)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Supplier Name"},
+		},
+	},
+	{
+		ID:    RuleQualityName,
+		Title: "Components declare a name (quality)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Component Name"},
+		},
+	},
+	{
+		ID:    RuleQualityVersion,
+		Title: "Components declare a version (quality)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Version of the Component"},
+		},
+	},
+	{
+		ID:    RuleQualityIdentifier,
+		Title: "Components carry a unique identifier (purl, CPE, SWID or OmniBOR) (quality)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Other Unique Identifiers"},
+		},
+	},
+	{
+		ID:    RuleQualityDependencies,
+		Title: "Dependency relationships are declared for every component (quality)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Dependency Relationship"},
+		},
+	},
+	{
+		ID:    RuleQualityAuthor,
+		Title: "The SBOM declares its author (quality)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Author of SBOM Data"},
+		},
+	},
+	{
+		ID:    RuleQualityTimestamp,
+		Title: "The SBOM declares when it was created (quality)",
+		Controls: []ControlMapping{
+			{Framework: FrameworkNTIA, Control: "Timestamp"},
+		},
+	},
+}
+
+// RuleCatalog returns every rule known to this package, including its
+// control framework mappings. The returned slice is a copy and may be modified.
+func RuleCatalog() []Rule {
+	rules := make([]Rule, len(ruleCatalog))
+	copy(rules, ruleCatalog)
+	return rules
+}
+
+// LookupRule returns the catalog entry for a rule ID.
+func LookupRule(id string) (Rule, bool) {
+	for _, rule := range ruleCatalog {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// controlsForRules returns the de-duplicated control mappings of the given
+// rules, in catalog order.
+func controlsForRules(ids ...string) []ControlMapping {
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var controls []ControlMapping
+	seen := map[ControlMapping]bool{}
+	for _, rule := range ruleCatalog {
+		if !wanted[rule.ID] {
+			continue
+		}
+		for _, control := range rule.Controls {
+			if !seen[control] {
+				seen[control] = true
+				controls = append(controls, control)
+			}
+		}
+	}
+	return controls
+}
@@ -0,0 +1,69 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"testing"
+)
+
+func TestRuleCatalog(t *testing.T) {
+	seen := map[string]bool{}
+	for _, rule := range RuleCatalog() {
+		if rule.ID == "" || rule.Title == "" {
+			t.Errorf("rule %+v is missing an ID or title", rule)
+		}
+		if seen[rule.ID] {
+			t.Errorf("duplicate rule ID %q", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+
+	rule, ok := LookupRule(RuleArtifactHash)
+	if !ok {
+		t.Fatalf("LookupRule(%q) not found", RuleArtifactHash)
+	}
+	if len(rule.Controls) == 0 {
+		t.Errorf("expected %q to have control mappings", RuleArtifactHash)
+	}
+
+	if _, ok := LookupRule("no-such-rule"); ok {
+		t.Errorf("LookupRule() found an unknown rule")
+	}
+}
+
+func TestControlsForRules(t *testing.T) {
+	controls := controlsForRules(RuleDocument, RuleSchema)
+
+	want := []ControlMapping{
+		{Framework: FrameworkNISTSSDF, Control: "PS.3.2"},
+		{Framework: FrameworkISO27001, Control: "A.5.21"},
+	}
+	if len(controls) != len(want) {
+		t.Fatalf("controlsForRules() = %v, want %v", controls, want)
+	}
+	for i := range want {
+		if controls[i] != want[i] {
+			t.Errorf("control %d = %v, want %v", i, controls[i], want[i])
+		}
+	}
+}
+
+func TestValidateSBOMDataControlMappings(t *testing.T) {
+	sbom := []byte(`{"spdxVersion": "SPDX-2.3"}`)
+
+	result, err := ValidateSBOMData(sbom)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Controls) != 0 {
+		t.Errorf("expected no controls by default, got %v", result.Controls)
+	}
+
+	result, err = ValidateSBOMData(sbom, WithControlMappings(true))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Controls) == 0 {
+		t.Errorf("expected controls with WithControlMappings(true)")
+	}
+}
@@ -0,0 +1,138 @@
+package sbomvalidator
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+)
+
+// sbomDocument is the JSON form of an SBOM, parsed once and shared by every
+// check run on it.
+type sbomDocument struct {
+	obj      map[string]interface{}
+	sbomType string
+}
+
+// parseSBOMDocument parses SBOM JSON and detects its type.
+func parseSBOMDocument(content []byte) (*sbomDocument, error) {
+	obj, err := parseJSON(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	sbomType, err := detectSBOMType(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error detecting SBOM Type %v", err)
+	}
+
+	return &sbomDocument{obj: obj, sbomType: sbomType}, nil
+}
+
+// RunOptions selects what Run produces besides the validation result. Each
+// artifact is left out of the RunResult unless requested.
+type RunOptions struct {
+	// Options are the validation options, as for ValidateSBOMData. Quality
+	// checks (`WithQualityChecks`) and compliance profiles (`WithProfiles`)
+	// are evaluated in the same rule pass as the schema.
+	Options []Option
+	// Artifacts, when set, are checked against the hashes declared in the
+	// SBOM, as by VerifyArtifactHashes.
+	Artifacts fs.FS
+	// Provenance, when set, is SLSA provenance to cross-check the SBOM
+	// against, as by VerifyProvenance.
+	Provenance []byte
+	// LicenseLookup, when set, cross-checks declared licenses for up to
+	// LicenseSample components, as by CrossCheckLicenses.
+	LicenseLookup LicenseLookup
+	LicenseSample int
+	// CryptoReport reports the cryptographic assets of a CycloneDX SBOM, as
+	// by CheckCryptoAssets. It is ignored for other formats.
+	CryptoReport bool
+	// NameClusters reports inconsistently named components, as by
+	// ComponentNameClusters.
+	NameClusters bool
+}
+
+// RunResult holds the validation result and the artifacts requested from Run.
+type RunResult struct {
+	Validation   *StructuredResult        `json:"validation"`
+	Hashes       *HashVerificationResult  `json:"hashes,omitempty"`
+	Provenance   *ProvenanceResult        `json:"provenance,omitempty"`
+	Licenses     *LicenseCrossCheckResult `json:"licenses,omitempty"`
+	Crypto       *CryptoReport            `json:"crypto,omitempty"`
+	NameClusters []NameCluster            `json:"nameClusters,omitempty"`
+}
+
+// Run validates an SBOM and produces every requested artifact from a single
+// parse of the document and a single rule pass, instead of one full pass per
+// function (ValidateSBOMDataStructured, VerifyArtifactHashes,
+// VerifyProvenance, CrossCheckLicenses, ...).
+//
+// Because the artifacts are computed from the validated JSON form, they are
+// also available for compressed, CycloneDX XML and SPDX tag-value SBOMs. They
+// are computed even when the SBOM is invalid, but not when validation fails
+// with an error.
+//
+// Parameters:
+//   - ctx: Bounds validation as for ValidateSBOMDataContext, and is checked
+//     between stages and between license lookups; when it is done, Run stops
+//     and returns its error.
+//   - sbomContent: A byte slice containing the SBOM data.
+//   - opts: The validation options and the artifacts to produce.
+//
+// Returns:
+//   - A RunResult whose `Validation` is never nil, even alongside an error.
+//   - An error if validation or a requested artifact fails.
+//
+// Example:
+//
+//	result, err := Run(ctx, sbomBytes, RunOptions{
+//	    Options:   []Option{WithQualityChecks(QualityChecks()...)},
+//	    Artifacts: os.DirFS("dist"),
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(result.Validation.IsValid, result.Hashes.IsValid)
+func Run(ctx context.Context, sbomContent []byte, opts RunOptions) (*RunResult, error) {
+	result := &RunResult{}
+	validation, doc, err := runValidation(ctx, sbomContent, newValidationOptions(opts.Options))
+	result.Validation = validation
+	if err != nil {
+		return result, err
+	}
+
+	if opts.Artifacts != nil {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if result.Hashes, err = verifyArtifactHashes(doc, opts.Artifacts); err != nil {
+			return result, fmt.Errorf("hash verification failed: %v", err)
+		}
+	}
+
+	if opts.Provenance != nil {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if result.Provenance, err = verifyProvenance(doc, opts.Provenance); err != nil {
+			return result, fmt.Errorf("provenance verification failed: %v", err)
+		}
+	}
+
+	if opts.LicenseLookup != nil {
+		if result.Licenses, err = crossCheckLicenses(ctx, doc, opts.LicenseLookup, opts.LicenseSample); err != nil {
+			return result, fmt.Errorf("license cross-check failed: %w", err)
+		}
+	}
+
+	if opts.CryptoReport && doc.sbomType == SBOM_CYCLONEDX {
+		result.Crypto = cryptoReport(doc.obj)
+	}
+
+	if opts.NameClusters {
+		result.NameClusters = findNameClusters(doc.obj, doc.sbomType)
+	}
+
+	return result, nil
+}
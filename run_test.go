@@ -0,0 +1,92 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRun(t *testing.T) {
+	const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	sbom := strings.Replace(string(spdxDocument(spdxPackage("a", "left-pad", "MIT"), spdxPackage("b", "Left_Pad", "ISC"))),
+		`"packages"`, `"files": [{"SPDXID": "SPDXRef-app", "fileName": "./bin/app",
+    "checksums": [{"algorithm": "SHA256", "checksumValue": "`+helloSHA256+`"}]}],
+  "packages"`, 1)
+
+	// artifacts are computed from the decompressed document
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(sbom))
+	zw.Close()
+
+	result, err := Run(context.Background(), compressed.Bytes(), RunOptions{
+		Options:       []Option{WithQualityChecks(RuleQualityName)},
+		Artifacts:     fstest.MapFS{"bin/app": &fstest.MapFile{Data: []byte("hello")}},
+		LicenseLookup: fakeLicenseLookup{"pkg:npm/left-pad@1.0.0": {"MIT"}, "pkg:npm/Left_Pad@1.0.0": {"MIT"}},
+		CryptoReport:  true,
+		NameClusters:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Validation.Compression != EncodingGzip || len(result.Validation.Quality) != 1 {
+		t.Errorf("unexpected validation result: %+v", result.Validation.ValidationResult)
+	}
+	if result.Hashes == nil || !result.Hashes.IsValid || len(result.Hashes.Checks) != 1 {
+		t.Errorf("expected one matching hash check, got %+v", result.Hashes)
+	}
+	if result.Licenses == nil || result.Licenses.Checked != 2 || len(result.Licenses.Discrepancies) != 1 {
+		t.Errorf("expected one license discrepancy out of two checks, got %+v", result.Licenses)
+	}
+	if result.Crypto != nil {
+		t.Errorf("expected no crypto report for SPDX, got %+v", result.Crypto)
+	}
+	if len(result.NameClusters) != 1 {
+		t.Errorf("expected one name cluster, got %+v", result.NameClusters)
+	}
+	if result.Provenance != nil {
+		t.Errorf("expected no provenance result when none was requested, got %+v", result.Provenance)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+
+	t.Run("Canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := Run(ctx, sbom, RunOptions{})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if result == nil || result.Validation == nil {
+			t.Errorf("expected a result with a validation result alongside the error")
+		}
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		result, err := Run(context.Background(), []byte("not an SBOM"), RunOptions{NameClusters: true})
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+		if result.Validation == nil || result.NameClusters != nil {
+			t.Errorf("expected only the validation result, got %+v", result)
+		}
+	})
+
+	t.Run("Invalid provenance", func(t *testing.T) {
+		_, err := Run(context.Background(), sbom, RunOptions{Provenance: []byte("{")})
+		if err == nil || !strings.Contains(err.Error(), "provenance verification failed") {
+			t.Errorf("expected a provenance error, got %v", err)
+		}
+	})
+}
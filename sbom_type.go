@@ -0,0 +1,266 @@
+package sbomvalidator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Format is an SBOM format, such as CycloneDX or SPDX.
+type Format string
+
+// The SBOM formats this package validates.
+const (
+	FormatCycloneDX Format = SBOM_CYCLONEDX
+	FormatSPDX      Format = SBOM_SPDX
+)
+
+// SpecVersion is the specification version an SBOM declares, without the
+// format prefix (e.g., "1.6" for CycloneDX, "2.3" for SPDX).
+type SpecVersion string
+
+// specVersionPattern matches dotted numeric versions.
+var specVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// knownSpecVersions are the published spec versions of each format, oldest
+// first, whether or not a schema is embedded for them.
+var knownSpecVersions = map[Format][]SpecVersion{
+	FormatCycloneDX: {"1.0", "1.1", "1.2", "1.3", "1.4", "1.5", "1.6", "1.7"},
+	FormatSPDX:      {"2.0", "2.1", "2.2", "2.3", "3.0"},
+}
+
+// ParseSpecVersion parses a spec version as found in a "specVersion" or
+// "spdxVersion" field: "1.6", or "SPDX-2.3" with the SPDX prefix removed.
+//
+// Parameters:
+//   - version: The version.
+//
+// Returns:
+//   - The version.
+//   - An error if it is not a dotted numeric version.
+//
+// Example:
+//
+//	v, err := ParseSpecVersion("SPDX-2.3")
+//	fmt.Println(v, err) // 2.3 <nil>
+func ParseSpecVersion(version string) (SpecVersion, error) {
+	v := strings.TrimPrefix(version, SBOM_SPDX+"-")
+	if !specVersionPattern.MatchString(v) {
+		return "", fmt.Errorf("invalid spec version %q", version)
+	}
+	return SpecVersion(v), nil
+}
+
+// Compare compares two versions numerically, component by component, so
+// "1.10" is after "1.9".
+//
+// Returns -1 if v < other, 0 if v == other, and 1 if v > other.
+func (v SpecVersion) Compare(other SpecVersion) int {
+	return compareVersions(string(v), string(other))
+}
+
+// AtLeast reports whether v is min or later, e.g. for checks that only apply
+// from the spec version that introduced a field.
+//
+// Example:
+//
+//	if SpecVersion(specVersion).AtLeast("1.5") {
+//	    // lifecycles are defined
+//	}
+func (v SpecVersion) AtLeast(min SpecVersion) bool {
+	return v.Compare(min) >= 0
+}
+
+// KnownSpecVersions returns the published spec versions of a format, oldest
+// first, including those without an embedded schema (see
+// `SupportedVersions`).
+//
+// Example:
+//
+//	fmt.Println(KnownSpecVersions(FormatSPDX)) // [2.0 2.1 2.2 2.3 3.0]
+func KnownSpecVersions(format Format) []SpecVersion {
+	return append([]SpecVersion(nil), knownSpecVersions[format]...)
+}
+
+// SBOMType is the format and specification version an SBOM declares.
+type SBOMType struct {
+	Format  Format
+	Version SpecVersion
+}
+
+// String returns the type as "<format> <version>", e.g. "SPDX 2.3".
+func (t SBOMType) String() string {
+	return fmt.Sprintf("%s %s", t.Format, t.Version)
+}
+
+// declared returns the type and version as the SBOM spells them, which is how
+// the rest of the pipeline keys formats and schemas: "CycloneDX" and "1.6",
+// or "SPDX-2.3" for both. Without a version the type is the bare format.
+func (t SBOMType) declared() (sbomType, version string) {
+	if t.Format == FormatSPDX && t.Version != "" {
+		spdxVersion := SBOM_SPDX + "-" + string(t.Version)
+		return spdxVersion, spdxVersion
+	}
+	return string(t.Format), string(t.Version)
+}
+
+// Detect reads the format and version an SBOM declares.
+//
+// Only the top-level "bomFormat", "specVersion" and "spdxVersion" fields are
+// read, and reading stops as soon as both are known, so only the start of
+// well-ordered documents is consumed. CycloneDX protobuf is recognized from
+// its first bytes and then decoded in full to find its version.
+//
+// Parameters:
+//   - r: A reader supplying the SBOM JSON, or CycloneDX protobuf, data.
+//
+// Returns:
+//   - The detected format and version.
+//   - An error if the JSON is malformed before the fields are found, no type
+//     field exists, or the version is missing or malformed. When only the
+//     version is at fault, the returned type still carries the format.
+//
+// Example:
+//
+//	f, _ := os.Open("large.cdx.json")
+//	defer f.Close()
+//	sbomType, err := Detect(f)
+//	if err == nil && sbomType.Format == FormatCycloneDX && sbomType.Version.AtLeast("1.5") {
+//	    fmt.Println("CycloneDX 1.5 or later")
+//	}
+func Detect(r io.Reader) (SBOMType, error) {
+	declaredType, declaredVersion, err := scanSBOMType(r, true)
+	if err != nil {
+		return SBOMType{}, err
+	}
+
+	t := SBOMType{Format: Format(sbomFormat(declaredType))}
+	switch t.Format {
+	case FormatCycloneDX:
+		if declaredVersion == "" {
+			return t, fmt.Errorf(`"specVersion" field missing or not a string`)
+		}
+		t.Version = SpecVersion(declaredVersion)
+	case FormatSPDX:
+		version, err := getSPDXVersion(declaredVersion)
+		if err != nil {
+			return t, err
+		}
+		t.Version = SpecVersion(version)
+	default:
+		return t, fmt.Errorf("unknown SBOM Format: %s", declaredType)
+	}
+	return t, nil
+}
+
+// detectSBOM checks that jsonData is well-formed JSON and detects its type.
+// Like Detect, it returns the format alongside an error about the version.
+func detectSBOM(jsonData []byte) (SBOMType, error) {
+	// json.Valid scans without allocating, unlike unmarshalling into a map
+	if !json.Valid(jsonData) {
+		return SBOMType{}, fmt.Errorf("failed to parse JSON: invalid JSON format")
+	}
+	return Detect(bytes.NewReader(jsonData))
+}
+
+// LoadSchema returns the embedded JSON schema for an SBOM type.
+//
+// Parameters:
+//   - t: The SBOM type, e.g. as returned by Detect.
+//
+// Returns:
+//   - The schema content.
+//   - An error if no schema is embedded for the type; it wraps fs.ErrNotExist
+//     for an unsupported version.
+//
+// Example:
+//
+//	schema, err := LoadSchema(SBOMType{Format: FormatSPDX, Version: "2.3"})
+func LoadSchema(t SBOMType) ([]byte, error) {
+	sbomType, version := t.declared()
+	schema, err := loadSBOMSchema(version, sbomType)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(schema), nil
+}
+
+// scanSBOMType streams the top-level fields of an SBOM until it has found
+// the declared type ("bomFormat" or "spdxVersion", whichever comes first)
+// and, when withVersion is set, the declared version. It returns the raw
+// field values.
+func scanSBOMType(r io.Reader, withVersion bool) (declaredType, declaredVersion string, err error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(protoSniffLength); isCycloneDXProtobuf(head) {
+		if !withVersion {
+			return SBOM_CYCLONEDX, "", nil
+		}
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return "", "", err
+		}
+		jsonContent, _, _, err := convertEncoding(encodingProtobuf, SBOM_CYCLONEDX, data)
+		if err != nil {
+			return "", "", err
+		}
+		return scanSBOMType(bytes.NewReader(jsonContent), withVersion)
+	}
+	dec := json.NewDecoder(br)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return "", "", fmt.Errorf("failed to parse JSON: expected a JSON object")
+	}
+
+	var specVersion string
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		key, _ := keyTok.(string)
+
+		valueTok, err := dec.Token()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to parse JSON: %w", err)
+		}
+
+		if value, ok := valueTok.(string); ok {
+			switch {
+			case declaredType == "" && (key == "bomFormat" || key == "spdxVersion"):
+				declaredType = value
+				if key == "spdxVersion" {
+					// SPDX embeds the version in the type
+					declaredVersion = value
+				}
+			case key == "specVersion":
+				specVersion = value
+			}
+		}
+
+		if delim, ok := valueTok.(json.Delim); ok {
+			if err := skipJSONValue(dec, delim); err != nil {
+				return "", "", fmt.Errorf("failed to parse JSON: %w", err)
+			}
+		}
+
+		if declaredType == SBOM_CYCLONEDX {
+			declaredVersion = specVersion
+		}
+		if declaredType != "" && (!withVersion || declaredVersion != "" || declaredType != SBOM_CYCLONEDX) {
+			return declaredType, declaredVersion, nil
+		}
+	}
+
+	if declaredType == "" {
+		return "", "", fmt.Errorf("unknown SBOM type or missing required fields")
+	}
+	return declaredType, declaredVersion, nil
+}
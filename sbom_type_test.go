@@ -0,0 +1,185 @@
+//go:build !sbomvalidator_no_cyclonedx && !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		reader    io.Reader
+		want      SBOMType
+		expectErr bool
+	}{
+		{
+			name:   "CycloneDX",
+			reader: strings.NewReader(`{"bomFormat": "CycloneDX", "specVersion": "1.4"}`),
+			want:   SBOMType{Format: FormatCycloneDX, Version: "1.4"},
+		},
+		{
+			name:   "specVersion before bomFormat",
+			reader: strings.NewReader(`{"specVersion": "1.6", "components": [], "bomFormat": "CycloneDX"}`),
+			want:   SBOMType{Format: FormatCycloneDX, Version: "1.6"},
+		},
+		{
+			name:   "SPDX",
+			reader: strings.NewReader(`{"spdxVersion": "SPDX-2.3"}`),
+			want:   SBOMType{Format: FormatSPDX, Version: "2.3"},
+		},
+		{
+			name:   "Protobuf",
+			reader: bytes.NewReader(protoBOM()),
+			want:   SBOMType{Format: FormatCycloneDX, Version: "1.6"},
+		},
+		{
+			name: "Stops reading once type and version are found",
+			reader: io.MultiReader(
+				strings.NewReader(`{"bomFormat": "CycloneDX", "specVersion": "1.5", "components": [`),
+				iotest.ErrReader(errors.New("read past detected version")),
+			),
+			want: SBOMType{Format: FormatCycloneDX, Version: "1.5"},
+		},
+		{
+			name:      "Missing specVersion field",
+			reader:    strings.NewReader(`{"bomFormat": "CycloneDX"}`),
+			want:      SBOMType{Format: FormatCycloneDX},
+			expectErr: true,
+		},
+		{
+			name:      "Invalid specVersion type",
+			reader:    strings.NewReader(`{"bomFormat": "CycloneDX", "specVersion": 1.4}`),
+			want:      SBOMType{Format: FormatCycloneDX},
+			expectErr: true,
+		},
+		{
+			name:      "Malformed spdxVersion",
+			reader:    strings.NewReader(`{"spdxVersion": "SPDX2.3"}`),
+			want:      SBOMType{Format: FormatSPDX},
+			expectErr: true,
+		},
+		{
+			name:      "Unknown format",
+			reader:    strings.NewReader(`{"bomFormat": "SWID"}`),
+			want:      SBOMType{Format: "SWID"},
+			expectErr: true,
+		},
+		{
+			name:      "Invalid JSON structure",
+			reader:    strings.NewReader(`{"specVersion": "1.4"`),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Detect(tt.reader)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Detect() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if got != tt.want {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSBOMTypeDeclared(t *testing.T) {
+	tests := []struct {
+		sbomType    SBOMType
+		wantType    string
+		wantVersion string
+	}{
+		{sbomType: SBOMType{Format: FormatCycloneDX, Version: "1.6"}, wantType: "CycloneDX", wantVersion: "1.6"},
+		{sbomType: SBOMType{Format: FormatSPDX, Version: "2.3"}, wantType: "SPDX-2.3", wantVersion: "SPDX-2.3"},
+		{sbomType: SBOMType{Format: FormatSPDX}, wantType: "SPDX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sbomType.String(), func(t *testing.T) {
+			sbomType, version := tt.sbomType.declared()
+			if sbomType != tt.wantType || version != tt.wantVersion {
+				t.Errorf("declared() = %q, %q, want %q, %q", sbomType, version, tt.wantType, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestLoadSchemaTyped(t *testing.T) {
+	schema, err := LoadSchema(SBOMType{Format: FormatSPDX, Version: "2.3"})
+	if err != nil || !bytes.Contains(schema, []byte(`"$schema"`)) {
+		t.Errorf("LoadSchema() = %d bytes, %v", len(schema), err)
+	}
+	if _, err := LoadSchema(SBOMType{Format: FormatCycloneDX, Version: "9.9"}); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
+
+func TestSpecVersion(t *testing.T) {
+	tests := []struct {
+		a, b        SpecVersion
+		wantCompare int
+	}{
+		{a: "1.10", b: "1.9", wantCompare: 1},
+		{a: "2.3", b: "2.3", wantCompare: 0},
+		{a: "1.4", b: "1.5", wantCompare: -1},
+		{a: "1.5", b: "1.5.0", wantCompare: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.a)+" "+string(tt.b), func(t *testing.T) {
+			if got := tt.a.Compare(tt.b); got != tt.wantCompare {
+				t.Errorf("Compare() = %d, want %d", got, tt.wantCompare)
+			}
+			if got := tt.a.AtLeast(tt.b); got != (tt.wantCompare >= 0) {
+				t.Errorf("AtLeast() = %v", got)
+			}
+		})
+	}
+}
+
+func TestParseSpecVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		want      SpecVersion
+		expectErr bool
+	}{
+		{version: "1.6", want: "1.6"},
+		{version: "SPDX-2.3", want: "2.3"},
+		{version: "1.x", expectErr: true},
+		{version: "", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseSpecVersion(tt.version)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseSpecVersion() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSpecVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnownSpecVersions(t *testing.T) {
+	// every embedded schema is for a published version
+	for _, format := range []Format{FormatCycloneDX, FormatSPDX} {
+		for _, v := range SupportedVersions(format) {
+			if !slices.Contains(KnownSpecVersions(format), v) {
+				t.Errorf("%s %s is not a known version", format, v)
+			}
+		}
+	}
+	if KnownSpecVersions("SWID") != nil {
+		t.Error("expected no versions for an unknown format")
+	}
+}
@@ -0,0 +1,155 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SchemaRevision is a schema file embedded in the build.
+//
+// The current schema of each spec version lives at the top of its format's
+// schema directory (e.g., "schemas/cyclonedx/bom-1.6.schema.json"). When an
+// upstream schema is revised, the previous file is kept under the `archive`
+// subdirectory with the same file name (e.g.,
+// "schemas/cyclonedx/archive/2024-04-09/bom-1.6.schema.json"), so results
+// recorded against it can be reproduced with `WithPinnedSchemas`.
+type SchemaRevision struct {
+	Format  string `json:"format"`
+	Path    string `json:"path"`
+	Digest  string `json:"digest"`
+	Current bool   `json:"current"`
+}
+
+// SchemaRevisions returns every schema revision embedded in the build, current
+// and archived, sorted by path. The embedded schemas do not change while the
+// process runs, so they are only read and digested on the first call.
+//
+// Returns:
+//   - The embedded revisions with their digests.
+//   - An error if the embedded schemas cannot be read.
+//
+// Example:
+//
+//	revisions, _ := SchemaRevisions()
+//	for _, r := range revisions {
+//	    fmt.Println(r.Digest, r.Path)
+//	}
+func SchemaRevisions() ([]SchemaRevision, error) {
+	revisions, err := embeddedRevisions.load()
+	return slices.Clone(revisions), err
+}
+
+// embeddedRevisions holds the schema revisions embedded in the build once they
+// have been listed.
+var embeddedRevisions schemaRevisionList
+
+// schemaRevisionList lists the embedded schema revisions on first use. It is
+// safe for concurrent use.
+type schemaRevisionList struct {
+	mu        sync.Mutex
+	loaded    bool
+	revisions []SchemaRevision
+	err       error
+}
+
+func (l *schemaRevisionList) load() ([]SchemaRevision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.loaded {
+		l.revisions, l.err = listSchemaRevisions()
+		l.loaded = true
+	}
+	return l.revisions, l.err
+}
+
+// Reset forgets the revisions listed, so the next call lists them again.
+func (l *schemaRevisionList) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loaded, l.revisions, l.err = false, nil, nil
+}
+
+// listSchemaRevisions reads and digests the schema revisions embedded in the
+// build.
+func listSchemaRevisions() ([]SchemaRevision, error) {
+	var revisions []SchemaRevision
+
+	for format, schemas := range formatSchemas {
+		err := fs.WalkDir(schemas, "schemas", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return skipSchemaRefs(d)
+			}
+			if !strings.HasSuffix(p, ".schema.json") {
+				return nil
+			}
+
+			data, err := fs.ReadFile(schemas, p)
+			if err != nil {
+				return fmt.Errorf("failed to read embedded schema file: %w", err)
+			}
+
+			revisions = append(revisions, SchemaRevision{
+				Format:  format,
+				Path:    p,
+				Digest:  schemaDigest(string(data)),
+				Current: !strings.Contains(p, "/archive/"),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Path < revisions[j].Path })
+	return revisions, nil
+}
+
+// pinnedSchemaRevision returns the revision of the schema at schemaPath that
+// one of the pinned digests selects, or nil if none of them is a revision of
+// that schema. A pinned digest that matches no embedded revision at all is an
+// error, since the verdict it was recorded with can no longer be reproduced.
+func pinnedSchemaRevision(schemaPath string, pins []string) (*SchemaRevision, error) {
+	revisions, err := embeddedRevisions.load()
+	if err != nil {
+		return nil, err
+	}
+
+	byDigest := make(map[string]SchemaRevision, len(revisions))
+	for _, revision := range revisions {
+		// a revision is the same schema if it has the same file name
+		if _, exists := byDigest[revision.Digest]; !exists || path.Base(revision.Path) == path.Base(schemaPath) {
+			byDigest[revision.Digest] = revision
+		}
+	}
+
+	var selected *SchemaRevision
+	for _, pin := range pins {
+		pin = strings.TrimSpace(pin)
+		if pin == "" {
+			continue
+		}
+
+		revision, ok := byDigest[pin]
+		if !ok {
+			return nil, fmt.Errorf("pinned schema %s is not embedded in this build", pin)
+		}
+		if path.Base(revision.Path) != path.Base(schemaPath) {
+			continue
+		}
+		if selected != nil && selected.Digest != revision.Digest {
+			return nil, fmt.Errorf("schemas %s and %s are both pinned for %s", selected.Digest, revision.Digest, path.Base(schemaPath))
+		}
+		selected = &revision
+	}
+
+	return selected, nil
+}
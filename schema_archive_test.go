@@ -0,0 +1,148 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const archivedSPDXSchemaPath = "schemas/spdx/archive/2022-01-01/spdx-2.3.schema.json"
+
+// withArchivedSPDXSchema registers an archived SPDX 2.3 schema revision that,
+// unlike the current one, does not require documentNamespace, and returns its
+// digest.
+func withArchivedSPDXSchema(t *testing.T) string {
+	t.Helper()
+
+	current, err := fs.ReadFile(formatSchemas[SBOM_SPDX], "schemas/spdx/spdx-2.3.schema.json")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(current, &schema); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	schema["required"] = []string{"SPDXID", "creationInfo", "dataLicense", "name", "spdxVersion"}
+	archived, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+
+	files := fstest.MapFS{archivedSPDXSchemaPath: {Data: archived}}
+	err = fs.WalkDir(formatSchemas[SBOM_SPDX], "schemas", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(formatSchemas[SBOM_SPDX], p)
+		files[p] = &fstest.MapFile{Data: data}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to copy schemas: %v", err)
+	}
+
+	original := formatSchemas[SBOM_SPDX]
+	formatSchemas[SBOM_SPDX] = files
+	embeddedRevisions.Reset()
+	t.Cleanup(func() {
+		formatSchemas[SBOM_SPDX] = original
+		embeddedRevisions.Reset()
+	})
+
+	return schemaDigest(string(archived))
+}
+
+func TestSchemaRevisions(t *testing.T) {
+	archivedDigest := withArchivedSPDXSchema(t)
+
+	revisions, err := SchemaRevisions()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var found bool
+	for _, revision := range revisions {
+		if !strings.HasPrefix(revision.Digest, "sha256:") {
+			t.Errorf("Unexpected digest %q for %s", revision.Digest, revision.Path)
+		}
+		if revision.Path == archivedSPDXSchemaPath {
+			found = true
+			if revision.Current || revision.Digest != archivedDigest || revision.Format != SBOM_SPDX {
+				t.Errorf("Unexpected archived revision: %+v", revision)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Archived revision not listed in %v", revisions)
+	}
+}
+
+func TestWithPinnedSchemas(t *testing.T) {
+	archivedDigest := withArchivedSPDXSchema(t)
+
+	current, err := loadSBOMSchema("SPDX-2.3", "SPDX-2.3")
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	currentDigest := schemaDigest(current)
+
+	// documentNamespace is only required by the current schema
+	var doc map[string]interface{}
+	json.Unmarshal(spdxDocument(spdxPackage("a", "a", "MIT")), &doc)
+	delete(doc, "documentNamespace")
+	sbom, _ := json.Marshal(doc)
+
+	tests := []struct {
+		name       string
+		pins       []string
+		wantValid  bool
+		wantSchema string
+		wantDigest string
+		wantErr    bool
+	}{
+		{name: "Current schema by default", wantSchema: "schemas/spdx/spdx-2.3.schema.json", wantDigest: currentDigest},
+		{name: "Pinned archived revision", pins: []string{archivedDigest}, wantValid: true,
+			wantSchema: archivedSPDXSchemaPath, wantDigest: archivedDigest},
+		{name: "Pinned current revision", pins: []string{currentDigest},
+			wantSchema: "schemas/spdx/spdx-2.3.schema.json", wantDigest: currentDigest},
+		{name: "Pin for another version is ignored", pins: []string{mustSchemaDigest(t, "SPDX-2.2")},
+			wantSchema: "schemas/spdx/spdx-2.3.schema.json", wantDigest: currentDigest},
+		{name: "Unknown pin", pins: []string{"sha256:0000"}, wantErr: true},
+		{name: "Conflicting pins", pins: []string{archivedDigest, currentDigest}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMData(sbom, WithPinnedSchemas(tt.pins...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error state: got %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v (errors: %v)", result.IsValid, tt.wantValid, result.ValidationErrors)
+			}
+			if result.SchemaUsed != tt.wantSchema {
+				t.Errorf("SchemaUsed = %q, want %q", result.SchemaUsed, tt.wantSchema)
+			}
+			if result.SchemaDigest != tt.wantDigest {
+				t.Errorf("SchemaDigest = %q, want %q", result.SchemaDigest, tt.wantDigest)
+			}
+		})
+	}
+}
+
+func mustSchemaDigest(t *testing.T, version string) string {
+	t.Helper()
+	schema, err := loadSBOMSchema(version, version)
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	return schemaDigest(schema)
+}
@@ -0,0 +1,209 @@
+package sbomvalidator
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io/fs"
+	"sync"
+)
+
+// maxCompiledSchemas bounds the number of compiled schemas cached per
+// process. The embedded schemas fit many times over; the bound only evicts
+// when schema providers supply many distinct schemas, e.g. an HTTP provider
+// serving a revision per request.
+const maxCompiledSchemas = 64
+
+// compiledSchemas caches compiled schemas keyed by their digest so each
+// schema is only compiled once per process, whichever provider supplied it.
+//
+// The cache lives in memory only. Compiled schemas are graphs of the schema
+// library's internal types, which it cannot serialize, so they cannot be
+// persisted across processes or generated at build time; a new process
+// compiles each schema again on first use, or up front with
+// PrecompileSchemas or New. That takes 2 to 28 ms per embedded schema (see
+// "Cold start" in the README), so a serialized form is not worth its
+// maintenance.
+var compiledSchemas = newSchemaCache(maxCompiledSchemas)
+
+// schemaCache is a cache of compiled schemas that evicts the least recently
+// used schema beyond its size. It is safe for concurrent use.
+type schemaCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *schemaCacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type schemaCacheEntry struct {
+	key    string
+	schema *compiledSchema
+}
+
+func newSchemaCache(size int) *schemaCache {
+	return &schemaCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// Load returns the schema cached under key, if any.
+func (c *schemaCache) Load(key string) (*compiledSchema, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*schemaCacheEntry).schema, true
+}
+
+// LoadOrStore returns the schema cached under key if there is one, and
+// otherwise caches and returns schema.
+func (c *schemaCache) LoadOrStore(key string, schema *compiledSchema) *compiledSchema {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return element.Value.(*schemaCacheEntry).schema
+	}
+	c.entries[key] = c.order.PushFront(&schemaCacheEntry{key: key, schema: schema})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*schemaCacheEntry).key)
+	}
+	return schema
+}
+
+// Reset empties the cache.
+func (c *schemaCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+}
+
+// PrecompileSchemas compiles every embedded schema of the formats compiled into
+// the build and caches the result.
+//
+// Compiling a schema (including resolving its `$ref`s) is the most expensive
+// part of a cold validation. Calling this once during process start-up, for
+// example in the init phase of a serverless function, moves that cost out of
+// the first request. Calling it is optional; schemas are otherwise compiled
+// lazily on first use.
+//
+// Returns:
+//   - An error if any embedded schema fails to compile.
+func PrecompileSchemas() error {
+	for _, schemas := range formatSchemas {
+		err := fs.WalkDir(schemas, "schemas", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return skipSchemaRefs(d)
+			}
+
+			data, err := fs.ReadFile(schemas, path)
+			if err != nil {
+				return fmt.Errorf("failed to read embedded schema file: %w", err)
+			}
+
+			if _, err := compileSchema(schemaDigest(string(data)), string(data)); err != nil {
+				return fmt.Errorf("failed to compile %s: %w", path, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadedSchema is an embedded schema read and compiled by `New`, so the
+// validations of a Validator neither read nor digest it again.
+type preloadedSchema struct {
+	digest   string
+	compiled *compiledSchema
+}
+
+// preloadSchemas compiles the embedded schemas, current and archived, of the
+// formats accepted by formats (every format compiled into the build if it is
+// empty), keyed by their path (e.g., "schemas/spdx/spdx-2.3.schema.json").
+func preloadSchemas(formats []string) (map[string]preloadedSchema, error) {
+	preloaded := map[string]preloadedSchema{}
+	for format, schemas := range formatSchemas {
+		if checkFormatEnabled(format, formats) != nil {
+			continue
+		}
+		err := fs.WalkDir(schemas, "schemas", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return skipSchemaRefs(d)
+			}
+
+			data, err := fs.ReadFile(schemas, path)
+			if err != nil {
+				return fmt.Errorf("failed to read embedded schema file: %w", err)
+			}
+
+			digest := schemaDigest(string(data))
+			compiled, err := compileSchema(digest, string(data))
+			if err != nil {
+				return fmt.Errorf("failed to compile %s: %w", path, err)
+			}
+			preloaded[path] = preloadedSchema{digest: digest, compiled: compiled}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return preloaded, nil
+}
+
+// compileSchema returns the compiled form of schemaJSON, compiling and caching
+// it under key on first use.
+func compileSchema(key string, schemaJSON string) (*compiledSchema, error) {
+	if cached, ok := compiledSchemas.Load(key); ok {
+		return cached, nil
+	}
+
+	schema, err := newCompiledSchema(schemaJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema format: %v", err)
+	}
+
+	return compiledSchemas.LoadOrStore(key, schema), nil
+}
+
+// compileSchemaContext is compileSchema, returning ctx.Err() if ctx is done
+// before the schema is compiled. Compilation cannot be interrupted, so an
+// abandoned compilation finishes in the background and is cached.
+func compileSchemaContext(ctx context.Context, key string, schemaJSON string) (*compiledSchema, error) {
+	if cached, ok := compiledSchemas.Load(key); ok {
+		return cached, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type compiled struct {
+		schema *compiledSchema
+		err    error
+	}
+	done := make(chan compiled, 1)
+	go func() {
+		schema, err := compileSchema(key, schemaJSON)
+		done <- compiled{schema, err}
+	}()
+
+	select {
+	case c := <-done:
+		return c.schema, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,151 @@
+package sbomvalidator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCompileSchemaCaches(t *testing.T) {
+	schemaJSON := `{"type": "object", "required": ["bomFormat"]}`
+
+	first, err := compileSchema("test/cache.schema.json", schemaJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	second, err := compileSchema("test/cache.schema.json", schemaJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if first != second {
+		t.Errorf("Expected cached schema to be reused")
+	}
+}
+
+func TestCompileSchemaInvalid(t *testing.T) {
+	if _, err := compileSchema("test/invalid.schema.json", `{ "invalid": `); err == nil {
+		t.Errorf("Expected an error but got none")
+	}
+
+	if _, ok := compiledSchemas.Load("test/invalid.schema.json"); ok {
+		t.Errorf("Expected invalid schema not to be cached")
+	}
+}
+
+func TestCompileSchemaLaterDrafts(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		document string
+		want     []string
+	}{
+		{name: "Draft 07", schema: `{"$schema": "http://json-schema.org/draft-07/schema#", "items": [{"type": "string"}]}`,
+			document: `[1]`, want: []string{"0: Invalid type. Expected: string, given: integer"}},
+		{name: "Draft 07 by default", schema: `{"items": [{"type": "string"}], "additionalItems": false}`,
+			document: `["a", "b"]`, want: []string{"(root): No additional items allowed on array"}},
+		{name: "unevaluatedProperties", schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"allOf": [{"properties": {"a": true}}], "unevaluatedProperties": false}`,
+			document: `{"a": 1, "b": 2}`, want: []string{"(root): Unevaluated property b is not allowed"}},
+		{name: "prefixItems", schema: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "prefixItems": [{"type": "string"}]}`,
+			document: `[1]`, want: []string{"0: Invalid type. Expected: string, given: integer"}},
+		{name: "dependentRequired", schema: `{"$schema": "https://json-schema.org/draft/2019-09/schema", "dependentRequired": {"a": ["b"]}}`,
+			document: `{"a": 1}`, want: []string{"(root): Has a dependency on b"}},
+		{name: "Keyword as a property name", schema: `{"properties": {"prefixItems": {"type": "array"}}, "required": ["unevaluatedProperties"]}`,
+			document: `{}`, want: []string{"(root): unevaluatedProperties is required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := newCompiledSchema(tt.schema)
+			if err != nil {
+				t.Fatalf("newCompiledSchema() error = %v", err)
+			}
+			findings, err := schema.validate(tt.document)
+			if err != nil {
+				t.Fatalf("validate() error = %v", err)
+			}
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaCacheEvicts(t *testing.T) {
+	cache := newSchemaCache(2)
+	a, b, c := &compiledSchema{}, &compiledSchema{}, &compiledSchema{}
+	cache.LoadOrStore("a", a)
+	cache.LoadOrStore("b", b)
+	if got := cache.LoadOrStore("a", &compiledSchema{}); got != a {
+		t.Errorf("Expected the cached schema to be kept")
+	}
+	cache.LoadOrStore("c", c)
+
+	if _, ok := cache.Load("b"); ok {
+		t.Errorf("Expected the least recently used schema to be evicted")
+	}
+	for key, want := range map[string]*compiledSchema{"a": a, "c": c} {
+		if got, ok := cache.Load(key); !ok || got != want {
+			t.Errorf("Load(%q) = %p, %v, want %p", key, got, ok, want)
+		}
+	}
+}
+
+// BenchmarkValidateSBOMDataCold measures a validation that compiles its
+// schema, as the first validation of a process does.
+func BenchmarkValidateSBOMDataCold(b *testing.B) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+	for i := 0; i < b.N; i++ {
+		compiledSchemas.Reset()
+		if _, err := ValidateSBOMData(sbom); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidateSBOMDataWarm measures a validation against a cached
+// schema, as every later validation of a process does.
+func BenchmarkValidateSBOMDataWarm(b *testing.B) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+	if _, err := ValidateSBOMData(sbom); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ValidateSBOMData(sbom); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidatorPreloaded measures a validation by a Validator from New,
+// which neither reads nor digests its schema.
+func BenchmarkValidatorPreloaded(b *testing.B) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+	validator, err := New(ValidatorConfig{Options: []Option{WithFormats(SBOM_SPDX)}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := validator.Validate(context.Background(), sbom); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestValidateSBOMCachesSchema(t *testing.T) {
+	schemaJSON := `{"type": "object", "required": ["name"]}`
+	if _, _, err := validateSBOM(schemaJSON, `{"name": "a"}`); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := compiledSchemas.Load(schemaDigest(schemaJSON)); !ok {
+		t.Errorf("Expected the schema to be cached by its digest")
+	}
+}
@@ -0,0 +1,443 @@
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// This file is the only one that depends on the JSON schema library, so
+// replacing it only requires reimplementing what is below: compiling a
+// schema, validating a document against it, mapping its errors to findings
+// and reporting which formats it checks.
+//
+// santhosh-tekuri/jsonschema implements drafts 04, 06, 07, 2019-09 and
+// 2020-12, and compiles a schema with the draft its `$schema` declares.
+// Schemas that declare none are compiled as draft 07, the draft the CycloneDX
+// and SPDX schemas were written for.
+
+// schemaLocation is the URL a schema without an `$id` is compiled at. Its
+// scheme has no loader, so a relative `$ref` in such a schema fails to
+// compile instead of being resolved against the working directory.
+const schemaLocation = "sbomvalidator:///schema.json"
+
+// schemaFetchTimeout bounds the fetch of a `$ref`erenced schema that is not
+// embedded in the build.
+const schemaFetchTimeout = 30 * time.Second
+
+// schemaLoader loads the `$ref`erenced schemas that are not embedded: from
+// files for file URLs and over HTTP(S) otherwise.
+var schemaLoader = jsonschema.SchemeURLLoader{
+	"file":  jsonschema.FileLoader{},
+	"http":  httpSchemaLoader{},
+	"https": httpSchemaLoader{},
+}
+
+// httpSchemaLoader fetches a schema over HTTP(S).
+type httpSchemaLoader struct{}
+
+func (httpSchemaLoader) Load(url string) (any, error) {
+	client := &http.Client{Timeout: schemaFetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+	return jsonschema.UnmarshalJSON(io.LimitReader(resp.Body, maxSchemaSize))
+}
+
+// schemaFormatCheckers are the formats checked in addition to those the
+// schema library checks itself.
+var schemaFormatCheckers = []*jsonschema.Format{
+	{Name: "idn-email", Validate: func(v any) error {
+		s, ok := v.(string)
+		if !ok {
+			return nil
+		}
+		_, err := mail.ParseAddress(s)
+		return err
+	}},
+}
+
+// libraryFormats are the formats the schema library checks itself.
+var libraryFormats = []string{
+	"date", "date-time", "duration", "email", "hostname", "ipv4", "ipv6",
+	"iri", "iri-reference", "json-pointer", "period", "relative-json-pointer",
+	"semver", "time", "uri", "uri-reference", "uri-template", "uuid",
+}
+
+// compiledSchema is a JSON schema ready to validate documents. It is safe for
+// concurrent use.
+type compiledSchema struct {
+	schema *jsonschema.Schema
+}
+
+// newCompiledSchema compiles a JSON schema, resolving its `$ref`s to the
+// referenced schemas embedded in the build before fetching any other.
+func newCompiledSchema(schemaJSON string) (*compiledSchema, error) {
+	document, err := jsonschema.UnmarshalJSON(strings.NewReader(schemaJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := embeddedSchemaRefs()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := referencedSchemaRefs(schemaJSON)
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft7)
+	compiler.AssertFormat()
+	compiler.UseLoader(schemaLoader)
+	for _, format := range schemaFormatCheckers {
+		compiler.RegisterFormat(format)
+	}
+	for _, id := range ids {
+		refDocument, err := jsonschema.UnmarshalJSON(strings.NewReader(refs[id]))
+		if err != nil {
+			return nil, fmt.Errorf("embedded schema %s: %v", id, err)
+		}
+		if err := compiler.AddResource(id, refDocument); err != nil {
+			return nil, fmt.Errorf("embedded schema %s: %v", id, err)
+		}
+	}
+	if err := compiler.AddResource(schemaLocation, document); err != nil {
+		return nil, err
+	}
+
+	schema, err := compiler.Compile(schemaLocation)
+	if err != nil {
+		return nil, err
+	}
+	return &compiledSchema{schema: schema}, nil
+}
+
+// validate validates a JSON document against the schema and returns the
+// schema errors as findings.
+func (s *compiledSchema) validate(document string) ([]Finding, error) {
+	instance, err := jsonschema.UnmarshalJSON(strings.NewReader(document))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+	return schemaFindings(validationErr, instance), nil
+}
+
+// formatChecked reports whether values are checked against a `format`.
+func formatChecked(format string) bool {
+	for _, checker := range schemaFormatCheckers {
+		if checker.Name == format {
+			return true
+		}
+	}
+	for _, f := range libraryFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaFindings converts the errors of validating instance into findings.
+// Properties the schema does not allow are fixed by removing them. Messages
+// are rendered when the findings are reported (see Finding.renderMessage).
+func schemaFindings(err *jsonschema.ValidationError, instance any) []Finding {
+	c := schemaFindingCollector{instance: instance}
+	c.collect(err, nil)
+	return c.findings
+}
+
+// schemaFindingCollector walks the tree of a validation error.
+type schemaFindingCollector struct {
+	instance any
+	findings []Finding
+}
+
+// collect appends the findings of a validation error and its causes. Errors
+// that only group others, such as a failed `$ref`, are not findings
+// themselves. For an `anyOf` or `oneOf` that no subschema matched, only the
+// errors of the subschema that came closest are reported, rather than those
+// of every alternative.
+//
+// parent is the instance location of the closest enclosing error. The schema
+// library reports `propertyNames` errors without the location of the object,
+// so they take that of the closest enclosing error that has one.
+func (c *schemaFindingCollector) collect(err *jsonschema.ValidationError, parent []string) {
+	switch k := err.ErrorKind.(type) {
+	case *kind.Schema, *kind.Group, *kind.Reference:
+		for _, cause := range err.Causes {
+			c.collect(cause, err.InstanceLocation)
+		}
+		return
+	case *kind.AnyOf, *kind.OneOf:
+		c.add(err, "")
+		var closest []Finding
+		for i, cause := range err.Causes {
+			candidate := schemaFindingCollector{instance: c.instance}
+			candidate.collect(cause, err.InstanceLocation)
+			if i == 0 || len(candidate.findings) < len(closest) {
+				closest = candidate.findings
+			}
+		}
+		c.findings = append(c.findings, closest...)
+		return
+	case *kind.Required:
+		for _, property := range k.Missing {
+			c.add(err, property)
+		}
+		return
+	case *kind.AdditionalProperties:
+		for _, property := range k.Properties {
+			f := c.add(err, property)
+			f.Fix = []PatchOperation{{Op: PatchRemove, Path: f.Pointer + "/" + escapeJSONPointer(property)}}
+		}
+		return
+	case *kind.Dependency:
+		for _, property := range k.Missing {
+			c.add(err, property)
+		}
+		return
+	case *kind.DependentRequired:
+		for _, property := range k.Missing {
+			c.add(err, property)
+		}
+		return
+	case *kind.FalseSchema:
+		// like additionalProperties, report an unevaluated property against
+		// the object, with the fix of removing it
+		n := len(err.InstanceLocation)
+		if n > 0 && strings.HasSuffix(err.SchemaURL, "/unevaluatedProperties") {
+			property := err.InstanceLocation[n-1]
+			object := *err
+			object.InstanceLocation = err.InstanceLocation[:n-1]
+			if _, ok := jsonValueAt(c.instance, object.InstanceLocation).(map[string]any); ok {
+				f := c.add(&object, property)
+				f.Fix = []PatchOperation{{Op: PatchRemove, Path: f.Pointer + "/" + escapeJSONPointer(property)}}
+				return
+			}
+		}
+	case *kind.Type:
+		// the library reports integers as numbers
+		if k.Got == "number" && isJSONInteger(jsonValueAt(c.instance, err.InstanceLocation)) {
+			k.Got = "integer"
+		}
+	case *kind.PropertyNames:
+		// the causes are errors of the name, which the message already names
+		if len(err.InstanceLocation) == 0 {
+			err.InstanceLocation = parent
+		}
+		c.add(err, "")
+		return
+	}
+	c.add(err, "")
+	for _, cause := range err.Causes {
+		c.collect(cause, err.InstanceLocation)
+	}
+}
+
+// add appends the finding of a validation error and returns it.
+func (c *schemaFindingCollector) add(err *jsonschema.ValidationError, property string) *Finding {
+	c.findings = append(c.findings, newSchemaFinding(err, property))
+	return &c.findings[len(c.findings)-1]
+}
+
+// jsonValueAt returns the value at a location of a JSON document decoded by
+// the schema library, or nil if there is none.
+func jsonValueAt(document any, location []string) any {
+	for _, token := range location {
+		switch v := document.(type) {
+		case map[string]any:
+			document = v[token]
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			document = v[i]
+		default:
+			return nil
+		}
+	}
+	return document
+}
+
+// isJSONInteger reports whether a number decoded by the schema library is an
+// integer.
+func isJSONInteger(v any) bool {
+	n, ok := v.(json.Number)
+	if !ok {
+		return false
+	}
+	r, ok := new(big.Rat).SetString(string(n))
+	return ok && r.IsInt()
+}
+
+// newSchemaFinding returns the finding of a validation error. property is
+// the property the error is about, for errors that name several (e.g., the
+// missing properties of `required`).
+func newSchemaFinding(err *jsonschema.ValidationError, property string) Finding {
+	path := "(root)"
+	if len(err.InstanceLocation) > 0 {
+		path = strings.Join(err.InstanceLocation, ".")
+	}
+	var pointer strings.Builder
+	for _, token := range err.InstanceLocation {
+		pointer.WriteString("/" + escapeJSONPointer(token))
+	}
+	return Finding{
+		Level:   LevelError,
+		Rule:    RuleSchema,
+		Path:    path,
+		Pointer: pointer.String(),
+		Keyword: schemaKeyword(err),
+		render:  func() string { return schemaMessage(err, property) },
+	}
+}
+
+// escapeJSONPointer escapes a reference token of a JSON pointer (RFC 6901).
+func escapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// unevaluatedKeywords are the keywords whose false schema rejects the
+// properties or items that no other keyword evaluated.
+var unevaluatedKeywords = []string{"unevaluatedProperties", "unevaluatedItems"}
+
+// schemaKeyword returns the JSON schema keyword that failed.
+func schemaKeyword(err *jsonschema.ValidationError) string {
+	switch err.ErrorKind.(type) {
+	case *kind.Not:
+		return "not"
+	case *kind.FalseSchema:
+		for _, keyword := range unevaluatedKeywords {
+			if strings.HasSuffix(err.SchemaURL, "/"+keyword) {
+				return keyword
+			}
+		}
+		return "false"
+	case *kind.Dependency:
+		return "dependencies"
+	}
+	if path := err.ErrorKind.KeywordPath(); len(path) > 0 {
+		return path[0]
+	}
+	return ""
+}
+
+// schemaMessagePrinter renders the messages of the schema library for the
+// error kinds schemaMessage has no message of its own for.
+var schemaMessagePrinter = message.NewPrinter(language.English)
+
+// schemaMessage returns the message of a validation error. property is the
+// property the error is about, for errors that name several.
+func schemaMessage(err *jsonschema.ValidationError, property string) string {
+	name := "(root)"
+	if n := len(err.InstanceLocation); n > 0 {
+		name = err.InstanceLocation[n-1]
+	}
+
+	switch k := err.ErrorKind.(type) {
+	case *kind.Required:
+		return property + " is required"
+	case *kind.AdditionalProperties:
+		return "Additional property " + property + " is not allowed"
+	case *kind.Dependency, *kind.DependentRequired:
+		return "Has a dependency on " + property
+	case *kind.Type:
+		want := strings.Join(k.Want, ",")
+		if len(k.Want) > 1 {
+			want = "[" + want + "]"
+		}
+		return fmt.Sprintf("Invalid type. Expected: %s, given: %s", want, k.Got)
+	case *kind.Enum:
+		values := make([]string, len(k.Want))
+		for i, v := range k.Want {
+			values[i] = schemaValue(v)
+		}
+		return fmt.Sprintf("%s must be one of the following: %s", name, strings.Join(values, ", "))
+	case *kind.Const:
+		return fmt.Sprintf("%s does not match: %s", name, schemaValue(k.Want))
+	case *kind.Format:
+		return fmt.Sprintf("Does not match format '%s'", k.Want)
+	case *kind.Pattern:
+		return fmt.Sprintf("Does not match pattern '%s'", k.Want)
+	case *kind.MinLength:
+		return fmt.Sprintf("String length must be greater than or equal to %d", k.Want)
+	case *kind.MaxLength:
+		return fmt.Sprintf("String length must be less than or equal to %d", k.Want)
+	case *kind.MinItems:
+		return fmt.Sprintf("Array must have at least %d items", k.Want)
+	case *kind.MaxItems:
+		return fmt.Sprintf("Array must have at most %d items", k.Want)
+	case *kind.UniqueItems:
+		return fmt.Sprintf("array items[%d,%d] must be unique", k.Duplicates[0], k.Duplicates[1])
+	case *kind.MinProperties:
+		return fmt.Sprintf("Must have at least %d properties", k.Want)
+	case *kind.MaxProperties:
+		return fmt.Sprintf("Must have at most %d properties", k.Want)
+	case *kind.Minimum:
+		return "Must be greater than or equal to " + k.Want.RatString()
+	case *kind.Maximum:
+		return "Must be less than or equal to " + k.Want.RatString()
+	case *kind.ExclusiveMinimum:
+		return "Must be greater than " + k.Want.RatString()
+	case *kind.ExclusiveMaximum:
+		return "Must be less than " + k.Want.RatString()
+	case *kind.MultipleOf:
+		return "Must be a multiple of " + k.Want.RatString()
+	case *kind.PropertyNames:
+		return fmt.Sprintf("Property name of %q does not match", k.Property)
+	case *kind.Contains:
+		return "At least one of the items must match"
+	case *kind.AdditionalItems:
+		return "No additional items allowed on array"
+	case *kind.AllOf:
+		return "Must validate all the schemas (allOf)"
+	case *kind.AnyOf:
+		return "Must validate at least one schema (anyOf)"
+	case *kind.OneOf:
+		return "Must validate one and only one schema (oneOf)"
+	case *kind.Not:
+		return "Must not validate the schema (not)"
+	case *kind.FalseSchema:
+		if property != "" {
+			return "Unevaluated property " + property + " is not allowed"
+		}
+		return "False always fails validation"
+	}
+	return err.ErrorKind.LocalizedString(schemaMessagePrinter)
+}
+
+// schemaValue renders a value of a schema in a message as JSON.
+func schemaValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
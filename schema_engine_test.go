@@ -0,0 +1,39 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// manyErrorsSBOM returns an SPDX document whose packages each break the
+// schema several times, like the pathological documents batch and server
+// validations see.
+func manyErrorsSBOM(packages int) []byte {
+	invalid := make([]string, packages)
+	for i := range invalid {
+		invalid[i] = fmt.Sprintf(`{"SPDXID": "SPDXRef-%d", "versionInfo": 1, "filesAnalyzed": "no", "unknown": true}`, i)
+	}
+	return spdxDocument(strings.Join(invalid, ","))
+}
+
+// BenchmarkValidateSBOMDataManyErrors measures the allocations of a
+// validation reporting thousands of schema errors. Messages are only rendered
+// for the errors reported, so capping them with MaxErrors saves the rendering
+// of the rest.
+func BenchmarkValidateSBOMDataManyErrors(b *testing.B) {
+	sbom := manyErrorsSBOM(1000)
+	for _, maxErrors := range []int{0, 10} {
+		b.Run(fmt.Sprintf("MaxErrors=%d", maxErrors), func(b *testing.B) {
+			opts := []Option{WithValidationOptions(ValidationOptions{MaxErrors: maxErrors})}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ValidateSBOMData(sbom, opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// FormatCoverage reports how the `format` keywords of the schema used were
+// enforced. Whether `format` is an assertion or only an annotation depends on
+// the JSON schema draft and the validator, so a schema declaring
+// "format": "date-time" does not by itself mean timestamps were checked.
+type FormatCoverage struct {
+	// Asserted is true when values that violate their format are validation
+	// errors, and false when they are reported as warnings. See
+	// `WithFormatAssertion`.
+	Asserted bool `json:"asserted"`
+	// Checked lists the formats used by the schema that values were checked
+	// against (e.g., "date-time", "iri-reference").
+	Checked []string `json:"checked,omitempty"`
+	// Unchecked lists the formats used by the schema that this validator
+	// has no check for. Values declared with them were not checked.
+	Unchecked []string `json:"unchecked,omitempty"`
+}
+
+// schemaFormatsCache holds the formats used by each schema, by digest.
+var schemaFormatsCache sync.Map
+
+// schemaFormats returns the distinct `format` keyword values of a schema,
+// sorted.
+func schemaFormats(digest string, schemaJSON string) ([]string, error) {
+	if cached, ok := schemaFormatsCache.Load(digest); ok {
+		return cached.([]string), nil
+	}
+
+	var schema interface{}
+	if err := json.Unmarshal([]byte(schemaJSON), &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema format: %v", err)
+	}
+
+	found := map[string]bool{}
+	var walk func(v interface{}, names bool)
+	walk = func(v interface{}, names bool) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				// in a map of property or definition names, "format" is a
+				// name rather than the keyword
+				if format, ok := child.(string); ok && key == "format" && !names {
+					found[format] = true
+					continue
+				}
+				walk(child, !names && (key == "properties" || key == "definitions" || key == "$defs" || key == "patternProperties"))
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child, false)
+			}
+		}
+	}
+	walk(schema, false)
+
+	formats := make([]string, 0, len(found))
+	for format := range found {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	schemaFormatsCache.Store(digest, formats)
+	return formats, nil
+}
+
+// checkSchemaFormats reports the format coverage of a schema and applies the
+// format assertion setting to the schema findings: unless asserted, format
+// violations are demoted to warnings.
+//
+// Returns the coverage and an info finding per format of the schema that is
+// not checked. They are not warnings, since an unchecked format is a gap of
+// the validator rather than of the SBOM, and `FailOnWarnings` would otherwise
+// reject valid SBOMs of every spec version whose schema uses one.
+func checkSchemaFormats(digest, schemaJSON string, asserted bool, schemaErrors []Finding) (*FormatCoverage, []Finding, error) {
+	formats, err := schemaFormats(digest, schemaJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	coverage := &FormatCoverage{Asserted: asserted}
+	var infos []Finding
+	for _, format := range formats {
+		if formatChecked(format) {
+			coverage.Checked = append(coverage.Checked, format)
+			continue
+		}
+		coverage.Unchecked = append(coverage.Unchecked, format)
+		infos = append(infos, Finding{
+			Level:   LevelInfo,
+			Rule:    RuleSchemaFormat,
+			Message: fmt.Sprintf("the schema uses format %q, which is not checked; values declared with it were not validated against it", format),
+		})
+	}
+
+	if !asserted {
+		for i := range schemaErrors {
+			if schemaErrors[i].Keyword == "format" {
+				schemaErrors[i].Level = LevelWarning
+			}
+		}
+	}
+
+	return coverage, infos, nil
+}
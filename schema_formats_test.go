@@ -0,0 +1,104 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFormats(t *testing.T) {
+	schema := `{
+  "properties": {
+    "format": {"type": "string", "format": "iri-reference"},
+    "created": {"type": "string", "format": "date-time"}
+  },
+  "definitions": {
+    "hash": {"type": "string", "format": "x-sha256"},
+    "format": {"type": "object"}
+  },
+  "items": [{"format": "date-time"}]
+}`
+
+	formats, err := schemaFormats("sha256:test-formats", schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(formats, ","); got != "date-time,iri-reference,x-sha256" {
+		t.Errorf("schemaFormats() = %q, want the three format keywords", got)
+	}
+
+	coverage, infos, err := checkSchemaFormats("sha256:test-formats", schema, true, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(coverage.Checked, ",") != "date-time,iri-reference" || strings.Join(coverage.Unchecked, ",") != "x-sha256" {
+		t.Errorf("unexpected coverage: %+v", coverage)
+	}
+	if len(infos) != 1 || infos[0].Level != LevelInfo || infos[0].Rule != RuleSchemaFormat || !strings.Contains(infos[0].Message, `"x-sha256"`) {
+		t.Errorf("expected one unchecked format info finding, got %+v", infos)
+	}
+}
+
+func TestWithFormatAssertion(t *testing.T) {
+	// the SPDX schema declares no formats, so add one for creationInfo.created
+	_, data, err := EmbeddedSchemas().Schema(SBOM_SPDX, "2.3")
+	if err != nil {
+		t.Fatalf("failed to read embedded schema: %v", err)
+	}
+	created := `"created" : {
+            "description"`
+	forked := strings.Replace(string(data), created, `"created" : {
+            "format" : "date-time",
+            "description"`, 1)
+	if forked == string(data) {
+		t.Fatalf("embedded schema has no creationInfo.created property")
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "spdx"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "spdx", "spdx-2.3.schema.json"), []byte(forked), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	provider := WithSchemaProviders(NewDirSchemaProvider(dir))
+	sbom := []byte(strings.Replace(string(spdxDocument(spdxPackage("a", "a", "MIT"))), "2024-10-22T12:00:00Z", "22 Oct 2024", 1))
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantValid    bool
+		wantAsserted bool
+	}{
+		{name: "Asserted by default", opts: []Option{provider}, wantValid: false, wantAsserted: true},
+		{name: "Annotation only", opts: []Option{provider, WithFormatAssertion(false)}, wantValid: true, wantAsserted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMDataStructured(sbom, tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v: %v", result.IsValid, tt.wantValid, result.ValidationErrors)
+			}
+			if result.Formats == nil || result.Formats.Asserted != tt.wantAsserted || strings.Join(result.Formats.Checked, ",") != "date-time" {
+				t.Errorf("unexpected format coverage: %+v", result.Formats)
+			}
+
+			var formatFindings []Finding
+			for _, f := range result.Findings {
+				if f.Keyword == "format" {
+					formatFindings = append(formatFindings, f)
+				}
+			}
+			if len(formatFindings) != 1 || formatFindings[0].Path != "creationInfo.created" {
+				t.Errorf("expected one format finding for creationInfo.created, got %+v", formatFindings)
+			}
+		})
+	}
+}
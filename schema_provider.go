@@ -0,0 +1,293 @@
+package sbomvalidator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxSchemaSize bounds the size of a schema fetched over HTTPS.
+const maxSchemaSize = 16 << 20
+
+// SchemaProvider supplies the JSON schemas SBOMs are validated against, so
+// newer spec versions or internal schema forks can be used without rebuilding
+// the library. See `WithSchemaProviders`.
+type SchemaProvider interface {
+	// Schema returns the schema for an SBOM format (`SBOM_CYCLONEDX` or
+	// `SBOM_SPDX`) and spec version (e.g., "1.6" or "2.3"), together with the
+	// name it is reported under in `SchemaUsed`. The error wraps
+	// fs.ErrNotExist when the provider has no schema for the version, so the
+	// next provider is tried.
+	Schema(format, version string) (name string, schema []byte, err error)
+}
+
+// ContextSchemaProvider is implemented by SchemaProviders that can abandon a
+// slow lookup, such as a remote fetch. When validation is given a context
+// (e.g., `ValidateSBOMDataContext`), SchemaContext is called instead of
+// Schema.
+type ContextSchemaProvider interface {
+	SchemaProvider
+	// SchemaContext is Schema, returning early when ctx is done.
+	SchemaContext(ctx context.Context, format, version string) (name string, schema []byte, err error)
+}
+
+// schemaFileName returns the file name of the schema for a format and spec
+// version, as laid out in the embedded schemas and in schema directories.
+func schemaFileName(format, version string) (string, error) {
+	switch format {
+	case SBOM_CYCLONEDX:
+		return fmt.Sprintf("cyclonedx/bom-%s.schema.json", version), nil
+	case SBOM_SPDX:
+		return fmt.Sprintf("spdx/spdx-%s.schema.json", version), nil
+	}
+	return "", fmt.Errorf("unsupported SBOM type: %s", format)
+}
+
+// EmbeddedSchemas returns the SchemaProvider for the schemas embedded in the
+// build. It is always consulted after the providers passed to
+// `WithSchemaProviders`.
+func EmbeddedSchemas() SchemaProvider {
+	return embeddedSchemaProvider{}
+}
+
+type embeddedSchemaProvider struct{}
+
+// Schema reads an embedded schema, e.g. "schemas/cyclonedx/bom-1.6.schema.json".
+func (embeddedSchemaProvider) Schema(format, version string) (string, []byte, error) {
+	file, err := schemaFileName(format, version)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := path.Join("schemas", file)
+	data, err := schemaFS.ReadFile(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read embedded schema file: %w", err)
+	}
+	return name, data, nil
+}
+
+// DirSchemaProvider serves schemas from a local directory laid out like the
+// embedded schemas: "cyclonedx/bom-<version>.schema.json" and
+// "spdx/spdx-<version>.schema.json". Files are read on every call, so schemas
+// can be updated in place.
+type DirSchemaProvider struct {
+	Dir string
+}
+
+// NewDirSchemaProvider returns a DirSchemaProvider for dir.
+//
+// Example:
+//
+//	result, err := ValidateSBOMData(data,
+//	    WithSchemaProviders(NewDirSchemaProvider("/etc/sbom-schemas")))
+func NewDirSchemaProvider(dir string) *DirSchemaProvider {
+	return &DirSchemaProvider{Dir: dir}
+}
+
+// Schema reads the schema file for a format and spec version from the
+// directory.
+func (p *DirSchemaProvider) Schema(format, version string) (string, []byte, error) {
+	file, err := schemaFileName(format, version)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := filepath.Join(p.Dir, filepath.FromSlash(file))
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+	return name, data, nil
+}
+
+// Default schema locations of HTTPSchemaProvider. "{version}" is replaced by
+// the spec version.
+const (
+	CycloneDXSchemaURL = "https://cyclonedx.org/schema/bom-{version}.schema.json"
+	SPDXSchemaURL      = "https://raw.githubusercontent.com/spdx/spdx-spec/v{version}/schemas/spdx-schema.json"
+)
+
+// HTTPSchemaProvider fetches schemas over HTTPS and keeps them in an on-disk
+// cache.
+//
+// `URLs` maps each format to a URL template in which "{version}" is replaced
+// by the spec version; formats without a template are not served. Published
+// schemas do not change once a spec version is released, so a cached schema
+// is used until it is removed from `CacheDir`. When `CacheDir` is empty,
+// schemas are fetched on every call.
+//
+// `Checksums` pins schemas by URL to their "sha256:<hex>" digest, as reported
+// in `SchemaDigest`. A fetched or cached schema that does not match its pin is
+// rejected, which protects against a compromised server or cache.
+type HTTPSchemaProvider struct {
+	Client    *http.Client
+	URLs      map[string]string
+	CacheDir  string
+	Checksums map[string]string
+}
+
+// NewHTTPSchemaProvider returns an HTTPSchemaProvider fetching from the
+// official CycloneDX and SPDX schema locations and caching in cacheDir.
+//
+// Parameters:
+//   - cacheDir: The cache directory, or "" to disable caching. It is created
+//     on first use.
+//
+// Returns:
+//   - The provider. Set `Checksums` on it to pin schemas.
+//
+// Example:
+//
+//	remote := NewHTTPSchemaProvider(filepath.Join(os.Getenv("HOME"), ".cache", "sbom-schemas"))
+//	remote.Checksums = map[string]string{
+//	    "https://cyclonedx.org/schema/bom-1.7.schema.json": "sha256:...",
+//	}
+//	result, err := ValidateSBOMData(data, WithSchemaProviders(remote))
+func NewHTTPSchemaProvider(cacheDir string) *HTTPSchemaProvider {
+	return &HTTPSchemaProvider{
+		Client: &http.Client{Timeout: 30 * time.Second},
+		URLs: map[string]string{
+			SBOM_CYCLONEDX: CycloneDXSchemaURL,
+			SBOM_SPDX:      SPDXSchemaURL,
+		},
+		CacheDir: cacheDir,
+	}
+}
+
+// Schema returns the schema for a format and spec version from the cache, or
+// fetches and caches it. The schema is reported under its URL.
+func (p *HTTPSchemaProvider) Schema(format, version string) (string, []byte, error) {
+	return p.SchemaContext(context.Background(), format, version)
+}
+
+// SchemaContext is Schema, abandoning the fetch when ctx is done.
+func (p *HTTPSchemaProvider) SchemaContext(ctx context.Context, format, version string) (string, []byte, error) {
+	template, ok := p.URLs[format]
+	if !ok {
+		return "", nil, fmt.Errorf("no schema URL for %s: %w", format, fs.ErrNotExist)
+	}
+	url := strings.ReplaceAll(template, "{version}", version)
+
+	cached := p.cachePath(url)
+	if cached != "" {
+		// a cached schema that no longer matches its pin is fetched again
+		if data, err := os.ReadFile(cached); err == nil && p.verify(url, data) == nil {
+			return url, data, nil
+		}
+	}
+
+	data, err := p.fetch(ctx, url)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := p.verify(url, data); err != nil {
+		return "", nil, err
+	}
+
+	if cached != "" {
+		// caching is best effort; a failed write only costs a future fetch
+		_ = writeFileAtomic(cached, data)
+	}
+	return url, data, nil
+}
+
+func (p *HTTPSchemaProvider) fetch(ctx context.Context, url string) ([]byte, error) {
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "sbom-validator")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("schema %s: %w", url, fs.ErrNotExist)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch schema %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSchemaSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema %s: %v", url, err)
+	}
+	if len(data) > maxSchemaSize {
+		return nil, fmt.Errorf("schema %s exceeds %d bytes", url, maxSchemaSize)
+	}
+	return data, nil
+}
+
+// verify checks a schema against its pinned digest, if any.
+func (p *HTTPSchemaProvider) verify(url string, data []byte) error {
+	want, ok := p.Checksums[url]
+	if !ok {
+		return nil
+	}
+	if got := schemaDigest(string(data)); got != strings.ToLower(want) {
+		return fmt.Errorf("schema %s has digest %s, but %s is pinned", url, got, want)
+	}
+	return nil
+}
+
+func (p *HTTPSchemaProvider) cachePath(url string) string {
+	if p.CacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(p.CacheDir, hex.EncodeToString(sum[:])+".schema.json")
+}
+
+// loadSchema returns the schema for an SBOM type and version from the first
+// provider that has it, falling back to the embedded schemas. provided reports
+// whether one of the providers, rather than the embedded schemas, supplied it.
+// Providers implementing ContextSchemaProvider are given ctx.
+func loadSchema(ctx context.Context, providers []SchemaProvider, version, sbomType string) (name, schema string, provided bool, err error) {
+	format := sbomFormat(sbomType)
+	if format == SBOM_SPDX {
+		if version, err = getSPDXVersion(version); err != nil {
+			return "", "", false, fmt.Errorf("failed to extract SPDX version")
+		}
+	}
+
+	candidates := append(append([]SchemaProvider{}, providers...), EmbeddedSchemas())
+	for i, provider := range candidates {
+		if err := ctx.Err(); err != nil {
+			return "", "", false, err
+		}
+
+		var name string
+		var data []byte
+		if p, ok := provider.(ContextSchemaProvider); ok {
+			name, data, err = p.SchemaContext(ctx, format, version)
+		} else {
+			name, data, err = provider.Schema(format, version)
+		}
+		if err == nil {
+			return name, string(data), i < len(providers), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", "", false, err
+		}
+	}
+	return "", "", false, fmt.Errorf("no schema for %s version %s: %w", format, version, fs.ErrNotExist)
+}
@@ -0,0 +1,202 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// forkedSPDXSchema returns the embedded SPDX 2.3 schema with an extra
+// required top-level property, standing in for an internal schema fork.
+func forkedSPDXSchema(t *testing.T) []byte {
+	t.Helper()
+	_, data, err := EmbeddedSchemas().Schema(SBOM_SPDX, "2.3")
+	if err != nil {
+		t.Fatalf("failed to read embedded schema: %v", err)
+	}
+	forked := strings.Replace(string(data), `"required" : [ "SPDXID",`, `"required" : [ "x-acme-owner", "SPDXID",`, 1)
+	if forked == string(data) {
+		t.Fatalf("embedded schema has no top-level required list")
+	}
+	return []byte(forked)
+}
+
+func TestDirSchemaProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "spdx"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	forked := forkedSPDXSchema(t)
+	for _, version := range []string{"2.3", "2.9"} {
+		if err := os.WriteFile(filepath.Join(dir, "spdx", "spdx-"+version+".schema.json"), forked, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	provider := NewDirSchemaProvider(dir)
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+
+	result, err := ValidateSBOMData(sbom, WithSchemaProviders(provider))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Errorf("expected the forked schema to reject the SBOM")
+	}
+	if want := filepath.Join(dir, "spdx", "spdx-2.3.schema.json"); result.SchemaUsed != want {
+		t.Errorf("SchemaUsed = %q, want %q", result.SchemaUsed, want)
+	}
+	if result.SchemaDigest != schemaDigest(string(forked)) {
+		t.Errorf("SchemaDigest = %q, want the digest of the forked schema", result.SchemaDigest)
+	}
+
+	// a newer spec version served by the provider is not an unknown version
+	newer := []byte(strings.Replace(string(sbom), "SPDX-2.3", "SPDX-2.9", 1))
+	result, err = ValidateSBOMData(newer, WithSchemaProviders(provider))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.UnknownVersion {
+		t.Errorf("expected SPDX 2.9 to be validated against the provided schema")
+	}
+
+	// versions the directory lacks fall back to the embedded schemas
+	result, err = ValidateSBOMData(sbom, WithSchemaProviders(NewDirSchemaProvider(t.TempDir())))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid || result.SchemaUsed != "schemas/spdx/spdx-2.3.schema.json" {
+		t.Errorf("expected the embedded schema to validate the SBOM, got %+v", result)
+	}
+}
+
+func TestHTTPSchemaProvider(t *testing.T) {
+	forked := forkedSPDXSchema(t)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/spdx/2.3.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(forked)
+	}))
+	defer server.Close()
+
+	newProvider := func(cacheDir string) *HTTPSchemaProvider {
+		p := NewHTTPSchemaProvider(cacheDir)
+		p.Client = server.Client()
+		p.URLs = map[string]string{SBOM_SPDX: server.URL + "/spdx/{version}.json"}
+		return p
+	}
+	url := server.URL + "/spdx/2.3.json"
+
+	t.Run("Fetches and caches", func(t *testing.T) {
+		requests.Store(0)
+		provider := newProvider(t.TempDir())
+		for i := 0; i < 2; i++ {
+			name, data, err := provider.Schema(SBOM_SPDX, "2.3")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != url || string(data) != string(forked) {
+				t.Errorf("Schema() = %q, %d bytes; want %q, %d bytes", name, len(data), url, len(forked))
+			}
+		}
+		if got := requests.Load(); got != 1 {
+			t.Errorf("expected 1 request, got %d", got)
+		}
+	})
+
+	t.Run("Pinned checksum", func(t *testing.T) {
+		provider := newProvider("")
+		provider.Checksums = map[string]string{url: schemaDigest(string(forked))}
+		if _, _, err := provider.Schema(SBOM_SPDX, "2.3"); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		provider.Checksums[url] = SBOMDigest([]byte("something else"))
+		if _, _, err := provider.Schema(SBOM_SPDX, "2.3"); err == nil || !strings.Contains(err.Error(), "is pinned") {
+			t.Errorf("expected a pin mismatch error, got %v", err)
+		}
+	})
+
+	t.Run("Tampered cache is fetched again", func(t *testing.T) {
+		requests.Store(0)
+		provider := newProvider(t.TempDir())
+		provider.Checksums = map[string]string{url: schemaDigest(string(forked))}
+		if err := os.WriteFile(provider.cachePath(url), []byte(`{}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, data, err := provider.Schema(SBOM_SPDX, "2.3"); err != nil || string(data) != string(forked) {
+			t.Errorf("expected the pinned schema to be fetched again, got err %v", err)
+		}
+		if got := requests.Load(); got != 1 {
+			t.Errorf("expected 1 request, got %d", got)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+		provider := newProvider(t.TempDir())
+
+		result, err := ValidateSBOMData(sbom, WithSchemaProviders(provider), WithPinnedSchemas(schemaDigest(string(forked))))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.IsValid || result.SchemaUsed != url {
+			t.Errorf("expected the fetched schema to reject the SBOM, got %+v", result)
+		}
+
+		// SPDX 2.2 is not on the server, so the embedded schema is used
+		older := []byte(strings.Replace(string(sbom), "SPDX-2.3", "SPDX-2.2", 1))
+		result, err = ValidateSBOMData(older, WithSchemaProviders(provider))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.SchemaUsed != "schemas/spdx/spdx-2.2.schema.json" {
+			t.Errorf("SchemaUsed = %q, want the embedded SPDX 2.2 schema", result.SchemaUsed)
+		}
+	})
+
+	t.Run("Server errors are not a missing schema", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer failing.Close()
+
+		provider := NewHTTPSchemaProvider("")
+		provider.URLs = map[string]string{SBOM_SPDX: failing.URL + "/{version}"}
+		_, err := ValidateSBOMData(spdxDocument(spdxPackage("a", "a", "MIT")), WithSchemaProviders(provider))
+		if err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("expected the server error to be returned, got %v", err)
+		}
+	})
+}
+
+func TestHTTPSchemaProviderContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// never answers, like an unresponsive schema host
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	provider := NewHTTPSchemaProvider("")
+	provider.Client = server.Client()
+	provider.URLs = map[string]string{SBOM_SPDX: server.URL + "/spdx/{version}.json"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := ValidateSBOMDataContext(ctx, spdxDocument(spdxPackage("a", "a", "MIT")), WithSchemaProviders(provider))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
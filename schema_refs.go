@@ -0,0 +1,172 @@
+package sbomvalidator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// schemaRefsDir is the subdirectory of a format's schema directory holding the
+// schemas its schemas reference by `$ref` (e.g.,
+// "schemas/cyclonedx/refs/spdx.schema.json"). They are not schemas of a spec
+// version themselves, so they are neither listed as revisions nor compiled on
+// their own.
+const schemaRefsDir = "refs"
+
+var (
+	schemaRefsOnce sync.Once
+	schemaRefs     map[string]string
+	schemaRefsErr  error
+)
+
+// embeddedSchemaRefs returns the referenced schemas embedded in the build,
+// keyed by their `$id`, so that compiling a schema resolves its `$ref`s to
+// them instead of fetching them.
+func embeddedSchemaRefs() (map[string]string, error) {
+	schemaRefsOnce.Do(func() {
+		schemaRefs = map[string]string{}
+		for format, schemas := range formatSchemas {
+			dir := path.Join("schemas", strings.ToLower(format), schemaRefsDir)
+			entries, err := fs.ReadDir(schemas, dir)
+			if err != nil {
+				continue // the format references no other schemas
+			}
+			for _, entry := range entries {
+				if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".schema.json") {
+					continue
+				}
+				data, err := fs.ReadFile(schemas, path.Join(dir, entry.Name()))
+				if err != nil {
+					schemaRefsErr = fmt.Errorf("failed to read embedded schema file: %w", err)
+					return
+				}
+				var schema struct {
+					ID string `json:"$id"`
+				}
+				if err := json.Unmarshal(data, &schema); err != nil || schema.ID == "" {
+					schemaRefsErr = fmt.Errorf("embedded schema %s has no $id", entry.Name())
+					return
+				}
+				schemaRefs[schema.ID] = string(data)
+			}
+		}
+	})
+	return schemaRefs, schemaRefsErr
+}
+
+// skipSchemaRefs returns fs.SkipDir for a directory of referenced schemas, so
+// that walks over the embedded schemas skip it, and nil for any other
+// directory.
+func skipSchemaRefs(d fs.DirEntry) error {
+	if d.IsDir() && d.Name() == schemaRefsDir {
+		return fs.SkipDir
+	}
+	return nil
+}
+
+// referencedSchemaRefs returns the `$id`s of the embedded schemas that schema
+// references by `$ref`, directly or through another embedded schema, sorted.
+// References are resolved against the schema's `$id`, as when it is compiled.
+func referencedSchemaRefs(schema string) ([]string, error) {
+	refs, err := embeddedSchemaRefs()
+	if err != nil || len(refs) == 0 {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	pending := []string{schema}
+	for len(pending) > 0 {
+		document := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		base, _ := url.Parse(jsonStringValue(document, `"$id"`))
+		for _, ref := range jsonStringValues(document, `"$ref"`) {
+			if strings.HasPrefix(ref, "#") {
+				continue // within the same schema
+			}
+			target, err := url.Parse(ref)
+			if err != nil {
+				continue
+			}
+			if base != nil {
+				target = base.ResolveReference(target)
+			}
+			target.Fragment = ""
+			id := target.String()
+			if _, ok := refs[id]; ok && !seen[id] {
+				seen[id] = true
+				pending = append(pending, refs[id])
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// schemaDigest returns the digest recorded for a schema in `SchemaDigest`, in
+// the same "sha256:<hex>" form as SBOM digests. It covers the embedded schemas
+// the schema references, since they take part in its verdicts, so a schema
+// that references none has the digest of its content alone.
+func schemaDigest(schema string) string {
+	ids, err := referencedSchemaRefs(schema)
+	if err != nil || len(ids) == 0 {
+		return SBOMDigest([]byte(schema))
+	}
+
+	refs, _ := embeddedSchemaRefs()
+	h := sha256.New()
+	h.Write([]byte(schema))
+	for _, id := range ids {
+		fmt.Fprintf(h, "\x00%s\x00%s", id, refs[id])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// jsonStringValue returns the string value of the first occurrence of key
+// (quoted) in a JSON document, or "" if it has none.
+func jsonStringValue(document, key string) string {
+	if values := jsonStringValues(document, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// jsonStringValues returns the string values of the members named key
+// (quoted) in a JSON document, found by scanning its text rather than decoding
+// it. Values with escape sequences are skipped; schema references have none.
+func jsonStringValues(document, key string) []string {
+	var values []string
+	for rest := document; ; {
+		i := strings.Index(rest, key)
+		if i < 0 {
+			return values
+		}
+		rest = strings.TrimLeft(rest[i+len(key):], " \t\r\n")
+		if !strings.HasPrefix(rest, ":") {
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		if !strings.HasPrefix(rest, `"`) {
+			continue
+		}
+		end := strings.IndexAny(rest[1:], `"\`)
+		if end < 0 {
+			return values
+		}
+		if rest[1+end] == '"' {
+			values = append(values, rest[1:1+end])
+		}
+		rest = rest[1+end:]
+	}
+}
@@ -0,0 +1,130 @@
+//go:build !sbomvalidator_no_cyclonedx
+
+package sbomvalidator
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedSchemaRefs(t *testing.T) {
+	refs, err := embeddedSchemaRefs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, id := range []string{
+		"http://cyclonedx.org/schema/spdx.schema.json",
+		"http://cyclonedx.org/schema/jsf-0.82.schema.json",
+		"http://cyclonedx.org/schema/cryptography-defs.schema.json",
+	} {
+		if _, ok := refs[id]; !ok {
+			t.Errorf("Expected %s to be embedded, got %d schemas", id, len(refs))
+		}
+	}
+
+	revisions, err := SchemaRevisions()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, r := range revisions {
+		if strings.Contains(r.Path, "/"+schemaRefsDir+"/") {
+			t.Errorf("Referenced schema %s listed as a schema revision", r.Path)
+		}
+	}
+}
+
+// TestValidateSBOMDataEmbeddedSchemaRefs validates the bundled samples of
+// every CycloneDX version without network access: the `$ref`s of their schemas
+// resolve to the embedded schemas.
+func TestValidateSBOMDataEmbeddedSchemaRefs(t *testing.T) {
+	for _, name := range []string{
+		"sample-1.2.cdx.json", "sample-1.3.cdx.json", "sample-1.4.cdx.json", "sample-1.5.cdx.json",
+		"sample-1.6.cdx.json", "sample-1.6.cdx.signed.json", "shiftsbom-validator-1.7.cdx.json",
+	} {
+		t.Run(name, func(t *testing.T) {
+			sbom, err := os.ReadFile("sample-sboms/" + name)
+			if err != nil {
+				t.Fatalf("Failed to read SBOM: %v", err)
+			}
+			result, err := ValidateSBOMData(sbom)
+			if err != nil || !result.IsValid {
+				t.Fatalf("Expected a valid SBOM, got %+v, %v", result, err)
+			}
+		})
+	}
+}
+
+// TestValidateSBOMDataEmbeddedSPDXRef checks that license identifiers are
+// validated against the embedded SPDX License List.
+func TestValidateSBOMDataEmbeddedSPDXRef(t *testing.T) {
+	sbom, err := os.ReadFile("sample-sboms/sample-1.3.cdx.json")
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+
+	result, err := ValidateSBOMData(sbom)
+	if err != nil || !result.IsValid {
+		t.Fatalf("Expected a valid SBOM, got %+v, %v", result, err)
+	}
+
+	unknown := bytes.Replace(sbom, []byte(`"id": "MIT"`), []byte(`"id": "Not-A-License"`), 1)
+	result, err = ValidateSBOMData(unknown)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsValid {
+		t.Error("Expected a license identifier missing from the embedded SPDX License List to be invalid")
+	}
+}
+
+func TestSchemaDigestCoversRefs(t *testing.T) {
+	schema, err := loadSBOMSchema("1.6", SBOM_CYCLONEDX)
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	ids, err := referencedSchemaRefs(schema)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"http://cyclonedx.org/schema/jsf-0.82.schema.json", "http://cyclonedx.org/schema/spdx.schema.json"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("referencedSchemaRefs() = %v, want %v", ids, want)
+	}
+
+	digest := schemaDigest(schema)
+	if digest == SBOMDigest([]byte(schema)) {
+		t.Error("Expected the digest to cover the referenced schemas")
+	}
+
+	refs, _ := embeddedSchemaRefs()
+	jsf := refs[want[0]]
+	refs[want[0]] = strings.Replace(jsf, `"ES256",`, "", 1)
+	t.Cleanup(func() { refs[want[0]] = jsf })
+	if schemaDigest(schema) == digest {
+		t.Error("Expected a changed referenced schema to change the digest")
+	}
+}
+
+// TestUncheckedSchemaFormatsFailOnWarnings checks that the format the
+// CycloneDX 1.2 schema declares without a check does not make a valid SBOM
+// fail under FailOnWarnings.
+func TestUncheckedSchemaFormatsFailOnWarnings(t *testing.T) {
+	sbom, err := os.ReadFile("sample-sboms/sample-1.2.cdx.json")
+	if err != nil {
+		t.Fatalf("Failed to read SBOM: %v", err)
+	}
+
+	result, err := ValidateSBOMDataStructured(sbom, WithValidationOptions(ValidationOptions{FailOnWarnings: true}))
+	if err != nil || !result.IsValid {
+		t.Fatalf("Expected a valid SBOM, got %+v, %v", result, err)
+	}
+	if result.Formats == nil || len(result.Formats.Unchecked) == 0 {
+		t.Errorf("Expected unchecked formats in the coverage, got %+v", result.Formats)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", result.Warnings)
+	}
+}
@@ -0,0 +1,108 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VersionMode selects what happens when an SBOM declares a spec version that
+// has no schema. See `WithVersionMode`.
+type VersionMode string
+
+// Version modes.
+const (
+	// VersionStrict fails validation, listing the supported versions. It is
+	// the default.
+	VersionStrict VersionMode = "strict"
+	// VersionLenient validates against the closest supported version and
+	// reports a warning.
+	VersionLenient VersionMode = "lenient"
+)
+
+// SupportedVersions returns the spec versions of a format with an embedded
+// schema, oldest first.
+//
+// Parameters:
+//   - format: `FormatCycloneDX` or `FormatSPDX`.
+//
+// Returns:
+//   - The versions, e.g. [1.2 1.3 1.4 1.5 1.6 1.7] for CycloneDX, or nil if
+//     the format is unknown or not compiled in.
+//
+// Example:
+//
+//	fmt.Println(SupportedVersions(FormatCycloneDX))
+func SupportedVersions(format Format) []SpecVersion {
+	file, err := schemaFileName(string(format), "{version}")
+	if err != nil {
+		return nil
+	}
+	dir, pattern, _ := strings.Cut(file, "/")
+	prefix, suffix, _ := strings.Cut(pattern, "{version}")
+
+	entries, err := schemaFS.ReadDir("schemas/" + dir)
+	if err != nil {
+		return nil
+	}
+
+	var versions []SpecVersion
+	for _, entry := range entries {
+		// archived schema revisions live in subdirectories
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) || !strings.HasSuffix(entry.Name(), suffix) {
+			continue
+		}
+		versions = append(versions, SpecVersion(strings.TrimSuffix(strings.TrimPrefix(entry.Name(), prefix), suffix)))
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Compare(versions[j]) < 0 })
+	return versions
+}
+
+// closestSchemaVersion returns the supported version closest to the given
+// one: the newest version not after it or, for versions older than every
+// schema, the oldest. The result is in the form loadSchema takes (e.g., "1.2"
+// or "SPDX-2.2").
+func closestSchemaVersion(version string, sbomType string) (string, error) {
+	format := sbomFormat(sbomType)
+	declared := version
+	if format == SBOM_SPDX {
+		var err error
+		if declared, err = getSPDXVersion(version); err != nil {
+			return "", err
+		}
+	}
+
+	versions := SupportedVersions(Format(format))
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no embedded schemas found for %s", format)
+	}
+
+	closest := versions[0]
+	for _, v := range versions {
+		if SpecVersion(declared).AtLeast(v) {
+			closest = v
+		}
+	}
+
+	if format == SBOM_SPDX {
+		return SBOM_SPDX + "-" + string(closest), nil
+	}
+	return string(closest), nil
+}
+
+// unsupportedVersionError explains a missing schema, listing the versions
+// that are supported.
+func unsupportedVersionError(version string, sbomType string, err error) error {
+	format := sbomFormat(sbomType)
+	var supported []string
+	for _, v := range SupportedVersions(Format(format)) {
+		supported = append(supported, string(v))
+	}
+
+	// CycloneDX 1.0 and 1.1 predate the JSON encoding
+	if format == SBOM_CYCLONEDX && (version == "1.0" || version == "1.1") {
+		return fmt.Errorf("failed to load schema: %w; supported %s versions: %s (CycloneDX 1.0 and 1.1 were only published as XML schemas)",
+			err, format, strings.Join(supported, ", "))
+	}
+	return fmt.Errorf("failed to load schema: %w; supported %s versions: %s", err, format, strings.Join(supported, ", "))
+}
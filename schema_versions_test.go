@@ -0,0 +1,87 @@
+//go:build !sbomvalidator_no_cyclonedx && !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSupportedVersions(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   []SpecVersion
+	}{
+		{format: FormatCycloneDX, want: []SpecVersion{"1.2", "1.3", "1.4", "1.5", "1.6", "1.7"}},
+		{format: FormatSPDX, want: []SpecVersion{"2.2", "2.3"}},
+		{format: "SWID", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			if got := SupportedVersions(tt.format); !slices.Equal(got, tt.want) {
+				t.Errorf("SupportedVersions(%q) = %v, want %v", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		sbomType string
+		want     string
+	}{
+		{version: "1.0", sbomType: SBOM_CYCLONEDX, want: "1.2"},
+		{version: "1.5", sbomType: SBOM_CYCLONEDX, want: "1.5"},
+		{version: "1.9", sbomType: SBOM_CYCLONEDX, want: "1.7"},
+		{version: "SPDX-2.1", sbomType: "SPDX-2.1", want: "SPDX-2.2"},
+		{version: "SPDX-3.0", sbomType: "SPDX-3.0", want: "SPDX-2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := closestSchemaVersion(tt.version, tt.sbomType)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("closestSchemaVersion(%q) = %q, want %q", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithVersionMode(t *testing.T) {
+	older := []byte(strings.Replace(string(spdxDocument(spdxPackage("a", "a", "MIT"))), "SPDX-2.3", "SPDX-2.1", 1))
+
+	t.Run("Strict", func(t *testing.T) {
+		for _, sbom := range [][]byte{older, []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.1"}`)} {
+			_, err := ValidateSBOMData(sbom, WithVersionMode(VersionStrict))
+			if err == nil || !strings.Contains(err.Error(), "supported") {
+				t.Errorf("expected an error listing the supported versions, got %v", err)
+			}
+		}
+
+		_, err := ValidateSBOMData([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.1"}`))
+		if err == nil || !strings.Contains(err.Error(), "1.2, 1.3, 1.4, 1.5, 1.6, 1.7 (CycloneDX 1.0 and 1.1 were only published as XML schemas)") {
+			t.Errorf("expected the CycloneDX versions and an XML note, got %v", err)
+		}
+	})
+
+	t.Run("Lenient", func(t *testing.T) {
+		result, err := ValidateSBOMDataStructured(older, WithVersionMode(VersionLenient))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.IsValid || !result.UnknownVersion || result.SchemaUsed != "schemas/spdx/spdx-2.2.schema.json" {
+			t.Errorf("expected SPDX 2.1 to validate against SPDX 2.2, got %+v", result.ValidationResult)
+		}
+		warnings := result.WarningFindings()
+		if len(warnings) != 1 || warnings[0].Rule != RuleUnknownSpecVersion ||
+			warnings[0].Message != "SPDX 2.1 has no schema; validated against 2.2 as the closest supported version" {
+			t.Errorf("expected a version warning, got %+v", warnings)
+		}
+	})
+}
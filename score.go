@@ -0,0 +1,517 @@
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Score categories group the scoring criteria.
+const (
+	ScoreCompleteness = "completeness"
+	ScoreIdentifiers  = "identifiers"
+	ScoreDependencies = "dependencies"
+	ScoreMetadata     = "metadata"
+)
+
+// Scoring criteria. Component criteria score the share of components that
+// meet them; document criteria score 0 or 1.
+const (
+	CriterionComponentName     = "component-name"
+	CriterionComponentVersion  = "component-version"
+	CriterionComponentSupplier = "component-supplier"
+	CriterionComponentLicense  = "component-license"
+	CriterionComponentHash     = "component-hash"
+
+	CriterionComponentIdentifier = "component-identifier"
+	CriterionValidPURL           = "valid-purl"
+
+	CriterionDependencyRelationships = "dependency-relationships"
+	CriterionDependencyDepth         = "dependency-depth"
+
+	CriterionSBOMAuthor           = "sbom-author"
+	CriterionSBOMTool             = "sbom-tool"
+	CriterionSBOMTimestamp        = "sbom-timestamp"
+	CriterionSBOMPrimaryComponent = "sbom-primary-component"
+	CriterionSBOMIdentifier       = "sbom-identifier"
+)
+
+// scoringCriteria lists every criterion, with its category, in reporting
+// order.
+var scoringCriteria = []struct {
+	id       string
+	category string
+}{
+	{CriterionComponentName, ScoreCompleteness},
+	{CriterionComponentVersion, ScoreCompleteness},
+	{CriterionComponentSupplier, ScoreCompleteness},
+	{CriterionComponentLicense, ScoreCompleteness},
+	{CriterionComponentHash, ScoreCompleteness},
+	{CriterionComponentIdentifier, ScoreIdentifiers},
+	{CriterionValidPURL, ScoreIdentifiers},
+	{CriterionDependencyRelationships, ScoreDependencies},
+	{CriterionDependencyDepth, ScoreDependencies},
+	{CriterionSBOMAuthor, ScoreMetadata},
+	{CriterionSBOMTool, ScoreMetadata},
+	{CriterionSBOMTimestamp, ScoreMetadata},
+	{CriterionSBOMPrimaryComponent, ScoreMetadata},
+	{CriterionSBOMIdentifier, ScoreMetadata},
+}
+
+// ScoringProfile weights the scoring criteria. Criteria without a positive
+// weight do not count towards the score.
+type ScoringProfile struct {
+	Name    string             `json:"name"`
+	Weights map[string]float64 `json:"weights"`
+}
+
+// Built-in scoring profiles.
+var (
+	// DefaultScoringProfile weights the four categories equally, and the
+	// criteria equally within each category.
+	DefaultScoringProfile = ScoringProfile{Name: "default", Weights: map[string]float64{
+		CriterionComponentName:           5,
+		CriterionComponentVersion:        5,
+		CriterionComponentSupplier:       5,
+		CriterionComponentLicense:        5,
+		CriterionComponentHash:           5,
+		CriterionComponentIdentifier:     12.5,
+		CriterionValidPURL:               12.5,
+		CriterionDependencyRelationships: 12.5,
+		CriterionDependencyDepth:         12.5,
+		CriterionSBOMAuthor:              5,
+		CriterionSBOMTool:                5,
+		CriterionSBOMTimestamp:           5,
+		CriterionSBOMPrimaryComponent:    5,
+		CriterionSBOMIdentifier:          5,
+	}}
+	// NTIAScoringProfile only scores the NTIA minimum elements, equally.
+	NTIAScoringProfile = ScoringProfile{Name: "ntia", Weights: map[string]float64{
+		CriterionComponentName:           1,
+		CriterionComponentVersion:        1,
+		CriterionComponentSupplier:       1,
+		CriterionComponentIdentifier:     1,
+		CriterionDependencyRelationships: 1,
+		CriterionSBOMAuthor:              1,
+		CriterionSBOMTimestamp:           1,
+	}}
+)
+
+// ParseScoringProfile reads a scoring profile from JSON, e.g.
+// `{"name": "team", "weights": {"component-license": 2, "valid-purl": 1}}`.
+//
+// Parameters:
+//   - data: The JSON profile.
+//
+// Returns:
+//   - The profile.
+//   - An error if the JSON is malformed, a criterion is unknown, a weight is
+//     negative or no weight is positive.
+//
+// Example:
+//
+//	data, _ := os.ReadFile("scoring.json")
+//	profile, err := ParseScoringProfile(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	report, err := Score(sbom, WithScoringProfile(profile))
+func ParseScoringProfile(data []byte) (ScoringProfile, error) {
+	var profile ScoringProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return ScoringProfile{}, fmt.Errorf("invalid scoring profile: %v", err)
+	}
+
+	var total float64
+	for id, weight := range profile.Weights {
+		if criterionCategory(id) == "" {
+			return ScoringProfile{}, fmt.Errorf("unknown scoring criterion %q", id)
+		}
+		if weight < 0 {
+			return ScoringProfile{}, fmt.Errorf("negative weight for %q", id)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return ScoringProfile{}, fmt.Errorf("scoring profile %q weights no criteria", profile.Name)
+	}
+	return profile, nil
+}
+
+func criterionCategory(id string) string {
+	for _, c := range scoringCriteria {
+		if c.id == id {
+			return c.category
+		}
+	}
+	return ""
+}
+
+// CriterionScore is the score of a single criterion.
+type CriterionScore struct {
+	Criterion string `json:"criterion"`
+	Category  string `json:"category"`
+	// Score is from 0 to 100.
+	Score  float64 `json:"score"`
+	Weight float64 `json:"weight"`
+	// Detail explains the score, e.g., "12 of 15 components".
+	Detail string `json:"detail"`
+	// Applicable is false for criteria that do not apply to the SBOM, e.g.,
+	// purl validity in an SBOM without purls. They do not count towards the
+	// score.
+	Applicable bool `json:"applicable"`
+}
+
+// CategoryScore is the weighted score of the criteria in a category.
+type CategoryScore struct {
+	Category string  `json:"category"`
+	Score    float64 `json:"score"`
+}
+
+// ScoreReport grades the quality of an SBOM beyond whether it is valid.
+type ScoreReport struct {
+	// Score is the weighted score of the criteria, from 0 to 100.
+	Score float64 `json:"score"`
+	// Grade is the letter grade of the score: A (90 and above), B (80), C
+	// (70), D (60) or F.
+	Grade      string           `json:"grade"`
+	Profile    string           `json:"profile"`
+	Categories []CategoryScore  `json:"categories"`
+	Criteria   []CriterionScore `json:"criteria"`
+	// Components is the number of components (CycloneDX) or packages (SPDX).
+	Components int `json:"components"`
+	// DependencyDepth is the length of the longest dependency chain from the
+	// roots of the dependency graph.
+	DependencyDepth int `json:"dependencyDepth"`
+}
+
+// Score grades the completeness of an SBOM, similar to sbomqs, so teams can
+// track SBOM quality over time. Each criterion is scored from 0 to 100:
+//   - completeness: the share of components declaring a name, version,
+//     supplier, license and hash;
+//   - identifiers: the share of components with a unique identifier (purl,
+//     CPE, SWID, OmniBOR or gitoid), and the share of purls that are valid;
+//   - dependencies: the share of components in a dependency relationship, and
+//     the depth of the dependency graph (a depth of 2 or more, i.e.
+//     transitive dependencies, scores 100; 1 scores 50);
+//   - metadata: whether the SBOM declares its author, the tool that generated
+//     it, a timestamp, its primary component and a unique identifier (serial
+//     number or document namespace).
+//
+// The score is their average weighted by the scoring profile
+// (`WithScoringProfile`, DefaultScoringProfile by default). The SBOM is not
+// validated.
+//
+// Parameters:
+//   - sbomContent: The SBOM in any supported encoding, optionally compressed.
+//   - opts: Optional settings; `WithScoringProfile`, `WithContentEncoding`
+//     and `WithDecompressor` apply.
+//
+// Returns:
+//   - The score report.
+//   - An error if the SBOM cannot be decoded or its type is unsupported.
+//
+// Example:
+//
+//	report, err := Score(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%.0f (%s)\n", report.Score, report.Grade)
+func Score(sbomContent []byte, opts ...Option) (*ScoreReport, error) {
+	options := newValidationOptions(opts)
+	jsonContent, err := sbomJSON(sbomContent, options)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseSBOMDocument(jsonContent)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := DefaultScoringProfile
+	if options.scoringProfile != nil {
+		profile = *options.scoringProfile
+	}
+	return scoreDocument(doc.obj, doc.sbomType, profile), nil
+}
+
+// scoredComponent records which component criteria a component meets.
+type scoredComponent struct {
+	name, version, supplier, license, hash, identifier bool
+	// purl is the component's purl, or "".
+	purl string
+}
+
+// documentFacts records which document criteria an SBOM meets.
+type documentFacts struct {
+	author, tool, timestamp, primary, identifier bool
+}
+
+func scoreDocument(obj map[string]interface{}, sbomType string, profile ScoringProfile) *ScoreReport {
+	var components []scoredComponent
+	var facts documentFacts
+	if sbomType == SBOM_CYCLONEDX {
+		components, facts = cycloneDXScoreFacts(obj)
+	} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		components, facts = spdxScoreFacts(obj)
+	}
+
+	graph, _ := buildDependencyGraph(obj, sbomType)
+	depth := dependencyDepth(graph)
+
+	report := &ScoreReport{Profile: profile.Name, Components: len(components), DependencyDepth: depth}
+	share := func(meets func(c scoredComponent) bool) (float64, string) {
+		n := 0
+		for _, c := range components {
+			if meets(c) {
+				n++
+			}
+		}
+		if len(components) == 0 {
+			return 0, "no components"
+		}
+		return float64(n) / float64(len(components)), fmt.Sprintf("%d of %d components", n, len(components))
+	}
+	flag := func(ok bool, present, missing string) (float64, string) {
+		if ok {
+			return 1, present
+		}
+		return 0, missing
+	}
+
+	for _, criterion := range scoringCriteria {
+		score := CriterionScore{Criterion: criterion.id, Category: criterion.category, Weight: profile.Weights[criterion.id], Applicable: true}
+		var value float64
+		switch criterion.id {
+		case CriterionComponentName:
+			value, score.Detail = share(func(c scoredComponent) bool { return c.name })
+		case CriterionComponentVersion:
+			value, score.Detail = share(func(c scoredComponent) bool { return c.version })
+		case CriterionComponentSupplier:
+			value, score.Detail = share(func(c scoredComponent) bool { return c.supplier })
+		case CriterionComponentLicense:
+			value, score.Detail = share(func(c scoredComponent) bool { return c.license })
+		case CriterionComponentHash:
+			value, score.Detail = share(func(c scoredComponent) bool { return c.hash })
+		case CriterionComponentIdentifier:
+			value, score.Detail = share(func(c scoredComponent) bool { return c.identifier })
+		case CriterionValidPURL:
+			purls, valid := 0, 0
+			for _, c := range components {
+				if c.purl != "" {
+					purls++
+					if _, err := parsePackageURL(c.purl); err == nil {
+						valid++
+					}
+				}
+			}
+			if purls == 0 {
+				score.Applicable, score.Detail = false, "no purls"
+			} else {
+				value, score.Detail = float64(valid)/float64(purls), fmt.Sprintf("%d of %d purls", valid, purls)
+			}
+		case CriterionDependencyRelationships:
+			if len(graph.Components) == 0 {
+				score.Detail = "no components with an ID"
+			} else {
+				dependedOn := graph.dependedOn()
+				related := 0
+				for _, id := range graph.Components {
+					if dependedOn[id] || len(graph.Dependencies[id]) > 0 {
+						related++
+					}
+				}
+				value = float64(related) / float64(len(graph.Components))
+				score.Detail = fmt.Sprintf("%d of %d components", related, len(graph.Components))
+			}
+		case CriterionDependencyDepth:
+			value, score.Detail = math.Min(float64(depth), 2)/2, fmt.Sprintf("depth %d", depth)
+		case CriterionSBOMAuthor:
+			value, score.Detail = flag(facts.author, "author declared", "no author")
+		case CriterionSBOMTool:
+			value, score.Detail = flag(facts.tool, "tool declared", "no tool")
+		case CriterionSBOMTimestamp:
+			value, score.Detail = flag(facts.timestamp, "timestamp declared", "no timestamp")
+		case CriterionSBOMPrimaryComponent:
+			value, score.Detail = flag(facts.primary, "primary component declared", "no primary component")
+		case CriterionSBOMIdentifier:
+			value, score.Detail = flag(facts.identifier, "document identifier declared", "no document identifier")
+		}
+		score.Score = roundScore(value * 100)
+		report.Criteria = append(report.Criteria, score)
+	}
+
+	report.Score = weightedScore(report.Criteria, func(CriterionScore) bool { return true })
+	for _, category := range []string{ScoreCompleteness, ScoreIdentifiers, ScoreDependencies, ScoreMetadata} {
+		report.Categories = append(report.Categories, CategoryScore{
+			Category: category,
+			Score:    weightedScore(report.Criteria, func(c CriterionScore) bool { return c.Category == category }),
+		})
+	}
+	report.Grade = scoreGrade(report.Score)
+	return report
+}
+
+// weightedScore returns the average of the applicable criteria selected,
+// weighted by their weights.
+func weightedScore(criteria []CriterionScore, selected func(CriterionScore) bool) float64 {
+	var sum, weights float64
+	for _, c := range criteria {
+		if c.Applicable && c.Weight > 0 && selected(c) {
+			sum += c.Score * c.Weight
+			weights += c.Weight
+		}
+	}
+	if weights == 0 {
+		return 0
+	}
+	return roundScore(sum / weights)
+}
+
+// roundScore rounds a score to one decimal.
+func roundScore(score float64) float64 {
+	return math.Round(score*10) / 10
+}
+
+func scoreGrade(score float64) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	}
+	return "F"
+}
+
+// dependencyDepth returns the length of the longest dependency chain of a
+// graph, from its primary component if it has one, or else from its roots.
+// Cycles are not followed.
+func dependencyDepth(graph *DependencyGraph) int {
+	onPath := map[string]bool{}
+	var longest func(id string) int
+	longest = func(id string) int {
+		onPath[id] = true
+		depth := 0
+		for _, dep := range graph.Dependencies[id] {
+			if !onPath[dep] {
+				depth = max(depth, 1+longest(dep))
+			}
+		}
+		onPath[id] = false
+		return depth
+	}
+
+	roots := graph.Roots()
+	if graph.Primary != "" {
+		roots = []string{graph.Primary}
+	}
+	depth := 0
+	for _, root := range roots {
+		depth = max(depth, longest(root))
+	}
+	return depth
+}
+
+func cycloneDXScoreFacts(obj map[string]interface{}) ([]scoredComponent, documentFacts) {
+	var components []scoredComponent
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		if path == "metadata.component" {
+			return
+		}
+		name, _ := component["name"].(string)
+		version, _ := component["version"].(string)
+		supplier, _ := component["supplier"].(map[string]interface{})
+		supplierName, _ := supplier["name"].(string)
+		publisher, _ := component["publisher"].(string)
+		licenses, _ := component["licenses"].([]interface{})
+		hashes, _ := component["hashes"].([]interface{})
+		purl, _ := component["purl"].(string)
+		components = append(components, scoredComponent{
+			name:       !isBlank(name),
+			version:    !isBlank(version),
+			supplier:   !isBlank(supplierName) || !isBlank(publisher),
+			license:    len(licenses) > 0,
+			hash:       len(hashes) > 0,
+			identifier: hasCycloneDXIdentifier(component),
+			purl:       purl,
+		})
+	})
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	authors, _ := metadata["authors"].([]interface{})
+	timestamp, _ := metadata["timestamp"].(string)
+	serialNumber, _ := obj["serialNumber"].(string)
+	facts := documentFacts{
+		author:     len(authors) > 0 || metadata["manufacturer"] != nil || metadata["supplier"] != nil,
+		tool:       hasCycloneDXTools(metadata),
+		timestamp:  !isBlank(timestamp),
+		primary:    metadata["component"] != nil,
+		identifier: !isBlank(serialNumber),
+	}
+	return components, facts
+}
+
+func spdxScoreFacts(obj map[string]interface{}) ([]scoredComponent, documentFacts) {
+	var components []scoredComponent
+	packages, _ := obj["packages"].([]interface{})
+	for _, p := range packages {
+		pkg, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := pkg["name"].(string)
+		version, _ := pkg["versionInfo"].(string)
+		supplier, _ := pkg["supplier"].(string)
+		declared, _ := pkg["licenseDeclared"].(string)
+		concluded, _ := pkg["licenseConcluded"].(string)
+		checksums, _ := pkg["checksums"].([]interface{})
+		var purl string
+		refs, _ := pkg["externalRefs"].([]interface{})
+		for _, r := range refs {
+			ref, _ := r.(map[string]interface{})
+			if ref["referenceType"] == "purl" {
+				purl, _ = ref["referenceLocator"].(string)
+			}
+		}
+		components = append(components, scoredComponent{
+			name:       !isBlank(name),
+			version:    !isBlank(version),
+			supplier:   !isBlank(supplier) && supplier != "NOASSERTION",
+			license:    isSPDXLicenseAsserted(declared) || isSPDXLicenseAsserted(concluded),
+			hash:       len(checksums) > 0,
+			identifier: hasSPDXIdentifier(pkg),
+			purl:       purl,
+		})
+	}
+
+	var facts documentFacts
+	creationInfo, _ := obj["creationInfo"].(map[string]interface{})
+	creators, _ := creationInfo["creators"].([]interface{})
+	for _, c := range creators {
+		creator, _ := c.(string)
+		switch {
+		case strings.HasPrefix(creator, "Tool:"):
+			facts.tool = true
+		case strings.HasPrefix(creator, "Person:"), strings.HasPrefix(creator, "Organization:"):
+			facts.author = true
+		}
+	}
+	created, _ := creationInfo["created"].(string)
+	namespace, _ := obj["documentNamespace"].(string)
+	facts.timestamp = !isBlank(created)
+	facts.primary = len(spdxDescribedIDs(obj)) > 0
+	facts.identifier = !isBlank(namespace)
+	return components, facts
+}
+
+// isSPDXLicenseAsserted reports whether an SPDX license field asserts a
+// license, i.e. is neither blank nor NOASSERTION.
+func isSPDXLicenseAsserted(license string) bool {
+	return !isBlank(license) && license != "NOASSERTION"
+}
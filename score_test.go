@@ -0,0 +1,133 @@
+package sbomvalidator
+
+import (
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	complete := []byte(`{
+  "bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1,
+  "serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+  "metadata": {
+    "timestamp": "2024-10-22T12:00:00Z",
+    "authors": [{"name": "Jane"}],
+    "tools": {"components": [{"type": "application", "name": "syft"}]},
+    "component": {"type": "application", "name": "app", "bom-ref": "app"}
+  },
+  "components": [
+    {"type": "library", "name": "a", "version": "1.0.0", "bom-ref": "a", "purl": "pkg:npm/a@1.0.0",
+     "supplier": {"name": "A Inc"}, "licenses": [{"license": {"id": "MIT"}}], "hashes": [{"alg": "SHA-256", "content": "00"}]},
+    {"type": "library", "name": "b", "version": "2.0.0", "bom-ref": "b", "purl": "pkg:npm/b@2.0.0",
+     "supplier": {"name": "B Inc"}, "licenses": [{"license": {"id": "MIT"}}], "hashes": [{"alg": "SHA-256", "content": "00"}]}
+  ],
+  "dependencies": [{"ref": "app", "dependsOn": ["a"]}, {"ref": "a", "dependsOn": ["b"]}]
+}`)
+
+	sparse := []byte(`{
+  "bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1,
+  "components": [
+    {"type": "library", "name": "a", "purl": "npm/a"},
+    {"type": "library", "name": "b", "version": "2.0.0", "purl": "pkg:npm/b@2.0.0"}
+  ]
+}`)
+
+	spdx := []byte(`{
+  "spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "name": "app",
+  "documentNamespace": "https://example.com/app",
+  "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: syft", "Organization: Example"]},
+  "documentDescribes": ["SPDXRef-app"],
+  "packages": [
+    {"SPDXID": "SPDXRef-app", "name": "app", "versionInfo": "1.0.0", "supplier": "Organization: Example",
+     "licenseDeclared": "MIT", "checksums": [{"algorithm": "SHA256", "checksumValue": "00"}],
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/app@1.0.0"}]},
+    {"SPDXID": "SPDXRef-a", "name": "a", "versionInfo": "1.0.0", "supplier": "NOASSERTION", "licenseDeclared": "NOASSERTION"}
+  ],
+  "relationships": [{"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-a"}]
+}`)
+
+	tests := []struct {
+		name           string
+		sbom           []byte
+		opts           []Option
+		wantScore      float64
+		wantGrade      string
+		wantDepth      int
+		wantCategories map[string]float64
+	}{
+		{
+			name: "Complete", sbom: complete, wantScore: 100, wantGrade: "A", wantDepth: 2,
+			wantCategories: map[string]float64{ScoreCompleteness: 100, ScoreIdentifiers: 100, ScoreDependencies: 100, ScoreMetadata: 100},
+		},
+		{
+			// completeness: name 100, version 50, others 0; identifiers: 100 and
+			// 50; dependencies: none
+			name: "Sparse", sbom: sparse, wantScore: 26.3, wantGrade: "F", wantDepth: 0,
+			wantCategories: map[string]float64{ScoreCompleteness: 30, ScoreIdentifiers: 75, ScoreDependencies: 0, ScoreMetadata: 0},
+		},
+		{
+			// completeness: 100, 100, 50, 50, 50; identifiers: 50 and 100;
+			// dependencies: 100 and 50; metadata: 100
+			name: "SPDX", sbom: spdx, wantScore: 80, wantGrade: "B", wantDepth: 1,
+			wantCategories: map[string]float64{ScoreCompleteness: 70, ScoreIdentifiers: 75, ScoreDependencies: 75, ScoreMetadata: 100},
+		},
+		{
+			// name, version, supplier, identifier, relationships, author and
+			// timestamp
+			name: "NTIA profile", sbom: spdx, opts: []Option{WithScoringProfile(NTIAScoringProfile)},
+			wantScore: 85.7, wantGrade: "B", wantDepth: 1,
+			wantCategories: map[string]float64{ScoreCompleteness: 83.3, ScoreIdentifiers: 50, ScoreDependencies: 100, ScoreMetadata: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Score(tt.sbom, tt.opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if report.Score != tt.wantScore || report.Grade != tt.wantGrade {
+				t.Errorf("Score() = %v (%s), want %v (%s); criteria %+v", report.Score, report.Grade, tt.wantScore, tt.wantGrade, report.Criteria)
+			}
+			if report.DependencyDepth != tt.wantDepth {
+				t.Errorf("DependencyDepth = %d, want %d", report.DependencyDepth, tt.wantDepth)
+			}
+			for _, c := range report.Categories {
+				if c.Score != tt.wantCategories[c.Category] {
+					t.Errorf("%s = %v, want %v", c.Category, c.Score, tt.wantCategories[c.Category])
+				}
+			}
+		})
+	}
+}
+
+func TestScoreErrors(t *testing.T) {
+	if _, err := Score([]byte(`{"name": "app"}`)); err == nil {
+		t.Error("expected an error for a document that is not an SBOM")
+	}
+}
+
+func TestParseScoringProfile(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		expectErr bool
+	}{
+		{name: "Valid", data: `{"name": "team", "weights": {"component-license": 2, "valid-purl": 1}}`},
+		{name: "Unknown criterion", data: `{"name": "team", "weights": {"stars": 1}}`, expectErr: true},
+		{name: "Negative weight", data: `{"name": "team", "weights": {"valid-purl": -1}}`, expectErr: true},
+		{name: "No weights", data: `{"name": "team", "weights": {"valid-purl": 0}}`, expectErr: true},
+		{name: "Malformed", data: `{`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := ParseScoringProfile([]byte(tt.data))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseScoringProfile() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if !tt.expectErr && profile.Weights[CriterionComponentLicense] != 2 {
+				t.Errorf("unexpected profile %+v", profile)
+			}
+		})
+	}
+}
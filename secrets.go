@@ -0,0 +1,127 @@
+package sbomvalidator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SecretPattern recognizes one kind of secret, such as a cloud access key or a
+// CI token, in the free text of an SBOM.
+type SecretPattern struct {
+	// Name describes the secret in findings (e.g., "GitHub token").
+	Name string
+	// Pattern matches the secret itself.
+	Pattern *regexp.Regexp
+}
+
+// DefaultSecretPatterns are the patterns used when WithSecretScanning is
+// given none. They match credentials with a recognizable shape, so that
+// digests and identifiers are not mistaken for secrets.
+var DefaultSecretPatterns = []SecretPattern{
+	{Name: "AWS access key ID", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "GitHub token", Pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b|\bgithub_pat_[A-Za-z0-9_]{22,}`)},
+	{Name: "GitLab token", Pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{Name: "Slack token", Pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{Name: "npm token", Pattern: regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`)},
+	{Name: "JSON Web Token", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{Name: "private key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{Name: "password in URL", Pattern: regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^/\s:@]+:[^/\s@]+@`)},
+}
+
+// ParseSecretPatterns reads secret patterns, one per line in the form
+// "name = regular expression". Blank lines and lines starting with "#" are
+// ignored.
+//
+// Parameters:
+//   - data: The pattern definitions.
+//
+// Returns:
+//   - The patterns, in the order they were defined.
+//   - An error naming the line of a malformed definition or invalid expression.
+//
+// Example:
+//
+//	data, _ := os.ReadFile("secret-patterns.txt")
+//	patterns, err := ParseSecretPatterns(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result, err := ValidateSBOMData(jsonData, WithSecretScanning(patterns...))
+func ParseSecretPatterns(data []byte) ([]SecretPattern, error) {
+	var patterns []SecretPattern
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, expr, ok := strings.Cut(text, "=")
+		name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+		if !ok || name == "" || expr == "" {
+			return nil, fmt.Errorf("line %d: expected \"name = regular expression\"", line)
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		patterns = append(patterns, SecretPattern{Name: name, Pattern: pattern})
+	}
+	return patterns, scanner.Err()
+}
+
+// checkSecrets reports the string values of a parsed SBOM that contain a
+// secret. Every value is scanned, since generators leak tokens into
+// descriptions, properties and URLs alike. The secret is left out of the
+// message so that reports do not spread it further.
+func checkSecrets(obj map[string]interface{}, patterns []SecretPattern) []Finding {
+	var findings []Finding
+	walkStrings("", obj, func(path, value string) {
+		for _, pattern := range patterns {
+			if !pattern.Pattern.MatchString(value) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Level:   LevelError,
+				Rule:    RuleSecret,
+				Path:    path,
+				Pointer: jsonPointer(path),
+				Message: fmt.Sprintf("contains what looks like a %s; remove it and revoke the credential", pattern.Name),
+			})
+		}
+	})
+	return findings
+}
+
+// walkStrings calls fn with the dotted path of every string in a JSON value,
+// visiting object keys in sorted order.
+func walkStrings(path string, value interface{}, fn func(path, value string)) {
+	child := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := value.(type) {
+	case string:
+		fn(path, v)
+	case []interface{}:
+		for i, item := range v {
+			walkStrings(child(strconv.Itoa(i)), item, fn)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkStrings(child(key), v[key], fn)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// resultCache keeps the results of the documents most recently validated on
+// a channel, by ETag, so that clients polling with the same SBOM are answered
+// without validating it again. A nil cache keeps nothing.
+type resultCache struct {
+	size  int
+	ttl   time.Duration
+	clock sbomvalidator.Clock
+
+	mu      sync.Mutex
+	order   *list.List // of *cachedResult, most recently used first
+	entries map[string]*list.Element
+}
+
+type cachedResult struct {
+	etag    string
+	result  *sbomvalidator.ValidationResult
+	expires time.Time
+}
+
+// newResultCache returns a cache of size results, each kept for ttl, or nil
+// if size is negative. cfg has its defaults applied.
+func newResultCache(cfg Config) *resultCache {
+	if cfg.CacheSize < 0 {
+		return nil
+	}
+	return &resultCache{
+		size:    cfg.CacheSize,
+		ttl:     cfg.CacheTTL,
+		clock:   cfg.Clock,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the result cached for an ETag, if it has not expired.
+func (c *resultCache) get(etag string) (*sbomvalidator.ValidationResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[etag]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*cachedResult)
+	if !c.clock.Now().Before(entry.expires) {
+		c.order.Remove(e)
+		delete(c.entries, etag)
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return entry.result, true
+}
+
+// put caches the result for an ETag, evicting the least recently used
+// result when the cache is full.
+func (c *resultCache) put(etag string, result *sbomvalidator.ValidationResult) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cachedResult{etag: etag, result: result, expires: c.clock.Now().Add(c.ttl)}
+	if e, ok := c.entries[etag]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[etag] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResult).etag)
+	}
+}
+
+// documentETag returns the strong ETag of a submitted document: the SHA256
+// digest of its content.
+func documentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"sha256:` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as RFC 9110 requires, or is "*".
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
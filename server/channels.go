@@ -0,0 +1,303 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// notifyTimeout bounds the delivery of a notification.
+const notifyTimeout = 30 * time.Second
+
+// defaultChannelName names the default endpoints in quarantine names.
+const defaultChannelName = "default"
+
+// submissionSeq makes submission IDs created in the same instant unique.
+var submissionSeq atomic.Uint64
+
+// PolicyPack is a named set of validation options that a channel applies to
+// the SBOMs submitted on it.
+type PolicyPack struct {
+	Name    string
+	Options []sbomvalidator.Option
+}
+
+// Built-in policy packs.
+var (
+	// StrictPolicy suits supplier-facing intake: SBOMs must declare a known
+	// spec version, pass every NTIA quality check, use well-formed license
+	// expressions and contain no secrets, and warnings make them invalid.
+	StrictPolicy = PolicyPack{Name: "strict", Options: []sbomvalidator.Option{
+		sbomvalidator.WithVersionMode(sbomvalidator.VersionStrict),
+		sbomvalidator.WithQualityChecks(sbomvalidator.QualityChecks()...),
+		sbomvalidator.WithLicenseValidation(true),
+		sbomvalidator.WithSecretScanning(),
+		sbomvalidator.WithValidationOptions(sbomvalidator.ValidationOptions{FailOnWarnings: true}),
+	}}
+	// LenientPolicy suits internal development intake: SBOMs of newer spec
+	// versions are validated against the closest schema, and schema format
+	// violations are warnings.
+	LenientPolicy = PolicyPack{Name: "lenient", Options: []sbomvalidator.Option{
+		sbomvalidator.WithVersionMode(sbomvalidator.VersionLenient),
+		sbomvalidator.WithFormatAssertion(false),
+	}}
+)
+
+// PolicyPacks are the built-in policy packs by name, for configuration files
+// (see ParseChannels).
+var PolicyPacks = map[string]PolicyPack{
+	StrictPolicy.Name:  StrictPolicy,
+	LenientPolicy.Name: LenientPolicy,
+}
+
+// Channel is a submission channel: an intake with its own policy, such as a
+// supplier-facing portal or an internal CI intake, served from the same
+// deployment as others. A channel named "suppliers" serves
+// /v1/channels/suppliers/validate, /validate/bulk and /validate/stream.
+type Channel struct {
+	// Name identifies the channel in its URLs.
+	Name string
+	// Policy is applied to every SBOM submitted on the channel, after
+	// Config.Options.
+	Policy PolicyPack
+	// Formats are the SBOM formats accepted (e.g., `sbomvalidator.SBOM_CYCLONEDX`);
+	// others fail validation. Empty accepts every format.
+	Formats []string
+	// Notifiers are told of the outcome of every SBOM submitted on the
+	// channel.
+	Notifiers []Notifier
+	// Quarantine, if set, stores the SBOMs submitted on the channel that
+	// fail validation, so that they can be reviewed.
+	Quarantine sbomvalidator.Quarantine
+}
+
+// Submission is the outcome of validating an SBOM submitted on a channel.
+// Exactly one of Result and Error is set.
+type Submission struct {
+	Channel     string                          `json:"channel"`
+	Name        string                          `json:"name"`
+	ValidatedAt time.Time                       `json:"validatedAt"`
+	Result      *sbomvalidator.ValidationResult `json:"result,omitempty"`
+	Error       string                          `json:"error,omitempty"`
+	Quarantined bool                            `json:"quarantined,omitempty"`
+}
+
+// Notifier is told of submissions, e.g., to alert the team that owns a
+// supplier relationship. Notify is called in its own goroutine once the SBOM
+// is validated, and does not delay the response; failures are logged.
+type Notifier interface {
+	Notify(ctx context.Context, submission Submission) error
+}
+
+// WebhookNotifier posts each submission as JSON to a URL.
+type WebhookNotifier struct {
+	URL string
+	// Header is added to every request, e.g., for an Authorization token.
+	Header http.Header
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Notify posts the submission and fails unless the response status is 2xx.
+func (n *WebhookNotifier) Notify(ctx context.Context, submission Submission) error {
+	body, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range n.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s returned %s", n.URL, resp.Status)
+	}
+	return nil
+}
+
+// ChannelConfig is the configuration file form of a Channel.
+type ChannelConfig struct {
+	Name string `json:"name"`
+	// Policy names a built-in policy pack (see PolicyPacks); empty applies
+	// none.
+	Policy   string   `json:"policy,omitempty"`
+	Formats  []string `json:"formats,omitempty"`
+	Webhooks []string `json:"webhooks,omitempty"`
+	// Quarantine is a directory to store SBOMs that fail validation in.
+	Quarantine string `json:"quarantine,omitempty"`
+}
+
+// ParseChannels reads channels from a JSON array of ChannelConfig, notifying
+// each channel's webhooks with a WebhookNotifier and quarantining to a
+// `sbomvalidator.DirQuarantine`.
+//
+// Parameters:
+//   - data: The JSON configuration.
+//
+// Returns:
+//   - The channels, in the order they are configured.
+//   - An error if the JSON is malformed, a channel has no or a duplicate name,
+//     or a policy is unknown.
+//
+// Example:
+//
+//	data, _ := os.ReadFile("channels.json")
+//	channels, err := server.ParseChannels(data)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	s := server.New(server.Config{Channels: channels})
+func ParseChannels(data []byte) ([]Channel, error) {
+	var configs []ChannelConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("invalid channel configuration: %v", err)
+	}
+
+	channels := make([]Channel, 0, len(configs))
+	seen := map[string]bool{}
+	for _, config := range configs {
+		if config.Name == "" || strings.Contains(config.Name, "/") {
+			return nil, fmt.Errorf("invalid channel name %q", config.Name)
+		}
+		if seen[config.Name] {
+			return nil, fmt.Errorf("duplicate channel %q", config.Name)
+		}
+		seen[config.Name] = true
+
+		ch := Channel{Name: config.Name, Formats: config.Formats}
+		if config.Policy != "" {
+			policy, ok := PolicyPacks[config.Policy]
+			if !ok {
+				return nil, fmt.Errorf("channel %q: unknown policy %q", config.Name, config.Policy)
+			}
+			ch.Policy = policy
+		}
+		for _, url := range config.Webhooks {
+			ch.Notifiers = append(ch.Notifiers, &WebhookNotifier{URL: url})
+		}
+		if config.Quarantine != "" {
+			ch.Quarantine = sbomvalidator.DirQuarantine{Dir: config.Quarantine}
+		}
+		channels = append(channels, ch)
+	}
+	return channels, nil
+}
+
+// channel is a Channel, or the default endpoints, ready to serve.
+type channel struct {
+	name       string
+	options    []sbomvalidator.Option
+	notifiers  []Notifier
+	quarantine sbomvalidator.Quarantine
+	logger     *slog.Logger
+	clock      sbomvalidator.Clock
+	cache      *resultCache
+}
+
+// newChannel returns a channel validating with the server's options followed
+// by the channel's. cfg has its defaults applied.
+func newChannel(ch Channel, cfg Config) *channel {
+	options := append(append([]sbomvalidator.Option(nil), cfg.Options...), ch.Policy.Options...)
+	if len(ch.Formats) > 0 {
+		options = append(options, sbomvalidator.WithFormats(ch.Formats...))
+	}
+	return &channel{name: ch.Name, options: options, notifiers: ch.Notifiers, quarantine: ch.Quarantine, logger: cfg.Logger, clock: cfg.Clock, cache: newResultCache(cfg)}
+}
+
+// quarantineFile quarantines a file that did not pass validation, unless it
+// was abandoned because ctx is done. Failures are logged.
+func (ch *channel) quarantineFile(ctx context.Context, content []byte, result *FileResult) {
+	if ch.quarantine == nil || ctx.Err() != nil || (result.Result != nil && result.Result.IsValid) {
+		return
+	}
+	name := ch.name
+	if name == "" {
+		name = defaultChannelName
+	}
+
+	report, err := json.MarshalIndent(result, "", "  ")
+	if err == nil {
+		id := fmt.Sprintf("%s-%d", ch.clock.Now().UTC().Format("20060102T150405.000000000Z"), submissionSeq.Add(1))
+		err = ch.quarantine.Quarantine(ctx, sbomvalidator.QuarantinedSBOM{Name: path.Join(name, id, path.Clean("/"+result.Name)), Content: content, Report: report})
+	}
+	if err != nil {
+		ch.logger.ErrorContext(ctx, "failed to quarantine", "channel", name, "file", result.Name, "error", err)
+		return
+	}
+	result.Quarantined = true
+}
+
+// notify hands the outcome of a file to the channel's notifiers.
+func (ch *channel) notify(result FileResult) {
+	if len(ch.notifiers) == 0 {
+		return
+	}
+	submission := Submission{
+		Channel:     ch.name,
+		Name:        result.Name,
+		ValidatedAt: ch.clock.Now().UTC(),
+		Result:      result.Result,
+		Error:       result.Error,
+		Quarantined: result.Quarantined,
+	}
+	for _, notifier := range ch.notifiers {
+		go func(notifier Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := notifier.Notify(ctx, submission); err != nil {
+				ch.logger.ErrorContext(ctx, "failed to notify", "channel", ch.name, "file", submission.Name, "error", err)
+			}
+		}(notifier)
+	}
+}
+
+// channelHandler serves an endpoint for a channel.
+func channelHandler(ch *channel, handle func(http.ResponseWriter, *http.Request, *channel)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handle(w, r, ch)
+	}
+}
+
+// handleChannel routes /v1/channels/<name>/<endpoint> to the endpoint of the
+// named channel.
+func (s *Server) handleChannel(w http.ResponseWriter, r *http.Request) {
+	name, endpoint, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/channels/"), "/")
+	ch, ok := s.channels[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown channel %q", name))
+		return
+	}
+
+	switch endpoint {
+	case "validate":
+		s.handleValidate(w, r, ch)
+	case "validate/bulk":
+		s.handleBulk(w, r, ch)
+	case "validate/stream":
+		s.handleStream(w, r, ch)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown endpoint %q", endpoint))
+	}
+}
@@ -0,0 +1,168 @@
+//go:build !sbomvalidator_no_spdx
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+type recordingNotifier struct {
+	submissions chan Submission
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, submission Submission) error {
+	n.submissions <- submission
+	return nil
+}
+
+func TestChannels(t *testing.T) {
+	notifier := &recordingNotifier{submissions: make(chan Submission, 1)}
+	s := New(Config{Channels: []Channel{
+		{Name: "suppliers", Policy: StrictPolicy, Formats: []string{sbomvalidator.SBOM_CYCLONEDX}, Notifiers: []Notifier{notifier}},
+		{Name: "dev", Policy: LenientPolicy},
+	}})
+	defer s.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "Default endpoint", path: "/v1/validate", wantStatus: http.StatusOK},
+		{name: "Lenient channel", path: "/v1/channels/dev/validate", wantStatus: http.StatusOK},
+		{name: "Format not accepted", path: "/v1/channels/suppliers/validate", wantStatus: http.StatusUnprocessableEntity},
+		{name: "Unknown channel", path: "/v1/channels/partners/validate", wantStatus: http.StatusNotFound},
+		{name: "Unknown endpoint", path: "/v1/channels/dev/lint", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(validSPDX))
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body = %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	select {
+	case submission := <-notifier.submissions:
+		if submission.Channel != "suppliers" || submission.Error == "" || submission.Result != nil {
+			t.Errorf("unexpected submission: %+v", submission)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel's notifier was not called")
+	}
+}
+
+type recordingQuarantine struct {
+	sboms []sbomvalidator.QuarantinedSBOM
+}
+
+func (q *recordingQuarantine) Quarantine(ctx context.Context, sbom sbomvalidator.QuarantinedSBOM) error {
+	q.sboms = append(q.sboms, sbom)
+	return nil
+}
+
+func TestQuarantine(t *testing.T) {
+	quarantine := &recordingQuarantine{}
+	notifier := &recordingNotifier{submissions: make(chan Submission, 1)}
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := New(Config{Workers: 1, Quarantine: quarantine, Clock: sbomvalidator.FixedClock(at), Channels: []Channel{
+		{Name: "suppliers", Formats: []string{sbomvalidator.SBOM_CYCLONEDX}, Notifiers: []Notifier{notifier}, Quarantine: quarantine},
+	}})
+	defer s.Close()
+
+	for _, path := range []string{"/v1/validate", "/v1/channels/suppliers/validate"} {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(validSPDX))
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(quarantine.sboms) != 1 {
+		t.Fatalf("quarantined %d SBOMs, want the one rejected by the channel", len(quarantine.sboms))
+	}
+	sbom := quarantine.sboms[0]
+	if !strings.HasPrefix(sbom.Name, "suppliers/20250101T120000.000000000Z-") || !strings.HasSuffix(sbom.Name, "/body") || string(sbom.Content) != validSPDX {
+		t.Errorf("unexpected quarantined SBOM %q: %s", sbom.Name, sbom.Content)
+	}
+	var report FileResult
+	if err := json.Unmarshal(sbom.Report, &report); err != nil || report.Error == "" {
+		t.Errorf("report = %s, %v; want the failed result", sbom.Report, err)
+	}
+	if submission := <-notifier.submissions; !submission.Quarantined || !submission.ValidatedAt.Equal(at) {
+		t.Errorf("submission does not report the quarantine: %+v", submission)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan Submission, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var submission Submission
+		json.NewDecoder(r.Body).Decode(&submission)
+		received <- submission
+	}))
+	defer hook.Close()
+
+	notifier := &WebhookNotifier{URL: hook.URL, Header: http.Header{"Authorization": {"Bearer secret"}}}
+	if err := notifier.Notify(context.Background(), Submission{Channel: "suppliers", Name: "app.cdx.json"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if submission := <-received; submission.Name != "app.cdx.json" {
+		t.Errorf("unexpected submission: %+v", submission)
+	}
+
+	unauthorized := &WebhookNotifier{URL: hook.URL}
+	if err := unauthorized.Notify(context.Background(), Submission{}); err == nil {
+		t.Error("expected an error for a 401 response")
+	}
+}
+
+func TestParseChannels(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name: "Valid",
+			config: `[{"name": "suppliers", "policy": "strict", "formats": ["CycloneDX"], "webhooks": ["https://hooks.example.com/sbom"],
+				"quarantine": "/var/quarantine"},
+				{"name": "dev", "policy": "lenient"}]`,
+		},
+		{name: "Unknown policy", config: `[{"name": "suppliers", "policy": "paranoid"}]`, wantErr: true},
+		{name: "Duplicate name", config: `[{"name": "dev"}, {"name": "dev"}]`, wantErr: true},
+		{name: "Missing name", config: `[{"policy": "strict"}]`, wantErr: true},
+		{name: "Malformed", config: `{"name": "dev"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channels, err := ParseChannels([]byte(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(channels) != 2 || channels[0].Policy.Name != "strict" || len(channels[0].Notifiers) != 1 || channels[1].Policy.Name != "lenient" ||
+				channels[0].Quarantine != (sbomvalidator.DirQuarantine{Dir: "/var/quarantine"}) || channels[1].Quarantine != nil {
+				t.Errorf("unexpected channels: %+v", channels)
+			}
+		})
+	}
+}
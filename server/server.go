@@ -0,0 +1,545 @@
+// Package server exposes sbom-validator over HTTP.
+//
+// Three endpoints are served:
+//
+//   - POST /v1/validate validates the SBOM in the request body.
+//   - POST /v1/validate/bulk validates every SBOM in a multipart/form-data or
+//     application/zip request body and returns one result per file.
+//   - POST /v1/validate/stream validates the SBOM in the request body and
+//     streams its findings as Server-Sent Events while it is validated (see
+//     handleStream).
+//
+// Each submission channel (see Channel) serves the same endpoints under
+// /v1/channels/<name>/, with its own policy pack, accepted formats and
+// notification targets.
+//
+// Files are validated by a fixed pool of workers fed from a bounded queue. A
+// bulk request reserves queue space for all of its files up front; when the
+// queue cannot take them the request is rejected with 429 Too Many Requests
+// and a Retry-After header, so CI systems back off instead of piling up work.
+//
+// With a quarantine (see Config.Quarantine and Channel.Quarantine), SBOMs
+// that are invalid or cannot be validated are stored with their FileResult as
+// report, under "<channel>/<submission ID>/<file name>", before the response
+// is written.
+//
+// Validation is bound to the request's context, so the files of a request
+// whose client disconnects are abandoned, and to Config.Timeout, if set.
+//
+// Results of /v1/validate carry an ETag computed from the digest of the
+// submitted document, and are cached per channel (see Config.CacheSize). A
+// request whose If-None-Match lists the ETag of a cached result is answered
+// with 304 Not Modified, and one without it with the cached result, so CI
+// clients polling with the same SBOM are not revalidated, quarantined or
+// notified again.
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// Defaults for Config fields left at zero.
+const (
+	DefaultQueueSize    = 256
+	DefaultMaxBodyBytes = 256 << 20
+	DefaultRetryAfter   = 5 * time.Second
+	DefaultCacheSize    = 1024
+	DefaultCacheTTL     = 10 * time.Minute
+)
+
+// errQueueFull is returned when the queue has no room for a request.
+var errQueueFull = errors.New("validation queue is full")
+
+// Config configures a Server.
+type Config struct {
+	// Workers is the number of SBOMs validated concurrently. Defaults to the
+	// number of CPUs.
+	Workers int
+	// QueueSize is the number of files that may be queued or in progress at
+	// once, across all requests.
+	QueueSize int
+	// MaxBodyBytes caps the size of a request body and, for the bulk
+	// endpoint, the total size of its files once decompressed.
+	MaxBodyBytes int64
+	// RetryAfter is advertised to clients rejected with 429.
+	RetryAfter time.Duration
+	// Timeout bounds the validation of each file; a file that takes longer
+	// fails with a deadline error. Zero means no limit.
+	Timeout time.Duration
+	// CacheSize is the number of /v1/validate results cached per channel,
+	// by ETag. Negative disables the cache, ETags and If-None-Match.
+	CacheSize int
+	// CacheTTL is how long a result is cached; a document submitted after
+	// its result expired is validated again.
+	CacheTTL time.Duration
+	// Options are passed to `sbomvalidator.ValidateSBOMDataContext` for every
+	// file.
+	Options []sbomvalidator.Option
+	// Channels are the submission channels served besides the default
+	// endpoints. Their names must be unique.
+	Channels []Channel
+	// Quarantine, if set, stores the SBOMs submitted to the default
+	// endpoints that fail validation.
+	Quarantine sbomvalidator.Quarantine
+	// Logger receives failures to quarantine files or notify of them, and
+	// the diagnostics of validation (see `sbomvalidator.WithLogger`).
+	// Defaults to slog.Default().
+	Logger *slog.Logger
+	// Clock stamps quarantined files and notifications, and is the clock of
+	// validation (see `sbomvalidator.WithClock`). Defaults to
+	// `sbomvalidator.SystemClock`.
+	Clock sbomvalidator.Clock
+}
+
+// FileResult is the outcome of validating one file of a bulk request. Exactly
+// one of Result and Error is set. Quarantined reports whether the file was
+// quarantined.
+type FileResult struct {
+	Name        string                          `json:"name"`
+	Result      *sbomvalidator.ValidationResult `json:"result,omitempty"`
+	Error       string                          `json:"error,omitempty"`
+	Quarantined bool                            `json:"quarantined,omitempty"`
+}
+
+// BulkResponse is the body returned by the bulk endpoint. Results are in the
+// order the files appeared in the request.
+type BulkResponse struct {
+	Results []FileResult `json:"results"`
+	Valid   int          `json:"valid"`
+	Invalid int          `json:"invalid"`
+	Failed  int          `json:"failed"`
+}
+
+type job struct {
+	ctx      context.Context
+	channel  *channel
+	name     string
+	content  []byte
+	result   *FileResult
+	done     *sync.WaitGroup
+	progress func(sbomvalidator.ProgressEvent)
+}
+
+// Server is an http.Handler validating SBOMs. Create it with New and release
+// its workers with Close.
+type Server struct {
+	cfg      Config
+	jobs     chan job
+	mux      *http.ServeMux
+	base     *channel
+	channels map[string]*channel
+
+	mu      sync.Mutex
+	pending int
+
+	closeOnce sync.Once
+}
+
+// New starts the workers of a Server configured by cfg.
+func New(cfg Config) *Server {
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = DefaultRetryAfter
+	}
+	if cfg.CacheSize == 0 {
+		cfg.CacheSize = DefaultCacheSize
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = DefaultCacheTTL
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = sbomvalidator.SystemClock
+	}
+	// first, so that Options may replace them
+	cfg.Options = append([]sbomvalidator.Option{sbomvalidator.WithLogger(cfg.Logger), sbomvalidator.WithClock(cfg.Clock)}, cfg.Options...)
+
+	s := &Server{
+		cfg:      cfg,
+		jobs:     make(chan job, cfg.QueueSize),
+		mux:      http.NewServeMux(),
+		base:     &channel{options: cfg.Options, quarantine: cfg.Quarantine, logger: cfg.Logger, clock: cfg.Clock, cache: newResultCache(cfg)},
+		channels: make(map[string]*channel, len(cfg.Channels)),
+	}
+	for _, ch := range cfg.Channels {
+		s.channels[ch.Name] = newChannel(ch, cfg)
+	}
+	s.mux.HandleFunc("/v1/validate", channelHandler(s.base, s.handleValidate))
+	s.mux.HandleFunc("/v1/validate/bulk", channelHandler(s.base, s.handleBulk))
+	s.mux.HandleFunc("/v1/validate/stream", channelHandler(s.base, s.handleStream))
+	s.mux.HandleFunc("/v1/channels/", s.handleChannel)
+
+	for i := 0; i < cfg.Workers; i++ {
+		go s.worker()
+	}
+	return s
+}
+
+// Close stops the workers. Requests must not be served after Close.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.jobs) })
+}
+
+// ServeHTTP routes a request to its endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) worker() {
+	for j := range s.jobs {
+		result, err := s.validate(j)
+		if err != nil {
+			j.result.Error = err.Error()
+		} else {
+			j.result.Result = result
+		}
+		j.channel.quarantineFile(j.ctx, j.content, j.result)
+		j.channel.notify(*j.result)
+
+		s.mu.Lock()
+		s.pending--
+		s.mu.Unlock()
+		j.done.Done()
+	}
+}
+
+// validate validates the file of a job within its context and the
+// configured timeout.
+func (s *Server) validate(j job) (*sbomvalidator.ValidationResult, error) {
+	ctx := j.ctx
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+	opts := j.channel.options
+	if j.progress != nil {
+		opts = append(opts[:len(opts):len(opts)], sbomvalidator.WithProgress(j.progress))
+	}
+	return sbomvalidator.ValidateSBOMDataContext(ctx, j.content, opts...)
+}
+
+// reserve claims queue space for n files, or fails without claiming any.
+func (s *Server) reserve(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending+n > s.cfg.QueueSize {
+		return errQueueFull
+	}
+	s.pending += n
+	return nil
+}
+
+// validateAll queues the files and waits for all of their results. Files
+// still queued when ctx is done fail without being validated.
+func (s *Server) validateAll(ctx context.Context, ch *channel, names []string, contents [][]byte) ([]FileResult, error) {
+	if err := s.reserve(len(names)); err != nil {
+		return nil, err
+	}
+
+	results := make([]FileResult, len(names))
+	var done sync.WaitGroup
+	done.Add(len(names))
+	for i := range names {
+		results[i].Name = names[i]
+		// space was reserved, so this never blocks
+		s.jobs <- job{ctx: ctx, channel: ch, name: names[i], content: contents[i], result: &results[i], done: &done}
+	}
+	done.Wait()
+
+	return results, nil
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request, ch *channel) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	etag := documentETag(content)
+	if result, ok := ch.cache.get(etag); ok {
+		setCacheHeaders(w, etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+
+	results, err := s.validateAll(r.Context(), ch, []string{"body"}, [][]byte{content})
+	if err != nil {
+		s.writeBusy(w)
+		return
+	}
+
+	if results[0].Error != "" {
+		writeError(w, http.StatusUnprocessableEntity, results[0].Error)
+		return
+	}
+	if ch.cache != nil {
+		ch.cache.put(etag, results[0].Result)
+		setCacheHeaders(w, etag)
+	}
+	writeJSON(w, http.StatusOK, results[0].Result)
+}
+
+// setCacheHeaders sets the ETag of a result, and asks clients to revalidate
+// it on every use.
+func setCacheHeaders(w http.ResponseWriter, etag string) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+}
+
+// handleStream validates the SBOM in the request body like handleValidate,
+// but responds with a text/event-stream as soon as the SBOM is queued: a
+// "progress" event for each validation stage as it finishes, carrying a JSON
+// `sbomvalidator.ProgressEvent`, then either a "result" event with the
+// ValidationResult or an "error" event with {"error": "..."}.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request, ch *channel) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	if err := s.reserve(1); err != nil {
+		s.writeBusy(w)
+		return
+	}
+
+	events := make(chan sbomvalidator.ProgressEvent)
+	result := FileResult{Name: "body"}
+	var done sync.WaitGroup
+	done.Add(1)
+	// space was reserved, so this never blocks
+	s.jobs <- job{
+		ctx:      r.Context(),
+		channel:  ch,
+		name:     result.Name,
+		content:  content,
+		result:   &result,
+		done:     &done,
+		progress: func(event sbomvalidator.ProgressEvent) { events <- event },
+	}
+	// progress is only called before the job is done
+	go func() {
+		done.Wait()
+		close(events)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for event := range events {
+		writeEvent(w, "progress", event)
+		flusher.Flush()
+	}
+	if result.Error != "" {
+		writeEvent(w, "error", map[string]string{"error": result.Error})
+	} else {
+		writeEvent(w, "result", result.Result)
+	}
+	flusher.Flush()
+}
+
+func (s *Server) handleBulk(w http.ResponseWriter, r *http.Request, ch *channel) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+
+	names, contents, err := s.readBundle(w, r)
+	var tooLarge *bundleTooLargeError
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge), errors.As(err, &maxBytes):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case len(names) == 0:
+		writeError(w, http.StatusBadRequest, "no files in request")
+		return
+	}
+
+	results, err := s.validateAll(r.Context(), ch, names, contents)
+	if err != nil {
+		s.writeBusy(w)
+		return
+	}
+
+	response := BulkResponse{Results: results}
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			response.Failed++
+		case result.Result.IsValid:
+			response.Valid++
+		default:
+			response.Invalid++
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// bundleTooLargeError is returned by readBundle for a request over the
+// limits on the number or total size of its files.
+type bundleTooLargeError struct {
+	message string
+}
+
+func (e *bundleTooLargeError) Error() string {
+	return e.message
+}
+
+// readBundle extracts the files of a multipart/form-data or application/zip
+// request body. A request may hold at most QueueSize files, which may add up
+// to at most MaxBodyBytes, also once decompressed from a zip archive; reading
+// stops as soon as either limit is exceeded.
+func (s *Server) readBundle(w http.ResponseWriter, r *http.Request) ([]string, [][]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes)
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid Content-Type: %v", err)
+	}
+
+	var names []string
+	var contents [][]byte
+	var size int64
+	add := func(name string, f io.Reader) error {
+		if len(names) == s.cfg.QueueSize {
+			return &bundleTooLargeError{fmt.Sprintf("request has more than %d files", s.cfg.QueueSize)}
+		}
+		content, err := io.ReadAll(io.LimitReader(f, s.cfg.MaxBodyBytes-size+1))
+		if err != nil {
+			return err
+		}
+		size += int64(len(content))
+		if size > s.cfg.MaxBodyBytes {
+			return &bundleTooLargeError{fmt.Sprintf("request files exceed %d bytes in total", s.cfg.MaxBodyBytes)}
+		}
+		names = append(names, name)
+		contents = append(contents, content)
+		return nil
+	}
+
+	switch mediaType {
+	case "multipart/form-data":
+		reader, err := r.MultipartReader()
+		if err != nil {
+			return nil, nil, err
+		}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if part.FileName() == "" {
+				continue
+			}
+			if err := add(part.FileName(), part); err != nil {
+				return nil, nil, err
+			}
+		}
+
+	case "application/zip":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid zip archive: %v", err)
+		}
+		for _, file := range archive.File {
+			if file.FileInfo().IsDir() {
+				continue
+			}
+			f, err := file.Open()
+			if err != nil {
+				return nil, nil, err
+			}
+			err = add(file.Name, f)
+			f.Close()
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported Content-Type %q", mediaType)
+	}
+
+	return names, contents, nil
+}
+
+func (s *Server) writeBusy(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int((s.cfg.RetryAfter+time.Second-1)/time.Second)))
+	writeError(w, http.StatusTooManyRequests, errQueueFull.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeEvent writes a Server-Sent Event whose data is v as JSON, which never
+// spans lines.
+func writeEvent(w io.Writer, name string, v interface{}) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,323 @@
+//go:build !sbomvalidator_no_spdx
+
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+const validSPDX = `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "test",
+  "documentNamespace": "https://example.com/test",
+  "creationInfo": {"created": "2024-10-22T12:00:00Z", "creators": ["Tool: test"]}
+}`
+
+func multipartBody(t *testing.T, files map[string]string, order []string) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, name := range order {
+		part, err := writer.CreateFormFile("sbom", name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		part.Write([]byte(files[name]))
+	}
+	writer.Close()
+	return &buf, writer.FormDataContentType()
+}
+
+func zipBody(t *testing.T, files map[string]string, order []string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, name := range order {
+		f, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		f.Write([]byte(files[name]))
+	}
+	writer.Close()
+	return &buf
+}
+
+func TestBulk(t *testing.T) {
+	files := map[string]string{
+		"good.spdx.json":   validSPDX,
+		"bad.spdx.json":    `{"spdxVersion": "SPDX-2.3"}`,
+		"broken.spdx.json": `not json`,
+	}
+	order := []string{"good.spdx.json", "bad.spdx.json", "broken.spdx.json"}
+
+	multipartData, multipartType := multipartBody(t, files, order)
+
+	tests := []struct {
+		name        string
+		body        *bytes.Buffer
+		contentType string
+	}{
+		{name: "Multipart", body: multipartData, contentType: multipartType},
+		{name: "Zip", body: zipBody(t, files, order), contentType: "application/zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(Config{Workers: 2})
+			defer s.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/validate/bulk", tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+			}
+
+			var response BulkResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(response.Results) != len(order) {
+				t.Fatalf("got %d results, want %d", len(response.Results), len(order))
+			}
+			for i, name := range order {
+				if response.Results[i].Name != name {
+					t.Errorf("result %d is %q, want %q", i, response.Results[i].Name, name)
+				}
+			}
+			if response.Valid != 1 || response.Invalid != 1 || response.Failed != 1 {
+				t.Errorf("unexpected counts: %+v", response)
+			}
+		})
+	}
+}
+
+func TestBulkBackpressure(t *testing.T) {
+	s := New(Config{Workers: 1, QueueSize: 2})
+	defer s.Close()
+
+	// simulate another request holding the queue
+	if err := s.reserve(2); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	files := map[string]string{"a.spdx.json": validSPDX}
+	body, contentType := multipartBody(t, files, []string{"a.spdx.json"})
+	req := httptest.NewRequest(http.MethodPost, "/v1/validate/bulk", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "5" {
+		t.Errorf("Retry-After = %q, want 5", rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestBulkTooLarge(t *testing.T) {
+	files := map[string]string{"a.json": validSPDX, "b.json": validSPDX, "big.json": strings.Repeat(" ", 4096)}
+	multipart, multipartType := multipartBody(t, files, []string{"a.json", "b.json"})
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		body        *bytes.Buffer
+		contentType string
+		wantError   string
+	}{
+		{name: "Too many files", cfg: Config{QueueSize: 1}, body: multipart, contentType: multipartType,
+			wantError: "request has more than 1 files"},
+		{name: "Too many zip entries", cfg: Config{QueueSize: 1}, body: zipBody(t, files, []string{"a.json", "b.json"}), contentType: "application/zip",
+			wantError: "request has more than 1 files"},
+		// the zip is smaller than the limit, but not its files
+		{name: "Too large once decompressed", cfg: Config{MaxBodyBytes: 1024}, body: zipBody(t, files, []string{"big.json"}), contentType: "application/zip",
+			wantError: "request files exceed 1024 bytes in total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Workers = 1
+			s := New(tt.cfg)
+			defer s.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/validate/bulk", tt.body)
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), tt.wantError) {
+				t.Errorf("response = %d %s, want 413 %q", rec.Code, rec.Body, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := New(Config{})
+	defer s.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/validate", bytes.NewBufferString(validSPDX))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		IsValid bool `json:"isValid"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if !result.IsValid {
+		t.Errorf("expected a valid result, got %s", rec.Body.String())
+	}
+}
+
+func TestValidateETag(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var validations atomic.Int32
+	s := New(Config{
+		Clock: sbomvalidator.ClockFunc(func() time.Time { return now }),
+		Options: []sbomvalidator.Option{sbomvalidator.WithProgress(func(event sbomvalidator.ProgressEvent) {
+			if event.Stage == sbomvalidator.StageSchema {
+				validations.Add(1)
+			}
+		})},
+	})
+	defer s.Close()
+
+	post := func(body, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/validate", bytes.NewBufferString(body))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := post(validSPDX, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `"sha256:`) || first.Header().Get("Cache-Control") != "private, no-cache" {
+		t.Fatalf("status = %d, headers = %v", first.Code, first.Header())
+	}
+
+	if rec := post(validSPDX, `"other", W/`+etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 || rec.Header().Get("ETag") != etag {
+		t.Errorf("If-None-Match: status = %d, ETag = %q, body = %s", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+	if rec := post(validSPDX, ""); rec.Code != http.StatusOK || rec.Body.String() != first.Body.String() {
+		t.Errorf("cached result: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if n := validations.Load(); n != 1 {
+		t.Errorf("validated %d times, want once", n)
+	}
+
+	other := strings.Replace(validSPDX, `"name": "test"`, `"name": "other"`, 1)
+	if rec := post(other, etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("other document: status = %d, ETag = %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	now = now.Add(DefaultCacheTTL)
+	if rec := post(validSPDX, etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") != etag {
+		t.Errorf("expired result: status = %d, ETag = %q", rec.Code, rec.Header().Get("ETag"))
+	}
+	if n := validations.Load(); n != 3 {
+		t.Errorf("validated %d times, want 3", n)
+	}
+
+	uncached := New(Config{CacheSize: -1})
+	defer uncached.Close()
+	req := httptest.NewRequest(http.MethodPost, "/v1/validate", bytes.NewBufferString(validSPDX))
+	req.Header.Set("If-None-Match", etag)
+	rec := httptest.NewRecorder()
+	uncached.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Errorf("disabled cache: status = %d, ETag = %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantEvents []string
+	}{
+		{
+			name:       "Valid SBOM",
+			body:       validSPDX,
+			wantEvents: []string{"progress", "progress", "result"},
+		},
+		{
+			name:       "Invalid SBOM",
+			body:       `{"spdxVersion": "SPDX-2.3"}`,
+			wantEvents: []string{"progress", "progress", "result"},
+		},
+		{
+			name:       "Malformed SBOM",
+			body:       `not json`,
+			wantEvents: []string{"error"},
+		},
+	}
+
+	s := New(Config{})
+	defer s.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/validate/stream", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+				t.Fatalf("status = %d, Content-Type = %q", rec.Code, rec.Header().Get("Content-Type"))
+			}
+
+			var events []string
+			var streamed int
+			var last string
+			for _, block := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n") {
+				name, data, _ := strings.Cut(block, "\n")
+				events = append(events, strings.TrimPrefix(name, "event: "))
+				last = strings.TrimPrefix(data, "data: ")
+				var progress struct {
+					Findings []json.RawMessage `json:"findings"`
+				}
+				json.Unmarshal([]byte(last), &progress)
+				streamed += len(progress.Findings)
+			}
+			if !reflect.DeepEqual(events, tt.wantEvents) {
+				t.Fatalf("events = %q, want %q", events, tt.wantEvents)
+			}
+
+			if events[len(events)-1] == "result" {
+				var result struct {
+					IsValid          bool     `json:"isValid"`
+					ValidationErrors []string `json:"validationErrors"`
+					Warnings         []string `json:"warnings"`
+				}
+				json.Unmarshal([]byte(last), &result)
+				if got := len(result.ValidationErrors) + len(result.Warnings); got != streamed {
+					t.Errorf("streamed %d findings, result has %d", streamed, got)
+				}
+			}
+		})
+	}
+}
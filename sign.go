@@ -0,0 +1,286 @@
+package sbomvalidator
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// sigstoreBundleMediaType is the media type of the bundles produced for SPDX.
+const sigstoreBundleMediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+// SignedSBOM represents the outcome of SignSBOM.
+//
+// `Document` is the normalized SBOM. For CycloneDX it carries an embedded JSF
+// signature; for SPDX it is unchanged apart from normalization and `Bundle`
+// holds the detached sigstore bundle that signs it.
+type SignedSBOM struct {
+	Document  []byte
+	Bundle    []byte
+	Algorithm string
+}
+
+// SignSBOM validates an SBOM, normalizes it and signs the result.
+//
+// Normalization re-serializes the document with object keys sorted and no
+// insignificant whitespace, so the signature does not depend on how the SBOM
+// was formatted. CycloneDX SBOMs are signed with an enveloped JSON Signature
+// Format (JSF) signature in the top-level `signature` property, which replaces
+// any existing signature. SPDX has no embedded signature, so a detached
+// sigstore bundle carrying a message signature over the normalized document is
+// returned instead. The bundle identifies the key by a hint (the base64
+// SHA-256 of its PKIX encoding) rather than a certificate, and has no
+// transparency log entry; keyless signing is not supported.
+//
+// Supported keys are ECDSA P-256 and P-384, Ed25519 and RSA.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM JSON data.
+//   - signer: The private key to sign with.
+//
+// Returns:
+//   - A SignedSBOM with the signed document and, for SPDX, the bundle.
+//   - An error if the SBOM is invalid, or the key type is unsupported.
+//
+// Example:
+//
+//	signed, err := SignSBOM(jsonData, key)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.WriteFile("sbom.signed.cdx.json", signed.Document, 0o644)
+func SignSBOM(sbomContent []byte, signer crypto.Signer) (*SignedSBOM, error) {
+	result, err := ValidateSBOMData(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+	if !result.IsValid {
+		return nil, fmt.Errorf("refusing to sign an invalid SBOM: %d validation errors", len(result.ValidationErrors))
+	}
+
+	obj, err := parseJSONPreservingNumbers(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.SBOMType == SBOM_CYCLONEDX {
+		return signJSF(obj, signer)
+	}
+	return signSigstoreBundle(obj, signer)
+}
+
+// signJSF adds an enveloped JSF signature to a CycloneDX document. The
+// signature covers the canonical document including the signature object
+// without its "value".
+func signJSF(obj map[string]interface{}, signer crypto.Signer) (*SignedSBOM, error) {
+	algorithm, err := jwsAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := jsonWebKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	signature := map[string]interface{}{
+		"algorithm": algorithm,
+		"publicKey": publicKey,
+	}
+	obj["signature"] = signature
+
+	payload, err := canonicalJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := signPayload(signer, payload, true)
+	if err != nil {
+		return nil, err
+	}
+	signature["value"] = base64.RawURLEncoding.EncodeToString(value)
+
+	document, err := canonicalJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedSBOM{Document: document, Algorithm: algorithm}, nil
+}
+
+// signSigstoreBundle signs a normalized SPDX document and returns it along with
+// a detached sigstore bundle.
+func signSigstoreBundle(obj map[string]interface{}, signer crypto.Signer) (*SignedSBOM, error) {
+	algorithm, err := jwsAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := canonicalJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := signPayload(signer, document, false)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %v", err)
+	}
+	hint := sha256.Sum256(der)
+	digest := sha256.Sum256(document)
+
+	bundle := map[string]interface{}{
+		"mediaType": sigstoreBundleMediaType,
+		"verificationMaterial": map[string]interface{}{
+			"publicKey": map[string]interface{}{
+				"hint": base64.StdEncoding.EncodeToString(hint[:]),
+			},
+		},
+		"messageSignature": map[string]interface{}{
+			"messageDigest": map[string]interface{}{
+				"algorithm": "SHA2_256",
+				"digest":    base64.StdEncoding.EncodeToString(digest[:]),
+			},
+			"signature": base64.StdEncoding.EncodeToString(signature),
+		},
+	}
+
+	bundleJSON, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedSBOM{Document: document, Bundle: bundleJSON, Algorithm: algorithm}, nil
+}
+
+// signPayload signs the payload with the hash matching the key. ECDSA
+// signatures are returned as R||S when rawECDSA is set (as JWA and JSF
+// require) and ASN.1 DER otherwise (as sigstore expects).
+func signPayload(signer crypto.Signer, payload []byte, rawECDSA bool) ([]byte, error) {
+	var opts crypto.SignerOpts = crypto.SHA256
+	digest := payload
+
+	switch key := signer.Public().(type) {
+	case ed25519.PublicKey:
+		// Ed25519 signs the message itself
+		opts = crypto.Hash(0)
+	case *ecdsa.PublicKey:
+		if key.Curve == elliptic.P384() {
+			opts = crypto.SHA384
+		}
+	}
+
+	if hash := opts.HashFunc(); hash != 0 {
+		h := hash.New()
+		h.Write(payload)
+		digest = h.Sum(nil)
+	}
+
+	signature, err := signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %v", err)
+	}
+
+	if key, ok := signer.Public().(*ecdsa.PublicKey); ok && rawECDSA {
+		var parsed struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(signature, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to decode ECDSA signature: %v", err)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		raw := make([]byte, 2*size)
+		parsed.R.FillBytes(raw[:size])
+		parsed.S.FillBytes(raw[size:])
+		signature = raw
+	}
+
+	return signature, nil
+}
+
+// jwsAlgorithm returns the JWA algorithm name for a public key.
+func jwsAlgorithm(publicKey crypto.PublicKey) (string, error) {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return "ES256", nil
+		case elliptic.P384():
+			return "ES384", nil
+		}
+		return "", fmt.Errorf("unsupported ECDSA curve: %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "Ed25519", nil
+	case *rsa.PublicKey:
+		return "RS256", nil
+	}
+	return "", fmt.Errorf("unsupported key type: %T", publicKey)
+}
+
+// jsonWebKey returns the JWK representation of a public key, as embedded in
+// JSF signatures.
+func jsonWebKey(publicKey crypto.PublicKey) (map[string]interface{}, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return map[string]interface{}{
+			"kty": "EC",
+			"crv": key.Curve.Params().Name,
+			"x":   encode(key.X.FillBytes(make([]byte, size))),
+			"y":   encode(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return map[string]interface{}{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   encode(key),
+		}, nil
+	case *rsa.PublicKey:
+		return map[string]interface{}{
+			"kty": "RSA",
+			"n":   encode(key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %T", publicKey)
+}
+
+// parseJSONPreservingNumbers parses a JSON object keeping numbers as written,
+// so normalization does not alter values such as large integers.
+func parseJSONPreservingNumbers(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	return obj, nil
+}
+
+// canonicalJSON serializes a value with sorted object keys, no insignificant
+// whitespace and no HTML escaping.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to serialize JSON: %v", err)
+	}
+	return []byte(strings.TrimSuffix(buf.String(), "\n")), nil
+}
@@ -0,0 +1,142 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestSignJSF(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name          string
+		signer        crypto.Signer
+		wantAlgorithm string
+	}{
+		{name: "ECDSA P-256", signer: ecKey, wantAlgorithm: "ES256"},
+		{name: "Ed25519", signer: edKey, wantAlgorithm: "Ed25519"},
+		{name: "RSA", signer: rsaKey, wantAlgorithm: "RS256"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSONPreservingNumbers([]byte(`{"specVersion": "1.6", "bomFormat": "CycloneDX", "version": 12345678901234567890,
+				"signature": {"algorithm": "ES256", "value": "stale"}}`))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			signed, err := signJSF(obj, tt.signer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if signed.Algorithm != tt.wantAlgorithm {
+				t.Errorf("Algorithm = %q, want %q", signed.Algorithm, tt.wantAlgorithm)
+			}
+
+			document, err := parseJSONPreservingNumbers(signed.Document)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if document["version"].(json.Number).String() != "12345678901234567890" {
+				t.Errorf("normalization altered a number: %v", document["version"])
+			}
+
+			signature := document["signature"].(map[string]interface{})
+			value, err := base64.RawURLEncoding.DecodeString(signature["value"].(string))
+			if err != nil {
+				t.Fatalf("signature value is not base64url: %v", err)
+			}
+			delete(signature, "value")
+			payload, _ := canonicalJSON(document)
+
+			if !verifyTestSignature(t, tt.signer.Public(), payload, value) {
+				t.Errorf("JSF signature does not verify")
+			}
+		})
+	}
+}
+
+func TestSignSBOMSPDX(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	signed, err := SignSBOM(spdxDocument(spdxPackage("a", "a", "MIT")), key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var bundle struct {
+		MediaType            string `json:"mediaType"`
+		VerificationMaterial struct {
+			PublicKey struct {
+				Hint string `json:"hint"`
+			} `json:"publicKey"`
+		} `json:"verificationMaterial"`
+		MessageSignature struct {
+			MessageDigest struct {
+				Digest string `json:"digest"`
+			} `json:"messageDigest"`
+			Signature string `json:"signature"`
+		} `json:"messageSignature"`
+	}
+	if err := json.Unmarshal(signed.Bundle, &bundle); err != nil {
+		t.Fatalf("bundle is not JSON: %v", err)
+	}
+
+	if bundle.MediaType != sigstoreBundleMediaType {
+		t.Errorf("mediaType = %q", bundle.MediaType)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(key.Public())
+	hint := sha256.Sum256(der)
+	if bundle.VerificationMaterial.PublicKey.Hint != base64.StdEncoding.EncodeToString(hint[:]) {
+		t.Errorf("unexpected key hint %q", bundle.VerificationMaterial.PublicKey.Hint)
+	}
+	digest := sha256.Sum256(signed.Document)
+	if bundle.MessageSignature.MessageDigest.Digest != base64.StdEncoding.EncodeToString(digest[:]) {
+		t.Errorf("message digest does not match the signed document")
+	}
+
+	signature, _ := base64.StdEncoding.DecodeString(bundle.MessageSignature.Signature)
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], signature) {
+		t.Errorf("bundle signature does not verify")
+	}
+}
+
+func TestSignSBOMRejectsInvalid(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if _, err := SignSBOM([]byte(`{"spdxVersion": "SPDX-2.3"}`), key); err == nil {
+		t.Errorf("expected an error for an invalid SBOM")
+	}
+}
+
+func verifyTestSignature(t *testing.T, publicKey crypto.PublicKey, payload, signature []byte) bool {
+	t.Helper()
+	digest := sha256.Sum256(payload)
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		r := new(big.Int).SetBytes(signature[:len(signature)/2])
+		s := new(big.Int).SetBytes(signature[len(signature)/2:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, payload, signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
@@ -0,0 +1,599 @@
+package sbomvalidator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Formats of verified signatures.
+const (
+	SignatureFormatJSF      = "jsf"
+	SignatureFormatSigstore = "sigstore-bundle"
+	SignatureFormatDetached = "detached"
+)
+
+// fulcioIssuerOIDs identify the certificate extensions in which Fulcio records
+// the OIDC issuer of the signer's identity (v2 and the deprecated v1).
+var fulcioIssuerOIDs = []asn1.ObjectIdentifier{
+	{1, 3, 6, 1, 4, 1, 57264, 1, 8},
+	{1, 3, 6, 1, 4, 1, 57264, 1, 1},
+}
+
+// SignaturePolicy configures signature verification. A signature is accepted
+// when it verifies and its key is trusted: either one of TrustedKeys, or
+// certified by a certificate that chains to Roots.
+type SignaturePolicy struct {
+	// TrustedKeys maps signer names, reported as the signer identity, to their
+	// public keys.
+	TrustedKeys map[string]crypto.PublicKey
+	// Roots and Intermediates verify signing certificates, which must allow
+	// code signing.
+	Roots         *x509.CertPool
+	Intermediates *x509.CertPool
+	// CurrentTime is the time certificates must be valid at; defaults to now.
+	// Short-lived certificates, such as sigstore's, need the signing time
+	// recorded by a transparency log.
+	CurrentTime time.Time
+	// Identities, if set, restricts the accepted signers to those names,
+	// certificate email addresses or URIs.
+	Identities []string
+
+	// DetachedSignature is a signature distributed alongside the SBOM: a
+	// sigstore bundle, or a base64 encoded signature (e.g., cosign's .sig)
+	// over the SBOM file as is. When empty, the SBOM must carry an embedded
+	// JSF signature (CycloneDX JSON only).
+	DetachedSignature []byte
+	// Certificate is the PEM or DER encoded signing certificate of a detached
+	// signature, when neither a trusted key nor the bundle provides it.
+	Certificate []byte
+}
+
+// SignatureResult describes a verified signature.
+type SignatureResult struct {
+	Format    string `json:"format"`
+	Algorithm string `json:"algorithm,omitempty"`
+	// Signer is the trusted key's name or the certificate's email address,
+	// URI or common name.
+	Signer string `json:"signer"`
+	// Issuer is the OIDC issuer recorded in a sigstore certificate, or the
+	// certificate's issuer.
+	Issuer string `json:"issuer,omitempty"`
+	// KeyID is the base64 SHA-256 of the signing key's PKIX encoding.
+	KeyID string `json:"keyId"`
+}
+
+// signingKey is a public key with the identity that vouches for it.
+type signingKey struct {
+	key    crypto.PublicKey
+	signer string
+	issuer string
+}
+
+// VerifySBOMSignature verifies the signature of an SBOM before it is trusted,
+// either the enveloped JSF signature of a CycloneDX JSON SBOM (including those
+// made by SignSBOM) or a detached signature given in the policy.
+//
+// JSF signatures are verified over the document with the signature's value
+// removed, serialized with sorted keys and no insignificant whitespace, as
+// SignSBOM does. Only single signatures are supported, not JSF `signers` or
+// `chain`. The signing key comes from the signature's `certificatePath`, or its
+// `publicKey`, which must match a trusted key.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM as signed.
+//   - policy: The trusted keys or roots, and any detached signature.
+//
+// Returns:
+//   - A SignatureResult identifying the signer.
+//   - An error if the SBOM is not signed, the signature does not verify or
+//     its key is not trusted.
+//
+// Example:
+//
+//	sig, err := VerifySBOMSignature(sbomBytes, SignaturePolicy{
+//	    TrustedKeys: map[string]crypto.PublicKey{"release": releaseKey},
+//	})
+//	if err != nil {
+//	    log.Fatalf("untrusted SBOM: %v", err)
+//	}
+//	fmt.Println("signed by", sig.Signer)
+func VerifySBOMSignature(sbomContent []byte, policy SignaturePolicy) (*SignatureResult, error) {
+	if len(policy.TrustedKeys) == 0 && policy.Roots == nil {
+		return nil, fmt.Errorf("signature policy has no trusted keys or roots")
+	}
+
+	var result *SignatureResult
+	var err error
+	switch {
+	case len(policy.DetachedSignature) == 0:
+		result, err = verifyJSF(sbomContent, policy)
+	case isJSON(policy.DetachedSignature):
+		result, err = verifySigstoreBundle(sbomContent, policy)
+	default:
+		result, err = verifyDetached(sbomContent, policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(policy.Identities) > 0 && !slices.Contains(policy.Identities, result.Signer) {
+		return nil, fmt.Errorf("signer %q is not an accepted identity", result.Signer)
+	}
+	return result, nil
+}
+
+// verifyJSF verifies the enveloped JSF signature of a CycloneDX document.
+func verifyJSF(sbomContent []byte, policy SignaturePolicy) (*SignatureResult, error) {
+	obj, err := parseJSONPreservingNumbers(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, ok := obj["signature"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("SBOM is not signed")
+	}
+	if _, ok := signature["signers"]; ok {
+		return nil, fmt.Errorf("JSF multiple signatures (signers) are not supported")
+	}
+	if _, ok := signature["chain"]; ok {
+		return nil, fmt.Errorf("JSF signature chains are not supported")
+	}
+
+	algorithm, _ := signature["algorithm"].(string)
+	encoded, _ := signature["value"].(string)
+	value, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil || len(value) == 0 {
+		return nil, fmt.Errorf("JSF signature has no valid value")
+	}
+
+	var key *signingKey
+	if path, ok := signature["certificatePath"].([]interface{}); ok && len(path) > 0 {
+		key, err = jsfCertificateKey(path, policy)
+	} else if jwk, ok := signature["publicKey"].(map[string]interface{}); ok {
+		var publicKey crypto.PublicKey
+		if publicKey, err = parseJSONWebKey(jwk); err == nil {
+			key, err = trustedKey(publicKey, policy)
+		}
+	} else {
+		err = fmt.Errorf("JSF signature has neither publicKey nor certificatePath")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delete(signature, "value")
+	payload, err := canonicalJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWASignature(key.key, algorithm, payload, value); err != nil {
+		return nil, err
+	}
+
+	return newSignatureResult(SignatureFormatJSF, algorithm, key)
+}
+
+// jsfCertificateKey returns the key of the first certificate of a JSF
+// certificatePath, verified against the policy's roots.
+func jsfCertificateKey(path []interface{}, policy SignaturePolicy) (*signingKey, error) {
+	var chain []*x509.Certificate
+	for _, entry := range path {
+		encoded, _ := entry.(string)
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in certificatePath: %v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in certificatePath: %v", err)
+		}
+		chain = append(chain, cert)
+	}
+	return certificateKey(chain[0], chain[1:], policy)
+}
+
+// verifySigstoreBundle verifies a sigstore bundle carrying a message
+// signature over the SBOM.
+func verifySigstoreBundle(sbomContent []byte, policy SignaturePolicy) (*SignatureResult, error) {
+	var bundle struct {
+		MediaType            string `json:"mediaType"`
+		VerificationMaterial struct {
+			Certificate *struct {
+				RawBytes string `json:"rawBytes"`
+			} `json:"certificate"`
+			X509CertificateChain *struct {
+				Certificates []struct {
+					RawBytes string `json:"rawBytes"`
+				} `json:"certificates"`
+			} `json:"x509CertificateChain"`
+		} `json:"verificationMaterial"`
+		MessageSignature *struct {
+			MessageDigest struct {
+				Algorithm string `json:"algorithm"`
+				Digest    string `json:"digest"`
+			} `json:"messageDigest"`
+			Signature string `json:"signature"`
+		} `json:"messageSignature"`
+	}
+	if err := json.Unmarshal(policy.DetachedSignature, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid sigstore bundle: %v", err)
+	}
+	if !strings.HasPrefix(bundle.MediaType, "application/vnd.dev.sigstore.bundle") {
+		return nil, fmt.Errorf("unsupported signature bundle media type %q", bundle.MediaType)
+	}
+	if bundle.MessageSignature == nil {
+		return nil, fmt.Errorf("only sigstore bundles with a message signature are supported")
+	}
+
+	digest := sha256.Sum256(sbomContent)
+	if d := bundle.MessageSignature.MessageDigest; d.Digest != "" &&
+		(d.Algorithm != "SHA2_256" || d.Digest != base64.StdEncoding.EncodeToString(digest[:])) {
+		return nil, fmt.Errorf("sigstore bundle digest does not match the SBOM")
+	}
+	signature, err := base64.StdEncoding.DecodeString(bundle.MessageSignature.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sigstore bundle signature: %v", err)
+	}
+
+	var certs []string
+	material := bundle.VerificationMaterial
+	if material.Certificate != nil {
+		certs = append(certs, material.Certificate.RawBytes)
+	}
+	if material.X509CertificateChain != nil {
+		for _, c := range material.X509CertificateChain.Certificates {
+			certs = append(certs, c.RawBytes)
+		}
+	}
+
+	var key *signingKey
+	if len(certs) > 0 {
+		var chain []*x509.Certificate
+		for _, encoded := range certs {
+			der, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("invalid certificate in sigstore bundle: %v", err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("invalid certificate in sigstore bundle: %v", err)
+			}
+			chain = append(chain, cert)
+		}
+		key, err = certificateKey(chain[0], chain[1:], policy)
+	} else {
+		key, err = detachedKey(sbomContent, signature, policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, err := verifyDetachedSignature(key.key, sbomContent, signature)
+	if err != nil {
+		return nil, err
+	}
+	return newSignatureResult(SignatureFormatSigstore, algorithm, key)
+}
+
+// verifyDetached verifies a base64 encoded detached signature, as written by
+// `cosign sign-blob`.
+func verifyDetached(sbomContent []byte, policy SignaturePolicy) (*SignatureResult, error) {
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(policy.DetachedSignature)))
+	if err != nil {
+		return nil, fmt.Errorf("detached signature is not base64: %v", err)
+	}
+
+	key, err := detachedKey(sbomContent, signature, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, err := verifyDetachedSignature(key.key, sbomContent, signature)
+	if err != nil {
+		return nil, err
+	}
+	return newSignatureResult(SignatureFormatDetached, algorithm, key)
+}
+
+// detachedKey returns the key of a detached signature: the policy's
+// certificate, or the trusted key the signature verifies with.
+func detachedKey(sbomContent, signature []byte, policy SignaturePolicy) (*signingKey, error) {
+	if len(policy.Certificate) > 0 {
+		der := policy.Certificate
+		if block, _ := pem.Decode(policy.Certificate); block != nil {
+			der = block.Bytes
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing certificate: %v", err)
+		}
+		return certificateKey(cert, nil, policy)
+	}
+
+	for name, key := range policy.TrustedKeys {
+		if _, err := verifyDetachedSignature(key, sbomContent, signature); err == nil {
+			return &signingKey{key: key, signer: name}, nil
+		}
+	}
+	return nil, fmt.Errorf("signature does not verify with any trusted key")
+}
+
+// certificateKey verifies a signing certificate against the policy's roots and
+// returns its key and identity.
+func certificateKey(cert *x509.Certificate, intermediates []*x509.Certificate, policy SignaturePolicy) (*signingKey, error) {
+	if policy.Roots == nil {
+		return nil, fmt.Errorf("signing certificate cannot be verified: no trusted roots")
+	}
+
+	pool := x509.NewCertPool()
+	if policy.Intermediates != nil {
+		pool = policy.Intermediates.Clone()
+	}
+	for _, c := range intermediates {
+		pool.AddCert(c)
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         policy.Roots,
+		Intermediates: pool,
+		CurrentTime:   policy.CurrentTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("untrusted signing certificate: %v", err)
+	}
+
+	return &signingKey{key: cert.PublicKey, signer: certificateIdentity(cert), issuer: certificateIssuer(cert)}, nil
+}
+
+// trustedKey returns the name of a trusted key equal to publicKey.
+func trustedKey(publicKey crypto.PublicKey, policy SignaturePolicy) (*signingKey, error) {
+	type equaler interface {
+		Equal(crypto.PublicKey) bool
+	}
+
+	for name, key := range policy.TrustedKeys {
+		if k, ok := key.(equaler); ok && k.Equal(publicKey) {
+			return &signingKey{key: key, signer: name}, nil
+		}
+	}
+	return nil, fmt.Errorf("signing key is not trusted")
+}
+
+// certificateIdentity returns the identity a certificate was issued to.
+func certificateIdentity(cert *x509.Certificate) string {
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	return cert.Subject.String()
+}
+
+// certificateIssuer returns the OIDC issuer recorded by sigstore's Fulcio, or
+// the certificate's issuer.
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, oid := range fulcioIssuerOIDs {
+		for _, ext := range cert.Extensions {
+			if !ext.Id.Equal(oid) {
+				continue
+			}
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+			// v1 stores the raw string
+			return string(ext.Value)
+		}
+	}
+	if cert.Issuer.CommonName != "" {
+		return cert.Issuer.CommonName
+	}
+	return cert.Issuer.String()
+}
+
+func newSignatureResult(format, algorithm string, key *signingKey) (*SignatureResult, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %v", err)
+	}
+	keyID := sha256.Sum256(der)
+
+	return &SignatureResult{
+		Format:    format,
+		Algorithm: algorithm,
+		Signer:    key.signer,
+		Issuer:    key.issuer,
+		KeyID:     base64.StdEncoding.EncodeToString(keyID[:]),
+	}, nil
+}
+
+// verifyJWASignature verifies a JSF signature made with a JWA algorithm.
+// ECDSA signatures are R||S.
+func verifyJWASignature(publicKey crypto.PublicKey, algorithm string, payload, signature []byte) error {
+	var hash crypto.Hash
+	switch {
+	case strings.HasSuffix(algorithm, "256"):
+		hash = crypto.SHA256
+	case strings.HasSuffix(algorithm, "384"):
+		hash = crypto.SHA384
+	case strings.HasSuffix(algorithm, "512"):
+		hash = crypto.SHA512
+	}
+
+	valid := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "ES") || hash == 0 || len(signature)%2 != 0 {
+			return fmt.Errorf("algorithm %q does not match the ECDSA key", algorithm)
+		}
+		r := new(big.Int).SetBytes(signature[:len(signature)/2])
+		s := new(big.Int).SetBytes(signature[len(signature)/2:])
+		valid = ecdsa.Verify(key, hashOf(hash, payload), r, s)
+	case ed25519.PublicKey:
+		if algorithm != "Ed25519" && algorithm != "EdDSA" {
+			return fmt.Errorf("algorithm %q does not match the Ed25519 key", algorithm)
+		}
+		valid = ed25519.Verify(key, payload, signature)
+	case *rsa.PublicKey:
+		switch {
+		case hash == 0:
+			return fmt.Errorf("algorithm %q does not match the RSA key", algorithm)
+		case strings.HasPrefix(algorithm, "RS"):
+			valid = rsa.VerifyPKCS1v15(key, hash, hashOf(hash, payload), signature) == nil
+		case strings.HasPrefix(algorithm, "PS"):
+			valid = rsa.VerifyPSS(key, hash, hashOf(hash, payload), signature, nil) == nil
+		default:
+			return fmt.Errorf("algorithm %q does not match the RSA key", algorithm)
+		}
+	default:
+		return fmt.Errorf("unsupported key type: %T", publicKey)
+	}
+
+	if !valid {
+		return fmt.Errorf("JSF signature does not verify")
+	}
+	return nil
+}
+
+// verifyDetachedSignature verifies a signature over a message, with the hash
+// implied by the key as SignSBOM and cosign use it. ECDSA signatures are
+// ASN.1 DER. Returns the JWA name of the algorithm.
+func verifyDetachedSignature(publicKey crypto.PublicKey, message, signature []byte) (string, error) {
+	algorithm, err := jwsAlgorithm(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	valid := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		hash := crypto.SHA256
+		if key.Curve == elliptic.P384() {
+			hash = crypto.SHA384
+		}
+		valid = ecdsa.VerifyASN1(key, hashOf(hash, message), signature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, message, signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, hashOf(crypto.SHA256, message), signature) == nil
+	}
+
+	if !valid {
+		return "", fmt.Errorf("signature does not verify")
+	}
+	return algorithm, nil
+}
+
+func hashOf(hash crypto.Hash, message []byte) []byte {
+	h := hash.New()
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// parseJSONWebKey parses the JWK of a JSF publicKey.
+func parseJSONWebKey(jwk map[string]interface{}) (crypto.PublicKey, error) {
+	field := func(name string) ([]byte, error) {
+		encoded, _ := jwk[name].(string)
+		value, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil || len(value) == 0 {
+			return nil, fmt.Errorf("invalid JWK: missing or malformed %q", name)
+		}
+		return value, nil
+	}
+
+	kty, _ := jwk["kty"].(string)
+	crv, _ := jwk["crv"].(string)
+	switch kty {
+	case "EC":
+		var curve elliptic.Curve
+		switch crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported JWK curve %q", crv)
+		}
+		x, err := field("x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := field("y")
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("invalid JWK: point is not on curve %s", crv)
+		}
+		return key, nil
+
+	case "OKP":
+		if crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported JWK curve %q", crv)
+		}
+		x, err := field("x")
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid JWK: Ed25519 key has %d bytes", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+
+	case "RSA":
+		n, err := field("n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := field("e")
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid JWK: RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	}
+	return nil, fmt.Errorf("unsupported JWK key type %q", kty)
+}
+
+// ParsePublicKeyPEM parses a PEM encoded PKIX public key, or the key of a PEM
+// encoded certificate, for use in SignaturePolicy.TrustedKeys.
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,292 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCertificate issues a code signing certificate for email, signed by a new
+// CA, and returns it with a pool holding the CA.
+func testCertificate(t *testing.T, key crypto.Signer, email string) (*x509.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		EmailAddresses: []string{email},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, key.Public(), caKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(leafDER)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return leaf, roots
+}
+
+func TestVerifySBOMSignatureJSF(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	sign := func(signer crypto.Signer) []byte {
+		obj, _ := parseJSONPreservingNumbers([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", "version": 1}`))
+		signed, err := signJSF(obj, signer)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return signed.Document
+	}
+
+	tests := []struct {
+		name       string
+		document   []byte
+		policy     SignaturePolicy
+		wantSigner string
+		wantErr    string
+	}{
+		{
+			name:       "ECDSA",
+			document:   sign(ecKey),
+			policy:     SignaturePolicy{TrustedKeys: map[string]crypto.PublicKey{"release": ecKey.Public()}},
+			wantSigner: "release",
+		},
+		{
+			name:       "Ed25519",
+			document:   sign(edKey),
+			policy:     SignaturePolicy{TrustedKeys: map[string]crypto.PublicKey{"release": edKey.Public()}},
+			wantSigner: "release",
+		},
+		{
+			name:     "Untrusted key",
+			document: sign(ecKey),
+			policy:   SignaturePolicy{TrustedKeys: map[string]crypto.PublicKey{"release": otherKey.Public()}},
+			wantErr:  "signing key is not trusted",
+		},
+		{
+			name:     "Tampered",
+			document: []byte(strings.Replace(string(sign(ecKey)), `"version":1`, `"version":2`, 1)),
+			policy:   SignaturePolicy{TrustedKeys: map[string]crypto.PublicKey{"release": ecKey.Public()}},
+			wantErr:  "JSF signature does not verify",
+		},
+		{
+			name:     "Unsigned",
+			document: []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6"}`),
+			policy:   SignaturePolicy{TrustedKeys: map[string]crypto.PublicKey{"release": ecKey.Public()}},
+			wantErr:  "SBOM is not signed",
+		},
+		{
+			name:     "No trust anchors",
+			document: sign(ecKey),
+			wantErr:  "no trusted keys or roots",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := VerifySBOMSignature(tt.document, tt.policy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Format != SignatureFormatJSF || result.Signer != tt.wantSigner || result.KeyID == "" {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+		})
+	}
+}
+
+func TestVerifySBOMSignatureJSFCertificate(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	cert, roots := testCertificate(t, key, "builder@example.com")
+
+	obj, _ := parseJSONPreservingNumbers([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6"}`))
+	signature := map[string]interface{}{
+		"algorithm":       "ES256",
+		"certificatePath": []interface{}{base64.StdEncoding.EncodeToString(cert.Raw)},
+	}
+	obj["signature"] = signature
+	payload, _ := canonicalJSON(obj)
+	value, err := signPayload(key, payload, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	signature["value"] = base64.RawURLEncoding.EncodeToString(value)
+	document, _ := canonicalJSON(obj)
+
+	result, err := VerifySBOMSignature(document, SignaturePolicy{Roots: roots})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Signer != "builder@example.com" || result.Issuer != "Test CA" || result.Algorithm != "ES256" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	_, otherRoots := testCertificate(t, key, "builder@example.com")
+	if _, err := VerifySBOMSignature(document, SignaturePolicy{Roots: otherRoots}); err == nil {
+		t.Errorf("Expected a certificate from another CA to be rejected")
+	}
+}
+
+func TestVerifySBOMSignatureDetached(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	cert, roots := testCertificate(t, key, "builder@example.com")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+
+	signed, err := SignSBOM(spdxDocument(spdxPackage("a", "a", "MIT")), key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	raw, _ := signPayload(key, signed.Document, false)
+	cosignSignature := []byte(base64.StdEncoding.EncodeToString(raw) + "\n")
+	trusted := map[string]crypto.PublicKey{"release": key.Public()}
+
+	tests := []struct {
+		name       string
+		document   []byte
+		policy     SignaturePolicy
+		wantFormat string
+		wantSigner string
+		wantErr    string
+	}{
+		{
+			name:       "Sigstore bundle",
+			document:   signed.Document,
+			policy:     SignaturePolicy{TrustedKeys: trusted, DetachedSignature: signed.Bundle},
+			wantFormat: SignatureFormatSigstore,
+			wantSigner: "release",
+		},
+		{
+			name:     "Sigstore bundle for another document",
+			document: spdxDocument(),
+			policy:   SignaturePolicy{TrustedKeys: trusted, DetachedSignature: signed.Bundle},
+			wantErr:  "digest does not match",
+		},
+		{
+			name:       "Cosign signature with key",
+			document:   signed.Document,
+			policy:     SignaturePolicy{TrustedKeys: trusted, DetachedSignature: cosignSignature},
+			wantFormat: SignatureFormatDetached,
+			wantSigner: "release",
+		},
+		{
+			name:       "Cosign signature with certificate",
+			document:   signed.Document,
+			policy:     SignaturePolicy{Roots: roots, DetachedSignature: cosignSignature, Certificate: certPEM},
+			wantFormat: SignatureFormatDetached,
+			wantSigner: "builder@example.com",
+		},
+		{
+			name:     "Unaccepted identity",
+			document: signed.Document,
+			policy: SignaturePolicy{Roots: roots, DetachedSignature: cosignSignature, Certificate: certPEM,
+				Identities: []string{"someone@example.com"}},
+			wantErr: "not an accepted identity",
+		},
+		{
+			name:     "Cosign signature for another document",
+			document: spdxDocument(),
+			policy:   SignaturePolicy{TrustedKeys: trusted, DetachedSignature: cosignSignature},
+			wantErr:  "does not verify with any trusted key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := VerifySBOMSignature(tt.document, tt.policy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Format != tt.wantFormat || result.Signer != tt.wantSigner {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+		})
+	}
+}
+
+func TestValidateSBOMDataSignatureVerification(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signed, err := SignSBOM(spdxDocument(spdxPackage("a", "a", "MIT")), key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	policy := SignaturePolicy{TrustedKeys: map[string]crypto.PublicKey{"release": key.Public()}}
+
+	policy.DetachedSignature = signed.Bundle
+	result, err := ValidateSBOMData(signed.Document, WithSignatureVerification(policy))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.IsValid || result.Signature == nil || result.Signature.Signer != "release" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	policy.DetachedSignature = nil
+	if _, err := ValidateSBOMData(signed.Document, WithSignatureVerification(policy)); err == nil ||
+		!strings.Contains(err.Error(), "signature verification failed") {
+		t.Errorf("Expected an unsigned SBOM to be rejected, got %v", err)
+	}
+}
+
+func TestParseJSONWebKey(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	jwk, _ := jsonWebKey(ecKey.Public())
+
+	key, err := parseJSONWebKey(jwk)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !ecKey.PublicKey.Equal(key) {
+		t.Errorf("Parsed key does not match")
+	}
+
+	jwk["y"] = jwk["x"]
+	if _, err := parseJSONWebKey(jwk); err == nil {
+		t.Errorf("Expected an off-curve point to be rejected")
+	}
+}
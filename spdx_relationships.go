@@ -0,0 +1,183 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// spdxElementKind is the kind of SPDX element a relationship refers to.
+type spdxElementKind string
+
+const (
+	spdxKindDocument spdxElementKind = "document"
+	spdxKindPackage  spdxElementKind = "package"
+	spdxKindFile     spdxElementKind = "file"
+	spdxKindSnippet  spdxElementKind = "snippet"
+	// spdxKindExternal is an element of another document
+	// ("DocumentRef-<id>:SPDXRef-<id>"), whose kind is unknown.
+	spdxKindExternal spdxElementKind = "external"
+	// spdxKindNone is NONE or NOASSERTION, which may only be the related
+	// element.
+	spdxKindNone spdxElementKind = "none"
+)
+
+// spdxRelationshipRule lists the element kinds a relationship type may relate.
+// A nil list allows any kind.
+type spdxRelationshipRule struct {
+	from []spdxElementKind
+	to   []spdxElementKind
+	// description is used in warnings, e.g. "relate packages".
+	description string
+}
+
+var (
+	spdxPackageKinds   = []spdxElementKind{spdxKindPackage}
+	spdxArtifactKinds  = []spdxElementKind{spdxKindPackage, spdxKindFile}
+	spdxContainedKinds = []spdxElementKind{spdxKindPackage, spdxKindFile, spdxKindSnippet}
+
+	dependencyRule = spdxRelationshipRule{from: spdxPackageKinds, to: spdxPackageKinds, description: "relate packages"}
+	linkRule       = spdxRelationshipRule{from: spdxArtifactKinds, to: spdxArtifactKinds, description: "relate packages or files"}
+)
+
+// spdxRelationshipRules are the per-type rules for SPDX relationships. Types
+// without an entry are only checked for self-relationships and undefined
+// elements.
+var spdxRelationshipRules = map[string]spdxRelationshipRule{
+	"DESCRIBES":    {from: []spdxElementKind{spdxKindDocument}, description: "come from the document"},
+	"DESCRIBED_BY": {to: []spdxElementKind{spdxKindDocument}, description: "point to the document"},
+
+	"CONTAINS":     {from: spdxArtifactKinds, to: spdxContainedKinds, description: "relate a package or file to what it contains"},
+	"CONTAINED_BY": {from: spdxContainedKinds, to: spdxArtifactKinds, description: "relate an element to the package or file containing it"},
+	"PACKAGE_OF":   {from: spdxPackageKinds, description: "come from a package"},
+
+	"DEPENDS_ON":             dependencyRule,
+	"DEPENDENCY_OF":          dependencyRule,
+	"BUILD_DEPENDENCY_OF":    dependencyRule,
+	"DEV_DEPENDENCY_OF":      dependencyRule,
+	"OPTIONAL_DEPENDENCY_OF": dependencyRule,
+	"PROVIDED_DEPENDENCY_OF": dependencyRule,
+	"TEST_DEPENDENCY_OF":     dependencyRule,
+	"RUNTIME_DEPENDENCY_OF":  dependencyRule,
+
+	"STATIC_LINK":  linkRule,
+	"DYNAMIC_LINK": linkRule,
+}
+
+// checkSPDXRelationships checks that the relationships of an SPDX SBOM are
+// used sensibly:
+//
+//   - both elements are defined in the document, or in a referenced external
+//     document;
+//   - NONE and NOASSERTION only appear as the related element;
+//   - no element is related to itself;
+//   - the elements have the kinds the relationship type requires (see
+//     `spdxRelationshipRules`), e.g., DESCRIBES comes from the document and
+//     DEPENDS_ON relates packages.
+//
+// Returns a warning message per problem, prefixed with the JSON path of the
+// relationship, and the number of relationships of each type.
+func checkSPDXRelationships(obj map[string]interface{}) ([]string, map[string]int) {
+	relationships, _ := obj["relationships"].([]interface{})
+	if len(relationships) == 0 {
+		return nil, nil
+	}
+
+	kinds := spdxElementKinds(obj)
+	kindOf := func(id string) (spdxElementKind, bool) {
+		switch {
+		case id == "NONE" || id == "NOASSERTION":
+			return spdxKindNone, true
+		case strings.HasPrefix(id, "DocumentRef-") && strings.Contains(id, ":"):
+			return spdxKindExternal, true
+		}
+		kind, ok := kinds[id]
+		return kind, ok
+	}
+
+	var warnings []string
+	counts := map[string]int{}
+
+	for i, r := range relationships {
+		rel, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("relationships.%d", i)
+		relType, _ := rel["relationshipType"].(string)
+		from, _ := rel["spdxElementId"].(string)
+		to, _ := rel["relatedSpdxElement"].(string)
+		if relType == "" {
+			continue
+		}
+		counts[relType]++
+
+		fromKind, fromDefined := kindOf(from)
+		toKind, toDefined := kindOf(to)
+		if !fromDefined {
+			warnings = append(warnings, fmt.Sprintf("%s.spdxElementId: %s is not defined in the document", path, from))
+		} else if fromKind == spdxKindNone {
+			warnings = append(warnings, fmt.Sprintf("%s.spdxElementId: %s may only be the related element of a relationship", path, from))
+		}
+		if !toDefined {
+			warnings = append(warnings, fmt.Sprintf("%s.relatedSpdxElement: %s is not defined in the document", path, to))
+		}
+		if !fromDefined || !toDefined || fromKind == spdxKindNone {
+			continue
+		}
+
+		if from == to {
+			warnings = append(warnings, fmt.Sprintf("%s: %s has a %s relationship with itself", path, from, relType))
+			continue
+		}
+
+		rule, ok := spdxRelationshipRules[relType]
+		if !ok {
+			continue
+		}
+		if !spdxKindAllowed(fromKind, rule.from) || !spdxKindAllowed(toKind, rule.to) {
+			warnings = append(warnings, fmt.Sprintf("%s: %s relates %s %s to %s %s; it must %s",
+				path, relType, fromKind, from, toKind, to, rule.description))
+		}
+	}
+
+	return warnings, counts
+}
+
+// spdxElementKinds returns the kind of every element defined in an SPDX
+// document, keyed by SPDX ID.
+func spdxElementKinds(obj map[string]interface{}) map[string]spdxElementKind {
+	kinds := map[string]spdxElementKind{}
+
+	documentID, _ := obj["SPDXID"].(string)
+	if documentID == "" {
+		documentID = "SPDXRef-DOCUMENT"
+	}
+	kinds[documentID] = spdxKindDocument
+
+	for field, kind := range map[string]spdxElementKind{
+		"packages": spdxKindPackage,
+		"files":    spdxKindFile,
+		"snippets": spdxKindSnippet,
+	} {
+		elements, _ := obj[field].([]interface{})
+		for _, e := range elements {
+			element, _ := e.(map[string]interface{})
+			if id, ok := element["SPDXID"].(string); ok {
+				kinds[id] = kind
+			}
+		}
+	}
+
+	return kinds
+}
+
+// spdxKindAllowed reports whether an element of the given kind satisfies a
+// rule's list of kinds. Elements of external documents and NONE or
+// NOASSERTION always do, as their kind cannot be checked.
+func spdxKindAllowed(kind spdxElementKind, allowed []spdxElementKind) bool {
+	if allowed == nil || kind == spdxKindExternal || kind == spdxKindNone {
+		return true
+	}
+	return slices.Contains(allowed, kind)
+}
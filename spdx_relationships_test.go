@@ -0,0 +1,105 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckSPDXRelationships(t *testing.T) {
+	document := func(relationships string) map[string]interface{} {
+		obj, err := parseJSON(`{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT",
+			"packages": [{"SPDXID": "SPDXRef-app"}, {"SPDXID": "SPDXRef-lib"}],
+			"files": [{"SPDXID": "SPDXRef-main.c"}],
+			"relationships": [` + relationships + `]}`)
+		if err != nil {
+			t.Fatalf("failed to parse test document: %v", err)
+		}
+		return obj
+	}
+	relationship := func(from, relType, to string) string {
+		return `{"spdxElementId": "` + from + `", "relationshipType": "` + relType + `", "relatedSpdxElement": "` + to + `"}`
+	}
+
+	tests := []struct {
+		name          string
+		relationships []string
+		want          []string
+	}{
+		{
+			name: "Sensible relationships",
+			relationships: []string{
+				relationship("SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-app"),
+				relationship("SPDXRef-app", "DEPENDS_ON", "SPDXRef-lib"),
+				relationship("SPDXRef-lib", "RUNTIME_DEPENDENCY_OF", "SPDXRef-app"),
+				relationship("SPDXRef-app", "CONTAINS", "SPDXRef-main.c"),
+				relationship("SPDXRef-app", "DEPENDS_ON", "DocumentRef-base:SPDXRef-libc"),
+				relationship("SPDXRef-app", "DEPENDS_ON", "NOASSERTION"),
+				relationship("SPDXRef-main.c", "OTHER", "SPDXRef-lib"),
+			},
+		},
+		{
+			name:          "DESCRIBES from a package",
+			relationships: []string{relationship("SPDXRef-app", "DESCRIBES", "SPDXRef-lib")},
+			want:          []string{"relationships.0: DESCRIBES relates package SPDXRef-app to package SPDXRef-lib; it must come from the document"},
+		},
+		{
+			name:          "DEPENDS_ON a file",
+			relationships: []string{relationship("SPDXRef-app", "DEPENDS_ON", "SPDXRef-main.c")},
+			want:          []string{"relationships.0: DEPENDS_ON relates package SPDXRef-app to file SPDXRef-main.c; it must relate packages"},
+		},
+		{
+			name:          "Self-relationship",
+			relationships: []string{relationship("SPDXRef-lib", "DEPENDS_ON", "SPDXRef-lib")},
+			want:          []string{"relationships.0: SPDXRef-lib has a DEPENDS_ON relationship with itself"},
+		},
+		{
+			name:          "Undefined elements",
+			relationships: []string{relationship("SPDXRef-missing", "DEPENDS_ON", "SPDXRef-gone")},
+			want: []string{
+				"relationships.0.spdxElementId: SPDXRef-missing is not defined in the document",
+				"relationships.0.relatedSpdxElement: SPDXRef-gone is not defined in the document",
+			},
+		},
+		{
+			name:          "NONE as the subject",
+			relationships: []string{relationship("NONE", "CONTAINS", "SPDXRef-lib")},
+			want:          []string{"relationships.0.spdxElementId: NONE may only be the related element of a relationship"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := checkSPDXRelationships(document(strings.Join(tt.relationships, ",")))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkSPDXRelationships() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSPDXRelationshipCounts(t *testing.T) {
+	sbom := []byte(strings.Replace(string(spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "MIT"))),
+		`"packages"`, `"relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-a"},
+    {"spdxElementId": "SPDXRef-a", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-b"},
+    {"spdxElementId": "SPDXRef-a", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-a"}
+  ],
+  "packages"`, 1))
+
+	result, err := ValidateSBOMDataStructured(sbom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Errorf("expected relationship warnings not to invalidate the SBOM, got %v", result.ValidationErrors)
+	}
+	if want := map[string]int{"DESCRIBES": 1, "DEPENDS_ON": 2}; !reflect.DeepEqual(result.Relationships, want) {
+		t.Errorf("Relationships = %v, want %v", result.Relationships, want)
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Rule != RuleSPDXRelationship || result.Findings[0].Path != "relationships.2" {
+		t.Errorf("expected one %s finding at relationships.2, got %+v", RuleSPDXRelationship, result.Findings)
+	}
+}
@@ -0,0 +1,432 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sections of an SPDX tag-value document. Element tags apply to the most
+// recent element of their section.
+const (
+	tagSectionDocument     = "document"
+	tagSectionCreationInfo = "creationInfo"
+	tagSectionPackage      = "package"
+	tagSectionFile         = "file"
+	tagSectionSnippet      = "snippet"
+	tagSectionLicense      = "license"
+	tagSectionAnnotation   = "annotation"
+	tagSectionRelationship = "relationship"
+)
+
+// tagField describes how a tag maps to the SPDX JSON object model.
+type tagField struct {
+	section string
+	key     string
+	// list fields may repeat and become JSON arrays
+	list bool
+	// starts begins a new element of the section
+	starts bool
+	parse  func(value string) (interface{}, error)
+}
+
+// spdxTags maps SPDX 2.x tag-value tags to their JSON properties. SPDXID is
+// handled separately since it applies to whichever element is current.
+var spdxTags = map[string]tagField{
+	// document creation information
+	"SPDXVersion":         {section: tagSectionDocument, key: "spdxVersion"},
+	"DataLicense":         {section: tagSectionDocument, key: "dataLicense"},
+	"DocumentName":        {section: tagSectionDocument, key: "name"},
+	"DocumentNamespace":   {section: tagSectionDocument, key: "documentNamespace"},
+	"DocumentComment":     {section: tagSectionDocument, key: "comment"},
+	"ExternalDocumentRef": {section: tagSectionDocument, key: "externalDocumentRefs", list: true, parse: parseExternalDocumentRef},
+	"LicenseListVersion":  {section: tagSectionCreationInfo, key: "licenseListVersion"},
+	"Creator":             {section: tagSectionCreationInfo, key: "creators", list: true},
+	"Created":             {section: tagSectionCreationInfo, key: "created"},
+	"CreatorComment":      {section: tagSectionCreationInfo, key: "comment"},
+
+	// packages
+	"PackageName":                 {section: tagSectionPackage, key: "name", starts: true},
+	"PackageVersion":              {section: tagSectionPackage, key: "versionInfo"},
+	"PackageFileName":             {section: tagSectionPackage, key: "packageFileName"},
+	"PackageSupplier":             {section: tagSectionPackage, key: "supplier"},
+	"PackageOriginator":           {section: tagSectionPackage, key: "originator"},
+	"PackageDownloadLocation":     {section: tagSectionPackage, key: "downloadLocation"},
+	"FilesAnalyzed":               {section: tagSectionPackage, key: "filesAnalyzed", parse: parseTagBool},
+	"PackageVerificationCode":     {section: tagSectionPackage, key: "packageVerificationCode", parse: parseVerificationCode},
+	"PackageChecksum":             {section: tagSectionPackage, key: "checksums", list: true, parse: parseTagChecksum},
+	"PackageHomePage":             {section: tagSectionPackage, key: "homepage"},
+	"PackageSourceInfo":           {section: tagSectionPackage, key: "sourceInfo"},
+	"PackageLicenseConcluded":     {section: tagSectionPackage, key: "licenseConcluded"},
+	"PackageLicenseInfoFromFiles": {section: tagSectionPackage, key: "licenseInfoFromFiles", list: true},
+	"PackageLicenseDeclared":      {section: tagSectionPackage, key: "licenseDeclared"},
+	"PackageLicenseComments":      {section: tagSectionPackage, key: "licenseComments"},
+	"PackageCopyrightText":        {section: tagSectionPackage, key: "copyrightText"},
+	"PackageSummary":              {section: tagSectionPackage, key: "summary"},
+	"PackageDescription":          {section: tagSectionPackage, key: "description"},
+	"PackageComment":              {section: tagSectionPackage, key: "comment"},
+	"PackageAttributionText":      {section: tagSectionPackage, key: "attributionTexts", list: true},
+	"PrimaryPackagePurpose":       {section: tagSectionPackage, key: "primaryPackagePurpose"},
+	"ReleaseDate":                 {section: tagSectionPackage, key: "releaseDate"},
+	"BuiltDate":                   {section: tagSectionPackage, key: "builtDate"},
+	"ValidUntilDate":              {section: tagSectionPackage, key: "validUntilDate"},
+	"ExternalRef":                 {section: tagSectionPackage, key: "externalRefs", list: true, parse: parseExternalRef},
+
+	// files
+	"FileName":            {section: tagSectionFile, key: "fileName", starts: true},
+	"FileType":            {section: tagSectionFile, key: "fileTypes", list: true},
+	"FileChecksum":        {section: tagSectionFile, key: "checksums", list: true, parse: parseTagChecksum},
+	"LicenseInfoInFile":   {section: tagSectionFile, key: "licenseInfoInFiles", list: true},
+	"FileCopyrightText":   {section: tagSectionFile, key: "copyrightText"},
+	"FileComment":         {section: tagSectionFile, key: "comment"},
+	"FileNotice":          {section: tagSectionFile, key: "noticeText"},
+	"FileContributor":     {section: tagSectionFile, key: "fileContributors", list: true},
+	"FileAttributionText": {section: tagSectionFile, key: "attributionTexts", list: true},
+
+	// snippets
+	"SnippetSPDXID":           {section: tagSectionSnippet, key: "SPDXID", starts: true},
+	"SnippetFromFileSPDXID":   {section: tagSectionSnippet, key: "snippetFromFile"},
+	"SnippetByteRange":        {section: tagSectionSnippet, key: "ranges", list: true, parse: parseRange("offset")},
+	"SnippetLineRange":        {section: tagSectionSnippet, key: "ranges", list: true, parse: parseRange("lineNumber")},
+	"SnippetLicenseConcluded": {section: tagSectionSnippet, key: "licenseConcluded"},
+	"LicenseInfoInSnippet":    {section: tagSectionSnippet, key: "licenseInfoInSnippets", list: true},
+	"SnippetLicenseComments":  {section: tagSectionSnippet, key: "licenseComments"},
+	"SnippetCopyrightText":    {section: tagSectionSnippet, key: "copyrightText"},
+	"SnippetComment":          {section: tagSectionSnippet, key: "comment"},
+	"SnippetName":             {section: tagSectionSnippet, key: "name"},
+	"SnippetAttributionText":  {section: tagSectionSnippet, key: "attributionTexts", list: true},
+
+	// other licensing information
+	"LicenseID":             {section: tagSectionLicense, key: "licenseId", starts: true},
+	"ExtractedText":         {section: tagSectionLicense, key: "extractedText"},
+	"LicenseName":           {section: tagSectionLicense, key: "name"},
+	"LicenseCrossReference": {section: tagSectionLicense, key: "seeAlsos", list: true},
+	"LicenseComment":        {section: tagSectionLicense, key: "comment"},
+
+	// relationships and annotations
+	"Relationship":        {section: tagSectionRelationship, starts: true, parse: parseRelationship},
+	"RelationshipComment": {section: tagSectionRelationship, key: "comment"},
+	"Annotator":           {section: tagSectionAnnotation, key: "annotator", starts: true},
+	"AnnotationDate":      {section: tagSectionAnnotation, key: "annotationDate"},
+	"AnnotationType":      {section: tagSectionAnnotation, key: "annotationType"},
+	"SPDXREF":             {section: tagSectionAnnotation, key: "SPDXREF"},
+	"AnnotationComment":   {section: tagSectionAnnotation, key: "comment"},
+}
+
+// sharedElementTags are tags whose meaning depends on the element they follow
+// (a file or a snippet in SPDX 2.x), mapped by section.
+var sharedElementTags = map[string]map[string]string{
+	"LicenseConcluded": {tagSectionFile: "licenseConcluded", tagSectionSnippet: "licenseConcluded"},
+	"LicenseComments":  {tagSectionFile: "licenseComments", tagSectionSnippet: "licenseComments"},
+}
+
+// spdxTagValueConverter builds the JSON object model of a tag-value document.
+type spdxTagValueConverter struct {
+	doc     map[string]interface{}
+	section string
+	// current is the element that element tags apply to
+	current map[string]interface{}
+	// annotations are attached to the element named by their SPDXREF
+	annotations []map[string]interface{}
+	errors      []string
+}
+
+// spdxTagValueToJSON converts an SPDX tag-value document to the equivalent
+// SPDX JSON document, so it can be validated against the JSON schema of its
+// version, which checks mandatory fields and value formats.
+//
+// Lines that are not valid tag-value syntax (unknown tags, tags outside the
+// section they belong to, malformed structured values or unterminated
+// <text> blocks) are returned as errors prefixed with their line number, so
+// they can be reported alongside the schema errors.
+func spdxTagValueToJSON(data []byte) ([]byte, []string, error) {
+	c := &spdxTagValueConverter{
+		doc:     map[string]interface{}{},
+		section: tagSectionDocument,
+	}
+	c.current = c.doc
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := tagLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			c.errorf(lineNumber, "expected \"Tag: value\", got %q", line)
+			continue
+		}
+		tag, value := m[1], strings.TrimSpace(m[2])
+
+		// <text> values may span several lines
+		if strings.HasPrefix(value, "<text>") {
+			text := strings.TrimPrefix(value, "<text>")
+			for !strings.Contains(text, "</text>") {
+				i++
+				if i >= len(lines) {
+					c.errorf(lineNumber, "<text> value of %s is not closed", tag)
+					break
+				}
+				text += "\n" + lines[i]
+			}
+			text, _, _ = strings.Cut(text, "</text>")
+			value = text
+		}
+
+		c.add(lineNumber, tag, value)
+	}
+
+	c.attachAnnotations()
+
+	converted, err := json.Marshal(c.doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return converted, c.errors, nil
+}
+
+func (c *spdxTagValueConverter) errorf(line int, format string, args ...interface{}) {
+	c.errors = append(c.errors, fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, args...)))
+}
+
+// add applies a single tag to the document.
+func (c *spdxTagValueConverter) add(line int, tag, value string) {
+	if tag == "SPDXID" {
+		c.current["SPDXID"] = value
+		return
+	}
+
+	if keys, ok := sharedElementTags[tag]; ok {
+		key, ok := keys[c.section]
+		if !ok {
+			c.errorf(line, "%s must follow a FileName or SnippetSPDXID", tag)
+			return
+		}
+		c.current[key] = value
+		return
+	}
+
+	field, ok := spdxTags[tag]
+	if !ok {
+		c.errorf(line, "unknown tag %q", tag)
+		return
+	}
+
+	var parsed interface{} = value
+	if field.parse != nil {
+		var err error
+		if parsed, err = field.parse(value); err != nil {
+			c.errorf(line, "invalid %s: %v", tag, err)
+			return
+		}
+	}
+
+	var target map[string]interface{}
+	switch {
+	case field.section == tagSectionDocument:
+		target = c.doc
+	case field.section == tagSectionCreationInfo:
+		info, _ := c.doc["creationInfo"].(map[string]interface{})
+		if info == nil {
+			info = map[string]interface{}{}
+			c.doc["creationInfo"] = info
+		}
+		target = info
+	case field.starts:
+		target = c.startElement(field.section)
+	case field.section == c.section:
+		target = c.current
+	default:
+		c.errorf(line, "%s is only valid in the %s section", tag, field.section)
+		return
+	}
+
+	if field.section == tagSectionRelationship && field.starts {
+		for k, v := range parsed.(map[string]interface{}) {
+			target[k] = v
+		}
+		return
+	}
+
+	if field.list {
+		existing, _ := target[field.key].([]interface{})
+		target[field.key] = append(existing, parsed)
+		return
+	}
+	target[field.key] = parsed
+}
+
+// startElement appends a new element of the section to the document and
+// makes it current.
+func (c *spdxTagValueConverter) startElement(section string) map[string]interface{} {
+	element := map[string]interface{}{}
+	c.section = section
+	c.current = element
+
+	key := map[string]string{
+		tagSectionPackage:      "packages",
+		tagSectionFile:         "files",
+		tagSectionSnippet:      "snippets",
+		tagSectionLicense:      "hasExtractedLicensingInfos",
+		tagSectionRelationship: "relationships",
+	}[section]
+
+	if section == tagSectionAnnotation {
+		c.annotations = append(c.annotations, element)
+		return element
+	}
+
+	existing, _ := c.doc[key].([]interface{})
+	c.doc[key] = append(existing, element)
+	return element
+}
+
+// attachAnnotations moves each annotation to the element its SPDXREF names,
+// and completes snippet ranges with the file they point into.
+func (c *spdxTagValueConverter) attachAnnotations() {
+	elements := map[string]map[string]interface{}{}
+	if id, ok := c.doc["SPDXID"].(string); ok {
+		elements[id] = c.doc
+	}
+	for _, key := range []string{"packages", "files", "snippets"} {
+		list, _ := c.doc[key].([]interface{})
+		for _, e := range list {
+			element := e.(map[string]interface{})
+			if id, ok := element["SPDXID"].(string); ok {
+				elements[id] = element
+			}
+		}
+	}
+
+	for _, annotation := range c.annotations {
+		ref, _ := annotation["SPDXREF"].(string)
+		delete(annotation, "SPDXREF")
+		target, ok := elements[ref]
+		if !ok {
+			target = c.doc
+		}
+		existing, _ := target["annotations"].([]interface{})
+		target["annotations"] = append(existing, annotation)
+	}
+
+	snippets, _ := c.doc["snippets"].([]interface{})
+	for _, s := range snippets {
+		snippet := s.(map[string]interface{})
+		file, _ := snippet["snippetFromFile"].(string)
+		ranges, _ := snippet["ranges"].([]interface{})
+		for _, r := range ranges {
+			for _, pointer := range []string{"startPointer", "endPointer"} {
+				r.(map[string]interface{})[pointer].(map[string]interface{})["reference"] = file
+			}
+		}
+	}
+}
+
+func parseTagBool(value string) (interface{}, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not true or false", value)
+	}
+	return b, nil
+}
+
+// parseTagChecksum parses "SHA1: <hex>".
+func parseTagChecksum(value string) (interface{}, error) {
+	algorithm, checksum, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("%q is not of the form \"ALGORITHM: value\"", value)
+	}
+	return map[string]interface{}{
+		"algorithm":     strings.TrimSpace(algorithm),
+		"checksumValue": strings.TrimSpace(checksum),
+	}, nil
+}
+
+// parseExternalDocumentRef parses "DocumentRef-<id> <uri> SHA1: <hex>".
+func parseExternalDocumentRef(value string) (interface{}, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("%q is not of the form \"DocumentRef-id uri SHA1: value\"", value)
+	}
+	checksum, err := parseTagChecksum(strings.Join(fields[2:], " "))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"externalDocumentId": fields[0],
+		"spdxDocument":       fields[1],
+		"checksum":           checksum,
+	}, nil
+}
+
+// parseExternalRef parses "<category> <type> <locator>".
+func parseExternalRef(value string) (interface{}, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("%q is not of the form \"category type locator\"", value)
+	}
+	return map[string]interface{}{
+		"referenceCategory": fields[0],
+		"referenceType":     fields[1],
+		"referenceLocator":  fields[2],
+	}, nil
+}
+
+// parseVerificationCode parses "<hex>" or "<hex> (excludes: <file>, ...)".
+func parseVerificationCode(value string) (interface{}, error) {
+	code, excludes, hasExcludes := strings.Cut(value, "(")
+	result := map[string]interface{}{"packageVerificationCodeValue": strings.TrimSpace(code)}
+
+	if hasExcludes {
+		excludes, ok := strings.CutSuffix(strings.TrimSpace(excludes), ")")
+		if !ok {
+			return nil, fmt.Errorf("%q has an unterminated excludes list", value)
+		}
+		excludes = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(excludes), "excludes:"))
+		var files []interface{}
+		for _, file := range strings.Split(excludes, ",") {
+			if file = strings.TrimSpace(file); file != "" {
+				files = append(files, file)
+			}
+		}
+		result["packageVerificationCodeExcludedFiles"] = files
+	}
+	return result, nil
+}
+
+// parseRelationship parses "<element> <TYPE> <related element>".
+func parseRelationship(value string) (interface{}, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("%q is not of the form \"element TYPE element\"", value)
+	}
+	return map[string]interface{}{
+		"spdxElementId":      fields[0],
+		"relationshipType":   fields[1],
+		"relatedSpdxElement": fields[2],
+	}, nil
+}
+
+// parseRange returns a parser for "<start>:<end>" snippet ranges, using the
+// given pointer property ("offset" or "lineNumber"). The file reference is
+// filled in once the whole snippet has been read.
+func parseRange(pointer string) func(string) (interface{}, error) {
+	return func(value string) (interface{}, error) {
+		start, end, ok := strings.Cut(value, ":")
+		startN, startErr := strconv.Atoi(strings.TrimSpace(start))
+		endN, endErr := strconv.Atoi(strings.TrimSpace(end))
+		if !ok || startErr != nil || endErr != nil {
+			return nil, fmt.Errorf("%q is not of the form \"start:end\"", value)
+		}
+		return map[string]interface{}{
+			"startPointer": map[string]interface{}{pointer: startN},
+			"endPointer":   map[string]interface{}{pointer: endN},
+		}, nil
+	}
+}
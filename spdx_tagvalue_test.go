@@ -0,0 +1,167 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSPDXTagValueToJSON(t *testing.T) {
+	input := tagValueHeader + `
+PackageName: lib-a
+SPDXID: SPDXRef-lib-a
+PackageDownloadLocation: NOASSERTION
+FilesAnalyzed: true
+PackageVerificationCode: d6a770ba38583ed4bb4525bd96e50461655d2758 (excludes: ./package.spdx, ./other.spdx)
+PackageComment: <text>first line
+second line</text>
+ExternalRef: PACKAGE-MANAGER purl pkg:npm/lib-a@1.0.0
+
+FileName: ./src/a.c
+SPDXID: SPDXRef-file-a
+FileChecksum: SHA1: d6a770ba38583ed4bb4525bd96e50461655d2758
+LicenseConcluded: MIT
+
+SnippetSPDXID: SPDXRef-snippet-a
+SnippetFromFileSPDXID: SPDXRef-file-a
+SnippetByteRange: 310:420
+SnippetLicenseConcluded: MIT
+
+LicenseID: LicenseRef-custom
+ExtractedText: <text>Custom terms</text>
+
+Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-lib-a
+RelationshipComment: main package
+
+Annotator: Person: Jane
+AnnotationDate: 2024-10-22T12:00:00Z
+AnnotationType: REVIEW
+SPDXREF: SPDXRef-lib-a
+AnnotationComment: reviewed
+`
+
+	converted, syntaxErrors, err := spdxTagValueToJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(syntaxErrors) > 0 {
+		t.Fatalf("Unexpected syntax errors: %v", syntaxErrors)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(converted, &doc); err != nil {
+		t.Fatalf("Converted document is not JSON: %v", err)
+	}
+
+	pkg := doc["packages"].([]interface{})[0].(map[string]interface{})
+	checks := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"creators", doc["creationInfo"].(map[string]interface{})["creators"], []interface{}{"Tool: test"}},
+		{"filesAnalyzed", pkg["filesAnalyzed"], true},
+		{"packageVerificationCode", pkg["packageVerificationCode"], map[string]interface{}{
+			"packageVerificationCodeValue":         "d6a770ba38583ed4bb4525bd96e50461655d2758",
+			"packageVerificationCodeExcludedFiles": []interface{}{"./package.spdx", "./other.spdx"},
+		}},
+		{"comment", pkg["comment"], "first line\nsecond line"},
+		{"externalRefs", pkg["externalRefs"], []interface{}{map[string]interface{}{
+			"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/lib-a@1.0.0",
+		}}},
+		{"annotations", pkg["annotations"], []interface{}{map[string]interface{}{
+			"annotator": "Person: Jane", "annotationDate": "2024-10-22T12:00:00Z", "annotationType": "REVIEW", "comment": "reviewed",
+		}}},
+		{"file checksum", doc["files"].([]interface{})[0].(map[string]interface{})["checksums"], []interface{}{
+			map[string]interface{}{"algorithm": "SHA1", "checksumValue": "d6a770ba38583ed4bb4525bd96e50461655d2758"},
+		}},
+		{"file license", doc["files"].([]interface{})[0].(map[string]interface{})["licenseConcluded"], "MIT"},
+		{"snippet range", doc["snippets"].([]interface{})[0].(map[string]interface{})["ranges"], []interface{}{map[string]interface{}{
+			"startPointer": map[string]interface{}{"offset": float64(310), "reference": "SPDXRef-file-a"},
+			"endPointer":   map[string]interface{}{"offset": float64(420), "reference": "SPDXRef-file-a"},
+		}}},
+		{"extracted license", doc["hasExtractedLicensingInfos"], []interface{}{map[string]interface{}{
+			"licenseId": "LicenseRef-custom", "extractedText": "Custom terms",
+		}}},
+		{"relationships", doc["relationships"], []interface{}{map[string]interface{}{
+			"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-lib-a", "comment": "main package",
+		}}},
+	}
+
+	for _, check := range checks {
+		if !reflect.DeepEqual(check.got, check.want) {
+			t.Errorf("%s = %#v, want %#v", check.name, check.got, check.want)
+		}
+	}
+}
+
+func TestSPDXTagValueSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "Unknown tag", input: "Colour: blue\n", wantErr: `line 9: unknown tag "Colour"`},
+		{name: "Not a tag", input: "just text\n", wantErr: `line 9: expected "Tag: value"`},
+		{name: "Package tag outside a package", input: "PackageVersion: 1.0\n", wantErr: "line 9: PackageVersion is only valid in the package section"},
+		{name: "File tag outside a file", input: "LicenseConcluded: MIT\n", wantErr: "line 9: LicenseConcluded must follow a FileName or SnippetSPDXID"},
+		{name: "Malformed checksum", input: "PackageName: a\nPackageChecksum: abc\n", wantErr: "line 10: invalid PackageChecksum"},
+		{name: "Malformed boolean", input: "PackageName: a\nFilesAnalyzed: maybe\n", wantErr: "line 10: invalid FilesAnalyzed"},
+		{name: "Unterminated text", input: "DocumentComment: <text>open\n", wantErr: "line 9: <text> value of DocumentComment is not closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, syntaxErrors, err := spdxTagValueToJSON([]byte(tagValueHeader + "\n" + tt.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(syntaxErrors) != 1 || !strings.HasPrefix(syntaxErrors[0], tt.wantErr) {
+				t.Errorf("Expected one error starting with %q, got %v", tt.wantErr, syntaxErrors)
+			}
+		})
+	}
+}
+
+func TestValidateSBOMDataTagValue(t *testing.T) {
+	sample, err := os.ReadFile("sample-sboms/sample-2.3.spdx")
+	if err != nil {
+		t.Fatalf("Failed to read sample: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		wantValid bool
+		wantError string
+	}{
+		{name: "Sample document", input: string(sample), wantValid: true},
+		{name: "Missing mandatory field", input: strings.Replace(tagValueHeader, "DocumentNamespace: https://example.com/test\n", "", 1),
+			wantError: "documentNamespace is required"},
+		{name: "Invalid value format", input: tagValueHeader + "PackageName: a\nSPDXID: SPDXRef-a\nPackageDownloadLocation: NONE\nPrimaryPackagePurpose: GADGET\n",
+			wantError: "packages.0.primaryPackagePurpose"},
+		{name: "Syntax error", input: tagValueHeader + "Colour: blue\n", wantError: `line 8: unknown tag "Colour"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMData([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.DetectedFormat != "tag-value" || result.SBOMType != SBOM_SPDX || result.SBOMVersion != "2.3" {
+				t.Errorf("Unexpected result: %+v", result)
+			}
+			if result.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v (errors: %v)", result.IsValid, tt.wantValid, result.ValidationErrors)
+			}
+			if tt.wantError != "" && !strings.Contains(strings.Join(result.ValidationErrors, "\n"), tt.wantError) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantError, result.ValidationErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SpecReference points a validation error at the clause of the specification
+// that defines the offending field.
+type SpecReference struct {
+	Path    string `json:"path"`
+	Section string `json:"section"`
+	URL     string `json:"url"`
+}
+
+// spdxClause is a field defined by the SPDX specification. Clause numbers are
+// those of SPDX 2.3; SPDX 2.2 numbers its chapters four lower (e.g., package
+// information is chapter 3 in 2.2 and chapter 7 in 2.3) but keeps the clause
+// order within a chapter.
+type spdxClause struct {
+	pattern string
+	chapter int
+	clause  int
+	title   string
+	// since23 marks fields introduced in SPDX 2.3
+	since23 bool
+}
+
+// spdxChapters maps a top-level SPDX property to its chapter and the path of
+// that chapter on the published specification site.
+var spdxChapters = map[string]struct {
+	chapter int
+	title   string
+	slug    string
+}{
+	"":                           {6, "Document creation information", "document-creation-information"},
+	"creationInfo":               {6, "Document creation information", "document-creation-information"},
+	"packages":                   {7, "Package information", "package-information"},
+	"files":                      {8, "File information", "file-information"},
+	"snippets":                   {9, "Snippet information", "snippet-information"},
+	"hasExtractedLicensingInfos": {10, "Other licensing information detected", "other-licensing-information-detected"},
+	"relationships":              {11, "Relationships between SPDX elements", "relationships-between-SPDX-elements"},
+	"annotations":                {12, "Annotations", "annotations"},
+}
+
+var spdxClauses = []spdxClause{
+	{pattern: "spdxVersion", chapter: 6, clause: 1, title: "SPDX version field"},
+	{pattern: "dataLicense", chapter: 6, clause: 2, title: "Data license field"},
+	{pattern: "SPDXID", chapter: 6, clause: 3, title: "SPDX identifier field"},
+	{pattern: "name", chapter: 6, clause: 4, title: "Document name field"},
+	{pattern: "documentNamespace", chapter: 6, clause: 5, title: "SPDX document namespace field"},
+	{pattern: "externalDocumentRefs", chapter: 6, clause: 6, title: "External document references field"},
+	{pattern: "creationInfo.licenseListVersion", chapter: 6, clause: 7, title: "License list version field"},
+	{pattern: "creationInfo.creators", chapter: 6, clause: 8, title: "Creator field"},
+	{pattern: "creationInfo.created", chapter: 6, clause: 9, title: "Created field"},
+	{pattern: "creationInfo.comment", chapter: 6, clause: 10, title: "Creator comment field"},
+	{pattern: "comment", chapter: 6, clause: 11, title: "Document comment field"},
+
+	{pattern: "packages[].name", chapter: 7, clause: 1, title: "Package name field"},
+	{pattern: "packages[].SPDXID", chapter: 7, clause: 2, title: "Package SPDX identifier field"},
+	{pattern: "packages[].versionInfo", chapter: 7, clause: 3, title: "Package version field"},
+	{pattern: "packages[].packageFileName", chapter: 7, clause: 4, title: "Package file name field"},
+	{pattern: "packages[].supplier", chapter: 7, clause: 5, title: "Package supplier field"},
+	{pattern: "packages[].originator", chapter: 7, clause: 6, title: "Package originator field"},
+	{pattern: "packages[].downloadLocation", chapter: 7, clause: 7, title: "Package download location field"},
+	{pattern: "packages[].filesAnalyzed", chapter: 7, clause: 8, title: "Files analyzed field"},
+	{pattern: "packages[].packageVerificationCode", chapter: 7, clause: 9, title: "Package verification code field"},
+	{pattern: "packages[].checksums", chapter: 7, clause: 10, title: "Package checksum field"},
+	{pattern: "packages[].homepage", chapter: 7, clause: 11, title: "Package home page field"},
+	{pattern: "packages[].sourceInfo", chapter: 7, clause: 12, title: "Source information field"},
+	{pattern: "packages[].licenseConcluded", chapter: 7, clause: 13, title: "Concluded license field"},
+	{pattern: "packages[].licenseInfoFromFiles", chapter: 7, clause: 14, title: "All licenses information from files field"},
+	{pattern: "packages[].licenseDeclared", chapter: 7, clause: 15, title: "Declared license field"},
+	{pattern: "packages[].licenseComments", chapter: 7, clause: 16, title: "Comments on license field"},
+	{pattern: "packages[].copyrightText", chapter: 7, clause: 17, title: "Copyright text field"},
+	{pattern: "packages[].summary", chapter: 7, clause: 18, title: "Package summary description field"},
+	{pattern: "packages[].description", chapter: 7, clause: 19, title: "Package detailed description field"},
+	{pattern: "packages[].comment", chapter: 7, clause: 20, title: "Package comment field"},
+	{pattern: "packages[].externalRefs", chapter: 7, clause: 21, title: "External reference field"},
+	{pattern: "packages[].attributionTexts", chapter: 7, clause: 23, title: "Package attribution text field"},
+	{pattern: "packages[].primaryPackagePurpose", chapter: 7, clause: 24, title: "Primary package purpose field", since23: true},
+	{pattern: "packages[].releaseDate", chapter: 7, clause: 25, title: "Release date field", since23: true},
+	{pattern: "packages[].builtDate", chapter: 7, clause: 26, title: "Built date field", since23: true},
+	{pattern: "packages[].validUntilDate", chapter: 7, clause: 27, title: "Valid until date field", since23: true},
+
+	{pattern: "files[].fileName", chapter: 8, clause: 1, title: "File name field"},
+	{pattern: "files[].SPDXID", chapter: 8, clause: 2, title: "File SPDX identifier field"},
+	{pattern: "files[].fileTypes", chapter: 8, clause: 3, title: "File type field"},
+	{pattern: "files[].checksums", chapter: 8, clause: 4, title: "File checksum field"},
+	{pattern: "files[].licenseConcluded", chapter: 8, clause: 5, title: "Concluded license field"},
+	{pattern: "files[].licenseInfoInFiles", chapter: 8, clause: 6, title: "License information in file field"},
+	{pattern: "files[].licenseComments", chapter: 8, clause: 7, title: "Comments on license field"},
+	{pattern: "files[].copyrightText", chapter: 8, clause: 8, title: "Copyright text field"},
+	{pattern: "files[].comment", chapter: 8, clause: 12, title: "File comment field"},
+	{pattern: "files[].noticeText", chapter: 8, clause: 13, title: "File notice field"},
+	{pattern: "files[].fileContributors", chapter: 8, clause: 14, title: "File contributor field"},
+	{pattern: "files[].attributionTexts", chapter: 8, clause: 15, title: "File attribution text field"},
+
+	{pattern: "hasExtractedLicensingInfos[].licenseId", chapter: 10, clause: 1, title: "License identifier field"},
+	{pattern: "hasExtractedLicensingInfos[].extractedText", chapter: 10, clause: 2, title: "Extracted text field"},
+	{pattern: "hasExtractedLicensingInfos[].name", chapter: 10, clause: 3, title: "License name field"},
+	{pattern: "hasExtractedLicensingInfos[].seeAlsos", chapter: 10, clause: 4, title: "License cross reference field"},
+	{pattern: "hasExtractedLicensingInfos[].comment", chapter: 10, clause: 5, title: "License comment field"},
+
+	{pattern: "relationships[].spdxElementId", chapter: 11, clause: 1, title: "Relationship field"},
+	{pattern: "relationships[].relationshipType", chapter: 11, clause: 1, title: "Relationship field"},
+	{pattern: "relationships[].relatedSpdxElement", chapter: 11, clause: 1, title: "Relationship field"},
+	{pattern: "relationships[].comment", chapter: 11, clause: 2, title: "Relationship comment field"},
+
+	{pattern: "annotations[].annotator", chapter: 12, clause: 1, title: "Annotator field"},
+	{pattern: "annotations[].annotationDate", chapter: 12, clause: 2, title: "Annotation date field"},
+	{pattern: "annotations[].annotationType", chapter: 12, clause: 3, title: "Annotation type field"},
+	{pattern: "annotations[].comment", chapter: 12, clause: 5, title: "Annotation comment field"},
+}
+
+// SpecReferenceFor returns the specification reference for a validation error
+// in `ValidationErrors`, if one is known.
+//
+// Example:
+//
+//	for _, msg := range result.ValidationErrors {
+//	    if ref, ok := result.SpecReferenceFor(msg); ok {
+//	        fmt.Printf("%s (see %s: %s)\n", msg, ref.Section, ref.URL)
+//	    }
+//	}
+func (r *ValidationResult) SpecReferenceFor(msg string) (SpecReference, bool) {
+	if r == nil {
+		return SpecReference{}, false
+	}
+
+	path := errorFieldPath(msg)
+	for _, ref := range r.SpecReferences {
+		if ref.Path == path {
+			return ref, true
+		}
+	}
+	return SpecReference{}, false
+}
+
+// specReferences returns a reference for every validation error whose field
+// can be tied to a clause of the specification, de-duplicated by path.
+//
+// sbomType is the detected type (e.g., "CycloneDX" or "SPDX-2.3") and
+// schemaVersion the version of the schema the errors were produced against.
+func specReferences(sbomType, schemaVersion string, validationErrors []string) []SpecReference {
+	paths := make([]string, len(validationErrors))
+	for i, msg := range validationErrors {
+		paths[i] = errorFieldPath(msg)
+	}
+	return specReferencesForPaths(sbomType, schemaVersion, paths)
+}
+
+// specReferencesForPaths returns a reference for every field path that can
+// be tied to a clause of the specification, de-duplicated by path.
+func specReferencesForPaths(sbomType, schemaVersion string, paths []string) []SpecReference {
+	var references []SpecReference
+	seen := map[string]bool{}
+
+	for _, path := range paths {
+		if path == "" || seen[path] {
+			continue
+		}
+
+		var reference SpecReference
+		var ok bool
+		if sbomType == SBOM_CYCLONEDX {
+			reference, ok = cycloneDXSpecReference(schemaVersion, path)
+		} else if strings.HasPrefix(sbomType, SBOM_SPDX) {
+			reference, ok = spdxSpecReference(schemaVersion, path)
+		}
+
+		if ok {
+			seen[path] = true
+			references = append(references, reference)
+		}
+	}
+
+	return references
+}
+
+// errorFieldPath extracts the path of the offending field from a validation
+// error. For "is required" errors, which are reported against the parent
+// object, the missing property is appended.
+func errorFieldPath(msg string) string {
+	i := strings.Index(msg, ": ")
+	if i < 0 {
+		return ""
+	}
+	path, description := msg[:i], msg[i+2:]
+	if path == "(root)" {
+		path = ""
+	}
+
+	if property, ok := strings.CutSuffix(description, " is required"); ok && !strings.Contains(property, " ") {
+		path = joinJSONPath(path, property)
+	}
+
+	return path
+}
+
+// findingFieldPath returns the path of the offending field of a schema
+// finding, like errorFieldPath does for its message. Only "required" messages
+// are rendered, so those of the other findings stay deferred.
+func findingFieldPath(f Finding) string {
+	if f.Keyword == "required" {
+		return errorFieldPath(f.String())
+	}
+	if f.Path == "(root)" {
+		return ""
+	}
+	return f.Path
+}
+
+// genericPath replaces array indexes in a dotted path with "[]" suffixes on the
+// preceding property, e.g. "packages.0.name" becomes "packages[].name".
+func genericPath(path string) string {
+	var parts []string
+	for _, segment := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(segment); err == nil && len(parts) > 0 {
+			parts[len(parts)-1] += "[]"
+			continue
+		}
+		parts = append(parts, segment)
+	}
+	return strings.Join(parts, ".")
+}
+
+// cycloneDXSpecReference links to the CycloneDX JSON reference, whose anchors
+// join property names with underscores and use "items" for array elements
+// (e.g., "components_items_purl").
+func cycloneDXSpecReference(version, path string) (SpecReference, bool) {
+	if version == "" {
+		return SpecReference{}, false
+	}
+
+	var anchor []string
+	for _, segment := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(segment); err == nil {
+			anchor = append(anchor, "items")
+		} else {
+			anchor = append(anchor, segment)
+		}
+	}
+
+	return SpecReference{
+		Path:    path,
+		Section: genericPath(path),
+		URL:     fmt.Sprintf("https://cyclonedx.org/docs/%s/json/#%s", version, strings.Join(anchor, "_")),
+	}, true
+}
+
+// spdxSpecReference links to the chapter of the SPDX specification that
+// defines the field, naming the clause when it is known.
+func spdxSpecReference(version, path string) (SpecReference, bool) {
+	if strings.HasPrefix(version, SBOM_SPDX) {
+		v, err := getSPDXVersion(version)
+		if err != nil {
+			return SpecReference{}, false
+		}
+		version = v
+	}
+
+	// SPDX 2.2 chapters are numbered four lower than in 2.3
+	offset := 0
+	switch version {
+	case "2.3":
+	case "2.2":
+		offset = -4
+	default:
+		return SpecReference{}, false
+	}
+
+	generic := genericPath(path)
+	top := strings.TrimSuffix(strings.SplitN(generic, ".", 2)[0], "[]")
+	chapter, ok := spdxChapters[top]
+	if !ok {
+		// a top-level document field
+		chapter = spdxChapters[""]
+	}
+
+	section := fmt.Sprintf("%d %s", chapter.chapter+offset, chapter.title)
+	for _, clause := range spdxClauses {
+		if clause.pattern == generic && (!clause.since23 || version == "2.3") {
+			section = fmt.Sprintf("%d.%d %s", clause.chapter+offset, clause.clause, clause.title)
+			break
+		}
+	}
+
+	urlVersion := version
+	if version == "2.2" {
+		urlVersion = "2.2.2"
+	}
+
+	return SpecReference{
+		Path:    path,
+		Section: section,
+		URL:     fmt.Sprintf("https://spdx.github.io/spdx-spec/v%s/%s/", urlVersion, chapter.slug),
+	}, true
+}
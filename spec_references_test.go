@@ -0,0 +1,119 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import "testing"
+
+func TestErrorFieldPath(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{msg: "packages.0.name: Invalid type. Expected: string, given: integer", want: "packages.0.name"},
+		{msg: "packages.0: downloadLocation is required", want: "packages.0.downloadLocation"},
+		{msg: "(root): dataLicense is required", want: "dataLicense"},
+		{msg: "(root): Additional property foo is not allowed", want: ""},
+		{msg: "no separator", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := errorFieldPath(tt.msg); got != tt.want {
+			t.Errorf("errorFieldPath(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestSpecReferences(t *testing.T) {
+	tests := []struct {
+		name        string
+		sbomType    string
+		version     string
+		errors      []string
+		wantSection string
+		wantURL     string
+	}{
+		{
+			name:        "CycloneDX nested component field",
+			sbomType:    SBOM_CYCLONEDX,
+			version:     "1.6",
+			errors:      []string{"components.3.components.0.purl: Invalid type. Expected: string, given: integer"},
+			wantSection: "components[].components[].purl",
+			wantURL:     "https://cyclonedx.org/docs/1.6/json/#components_items_components_items_purl",
+		},
+		{
+			name:        "SPDX 2.3 package clause",
+			sbomType:    "SPDX-2.3",
+			version:     "SPDX-2.3",
+			errors:      []string{"packages.0: downloadLocation is required"},
+			wantSection: "7.7 Package download location field",
+			wantURL:     "https://spdx.github.io/spdx-spec/v2.3/package-information/",
+		},
+		{
+			name:        "SPDX 2.2 renumbered chapter",
+			sbomType:    "SPDX-2.2",
+			version:     "SPDX-2.2",
+			errors:      []string{"packages.0: downloadLocation is required"},
+			wantSection: "3.7 Package download location field",
+			wantURL:     "https://spdx.github.io/spdx-spec/v2.2.2/package-information/",
+		},
+		{
+			name:        "SPDX 2.2 field introduced in 2.3 falls back to chapter",
+			sbomType:    "SPDX-2.2",
+			version:     "SPDX-2.2",
+			errors:      []string{"packages.0.releaseDate: Additional property releaseDate is not allowed"},
+			wantSection: "3 Package information",
+			wantURL:     "https://spdx.github.io/spdx-spec/v2.2.2/package-information/",
+		},
+		{
+			name:        "SPDX document field",
+			sbomType:    "SPDX-2.3",
+			version:     "SPDX-2.3",
+			errors:      []string{"(root): creationInfo is required"},
+			wantSection: "6 Document creation information",
+			wantURL:     "https://spdx.github.io/spdx-spec/v2.3/document-creation-information/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			references := specReferences(tt.sbomType, tt.version, tt.errors)
+			if len(references) != 1 {
+				t.Fatalf("got %d references, want 1: %+v", len(references), references)
+			}
+			if references[0].Section != tt.wantSection {
+				t.Errorf("Section = %q, want %q", references[0].Section, tt.wantSection)
+			}
+			if references[0].URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", references[0].URL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestValidateSBOMDataSpecReferences(t *testing.T) {
+	result, err := ValidateSBOMData([]byte(`{"spdxVersion": "SPDX-2.3", "packages": [{"name": 5}]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, ref := range result.SpecReferences {
+		if ref.Path == "packages.0.name" {
+			if ref.Section != "7.1 Package name field" {
+				t.Errorf("Section = %q", ref.Section)
+			}
+			return
+		}
+	}
+	t.Errorf("no reference for packages.0.name in %+v", result.SpecReferences)
+}
+
+func TestSpecReferenceFor(t *testing.T) {
+	result := &ValidationResult{SpecReferences: []SpecReference{{Path: "packages.0.downloadLocation", Section: "7.7"}}}
+
+	if ref, ok := result.SpecReferenceFor("packages.0: downloadLocation is required"); !ok || ref.Section != "7.7" {
+		t.Errorf("SpecReferenceFor() = %+v, %v", ref, ok)
+	}
+	if _, ok := result.SpecReferenceFor("packages.1: downloadLocation is required"); ok {
+		t.Errorf("expected no reference for an unrelated error")
+	}
+}
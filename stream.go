@@ -0,0 +1,198 @@
+package sbomvalidator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// componentBufferSize bounds how many decoded components may be waiting for
+// the checker at once, which caps memory regardless of the SBOM size.
+const componentBufferSize = 64
+
+// StreamCheckResult represents the outcome of StreamComponentChecks.
+type StreamCheckResult struct {
+	SBOMType         string   `json:"sbomType,omitempty"`
+	ComponentCount   int      `json:"componentCount"`
+	ValidationErrors []string `json:"validationErrors,omitempty"`
+	Warnings         []string `json:"warnings,omitempty"`
+}
+
+// streamedComponent is a single top-level component handed from the decoder
+// to the checker.
+type streamedComponent struct {
+	path      string
+	component map[string]interface{}
+	// metadata marks metadata.component, which is checked but not counted
+	metadata bool
+	// countOnly marks SPDX packages, which have no per-component checks yet
+	countOnly bool
+}
+
+// StreamComponentChecks runs the per-component checks (OmniBOR identifiers and
+// purl/CPE/SWID agreement) over an SBOM without materializing it.
+//
+// The document is tokenized once; each element of the top-level "components"
+// (CycloneDX) or "packages" (SPDX) array is decoded on its own and handed to
+// the checker through a bounded buffer, so memory use depends on the size of a
+// single component rather than the whole SBOM. This makes it possible to check
+// SBOMs with millions of components.
+//
+// Schema validation needs the whole document and is not performed; use
+// `ValidateSBOMData` for that.
+//
+// Parameters:
+//   - r: A reader supplying the SBOM JSON data.
+//
+// Returns:
+//   - A StreamCheckResult with the errors and warnings found.
+//   - An error if the JSON is malformed or the SBOM type cannot be detected.
+//
+// Example:
+//
+//	f, _ := os.Open("huge.cdx.json")
+//	defer f.Close()
+//	result, err := StreamComponentChecks(f)
+func StreamComponentChecks(r io.Reader) (*StreamCheckResult, error) {
+	result := &StreamCheckResult{}
+
+	components := make(chan streamedComponent, componentBufferSize)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for c := range components {
+			if c.metadata {
+				result.ValidationErrors = append(result.ValidationErrors, componentOmniBORErrors(c.path, c.component)...)
+				result.Warnings = append(result.Warnings, componentIdentifierWarnings(c.path, c.component)...)
+				continue
+			}
+
+			result.ComponentCount++
+			if c.countOnly {
+				continue
+			}
+			walkCycloneDXComponent(c.path, c.component, func(path string, component map[string]interface{}) {
+				result.ValidationErrors = append(result.ValidationErrors, componentOmniBORErrors(path, component)...)
+				result.Warnings = append(result.Warnings, componentIdentifierWarnings(path, component)...)
+			})
+		}
+	}()
+
+	sbomType, err := streamTopLevel(r, components)
+	close(components)
+	<-done
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if sbomType == "" {
+		return nil, fmt.Errorf("unknown SBOM type or missing required fields")
+	}
+
+	result.SBOMType = sbomType
+	if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		result.SBOMType = SBOM_SPDX
+	}
+
+	return result, nil
+}
+
+// streamTopLevel scans the top-level object, sending each element of the
+// "components" or "packages" array to out and checking metadata.component.
+// It returns the SBOM type found in "bomFormat" or "spdxVersion".
+func streamTopLevel(r io.Reader, out chan<- streamedComponent) (string, error) {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return "", fmt.Errorf("expected a JSON object")
+	}
+
+	sbomType := ""
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		key, _ := keyTok.(string)
+
+		switch key {
+		case "components", "packages":
+			if err := streamArray(dec, key, out); err != nil {
+				return "", err
+			}
+
+		case "metadata":
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return "", err
+			}
+			metadata, _ := value.(map[string]interface{})
+			if component, ok := metadata["component"].(map[string]interface{}); ok {
+				out <- streamedComponent{path: "metadata.component", component: component, metadata: true}
+			}
+
+		case "bomFormat", "spdxVersion":
+			valueTok, err := dec.Token()
+			if err != nil {
+				return "", err
+			}
+			if value, ok := valueTok.(string); ok && sbomType == "" {
+				sbomType = value
+			} else if delim, ok := valueTok.(json.Delim); ok {
+				if err := skipJSONValue(dec, delim); err != nil {
+					return "", err
+				}
+			}
+
+		default:
+			valueTok, err := dec.Token()
+			if err != nil {
+				return "", err
+			}
+			if delim, ok := valueTok.(json.Delim); ok {
+				if err := skipJSONValue(dec, delim); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	return sbomType, nil
+}
+
+// streamArray decodes the elements of an array one at a time.
+func streamArray(dec *json.Decoder, key string, out chan<- streamedComponent) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		// not an array (e.g., null); nothing to stream
+		return nil
+	}
+	if delim != '[' {
+		return skipJSONValue(dec, delim)
+	}
+
+	for i := 0; dec.More(); i++ {
+		var element interface{}
+		if err := dec.Decode(&element); err != nil {
+			return err
+		}
+		// non-object elements are a schema problem, not a streaming one
+		if component, ok := element.(map[string]interface{}); ok {
+			out <- streamedComponent{path: fmt.Sprintf("%s.%d", key, i), component: component, countOnly: key == "packages"}
+		}
+	}
+
+	// consume the closing bracket
+	_, err = dec.Token()
+	return err
+}
@@ -0,0 +1,104 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreamComponentChecks(t *testing.T) {
+	tests := []struct {
+		name         string
+		jsonData     string
+		wantType     string
+		wantCount    int
+		wantErrors   int
+		wantWarnings int
+		expectErr    bool
+	}{
+		{
+			name: "CycloneDX with findings and bomFormat last",
+			jsonData: `{
+				"metadata": {"component": {"name": "app", "purl": "pkg:npm/app@1.0.0", "cpe": "cpe:2.3:a:x:app:2.0.0"}},
+				"components": [
+					{"name": "a", "omniborId": ["not-a-gitoid"]},
+					{"name": "b", "components": [{"name": "c", "purl": "pkg:npm/c@1.0.0", "swid": {"tagId": "c", "name": "d"}}]},
+					"not-an-object"
+				],
+				"bomFormat": "CycloneDX"
+			}`,
+			wantType:     "CycloneDX",
+			wantCount:    2,
+			wantErrors:   1,
+			wantWarnings: 2,
+		},
+		{
+			name:      "SPDX packages are counted",
+			jsonData:  `{"spdxVersion": "SPDX-2.3", "packages": [{"name": "a"}, {"name": "b"}]}`,
+			wantType:  "SPDX",
+			wantCount: 2,
+		},
+		{
+			name:      "Missing type",
+			jsonData:  `{"components": []}`,
+			expectErr: true,
+		},
+		{
+			name:      "Malformed component",
+			jsonData:  `{"bomFormat": "CycloneDX", "components": [{"name": }]}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := StreamComponentChecks(strings.NewReader(tt.jsonData))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("StreamComponentChecks() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if result.SBOMType != tt.wantType {
+				t.Errorf("SBOMType = %q, want %q", result.SBOMType, tt.wantType)
+			}
+			if result.ComponentCount != tt.wantCount {
+				t.Errorf("ComponentCount = %d, want %d", result.ComponentCount, tt.wantCount)
+			}
+			if len(result.ValidationErrors) != tt.wantErrors {
+				t.Errorf("ValidationErrors = %v, want %d", result.ValidationErrors, tt.wantErrors)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("Warnings = %v, want %d", result.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+// TestStreamComponentChecksLarge streams a generated SBOM through a pipe so the
+// document never exists in memory as a whole.
+func TestStreamComponentChecksLarge(t *testing.T) {
+	const count = 20000
+
+	pr, pw := io.Pipe()
+	go func() {
+		fmt.Fprint(pw, `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [`)
+		for i := 0; i < count; i++ {
+			if i > 0 {
+				fmt.Fprint(pw, ",")
+			}
+			fmt.Fprintf(pw, `{"type": "library", "name": "lib%d", "purl": "pkg:npm/lib%d@1.0.0"}`, i, i)
+		}
+		fmt.Fprint(pw, `]}`)
+		pw.Close()
+	}()
+
+	result, err := StreamComponentChecks(pr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.ComponentCount != count {
+		t.Errorf("ComponentCount = %d, want %d", result.ComponentCount, count)
+	}
+}
@@ -0,0 +1,230 @@
+package sbomvalidator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// suppressionDateLayout is the layout of `Suppression.Expires`.
+const suppressionDateLayout = "2006-01-02"
+
+// Suppression is a temporary exception to a rule for one component, e.g. a
+// license finding accepted until a dependency is replaced. It drops the
+// findings of the rule on the component, and on its fields, until it
+// expires; suppressed findings are still listed in `StructuredResult.Suppressed`
+// with the suppression, so reports show every exception in force.
+//
+// Every field is required: a suppression without a justification or an
+// expiry date suppresses nothing, and one that has expired is reported as a
+// warning of the suppression rule until it is removed, so that exceptions
+// cannot silently live forever.
+//
+// Example (in a policy file):
+//
+//	suppressions:
+//	  - rule: policy-license
+//	    component: pkg:npm/left-pad
+//	    justification: Replaced by an in-house package in JIRA-1234
+//	    expires: 2025-06-30
+type Suppression struct {
+	// Rule is the ID of the rule whose findings are suppressed (see
+	// `RuleCatalog`).
+	Rule string `json:"rule" yaml:"rule"`
+	// Component identifies the component: a purl, which matches any version
+	// of the package unless it has one, or a CycloneDX bom-ref or SPDX
+	// package SPDXID.
+	Component string `json:"component" yaml:"component"`
+	// Justification says why the findings are accepted.
+	Justification string `json:"justification" yaml:"justification"`
+	// Expires is the last day the suppression applies, as YYYY-MM-DD (UTC).
+	Expires string `json:"expires" yaml:"expires"`
+}
+
+// SuppressedFinding is a finding dropped by a suppression.
+type SuppressedFinding struct {
+	Finding     Finding     `json:"finding"`
+	Suppression Suppression `json:"suppression"`
+}
+
+// Validate checks that every field of the suppression is set and that the
+// rule and expiry date are valid.
+func (s Suppression) Validate() error {
+	var errs []error
+	if s.Rule == "" {
+		errs = append(errs, errors.New("rule is required"))
+	} else if _, ok := LookupRule(s.Rule); !ok {
+		errs = append(errs, fmt.Errorf("unknown rule %q", s.Rule))
+	}
+	if strings.TrimSpace(s.Component) == "" {
+		errs = append(errs, errors.New("component is required"))
+	}
+	if strings.TrimSpace(s.Justification) == "" {
+		errs = append(errs, errors.New("justification is required"))
+	}
+	if s.Expires == "" {
+		errs = append(errs, errors.New("expiry date is required"))
+	} else if _, err := time.Parse(suppressionDateLayout, s.Expires); err != nil {
+		errs = append(errs, fmt.Errorf("expiry date %q is not a YYYY-MM-DD date", s.Expires))
+	}
+	return errors.Join(errs...)
+}
+
+// expired reports whether the suppression no longer applies at now: it
+// applies until the end of its expiry date.
+func (s Suppression) expired(now time.Time) bool {
+	expires, err := time.Parse(suppressionDateLayout, s.Expires)
+	return err != nil || !now.Before(expires.AddDate(0, 0, 1))
+}
+
+// String describes the suppression in findings.
+func (s Suppression) String() string {
+	return fmt.Sprintf("suppression of %s for %s", s.Rule, s.Component)
+}
+
+// suppressionIndex matches findings against the suppressions in force for a
+// parsed SBOM. A nil index suppresses nothing.
+type suppressionIndex struct {
+	active []Suppression
+	// identities are the components the active suppressions name, as
+	// compared with the identities of suppressedComponent.
+	identities []string
+	components []suppressedComponent
+}
+
+// suppressedComponent is the path of a component in an SBOM and the
+// identities a suppression can name it by.
+type suppressedComponent struct {
+	path       string
+	identities []string
+}
+
+// newSuppressionIndex indexes the components of doc for the suppressions in
+// force at now.
+//
+// Returns the index, or nil if no suppression is in force, and a warning per
+// suppression that is invalid or has expired.
+func newSuppressionIndex(doc *sbomDocument, suppressions []Suppression, now time.Time) (*suppressionIndex, []Finding) {
+	var findings []Finding
+	var active []Suppression
+	for _, s := range suppressions {
+		switch err := s.Validate(); {
+		case err != nil:
+			findings = append(findings, Finding{Level: LevelWarning, Rule: RuleSuppression,
+				Message: fmt.Sprintf("%s is ignored: %s", s, strings.ReplaceAll(err.Error(), "\n", "; "))})
+		case s.expired(now):
+			findings = append(findings, Finding{Level: LevelWarning, Rule: RuleSuppression,
+				Message: fmt.Sprintf("%s expired on %s; remove it or renew it with a new justification (%s)", s, s.Expires, s.Justification)})
+		default:
+			active = append(active, s)
+		}
+	}
+	if len(active) == 0 {
+		return nil, findings
+	}
+
+	index := &suppressionIndex{active: active}
+	for _, s := range active {
+		index.identities = append(index.identities, suppressionIdentity(s.Component))
+	}
+	add := func(path, ref, purl string) {
+		c := suppressedComponent{path: path}
+		for _, identity := range []string{ref, suppressionPURL(purl), PackageIdentity.ResolveIdentity(ComponentDescriptor{PURL: purl})} {
+			if identity != "" {
+				c.identities = append(c.identities, identity)
+			}
+		}
+		index.components = append(index.components, c)
+	}
+	if doc.sbomType == SBOM_CYCLONEDX {
+		walkCycloneDXComponents(doc.obj, func(path string, component map[string]interface{}) {
+			ref, _ := component["bom-ref"].(string)
+			purl, _ := component["purl"].(string)
+			add(path, ref, purl)
+		})
+		walkCycloneDXServices(doc.obj, func(path string, service map[string]interface{}) {
+			ref, _ := service["bom-ref"].(string)
+			add(path, ref, "")
+		})
+	} else if strings.HasPrefix(doc.sbomType, SBOM_SPDX) {
+		packages, _ := doc.obj["packages"].([]interface{})
+		for i, p := range packages {
+			if pkg, ok := p.(map[string]interface{}); ok {
+				id, _ := pkg["SPDXID"].(string)
+				add(fmt.Sprintf("packages.%d", i), id, spdxPackagePURL(pkg))
+			}
+		}
+	}
+	return index, findings
+}
+
+// suppressionPURL normalizes a purl as suppressions compare them: without
+// qualifiers or subpath.
+func suppressionPURL(purl string) string {
+	if purl == "" {
+		return ""
+	}
+	return PURLIdentity.ResolveIdentity(ComponentDescriptor{PURL: purl})
+}
+
+// suppressionIdentity normalizes the component a suppression names: a purl
+// without a version names the package, as PackageIdentity identifies it.
+func suppressionIdentity(component string) string {
+	if !strings.HasPrefix(component, "pkg:") {
+		return component
+	}
+	if p, err := parsePackageURL(component); err == nil && p.Version == "" {
+		return PackageIdentity.ResolveIdentity(ComponentDescriptor{PURL: component})
+	}
+	return suppressionPURL(component)
+}
+
+// match returns the suppression in force for a finding, if any. A finding
+// belongs to the innermost component whose path is a prefix of its own.
+func (x *suppressionIndex) match(f Finding) (Suppression, bool) {
+	if x == nil || f.Rule == RuleSuppression {
+		return Suppression{}, false
+	}
+	var owner *suppressedComponent
+	for i, c := range x.components {
+		if (f.Path == c.path || strings.HasPrefix(f.Path, c.path+".")) && (owner == nil || len(c.path) > len(owner.path)) {
+			owner = &x.components[i]
+		}
+	}
+	if owner == nil {
+		return Suppression{}, false
+	}
+	for i, s := range x.active {
+		if s.Rule == f.Rule && slices.Contains(owner.identities, x.identities[i]) {
+			return s, true
+		}
+	}
+	return Suppression{}, false
+}
+
+// suppresses reports whether a suppression in force drops the finding.
+func (x *suppressionIndex) suppresses(f Finding) bool {
+	_, ok := x.match(f)
+	return ok
+}
+
+// apply drops the suppressed findings, returning those kept and those
+// suppressed.
+func (x *suppressionIndex) apply(findings []Finding) ([]Finding, []SuppressedFinding) {
+	if x == nil {
+		return findings, nil
+	}
+	var kept []Finding
+	var suppressed []SuppressedFinding
+	for _, f := range findings {
+		if s, ok := x.match(f); ok {
+			f.renderMessage()
+			suppressed = append(suppressed, SuppressedFinding{Finding: f, Suppression: s})
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	return kept, suppressed
+}
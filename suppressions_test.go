@@ -0,0 +1,113 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuppressionValidate(t *testing.T) {
+	valid := Suppression{Rule: RulePolicyLicense, Component: "pkg:npm/left-pad", Justification: "Accepted until replaced", Expires: "2025-06-30"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		suppression Suppression
+		wantErr     string
+	}{
+		{name: "Unknown rule", suppression: Suppression{Rule: "nope", Component: "x", Justification: "x", Expires: "2025-06-30"}, wantErr: `unknown rule "nope"`},
+		{name: "No component", suppression: Suppression{Rule: RuleSchema, Justification: "x", Expires: "2025-06-30"}, wantErr: "component is required"},
+		{name: "Blank justification", suppression: Suppression{Rule: RuleSchema, Component: "x", Justification: " ", Expires: "2025-06-30"}, wantErr: "justification is required"},
+		{name: "No expiry", suppression: Suppression{Rule: RuleSchema, Component: "x", Justification: "x"}, wantErr: "expiry date is required"},
+		{name: "Invalid expiry", suppression: Suppression{Rule: RuleSchema, Component: "x", Justification: "x", Expires: "30/06/2025"}, wantErr: "not a YYYY-MM-DD date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.suppression.Validate(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithSuppressions(t *testing.T) {
+	sbom := spdxDocument(
+		spdxPackage("left-pad", "left-pad", "GPL-3.0-only"),
+		spdxPackage("right-pad", "right-pad", "GPL-3.0-only"),
+		spdxPackage("is-odd", "is-odd", "GPL-3.0-only"),
+	)
+	at := time.Date(2025, 6, 30, 23, 0, 0, 0, time.UTC)
+	policy := Policy{AllowedLicenses: []string{"MIT"}}
+
+	tests := []struct {
+		name           string
+		suppressions   []Suppression
+		wantSuppressed []string
+		wantWarning    string
+	}{
+		{
+			name: "Versionless purl, SPDXID and versioned purl",
+			suppressions: []Suppression{
+				{Rule: RulePolicyLicense, Component: "pkg:npm/left-pad", Justification: "Replaced in JIRA-1234", Expires: "2025-06-30"},
+				{Rule: RulePolicyLicense, Component: "SPDXRef-right-pad", Justification: "Internal use only", Expires: "2025-07-01"},
+				{Rule: RulePolicyLicense, Component: "pkg:npm/is-odd@1.0.0?arch=x86", Justification: "Dual-licensed", Expires: "2025-07-01"},
+			},
+			wantSuppressed: []string{"packages.0.licenseDeclared", "packages.1.licenseDeclared", "packages.2.licenseDeclared"},
+		},
+		{
+			name: "Other version or rule",
+			suppressions: []Suppression{
+				{Rule: RulePolicyLicense, Component: "pkg:npm/left-pad@2.0.0", Justification: "x", Expires: "2025-07-01"},
+				{Rule: RuleLicenseExpression, Component: "pkg:npm/right-pad", Justification: "x", Expires: "2025-07-01"},
+			},
+		},
+		{
+			name:         "Expired",
+			suppressions: []Suppression{{Rule: RulePolicyLicense, Component: "pkg:npm/left-pad", Justification: "Replaced in JIRA-1234", Expires: "2025-06-29"}},
+			wantWarning:  "suppression of policy-license for pkg:npm/left-pad expired on 2025-06-29",
+		},
+		{
+			name:         "Invalid",
+			suppressions: []Suppression{{Rule: RulePolicyLicense, Component: "pkg:npm/left-pad", Expires: "2025-07-01"}},
+			wantWarning:  "suppression of policy-license for pkg:npm/left-pad is ignored: justification is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMDataStructured(sbom, WithClock(FixedClock(at)), WithPolicy(policy), WithSuppressions(tt.suppressions...))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var suppressed []string
+			for _, s := range result.Suppressed {
+				if s.Finding.Rule != RulePolicyLicense || s.Suppression.Justification == "" {
+					t.Errorf("unexpected suppressed finding %+v", s)
+				}
+				suppressed = append(suppressed, s.Finding.Path)
+			}
+			if strings.Join(suppressed, ",") != strings.Join(tt.wantSuppressed, ",") {
+				t.Errorf("suppressed = %q, want %q", suppressed, tt.wantSuppressed)
+			}
+			if want := 3 - len(tt.wantSuppressed); len(result.Errors()) != want {
+				t.Errorf("errors = %+v, want %d", result.Errors(), want)
+			}
+
+			var warning string
+			for _, f := range result.Findings {
+				if f.Rule == RuleSuppression {
+					warning = f.Message
+				}
+			}
+			if !strings.Contains(warning, tt.wantWarning) || (tt.wantWarning == "") != (warning == "") {
+				t.Errorf("suppression warning = %q, want %q", warning, tt.wantWarning)
+			}
+		})
+	}
+}
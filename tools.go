@@ -0,0 +1,98 @@
+package sbomvalidator
+
+import "fmt"
+
+// walkCycloneDXTools calls fn for every tool listed in metadata.tools of a
+// CycloneDX SBOM, in either form, passing the JSON path of each:
+//
+//   - the legacy array of tools (e.g., "metadata.tools.0"), deprecated since
+//     CycloneDX 1.5;
+//   - the components and services of the tools object of CycloneDX 1.5 and
+//     later (e.g., "metadata.tools.components.0", including nested
+//     components, and "metadata.tools.services.0").
+//
+// Tools are not part of what the SBOM describes, so only the rules about the
+// well-formedness of identifiers, hashes, licenses and references check them.
+func walkCycloneDXTools(obj map[string]interface{}, fn func(path string, tool map[string]interface{})) {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	switch tools := metadata["tools"].(type) {
+	case []interface{}:
+		for i, t := range tools {
+			if tool, ok := t.(map[string]interface{}); ok {
+				fn(fmt.Sprintf("metadata.tools.%d", i), tool)
+			}
+		}
+	case map[string]interface{}:
+		components, _ := tools["components"].([]interface{})
+		for i, c := range components {
+			if component, ok := c.(map[string]interface{}); ok {
+				walkCycloneDXComponent(fmt.Sprintf("metadata.tools.components.%d", i), component, fn)
+			}
+		}
+		services, _ := tools["services"].([]interface{})
+		for i, s := range services {
+			if service, ok := s.(map[string]interface{}); ok {
+				fn(fmt.Sprintf("metadata.tools.services.%d", i), service)
+			}
+		}
+	}
+}
+
+// checkLegacyTools warns about a CycloneDX 1.5 or later SBOM that lists its
+// tools in the legacy array form, which those versions deprecate in favour of
+// metadata.tools.components and metadata.tools.services. The fix rewrites
+// the tools as components of type application.
+func checkLegacyTools(obj map[string]interface{}) []Finding {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	tools, ok := metadata["tools"].([]interface{})
+	if !ok {
+		return nil
+	}
+	specVersion, _ := obj["specVersion"].(string)
+	version, err := ParseSpecVersion(specVersion)
+	if err != nil || !version.AtLeast("1.5") {
+		return nil
+	}
+
+	components := make([]interface{}, 0, len(tools))
+	for _, t := range tools {
+		tool, ok := t.(map[string]interface{})
+		if !ok {
+			// not a tool the schema allows; leave the fix to a person
+			return []Finding{legacyToolsFinding(specVersion, nil)}
+		}
+		components = append(components, legacyToolComponent(tool))
+	}
+	fix := []PatchOperation{{Op: PatchReplace, Path: "/metadata/tools", Value: map[string]interface{}{"components": components}}}
+	return []Finding{legacyToolsFinding(specVersion, fix)}
+}
+
+func legacyToolsFinding(specVersion string, fix []PatchOperation) Finding {
+	return Finding{
+		Level:   LevelWarning,
+		Rule:    RuleLegacyTools,
+		Path:    "metadata.tools",
+		Pointer: "/metadata/tools",
+		Message: fmt.Sprintf("the array of tools is deprecated in CycloneDX %s; list tools as metadata.tools.components and metadata.tools.services", specVersion),
+		Fix:     fix,
+	}
+}
+
+// legacyToolComponent converts a legacy tool to a component of type
+// application, the tool's vendor becoming its publisher.
+func legacyToolComponent(tool map[string]interface{}) map[string]interface{} {
+	component := map[string]interface{}{"type": "application"}
+	for _, field := range []string{"name", "version", "hashes", "externalReferences"} {
+		if value, ok := tool[field]; ok {
+			component[field] = value
+		}
+	}
+	if vendor, ok := tool["vendor"]; ok {
+		component["publisher"] = vendor
+	}
+	if _, ok := component["name"]; !ok {
+		// name is required of components, but optional for legacy tools
+		component["name"] = "unknown"
+	}
+	return component
+}
@@ -0,0 +1,112 @@
+package sbomvalidator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkCycloneDXTools(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonData string
+		want     []string
+	}{
+		{
+			name:     "Legacy array",
+			jsonData: `{"metadata": {"tools": [{"vendor": "acme", "name": "scanner"}, {"name": "builder"}]}}`,
+			want:     []string{"metadata.tools.0", "metadata.tools.1"},
+		},
+		{
+			name: "Components and services",
+			jsonData: `{"metadata": {"tools": {
+				"components": [{"type": "application", "name": "scanner", "components": [{"type": "library", "name": "plugin"}]}],
+				"services": [{"name": "sbom-service"}]}}}`,
+			want: []string{"metadata.tools.components.0", "metadata.tools.components.0.components.0", "metadata.tools.services.0"},
+		},
+		{name: "No tools", jsonData: `{"metadata": {}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(tt.jsonData)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var got []string
+			walkCycloneDXTools(obj, func(path string, _ map[string]interface{}) {
+				got = append(got, path)
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("walkCycloneDXTools() paths = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLegacyTools(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonData string
+		wantFix  interface{}
+		want     bool
+	}{
+		{
+			name:     "Legacy array in 1.5",
+			jsonData: `{"specVersion": "1.5", "metadata": {"tools": [{"vendor": "acme", "name": "scanner", "version": "2.0"}, {"vendor": "acme"}]}}`,
+			want:     true,
+			wantFix: map[string]interface{}{"components": []interface{}{
+				map[string]interface{}{"type": "application", "publisher": "acme", "name": "scanner", "version": "2.0"},
+				map[string]interface{}{"type": "application", "publisher": "acme", "name": "unknown"},
+			}},
+		},
+		{name: "Legacy array in 1.4", jsonData: `{"specVersion": "1.4", "metadata": {"tools": [{"name": "scanner"}]}}`},
+		{name: "Tools object in 1.6", jsonData: `{"specVersion": "1.6", "metadata": {"tools": {"components": [{"type": "application", "name": "scanner"}]}}}`},
+		{name: "Malformed tool in 1.6", jsonData: `{"specVersion": "1.6", "metadata": {"tools": ["scanner"]}}`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := parseJSON(tt.jsonData)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := checkLegacyTools(obj)
+			if !tt.want {
+				if len(got) != 0 {
+					t.Errorf("checkLegacyTools() = %v, want no findings", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].Rule != RuleLegacyTools || got[0].Level != LevelWarning || got[0].Pointer != "/metadata/tools" {
+				t.Fatalf("checkLegacyTools() = %v", got)
+			}
+			if tt.wantFix == nil {
+				if got[0].Fix != nil {
+					t.Errorf("Fix = %v, want none", got[0].Fix)
+				}
+				return
+			}
+			if len(got[0].Fix) != 1 || got[0].Fix[0].Op != PatchReplace || !reflect.DeepEqual(got[0].Fix[0].Value, tt.wantFix) {
+				t.Errorf("Fix = %+v, want replacement with %v", got[0].Fix, tt.wantFix)
+			}
+		})
+	}
+}
+
+func TestToolsChecked(t *testing.T) {
+	obj, err := parseJSON(`{"specVersion": "1.6", "metadata": {"tools": {"components": [{"type": "application", "name": "scanner",
+		"purl": "pkg:generic/scanner@2.0", "swid": {"tagId": "scanner", "name": "scanner", "version": "2.1"},
+		"omniborId": ["a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"]}]}}}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	wantWarnings := []string{`metadata.tools.components.0: purl version "2.0" disagrees with swid version "2.1"`}
+	if got := checkComponentIdentifiers(obj); !reflect.DeepEqual(got, wantWarnings) {
+		t.Errorf("checkComponentIdentifiers() = %q, want %q", got, wantWarnings)
+	}
+	wantErrors := []string{`metadata.tools.components.0.omniborId.0: invalid gitoid "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"`}
+	if got := checkOmniBORIDs(obj); !reflect.DeepEqual(got, wantErrors) {
+		t.Errorf("checkOmniBORIDs() = %q, want %q", got, wantErrors)
+	}
+}
@@ -3,6 +3,7 @@ package sbomvalidator
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -49,3 +50,58 @@ func getSPDXVersion(spdxVersion string) (string, error) {
 	}
 	return parts[1], nil
 }
+
+// compareVersions compares two dotted version strings (e.g., "1.4" and "1.10")
+// numerically, component by component. Missing components are treated as zero
+// and non-numeric components compare as zero.
+//
+// Returns -1 if a < b, 0 if a == b, and 1 if a > b.
+func compareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// skipJSONValue consumes the remainder of an object or array whose opening
+// delimiter has already been read from the decoder.
+func skipJSONValue(dec *json.Decoder, open json.Delim) error {
+	if open != '{' && open != '[' {
+		return nil
+	}
+
+	depth := 1
+	for depth > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+	}
+
+	return nil
+}
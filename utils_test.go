@@ -148,3 +148,25 @@ func TestGetSPDXVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.4", "1.4", 0},
+		{"1.4", "1.5", -1},
+		{"1.10", "1.9", 1},
+		{"2.0", "1.7", 1},
+		{"1.4", "1.4.0", 0},
+		{"1.4.1", "1.4", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_vs_"+tt.b, func(t *testing.T) {
+			if got := compareVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
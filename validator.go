@@ -1,13 +1,15 @@
 package sbomvalidator
 
 import (
-	"embed"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
+	"slices"
 	"strings"
-
-	"github.com/xeipuuv/gojsonschema"
+	"time"
 )
 
 const (
@@ -22,30 +24,67 @@ const (
 //   - The detected SBOM type (e.g., CycloneDX, SPDX).
 //   - The SBOM schema or specification version.
 //   - A list of any validation errors encountered.
-//   - The schema file or source used during validation.
+//   - Links to the specification clauses that define the fields in error.
+//   - A list of warnings that do not affect validity (e.g., conflicting identifiers).
+//   - A list of informational findings.
+//   - The number of errors left out by `ValidationOptions.MaxErrors`.
+//   - The schema file or source used during validation, and its digest.
 //   - The detected input format (e.g., JSON, XML, etc.).
+//   - Whether the declared version was unknown and a newer schema was used instead.
+//   - Which of the schema's `format` keywords were checked.
+//   - Optionally, the outcome of each quality check.
+//   - Optionally, license statistics for the SBOM's components.
+//   - Optionally, the control framework mappings of the rules evaluated.
+//   - Optionally, the digest published for the SBOM.
 //
 // This struct is returned by `ValidateSBOMData` and can be serialized to JSON
-// for use in CLI tools, APIs, or automated pipelines.
+// for use in CLI tools, APIs, or automated pipelines. Use `Locate` to map the
+// paths in errors and warnings back to a line and column in the source.
 type ValidationResult struct {
-	IsValid          bool     `json:"isValid"`
-	SBOMType         string   `json:"sbomType,omitempty"`
-	SBOMVersion      string   `json:"sbomVersion,omitempty"`
-	ValidationErrors []string `json:"validationErrors,omitempty"`
-	SchemaUsed       string   `json:"schemaUsed,omitempty"`
-	DetectedFormat   string   `json:"detectedFormat,omitempty"`
+	IsValid          bool            `json:"isValid"`
+	SBOMType         string          `json:"sbomType,omitempty"`
+	SBOMVersion      string          `json:"sbomVersion,omitempty"`
+	ValidationErrors []string        `json:"validationErrors,omitempty"`
+	SpecReferences   []SpecReference `json:"specReferences,omitempty"`
+	Warnings         []string        `json:"warnings,omitempty"`
+	Info             []string        `json:"info,omitempty"`
+	OmittedErrors    int             `json:"omittedErrors,omitempty"`
+	SchemaUsed       string          `json:"schemaUsed,omitempty"`
+	SchemaDigest     string          `json:"schemaDigest,omitempty"`
+	DetectedFormat   string          `json:"detectedFormat,omitempty"`
+	Compression      string          `json:"compression,omitempty"`
+	UnknownVersion   bool            `json:"unknownVersion,omitempty"`
+	Relationships    map[string]int  `json:"relationships,omitempty"`
+	Formats          *FormatCoverage `json:"formats,omitempty"`
+
+	Signature *SignatureResult     `json:"signature,omitempty"`
+	Quality   []QualityCheckResult `json:"quality,omitempty"`
+	Licenses  *LicenseStats        `json:"licenses,omitempty"`
+	Controls  []ControlMapping     `json:"controls,omitempty"`
+	Digest    string               `json:"digest,omitempty"`
+	// Group is the value of the property named by `WithGroupBy`.
+	Group string `json:"group,omitempty"`
+
+	// ValidatedAt and Duration record when the SBOM was validated and how
+	// long it took, on the clock of `WithClock`. `WithDeterministic` leaves
+	// them out.
+	ValidatedAt *time.Time    `json:"validatedAt,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty"`
+
+	NestedBOMs []NestedBOMResult `json:"nestedBoms,omitempty"`
+
+	locator *sourceLocator
 }
 
-// Embed all JSON schema files from the schemas/cyclonedx directory
-//
-//go:embed schemas/cyclonedx/*.json schemas/spdx/*.json
-var schemaFS embed.FS
-
 // ValidateSBOMData is the main function to validate SBOM data using this library.
 //
 // This function serves as a wrapper around multiple internal functions, making it the
 // recommended entry point for validating SBOMs. It performs the following steps:
-// 1. Detects whether the SBOM is in JSON format.
+// 1. Decompresses gzip content, and zstd or brotli content with a registered
+// `WithDecompressor`, then detects whether the SBOM is in JSON, XML, protobuf
+// or SPDX tag-value format.
+// CycloneDX XML and protobuf and SPDX tag-value are converted to their JSON
+// form and validated against the schema of the same spec version.
 // 2. Determines the SBOM type (CycloneDX, SPDX, etc.).
 // 3. Extracts the schema version from the SBOM data.
 // 4. Loads the corresponding schema for validation.
@@ -53,6 +92,7 @@ var schemaFS embed.FS
 //
 // Parameters:
 //   - sbomContent: A byte slice containing the SBOM data.
+//   - opts: Optional settings, such as `WithAllowUnknownVersion`.
 //
 // Returns:
 //   - bool: `true` if the SBOM is valid, `false` otherwise.
@@ -60,16 +100,20 @@ var schemaFS embed.FS
 //   - error: An error if the function encounters issues during validation.
 //
 // Errors:
-//   - Returns an error if the SBOM format is not JSON.
+//   - Returns an error if the SBOM format is not JSON, CycloneDX XML or protobuf, or SPDX tag-value.
 //   - Returns an error if SBOM type detection fails.
 //   - Returns an error if the SBOM type is not CycloneDX (currently the only supported format).
 //   - Returns an error if extracting the SBOM version fails.
-//   - Returns an error if loading the schema fails.
+//   - Returns an error if loading the schema fails, listing the supported versions.
+//     When `WithAllowUnknownVersion(true)` is set and the declared version is newer
+//     than every embedded schema, the latest known schema is used instead, and with
+//     `WithVersionMode(VersionLenient)` the closest supported schema is used for any
+//     version. Either way `UnknownVersion` is set on the result and a warning added.
 //
 // Note:
-//   - This function abstracts multiple lower-level functions, such as `DetectSBOMType`,
-//     `ExtractVersion`, `LoadSchema`, and `ValidateSBOM`. Instead of calling those
-//     individually, use `ValidateSBOMData` for a streamlined validation process.
+//   - This function runs detection (`Detect`), schema loading (`LoadSchema`) and every
+//     enabled check in one pass. Instead of calling those individually, use
+//     `ValidateSBOMData` for a streamlined validation process.
 //
 // Example usage:
 //
@@ -82,55 +126,529 @@ var schemaFS embed.FS
 //	} else {
 //	    fmt.Println("SBOM validation errors:", errors)
 //	}
-func ValidateSBOMData(sbomContent []byte) (*ValidationResult, error) {
-	result := &ValidationResult{}
+func ValidateSBOMData(sbomContent []byte, opts ...Option) (*ValidationResult, error) {
+	return ValidateSBOMDataContext(context.Background(), sbomContent, opts...)
+}
+
+// ValidateSBOMDataContext validates SBOM data like ValidateSBOMData, but
+// stops when ctx is done. The context bounds schema loading and compilation,
+// remote schema fetches (`HTTPSchemaProvider`) and URL resolution
+// (`WithReferenceCheck`), and is checked between validation stages.
+//
+// A schema compilation abandoned because ctx is done still completes in the
+// background and is cached, so a retry does not start over.
+//
+// Parameters:
+//   - ctx: The context; when it is done, validation returns its error.
+//   - sbomContent: A byte slice containing the SBOM data.
+//   - opts: Optional settings, as for ValidateSBOMData.
+//
+// Returns:
+//   - A ValidationResult, which is never nil, even alongside an error.
+//   - An error under the same conditions as ValidateSBOMData, or ctx.Err()
+//     (which `errors.Is` matches) when ctx is done first.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+//	defer cancel()
+//	result, err := ValidateSBOMDataContext(ctx, sbomBytes)
+//	if errors.Is(err, context.DeadlineExceeded) {
+//	    http.Error(w, "validation timed out", http.StatusServiceUnavailable)
+//	    return
+//	}
+func ValidateSBOMDataContext(ctx context.Context, sbomContent []byte, opts ...Option) (*ValidationResult, error) {
+	result, err := ValidateSBOMDataStructuredContext(ctx, sbomContent, opts...)
+	return &result.ValidationResult, err
+}
+
+// ValidateSBOMDataStructured validates SBOM data like ValidateSBOMData, and
+// additionally returns every validation error and warning as a Finding with
+// its rule ID, level, severity, JSON pointer and, for schema errors, the
+// failing schema keyword.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the SBOM data.
+//   - opts: Optional settings, as for ValidateSBOMData.
+//
+// Returns:
+//   - A StructuredResult, which is never nil, even alongside an error.
+//   - An error under the same conditions as ValidateSBOMData.
+//
+// Example:
+//
+//	result, err := ValidateSBOMDataStructured(sbomBytes)
+//	if err != nil {
+//	    log.Fatalf("SBOM validation failed: %v", err)
+//	}
+//	for _, f := range result.Errors() {
+//	    fmt.Printf("%s [%s/%s] %s\n", f.Pointer, f.Rule, f.Keyword, f.Message)
+//	}
+func ValidateSBOMDataStructured(sbomContent []byte, opts ...Option) (*StructuredResult, error) {
+	return ValidateSBOMDataStructuredContext(context.Background(), sbomContent, opts...)
+}
+
+// ValidateSBOMDataStructuredContext validates SBOM data like
+// ValidateSBOMDataStructured, but stops when ctx is done, as described for
+// ValidateSBOMDataContext.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	result, err := ValidateSBOMDataStructuredContext(ctx, sbomBytes)
+func ValidateSBOMDataStructuredContext(ctx context.Context, sbomContent []byte, opts ...Option) (*StructuredResult, error) {
+	result, _, err := runValidation(ctx, sbomContent, newValidationOptions(opts))
+	return result, err
+}
+
+// Validator validates SBOMs with options set once, such as a logger, so a
+// service can configure validation at startup and share it across requests.
+// It is safe for concurrent use.
+//
+// Example:
+//
+//	validator, err := New(ValidatorConfig{Options: []Option{
+//	    WithLogger(slog.Default()),
+//	    WithLicenseValidation(true),
+//	}})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result, err := validator.Validate(r.Context(), body)
+type Validator struct {
+	opts         []Option
+	schemas      map[string]preloadedSchema
+	onValidation func(ValidationMetrics)
+}
+
+// ValidatorConfig configures a Validator created with `New`.
+type ValidatorConfig struct {
+	// Options are applied to every validation. Only the embedded schemas of
+	// the formats they accept (see `WithFormats`) are preloaded.
+	Options []Option
+	// OnValidation, if set, is called after every validation with its
+	// metrics, e.g., to export them to Prometheus. It is called from the
+	// validating goroutine, so it must be safe for concurrent use and should
+	// return quickly.
+	OnValidation func(ValidationMetrics)
+}
 
-	if isJSON(sbomContent) {
+// ValidationMetrics describes a validation, for the `ValidatorConfig`
+// OnValidation hook.
+type ValidationMetrics struct {
+	// SBOMType and SBOMVersion are the detected type and version, if
+	// validation got that far.
+	SBOMType    string
+	SBOMVersion string
+	// Valid reports whether the SBOM is valid; it is false when Err is set.
+	Valid bool
+	// Errors and Warnings are the numbers of errors and warnings found.
+	Errors   int
+	Warnings int
+	// Duration is how long the validation took, on the clock of `WithClock`.
+	Duration time.Duration
+	// Err is the error the validation failed with, if any.
+	Err error
+}
+
+// NewValidator returns a Validator applying opts to every validation.
+//
+// Deprecated: Use New, which reports a schema that fails to compile when the
+// Validator is created. NewValidator calls it and, on such a failure, returns
+// a Validator that compiles schemas on first use instead.
+func NewValidator(opts ...Option) *Validator {
+	validator, err := New(ValidatorConfig{Options: opts})
+	if err != nil {
+		return &Validator{opts: slices.Clone(opts)}
+	}
+	return validator
+}
+
+// New returns a Validator for high-throughput use, such as validating every
+// build artifact. The embedded schemas are read, digested and compiled once,
+// here, rather than on the first validation of each spec version, and the
+// validations of the Validator reuse them.
+//
+// Parameters:
+//   - cfg: The options of every validation and the metrics hook.
+//
+// Returns:
+//   - The Validator, safe for concurrent use.
+//   - An error if an embedded schema fails to compile.
+//
+// Example:
+//
+//	validator, err := New(ValidatorConfig{
+//	    Options:      []Option{WithLicenseValidation(true)},
+//	    OnValidation: func(m ValidationMetrics) { durations.Observe(m.Duration.Seconds()) },
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result, err := validator.Validate(ctx, body)
+func New(cfg ValidatorConfig) (*Validator, error) {
+	schemas, err := preloadSchemas(newValidationOptions(cfg.Options).formats)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{opts: slices.Clone(cfg.Options), schemas: schemas, onValidation: cfg.OnValidation}, nil
+}
+
+// Validate validates an SBOM like ValidateSBOMDataContext, with the
+// validator's options followed by opts.
+func (v *Validator) Validate(ctx context.Context, sbomContent []byte, opts ...Option) (*ValidationResult, error) {
+	result, err := v.ValidateStructured(ctx, sbomContent, opts...)
+	return &result.ValidationResult, err
+}
+
+// ValidateStructured validates an SBOM like
+// ValidateSBOMDataStructuredContext, with the validator's options followed by
+// opts.
+func (v *Validator) ValidateStructured(ctx context.Context, sbomContent []byte, opts ...Option) (*StructuredResult, error) {
+	options := newValidationOptions(append(slices.Clip(v.opts), opts...))
+	options.preloaded = v.schemas
+
+	start := options.now()
+	result, _, err := runValidation(ctx, sbomContent, options)
+	if v.onValidation != nil {
+		v.onValidation(validationMetrics(result, err, options.now().Sub(start)))
+	}
+	return result, err
+}
+
+// validationMetrics returns the metrics of a validation.
+func validationMetrics(result *StructuredResult, err error, duration time.Duration) ValidationMetrics {
+	metrics := ValidationMetrics{Duration: duration, Err: err}
+	if result != nil {
+		metrics.SBOMType = result.SBOMType
+		metrics.SBOMVersion = result.SBOMVersion
+		metrics.Valid = result.IsValid && err == nil
+		metrics.Errors = len(result.ValidationErrors)
+		metrics.Warnings = len(result.Warnings)
+	}
+	return metrics
+}
+
+// runValidation implements ValidateSBOMDataStructuredContext. It also returns
+// the parsed JSON form of the SBOM, so further checks can reuse it, or nil if
+// validation failed before the SBOM was parsed. The outcome and how long it
+// took are logged to the configured logger and, unless the options are
+// deterministic, stamped on the result.
+func runValidation(ctx context.Context, sbomContent []byte, options *validationOptions) (*StructuredResult, *sbomDocument, error) {
+	start := options.now()
+	result, doc, err := validateSBOMContent(ctx, sbomContent, options)
+	duration := options.now().Sub(start)
+	logger := options.log()
+	if err != nil {
+		logger.DebugContext(ctx, "SBOM validation failed", "error", err, "duration", duration)
+		return result, doc, err
+	}
+
+	logger.DebugContext(ctx, "SBOM validated", "type", result.SBOMType, "version", result.SBOMVersion,
+		"valid", result.IsValid, "errors", len(result.ValidationErrors), "warnings", len(result.Warnings),
+		"duration", duration)
+	if !options.deterministic {
+		validatedAt := start.UTC()
+		result.ValidatedAt = &validatedAt
+		result.Duration = duration
+	}
+	return result, doc, nil
+}
+
+// validateSBOMContent runs the validation stages of runValidation.
+func validateSBOMContent(ctx context.Context, sbomContent []byte, options *validationOptions) (*StructuredResult, *sbomDocument, error) {
+	result := &StructuredResult{}
+	if err := ctx.Err(); err != nil {
+		return result, nil, err
+	}
+
+	signedContent := sbomContent
+	sbomContent, compression, err := decompress(sbomContent, options)
+	result.Compression = compression
+	if err != nil {
+		return result, nil, err
+	}
+
+	if policy := options.signaturePolicy; policy != nil {
+		if len(policy.DetachedSignature) == 0 {
+			signedContent = sbomContent
+		}
+		signature, err := VerifySBOMSignature(signedContent, *policy)
+		if err != nil {
+			return result, nil, fmt.Errorf("%w: %v", ErrSignatureVerification, err)
+		}
+		result.Signature = signature
+	}
+
+	// jsonContent is the JSON form of the SBOM, which rules are evaluated on
+	jsonContent := sbomContent
+	// syntaxErrors are errors in the source encoding that the JSON form hides
+	var syntaxErrors []string
+	// conversionWarnings are parts of the source the JSON form leaves out
+	var conversionWarnings []string
+
+	switch {
+	case isJSON(sbomContent):
 		result.DetectedFormat = "JSON"
+		result.locator = newSourceLocator(sbomContent)
 
-		sbomType, err := detectSBOMType(string(sbomContent))
+	default:
+		encoding, format := sbomEncoding(sbomContent)
+		if encoding == "" {
+			result.DetectedFormat = "non-JSON"
+			return result, nil, fmt.Errorf("unsupported file format")
+		}
+		result.DetectedFormat = encoding
+		result.SBOMType = format
+		if err := checkFormatEnabled(format, options.formats); err != nil {
+			return result, nil, err
+		}
+
+		jsonContent, syntaxErrors, conversionWarnings, err = convertEncoding(encoding, format, sbomContent)
 		if err != nil {
-			return result, fmt.Errorf("error detecting SBOM Type %v", err)
+			return result, nil, err
+		}
+	}
+
+	detected, err := detectSBOM(jsonContent)
+	if detected.Format == "" {
+		return result, nil, fmt.Errorf("error detecting SBOM Type %v", err)
+	}
+	sbomType, sbomSchemaVersion := detected.declared()
+	result.SBOMType = sbomType
+
+	if err := checkFormatEnabled(sbomType, options.formats); err != nil {
+		return result, nil, err
+	}
+
+	if err != nil {
+		return result, nil, fmt.Errorf("failed to extract SBOM version: %v", err)
+	}
+	result.SBOMVersion = sbomSchemaVersion
+	options.log().DebugContext(ctx, "SBOM type detected", "type", sbomType, "version", sbomSchemaVersion,
+		"encoding", result.DetectedFormat, "compression", result.Compression)
+
+	if err := ctx.Err(); err != nil {
+		return result, nil, err
+	}
+
+	schemaVersion := sbomSchemaVersion
+	schemaName, schema, provided, err := loadSchema(ctx, options.schemaProviders, schemaVersion, sbomType)
+	var versionWarning string
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return result, nil, ctxErr
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return result, nil, fmt.Errorf("failed to load schema: %v", err)
+		}
+
+		var fallbackVersion string
+		fallbackErr := err
+		if options.versionMode == VersionLenient {
+			fallbackVersion, fallbackErr = closestSchemaVersion(sbomSchemaVersion, sbomType)
+		} else if options.allowUnknownVersion {
+			fallbackVersion, fallbackErr = newerThanLatestSchema(sbomSchemaVersion, sbomType)
+		}
+		if fallbackErr != nil {
+			return result, nil, unsupportedVersionError(sbomSchemaVersion, sbomType, err)
 		}
-		result.SBOMType = sbomType
 
-		sbomSchemaVersion, err := extractSBOMVersion(string(sbomContent), sbomType)
+		options.log().InfoContext(ctx, "no schema for the SBOM's version, falling back", "type", sbomType,
+			"version", sbomSchemaVersion, "fallback", fallbackVersion)
+		schemaVersion = fallbackVersion
+		schemaName, schema, provided, err = loadSchema(ctx, options.schemaProviders, schemaVersion, sbomType)
 		if err != nil {
-			return result, fmt.Errorf("failed to extract SBOM version: %v", err)
+			return result, nil, fmt.Errorf("failed to load schema: %v", err)
 		}
-		result.SBOMVersion = sbomSchemaVersion
+		result.UnknownVersion = true
+		versionWarning = fmt.Sprintf("%s %s has no schema; validated against %s as the closest supported version",
+			sbomFormat(sbomType), strings.TrimPrefix(sbomSchemaVersion, SBOM_SPDX+"-"), strings.TrimPrefix(fallbackVersion, SBOM_SPDX+"-"))
+	}
+	result.SchemaUsed = schemaName
+	embedded := !provided
 
-		schema, err := loadSBOMSchema(sbomSchemaVersion, sbomType)
+	// a schema from a provider is pinned by its own digest rather than by an
+	// embedded revision
+	if len(options.pinnedSchemas) > 0 && !(provided && slices.Contains(options.pinnedSchemas, schemaDigest(schema))) {
+		revision, err := pinnedSchemaRevision(result.SchemaUsed, options.pinnedSchemas)
 		if err != nil {
-			return result, fmt.Errorf("failed to load schema: %v", err)
+			return result, nil, err
 		}
+		if revision != nil {
+			data, err := schemaFS.ReadFile(revision.Path)
+			if err != nil {
+				return result, nil, fmt.Errorf("failed to load schema: %v", err)
+			}
+			schema = string(data)
+			result.SchemaUsed = revision.Path
+			embedded = true
+		}
+	}
+
+	// embedded schemas preloaded by New are neither digested nor compiled again
+	var compiled *compiledSchema
+	if preloaded, ok := options.preloaded[result.SchemaUsed]; ok && embedded {
+		result.SchemaDigest, compiled = preloaded.digest, preloaded.compiled
+	} else {
+		result.SchemaDigest = schemaDigest(schema)
+	}
+	options.log().DebugContext(ctx, "schema selected", "schema", result.SchemaUsed, "digest", result.SchemaDigest)
 
-		isValid, validationErrors, err := validateSBOM(schema, string(sbomContent))
+	if compiled == nil {
+		compiled, err = compileSchemaContext(ctx, result.SchemaDigest, schema)
 		if err != nil {
-			return result, fmt.Errorf("validation error: %v", err)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return result, nil, ctxErr
+			}
+			return result, nil, fmt.Errorf("validation error: %v", err)
 		}
+	}
+
+	schemaErrors, err := validateSchemaFindings(compiled, string(jsonContent))
+	if err != nil {
+		return result, nil, fmt.Errorf("validation error: %v", err)
+	}
 
-		result.IsValid = isValid
-		result.ValidationErrors = validationErrors
+	formats, formatInfos, err := checkSchemaFormats(result.SchemaDigest, schema, !options.noFormatAssertion, schemaErrors)
+	if err != nil {
+		return result, nil, fmt.Errorf("validation error: %v", err)
+	}
+	result.Formats = formats
 
-		// for SPDX SBOMs split the type and version (ie: SPDX-2.3)
-		if strings.HasPrefix(sbomType, SBOM_SPDX) {
-			result.SBOMType = SBOM_SPDX
-			result.SBOMVersion, _ = getSPDXVersion(sbomType)
+	// syntax errors are located by line rather than by path
+	var findings []Finding
+	for _, msg := range syntaxErrors {
+		findings = append(findings, Finding{Level: LevelError, Rule: RuleDocument, Message: msg})
+	}
+	findings = append(findings, messageFindings(LevelWarning, RuleDocument, conversionWarnings)...)
+	findings = append(findings, schemaErrors...)
+	if versionWarning != "" {
+		findings = append(findings, Finding{Level: LevelWarning, Rule: RuleUnknownSpecVersion, Message: versionWarning})
+	}
+
+	findings = append(findings, formatInfos...)
+
+	obj, err := parseJSON(string(jsonContent))
+	if err != nil {
+		return result, nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	doc := &sbomDocument{obj: obj, sbomType: sbomType}
+
+	// suppressions apply to the findings of every stage; those invalid or
+	// expired are reported with the schema findings
+	suppressions, suppressionFindings := newSuppressionIndex(doc, options.suppressions, options.now())
+	findings = append(findings, suppressionFindings...)
+	progress := &progressReporter{handler: options.progress, filter: findingFilter{limits: options.limits, suppressions: suppressions}}
+	progress.report(StageSchema, findings)
+
+	errorPaths := make([]string, 0, len(schemaErrors))
+	for _, f := range schemaErrors {
+		if options.limits.level(f) == LevelError && !options.limits.ignores(f.Rule) && !suppressions.suppresses(f) {
+			errorPaths = append(errorPaths, findingFieldPath(f))
 		}
+	}
+	result.SpecReferences = specReferencesForPaths(sbomType, schemaVersion, errorPaths)
 
-		return result, nil
+	evaluatedRules := []string{RuleDocument, RuleSchema, RuleSchemaFormat}
+	if result.UnknownVersion {
+		evaluatedRules = append(evaluatedRules, RuleUnknownSpecVersion)
+	}
+	if len(options.suppressions) > 0 {
+		evaluatedRules = append(evaluatedRules, RuleSuppression)
+	}
 
-	} else {
+	if err := ctx.Err(); err != nil {
+		return result, nil, err
+	}
+
+	findings, rules, err := evaluateRules(ctx, doc, options, result, progress, findings)
+	if err != nil {
+		return result, nil, err
+	}
+	evaluatedRules = append(evaluatedRules, rules...)
+	result.evaluatedRules = evaluatedRules
+
+	findings, result.Suppressed = suppressions.apply(findings)
+	findings, result.OmittedErrors = options.limits.apply(findings)
+	result.setFindings(findings)
+	if options.limits.FailOnWarnings && len(result.Warnings) > 0 {
 		result.IsValid = false
-		result.DetectedFormat = "non-JSON"
-		return result, fmt.Errorf("unsupported file format")
 	}
+	if options.groupBy != "" {
+		result.Group = sbomProperty(doc, options.groupBy)
+	}
+
+	if options.controlMappings {
+		result.Controls = controlsForRules(evaluatedRules...)
+	}
+
+	// for SPDX SBOMs split the type and version (ie: SPDX-2.3)
+	if strings.HasPrefix(sbomType, SBOM_SPDX) {
+		result.SBOMType = SBOM_SPDX
+		result.SBOMVersion, _ = getSPDXVersion(sbomType)
+	}
+
+	if options.digestPublisher != nil && result.IsValid {
+		if err := ctx.Err(); err != nil {
+			return result, nil, err
+		}
+		record := DigestRecord{
+			Digest:      SBOMDigest(sbomContent),
+			SBOMType:    result.SBOMType,
+			SBOMVersion: result.SBOMVersion,
+			ValidatedAt: options.now().UTC(),
+		}
+		if err := options.digestPublisher.Publish(record); err != nil {
+			return result, nil, fmt.Errorf("failed to publish digest: %v", err)
+		}
+		result.Digest = record.Digest
+	}
+
+	return result, doc, nil
+}
+
+// DetectSBOMType identifies the SBOM format by streaming the top-level keys of
+// a JSON document.
+//
+// Unlike a full unmarshal, only the top-level keys are tokenized and nested
+// values are skipped without being materialized. Scanning stops as soon as a
+// "bomFormat" (CycloneDX) or "spdxVersion" (SPDX) string field is found, so
+// detection on very large SBOMs is fast and does not hold the document in
+// memory. Keys may appear in any order; if both are present, whichever comes
+// first wins.
+//
+// Because scanning stops early, the remainder of the document is not checked
+// for well-formedness. Use `ValidateSBOMData` for full validation.
+//
+// Binary input that starts like a protobuf-encoded CycloneDX BOM (a
+// spec_version field) is detected as CycloneDX from its first bytes.
+//
+// Parameters:
+//   - r: A reader supplying the SBOM JSON, or CycloneDX protobuf, data.
+//
+// Returns:
+//   - A string representing the detected SBOM format (e.g., "CycloneDX" or "SPDX-2.3").
+//   - An error if the JSON is malformed before a type field is found, or no type field exists.
+//
+// Example:
+//
+//	f, _ := os.Open("large.cdx.json")
+//	defer f.Close()
+//	sbomType, err := DetectSBOMType(f)
+//
+// Deprecated: Use Detect, which also reads the version and returns both as
+// typed values.
+func DetectSBOMType(r io.Reader) (string, error) {
+	sbomType, _, err := scanSBOMType(r, false)
+	return sbomType, err
 }
 
 // detectSBOMType identifies the SBOM format based on the JSON structure.
 //
-// This function parses the provided SBOM JSON data and detects its type by checking the "bomFormat" field.
+// This function checks that the provided SBOM data is well-formed JSON and then detects its
+// type by scanning the top-level "bomFormat" (CycloneDX) and "spdxVersion" (SPDX) fields.
 // It returns the detected SBOM type as a string (e.g., "CycloneDX").
 //
 // Parameters:
@@ -148,26 +666,12 @@ func ValidateSBOMData(sbomContent []byte) (*ValidationResult, error) {
 //	}
 //	fmt.Println("Detected SBOM type:", sbomType) // Output: "CycloneDX"
 func detectSBOMType(jsonData string) (string, error) {
-
-	obj, err := parseJSON(jsonData)
-	if err != nil {
-		return "", err
-	}
-
-	// CycloneDX contains a bomFormat field
-	cyclonedxFormat, ok := obj["bomFormat"].(string)
-	if ok {
-		log.Printf("%s SBOM type detected", cyclonedxFormat)
-		return cyclonedxFormat, nil
-	}
-
-	spdxVersion, ok := obj["spdxVersion"].(string)
-	if ok {
-		log.Printf("%s SBOM type detected", spdxVersion)
-		return spdxVersion, nil
+	// json.Valid scans without allocating, unlike unmarshalling into a map
+	if !json.Valid([]byte(jsonData)) {
+		return "", fmt.Errorf("failed to parse JSON: invalid JSON format")
 	}
 
-	return "", fmt.Errorf("unknown SBOM type or missing required fields")
+	return DetectSBOMType(strings.NewReader(jsonData))
 }
 
 // validateSBOM validates an SBOM JSON object against a provided SBOM schema.
@@ -203,23 +707,32 @@ func validateSBOM(schemaSBOM, sbomData string) (bool, []string, error) {
 		return false, nil, fmt.Errorf("invalid JSON format")
 	}
 
-	schemaLoader := gojsonschema.NewStringLoader(schemaSBOM)
-	documentLoader := gojsonschema.NewStringLoader(sbomData)
-
-	_, err := gojsonschema.NewSchema(schemaLoader)
+	schema, err := compileSchema(schemaDigest(schemaSBOM), schemaSBOM)
 	if err != nil {
-		return false, nil, fmt.Errorf("invalid schema format: %v", err)
+		return false, nil, err
 	}
 
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	return validateSBOMWithSchema(schema, sbomData)
+}
+
+// validateSBOMWithSchema validates SBOM JSON data against an already compiled schema.
+//
+// It behaves like validateSBOM but skips schema compilation, which lets callers
+// reuse a cached schema across many documents.
+func validateSBOMWithSchema(schema *compiledSchema, sbomData string) (bool, []string, error) {
+	if !isValidJSON(sbomData) {
+		return false, nil, fmt.Errorf("invalid JSON format")
+	}
+
+	findings, err := validateSchemaFindings(schema, sbomData)
 	if err != nil {
 		return false, nil, err
 	}
 
-	if !result.Valid() {
-		var errors []string
-		for _, desc := range result.Errors() {
-			errors = append(errors, desc.String())
+	if len(findings) > 0 {
+		errors := make([]string, 0, len(findings))
+		for _, f := range findings {
+			errors = append(errors, f.String())
 		}
 		return false, errors, nil
 	}
@@ -227,53 +740,10 @@ func validateSBOM(schemaSBOM, sbomData string) (bool, []string, error) {
 	return true, nil, nil
 }
 
-// extractSBOMVersion extracts the "version" field from an SBOM JSON string.
-//
-// This function parses the provided JSON data and retrieves the version field
-// based on the specified SBOM type. Currently, it supports CycloneDX SBOMs.
-//
-// Parameters:
-//   - jsonData: A string containing the SBOM JSON data.
-//   - sbomType: A string specifying the SBOM format (e.g., "CycloneDX").
-//
-// Returns:
-//   - A string representing the SBOM version if found.
-//   - An error if the JSON is invalid, the version field is missing, or the SBOM type is unsupported.
-//
-// Example:
-//
-//	version, err := extractSBOMVersion(`{"specVersion": "1.4"}`, "CycloneDX")
-//	if err != nil {
-//	    log.Println("Error extracting version: %v", err)
-//	}
-//	fmt.Println(version) // Output: "1.4"
-func extractSBOMVersion(jsonData string, sbomType string) (string, error) {
-	var obj map[string]interface{}
-
-	if err := json.Unmarshal([]byte(jsonData), &obj); err != nil {
-		return "", fmt.Errorf("invalid JSON format")
-	}
-
-	if sbomType == SBOM_CYCLONEDX {
-		version, ok := obj["specVersion"].(string)
-		if !ok {
-			return "", fmt.Errorf(`"specVersion" field missing or not a string`)
-		}
-
-		log.Println("CycloneDX version is set to:", version)
-		return version, nil
-		// SPDX SBOMs have the version embedded into the spdxVersion filed
-	} else if strings.Contains(sbomType, SBOM_SPDX) {
-		version, ok := obj["spdxVersion"].(string)
-		if !ok {
-			return "", fmt.Errorf(`"spdxVersion" field missing or not a string`)
-		}
-
-		log.Println("SPDX version is set to:", version)
-		return version, nil
-	}
-
-	return "", fmt.Errorf("unknown SBOM Format")
+// validateSchemaFindings validates SBOM JSON data against a compiled schema and
+// returns the schema errors as findings.
+func validateSchemaFindings(schema *compiledSchema, sbomData string) ([]Finding, error) {
+	return schema.validate(sbomData)
 }
 
 // loadSBOMSchema loads a JSON schema file for validating an SBOM.
@@ -298,24 +768,77 @@ func extractSBOMVersion(jsonData string, sbomType string) (string, error) {
 //	}
 //	fmt.Println("Schema content loaded successfully.")
 func loadSBOMSchema(version string, sbomType string) (string, error) {
-	var schemaFile string
+	schemaFile, err := schemaFilePath(version, sbomType)
+	if err != nil {
+		return "", err
+	}
 
+	data, err := schemaFS.ReadFile(schemaFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read embedded schema file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// schemaFilePath returns the path of the embedded schema file for the given
+// SBOM version and type. It does not check that the file exists.
+func schemaFilePath(version string, sbomType string) (string, error) {
 	if sbomType == SBOM_CYCLONEDX {
-		schemaFile = fmt.Sprintf("schemas/cyclonedx/bom-%s.schema.json", version)
+		return fmt.Sprintf("schemas/cyclonedx/bom-%s.schema.json", version), nil
 	} else if strings.Contains(sbomType, SBOM_SPDX) {
 		spdxVersion, err := getSPDXVersion(version)
 		if err != nil {
 			return "", fmt.Errorf("failed to extract SPDX version")
 		}
-		schemaFile = fmt.Sprintf("schemas/spdx/spdx-%s.schema.json", spdxVersion)
-	} else {
+		return fmt.Sprintf("schemas/spdx/spdx-%s.schema.json", spdxVersion), nil
+	}
+
+	return "", fmt.Errorf("unsupported SBOM type: %s", sbomType)
+}
+
+// latestSchemaVersion returns the highest schema version embedded for the
+// given SBOM type (e.g., "1.7" for CycloneDX, "2.3" for SPDX).
+func latestSchemaVersion(sbomType string) (string, error) {
+	format := sbomFormat(sbomType)
+	if format != SBOM_CYCLONEDX && format != SBOM_SPDX {
 		return "", fmt.Errorf("unsupported SBOM type: %s", sbomType)
 	}
 
-	data, err := schemaFS.ReadFile(schemaFile)
+	versions := SupportedVersions(Format(format))
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no embedded schemas found for %s", sbomType)
+	}
+	return string(versions[len(versions)-1]), nil
+}
+
+// newerThanLatestSchema checks whether the given version is newer than every
+// embedded schema for the SBOM type. If so, it returns the version to pass to
+// loadSBOMSchema for the latest known schema.
+//
+// Versions older than the latest schema (or otherwise unknown) return an error,
+// since falling back to a newer schema would not be meaningful for them.
+func newerThanLatestSchema(version string, sbomType string) (string, error) {
+	latest, err := latestSchemaVersion(sbomType)
 	if err != nil {
-		return "", fmt.Errorf("failed to read embedded schema file: %w", err)
+		return "", err
 	}
 
-	return string(data), nil
+	declared := version
+	if strings.Contains(sbomType, SBOM_SPDX) {
+		declared, err = getSPDXVersion(version)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if compareVersions(declared, latest) <= 0 {
+		return "", fmt.Errorf("version %s is not newer than latest known schema %s", declared, latest)
+	}
+
+	if strings.Contains(sbomType, SBOM_SPDX) {
+		return SBOM_SPDX + "-" + latest, nil
+	}
+
+	return latest, nil
 }
@@ -1,7 +1,15 @@
+//go:build !sbomvalidator_no_cyclonedx && !sbomvalidator_no_spdx
+
 package sbomvalidator
 
 import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 )
 
 // TestDetectSBOMType tests the DetectSBOMType function.
@@ -118,69 +126,6 @@ func TestLoadSchema(t *testing.T) {
 	}
 }
 
-// TestExtractVersion verifies ExtractVersion correctly extracts SBOM versions
-// and handles errors for invalid cases.
-func TestExtractVersion(t *testing.T) {
-	tests := []struct {
-		name      string
-		jsonData  string
-		sbomType  string
-		expectErr bool
-		wantValue string
-	}{
-		{
-			name:      "Valid CycloneDX SBOM",
-			jsonData:  `{"specVersion": "1.4"}`,
-			sbomType:  "CycloneDX",
-			expectErr: false,
-			wantValue: "1.4",
-		},
-		{
-			name:      "Valid SPDX SBOM",
-			jsonData:  `{"spdxVersion": "SPDX-2.3"}`,
-			sbomType:  "SPDX",
-			expectErr: false,
-			wantValue: "SPDX-2.3",
-		},
-		{
-			name:      "Missing specVersion field",
-			jsonData:  `{"bomFormat": "CycloneDX"}`,
-			sbomType:  "CycloneDX",
-			expectErr: true,
-		},
-		{
-			name:      "Invalid specVersion type",
-			jsonData:  `{"specVersion": 1.4}`,
-			sbomType:  "CycloneDX",
-			expectErr: true,
-		},
-		{
-			name:      "Invalid JSON structure",
-			jsonData:  `{"specVersion": "1.4"`,
-			sbomType:  "CycloneDX",
-			expectErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			version, err := extractSBOMVersion(tt.jsonData, tt.sbomType)
-
-			if tt.expectErr && err == nil {
-				t.Errorf("Expected error but got none")
-			}
-
-			if !tt.expectErr && err != nil {
-				t.Errorf("Unexpected error: %v", err)
-			}
-
-			if !tt.expectErr && version != tt.wantValue {
-				t.Errorf("Expected version %q but got %q", tt.wantValue, version)
-			}
-		})
-	}
-}
-
 // TestValidateSBOM verifies ValidateSBOM function for both valid and invalid SBOM data.
 func TestValidateSBOM(t *testing.T) {
 	validSchema := `{
@@ -262,3 +207,253 @@ func TestValidateSBOM(t *testing.T) {
 		})
 	}
 }
+
+// TestValidateSBOMDataUnknownVersion verifies that SBOMs declaring a spec version
+// newer than the embedded schemas only validate when explicitly allowed.
+func TestValidateSBOMDataUnknownVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		sbomData       string
+		allowUnknown   bool
+		expectErr      bool
+		wantUnknown    bool
+		wantSchemaUsed string
+	}{
+		{
+			name:           "Known SPDX version",
+			sbomData:       `{"spdxVersion": "SPDX-2.3"}`,
+			wantSchemaUsed: "schemas/spdx/spdx-2.3.schema.json",
+		},
+		{
+			name:      "Future SPDX version not allowed",
+			sbomData:  `{"spdxVersion": "SPDX-2.9"}`,
+			expectErr: true,
+		},
+		{
+			name:           "Future SPDX version allowed",
+			sbomData:       `{"spdxVersion": "SPDX-2.9"}`,
+			allowUnknown:   true,
+			wantUnknown:    true,
+			wantSchemaUsed: "schemas/spdx/spdx-2.3.schema.json",
+		},
+		{
+			name:         "Older unknown SPDX version allowed",
+			sbomData:     `{"spdxVersion": "SPDX-2.1"}`,
+			allowUnknown: true,
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateSBOMData([]byte(tt.sbomData), WithAllowUnknownVersion(tt.allowUnknown))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ValidateSBOMData() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if result.UnknownVersion != tt.wantUnknown {
+				t.Errorf("UnknownVersion = %v, want %v", result.UnknownVersion, tt.wantUnknown)
+			}
+			if result.SchemaUsed != tt.wantSchemaUsed {
+				t.Errorf("SchemaUsed = %q, want %q", result.SchemaUsed, tt.wantSchemaUsed)
+			}
+		})
+	}
+}
+
+func TestNewerThanLatestSchema(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		sbomType  string
+		want      string
+		expectErr bool
+	}{
+		{
+			name:     "Future CycloneDX version",
+			version:  "1.99",
+			sbomType: SBOM_CYCLONEDX,
+			want:     "1.7",
+		},
+		{
+			name:     "Future SPDX version",
+			version:  "SPDX-2.9",
+			sbomType: "SPDX-2.9",
+			want:     "SPDX-2.3",
+		},
+		{
+			name:      "Latest known CycloneDX version",
+			version:   "1.7",
+			sbomType:  SBOM_CYCLONEDX,
+			expectErr: true,
+		},
+		{
+			name:      "Older unknown CycloneDX version",
+			version:   "1.0",
+			sbomType:  SBOM_CYCLONEDX,
+			expectErr: true,
+		},
+		{
+			name:      "Unsupported SBOM type",
+			version:   "1.0",
+			sbomType:  "UnknownSBOM",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newerThanLatestSchema(tt.version, tt.sbomType)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("newerThanLatestSchema() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if got != tt.want {
+				t.Errorf("newerThanLatestSchema() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDetectSBOMTypeReader verifies the streaming detector tolerates field order
+// and stops reading once the type is known.
+func TestDetectSBOMTypeReader(t *testing.T) {
+	tests := []struct {
+		name      string
+		reader    io.Reader
+		want      string
+		expectErr bool
+	}{
+		{
+			name:   "bomFormat after nested values",
+			reader: strings.NewReader(`{"components": [{"name": "a", "hashes": [{"alg": "SHA-256"}]}], "metadata": {"bomFormat": "nested"}, "bomFormat": "CycloneDX"}`),
+			want:   "CycloneDX",
+		},
+		{
+			name:   "spdxVersion after packages",
+			reader: strings.NewReader(`{"packages": [{"name": "a"}], "spdxVersion": "SPDX-2.3"}`),
+			want:   "SPDX-2.3",
+		},
+		{
+			name:   "Non-string bomFormat is skipped",
+			reader: strings.NewReader(`{"bomFormat": {"x": 1}, "spdxVersion": "SPDX-2.2"}`),
+			want:   "SPDX-2.2",
+		},
+		{
+			name: "Stops reading once type is found",
+			reader: io.MultiReader(
+				strings.NewReader(`{"bomFormat": "CycloneDX", "components": [`),
+				iotest.ErrReader(errors.New("read past detected type")),
+			),
+			want: "CycloneDX",
+		},
+		{
+			name:      "Top-level array",
+			reader:    strings.NewReader(`[{"bomFormat": "CycloneDX"}]`),
+			expectErr: true,
+		},
+		{
+			name:      "Truncated before type",
+			reader:    strings.NewReader(`{"components": [`),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectSBOMType(tt.reader)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("DetectSBOMType() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if got != tt.want {
+				t.Errorf("DetectSBOMType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSBOMDataContext(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"))
+
+	result, err := ValidateSBOMDataContext(context.Background(), sbom)
+	if err != nil || !result.IsValid {
+		t.Fatalf("expected a valid SBOM, got %+v, %v", result, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = ValidateSBOMDataContext(ctx, sbom)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if result == nil || result.IsValid {
+		t.Errorf("expected an unvalidated result, got %+v", result)
+	}
+}
+
+func TestNew(t *testing.T) {
+	var mu sync.Mutex
+	var metrics []ValidationMetrics
+	validator, err := New(ValidatorConfig{
+		Options: []Option{WithFormats(SBOM_SPDX), WithComponentNaming(true)},
+		OnValidation: func(m ValidationMetrics) {
+			mu.Lock()
+			defer mu.Unlock()
+			metrics = append(metrics, m)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := validator.schemas["schemas/spdx/spdx-2.3.schema.json"]; !ok {
+		t.Fatal("the SPDX 2.3 schema was not preloaded")
+	}
+	for path := range validator.schemas {
+		if strings.HasPrefix(path, "schemas/cyclonedx/") {
+			t.Fatalf("%s was preloaded, but CycloneDX is not enabled", path)
+		}
+	}
+
+	misspelled := spdxDocument(spdxPackage("a", "left-pad", "MIT"), spdxPackage("b", "Left_Pad", "MIT"))
+	want, err := ValidateSBOMDataStructured(misspelled, WithComponentNaming(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := validator.ValidateStructured(context.Background(), misspelled)
+			if err != nil || result.SchemaDigest != want.SchemaDigest || len(result.Warnings) != len(want.Warnings) {
+				t.Errorf("ValidateStructured() = %+v, %v", result, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := validator.Validate(context.Background(), []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6"}`)); err == nil {
+		t.Error("expected an error for a format that is not enabled")
+	}
+
+	if len(metrics) != 9 {
+		t.Fatalf("got %d metrics, want 9", len(metrics))
+	}
+	failed := 0
+	for _, m := range metrics {
+		switch {
+		case m.Err != nil:
+			failed++
+			if m.Valid {
+				t.Errorf("a failed validation is valid: %+v", m)
+			}
+		case m.SBOMType != SBOM_SPDX || m.SBOMVersion != "2.3" || !m.Valid || m.Warnings != 1 || m.Errors != 0:
+			t.Errorf("unexpected metrics: %+v", m)
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d validations failed, want 1", failed)
+	}
+}
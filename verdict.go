@@ -0,0 +1,244 @@
+package sbomvalidator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSignatureVerification is wrapped by the error validation returns when
+// the signature required by `WithSignatureVerification` does not verify.
+var ErrSignatureVerification = errors.New("signature verification failed")
+
+// Subsystem is a group of checks whose outcome a Verdict reports separately.
+type Subsystem string
+
+// Subsystems, in the order validation runs them. Schema covers parsing the
+// document and checking it against its JSON schema; semantic covers every
+// rule beyond the schema; policy covers the rules of a policy file and its
+// suppressions.
+const (
+	SubsystemSignature Subsystem = "signature"
+	SubsystemSchema    Subsystem = "schema"
+	SubsystemSemantic  Subsystem = "semantic"
+	SubsystemPolicy    Subsystem = "policy"
+)
+
+// VerdictStatus is the outcome of a subsystem, or of the whole validation.
+type VerdictStatus string
+
+// Verdict statuses. Pass and warn let the SBOM through; fail means a check
+// rejected it; error means it could not be checked at all, e.g. because it
+// is not an SBOM; skipped means the subsystem was not enabled or did not run.
+const (
+	VerdictPass    VerdictStatus = "pass"
+	VerdictWarn    VerdictStatus = "warn"
+	VerdictFail    VerdictStatus = "fail"
+	VerdictError   VerdictStatus = "error"
+	VerdictSkipped VerdictStatus = "skipped"
+)
+
+// SubsystemVerdict is the outcome of one subsystem.
+type SubsystemVerdict struct {
+	Subsystem Subsystem     `json:"subsystem"`
+	Status    VerdictStatus `json:"status"`
+	// Errors and Warnings count the subsystem's findings, not including
+	// suppressed ones.
+	Errors   int `json:"errors,omitempty"`
+	Warnings int `json:"warnings,omitempty"`
+	// Reason explains the status, e.g. the first error of a failed
+	// subsystem or why it was skipped.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Verdict aggregates the outcome of every subsystem of a validation into a
+// single status, so that CI gates need not inspect each kind of check.
+type Verdict struct {
+	Status     VerdictStatus      `json:"status"`
+	Subsystems []SubsystemVerdict `json:"subsystems"`
+	// Explanation names the subsystems that caused a failure and why, or
+	// summarizes a passing verdict.
+	Explanation string `json:"explanation"`
+}
+
+// NewVerdict builds the verdict of a validation from its result and error,
+// so it composes with ValidateSBOMDataStructured:
+//
+//	verdict := sbomvalidator.NewVerdict(sbomvalidator.ValidateSBOMDataStructured(data, opts...))
+//	if !verdict.Passed() {
+//	    log.Fatal(verdict.Explanation)
+//	}
+//
+// Findings are attributed to subsystems by their rule (see `RuleCatalog`).
+// A subsystem that was not enabled, or did not run because validation
+// stopped early, is skipped. The overall status is the worst of the
+// subsystems': error, then fail, then warn, then pass. With
+// `ValidationOptions.FailOnWarnings`, subsystems with warnings fail.
+func NewVerdict(result *StructuredResult, err error) Verdict {
+	if result == nil {
+		result = &StructuredResult{}
+	}
+	signature := SubsystemVerdict{Subsystem: SubsystemSignature, Status: VerdictSkipped, Reason: "not enabled"}
+	if result.Signature != nil {
+		signature.Status = VerdictPass
+		signature.Reason = "signed by " + result.Signature.Signer
+	}
+
+	if err != nil {
+		notRun := "not run: the SBOM could not be validated"
+		schema := SubsystemVerdict{Subsystem: SubsystemSchema, Status: VerdictError, Reason: err.Error()}
+		if errors.Is(err, ErrSignatureVerification) {
+			signature = SubsystemVerdict{Subsystem: SubsystemSignature, Status: VerdictFail, Reason: err.Error()}
+			notRun = "not run: the signature did not verify"
+			schema = SubsystemVerdict{Subsystem: SubsystemSchema, Status: VerdictSkipped, Reason: notRun}
+		}
+		return newVerdict([]SubsystemVerdict{
+			signature,
+			schema,
+			{Subsystem: SubsystemSemantic, Status: VerdictSkipped, Reason: notRun},
+			{Subsystem: SubsystemPolicy, Status: VerdictSkipped, Reason: notRun},
+		})
+	}
+
+	subsystems := []SubsystemVerdict{
+		signature,
+		subsystemVerdict(result, SubsystemSchema),
+		subsystemVerdict(result, SubsystemSemantic),
+		subsystemVerdict(result, SubsystemPolicy),
+	}
+	if !result.IsValid && len(result.ValidationErrors) == 0 {
+		// the SBOM was rejected for its warnings (FailOnWarnings)
+		for i := range subsystems {
+			if subsystems[i].Status == VerdictWarn {
+				subsystems[i].Status = VerdictFail
+			}
+		}
+	}
+	return newVerdict(subsystems)
+}
+
+// subsystemVerdict counts the findings of a subsystem of a completed
+// validation.
+func subsystemVerdict(result *StructuredResult, subsystem Subsystem) SubsystemVerdict {
+	v := SubsystemVerdict{Subsystem: subsystem, Status: VerdictPass}
+	if subsystem == SubsystemPolicy && !policyEnabled(result) {
+		v.Status = VerdictSkipped
+		v.Reason = "not enabled"
+		return v
+	}
+
+	var firstError, firstWarning *Finding
+	for i, f := range result.Findings {
+		if ruleSubsystem(f.Rule) != subsystem {
+			continue
+		}
+		switch f.Level {
+		case LevelError:
+			v.Errors++
+			if firstError == nil {
+				firstError = &result.Findings[i]
+			}
+		case LevelWarning:
+			v.Warnings++
+			if firstWarning == nil {
+				firstWarning = &result.Findings[i]
+			}
+		}
+	}
+	switch {
+	case v.Errors > 0:
+		v.Status = VerdictFail
+		v.Reason = fmt.Sprintf("%d %s, first: %s", v.Errors, plural(v.Errors, "error"), firstError)
+	case v.Warnings > 0:
+		v.Status = VerdictWarn
+		v.Reason = fmt.Sprintf("%d %s, first: %s", v.Warnings, plural(v.Warnings, "warning"), firstWarning)
+	}
+	return v
+}
+
+// plural returns noun, made plural unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+	return noun + "s"
+}
+
+// ruleSubsystem returns the subsystem a rule belongs to.
+func ruleSubsystem(rule string) Subsystem {
+	switch rule {
+	case RuleDocument, RuleSchema, RuleSchemaFormat, RuleUnknownSpecVersion:
+		return SubsystemSchema
+	case RulePolicyLicense, RulePolicySpecVersion, RulePolicyRequiredField, RuleSuppression:
+		return SubsystemPolicy
+	default:
+		return SubsystemSemantic
+	}
+}
+
+// policyEnabled reports whether a policy or suppressions applied to the
+// validation. Results decoded from JSON do not record the rules evaluated,
+// so their policy findings also count.
+func policyEnabled(result *StructuredResult) bool {
+	for _, rule := range result.evaluatedRules {
+		if ruleSubsystem(rule) == SubsystemPolicy {
+			return true
+		}
+	}
+	for _, f := range result.Findings {
+		if ruleSubsystem(f.Rule) == SubsystemPolicy {
+			return true
+		}
+	}
+	return len(result.Suppressed) > 0
+}
+
+// newVerdict sets the overall status and explanation from the subsystems'.
+func newVerdict(subsystems []SubsystemVerdict) Verdict {
+	v := Verdict{Status: VerdictPass, Subsystems: subsystems}
+	rank := map[VerdictStatus]int{VerdictWarn: 1, VerdictFail: 2, VerdictError: 3}
+	var enabled []string
+	for _, s := range subsystems {
+		if rank[s.Status] > rank[v.Status] {
+			v.Status = s.Status
+		}
+		if s.Status != VerdictSkipped {
+			enabled = append(enabled, string(s.Subsystem))
+		}
+	}
+
+	var causes []string
+	for _, s := range subsystems {
+		if s.Status == v.Status {
+			causes = append(causes, fmt.Sprintf("%s (%s)", s.Subsystem, s.Reason))
+		}
+	}
+	switch v.Status {
+	case VerdictPass:
+		v.Explanation = "passed: " + strings.Join(enabled, ", ")
+	case VerdictWarn:
+		v.Explanation = "passed with warnings from " + strings.Join(causes, "; ")
+	case VerdictFail:
+		v.Explanation = "failed by " + strings.Join(causes, "; ")
+	case VerdictError:
+		v.Explanation = "could not validate: " + strings.Join(causes, "; ")
+	}
+	return v
+}
+
+// Passed reports whether the SBOM got through every enabled subsystem,
+// possibly with warnings.
+func (v Verdict) Passed() bool {
+	return v.Status == VerdictPass || v.Status == VerdictWarn
+}
+
+// Failed returns the subsystems that failed or could not run the SBOM.
+func (v Verdict) Failed() []Subsystem {
+	var failed []Subsystem
+	for _, s := range v.Subsystems {
+		if s.Status == VerdictFail || s.Status == VerdictError {
+			failed = append(failed, s.Subsystem)
+		}
+	}
+	return failed
+}
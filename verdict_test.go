@@ -0,0 +1,111 @@
+//go:build !sbomvalidator_no_spdx
+
+package sbomvalidator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewVerdict(t *testing.T) {
+	sbom := spdxDocument(spdxPackage("a", "a", "MIT"), spdxPackage("b", "b", "GPL-3.0-only"))
+	allowMIT := Policy{AllowedLicenses: []string{"MIT"}}
+	warnOnLicense := Policy{AllowedLicenses: []string{"MIT"}, Severity: map[string]string{RulePolicyLicense: "warning"}}
+
+	tests := []struct {
+		name            string
+		sbom            []byte
+		opts            []Option
+		wantStatus      VerdictStatus
+		wantSubsystems  []VerdictStatus // signature, schema, semantic, policy
+		wantExplanation string
+	}{
+		{
+			name: "Valid", sbom: sbom,
+			wantStatus:      VerdictPass,
+			wantSubsystems:  []VerdictStatus{VerdictSkipped, VerdictPass, VerdictPass, VerdictSkipped},
+			wantExplanation: "passed: schema, semantic",
+		},
+		{
+			name: "Policy violation", sbom: sbom, opts: []Option{WithPolicy(allowMIT)},
+			wantStatus:      VerdictFail,
+			wantSubsystems:  []VerdictStatus{VerdictSkipped, VerdictPass, VerdictPass, VerdictFail},
+			wantExplanation: `failed by policy (1 error, first: packages.1.licenseDeclared: license "GPL-3.0-only" is not allowed`,
+		},
+		{
+			name: "Policy warning", sbom: sbom, opts: []Option{WithPolicy(warnOnLicense)},
+			wantStatus:      VerdictWarn,
+			wantSubsystems:  []VerdictStatus{VerdictSkipped, VerdictPass, VerdictPass, VerdictWarn},
+			wantExplanation: "passed with warnings from policy (1 warning",
+		},
+		{
+			name: "Failing on warnings", sbom: sbom,
+			opts:            []Option{WithValidationOptions(ValidationOptions{FailOnWarnings: true}), WithPolicy(warnOnLicense)},
+			wantStatus:      VerdictFail,
+			wantSubsystems:  []VerdictStatus{VerdictSkipped, VerdictPass, VerdictPass, VerdictFail},
+			wantExplanation: "failed by policy (1 warning",
+		},
+		{
+			name: "Schema errors", sbom: []byte(`{"spdxVersion": "SPDX-2.3"}`),
+			wantStatus:      VerdictFail,
+			wantSubsystems:  []VerdictStatus{VerdictSkipped, VerdictFail, VerdictPass, VerdictSkipped},
+			wantExplanation: "failed by schema (5 errors, first: (root): SPDXID is required)",
+		},
+		{
+			name: "Not an SBOM", sbom: []byte("not json"),
+			wantStatus:      VerdictError,
+			wantSubsystems:  []VerdictStatus{VerdictSkipped, VerdictError, VerdictSkipped, VerdictSkipped},
+			wantExplanation: "could not validate: schema (unsupported file format)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := NewVerdict(ValidateSBOMDataStructured(tt.sbom, tt.opts...))
+			var statuses []VerdictStatus
+			for _, s := range verdict.Subsystems {
+				statuses = append(statuses, s.Status)
+			}
+			if verdict.Status != tt.wantStatus || !reflect.DeepEqual(statuses, tt.wantSubsystems) {
+				t.Errorf("NewVerdict() = %s %v, want %s %v", verdict.Status, statuses, tt.wantStatus, tt.wantSubsystems)
+			}
+			if !strings.HasPrefix(verdict.Explanation, tt.wantExplanation) {
+				t.Errorf("Explanation = %q, want %q", verdict.Explanation, tt.wantExplanation)
+			}
+			if passed := tt.wantStatus == VerdictPass || tt.wantStatus == VerdictWarn; verdict.Passed() != passed {
+				t.Errorf("Passed() = %v, want %v", verdict.Passed(), passed)
+			}
+		})
+	}
+}
+
+func TestNewVerdictSignature(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signed, err := SignSBOM(spdxDocument(spdxPackage("a", "a", "MIT")), key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	policy := SignaturePolicy{TrustedKeys: map[string]crypto.PublicKey{"release": key.Public()}, DetachedSignature: signed.Bundle}
+
+	verdict := NewVerdict(ValidateSBOMDataStructured(signed.Document, WithSignatureVerification(policy)))
+	if verdict.Status != VerdictPass || verdict.Subsystems[0].Status != VerdictPass || verdict.Explanation != "passed: signature, schema, semantic" {
+		t.Errorf("Unexpected verdict for a signed SBOM: %+v", verdict)
+	}
+
+	policy.DetachedSignature = nil
+	result, err := ValidateSBOMDataStructured(signed.Document, WithSignatureVerification(policy))
+	if !errors.Is(err, ErrSignatureVerification) {
+		t.Fatalf("Expected a signature verification error, got %v", err)
+	}
+	verdict = NewVerdict(result, err)
+	if verdict.Status != VerdictFail || !reflect.DeepEqual(verdict.Failed(), []Subsystem{SubsystemSignature}) ||
+		verdict.Subsystems[1].Reason != "not run: the signature did not verify" {
+		t.Errorf("Unexpected verdict for an unsigned SBOM: %+v", verdict)
+	}
+}
@@ -0,0 +1,130 @@
+package vex
+
+import (
+	"fmt"
+	"strings"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// checkCycloneDX checks the vulnerabilities of a CycloneDX VEX document. The
+// CycloneDX schema already checks that analysis states and justifications
+// are legal and that timestamps are date-times.
+func (c *checker) checkCycloneDX(doc map[string]interface{}) {
+	refs := map[string]map[string]interface{}{}
+	if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
+		if component, ok := metadata["component"].(map[string]interface{}); ok {
+			collectBOMRefs(refs, []interface{}{component})
+		}
+		c.timestamp(metadata, "metadata.", "timestamp", false)
+	}
+	components, _ := doc["components"].([]interface{})
+	collectBOMRefs(refs, components)
+	services, _ := doc["services"].([]interface{})
+	collectBOMRefs(refs, services)
+
+	vulnerabilities, _ := doc["vulnerabilities"].([]interface{})
+	for i, v := range vulnerabilities {
+		vulnerability, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("vulnerabilities.%d.", i)
+		c.timestamp(vulnerability, path, "created", false)
+		c.ordered(vulnerability, path, "published", "updated", false)
+		c.timestamp(vulnerability, path, "rejected", false)
+
+		if analysis, ok := vulnerability["analysis"].(map[string]interface{}); ok {
+			c.checkCycloneDXAnalysis(analysis, path+"analysis.")
+			c.ordered(analysis, path+"analysis.", "firstIssued", "lastUpdated", false)
+		}
+
+		affects, _ := vulnerability["affects"].([]interface{})
+		if len(affects) == 0 {
+			c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXProduct, path+"affects",
+				"the vulnerability affects no components or services")
+		}
+		for j, a := range affects {
+			affect, _ := a.(map[string]interface{})
+			ref, _ := affect["ref"].(string)
+			c.checkCycloneDXRef(refs, ref, fmt.Sprintf("%saffects.%d.ref", path, j))
+		}
+	}
+}
+
+// checkCycloneDXAnalysis checks that an analysis carries what its state
+// requires, following the CISA minimum requirements for VEX.
+func (c *checker) checkCycloneDXAnalysis(analysis map[string]interface{}, path string) {
+	state, _ := analysis["state"].(string)
+	_, hasJustification := analysis["justification"].(string)
+	_, hasDetail := analysis["detail"].(string)
+	responses, _ := analysis["response"].([]interface{})
+
+	switch state {
+	case "not_affected":
+		if !hasJustification && !hasDetail {
+			c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXStatus, path+"state",
+				"a not_affected analysis must give a justification or a detail")
+		}
+	case "exploitable":
+		if len(responses) == 0 && !hasDetail {
+			c.add(sbomvalidator.LevelWarning, sbomvalidator.RuleVEXStatus, path+"state",
+				"an exploitable analysis should give a response or a detail")
+		}
+	}
+	if hasJustification && state != "not_affected" {
+		c.add(sbomvalidator.LevelWarning, sbomvalidator.RuleVEXStatus, path+"justification",
+			fmt.Sprintf("justifications only apply to not_affected analyses, not %s", state))
+	}
+}
+
+// checkCycloneDXRef checks that an affected ref refers to a component or
+// service of the document or, as a BOM-Link, of another BOM. Given an SBOM,
+// a component of the document with a purl or CPE must be one of its
+// components.
+func (c *checker) checkCycloneDXRef(refs map[string]map[string]interface{}, ref, path string) {
+	if strings.HasPrefix(ref, "urn:cdx:") {
+		return
+	}
+	component, ok := refs[ref]
+	if !ok {
+		c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXProduct, path,
+			fmt.Sprintf("%q does not refer to a component or service", ref))
+		return
+	}
+	if c.products == nil {
+		return
+	}
+
+	var matchable, declared bool
+	for _, key := range []string{"purl", "cpe"} {
+		if id, ok := component[key].(string); ok {
+			if d, ok := c.product(path, id); ok {
+				matchable = true
+				declared = declared || d
+			}
+		}
+	}
+	if matchable && !declared {
+		c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXProduct, path,
+			fmt.Sprintf("%q is not a component of the SBOM", ref))
+	}
+}
+
+// collectBOMRefs indexes components and services, including nested ones, by
+// bom-ref.
+func collectBOMRefs(refs map[string]map[string]interface{}, list []interface{}) {
+	for _, item := range list {
+		element, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ref, ok := element["bom-ref"].(string); ok {
+			refs[ref] = element
+		}
+		for _, key := range []string{"components", "services"} {
+			nested, _ := element[key].([]interface{})
+			collectBOMRefs(refs, nested)
+		}
+	}
+}
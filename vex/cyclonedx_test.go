@@ -0,0 +1,91 @@
+package vex
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCheckCycloneDX(t *testing.T) {
+	const components = `"metadata": {"timestamp": "2024-10-01T09:00:00Z",
+    "component": {"type": "application", "name": "app", "bom-ref": "app",
+      "components": [{"type": "library", "name": "left-pad", "bom-ref": "left-pad", "purl": "pkg:npm/left-pad@1.3.0"}]}},
+  "services": [{"name": "api", "bom-ref": "api"}]`
+
+	tests := []struct {
+		name         string
+		vulns        string
+		sbom         string
+		wantFindings []string
+	}{
+		{
+			name: "Valid",
+			vulns: `[{"id": "CVE-2024-0001", "affects": [{"ref": "left-pad"}, {"ref": "api"}, {"ref": "urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1#lib"}],
+  "analysis": {"state": "not_affected", "justification": "code_not_reachable",
+    "firstIssued": "2024-10-01T09:00:00Z", "lastUpdated": "2024-10-02T09:00:00Z"}}]`,
+		},
+		{
+			name:  "Valid against the SBOM",
+			vulns: `[{"id": "CVE-2024-0001", "affects": [{"ref": "left-pad"}, {"ref": "app"}], "analysis": {"state": "resolved"}}]`,
+			sbom:  spdxSBOM,
+		},
+		{
+			name:         "Dangling ref",
+			vulns:        `[{"id": "CVE-2024-0001", "affects": [{"ref": "lodash"}], "analysis": {"state": "in_triage"}}]`,
+			wantFindings: []string{"vex-product vulnerabilities.0.affects.0.ref"},
+		},
+		{
+			name:         "No affects",
+			vulns:        `[{"id": "CVE-2024-0001", "analysis": {"state": "in_triage"}}]`,
+			wantFindings: []string{"vex-product vulnerabilities.0.affects"},
+		},
+		{
+			name: "Status requirements",
+			vulns: `[{"id": "CVE-2024-0001", "affects": [{"ref": "app"}], "analysis": {"state": "not_affected"}},
+  {"id": "CVE-2024-0002", "affects": [{"ref": "app"}], "analysis": {"state": "exploitable", "justification": "code_not_present"}}]`,
+			wantFindings: []string{
+				"vex-status vulnerabilities.0.analysis.state",
+				"vex-status vulnerabilities.1.analysis.state",
+				"vex-status vulnerabilities.1.analysis.justification",
+			},
+		},
+		{
+			name: "Timestamps",
+			vulns: `[{"id": "CVE-2024-0001", "affects": [{"ref": "app"}], "published": "2024-10-02T09:00:00Z", "updated": "2024-10-01T09:00:00Z",
+  "analysis": {"state": "in_triage", "firstIssued": "2030-01-01T00:00:00Z", "lastUpdated": "not a date"}}]`,
+			wantFindings: []string{"vex-timestamp vulnerabilities.0.updated", "vex-timestamp vulnerabilities.0.analysis.firstIssued"},
+		},
+		{
+			name:         "Component not in the SBOM",
+			vulns:        `[{"id": "CVE-2024-0001", "affects": [{"ref": "left-pad"}], "analysis": {"state": "in_triage"}}]`,
+			sbom:         `{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "packages": []}`,
+			wantFindings: []string{"vex-product vulnerabilities.0.affects.0.ref"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc map[string]interface{}
+			if err := json.Unmarshal([]byte(`{"bomFormat": "CycloneDX", "specVersion": "1.6", `+components+`, "vulnerabilities": `+tt.vulns+`}`), &doc); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			c := &checker{now: now}
+			if tt.sbom != "" {
+				var err error
+				if c.products, err = sbomProducts([]byte(tt.sbom), nil); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			c.checkCycloneDX(doc)
+
+			var got []string
+			for _, f := range c.findings {
+				got = append(got, f.Rule+" "+f.Path)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantFindings, "\n") {
+				t.Errorf("findings = %q, want %q", got, tt.wantFindings)
+			}
+		})
+	}
+}
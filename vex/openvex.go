@@ -0,0 +1,133 @@
+package vex
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// OpenVEX statuses.
+const (
+	StatusNotAffected        = "not_affected"
+	StatusAffected           = "affected"
+	StatusFixed              = "fixed"
+	StatusUnderInvestigation = "under_investigation"
+)
+
+var openVEXStatuses = []string{StatusNotAffected, StatusAffected, StatusFixed, StatusUnderInvestigation}
+
+// openVEXJustifications are the justifications a not_affected statement may
+// give.
+var openVEXJustifications = []string{
+	"component_not_present",
+	"vulnerable_code_not_present",
+	"vulnerable_code_not_in_execute_path",
+	"vulnerable_code_cannot_be_controlled_by_adversary",
+	"inline_mitigations_already_exist",
+}
+
+// checkOpenVEX checks the statements of an OpenVEX document.
+func (c *checker) checkOpenVEX(doc map[string]interface{}) {
+	c.ordered(doc, "", "timestamp", "last_updated", true)
+
+	statements, _ := doc["statements"].([]interface{})
+	for i, s := range statements {
+		statement, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("statements.%d.", i)
+		c.checkOpenVEXStatus(statement, path)
+		c.ordered(statement, path, "timestamp", "last_updated", true)
+		c.timestamp(statement, path, "action_statement_timestamp", true)
+
+		products, _ := statement["products"].([]interface{})
+		if len(products) == 0 {
+			c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXProduct, path+"products",
+				"the statement applies to no products")
+		}
+		for j, p := range products {
+			product, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			productPath := fmt.Sprintf("%sproducts.%d", path, j)
+			c.checkOpenVEXProduct(product, productPath)
+			subcomponents, _ := product["subcomponents"].([]interface{})
+			for k, sc := range subcomponents {
+				if subcomponent, ok := sc.(map[string]interface{}); ok {
+					c.checkOpenVEXProduct(subcomponent, fmt.Sprintf("%s.subcomponents.%d", productPath, k))
+				}
+			}
+		}
+	}
+}
+
+// checkOpenVEXStatus checks that the status of a statement is legal and that
+// the statement carries what its status requires.
+func (c *checker) checkOpenVEXStatus(statement map[string]interface{}, path string) {
+	status, _ := statement["status"].(string)
+	if !slices.Contains(openVEXStatuses, status) {
+		c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXStatus, path+"status",
+			fmt.Sprintf("%q is not an OpenVEX status; expected one of %s", status, strings.Join(openVEXStatuses, ", ")))
+		return
+	}
+
+	justification, hasJustification := statement["justification"].(string)
+	_, hasImpact := statement["impact_statement"].(string)
+	_, hasAction := statement["action_statement"].(string)
+
+	if hasJustification && !slices.Contains(openVEXJustifications, justification) {
+		c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXStatus, path+"justification",
+			fmt.Sprintf("%q is not an OpenVEX justification; expected one of %s", justification, strings.Join(openVEXJustifications, ", ")))
+	}
+
+	switch status {
+	case StatusNotAffected:
+		if !hasJustification && !hasImpact {
+			c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXStatus, path+"status",
+				"a not_affected statement must give a justification or an impact_statement")
+		}
+	case StatusAffected:
+		if !hasAction {
+			c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXStatus, path+"status",
+				"an affected statement must give an action_statement")
+		}
+	}
+	if status != StatusNotAffected && (hasJustification || hasImpact) {
+		c.add(sbomvalidator.LevelWarning, sbomvalidator.RuleVEXStatus, path+"status",
+			fmt.Sprintf("justifications and impact statements only apply to not_affected statements, not %s", status))
+	}
+	if status != StatusAffected && hasAction {
+		c.add(sbomvalidator.LevelWarning, sbomvalidator.RuleVEXStatus, path+"status",
+			fmt.Sprintf("action statements only apply to affected statements, not %s", status))
+	}
+}
+
+// checkOpenVEXProduct checks the identifiers of a product or subcomponent
+// and, given an SBOM, that it is one of its components.
+func (c *checker) checkOpenVEXProduct(product map[string]interface{}, path string) {
+	var matchable, declared bool
+	check := func(path, id string) {
+		if d, ok := c.product(path, id); ok {
+			matchable = true
+			declared = declared || d
+		}
+	}
+
+	if id, ok := product["@id"].(string); ok {
+		check(path+".@id", id)
+	}
+	identifiers, _ := product["identifiers"].(map[string]interface{})
+	for _, key := range []string{"purl", "cpe23", "cpe22"} {
+		if id, ok := identifiers[key].(string); ok {
+			check(path+".identifiers."+key, id)
+		}
+	}
+
+	if c.products != nil && matchable && !declared {
+		c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXProduct, path, "the product is not a component of the SBOM")
+	}
+}
@@ -0,0 +1,331 @@
+// Package vex validates VEX (Vulnerability Exploitability eXchange) documents
+// published alongside SBOMs: CycloneDX VEX, i.e. CycloneDX BOMs whose
+// vulnerabilities carry an analysis, and OpenVEX JSON.
+//
+// Besides the schema of its format, a document is checked for what a schema
+// cannot express: statements refer to well-formed product identifiers (and,
+// given the SBOM they describe, to its components), statuses are legal and
+// carry the justification or action their status requires, and timestamps
+// are well-formed, ordered and not in the future.
+//
+// Example:
+//
+//	result, err := vex.Validate(data, vex.Config{SBOM: sbom})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, f := range result.Findings {
+//	    fmt.Println(f)
+//	}
+package vex
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+// VEX formats recognized by Detect.
+const (
+	FormatCycloneDX = "CycloneDX VEX"
+	FormatOpenVEX   = "OpenVEX"
+)
+
+// maxClockSkew is how far in the future a timestamp may be before it is
+// reported, to tolerate clocks that are slightly off.
+const maxClockSkew = 5 * time.Minute
+
+//go:embed schemas
+var schemaFS embed.FS
+
+// openVEXContexts maps the OpenVEX JSON-LD contexts to the spec version they
+// declare; the unversioned context is the latest version.
+var openVEXContexts = map[string]string{
+	"https://openvex.dev/ns":        "0.2.0",
+	"https://openvex.dev/ns/v0.2.0": "0.2.0",
+}
+
+var openVEXSchema struct {
+	once   sync.Once
+	schema *sbomvalidator.JSONSchema
+	err    error
+}
+
+// Config configures the validation of a VEX document.
+type Config struct {
+	// Options are passed to `sbomvalidator.ValidateSBOMDataStructured` when
+	// validating CycloneDX VEX, and to `sbomvalidator.ExtractComponents` when
+	// reading SBOM.
+	Options []sbomvalidator.Option
+	// SBOM, when set, is the SBOM the document describes, in any format
+	// ValidateSBOMData accepts. Products identified by a purl or CPE must
+	// then be components of it. Purls are compared without their qualifiers
+	// and subpath.
+	SBOM []byte
+	// Now returns the current time, against which timestamps are checked
+	// not to be in the future. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Result is the outcome of validating a VEX document.
+type Result struct {
+	// Format is FormatCycloneDX or FormatOpenVEX.
+	Format string `json:"format"`
+	// Version is the spec version of the document, e.g., "1.6" or "0.2.0".
+	Version string `json:"version"`
+	// IsValid is false if any finding is an error.
+	IsValid bool `json:"isValid"`
+	// Statements is the number of statements (OpenVEX) or vulnerabilities
+	// (CycloneDX) in the document.
+	Statements int                     `json:"statements"`
+	Findings   []sbomvalidator.Finding `json:"findings,omitempty"`
+}
+
+// Detect reports whether a JSON document is a VEX document.
+//
+// Parameters:
+//   - content: The document, as JSON.
+//
+// Returns:
+//   - The format (FormatCycloneDX or FormatOpenVEX) and spec version.
+//   - An error if content is not JSON, not a VEX document, or an OpenVEX
+//     document of an unsupported version. A CycloneDX BOM is only VEX if one
+//     of its vulnerabilities carries an analysis.
+//
+// Example:
+//
+//	format, version, err := vex.Detect(data)
+//	if err == nil {
+//	    fmt.Println(format, version) // OpenVEX 0.2.0
+//	}
+func Detect(content []byte) (string, string, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return "", "", fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	return detect(doc)
+}
+
+func detect(doc map[string]interface{}) (string, string, error) {
+	if context, ok := doc["@context"].(string); ok && strings.HasPrefix(context, "https://openvex.dev/ns") {
+		version, ok := openVEXContexts[strings.TrimSuffix(context, "/")]
+		if !ok {
+			return "", "", fmt.Errorf("unsupported OpenVEX version: %s", context)
+		}
+		return FormatOpenVEX, version, nil
+	}
+
+	if doc["bomFormat"] == sbomvalidator.SBOM_CYCLONEDX {
+		vulnerabilities, _ := doc["vulnerabilities"].([]interface{})
+		for _, v := range vulnerabilities {
+			if vulnerability, ok := v.(map[string]interface{}); ok && vulnerability["analysis"] != nil {
+				version, _ := doc["specVersion"].(string)
+				return FormatCycloneDX, version, nil
+			}
+		}
+		return "", "", fmt.Errorf("the CycloneDX BOM has no vulnerability analysis, so it is not VEX")
+	}
+
+	return "", "", fmt.Errorf("not a CycloneDX VEX or OpenVEX document")
+}
+
+// Validate validates a VEX document against the schema of its format and
+// checks its statements.
+//
+// Parameters:
+//   - content: The document, as JSON.
+//   - config: The validation settings.
+//
+// Returns:
+//   - The result, with the schema findings and those of rules
+//     `sbomvalidator.RuleVEXProduct`, `RuleVEXStatus` and `RuleVEXTimestamp`.
+//     For CycloneDX VEX, it also has the findings of
+//     `sbomvalidator.ValidateSBOMDataStructured`.
+//   - An error if the document is not VEX (see Detect), or config.SBOM or a
+//     CycloneDX document cannot be validated at all.
+//
+// Example:
+//
+//	result, err := vex.Validate(data, vex.Config{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(result.Format, result.IsValid)
+func Validate(content []byte, config Config) (*Result, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %v", err)
+	}
+	format, version, err := detect(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{now: time.Now()}
+	if config.Now != nil {
+		c.now = config.Now()
+	}
+	if config.SBOM != nil {
+		if c.products, err = sbomProducts(config.SBOM, config.Options); err != nil {
+			return nil, fmt.Errorf("invalid SBOM: %v", err)
+		}
+	}
+
+	result := &Result{Format: format, Version: version, IsValid: true}
+	var findings []sbomvalidator.Finding
+	if format == FormatOpenVEX {
+		schema, err := compiledOpenVEXSchema()
+		if err != nil {
+			return nil, err
+		}
+		if findings, err = schema.Validate(content); err != nil {
+			return nil, err
+		}
+		statements, _ := doc["statements"].([]interface{})
+		result.Statements = len(statements)
+		c.checkOpenVEX(doc)
+	} else {
+		structured, err := sbomvalidator.ValidateSBOMDataStructured(content, config.Options...)
+		if err != nil {
+			return nil, err
+		}
+		findings = structured.Findings
+		result.IsValid = structured.IsValid
+		vulnerabilities, _ := doc["vulnerabilities"].([]interface{})
+		result.Statements = len(vulnerabilities)
+		c.checkCycloneDX(doc)
+	}
+
+	result.Findings = append(findings, c.findings...)
+	for _, f := range result.Findings {
+		if f.Level == sbomvalidator.LevelError {
+			result.IsValid = false
+		}
+	}
+	return result, nil
+}
+
+func compiledOpenVEXSchema() (*sbomvalidator.JSONSchema, error) {
+	openVEXSchema.once.Do(func() {
+		data, err := schemaFS.ReadFile("schemas/openvex-0.2.0.schema.json")
+		if err != nil {
+			openVEXSchema.err = err
+			return
+		}
+		openVEXSchema.schema, openVEXSchema.err = sbomvalidator.CompileJSONSchema(data)
+	})
+	return openVEXSchema.schema, openVEXSchema.err
+}
+
+// sbomProducts returns the purls, without qualifiers and subpath, and CPEs
+// of the components of an SBOM.
+func sbomProducts(sbom []byte, options []sbomvalidator.Option) (map[string]bool, error) {
+	components, err := sbomvalidator.ExtractComponents(sbom, options...)
+	if err != nil {
+		return nil, err
+	}
+	products := map[string]bool{}
+	for _, c := range components {
+		if c.PURL != "" {
+			products[purlIdentity(c.PURL)] = true
+		}
+		if c.CPE != "" {
+			products[c.CPE] = true
+		}
+	}
+	return products, nil
+}
+
+// purlIdentity strips the qualifiers and subpath of a purl.
+func purlIdentity(purl string) string {
+	if i := strings.IndexAny(purl, "?#"); i >= 0 {
+		return purl[:i]
+	}
+	return purl
+}
+
+// checker accumulates the findings of the semantic checks of a document.
+type checker struct {
+	now time.Time
+	// products are the purls and CPEs of the SBOM the document describes,
+	// or nil if there is none.
+	products map[string]bool
+	findings []sbomvalidator.Finding
+}
+
+func (c *checker) add(level sbomvalidator.FindingLevel, rule, path, message string) {
+	c.findings = append(c.findings, sbomvalidator.Finding{
+		Level:   level,
+		Rule:    rule,
+		Path:    path,
+		Pointer: jsonPointer(path),
+		Message: message,
+	})
+}
+
+// timestamp checks the timestamp at path, if the document has one, and
+// returns it. Malformed timestamps are reported unless the schema of the
+// format already checks them.
+func (c *checker) timestamp(holder map[string]interface{}, path, field string, reportMalformed bool) (time.Time, bool) {
+	value, ok := holder[field].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if reportMalformed {
+			c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXTimestamp, path+field,
+				fmt.Sprintf("%q is not an RFC 3339 timestamp", value))
+		}
+		return time.Time{}, false
+	}
+	if t.After(c.now.Add(maxClockSkew)) {
+		c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXTimestamp, path+field,
+			fmt.Sprintf("%s is in the future", value))
+	}
+	return t, true
+}
+
+// ordered checks that the later of two timestamps of holder, if both are
+// set, is not before the earlier.
+func (c *checker) ordered(holder map[string]interface{}, path, earlier, later string, reportMalformed bool) {
+	first, ok := c.timestamp(holder, path, earlier, reportMalformed)
+	second, ok2 := c.timestamp(holder, path, later, reportMalformed)
+	if ok && ok2 && second.Before(first) {
+		c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXTimestamp, path+later,
+			fmt.Sprintf("%s is before %s", later, earlier))
+	}
+}
+
+// product checks an identifier of a product, if it is a purl or CPE, and
+// reports whether it is a component of the SBOM; ok is false if the
+// identifier cannot be matched against the SBOM.
+func (c *checker) product(path, id string) (declared, ok bool) {
+	switch {
+	case strings.HasPrefix(id, "pkg:"):
+		if err := sbomvalidator.ValidatePackageURL(id); err != nil {
+			c.add(sbomvalidator.LevelError, sbomvalidator.RuleVEXProduct, path, err.Error())
+			return false, false
+		}
+		return c.products[purlIdentity(id)], true
+	case strings.HasPrefix(id, "cpe:"):
+		return c.products[id], true
+	}
+	return false, false
+}
+
+// jsonPointer returns the RFC 6901 JSON pointer of a dotted path.
+func jsonPointer(path string) string {
+	var b strings.Builder
+	for _, segment := range strings.Split(path, ".") {
+		segment = strings.ReplaceAll(segment, "~", "~0")
+		segment = strings.ReplaceAll(segment, "/", "~1")
+		fmt.Fprintf(&b, "/%s", segment)
+	}
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package vex
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	sbomvalidator "github.com/shiftleftcyber/sbom-validator"
+)
+
+var now = time.Date(2024, 10, 22, 12, 0, 0, 0, time.UTC)
+
+// openVEX returns an OpenVEX document with the given statements.
+func openVEX(statements ...string) []byte {
+	return []byte(fmt.Sprintf(`{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "@id": "https://example.com/vex/1",
+  "author": "Example Security",
+  "timestamp": "2024-10-01T09:00:00Z",
+  "version": 1,
+  "statements": [%s]
+}`, strings.Join(statements, ",")))
+}
+
+const notAffected = `{"vulnerability": {"name": "CVE-2024-0001"}, "products": [{"@id": "pkg:npm/left-pad@1.3.0"}],
+  "status": "not_affected", "justification": "vulnerable_code_not_in_execute_path"}`
+
+const spdxSBOM = `{"spdxVersion": "SPDX-2.3", "SPDXID": "SPDXRef-DOCUMENT", "packages": [
+  {"SPDXID": "SPDXRef-a", "name": "left-pad", "versionInfo": "1.3.0",
+   "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl",
+     "referenceLocator": "pkg:npm/left-pad@1.3.0?arch=any"}]}]}`
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		wantFormat  string
+		wantVersion string
+		expectErr   bool
+	}{
+		{name: "OpenVEX", doc: string(openVEX(notAffected)), wantFormat: FormatOpenVEX, wantVersion: "0.2.0"},
+		{name: "Unversioned OpenVEX", doc: `{"@context": "https://openvex.dev/ns"}`, wantFormat: FormatOpenVEX, wantVersion: "0.2.0"},
+		{name: "Unsupported OpenVEX", doc: `{"@context": "https://openvex.dev/ns/v0.0.1"}`, expectErr: true},
+		{
+			name:       "CycloneDX VEX",
+			doc:        `{"bomFormat": "CycloneDX", "specVersion": "1.6", "vulnerabilities": [{"id": "CVE-2024-0001", "analysis": {"state": "in_triage"}}]}`,
+			wantFormat: FormatCycloneDX, wantVersion: "1.6",
+		},
+		{name: "CycloneDX SBOM", doc: `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": []}`, expectErr: true},
+		{name: "SPDX", doc: spdxSBOM, expectErr: true},
+		{name: "Not JSON", doc: `not json`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, version, err := Detect([]byte(tt.doc))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Detect() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if format != tt.wantFormat || version != tt.wantVersion {
+				t.Errorf("Detect() = %q, %q, want %q, %q", format, version, tt.wantFormat, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestValidateOpenVEX(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       []byte
+		sbom      string
+		wantValid bool
+		// wantFindings are the "rule path" of the findings expected.
+		wantFindings []string
+	}{
+		{name: "Valid", doc: openVEX(notAffected), wantValid: true},
+		{name: "Valid against the SBOM", doc: openVEX(notAffected), sbom: spdxSBOM, wantValid: true},
+		{
+			name:         "Missing required fields",
+			doc:          []byte(`{"@context": "https://openvex.dev/ns/v0.2.0", "statements": []}`),
+			wantFindings: []string{"schema (root)", "schema (root)", "schema (root)", "schema (root)", "schema statements"},
+		},
+		{
+			name:         "Illegal status",
+			doc:          openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "products": [{"@id": "pkg:npm/a@1"}], "status": "safe"}`),
+			wantFindings: []string{"vex-status statements.0.status"},
+		},
+		{
+			name:         "Not affected without justification",
+			doc:          openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "products": [{"@id": "pkg:npm/a@1"}], "status": "not_affected"}`),
+			wantFindings: []string{"vex-status statements.0.status"},
+		},
+		{
+			name: "Affected without action",
+			doc: openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "products": [{"@id": "pkg:npm/a@1"}], "status": "affected",
+  "justification": "component_not_present"}`),
+			wantFindings: []string{"vex-status statements.0.status", "vex-status statements.0.status"},
+		},
+		{
+			name:         "Illegal justification",
+			doc:          openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "products": [{"@id": "pkg:npm/a@1"}], "status": "not_affected", "justification": "trust_me"}`),
+			wantFindings: []string{"vex-status statements.0.justification"},
+		},
+		{
+			name: "Malformed purls",
+			doc: openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "status": "fixed", "products": [
+  {"@id": "pkg:left-pad", "subcomponents": [{"identifiers": {"purl": "pkg:npm/"}}]}]}`),
+			wantFindings: []string{"vex-product statements.0.products.0.@id", "vex-product statements.0.products.0.subcomponents.0.identifiers.purl"},
+		},
+		{
+			name:         "No products",
+			doc:          openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "status": "fixed"}`),
+			wantFindings: []string{"vex-product statements.0.products"},
+		},
+		{
+			name: "Product not in the SBOM",
+			doc: openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "status": "fixed", "products": [
+  {"identifiers": {"purl": "pkg:npm/lodash@4.17.21"}}, {"@id": "https://example.com/products/app"}]}`),
+			sbom:         spdxSBOM,
+			wantFindings: []string{"vex-product statements.0.products.0"},
+		},
+		{
+			name: "Bad timestamps",
+			doc: openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "products": [{"@id": "pkg:npm/a@1"}], "status": "fixed",
+  "timestamp": "2024-10-02T09:00:00Z", "last_updated": "2024-09-01T09:00:00Z", "action_statement_timestamp": "2025-01-01T00:00:00Z"}`),
+			wantFindings: []string{"vex-timestamp statements.0.last_updated", "vex-timestamp statements.0.action_statement_timestamp"},
+		},
+		{
+			name:         "Malformed timestamp",
+			doc:          []byte(strings.Replace(string(openVEX(notAffected)), "2024-10-01T09:00:00Z", "last tuesday", 1)),
+			wantFindings: []string{"vex-timestamp timestamp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Now: func() time.Time { return now }}
+			if tt.sbom != "" {
+				config.SBOM = []byte(tt.sbom)
+			}
+			result, err := Validate(tt.doc, config)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Format != FormatOpenVEX || result.Version != "0.2.0" {
+				t.Errorf("unexpected format %q %q", result.Format, result.Version)
+			}
+
+			var got []string
+			for _, f := range result.Findings {
+				got = append(got, f.Rule+" "+f.Path)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantFindings, "\n") {
+				t.Errorf("findings = %q, want %q", got, tt.wantFindings)
+			}
+			if result.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v", result.IsValid, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	if _, err := Validate([]byte(spdxSBOM), Config{}); err == nil {
+		t.Error("expected an error for a document that is not VEX")
+	}
+	if _, err := Validate(openVEX(notAffected), Config{SBOM: []byte(`{}`)}); err == nil {
+		t.Error("expected an error for an invalid SBOM")
+	}
+}
+
+func TestFindingPointers(t *testing.T) {
+	result, err := Validate(openVEX(`{"vulnerability": {"name": "CVE-2024-0001"}, "status": "fixed"}`), Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := sbomvalidator.Finding{
+		Level:   sbomvalidator.LevelError,
+		Rule:    sbomvalidator.RuleVEXProduct,
+		Path:    "statements.0.products",
+		Pointer: "/statements/0/products",
+		Message: "the statement applies to no products",
+	}
+	if len(result.Findings) != 1 || result.Findings[0].String() != want.String() || result.Findings[0].Pointer != want.Pointer {
+		t.Errorf("findings = %+v, want %+v", result.Findings, want)
+	}
+}
@@ -0,0 +1,226 @@
+package sbomvalidator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// weakAlgorithms maps normalized algorithm names (upper-case, letters and
+// digits only) to the severity of using them.
+var weakAlgorithms = map[string]Severity{
+	"MD2":         SeverityCritical,
+	"MD4":         SeverityCritical,
+	"MD5":         SeverityHigh,
+	"RC2":         SeverityCritical,
+	"RC4":         SeverityCritical,
+	"DES":         SeverityCritical,
+	"SHA1":        SeverityHigh,
+	"3DES":        SeverityMedium,
+	"TDEA":        SeverityMedium,
+	"TRIPLEDES":   SeverityMedium,
+	"DESEDE":      SeverityMedium,
+	"BLOWFISH":    SeverityMedium,
+	"RIPEMD128":   SeverityMedium,
+	"HMACMD5":     SeverityMedium,
+	"HMACSHA1":    SeverityLow,
+	"SHA1WITHRSA": SeverityHigh,
+	"MD5WITHRSA":  SeverityCritical,
+}
+
+// minimumKeySizes are the smallest acceptable key sizes, in bits, for
+// algorithms whose strength depends on the modulus size.
+var minimumKeySizes = map[string]int{
+	"RSA": 2048,
+	"DSA": 2048,
+	"DH":  2048,
+}
+
+// algorithmKeySizePattern extracts a family and key size from names such as
+// "RSA-1024" or "RSA1024".
+var algorithmKeySizePattern = regexp.MustCompile(`^(RSA|DSA|DH)[-_ ]?(\d+)`)
+
+// CryptoFinding is a weak cryptographic asset found in a CBOM.
+type CryptoFinding struct {
+	Path     string   `json:"path"`
+	Asset    string   `json:"asset"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// CryptoReport represents the outcome of CheckCryptoAssets.
+type CryptoReport struct {
+	AssetCount int             `json:"assetCount"`
+	Findings   []CryptoFinding `json:"findings,omitempty"`
+}
+
+// CheckCryptoAssets inspects the cryptographic asset components of a
+// CycloneDX CBOM (spec 1.6 and later) and reports deprecated algorithms, keys
+// that are too small and legacy protocol versions:
+//
+//   - broken or legacy algorithms such as MD5, SHA-1, DES, 3DES and RC4;
+//   - RSA, DSA and DH keys smaller than 2048 bits;
+//   - SSL, and TLS versions before 1.2.
+//
+// Each finding carries a severity so results can be triaged as a crypto
+// inventory. SBOMs without cryptographic assets yield an empty report.
+//
+// Parameters:
+//   - sbomContent: A byte slice containing the CycloneDX JSON data.
+//
+// Returns:
+//   - A CryptoReport listing the findings.
+//   - An error if the SBOM cannot be parsed or is not CycloneDX.
+//
+// Example:
+//
+//	report, err := CheckCryptoAssets(jsonData)
+//	for _, f := range report.Findings {
+//	    fmt.Printf("[%s] %s: %s\n", f.Severity, f.Path, f.Message)
+//	}
+func CheckCryptoAssets(sbomContent []byte) (*CryptoReport, error) {
+	doc, err := parseSBOMDocument(sbomContent)
+	if err != nil {
+		return nil, err
+	}
+	return checkCryptoAssets(doc)
+}
+
+// checkCryptoAssets reports the cryptographic assets of a parsed SBOM.
+func checkCryptoAssets(doc *sbomDocument) (*CryptoReport, error) {
+	if doc.sbomType != SBOM_CYCLONEDX {
+		return nil, fmt.Errorf("cryptographic assets are only defined for CycloneDX, got %s", doc.sbomType)
+	}
+
+	return cryptoReport(doc.obj), nil
+}
+
+// cryptoReport applies the weak cryptography rules to every cryptographic
+// asset component of a parsed CycloneDX document.
+func cryptoReport(obj map[string]interface{}) *CryptoReport {
+	report := &CryptoReport{}
+
+	walkCycloneDXComponents(obj, func(path string, component map[string]interface{}) {
+		if componentType, _ := component["type"].(string); componentType != "cryptographic-asset" {
+			return
+		}
+		report.AssetCount++
+		report.Findings = append(report.Findings, cryptoAssetFindings(path, component)...)
+	})
+
+	return report
+}
+
+// checkWeakCrypto returns the weak cryptography findings of a parsed CycloneDX
+// document as warnings. Messages carry the severity tag, e.g. "[high] ...".
+func checkWeakCrypto(obj map[string]interface{}) []Finding {
+	var warnings []Finding
+	for _, finding := range cryptoReport(obj).Findings {
+		warnings = append(warnings, Finding{
+			Level:    LevelWarning,
+			Severity: finding.Severity,
+			Rule:     RuleWeakCrypto,
+			Path:     finding.Path,
+			Pointer:  jsonPointer(finding.Path),
+			Message:  fmt.Sprintf("[%s] %s", finding.Severity, finding.Message),
+		})
+	}
+	return warnings
+}
+
+func cryptoAssetFindings(path string, component map[string]interface{}) []CryptoFinding {
+	var findings []CryptoFinding
+
+	name, _ := component["name"].(string)
+	properties, _ := component["cryptoProperties"].(map[string]interface{})
+	assetType, _ := properties["assetType"].(string)
+
+	add := func(severity Severity, message string) {
+		findings = append(findings, CryptoFinding{Path: path, Asset: name, Severity: severity, Message: message})
+	}
+
+	switch assetType {
+	case "algorithm":
+		algorithm, _ := properties["algorithmProperties"].(map[string]interface{})
+		parameterSet, _ := algorithm["parameterSetIdentifier"].(string)
+
+		if severity, ok := weakAlgorithms[normalizeAlgorithmName(name)]; ok {
+			add(severity, fmt.Sprintf("deprecated algorithm %s", name))
+			break
+		}
+
+		if family, size, ok := algorithmKeySize(name, parameterSet); ok && size < minimumKeySizes[family] {
+			add(SeverityHigh, fmt.Sprintf("%s key size %d is below the minimum of %d bits", family, size, minimumKeySizes[family]))
+		}
+
+	case "related-crypto-material":
+		material, _ := properties["relatedCryptoMaterialProperties"].(map[string]interface{})
+		size, hasSize := material["size"].(float64)
+		if !hasSize {
+			break
+		}
+		// the size of the material itself wins over any size in its name
+		if family := algorithmFamily(name); family != "" && int(size) < minimumKeySizes[family] {
+			add(SeverityHigh, fmt.Sprintf("%s key size %d is below the minimum of %d bits", family, int(size), minimumKeySizes[family]))
+		}
+
+	case "protocol":
+		protocol, _ := properties["protocolProperties"].(map[string]interface{})
+		protocolType, _ := protocol["type"].(string)
+		version, _ := protocol["version"].(string)
+
+		if strings.EqualFold(protocolType, "ssl") || strings.HasPrefix(strings.ToUpper(name), "SSL") {
+			add(SeverityCritical, fmt.Sprintf("SSL %s is obsolete", version))
+		} else if strings.EqualFold(protocolType, "tls") && version != "" && compareVersions(version, "1.2") < 0 {
+			add(SeverityHigh, fmt.Sprintf("TLS %s is deprecated; use TLS 1.2 or later", version))
+		}
+	}
+
+	return findings
+}
+
+// normalizeAlgorithmName upper-cases a name and strips everything but letters
+// and digits, so "SHA-1", "sha1" and "SHA_1" compare equal.
+func normalizeAlgorithmName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// algorithmKeySize returns the algorithm family and key size for RSA, DSA and
+// DH assets, taken from the name (e.g., "RSA-1024") or, failing that, from the
+// parameter set identifier.
+func algorithmKeySize(name, parameterSet string) (string, int, bool) {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+
+	if m := algorithmKeySizePattern.FindStringSubmatch(upper); m != nil {
+		size, err := strconv.Atoi(m[2])
+		return m[1], size, err == nil
+	}
+
+	if family := algorithmFamily(name); family != "" {
+		size, err := strconv.Atoi(strings.TrimSpace(parameterSet))
+		return family, size, err == nil
+	}
+	return "", 0, false
+}
+
+// algorithmFamily returns "RSA", "DSA" or "DH" when the name starts with that
+// family (e.g., "RSA", "RSA-OAEP" or "RSA-2048 private key"), or "".
+func algorithmFamily(name string) string {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+	if m := algorithmKeySizePattern.FindStringSubmatch(upper); m != nil {
+		return m[1]
+	}
+	for family := range minimumKeySizes {
+		if upper == family || strings.HasPrefix(upper, family+"-") || strings.HasPrefix(upper, family+" ") {
+			return family
+		}
+	}
+	return ""
+}
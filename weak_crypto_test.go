@@ -0,0 +1,126 @@
+package sbomvalidator
+
+import (
+	"strings"
+	"testing"
+)
+
+func cryptoAsset(name, properties string) string {
+	return `{"type": "cryptographic-asset", "name": "` + name + `", "cryptoProperties": ` + properties + `}`
+}
+
+func TestCheckCryptoAssets(t *testing.T) {
+	tests := []struct {
+		name         string
+		component    string
+		wantSeverity Severity
+		wantMessage  string
+	}{
+		{
+			name:         "MD5",
+			component:    cryptoAsset("MD5", `{"assetType": "algorithm", "algorithmProperties": {"primitive": "hash"}}`),
+			wantSeverity: SeverityHigh,
+			wantMessage:  "deprecated algorithm MD5",
+		},
+		{
+			name:         "SHA-1",
+			component:    cryptoAsset("SHA-1", `{"assetType": "algorithm"}`),
+			wantSeverity: SeverityHigh,
+			wantMessage:  "deprecated algorithm SHA-1",
+		},
+		{
+			name:         "RSA key size in name",
+			component:    cryptoAsset("RSA-1024", `{"assetType": "algorithm"}`),
+			wantSeverity: SeverityHigh,
+			wantMessage:  "RSA key size 1024",
+		},
+		{
+			name:         "RSA key size in parameter set",
+			component:    cryptoAsset("RSA-OAEP", `{"assetType": "algorithm", "algorithmProperties": {"parameterSetIdentifier": "1536"}}`),
+			wantSeverity: SeverityHigh,
+			wantMessage:  "RSA key size 1536",
+		},
+		{
+			name:         "Small RSA key material",
+			component:    cryptoAsset("RSA-2048 private key", `{"assetType": "related-crypto-material", "relatedCryptoMaterialProperties": {"type": "private-key", "size": 1024}}`),
+			wantSeverity: SeverityHigh,
+			wantMessage:  "RSA key size 1024",
+		},
+		{
+			name:         "Legacy TLS",
+			component:    cryptoAsset("TLS", `{"assetType": "protocol", "protocolProperties": {"type": "tls", "version": "1.0"}}`),
+			wantSeverity: SeverityHigh,
+			wantMessage:  "TLS 1.0 is deprecated",
+		},
+		{
+			name:         "SSL",
+			component:    cryptoAsset("SSLv3", `{"assetType": "protocol", "protocolProperties": {"type": "other", "version": "3.0"}}`),
+			wantSeverity: SeverityCritical,
+			wantMessage:  "SSL 3.0 is obsolete",
+		},
+		{
+			name:      "Modern algorithm",
+			component: cryptoAsset("AES-256-GCM", `{"assetType": "algorithm"}`),
+		},
+		{
+			name:      "Adequate RSA key",
+			component: cryptoAsset("RSA-3072", `{"assetType": "algorithm"}`),
+		},
+		{
+			name:      "TLS 1.3",
+			component: cryptoAsset("TLS", `{"assetType": "protocol", "protocolProperties": {"type": "tls", "version": "1.3"}}`),
+		},
+		{
+			name:      "Not a cryptographic asset",
+			component: `{"type": "library", "name": "MD5"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sbom := `{"bomFormat": "CycloneDX", "specVersion": "1.6", "components": [` + tt.component + `]}`
+			report, err := CheckCryptoAssets([]byte(sbom))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if tt.wantMessage == "" {
+				if len(report.Findings) != 0 {
+					t.Errorf("expected no findings, got %+v", report.Findings)
+				}
+				return
+			}
+
+			if len(report.Findings) != 1 {
+				t.Fatalf("expected 1 finding, got %+v", report.Findings)
+			}
+			finding := report.Findings[0]
+			if finding.Severity != tt.wantSeverity {
+				t.Errorf("Severity = %q, want %q", finding.Severity, tt.wantSeverity)
+			}
+			if !strings.Contains(finding.Message, tt.wantMessage) {
+				t.Errorf("Message = %q, want it to contain %q", finding.Message, tt.wantMessage)
+			}
+			if finding.Path != "components.0" {
+				t.Errorf("Path = %q, want components.0", finding.Path)
+			}
+		})
+	}
+}
+
+func TestCheckCryptoAssetsRejectsSPDX(t *testing.T) {
+	if _, err := CheckCryptoAssets([]byte(`{"spdxVersion": "SPDX-2.3"}`)); err == nil {
+		t.Errorf("expected an error for SPDX")
+	}
+}
+
+func TestCheckWeakCrypto(t *testing.T) {
+	obj, _ := parseJSON(`{"bomFormat": "CycloneDX", "components": [{"name": "app", "components": [` +
+		cryptoAsset("MD5", `{"assetType": "algorithm"}`) + `]}]}`)
+
+	warnings := checkWeakCrypto(obj)
+	if len(warnings) != 1 || warnings[0].String() != "components.0.components.0: [high] deprecated algorithm MD5" ||
+		warnings[0].Severity != SeverityHigh || warnings[0].Pointer != "/components/0/components/0" {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+}